@@ -17,6 +17,8 @@ import (
 // CreateAndroidDriver creates an Android driver based on cfg.Driver type.
 // Exported for library use.
 func CreateAndroidDriver(cfg *RunConfig) (core.Driver, func(), error) {
+	w := cfg.output()
+
 	driverType := strings.ToLower(cfg.Driver)
 	if driverType == "" {
 		driverType = "uiautomator2"
@@ -25,10 +27,10 @@ func CreateAndroidDriver(cfg *RunConfig) (core.Driver, func(), error) {
 	// 1. Connect to device
 	deviceID := getFirstDevice(cfg)
 	if deviceID != "" {
-		printSetupStep(fmt.Sprintf("Connecting to device %s...", deviceID))
+		printSetupStep(w, fmt.Sprintf("Connecting to device %s...", deviceID))
 		logger.Info("Connecting to Android device: %s", deviceID)
 	} else {
-		printSetupStep("Connecting to device...")
+		printSetupStep(w, "Connecting to device...")
 		logger.Info("Auto-detecting Android device...")
 	}
 	dev, err := device.New(deviceID)
@@ -52,7 +54,7 @@ func CreateAndroidDriver(cfg *RunConfig) (core.Driver, func(), error) {
 	}
 	logger.Info("Device info: %s %s, SDK %s, Serial %s, Emulator: %v",
 		info.Brand, info.Model, info.SDK, info.Serial, info.IsEmulator)
-	printSetupSuccess(fmt.Sprintf("Connected to %s %s (SDK %s)", info.Brand, info.Model, info.SDK))
+	printSetupSuccess(w, fmt.Sprintf("Connected to %s %s (SDK %s)", info.Brand, info.Model, info.SDK))
 
 	// 2. Check if device is already in use (for UIAutomator2 driver)
 	// Do this BEFORE StartUIAutomator2 which would kill the other instance's server
@@ -68,14 +70,14 @@ func CreateAndroidDriver(cfg *RunConfig) (core.Driver, func(), error) {
 
 	// 3. Install app if specified
 	if cfg.AppFile != "" {
-		printSetupStep(fmt.Sprintf("Installing app: %s", cfg.AppFile))
+		printSetupStep(w, fmt.Sprintf("Installing app: %s", cfg.AppFile))
 		logger.Info("Installing app: %s", cfg.AppFile)
 		if err := dev.Install(cfg.AppFile); err != nil {
 			logger.Error("App installation failed: %v", err)
 			return nil, nil, fmt.Errorf("install app: %w", err)
 		}
 		logger.Info("App installed successfully")
-		printSetupSuccess("App installed")
+		printSetupSuccess(w, "App installed")
 	}
 
 	// 4. Create driver based on type
@@ -91,9 +93,11 @@ func CreateAndroidDriver(cfg *RunConfig) (core.Driver, func(), error) {
 
 // createUIAutomator2Driver creates a direct UIAutomator2 driver (no Appium server needed).
 func createUIAutomator2Driver(cfg *RunConfig, dev *device.AndroidDevice, info device.DeviceInfo) (core.Driver, func(), error) {
+	w := cfg.output()
+
 	// 1. Check/install UIAutomator2 APKs
 	if !dev.IsInstalled(device.UIAutomator2Server) {
-		printSetupStep("Installing UIAutomator2 APKs...")
+		printSetupStep(w, "Installing UIAutomator2 APKs...")
 		apksDir, err := getDriversDir("android")
 		if err != nil {
 			return nil, nil, fmt.Errorf("failed to locate drivers directory: %w", err)
@@ -101,11 +105,11 @@ func createUIAutomator2Driver(cfg *RunConfig, dev *device.AndroidDevice, info de
 		if err := dev.InstallUIAutomator2(apksDir); err != nil {
 			return nil, nil, fmt.Errorf("install UIAutomator2: %w", err)
 		}
-		printSetupSuccess("UIAutomator2 installed")
+		printSetupSuccess(w, "UIAutomator2 installed")
 	}
 
 	// 2. Start UIAutomator2 server
-	printSetupStep("Starting UIAutomator2 server...")
+	printSetupStep(w, "Starting UIAutomator2 server...")
 	logger.Info("Starting UIAutomator2 server on device %s", dev.Serial())
 	uia2Cfg := device.DefaultUIAutomator2Config()
 	if err := dev.StartUIAutomator2(uia2Cfg); err != nil {
@@ -115,16 +119,16 @@ func createUIAutomator2Driver(cfg *RunConfig, dev *device.AndroidDevice, info de
 
 	// Debug: Print socket/port info
 	if dev.SocketPath() != "" {
-		fmt.Printf("  → Socket: %s\n", dev.SocketPath())
+		fmt.Fprintf(w, "  → Socket: %s\n", dev.SocketPath())
 	} else if dev.LocalPort() != 0 {
-		fmt.Printf("  → Port: %d\n", dev.LocalPort())
+		fmt.Fprintf(w, "  → Port: %d\n", dev.LocalPort())
 	}
 
 	// Verify server is actually responding
 	if !dev.IsUIAutomator2Running() {
 		return nil, nil, fmt.Errorf("UIAutomator2 server not responding after start")
 	}
-	printSetupSuccess("UIAutomator2 server started")
+	printSetupSuccess(w, "UIAutomator2 server started")
 
 	// 3. Create client
 	var client *uiautomator2.Client
@@ -140,7 +144,7 @@ func createUIAutomator2Driver(cfg *RunConfig, dev *device.AndroidDevice, info de
 	}
 
 	// 4. Create session
-	printSetupStep("Creating session...")
+	printSetupStep(w, "Creating session...")
 	logger.Info("Creating UIAutomator2 session with capabilities: Platform=Android, Device=%s", info.Model)
 	caps := uiautomator2.Capabilities{
 		PlatformName: "Android",
@@ -154,7 +158,7 @@ func createUIAutomator2Driver(cfg *RunConfig, dev *device.AndroidDevice, info de
 		return nil, nil, fmt.Errorf("create session: %w", err)
 	}
 	logger.Info("Session created successfully: %s", client.SessionID())
-	printSetupSuccess("Session created")
+	printSetupSuccess(w, "Session created")
 
 	// Set waitForIdle timeout - configurable via --wait-for-idle-timeout or config.yaml
 	// Default is 5000ms which balances speed and reliability
@@ -162,7 +166,7 @@ func createUIAutomator2Driver(cfg *RunConfig, dev *device.AndroidDevice, info de
 	if err := client.SetAppiumSettings(map[string]interface{}{
 		"waitForIdleTimeout": cfg.WaitForIdleTimeout,
 	}); err != nil {
-		fmt.Printf("  %s⚠%s Warning: failed to set appium settings: %v\n", color(colorYellow), color(colorReset), err)
+		fmt.Fprintf(w, "  %s⚠%s Warning: failed to set appium settings: %v\n", color(colorYellow), color(colorReset), err)
 	}
 
 	// 5. Query app version from device if appId is known
@@ -26,8 +26,8 @@ var GlobalFlags = []cli.Flag{
 	},
 	&cli.StringFlag{
 		Name:    "device",
-		Aliases: []string{"udid"},
-		Usage:   "Device ID to run on (can be comma-separated)",
+		Aliases: []string{"udid", "devices"},
+		Usage:   "Device ID to run on (can be comma-separated to run on several devices in parallel)",
 		EnvVars: []string{"MAESTRO_DEVICE"},
 	},
 	&cli.StringFlag{
@@ -48,6 +48,12 @@ var GlobalFlags = []cli.Flag{
 		Usage:   "Path to Appium capabilities JSON file",
 		EnvVars: []string{"APPIUM_CAPS"},
 	},
+	&cli.StringFlag{
+		Name:    "caps-mode",
+		Usage:   "How --caps combines with the default capabilities: merge (caps file wins per key) or replace (send only the caps file plus platformName and automationName)",
+		Value:   "merge",
+		EnvVars: []string{"APPIUM_CAPS_MODE"},
+	},
 	&cli.BoolFlag{
 		Name:    "verbose",
 		Usage:   "Enable verbose logging",
@@ -1,7 +1,13 @@
 package cli
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
 	"net"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"strconv"
 	"strings"
@@ -10,11 +16,13 @@ import (
 
 	"github.com/devicelab-dev/maestro-runner/pkg/core"
 	"github.com/devicelab-dev/maestro-runner/pkg/device"
+	uia2driver "github.com/devicelab-dev/maestro-runner/pkg/driver/uiautomator2"
 	"github.com/devicelab-dev/maestro-runner/pkg/emulator"
 	"github.com/devicelab-dev/maestro-runner/pkg/executor"
 	"github.com/devicelab-dev/maestro-runner/pkg/flow"
 	"github.com/devicelab-dev/maestro-runner/pkg/report"
 	"github.com/devicelab-dev/maestro-runner/pkg/simulator"
+	"github.com/devicelab-dev/maestro-runner/pkg/uiautomator2"
 	"github.com/urfave/cli/v2"
 )
 
@@ -568,6 +576,22 @@ func TestLoadCapabilities_InvalidJSON(t *testing.T) {
 	}
 }
 
+func TestLoadCapabilities_TrailingData(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"extra value": `{"platformName": "Android"} {"x": 1}`,
+		"extra brace": `{"platformName": "Android"}}`,
+	} {
+		capsFile := dir + "/caps.json"
+		if err := os.WriteFile(capsFile, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := loadCapabilities(capsFile); err == nil || !strings.Contains(err.Error(), "failed to parse caps JSON") {
+			t.Errorf("%s: expected parse error, got: %v", name, err)
+		}
+	}
+}
+
 func TestLoadCapabilities_FileNotFound(t *testing.T) {
 	_, err := loadCapabilities("/nonexistent/caps.json")
 	if err == nil {
@@ -594,6 +618,176 @@ func TestLoadCapabilities_EmptyJSON(t *testing.T) {
 	}
 }
 
+func TestCreateAppiumDriver_ForwardsCapabilitiesVerbatim(t *testing.T) {
+	passthrough := map[string]string{
+		"appium:processArguments": `{"args":["-NMLDebug","YES"],"env":{"NML_SERVER":"https://eyes.example.com"}}`,
+		"appium:otherApps":        `["/apps/helper.app"]`,
+		"sauce:options":           `{"build":"build-42","extendedDebugging":true,"name":"checkout"}`,
+		"custom:buildNumber":      `12345678901234567890`,
+		"custom:ratio":            `1.50`,
+	}
+	var fields []string
+	for key, value := range passthrough {
+		fields = append(fields, strconv.Quote(key)+": "+value)
+	}
+	capsFile := t.TempDir() + "/caps.json"
+	capsJSON := `{"platformName": "iOS", ` + strings.Join(fields, ", ") + `}`
+	if err := os.WriteFile(capsFile, []byte(capsJSON), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var alwaysMatch map[string]json.RawMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/session" && r.Method == "POST" {
+			var body struct {
+				Capabilities struct {
+					AlwaysMatch map[string]json.RawMessage `json:"alwaysMatch"`
+				} `json:"capabilities"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				t.Errorf("decoding /session body: %v", err)
+			}
+			alwaysMatch = body.Capabilities.AlwaysMatch
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"value": map[string]interface{}{"sessionId": "caps-session", "capabilities": map[string]interface{}{}},
+			})
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	caps, err := loadCapabilities(capsFile)
+	if err != nil {
+		t.Fatalf("loadCapabilities: %v", err)
+	}
+	_, cleanup, err := createAppiumDriver(&RunConfig{AppiumURL: server.URL, Capabilities: caps})
+	if err != nil {
+		t.Fatalf("createAppiumDriver: %v", err)
+	}
+	defer cleanup()
+
+	for key, want := range passthrough {
+		got, ok := alwaysMatch[key]
+		if !ok {
+			t.Errorf("%s missing from /session alwaysMatch", key)
+			continue
+		}
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, []byte(want)); err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != compact.String() {
+			t.Errorf("%s = %s, want %s unchanged", key, got, compact.String())
+		}
+	}
+}
+
+// In replace mode the caps file's appium:settings reach the session as
+// json.Number values, and waitForIdleTimeout must still be applied.
+func TestCreateAppiumDriver_ReplaceModeAppliesSettings(t *testing.T) {
+	capsFile := t.TempDir() + "/caps.json"
+	capsJSON := `{"platformName": "Android", "appium:settings": {"waitForIdleTimeout": 750}}`
+	if err := os.WriteFile(capsFile, []byte(capsJSON), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var settings map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/session" && r.Method == "POST":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"value": map[string]interface{}{"sessionId": "caps-session", "capabilities": map[string]interface{}{}},
+			})
+		case strings.HasSuffix(r.URL.Path, "/appium/settings") && r.Method == "POST":
+			var body struct {
+				Settings map[string]interface{} `json:"settings"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				t.Errorf("decoding settings body: %v", err)
+			}
+			settings = body.Settings
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"value": nil})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	caps, err := loadCapabilities(capsFile)
+	if err != nil {
+		t.Fatalf("loadCapabilities: %v", err)
+	}
+	_, cleanup, err := createAppiumDriver(&RunConfig{AppiumURL: server.URL, Capabilities: caps, CapsMode: capsModeReplace})
+	if err != nil {
+		t.Fatalf("createAppiumDriver: %v", err)
+	}
+	defer cleanup()
+
+	if settings == nil {
+		t.Fatal("expected a settings request after creating the session")
+	}
+	if got := settings["waitForIdleTimeout"]; got != float64(750) {
+		t.Errorf("waitForIdleTimeout = %v, want 750 from the caps file", got)
+	}
+}
+
+func TestAppiumCapabilities_CapsModes(t *testing.T) {
+	userCaps := func() map[string]interface{} {
+		return map[string]interface{}{
+			"platformName":          "Android",
+			"appium:automationName": "Espresso",
+			"appium:noReset":        true,
+		}
+	}
+
+	t.Run("merge keeps other defaults", func(t *testing.T) {
+		caps := appiumCapabilities(&RunConfig{Capabilities: userCaps(), CapsMode: capsModeMerge, WaitForIdleTimeout: 200})
+
+		if caps["appium:automationName"] != "Espresso" {
+			t.Errorf("expected the caps file automationName to win, got %v", caps["appium:automationName"])
+		}
+		if caps["appium:noReset"] != true {
+			t.Errorf("expected appium:noReset from the caps file, got %v", caps["appium:noReset"])
+		}
+		if caps["appium:autoGrantPermissions"] != true {
+			t.Errorf("expected the autoGrantPermissions default, got %v", caps["appium:autoGrantPermissions"])
+		}
+		if _, ok := caps["appium:settings"]; !ok {
+			t.Error("expected the appium:settings default")
+		}
+	})
+
+	t.Run("replace sends only user caps", func(t *testing.T) {
+		caps := appiumCapabilities(&RunConfig{Capabilities: userCaps(), CapsMode: capsModeReplace, WaitForIdleTimeout: 200})
+
+		want := userCaps()
+		if len(caps) != len(want) {
+			t.Errorf("expected only the caps file keys, got %v", caps)
+		}
+		for key, value := range want {
+			if caps[key] != value {
+				t.Errorf("%s = %v, want %v", key, caps[key], value)
+			}
+		}
+	})
+
+	t.Run("replace adds required caps", func(t *testing.T) {
+		caps := appiumCapabilities(&RunConfig{
+			Capabilities: map[string]interface{}{"appium:bundleId": "com.example.app"},
+			CapsMode:     capsModeReplace,
+			Platform:     "ios",
+		})
+
+		if caps["platformName"] != "ios" || caps["appium:automationName"] != "XCUITest" {
+			t.Errorf("expected platformName and automationName to be filled in, got %v", caps)
+		}
+		if _, ok := caps["appium:autoGrantPermissions"]; ok {
+			t.Error("expected no optional defaults in replace mode")
+		}
+	})
+}
+
 // Test --caps flag is defined in GlobalFlags
 
 func TestGlobalFlags_CapsFlag(t *testing.T) {
@@ -607,6 +801,9 @@ func TestGlobalFlags_CapsFlag(t *testing.T) {
 	if !flagNames["caps"] {
 		t.Error("expected --caps flag to be defined in GlobalFlags")
 	}
+	if !flagNames["caps-mode"] {
+		t.Error("expected --caps-mode flag to be defined in GlobalFlags")
+	}
 }
 
 // Test RunConfig with Capabilities
@@ -1088,6 +1285,20 @@ func TestColor_Disabled(t *testing.T) {
 	}
 }
 
+func TestColorsForNonTerminal(t *testing.T) {
+	if colorsFor(&bytes.Buffer{}) {
+		t.Error("expected no colors for a buffer")
+	}
+	f, err := os.CreateTemp(t.TempDir(), "out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if colorsFor(f) {
+		t.Error("expected no colors for a regular file")
+	}
+}
+
 // ============================================================
 // Tests for enhanceNoDevicesError
 // ============================================================
@@ -1501,10 +1712,6 @@ func TestExecuteTest_SignalSetup(t *testing.T) {
 // ============================================================
 
 func TestPrintSummary_NoCrash(t *testing.T) {
-	oldStdout := os.Stdout
-	os.Stdout, _ = os.Open(os.DevNull)
-	defer func() { os.Stdout = oldStdout }()
-
 	result := &executor.RunResult{
 		TotalFlows:  2,
 		PassedFlows: 1,
@@ -1535,14 +1742,10 @@ func TestPrintSummary_NoCrash(t *testing.T) {
 	}
 
 	// Should not panic
-	printSummary(result)
+	printSummary(io.Discard, result)
 }
 
 func TestPrintSummary_WithSkipped(t *testing.T) {
-	oldStdout := os.Stdout
-	os.Stdout, _ = os.Open(os.DevNull)
-	defer func() { os.Stdout = oldStdout }()
-
 	result := &executor.RunResult{
 		TotalFlows:   1,
 		PassedFlows:  0,
@@ -1561,84 +1764,136 @@ func TestPrintSummary_WithSkipped(t *testing.T) {
 		},
 	}
 
-	printSummary(result)
+	printSummary(io.Discard, result)
 }
 
 // ============================================================
 // Tests for callback functions (no panics)
 // ============================================================
 
-func TestOnFlowStart_NoCrash(t *testing.T) {
-	oldStdout := os.Stdout
-	os.Stdout, _ = os.Open(os.DevNull)
-	defer func() { os.Stdout = oldStdout }()
-
-	onFlowStart(0, 5, "Login Flow", "login.yaml")
+func TestTerminalPrinter_FlowStart(t *testing.T) {
+	var buf bytes.Buffer
+	p := &terminalPrinter{w: &buf}
+	p.FlowStart(executor.FlowEvent{Index: 0, Total: 5, Name: "Login Flow", File: "login.yaml"})
+	if !strings.Contains(buf.String(), "[1/5]") || !strings.Contains(buf.String(), "Login Flow") {
+		t.Errorf("unexpected output: %q", buf.String())
+	}
 }
 
-func TestOnStepComplete_Passed(t *testing.T) {
-	oldStdout := os.Stdout
-	os.Stdout, _ = os.Open(os.DevNull)
-	defer func() { os.Stdout = oldStdout }()
-
-	onStepComplete(0, "tapOn: button", true, 100, "")
+func TestTerminalPrinter_StepPassed(t *testing.T) {
+	var buf bytes.Buffer
+	p := &terminalPrinter{w: &buf}
+	p.StepResult(executor.StepEvent{Description: "tapOn: button", Success: true, DurationMs: 100})
+	if !strings.Contains(buf.String(), "✓") || !strings.Contains(buf.String(), "tapOn: button") {
+		t.Errorf("unexpected output: %q", buf.String())
+	}
 }
 
-func TestOnStepComplete_Failed(t *testing.T) {
-	oldStdout := os.Stdout
-	os.Stdout, _ = os.Open(os.DevNull)
-	defer func() { os.Stdout = oldStdout }()
-
-	onStepComplete(0, "tapOn: button", false, 100, "element not found")
+func TestTerminalPrinter_StepFailed(t *testing.T) {
+	var buf bytes.Buffer
+	p := &terminalPrinter{w: &buf}
+	p.StepResult(executor.StepEvent{Description: "tapOn: button", DurationMs: 100, Error: "element not found"})
+	if !strings.Contains(buf.String(), "✗") || !strings.Contains(buf.String(), "element not found") {
+		t.Errorf("unexpected output: %q", buf.String())
+	}
 }
 
-func TestOnStepComplete_Slow(t *testing.T) {
-	oldStdout := os.Stdout
-	os.Stdout, _ = os.Open(os.DevNull)
-	defer func() { os.Stdout = oldStdout }()
-
+func TestTerminalPrinter_StepSlow(t *testing.T) {
+	var buf bytes.Buffer
+	p := &terminalPrinter{w: &buf}
 	// Should show slow warning (>5000ms)
-	onStepComplete(0, "tapOn: button", true, 6000, "")
+	p.StepResult(executor.StepEvent{Description: "tapOn: button", Success: true, DurationMs: 6000})
+	if !strings.Contains(buf.String(), "⚠") {
+		t.Errorf("expected slow warning, got %q", buf.String())
+	}
 }
 
-func TestOnStepComplete_CompoundStepNotSlow(t *testing.T) {
-	oldStdout := os.Stdout
-	os.Stdout, _ = os.Open(os.DevNull)
-	defer func() { os.Stdout = oldStdout }()
-
+func TestTerminalPrinter_CompoundStepNotSlow(t *testing.T) {
+	var buf bytes.Buffer
+	p := &terminalPrinter{w: &buf}
 	// Compound steps (runFlow, repeat, retry) should not show slow warning
-	onStepComplete(0, "runFlow: login", true, 10000, "")
-	onStepComplete(1, "repeat: 3 times", true, 15000, "")
-	onStepComplete(2, "retry: 2 times", true, 8000, "")
+	p.StepResult(executor.StepEvent{Description: "runFlow: login", Success: true, DurationMs: 10000})
+	p.StepResult(executor.StepEvent{Description: "repeat: 3 times", Success: true, DurationMs: 15000})
+	p.StepResult(executor.StepEvent{Description: "retry: 2 times", Success: true, DurationMs: 8000})
+	if strings.Contains(buf.String(), "⚠") {
+		t.Errorf("unexpected slow warning: %q", buf.String())
+	}
 }
 
-func TestOnNestedFlowStart_NoCrash(t *testing.T) {
-	oldStdout := os.Stdout
-	os.Stdout, _ = os.Open(os.DevNull)
-	defer func() { os.Stdout = oldStdout }()
+func TestTerminalPrinter_NestedFlowStart(t *testing.T) {
+	var buf bytes.Buffer
+	p := &terminalPrinter{w: &buf}
+	p.NestedFlowStart(1, "deeply nested flow")
+	if !strings.Contains(buf.String(), "▸ deeply nested flow") {
+		t.Errorf("unexpected output: %q", buf.String())
+	}
+}
 
-	onNestedFlowStart(0, "nested flow")
-	onNestedFlowStart(1, "deeply nested flow")
+func TestTerminalPrinter_NestedStep(t *testing.T) {
+	var buf bytes.Buffer
+	p := &terminalPrinter{w: &buf}
+	p.StepResult(executor.StepEvent{Depth: 1, Description: "tapOn: nested button", Success: true, DurationMs: 50})
+	p.StepResult(executor.StepEvent{Depth: 1, Description: "tapOn: nested button", DurationMs: 50, Error: "element not found"})
+	// Nested steps are indented past top-level steps
+	for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n") {
+		if !strings.HasPrefix(line, "      ") {
+			t.Errorf("expected nested indent, got %q", line)
+		}
+	}
 }
 
-func TestOnNestedStep_PassedAndFailed(t *testing.T) {
-	oldStdout := os.Stdout
-	os.Stdout, _ = os.Open(os.DevNull)
-	defer func() { os.Stdout = oldStdout }()
+func TestTerminalPrinter_FlowEnd(t *testing.T) {
+	var buf bytes.Buffer
+	p := &terminalPrinter{w: &buf}
+	p.FlowEnd(executor.FlowEvent{Name: "Login Flow", Passed: true, DurationMs: 2000})
+	p.FlowEnd(executor.FlowEvent{Name: "Checkout Flow", DurationMs: 5000, Error: "step failed"})
+	if !strings.Contains(buf.String(), "✓ Login Flow") || !strings.Contains(buf.String(), "✗ Checkout Flow") {
+		t.Errorf("unexpected output: %q", buf.String())
+	}
+}
+
+func TestParallelPrinter(t *testing.T) {
+	var buf bytes.Buffer
+	p := &parallelPrinter{w: &buf}
+	device := &report.Device{ID: "emulator-5554", Name: "Pixel 6"}
+	p.FlowStart(executor.FlowEvent{Index: 1, Total: 3, Name: "Login", File: "login.yaml", Device: device})
+	p.StepResult(executor.StepEvent{Description: "tapOn: button", Success: true})
+	p.FlowEnd(executor.FlowEvent{Index: 1, Total: 3, Name: "Login", File: "login.yaml", Device: device, DurationMs: 1500, Error: "step failed"})
 
-	onNestedStep(0, "tapOn: nested button", true, 50, "")
-	onNestedStep(0, "tapOn: nested button", false, 50, "element not found")
-	// Slow nested step
-	onNestedStep(1, "scrollDown", true, 6000, "")
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected start, end and error lines, got %q", buf.String())
+	}
+	if !strings.HasPrefix(lines[0], "[2/3] Login (login.yaml)") || !strings.HasSuffix(lines[0], "on Pixel 6") {
+		t.Errorf("unexpected start line: %q", lines[0])
+	}
+	if !strings.Contains(lines[1], "Failed") || !strings.Contains(lines[1], "on Pixel 6 (1.5s)") {
+		t.Errorf("unexpected end line: %q", lines[1])
+	}
+	if lines[2] != "  Error: step failed" {
+		t.Errorf("unexpected error line: %q", lines[2])
+	}
 }
 
-func TestOnFlowEnd_PassedAndFailed(t *testing.T) {
-	oldStdout := os.Stdout
-	os.Stdout, _ = os.Open(os.DevNull)
-	defer func() { os.Stdout = oldStdout }()
+func TestDeviceName(t *testing.T) {
+	tests := []struct {
+		name     string
+		device   *report.Device
+		expected string
+	}{
+		{"nil device", nil, "Unknown"},
+		{"device with name", &report.Device{ID: "emulator-5554", Name: "Pixel 6"}, "Pixel 6"},
+		{"device with empty name", &report.Device{ID: "emulator-5554"}, ""},
+		{"full device info", &report.Device{ID: "ABC123", Name: "iPhone 15 Pro", Platform: "ios", OSVersion: "17.0", IsSimulator: true}, "iPhone 15 Pro"},
+	}
 
-	onFlowEnd("Login Flow", true, 2000, "")
-	onFlowEnd("Checkout Flow", false, 5000, "step failed")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := deviceName(tt.device); got != tt.expected {
+				t.Errorf("deviceName() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
 }
 
 // ============================================================
@@ -2167,3 +2422,147 @@ func TestIsSocketInUse_SocketAndPidWithDeadProcess(t *testing.T) {
 		t.Error("expected socket with dead owner PID to not be in use")
 	}
 }
+
+func TestJSONResultWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := newJSONResultWriter(&buf)
+	w.StepResult(executor.StepEvent{Flow: "Login", Index: 2, Type: "tapOn", Selector: "id=login", Success: false,
+		Message: "Element not found", DurationMs: 1200, Error: "element not found"})
+	w.summary(&executor.RunResult{Status: report.StatusFailed, TotalFlows: 2, PassedFlows: 1, FailedFlows: 1, Duration: 5000})
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 JSON lines, got %d:\n%s", len(lines), buf.String())
+	}
+
+	var step map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &step); err != nil {
+		t.Fatalf("step line is not JSON: %v", err)
+	}
+	for key, want := range map[string]interface{}{
+		"event": "step", "flow": "Login", "type": "tapOn", "selector": "id=login",
+		"success": false, "message": "Element not found", "durationMs": float64(1200), "error": "element not found",
+	} {
+		if step[key] != want {
+			t.Errorf("step[%q] = %v, want %v", key, step[key], want)
+		}
+	}
+
+	want := `{"event":"summary","status":"failed","total":2,"passed":1,"failed":1,"skipped":0,"durationMs":5000}`
+	if lines[1] != want {
+		t.Errorf("summary = %s, want %s", lines[1], want)
+	}
+}
+
+func TestJSONResultWriterDeviceSummary(t *testing.T) {
+	var buf bytes.Buffer
+	w := newJSONResultWriter(&buf)
+	w.summary(&executor.RunResult{
+		Status: report.StatusFailed, TotalFlows: 4, PassedFlows: 3, FailedFlows: 1,
+		Devices: []executor.DeviceResult{
+			{DeviceID: "emulator-5554", Total: 2, Passed: 2},
+			{DeviceID: "emulator-5556", Total: 2, Passed: 1, Failed: 1},
+		},
+	})
+
+	want := `{"event":"summary","status":"failed","total":4,"passed":3,"failed":1,"skipped":0,"durationMs":0,` +
+		`"devices":{"emulator-5554":{"status":"passed","total":2,"passed":2,"failed":0,"skipped":0},` +
+		`"emulator-5556":{"status":"failed","total":2,"passed":1,"failed":1,"skipped":0}}}`
+	if got := strings.TrimSpace(buf.String()); got != want {
+		t.Errorf("summary = %s, want %s", got, want)
+	}
+}
+
+func TestRunConfigArtifactMode(t *testing.T) {
+	if got := (&RunConfig{ScreenshotOnFailure: true}).artifactMode(); got != executor.ArtifactOnFailure {
+		t.Errorf("artifactMode() = %v, want ArtifactOnFailure", got)
+	}
+	if got := (&RunConfig{}).artifactMode(); got != executor.ArtifactNever {
+		t.Errorf("artifactMode() = %v, want ArtifactNever", got)
+	}
+}
+
+func TestScreenshotOnFailureFlagDefaultsOn(t *testing.T) {
+	for _, f := range testCommand.Flags {
+		if bf, ok := f.(*cli.BoolFlag); ok && bf.Name == "screenshot-on-failure" {
+			if !bf.Value {
+				t.Error("expected --screenshot-on-failure to default to true")
+			}
+			return
+		}
+	}
+	t.Fatal("--screenshot-on-failure flag not found")
+}
+
+// swipeClient serves a page with one scrollable for the uiautomator2 driver;
+// other client calls are not expected.
+type swipeClient struct {
+	uia2driver.UIA2Client
+}
+
+func (swipeClient) Source() (string, error) {
+	return `<hierarchy><node class="android.widget.ScrollView" scrollable="true" displayed="true" bounds="[0,0][1080,1800]"/></hierarchy>`, nil
+}
+
+func (swipeClient) SetAppiumSettings(map[string]interface{}) error { return nil }
+
+func (swipeClient) GetDeviceInfo() (*uiautomator2.DeviceInfo, error) {
+	return &uiautomator2.DeviceInfo{RealDisplaySize: "1080x1920"}, nil
+}
+
+type swipeShell struct{}
+
+func (swipeShell) Shell(string) (string, error) { return "", nil }
+
+func TestJSONFormatKeepsStdoutJSON(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	var human bytes.Buffer
+	cfg := &RunConfig{Output: &human, results: newJSONResultWriter(os.Stdout)}
+	driver := uia2driver.New(swipeClient{}, &core.PlatformInfo{Platform: "android"}, swipeShell{})
+	runner := executor.New(driver, executor.RunnerConfig{
+		OutputDir:    t.TempDir(),
+		Artifacts:    executor.ArtifactNever,
+		Sink:         cfg.resultSink(false),
+		ScriptOutput: cfg.output(),
+	})
+	flows := []flow.Flow{{
+		SourcePath: "swipe.yaml",
+		Config:     flow.Config{Name: "Swipe"},
+		Steps: []flow.Step{
+			&flow.SwipeStep{BaseStep: flow.BaseStep{StepType: flow.StepSwipe}, Direction: "up"},
+			&flow.EvalScriptStep{BaseStep: flow.BaseStep{StepType: flow.StepEvalScript}, Script: "console.log('from script')"},
+		},
+	}}
+	result, err := runner.Run(context.Background(), flows)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	cfg.results.summary(result)
+	os.Stdout = stdout
+	_ = w.Close()
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 2 step lines and a summary on stdout, got:\n%s", out)
+	}
+	for _, line := range lines {
+		var v map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &v); err != nil {
+			t.Errorf("stdout line is not JSON: %q", line)
+		}
+	}
+	if !strings.Contains(human.String(), "from script") {
+		t.Errorf("expected console.log on the human output, got:\n%s", human.String())
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"time"
 
 	goios "github.com/danielpaulus/go-ios/ios"
 	"github.com/danielpaulus/go-ios/ios/zipconduit"
@@ -32,11 +33,13 @@ type iosDeviceInfo struct {
 // CreateIOSDriver creates an iOS driver using WebDriverAgent.
 // Exported for library use.
 func CreateIOSDriver(cfg *RunConfig) (core.Driver, func(), error) {
+	w := cfg.output()
+
 	udid := getFirstDevice(cfg)
 
 	if udid == "" {
 		// Try to find booted simulator or connected physical device
-		printSetupStep("Finding iOS device...")
+		printSetupStep(w, "Finding iOS device...")
 		logger.Info("Auto-detecting iOS device (simulator or physical)...")
 		var err error
 		udid, err = findIOSDevice()
@@ -46,7 +49,7 @@ func CreateIOSDriver(cfg *RunConfig) (core.Driver, func(), error) {
 				"Hint: Specify a device with --device <UDID>, start a simulator, or connect a physical device")
 		}
 		logger.Info("Found iOS device: %s", udid)
-		printSetupSuccess(fmt.Sprintf("Found device: %s", udid))
+		printSetupSuccess(w, fmt.Sprintf("Found device: %s", udid))
 	} else {
 		logger.Info("Using specified iOS device: %s", udid)
 	}
@@ -69,32 +72,33 @@ func CreateIOSDriver(cfg *RunConfig) (core.Driver, func(), error) {
 
 	// 1. Install app if specified
 	if cfg.AppFile != "" {
-		printSetupStep(fmt.Sprintf("Installing app: %s", cfg.AppFile))
+		printSetupStep(w, fmt.Sprintf("Installing app: %s", cfg.AppFile))
 		logger.Info("Installing iOS app: %s to device %s (simulator=%v)", cfg.AppFile, udid, isSimulator)
 		if err := installIOSApp(udid, cfg.AppFile, isSimulator); err != nil {
 			logger.Error("iOS app installation failed: %v", err)
 			return nil, nil, fmt.Errorf("install app failed: %w", err)
 		}
 		logger.Info("iOS app installed successfully")
-		printSetupSuccess("App installed")
+		printSetupSuccess(w, "App installed")
 	}
 
 	// 2. Check if WDA is installed
-	printSetupStep("Checking WDA installation...")
+	printSetupStep(w, "Checking WDA installation...")
 	if !wdadriver.IsWDAInstalled() {
-		printSetupStep("Downloading WDA...")
-		if _, err := wdadriver.Setup(); err != nil {
+		printSetupStep(w, "Downloading WDA...")
+		if _, err := wdadriver.Setup(w); err != nil {
 			return nil, nil, fmt.Errorf("WDA setup failed: %w", err)
 		}
-		printSetupSuccess("WDA installed")
+		printSetupSuccess(w, "WDA installed")
 	} else {
-		printSetupSuccess("WDA already installed")
+		printSetupSuccess(w, "WDA already installed")
 	}
 
 	// 3. Create WDA runner
-	printSetupStep("Building WDA...")
+	printSetupStep(w, "Building WDA...")
 	logger.Info("Building WDA for device %s (team ID: %s)", udid, cfg.TeamID)
 	runner := wdadriver.NewRunner(udid, cfg.TeamID)
+	runner.SetOutput(w)
 	ctx := context.Background()
 
 	if err := runner.Build(ctx); err != nil {
@@ -102,10 +106,10 @@ func CreateIOSDriver(cfg *RunConfig) (core.Driver, func(), error) {
 		return nil, nil, fmt.Errorf("WDA build failed: %w", err)
 	}
 	logger.Info("WDA build completed successfully")
-	printSetupSuccess("WDA built")
+	printSetupSuccess(w, "WDA built")
 
 	// 4. Start WDA
-	printSetupStep("Starting WDA...")
+	printSetupStep(w, "Starting WDA...")
 	logger.Info("Starting WDA on device %s (port: %d)", udid, runner.Port())
 	if err := runner.Start(ctx); err != nil {
 		logger.Error("WDA start failed: %v", err)
@@ -113,11 +117,12 @@ func CreateIOSDriver(cfg *RunConfig) (core.Driver, func(), error) {
 		return nil, nil, fmt.Errorf("WDA start failed: %w", err)
 	}
 	logger.Info("WDA started successfully on port %d", runner.Port())
-	printSetupSuccess("WDA started")
+	printSetupSuccess(w, "WDA started")
 
 	// 5. Create WDA client
-	printSetupSuccess(fmt.Sprintf("WDA port: %d", runner.Port()))
+	printSetupSuccess(w, fmt.Sprintf("WDA port: %d", runner.Port()))
 	client := wdadriver.NewClient(runner.Port())
+	client.SetTimeout(time.Duration(cfg.HTTPTimeout) * time.Second)
 
 	// 6. Get device info
 	deviceInfo, err := getIOSDeviceInfo(udid)
@@ -145,9 +150,22 @@ func CreateIOSDriver(cfg *RunConfig) (core.Driver, func(), error) {
 	// 8. Create driver
 	driver := wdadriver.NewDriver(client, platformInfo, udid)
 	driver.SetAppFile(cfg.AppFile)
+	if err := driver.SetTapMode(cfg.WDATapMode); err != nil {
+		runner.Cleanup()
+		return nil, nil, err
+	}
+	if err := driver.SetInvalidSessionMode(cfg.WDAInvalidSession); err != nil {
+		runner.Cleanup()
+		return nil, nil, err
+	}
+	driver.SetAutoDismissUnexpectedAlerts(cfg.AutoDismissUnexpectedAlerts)
+	driver.SetReuseSession(cfg.WDAReuseSession)
 
 	// Cleanup function
 	cleanup := func() {
+		if err := driver.Close(); err != nil {
+			logger.Debug("failed to close driver during cleanup: %v", err)
+		}
 		runner.Cleanup()
 	}
 
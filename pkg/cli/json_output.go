@@ -0,0 +1,105 @@
+package cli
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+
+	"github.com/devicelab-dev/maestro-runner/pkg/executor"
+	"github.com/devicelab-dev/maestro-runner/pkg/report"
+)
+
+// Output formats accepted by --format.
+const (
+	formatText = "text"
+	formatJSON = "json"
+)
+
+// jsonStepRecord is one line of the --format json stream for a finished step.
+type jsonStepRecord struct {
+	Event string `json:"event"` // always "step"
+	executor.StepEvent
+}
+
+// jsonSummaryRecord is the last line of the --format json stream.
+type jsonSummaryRecord struct {
+	Event      string `json:"event"` // always "summary"
+	Status     string `json:"status"`
+	Total      int    `json:"total"`
+	Passed     int    `json:"passed"`
+	Failed     int    `json:"failed"`
+	Skipped    int    `json:"skipped"`
+	DurationMs int64  `json:"durationMs"`
+
+	// Devices tallies flows per device ID in parallel runs
+	Devices map[string]jsonDeviceSummary `json:"devices,omitempty"`
+}
+
+// jsonDeviceSummary is one device's share of a parallel run.
+type jsonDeviceSummary struct {
+	Status  string `json:"status"`
+	Total   int    `json:"total"`
+	Passed  int    `json:"passed"`
+	Failed  int    `json:"failed"`
+	Skipped int    `json:"skipped"`
+}
+
+// jsonResultWriter is the ResultSink for --format json: it writes step
+// results and the run summary as JSON lines, one object per line, for tools
+// that follow a run's progress.
+type jsonResultWriter struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+func newJSONResultWriter(w io.Writer) *jsonResultWriter {
+	return &jsonResultWriter{enc: json.NewEncoder(w)}
+}
+
+// StepResult writes a finished step. It is safe for concurrent use by
+// parallel workers.
+func (w *jsonResultWriter) StepResult(ev executor.StepEvent) {
+	w.write(jsonStepRecord{Event: "step", StepEvent: ev})
+}
+
+// Flow boundaries are not part of the stream.
+func (w *jsonResultWriter) FlowStart(executor.FlowEvent)           {}
+func (w *jsonResultWriter) NestedFlowStart(depth int, desc string) {}
+func (w *jsonResultWriter) FlowEnd(executor.FlowEvent)             {}
+
+// summary writes the final run summary.
+func (w *jsonResultWriter) summary(result *executor.RunResult) {
+	var devices map[string]jsonDeviceSummary
+	if len(result.Devices) > 0 {
+		devices = make(map[string]jsonDeviceSummary, len(result.Devices))
+		for _, d := range result.Devices {
+			status := report.StatusPassed
+			if d.Failed > 0 {
+				status = report.StatusFailed
+			}
+			devices[d.DeviceID] = jsonDeviceSummary{
+				Status:  string(status),
+				Total:   d.Total,
+				Passed:  d.Passed,
+				Failed:  d.Failed,
+				Skipped: d.Skipped,
+			}
+		}
+	}
+	w.write(jsonSummaryRecord{
+		Event:      "summary",
+		Status:     string(result.Status),
+		Total:      result.TotalFlows,
+		Passed:     result.PassedFlows,
+		Failed:     result.FailedFlows,
+		Skipped:    result.SkippedFlows,
+		DurationMs: result.Duration,
+		Devices:    devices,
+	})
+}
+
+func (w *jsonResultWriter) write(v interface{}) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	_ = w.enc.Encode(v)
+}
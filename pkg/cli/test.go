@@ -1,10 +1,12 @@
 package cli
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net"
 	"os"
 	"os/exec"
@@ -80,6 +82,10 @@ Examples:
 			Aliases: []string{"e"},
 			Usage:   "Environment variables (KEY=VALUE)",
 		},
+		&cli.StringFlag{
+			Name:  "vars",
+			Usage: "JSON or YAML file of variables, available to scripts as config (e.g. ${config.api.url})",
+		},
 
 		// Tag filtering
 		&cli.StringSliceFlag{
@@ -100,12 +106,33 @@ Examples:
 			Name:  "flatten",
 			Usage: "Don't create timestamp subfolder (requires --output)",
 		},
+		&cli.StringFlag{
+			Name:  "timing-csv",
+			Usage: "Also write a per-step timing CSV (flow, step, selector, result, duration) to this path",
+		},
+		&cli.StringFlag{
+			Name:  "format",
+			Value: formatText,
+			Usage: "Output format: text, or json for one JSON object per step and a final summary on stdout (human output goes to stderr)",
+		},
+		&cli.StringFlag{
+			Name:  "output-junit",
+			Usage: "Also write a JUnit XML report with one testsuite per flow and one testcase per step to this path",
+		},
+		&cli.StringFlag{
+			Name:  "output-html",
+			Usage: "Also write a self-contained HTML report with screenshots embedded to this path",
+		},
 
 		// Parallelization
 		&cli.IntFlag{
 			Name:  "parallel",
 			Usage: "Run tests in parallel on N devices (auto-selects available devices)",
 		},
+		&cli.IntFlag{
+			Name:  "shard-all",
+			Usage: "Run every flow on each of N devices (auto-selects available devices, or use --devices)",
+		},
 
 		// Execution modes
 		&cli.BoolFlag{
@@ -113,6 +140,15 @@ Examples:
 			Aliases: []string{"c"},
 			Usage:   "Enable continuous mode for single flow",
 		},
+		&cli.IntFlag{
+			Name:  "http-timeout",
+			Value: int(wdadriver.DefaultHTTPTimeout / time.Second),
+			Usage: "Timeout in seconds for each request to WebDriverAgent on iOS; a hung device fails the step instead of stalling the run",
+		},
+		&cli.BoolFlag{
+			Name:  "dry-run",
+			Usage: "Parse and validate all flows, including each step's required fields, without using a device",
+		},
 
 		// Web options
 		&cli.BoolFlag{
@@ -127,6 +163,55 @@ Examples:
 			Value:   200,
 			EnvVars: []string{"MAESTRO_WAIT_FOR_IDLE_TIMEOUT"},
 		},
+		&cli.BoolFlag{
+			Name:    "screenshot-on-failure",
+			Value:   true,
+			Usage:   "Save a screenshot and view hierarchy when a step fails (--screenshot-on-failure=false to disable)",
+			EnvVars: []string{"MAESTRO_SCREENSHOT_ON_FAILURE"},
+		},
+		&cli.BoolFlag{
+			Name:    "record-on-failure",
+			Usage:   "Record each flow and keep the video only when the flow fails",
+			EnvVars: []string{"MAESTRO_RECORD_ON_FAILURE"},
+		},
+		&cli.BoolFlag{
+			Name:    "logcat-on-failure",
+			Aliases: []string{"syslog-on-failure"},
+			Usage:   "Capture device logs during each flow (adb logcat, iOS simulator log) and save them when the flow fails",
+			EnvVars: []string{"MAESTRO_LOGCAT_ON_FAILURE"},
+		},
+		&cli.StringFlag{
+			Name:    "logcat-tag",
+			Usage:   "Keep only this log tag (iOS: subsystem, category or process) instead of the app's process (with --logcat-on-failure)",
+			EnvVars: []string{"MAESTRO_LOGCAT_TAG"},
+		},
+		&cli.IntFlag{
+			Name:    "type-delay",
+			Usage:   "Default per-character inputText delay in ms (0 = type in one burst)",
+			EnvVars: []string{"MAESTRO_TYPE_DELAY"},
+		},
+		&cli.StringFlag{
+			Name:    "wda-tap-mode",
+			Usage:   "iOS tap implementation: wda (/wda/tap) or actions (W3C pointer actions)",
+			Value:   "wda",
+			EnvVars: []string{"MAESTRO_WDA_TAP_MODE"},
+		},
+		&cli.StringFlag{
+			Name:    "wda-invalid-session",
+			Usage:   "iOS handling of WDA \"invalid session id\" errors: recover (recreate the session and retry the step once) or fail",
+			Value:   "recover",
+			EnvVars: []string{"MAESTRO_WDA_INVALID_SESSION"},
+		},
+		&cli.BoolFlag{
+			Name:    "wda-reuse-session",
+			Usage:   "iOS: reuse the session WDA already has open (e.g. from an earlier run) instead of creating one",
+			EnvVars: []string{"MAESTRO_WDA_REUSE_SESSION"},
+		},
+		&cli.BoolFlag{
+			Name:    "auto-dismiss-alerts",
+			Usage:   "iOS: when a step fails with a system alert on screen (low storage, OS update), dismiss it and retry the step once",
+			EnvVars: []string{"MAESTRO_AUTO_DISMISS_ALERTS"},
+		},
 
 		// Emulator management flags (start-emulator, auto-start-emulator,
 		// shutdown-after, boot-timeout) are global flags defined in cli.go.
@@ -217,6 +302,8 @@ func bootTimeout(cfg *RunConfig) time.Duration {
 // 1. --start-emulator: Explicitly start a specific AVD
 // 2. --auto-start-emulator: Start an emulator if no devices are found
 func handleEmulatorStartup(cfg *RunConfig, mgr *emulator.Manager) error {
+	w := cfg.output()
+
 	// Only handle Android emulators
 	if cfg.Platform != "" && cfg.Platform != "android" {
 		return nil
@@ -226,7 +313,7 @@ func handleEmulatorStartup(cfg *RunConfig, mgr *emulator.Manager) error {
 
 	// Case 1: Explicit --start-emulator flag
 	if cfg.StartEmulator != "" {
-		fmt.Printf("  %s⏳ Starting emulator: %s%s\n", color(colorCyan), cfg.StartEmulator, color(colorReset))
+		fmt.Fprintf(w, "  %s⏳ Starting emulator: %s%s\n", color(colorCyan), cfg.StartEmulator, color(colorReset))
 		logger.Info("Starting emulator: %s (timeout: %v)", cfg.StartEmulator, timeout)
 
 		serial, err := mgr.Start(cfg.StartEmulator, timeout)
@@ -234,7 +321,7 @@ func handleEmulatorStartup(cfg *RunConfig, mgr *emulator.Manager) error {
 			return fmt.Errorf("failed to start emulator %s: %w", cfg.StartEmulator, err)
 		}
 
-		fmt.Printf("  %s✓ Emulator started: %s%s\n", color(colorGreen), serial, color(colorReset))
+		fmt.Fprintf(w, "  %s✓ Emulator started: %s%s\n", color(colorGreen), serial, color(colorReset))
 		logger.Info("Emulator started successfully: %s", serial)
 
 		// Add to device list if not already specified
@@ -258,7 +345,7 @@ func handleEmulatorStartup(cfg *RunConfig, mgr *emulator.Manager) error {
 
 		// No devices found - start an emulator
 		logger.Info("No devices found, auto-starting emulator...")
-		fmt.Printf("  %s⏳ No devices found, auto-starting emulator...%s\n", color(colorCyan), color(colorReset))
+		fmt.Fprintf(w, "  %s⏳ No devices found, auto-starting emulator...%s\n", color(colorCyan), color(colorReset))
 
 		// Find first available AVD
 		avds, err := emulator.ListAVDs()
@@ -272,14 +359,14 @@ func handleEmulatorStartup(cfg *RunConfig, mgr *emulator.Manager) error {
 		// Start the first AVD
 		avdName := avds[0].Name
 		logger.Info("Starting AVD: %s", avdName)
-		fmt.Printf("  %s⏳ Starting AVD: %s%s\n", color(colorCyan), avdName, color(colorReset))
+		fmt.Fprintf(w, "  %s⏳ Starting AVD: %s%s\n", color(colorCyan), avdName, color(colorReset))
 
 		serial, err := mgr.Start(avdName, timeout)
 		if err != nil {
 			return fmt.Errorf("failed to auto-start emulator %s: %w", avdName, err)
 		}
 
-		fmt.Printf("  %s✓ Emulator started: %s%s\n", color(colorGreen), serial, color(colorReset))
+		fmt.Fprintf(w, "  %s✓ Emulator started: %s%s\n", color(colorGreen), serial, color(colorReset))
 		logger.Info("Emulator auto-started successfully: %s", serial)
 
 		// Add to device list
@@ -327,11 +414,13 @@ func handleDeviceStartup(cfg *RunConfig, emulatorMgr *emulator.Manager, simulato
 // 1. --start-simulator: Explicitly start a named or UDID simulator
 // 2. --auto-start-emulator with --platform ios: Start a simulator if none booted
 func handleSimulatorStartup(cfg *RunConfig, mgr *simulator.Manager) error {
+	w := cfg.output()
+
 	timeout := bootTimeout(cfg)
 
 	// Case 1: Explicit --start-simulator flag
 	if cfg.StartSimulator != "" {
-		fmt.Printf("  %s⏳ Starting simulator: %s%s\n", color(colorCyan), cfg.StartSimulator, color(colorReset))
+		fmt.Fprintf(w, "  %s⏳ Starting simulator: %s%s\n", color(colorCyan), cfg.StartSimulator, color(colorReset))
 		logger.Info("Starting simulator: %s (timeout: %v)", cfg.StartSimulator, timeout)
 
 		udid, err := mgr.StartByName(cfg.StartSimulator, timeout)
@@ -339,7 +428,7 @@ func handleSimulatorStartup(cfg *RunConfig, mgr *simulator.Manager) error {
 			return fmt.Errorf("failed to start simulator %s: %w", cfg.StartSimulator, err)
 		}
 
-		fmt.Printf("  %s✓ Simulator started: %s%s\n", color(colorGreen), udid, color(colorReset))
+		fmt.Fprintf(w, "  %s✓ Simulator started: %s%s\n", color(colorGreen), udid, color(colorReset))
 		logger.Info("Simulator started successfully: %s", udid)
 
 		if len(cfg.Devices) == 0 {
@@ -362,7 +451,7 @@ func handleSimulatorStartup(cfg *RunConfig, mgr *simulator.Manager) error {
 
 		// No booted simulators — find one to start
 		logger.Info("No booted simulators found, auto-starting...")
-		fmt.Printf("  %s⏳ No simulators found, auto-starting...%s\n", color(colorCyan), color(colorReset))
+		fmt.Fprintf(w, "  %s⏳ No simulators found, auto-starting...%s\n", color(colorCyan), color(colorReset))
 
 		shutdownSims, err := simulator.ListShutdownSimulators()
 		if err != nil || len(shutdownSims) == 0 {
@@ -371,14 +460,14 @@ func handleSimulatorStartup(cfg *RunConfig, mgr *simulator.Manager) error {
 
 		target := shutdownSims[0]
 		logger.Info("Starting simulator: %s (%s)", target.Name, target.UDID)
-		fmt.Printf("  %s⏳ Starting simulator: %s%s\n", color(colorCyan), target.Name, color(colorReset))
+		fmt.Fprintf(w, "  %s⏳ Starting simulator: %s%s\n", color(colorCyan), target.Name, color(colorReset))
 
 		udid, err = mgr.Start(target.UDID, timeout)
 		if err != nil {
 			return fmt.Errorf("failed to auto-start simulator %s: %w", target.Name, err)
 		}
 
-		fmt.Printf("  %s✓ Simulator started: %s%s\n", color(colorGreen), udid, color(colorReset))
+		fmt.Fprintf(w, "  %s✓ Simulator started: %s%s\n", color(colorGreen), udid, color(colorReset))
 		logger.Info("Simulator auto-started successfully: %s", udid)
 
 		cfg.Devices = []string{udid}
@@ -395,7 +484,8 @@ type RunConfig struct {
 	ConfigPath string
 
 	// Environment
-	Env map[string]string
+	Env  map[string]string
+	Vars map[string]interface{} // From --vars; overridden by Env
 
 	// Filtering
 	IncludeTags []string
@@ -403,13 +493,24 @@ type RunConfig struct {
 
 	// Output
 	OutputDir string // Final resolved output directory
+	TimingCSV string // Path for the per-step timing CSV ("" = don't write one)
+	JUnitPath string // Path for the per-step JUnit XML ("" = don't write one)
+	HTMLPath  string // Path for the self-contained HTML report ("" = don't write one)
+
+	// Output receives the human-readable output (nil = os.Stdout)
+	Output io.Writer
+
+	// results receives step results and the summary with --format json (nil otherwise)
+	results *jsonResultWriter
 
 	// Parallelization
-	Parallel int // Number of devices to use (0 = single device mode)
+	Parallel int  // Number of devices to use (0 = single device mode)
+	ShardAll bool // Run every flow on every device instead of splitting flows across them
 
 	// Execution
 	Continuous bool
 	Headless   bool
+	DryRun     bool // Validate flows and exit without creating a driver
 
 	// Device
 	Platform string
@@ -422,11 +523,22 @@ type RunConfig struct {
 	Driver       string                 // uiautomator2, appium
 	AppiumURL    string                 // Appium server URL
 	CapsFile     string                 // Appium capabilities JSON file path
+	CapsMode     string                 // capsModeMerge (default) or capsModeReplace
 	Capabilities map[string]interface{} // Parsed Appium capabilities
 
 	// Driver settings
-	WaitForIdleTimeout int    // Wait for device idle in ms (0 = disabled, default 200)
-	TeamID             string // Apple Development Team ID for WDA code signing
+	WaitForIdleTimeout  int    // Wait for device idle in ms (0 = disabled, default 200)
+	TypeDelayMs         int    // Default per-character inputText delay in ms (0 = disabled)
+	RecordOnFailure     bool   // Record flows and keep the video only for failures
+	LogcatOnFailure     bool   // Save device logs (logcat, iOS simulator log) for failing flows
+	LogcatTag           string // Log tag to keep (default: the app's process)
+	ScreenshotOnFailure bool   // Capture a screenshot and hierarchy when a step fails
+	TeamID              string // Apple Development Team ID for WDA code signing
+	WDATapMode          string // iOS tap implementation: "wda" or "actions"
+	WDAInvalidSession   string // iOS handling of lost WDA sessions: "recover" or "fail"
+	WDAReuseSession     bool   // iOS: adopt the session WDA already has open
+
+	AutoDismissUnexpectedAlerts bool // iOS: dismiss an alert found after a failed step and retry it once
 
 	// Emulator/Simulator management
 	StartEmulator     string // AVD name to start (e.g., Pixel_7_API_33)
@@ -434,9 +546,10 @@ type RunConfig struct {
 	AutoStartEmulator bool   // Auto-start an emulator/simulator if no devices found
 	ShutdownAfter     bool   // Shutdown emulators/simulators started by maestro-runner after tests
 	BootTimeout       int    // Device boot timeout in seconds
+	HTTPTimeout       int    // Per-request WDA timeout in seconds (0 = driver default)
 }
 
-func printBanner() {
+func printBanner(w io.Writer) {
 	// Make DeviceLab.dev clickable and colored (cyan)
 	// OSC 8 hyperlink format: ESC]8;;URL BEL TEXT ESC]8;; BEL
 	deviceLabLink := "\x1b]8;;https://devicelab.dev\x07" + color(colorCyan) + "DeviceLab.dev" + color(colorReset) + "\x1b]8;;\x07"
@@ -455,24 +568,24 @@ func printBanner() {
 	githubLineVisible := 21 // "  ⭐ " + "Star us on GitHub" (⭐ is 3 bytes but 1 visual char)
 	githubPadding := strings.Repeat(" ", 64-githubLineVisible)
 
-	fmt.Println()
-	fmt.Println("╔═══════════════════════════════════════════════════════════════════╗")
-	fmt.Printf("║  maestro-runner %s - by %s%s   ║\n", Version, deviceLabLink, versionPadding)
-	fmt.Println("║  Fast, lightweight Maestro test runner                            ║")
-	fmt.Printf("║  ⭐ %s%s  ║\n", githubLink, githubPadding)
-	fmt.Println("╚═══════════════════════════════════════════════════════════════════╝")
-	fmt.Println()
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "╔═══════════════════════════════════════════════════════════════════╗")
+	fmt.Fprintf(w, "║  maestro-runner %s - by %s%s   ║\n", Version, deviceLabLink, versionPadding)
+	fmt.Fprintln(w, "║  Fast, lightweight Maestro test runner                            ║")
+	fmt.Fprintf(w, "║  ⭐ %s%s  ║\n", githubLink, githubPadding)
+	fmt.Fprintln(w, "╚═══════════════════════════════════════════════════════════════════╝")
+	fmt.Fprintln(w)
 }
 
-func printFooter() {
+func printFooter(w io.Writer) {
 	// Make DeviceLab.dev clickable and colored (cyan)
 	deviceLabLink := "\x1b]8;;https://devicelab.dev\x07" + color(colorCyan) + "DeviceLab.dev" + color(colorReset) + "\x1b]8;;\x07"
 
-	fmt.Println()
-	fmt.Println("╔══════════════════════════════════════════════════════════════════════════╗")
-	fmt.Printf("║ Built by %s - Turn Your Devices Into a Distributed Device Lab ║\n", deviceLabLink)
-	fmt.Println("╚══════════════════════════════════════════════════════════════════════════╝")
-	fmt.Println()
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "╔══════════════════════════════════════════════════════════════════════════╗")
+	fmt.Fprintf(w, "║ Built by %s - Turn Your Devices Into a Distributed Device Lab ║\n", deviceLabLink)
+	fmt.Fprintln(w, "╚══════════════════════════════════════════════════════════════════════════╝")
+	fmt.Fprintln(w)
 }
 
 func runTest(c *cli.Context) error {
@@ -480,12 +593,6 @@ func runTest(c *cli.Context) error {
 		return fmt.Errorf("at least one flow file or folder is required")
 	}
 
-	// Print banner at start
-	printBanner()
-
-	// Check for updates in background (prints at end)
-	startUpdateCheck()
-
 	// Helper to get flag value from current or parent context
 	// When run as subcommand, global flags are in parent context
 	getString := func(name string) string {
@@ -525,6 +632,37 @@ func runTest(c *cli.Context) error {
 		return c.StringSlice(name)
 	}
 
+	// With --format json, stdout carries only the JSON result stream and the
+	// human-readable output moves to stderr
+	var results *jsonResultWriter
+	var out io.Writer = os.Stdout
+	switch format := getString("format"); format {
+	case "", formatText:
+	case formatJSON:
+		results = newJSONResultWriter(os.Stdout)
+		out = os.Stderr
+	default:
+		return fmt.Errorf("invalid --format %q (expected %q or %q)", format, formatText, formatJSON)
+	}
+
+	// --shard-all N picks devices like --parallel N but runs the whole suite on each
+	parallel, shardAll := getInt("parallel"), getInt("shard-all")
+	if parallel > 0 && shardAll > 0 {
+		return fmt.Errorf("--parallel and --shard-all cannot be used together")
+	}
+	if shardAll > 0 {
+		parallel = shardAll
+	}
+
+	// Color only if the human-readable output (stderr in JSON mode) is a terminal
+	colorsEnabled = colorsFor(out)
+
+	// Print banner at start
+	printBanner(out)
+
+	// Check for updates in background (prints at end)
+	startUpdateCheck()
+
 	// Parse environment variables
 	env := parseEnvVars(getStringSlice("env"))
 
@@ -544,6 +682,12 @@ func runTest(c *cli.Context) error {
 			return err
 		}
 	}
+	capsMode := getString("caps-mode")
+	switch capsMode {
+	case "", capsModeMerge, capsModeReplace:
+	default:
+		return fmt.Errorf("invalid --caps-mode %q (expected %q or %q)", capsMode, capsModeMerge, capsModeReplace)
+	}
 
 	// Load workspace config if provided
 	var workspaceConfig *config.Config
@@ -567,6 +711,16 @@ func runTest(c *cli.Context) error {
 		mergedEnv[k] = v // CLI overrides workspace config
 	}
 
+	// Load variables file if provided
+	var vars map[string]interface{}
+	if varsPath := getString("vars"); varsPath != "" {
+		var err error
+		vars, err = config.LoadVars(varsPath)
+		if err != nil {
+			return fmt.Errorf("failed to load vars: %w", err)
+		}
+	}
+
 	// Get appId from workspace config or will be extracted from flows later
 	appID := ""
 	if workspaceConfig != nil && workspaceConfig.AppID != "" {
@@ -575,31 +729,52 @@ func runTest(c *cli.Context) error {
 
 	// Build run configuration
 	cfg := &RunConfig{
-		FlowPaths:          c.Args().Slice(),
-		ConfigPath:         configPath,
-		Env:                mergedEnv,
-		IncludeTags:        getStringSlice("include-tags"),
-		ExcludeTags:        getStringSlice("exclude-tags"),
-		OutputDir:          outputDir,
-		Parallel:           getInt("parallel"),
-		Continuous:         getBool("continuous"),
-		Headless:           getBool("headless"),
-		Platform:           getString("platform"),
-		Devices:            parseDevices(getString("device")),
-		Verbose:            getBool("verbose"),
-		AppFile:            getString("app-file"),
-		AppID:              appID,
-		Driver:             getString("driver"),
-		AppiumURL:          getString("appium-url"),
-		CapsFile:           capsFile,
-		Capabilities:       caps,
-		WaitForIdleTimeout: getInt("wait-for-idle-timeout"),
-		TeamID:             getString("team-id"),
-		StartEmulator:      getString("start-emulator"),
-		StartSimulator:     getString("start-simulator"),
-		AutoStartEmulator:  getBool("auto-start-emulator"),
-		ShutdownAfter:      getBool("shutdown-after"),
-		BootTimeout:        getInt("boot-timeout"),
+		FlowPaths:           c.Args().Slice(),
+		ConfigPath:          configPath,
+		Env:                 mergedEnv,
+		Vars:                vars,
+		IncludeTags:         getStringSlice("include-tags"),
+		ExcludeTags:         getStringSlice("exclude-tags"),
+		OutputDir:           outputDir,
+		TimingCSV:           getString("timing-csv"),
+		JUnitPath:           getString("output-junit"),
+		HTMLPath:            getString("output-html"),
+		Parallel:            parallel,
+		ShardAll:            shardAll > 0,
+		Continuous:          getBool("continuous"),
+		DryRun:              getBool("dry-run"),
+		Headless:            getBool("headless"),
+		Platform:            getString("platform"),
+		Devices:             parseDevices(getString("device")),
+		Verbose:             getBool("verbose"),
+		AppFile:             getString("app-file"),
+		AppID:               appID,
+		Driver:              getString("driver"),
+		AppiumURL:           getString("appium-url"),
+		CapsFile:            capsFile,
+		CapsMode:            capsMode,
+		Capabilities:        caps,
+		WaitForIdleTimeout:  getInt("wait-for-idle-timeout"),
+		TypeDelayMs:         getInt("type-delay"),
+		RecordOnFailure:     getBool("record-on-failure"),
+		LogcatOnFailure:     getBool("logcat-on-failure"),
+		LogcatTag:           getString("logcat-tag"),
+		ScreenshotOnFailure: getBool("screenshot-on-failure"),
+		TeamID:              getString("team-id"),
+		WDATapMode:          getString("wda-tap-mode"),
+		WDAInvalidSession:   getString("wda-invalid-session"),
+		WDAReuseSession:     getBool("wda-reuse-session"),
+		StartEmulator:       getString("start-emulator"),
+		StartSimulator:      getString("start-simulator"),
+		AutoStartEmulator:   getBool("auto-start-emulator"),
+		ShutdownAfter:       getBool("shutdown-after"),
+		BootTimeout:         getInt("boot-timeout"),
+		HTTPTimeout:         getInt("http-timeout"),
+
+		AutoDismissUnexpectedAlerts: getBool("auto-dismiss-alerts"),
+
+		Output:  out,
+		results: results,
 	}
 
 	// Apply waitForIdleTimeout with priority:
@@ -612,10 +787,10 @@ func runTest(c *cli.Context) error {
 			cfg.WaitForIdleTimeout = workspaceConfig.WaitForIdleTimeout
 		} else if caps != nil {
 			// Check caps file for waitForIdleTimeout
-			if val, ok := caps["appium:waitForIdleTimeout"].(float64); ok {
-				cfg.WaitForIdleTimeout = int(val)
-			} else if val, ok := caps["waitForIdleTimeout"].(float64); ok {
-				cfg.WaitForIdleTimeout = int(val)
+			if val, ok := appiumdriver.CapabilityInt(caps["appium:waitForIdleTimeout"]); ok {
+				cfg.WaitForIdleTimeout = val
+			} else if val, ok := appiumdriver.CapabilityInt(caps["waitForIdleTimeout"]); ok {
+				cfg.WaitForIdleTimeout = val
 			}
 			// else: keep default 200ms from CLI flag
 		}
@@ -648,6 +823,12 @@ func resolveOutputDir(output string, flatten bool) (string, error) {
 }
 
 func executeTest(cfg *RunConfig) error {
+	w := cfg.output()
+
+	if cfg.DryRun {
+		return executeDryRun(cfg)
+	}
+
 	// 1. Create output directory
 	if err := os.MkdirAll(cfg.OutputDir, 0o755); err != nil {
 		return fmt.Errorf("failed to create output directory: %w", err)
@@ -656,7 +837,7 @@ func executeTest(cfg *RunConfig) error {
 	// 2. Initialize logging
 	logPath := filepath.Join(cfg.OutputDir, "maestro-runner.log")
 	if err := logger.Init(logPath); err != nil {
-		fmt.Printf("Warning: Failed to initialize logger: %v\n", err)
+		fmt.Fprintf(w, "Warning: Failed to initialize logger: %v\n", err)
 	}
 	defer logger.Close()
 
@@ -725,6 +906,18 @@ func executeTest(cfg *RunConfig) error {
 			return fmt.Errorf("iOS with WDA driver requires --team-id for code signing\n" +
 				"Usage: maestro-runner --platform ios --team-id <APPLE_TEAM_ID> test <flow-files>")
 		}
+		switch cfg.WDATapMode {
+		case "", wdadriver.TapModeWDA, wdadriver.TapModeActions:
+		default:
+			return fmt.Errorf("invalid --wda-tap-mode %q (expected %q or %q)",
+				cfg.WDATapMode, wdadriver.TapModeWDA, wdadriver.TapModeActions)
+		}
+		switch cfg.WDAInvalidSession {
+		case "", wdadriver.InvalidSessionRecover, wdadriver.InvalidSessionFail:
+		default:
+			return fmt.Errorf("invalid --wda-invalid-session %q (expected %q or %q)",
+				cfg.WDAInvalidSession, wdadriver.InvalidSessionRecover, wdadriver.InvalidSessionFail)
+		}
 		if cfg.AppFile == "" && flowsUseClearState(flows) {
 			return fmt.Errorf("clearState on iOS requires --app-file to reinstall the app after uninstalling\n" +
 				"Usage: maestro-runner --app-file <path-to-ipa-or-app> --platform ios test <flow-files>")
@@ -764,19 +957,22 @@ func executeTest(cfg *RunConfig) error {
 	}
 	logger.Info("Flow execution completed: %d passed, %d failed, %d skipped",
 		result.PassedFlows, result.FailedFlows, result.SkippedFlows)
+	if cfg.results != nil {
+		cfg.results.summary(result)
+	}
 
 	// 6. Print unified output (works for both single and parallel)
-	if err := printUnifiedOutput(cfg.OutputDir, result); err != nil {
-		fmt.Printf("Warning: Failed to print unified output: %v\n", err)
+	if err := printUnifiedOutput(w, cfg.OutputDir, result); err != nil {
+		fmt.Fprintf(w, "Warning: Failed to print unified output: %v\n", err)
 		// Fallback to basic summary
-		printSummary(result)
+		printSummary(w, result)
 	}
 
 	// 7. Generate and display reports
 	logger.Info("Generating reports...")
-	fmt.Println()
-	fmt.Printf("  %s✓ Tests completed. Generating reports...%s\n", color(colorGreen), color(colorReset))
-	fmt.Println()
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "  %s✓ Tests completed. Generating reports...%s\n", color(colorGreen), color(colorReset))
+	fmt.Fprintln(w)
 
 	htmlPath := filepath.Join(cfg.OutputDir, "report.html")
 	jsonPath := filepath.Join(cfg.OutputDir, "report.json")
@@ -788,52 +984,92 @@ func executeTest(cfg *RunConfig) error {
 		Title:      "Test Report",
 	}); err != nil {
 		htmlGenerated = false
-		fmt.Printf("  %s⚠%s Warning: failed to generate HTML report: %v\n", color(colorYellow), color(colorReset), err)
+		fmt.Fprintf(w, "  %s⚠%s Warning: failed to generate HTML report: %v\n", color(colorYellow), color(colorReset), err)
 	}
 
 	junitGenerated := true
 	if err := report.GenerateJUnit(cfg.OutputDir); err != nil {
 		junitGenerated = false
-		fmt.Printf("  %s⚠%s Warning: failed to generate JUnit report: %v\n", color(colorYellow), color(colorReset), err)
+		fmt.Fprintf(w, "  %s⚠%s Warning: failed to generate JUnit report: %v\n", color(colorYellow), color(colorReset), err)
 	}
 
 	allurePath := filepath.Join(cfg.OutputDir, "allure-results")
 	allureGenerated := true
 	if err := report.GenerateAllure(cfg.OutputDir); err != nil {
 		allureGenerated = false
-		fmt.Printf("  %s⚠%s Warning: failed to generate Allure report: %v\n", color(colorYellow), color(colorReset), err)
+		fmt.Fprintf(w, "  %s⚠%s Warning: failed to generate Allure report: %v\n", color(colorYellow), color(colorReset), err)
+	}
+
+	timingGenerated := false
+	if cfg.TimingCSV != "" {
+		if err := report.GenerateTimingCSV(cfg.OutputDir, cfg.TimingCSV); err != nil {
+			fmt.Fprintf(w, "  %s⚠%s Warning: failed to generate timing CSV: %v\n", color(colorYellow), color(colorReset), err)
+		} else {
+			timingGenerated = true
+		}
+	}
+
+	stepJUnitGenerated := false
+	if cfg.JUnitPath != "" {
+		if err := report.GenerateStepJUnit(cfg.OutputDir, cfg.JUnitPath); err != nil {
+			fmt.Fprintf(w, "  %s⚠%s Warning: failed to generate step JUnit report: %v\n", color(colorYellow), color(colorReset), err)
+		} else {
+			stepJUnitGenerated = true
+		}
+	}
+
+	embeddedHTMLGenerated := false
+	if cfg.HTMLPath != "" {
+		if err := report.GenerateHTML(cfg.OutputDir, report.HTMLConfig{
+			OutputPath:  cfg.HTMLPath,
+			Title:       "Test Report",
+			EmbedAssets: true,
+		}); err != nil {
+			fmt.Fprintf(w, "  %s⚠%s Warning: failed to generate embedded HTML report: %v\n", color(colorYellow), color(colorReset), err)
+		} else {
+			embeddedHTMLGenerated = true
+		}
 	}
 
 	// Display reports section as a directory tree
-	fmt.Printf("  %sReports:%s %s\n", color(colorBold), color(colorReset), cfg.OutputDir)
-	fmt.Printf("    ├── report.json\n")
+	fmt.Fprintf(w, "  %sReports:%s %s\n", color(colorBold), color(colorReset), cfg.OutputDir)
+	fmt.Fprintf(w, "    ├── report.json\n")
 	if htmlGenerated {
-		fmt.Printf("    ├── report.html\n")
+		fmt.Fprintf(w, "    ├── report.html\n")
 	}
 	if junitGenerated {
-		fmt.Printf("    ├── junit-report.xml\n")
+		fmt.Fprintf(w, "    ├── junit-report.xml\n")
 	}
 	if allureGenerated {
-		fmt.Printf("    └── allure-results/\n")
+		fmt.Fprintf(w, "    └── allure-results/\n")
 	}
-	fmt.Println()
-	fmt.Println("  Paths:")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "  Paths:")
 	if htmlGenerated {
-		fmt.Printf("    HTML:   %s\n", htmlPath)
+		fmt.Fprintf(w, "    HTML:   %s\n", htmlPath)
 	}
-	fmt.Printf("    JSON:   %s\n", jsonPath)
+	fmt.Fprintf(w, "    JSON:   %s\n", jsonPath)
 	if junitGenerated {
-		fmt.Printf("    JUnit:  %s\n", junitPath)
+		fmt.Fprintf(w, "    JUnit:  %s\n", junitPath)
 	}
 	if allureGenerated {
-		fmt.Printf("    Allure: %s\n", allurePath)
+		fmt.Fprintf(w, "    Allure: %s\n", allurePath)
+	}
+	if timingGenerated {
+		fmt.Fprintf(w, "    Timing: %s\n", cfg.TimingCSV)
+	}
+	if stepJUnitGenerated {
+		fmt.Fprintf(w, "    Steps:  %s\n", cfg.JUnitPath)
+	}
+	if embeddedHTMLGenerated {
+		fmt.Fprintf(w, "    HTML:   %s\n", cfg.HTMLPath)
 	}
 
 	// 7. Print update notice if available
-	printUpdateNotice()
+	printUpdateNotice(w)
 
 	// 8. Print footer
-	printFooter()
+	printFooter(w)
 
 	// Exit with code 1 if any flows failed (summary already printed)
 	if result.Status != report.StatusPassed {
@@ -845,15 +1081,26 @@ func executeTest(cfg *RunConfig) error {
 
 // validateAndParseFlows validates and parses all flow files.
 func validateAndParseFlows(cfg *RunConfig) ([]flow.Flow, error) {
+	w := cfg.output()
+
 	v := validator.New(cfg.IncludeTags, cfg.ExcludeTags)
+	if cfg.DryRun {
+		v.WithStepChecks(cfg.Env, cfg.AppID)
+	}
 	var allTestCases []string
 	var allErrors []error
 
+	var skipped []string
+
 	for _, path := range cfg.FlowPaths {
 		result := v.Validate(path)
 		allTestCases = append(allTestCases, result.TestCases...)
+		skipped = append(skipped, result.SkippedByTags...)
 		allErrors = append(allErrors, result.Errors...)
 	}
+	// Flows left out by tags are still parsed so the runner can report them
+	// as skipped; the runner applies the same filters, including config.yaml's
+	cfg.IncludeTags, cfg.ExcludeTags = v.Tags()
 
 	if len(allErrors) > 0 {
 		fmt.Fprintf(os.Stderr, "Validation errors:\n")
@@ -863,16 +1110,20 @@ func validateAndParseFlows(cfg *RunConfig) ([]flow.Flow, error) {
 		return nil, fmt.Errorf("validation failed with %d error(s)", len(allErrors))
 	}
 
-	if len(allTestCases) == 0 {
+	if len(allTestCases) == 0 && len(skipped) == 0 {
 		return nil, fmt.Errorf("no test flows found")
 	}
 
-	fmt.Printf("\n%sSetup%s\n", color(colorBold), color(colorReset))
-	fmt.Println(strings.Repeat("─", 40))
-	printSetupSuccess(fmt.Sprintf("Found %d test flow(s)", len(allTestCases)))
+	fmt.Fprintf(w, "\n%sSetup%s\n", color(colorBold), color(colorReset))
+	fmt.Fprintln(w, strings.Repeat("─", 40))
+	found := fmt.Sprintf("Found %d test flow(s)", len(allTestCases))
+	if len(skipped) > 0 {
+		found += fmt.Sprintf(" (%d more skipped by tags)", len(skipped))
+	}
+	printSetupSuccess(w, found)
 
 	var flows []flow.Flow
-	for _, path := range allTestCases {
+	for _, path := range append(allTestCases, skipped...) {
 		f, err := flow.ParseFile(path)
 		if err != nil {
 			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
@@ -883,6 +1134,24 @@ func validateAndParseFlows(cfg *RunConfig) ([]flow.Flow, error) {
 	return flows, nil
 }
 
+// executeDryRun validates flows and their steps without creating a driver,
+// reporting every problem found rather than stopping at the first.
+func executeDryRun(cfg *RunConfig) error {
+	w := cfg.output()
+
+	flows, err := validateAndParseFlows(cfg)
+	if err != nil {
+		return err
+	}
+
+	steps := 0
+	for _, f := range flows {
+		steps += len(f.Config.OnFlowStart) + len(f.Steps) + len(f.Config.OnFlowComplete)
+	}
+	printSetupSuccess(w, fmt.Sprintf("Dry run: %d flow(s) with %d step(s) are valid; no device was used", len(flows), steps))
+	return nil
+}
+
 // flowsUseClearState checks if any flow uses clearState (standalone or via launchApp).
 func flowsUseClearState(flows []flow.Flow) bool {
 	for _, f := range flows {
@@ -915,6 +1184,8 @@ func flowsUseClearState(flows []flow.Flow) bool {
 // If --parallel N is specified with --auto-start-emulator, this will start additional
 // emulators to reach N total devices.
 func determineExecutionMode(cfg *RunConfig, emulatorMgr *emulator.Manager, simulatorMgr *simulator.Manager) (needsParallel bool, deviceIDs []string, err error) {
+	w := cfg.output()
+
 	needsParallel = cfg.Parallel > 0 || len(cfg.Devices) > 1
 
 	if needsParallel {
@@ -948,12 +1219,12 @@ func determineExecutionMode(cfg *RunConfig, emulatorMgr *emulator.Manager, simul
 				}
 				// Require enough unique AVDs -- same AVD cannot run twice (lock conflict)
 				if len(avds) < needed {
-					fmt.Println()
+					fmt.Fprintln(w)
 					return false, nil, buildNotEnoughAVDsError(cfg, len(deviceIDs), avds)
 				}
 
 				// Now we know we have enough AVDs -- print progress
-				fmt.Printf("  %s⏳ Starting %d emulator(s) for parallel execution...%s\n", color(colorCyan), needed, color(colorReset))
+				fmt.Fprintf(w, "  %s⏳ Starting %d emulator(s) for parallel execution...%s\n", color(colorCyan), needed, color(colorReset))
 
 				// Start emulators sequentially to avoid port conflicts.
 				timeout := bootTimeout(cfg)
@@ -961,7 +1232,7 @@ func determineExecutionMode(cfg *RunConfig, emulatorMgr *emulator.Manager, simul
 				for i := 0; i < needed; i++ {
 					avdName := avds[i].Name
 					logger.Info("Starting emulator %d/%d: %s", i+1, needed, avdName)
-					fmt.Printf("  %s⏳ Starting emulator %d/%d: %s%s\n", color(colorCyan), i+1, needed, avdName, color(colorReset))
+					fmt.Fprintf(w, "  %s⏳ Starting emulator %d/%d: %s%s\n", color(colorCyan), i+1, needed, avdName, color(colorReset))
 
 					serial, err := emulatorMgr.Start(avdName, timeout)
 					if err != nil {
@@ -975,7 +1246,7 @@ func determineExecutionMode(cfg *RunConfig, emulatorMgr *emulator.Manager, simul
 
 					deviceIDs = append(deviceIDs, serial)
 					logger.Info("Emulator started: %s (%d/%d)", serial, i+1, needed)
-					fmt.Printf("  %s✓ Emulator started: %s%s\n", color(colorGreen), serial, color(colorReset))
+					fmt.Fprintf(w, "  %s✓ Emulator started: %s%s\n", color(colorGreen), serial, color(colorReset))
 				}
 			} else if needed > 0 && cfg.AutoStartEmulator && cfg.Platform == "ios" {
 				// iOS simulator parallel startup
@@ -994,13 +1265,13 @@ func determineExecutionMode(cfg *RunConfig, emulatorMgr *emulator.Manager, simul
 						cfg.Parallel, needed, len(shutdownSims))
 				}
 
-				fmt.Printf("  %s⏳ Starting %d simulator(s) for parallel execution...%s\n", color(colorCyan), needed, color(colorReset))
+				fmt.Fprintf(w, "  %s⏳ Starting %d simulator(s) for parallel execution...%s\n", color(colorCyan), needed, color(colorReset))
 				timeout := bootTimeout(cfg)
 
 				for i := 0; i < needed; i++ {
 					sim := shutdownSims[i]
 					logger.Info("Starting simulator %d/%d: %s (%s)", i+1, needed, sim.Name, sim.UDID)
-					fmt.Printf("  %s⏳ Starting simulator %d/%d: %s%s\n", color(colorCyan), i+1, needed, sim.Name, color(colorReset))
+					fmt.Fprintf(w, "  %s⏳ Starting simulator %d/%d: %s%s\n", color(colorCyan), i+1, needed, sim.Name, color(colorReset))
 
 					udid, err := simulatorMgr.Start(sim.UDID, timeout)
 					if err != nil {
@@ -1013,29 +1284,51 @@ func determineExecutionMode(cfg *RunConfig, emulatorMgr *emulator.Manager, simul
 
 					deviceIDs = append(deviceIDs, udid)
 					logger.Info("Simulator started: %s (%d/%d)", sim.Name, i+1, needed)
-					fmt.Printf("  %s✓ Simulator started: %s (%s)%s\n", color(colorGreen), sim.Name, udid, color(colorReset))
+					fmt.Fprintf(w, "  %s✓ Simulator started: %s (%s)%s\n", color(colorGreen), sim.Name, udid, color(colorReset))
 				}
 			} else if needed > 0 {
 				// Need more devices but auto-start is disabled - build helpful error
 				return false, nil, buildParallelDeviceError(cfg, len(deviceIDs))
 			}
 		}
-		printSetupSuccess(fmt.Sprintf("Using %d device(s) for parallel execution", len(deviceIDs)))
-		fmt.Println()
-		fmt.Printf("  %sℹ Parallel Mode:%s\n", color(colorCyan), color(colorReset))
-		fmt.Println("    During execution, only brief status updates will be shown to avoid")
-		fmt.Println("    messy interleaved output. Detailed results will be displayed after")
-		fmt.Println("    all tests complete.")
-		fmt.Println()
+		printSetupSuccess(w, fmt.Sprintf("Using %d device(s) for parallel execution", len(deviceIDs)))
+		fmt.Fprintln(w)
+		fmt.Fprintf(w, "  %sℹ Parallel Mode:%s\n", color(colorCyan), color(colorReset))
+		fmt.Fprintln(w, "    During execution, only brief status updates will be shown to avoid")
+		fmt.Fprintln(w, "    messy interleaved output. Detailed results will be displayed after")
+		fmt.Fprintln(w, "    all tests complete.")
+		fmt.Fprintln(w)
 	}
 
-	printSetupSuccess(fmt.Sprintf("Report directory: %s", cfg.OutputDir))
-	fmt.Printf("\n%sExecution%s\n", color(colorBold), color(colorReset))
-	fmt.Println(strings.Repeat("─", 40))
+	printSetupSuccess(w, fmt.Sprintf("Report directory: %s", cfg.OutputDir))
+	fmt.Fprintf(w, "\n%sExecution%s\n", color(colorBold), color(colorReset))
+	fmt.Fprintln(w, strings.Repeat("─", 40))
 
 	return needsParallel, deviceIDs, nil
 }
 
+// output returns the writer for human-readable output.
+func (cfg *RunConfig) output() io.Writer {
+	if cfg.Output == nil {
+		return os.Stdout
+	}
+	return cfg.Output
+}
+
+// resultSink returns the executor's result sink: the terminal printer, plus
+// the JSON stream with --format json. Parallel runs print one line per flow
+// and device instead of every step.
+func (cfg *RunConfig) resultSink(parallel bool) executor.ResultSink {
+	var printer executor.ResultSink = &terminalPrinter{w: cfg.output()}
+	if parallel {
+		printer = &parallelPrinter{w: cfg.output()}
+	}
+	if cfg.results == nil {
+		return printer
+	}
+	return executor.MultiSink{printer, cfg.results}
+}
+
 // executeFlowsWithMode executes flows using the appropriate execution mode.
 func executeFlowsWithMode(cfg *RunConfig, flows []flow.Flow, needsParallel bool, deviceIDs []string) (*executor.RunResult, error) {
 	driverType := strings.ToLower(cfg.Driver)
@@ -1085,19 +1378,23 @@ func executeSingleDevice(cfg *RunConfig, flows []flow.Flow) (*executor.RunResult
 	runner := executor.New(driver, executor.RunnerConfig{
 		OutputDir:          cfg.OutputDir,
 		Parallelism:        0,
-		Artifacts:          executor.ArtifactOnFailure,
+		Artifacts:          cfg.artifactMode(),
 		Device:             deviceInfo,
 		App:                buildAppReport(driver),
 		RunnerVersion:      Version,
 		DriverName:         driverName,
 		Env:                cfg.Env,
+		Vars:               cfg.Vars,
+		IncludeTags:        cfg.IncludeTags,
+		ExcludeTags:        cfg.ExcludeTags,
 		WaitForIdleTimeout: cfg.WaitForIdleTimeout,
+		TypeDelayMs:        cfg.TypeDelayMs,
+		RecordOnFailure:    cfg.RecordOnFailure,
+		LogcatOnFailure:    cfg.LogcatOnFailure,
+		LogcatTag:          cfg.LogcatTag,
 		DeviceInfo:         &deviceInfo,
-		OnFlowStart:        onFlowStart,
-		OnStepComplete:     onStepComplete,
-		OnNestedStep:       onNestedStep,
-		OnNestedFlowStart:  onNestedFlowStart,
-		OnFlowEnd:          onFlowEnd,
+		Sink:               cfg.resultSink(false),
+		ScriptOutput:       cfg.output(),
 	})
 
 	return runner.Run(context.Background(), flows)
@@ -1124,19 +1421,23 @@ func ExecuteFlowWithDriver(driver core.Driver, cfg *RunConfig, f flow.Flow) (*ex
 	runner := executor.New(driver, executor.RunnerConfig{
 		OutputDir:          cfg.OutputDir,
 		Parallelism:        0,
-		Artifacts:          executor.ArtifactOnFailure,
+		Artifacts:          cfg.artifactMode(),
 		Device:             deviceInfo,
 		App:                buildAppReport(driver),
 		RunnerVersion:      Version,
 		DriverName:         driverName,
 		Env:                cfg.Env,
+		Vars:               cfg.Vars,
+		IncludeTags:        cfg.IncludeTags,
+		ExcludeTags:        cfg.ExcludeTags,
 		WaitForIdleTimeout: cfg.WaitForIdleTimeout,
+		TypeDelayMs:        cfg.TypeDelayMs,
+		RecordOnFailure:    cfg.RecordOnFailure,
+		LogcatOnFailure:    cfg.LogcatOnFailure,
+		LogcatTag:          cfg.LogcatTag,
 		DeviceInfo:         &deviceInfo,
-		OnFlowStart:        onFlowStart,
-		OnStepComplete:     onStepComplete,
-		OnNestedStep:       onNestedStep,
-		OnNestedFlowStart:  onNestedFlowStart,
-		OnFlowEnd:          onFlowEnd,
+		Sink:               cfg.resultSink(false),
+		ScriptOutput:       cfg.output(),
 	})
 
 	return runner.Run(context.Background(), []flow.Flow{f})
@@ -1161,17 +1462,24 @@ const slowThresholdMs = 5000
 var colorsEnabled = true
 
 func init() {
+	colorsEnabled = colorsFor(os.Stdout)
+}
+
+// colorsFor reports whether output to w should be colored: not with NO_COLOR,
+// and only when w is a terminal.
+func colorsFor(w io.Writer) bool {
 	// Respect NO_COLOR environment variable
 	if os.Getenv("NO_COLOR") != "" {
-		colorsEnabled = false
-		return
+		return false
 	}
-	// Check if stdout is a terminal
-	if fileInfo, err := os.Stdout.Stat(); err == nil {
-		if (fileInfo.Mode() & os.ModeCharDevice) == 0 {
-			colorsEnabled = false
-		}
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
 	}
+	if fileInfo, err := f.Stat(); err == nil {
+		return fileInfo.Mode()&os.ModeCharDevice != 0
+	}
+	return true
 }
 
 // color returns the color code if colors are enabled, empty string otherwise
@@ -1182,25 +1490,39 @@ func color(c string) string {
 	return ""
 }
 
-// Live progress callbacks
-// Note: For unified output, we'll read DeviceInfo from the runner config
-// This callback is used during execution but detailed device info is shown in summary
-func onFlowStart(flowIdx, totalFlows int, name, file string) {
-	fmt.Printf("\n  %s[%d/%d]%s %s%s%s (%s)\n",
-		color(colorCyan), flowIdx+1, totalFlows, color(colorReset),
-		color(colorBold), name, color(colorReset), file)
-	fmt.Println(strings.Repeat("─", 60))
+// terminalPrinter is the ResultSink for live progress in a terminal: every
+// flow with its steps, nested steps indented under their runFlow.
+type terminalPrinter struct {
+	w io.Writer
+}
+
+func (p *terminalPrinter) FlowStart(ev executor.FlowEvent) {
+	fmt.Fprintf(p.w, "\n  %s[%d/%d]%s %s%s%s (%s)\n",
+		color(colorCyan), ev.Index+1, ev.Total, color(colorReset),
+		color(colorBold), ev.Name, color(colorReset), ev.File)
+	fmt.Fprintln(p.w, strings.Repeat("─", 60))
+}
+
+func (p *terminalPrinter) NestedFlowStart(depth int, desc string) {
+	// Base indent (4 spaces) + 2 spaces per depth level
+	indent := strings.Repeat("  ", 2+depth)
+	fmt.Fprintf(p.w, "%s%s▸%s %s\n", indent, color(colorCyan), color(colorReset), desc)
 }
 
-func onStepComplete(idx int, desc string, passed bool, durationMs int64, errMsg string) {
+func (p *terminalPrinter) StepResult(ev executor.StepEvent) {
+	if ev.Depth > 0 {
+		p.nestedStep(ev)
+		return
+	}
+
 	// Don't mark runFlow/repeat/retry as slow - they contain multiple steps
-	isCompoundStep := strings.HasPrefix(desc, "runFlow:") ||
-		strings.HasPrefix(desc, "repeat:") ||
-		strings.HasPrefix(desc, "retry:")
-	isSlow := durationMs >= slowThresholdMs && !isCompoundStep
-	durStr := formatDuration(durationMs)
+	isCompoundStep := strings.HasPrefix(ev.Description, "runFlow:") ||
+		strings.HasPrefix(ev.Description, "repeat:") ||
+		strings.HasPrefix(ev.Description, "retry:")
+	isSlow := ev.DurationMs >= slowThresholdMs && !isCompoundStep
+	durStr := formatDuration(ev.DurationMs)
 
-	if passed {
+	if ev.Success {
 		symbol := "✓"
 		symbolColor := color(colorGreen)
 		durColor := ""
@@ -1209,29 +1531,23 @@ func onStepComplete(idx int, desc string, passed bool, durationMs int64, errMsg
 			symbol = "⚠"
 			symbolColor = color(colorYellow)
 		}
-		fmt.Printf("    %s%s%s %s %s(%s)%s\n",
-			symbolColor, symbol, color(colorReset), desc, durColor, durStr, color(colorReset))
+		fmt.Fprintf(p.w, "    %s%s%s %s %s(%s)%s\n",
+			symbolColor, symbol, color(colorReset), ev.Description, durColor, durStr, color(colorReset))
 	} else {
-		fmt.Printf("    %s✗%s %s (%s)\n", color(colorRed), color(colorReset), desc, durStr)
-		if errMsg != "" {
-			fmt.Printf("      %s╰─%s %s\n", color(colorGray), color(colorReset), errMsg)
+		fmt.Fprintf(p.w, "    %s✗%s %s (%s)\n", color(colorRed), color(colorReset), ev.Description, durStr)
+		if ev.Error != "" {
+			fmt.Fprintf(p.w, "      %s╰─%s %s\n", color(colorGray), color(colorReset), ev.Error)
 		}
 	}
 }
 
-func onNestedFlowStart(depth int, desc string) {
-	// Base indent (4 spaces) + 2 spaces per depth level
-	indent := strings.Repeat("  ", 2+depth)
-	fmt.Printf("%s%s▸%s %s\n", indent, color(colorCyan), color(colorReset), desc)
-}
-
-func onNestedStep(depth int, desc string, passed bool, durationMs int64, errMsg string) {
+func (p *terminalPrinter) nestedStep(ev executor.StepEvent) {
 	// Base indent (4 spaces) + 2 spaces per depth level + 2 more for being inside the flow
-	indent := strings.Repeat("  ", 2+depth+1)
-	isSlow := durationMs >= slowThresholdMs
-	durStr := formatDuration(durationMs)
+	indent := strings.Repeat("  ", 2+ev.Depth+1)
+	isSlow := ev.DurationMs >= slowThresholdMs
+	durStr := formatDuration(ev.DurationMs)
 
-	if passed {
+	if ev.Success {
 		symbol := "✓"
 		symbolColor := color(colorGreen)
 		durColor := ""
@@ -1240,27 +1556,72 @@ func onNestedStep(depth int, desc string, passed bool, durationMs int64, errMsg
 			symbol = "⚠"
 			symbolColor = color(colorYellow)
 		}
-		fmt.Printf("%s%s%s%s %s %s(%s)%s\n",
-			indent, symbolColor, symbol, color(colorReset), desc, durColor, durStr, color(colorReset))
+		fmt.Fprintf(p.w, "%s%s%s%s %s %s(%s)%s\n",
+			indent, symbolColor, symbol, color(colorReset), ev.Description, durColor, durStr, color(colorReset))
 	} else {
-		fmt.Printf("%s%s✗%s %s (%s)\n", indent, color(colorRed), color(colorReset), desc, durStr)
-		if errMsg != "" {
-			fmt.Printf("%s  %s╰─%s %s\n", indent, color(colorGray), color(colorReset), errMsg)
+		fmt.Fprintf(p.w, "%s%s✗%s %s (%s)\n", indent, color(colorRed), color(colorReset), ev.Description, durStr)
+		if ev.Error != "" {
+			fmt.Fprintf(p.w, "%s  %s╰─%s %s\n", indent, color(colorGray), color(colorReset), ev.Error)
 		}
 	}
 }
 
-func onFlowEnd(name string, passed bool, durationMs int64, errMsg string) {
-	if passed {
-		fmt.Printf("%s✓ %s%s %s%s%s\n",
-			color(colorGreen), color(colorReset), name, color(colorGray), formatDuration(durationMs), color(colorReset))
+func (p *terminalPrinter) FlowEnd(ev executor.FlowEvent) {
+	if ev.Passed {
+		fmt.Fprintf(p.w, "%s✓ %s%s %s%s%s\n",
+			color(colorGreen), color(colorReset), ev.Name, color(colorGray), formatDuration(ev.DurationMs), color(colorReset))
 	} else {
-		fmt.Printf("%s✗ %s%s %s%s%s\n",
-			color(colorRed), color(colorReset), name, color(colorGray), formatDuration(durationMs), color(colorReset))
+		fmt.Fprintf(p.w, "%s✗ %s%s %s%s%s\n",
+			color(colorRed), color(colorReset), ev.Name, color(colorGray), formatDuration(ev.DurationMs), color(colorReset))
 	}
 }
 
-func printSummary(result *executor.RunResult) {
+// parallelPrinter is the ResultSink for live progress in parallel runs: one
+// line per flow start and end, labelled with the device. Steps are left to
+// the summary.
+type parallelPrinter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (p *parallelPrinter) FlowStart(ev executor.FlowEvent) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	fmt.Fprintf(p.w, "[%d/%d] %s (%s) - %s⚡ Started%s on %s\n",
+		ev.Index+1, ev.Total, ev.Name, ev.File, color(colorCyan), color(colorReset), deviceName(ev.Device))
+}
+
+func (p *parallelPrinter) NestedFlowStart(depth int, desc string) {}
+
+func (p *parallelPrinter) StepResult(ev executor.StepEvent) {}
+
+func (p *parallelPrinter) FlowEnd(ev executor.FlowEvent) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	status := "✓ Passed"
+	statusColor := color(colorGreen)
+	if !ev.Passed {
+		status = "✗ Failed"
+		statusColor = color(colorRed)
+	}
+	fmt.Fprintf(p.w, "[%d/%d] %s (%s) - %s%s%s on %s (%s)\n",
+		ev.Index+1, ev.Total, ev.Name, ev.File,
+		statusColor, status, color(colorReset), deviceName(ev.Device), formatDuration(ev.DurationMs))
+	if !ev.Passed && ev.Error != "" {
+		fmt.Fprintf(p.w, "  Error: %s\n", ev.Error)
+	}
+}
+
+// deviceName returns the short device label used in parallel progress lines.
+func deviceName(device *report.Device) string {
+	if device == nil {
+		return "Unknown"
+	}
+	return device.Name
+}
+
+func printSummary(w io.Writer, result *executor.RunResult) {
 	// Calculate totals
 	totalSteps := 0
 	passedSteps := 0
@@ -1274,23 +1635,23 @@ func printSummary(result *executor.RunResult) {
 	}
 
 	// Print step summary
-	fmt.Println()
+	fmt.Fprintln(w)
 	if passedSteps > 0 {
-		fmt.Printf("  %s%d steps passing%s (%s)\n", color(colorGreen), passedSteps, color(colorReset), formatDuration(result.Duration))
+		fmt.Fprintf(w, "  %s%d steps passing%s (%s)\n", color(colorGreen), passedSteps, color(colorReset), formatDuration(result.Duration))
 	}
 	if failedSteps > 0 {
-		fmt.Printf("  %s%d steps failing%s\n", color(colorRed), failedSteps, color(colorReset))
+		fmt.Fprintf(w, "  %s%d steps failing%s\n", color(colorRed), failedSteps, color(colorReset))
 	}
 	if skippedSteps > 0 {
-		fmt.Printf("  %s%d steps skipped%s\n", color(colorCyan), skippedSteps, color(colorReset))
+		fmt.Fprintf(w, "  %s%d steps skipped%s\n", color(colorCyan), skippedSteps, color(colorReset))
 	}
-	fmt.Println()
+	fmt.Fprintln(w)
 
 	// Print table
 	tableWidth := 92
-	fmt.Println(strings.Repeat("═", tableWidth))
-	fmt.Printf("  %-42s %6s %7s %6s %6s %6s %10s\n", "Flow", "Status", "Steps", "Pass", "Fail", "Skip", "Duration")
-	fmt.Println(strings.Repeat("─", tableWidth))
+	fmt.Fprintln(w, strings.Repeat("═", tableWidth))
+	fmt.Fprintf(w, "  %-42s %6s %7s %6s %6s %6s %10s\n", "Flow", "Status", "Steps", "Pass", "Fail", "Skip", "Duration")
+	fmt.Fprintln(w, strings.Repeat("─", tableWidth))
 
 	// Print each flow result
 	for _, fr := range result.FlowResults {
@@ -1313,25 +1674,25 @@ func printSummary(result *executor.RunResult) {
 			name = name[:39] + "..."
 		}
 
-		fmt.Printf("  %-42s %s%6s%s %7d %6d %6d %6d %10s\n",
+		fmt.Fprintf(w, "  %-42s %s%6s%s %7d %6d %6d %6d %10s\n",
 			name, statusColor, status, color(colorReset),
 			fr.StepsTotal, fr.StepsPassed, fr.StepsFailed, fr.StepsSkipped,
 			formatDuration(fr.Duration))
 	}
 
 	// Print totals row
-	fmt.Println(strings.Repeat("─", tableWidth))
+	fmt.Fprintln(w, strings.Repeat("─", tableWidth))
 	statusStr := fmt.Sprintf("%d/%d", result.PassedFlows, result.TotalFlows)
 	statusColor := color(colorGreen)
 	if result.FailedFlows > 0 {
 		statusColor = color(colorRed)
 	}
-	fmt.Printf("  %s%-42s%s %s%6s%s %7d %6d %6d %6d %10s\n",
+	fmt.Fprintf(w, "  %s%-42s%s %s%6s%s %7d %6d %6d %6d %10s\n",
 		color(colorBold), "TOTAL", color(colorReset),
 		statusColor, statusStr, color(colorReset),
 		totalSteps, passedSteps, failedSteps, skippedSteps,
 		formatDuration(result.Duration))
-	fmt.Println(strings.Repeat("═", tableWidth))
+	fmt.Fprintln(w, strings.Repeat("═", tableWidth))
 }
 
 // formatDuration formats milliseconds to a human-readable string.
@@ -1359,7 +1720,17 @@ func parseEnvVars(envs []string) map[string]string {
 	return result
 }
 
-// loadCapabilities loads Appium capabilities from a JSON file.
+// artifactMode returns when the executor captures step artifacts.
+func (cfg *RunConfig) artifactMode() executor.ArtifactMode {
+	if cfg.ScreenshotOnFailure {
+		return executor.ArtifactOnFailure
+	}
+	return executor.ArtifactNever
+}
+
+// loadCapabilities loads Appium capabilities from a JSON file. Every key is
+// kept and sent to Appium as written; numbers stay json.Number so large
+// integers and their formatting aren't changed by a float64 round trip.
 func loadCapabilities(capsFile string) (map[string]interface{}, error) {
 	data, err := os.ReadFile(capsFile)
 	if err != nil {
@@ -1367,9 +1738,16 @@ func loadCapabilities(capsFile string) (map[string]interface{}, error) {
 	}
 
 	var caps map[string]interface{}
-	if err := json.Unmarshal(data, &caps); err != nil {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&caps); err != nil {
 		return nil, fmt.Errorf("failed to parse caps JSON: %w", err)
 	}
+	// Decode stops after the first value; reject anything after it, as
+	// json.Unmarshal would
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, fmt.Errorf("failed to parse caps JSON: unexpected data after the top-level object")
+	}
 	return caps, nil
 }
 
@@ -1395,19 +1773,23 @@ func executeAppiumSingleSession(cfg *RunConfig, flows []flow.Flow) (*executor.Ru
 	runner := executor.New(driver, executor.RunnerConfig{
 		OutputDir:          cfg.OutputDir,
 		Parallelism:        0,
-		Artifacts:          executor.ArtifactOnFailure,
+		Artifacts:          cfg.artifactMode(),
 		Device:             deviceInfo,
 		App:                buildAppReport(driver),
 		RunnerVersion:      Version,
 		DriverName:         "appium",
 		Env:                cfg.Env,
+		Vars:               cfg.Vars,
+		IncludeTags:        cfg.IncludeTags,
+		ExcludeTags:        cfg.ExcludeTags,
 		WaitForIdleTimeout: cfg.WaitForIdleTimeout,
+		TypeDelayMs:        cfg.TypeDelayMs,
+		RecordOnFailure:    cfg.RecordOnFailure,
+		LogcatOnFailure:    cfg.LogcatOnFailure,
+		LogcatTag:          cfg.LogcatTag,
 		DeviceInfo:         &deviceInfo,
-		OnFlowStart:        onFlowStart,
-		OnStepComplete:     onStepComplete,
-		OnNestedStep:       onNestedStep,
-		OnNestedFlowStart:  onNestedFlowStart,
-		OnFlowEnd:          onFlowEnd,
+		Sink:               cfg.resultSink(false),
+		ScriptOutput:       cfg.output(),
 	})
 
 	return runner.Run(context.Background(), flows)
@@ -1440,21 +1822,26 @@ func CreateDriver(cfg *RunConfig) (core.Driver, func(), error) {
 }
 
 // printSetupStep prints a setup step with spinner-style prefix
-func printSetupStep(msg string) {
-	fmt.Printf("  %s⏳%s %s\n", color(colorCyan), color(colorReset), msg)
+func printSetupStep(w io.Writer, msg string) {
+	fmt.Fprintf(w, "  %s⏳%s %s\n", color(colorCyan), color(colorReset), msg)
 }
 
 // printSetupSuccess prints a success message for setup
-func printSetupSuccess(msg string) {
-	fmt.Printf("  %s✓%s %s\n", color(colorGreen), color(colorReset), msg)
+func printSetupSuccess(w io.Writer, msg string) {
+	fmt.Fprintf(w, "  %s✓%s %s\n", color(colorGreen), color(colorReset), msg)
 }
 
-// createAppiumDriver creates a driver that connects to an external Appium server.
-// Uses capabilities from --caps file, with CLI flags taking precedence.
-func createAppiumDriver(cfg *RunConfig) (core.Driver, func(), error) {
-	printSetupStep(fmt.Sprintf("Connecting to Appium server: %s", cfg.AppiumURL))
-	logger.Info("Creating Appium driver, server URL: %s", cfg.AppiumURL)
+// --caps-mode values.
+const (
+	capsModeMerge   = "merge"   // caps file keys override the defaults, other defaults are kept
+	capsModeReplace = "replace" // only the caps file, plus the capabilities Appium requires
+)
 
+// appiumCapabilities assembles the session capabilities: the --caps file,
+// then the --platform, --device and --app flags, then defaults for whatever
+// is still missing. In replace mode the only defaults added are
+// platformName and appium:automationName, which Appium needs to pick a driver.
+func appiumCapabilities(cfg *RunConfig) map[string]interface{} {
 	// Start with capabilities from file (or empty map)
 	caps := cfg.Capabilities
 	if caps == nil {
@@ -1480,7 +1867,14 @@ func createAppiumDriver(cfg *RunConfig) (core.Driver, func(), error) {
 	}
 	if caps["appium:automationName"] == nil {
 		caps["appium:automationName"] = "UiAutomator2"
+		if p, ok := caps["platformName"].(string); ok && strings.EqualFold(p, "ios") {
+			caps["appium:automationName"] = "XCUITest"
+		}
 	}
+	if cfg.CapsMode == capsModeReplace {
+		return caps
+	}
+
 	// Auto-grant permissions by default (user can override with false in caps file)
 	if caps["appium:autoGrantPermissions"] == nil {
 		caps["appium:autoGrantPermissions"] = true
@@ -1496,8 +1890,20 @@ func createAppiumDriver(cfg *RunConfig) (core.Driver, func(), error) {
 	if settings, ok := caps["appium:settings"].(map[string]interface{}); ok {
 		settings["waitForIdleTimeout"] = cfg.WaitForIdleTimeout
 	}
+	return caps
+}
+
+// createAppiumDriver creates a driver that connects to an external Appium server.
+// Uses capabilities from --caps file, with CLI flags taking precedence.
+func createAppiumDriver(cfg *RunConfig) (core.Driver, func(), error) {
+	w := cfg.output()
 
-	printSetupStep("Creating Appium session...")
+	printSetupStep(w, fmt.Sprintf("Connecting to Appium server: %s", cfg.AppiumURL))
+	logger.Info("Creating Appium driver, server URL: %s", cfg.AppiumURL)
+
+	caps := appiumCapabilities(cfg)
+
+	printSetupStep(w, "Creating Appium session...")
 	logger.Info("Creating Appium session with capabilities: %v", caps)
 	driver, err := appiumdriver.NewDriver(cfg.AppiumURL, caps)
 	if err != nil {
@@ -1505,7 +1911,7 @@ func createAppiumDriver(cfg *RunConfig) (core.Driver, func(), error) {
 		return nil, nil, fmt.Errorf("create Appium session: %w", err)
 	}
 	logger.Info("Appium session created successfully: %s", driver.GetPlatformInfo().DeviceID)
-	printSetupSuccess("Appium session created")
+	printSetupSuccess(w, "Appium session created")
 
 	// Cleanup function
 	cleanup := func() {
@@ -1793,7 +2199,7 @@ func executeParallel(cfg *RunConfig, deviceIDs []string, flows []flow.Flow) (*ex
 	}
 
 	// 1. Validate devices
-	if err := validateDevicesAvailable(deviceIDs, platform); err != nil {
+	if err := validateDevicesAvailable(cfg.output(), deviceIDs, platform); err != nil {
 		return nil, err
 	}
 
@@ -1820,12 +2226,15 @@ func executeParallel(cfg *RunConfig, deviceIDs []string, flows []flow.Flow) (*ex
 
 	// 3. Run parallel
 	parallelRunner := createParallelRunner(cfg, workers, platform)
+	if cfg.ShardAll {
+		return parallelRunner.RunOnAllDevices(context.Background(), flows)
+	}
 	return parallelRunner.Run(context.Background(), flows)
 }
 
 // validateDevicesAvailable checks all devices before starting initialization.
-func validateDevicesAvailable(deviceIDs []string, platform string) error {
-	printSetupStep(fmt.Sprintf("Checking availability of %d device(s)...", len(deviceIDs)))
+func validateDevicesAvailable(w io.Writer, deviceIDs []string, platform string) error {
+	printSetupStep(w, fmt.Sprintf("Checking availability of %d device(s)...", len(deviceIDs)))
 
 	var unavailableDevices []string
 	for i, deviceID := range deviceIDs {
@@ -1840,12 +2249,14 @@ func validateDevicesAvailable(deviceIDs []string, platform string) error {
 			len(unavailableDevices), strings.Join(unavailableDevices, "\n"))
 	}
 
-	printSetupSuccess(fmt.Sprintf("All %d device(s) available", len(deviceIDs)))
+	printSetupSuccess(w, fmt.Sprintf("All %d device(s) available", len(deviceIDs)))
 	return nil
 }
 
 // createDeviceWorkers creates a worker for each device.
 func createDeviceWorkers(cfg *RunConfig, deviceIDs []string, platform string) ([]executor.DeviceWorker, error) {
+	w := cfg.output()
+
 	var workers []executor.DeviceWorker
 	var cleanups []func()
 
@@ -1856,7 +2267,7 @@ func createDeviceWorkers(cfg *RunConfig, deviceIDs []string, platform string) ([
 	}
 
 	for i, deviceID := range deviceIDs {
-		printSetupStep(fmt.Sprintf("[Device %d/%d] Connecting to %s...", i+1, len(deviceIDs), deviceID))
+		printSetupStep(w, fmt.Sprintf("[Device %d/%d] Connecting to %s...", i+1, len(deviceIDs), deviceID))
 
 		deviceCfg := *cfg
 		deviceCfg.Devices = []string{deviceID}
@@ -1900,14 +2311,22 @@ func createParallelRunner(cfg *RunConfig, workers []executor.DeviceWorker, platf
 	runnerConfig := executor.RunnerConfig{
 		OutputDir:          cfg.OutputDir,
 		Parallelism:        0,
-		Artifacts:          executor.ArtifactOnFailure,
+		Artifacts:          cfg.artifactMode(),
 		Device:             deviceInfo,
 		App:                buildAppReport(firstDriver),
 		RunnerVersion:      Version,
 		DriverName:         driverName,
 		Env:                cfg.Env,
+		Vars:               cfg.Vars,
+		IncludeTags:        cfg.IncludeTags,
+		ExcludeTags:        cfg.ExcludeTags,
 		WaitForIdleTimeout: cfg.WaitForIdleTimeout,
-		// Callbacks will be set per-worker in parallel.go with device info
+		TypeDelayMs:        cfg.TypeDelayMs,
+		RecordOnFailure:    cfg.RecordOnFailure,
+		LogcatOnFailure:    cfg.LogcatOnFailure,
+		LogcatTag:          cfg.LogcatTag,
+		Sink:               cfg.resultSink(true),
+		ScriptOutput:       cfg.output(),
 	}
 
 	return executor.NewParallelRunner(workers, runnerConfig)
@@ -3,6 +3,7 @@ package cli
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -13,26 +14,26 @@ import (
 
 // printUnifiedOutput prints detailed results, summary table, and device summary.
 // This unified format works for both single device and parallel execution.
-func printUnifiedOutput(outputDir string, result *executor.RunResult) error {
+func printUnifiedOutput(w io.Writer, outputDir string, result *executor.RunResult) error {
 	// Load report index to get device info
 	reportIndex, err := loadReportIndex(filepath.Join(outputDir, "report.json"))
 	if err != nil {
 		// Fallback to old summary if we can't load report
-		fmt.Printf("Warning: Could not load report for unified output: %v\n", err)
-		printSummary(result)
+		fmt.Fprintf(w, "Warning: Could not load report for unified output: %v\n", err)
+		printSummary(w, result)
 		return nil
 	}
 
 	// 1. Print detailed flow-by-flow results with device info
-	if err := printDetailedFlowResults(outputDir, reportIndex); err != nil {
-		fmt.Printf("Warning: Could not print detailed results: %v\n", err)
+	if err := printDetailedFlowResults(w, outputDir, reportIndex); err != nil {
+		fmt.Fprintf(w, "Warning: Could not print detailed results: %v\n", err)
 	}
 
 	// 2. Print summary table with device column
-	printUnifiedSummaryTable(reportIndex, result)
+	printUnifiedSummaryTable(w, reportIndex, result)
 
 	// 3. Print device summary stats
-	printDeviceSummary(reportIndex)
+	printDeviceSummary(w, reportIndex)
 
 	return nil
 }
@@ -80,25 +81,25 @@ func formatDeviceLabel(device *report.Device) string {
 }
 
 // printDetailedFlowResults prints flow-by-flow results with all commands.
-func printDetailedFlowResults(outputDir string, reportIndex *report.Index) error {
+func printDetailedFlowResults(w io.Writer, outputDir string, reportIndex *report.Index) error {
 	for i, flowEntry := range reportIndex.Flows {
 		// Print flow header with device info
 		deviceLabel := formatDeviceLabel(flowEntry.Device)
-		fmt.Printf("\n  %s[%d/%d]%s %s%s%s (%s) - Device: %s\n",
+		fmt.Fprintf(w, "\n  %s[%d/%d]%s %s%s%s (%s) - Device: %s\n",
 			color(colorCyan), i+1, len(reportIndex.Flows), color(colorReset),
 			color(colorBold), flowEntry.Name, color(colorReset),
 			flowEntry.SourceFile, deviceLabel)
-		fmt.Println("  " + strings.Repeat("─", 60))
+		fmt.Fprintln(w, "  "+strings.Repeat("─", 60))
 
 		// Load flow detail to get commands
 		flowDetailPath := filepath.Join(outputDir, flowEntry.DataFile)
 		flowDetail, err := loadFlowDetail(flowDetailPath)
 		if err != nil {
-			fmt.Printf("    (Could not load command details: %v)\n", err)
+			fmt.Fprintf(w, "    (Could not load command details: %v)\n", err)
 		} else {
 			// Print each command
 			for _, cmd := range flowDetail.Commands {
-				printCommand(cmd, 0)
+				printCommand(w, cmd, 0)
 			}
 		}
 
@@ -109,11 +110,11 @@ func printDetailedFlowResults(outputDir string, reportIndex *report.Index) error
 		}
 
 		if flowEntry.Status == report.StatusPassed {
-			fmt.Printf("%s✓ %s%s %s%s%s\n",
+			fmt.Fprintf(w, "%s✓ %s%s %s%s%s\n",
 				color(colorGreen), color(colorReset), flowEntry.Name,
 				color(colorGray), formatDuration(duration), color(colorReset))
 		} else if flowEntry.Status == report.StatusFailed {
-			fmt.Printf("%s✗ %s%s %s%s%s\n",
+			fmt.Fprintf(w, "%s✗ %s%s %s%s%s\n",
 				color(colorRed), color(colorReset), flowEntry.Name,
 				color(colorGray), formatDuration(duration), color(colorReset))
 		}
@@ -123,7 +124,7 @@ func printDetailedFlowResults(outputDir string, reportIndex *report.Index) error
 }
 
 // printCommand prints a single command with proper indentation.
-func printCommand(cmd report.Command, depth int) {
+func printCommand(w io.Writer, cmd report.Command, depth int) {
 	indent := strings.Repeat("  ", 2+depth) // Base indent of 2, plus depth
 
 	// Get command description (prefer Label, fallback to Type)
@@ -154,22 +155,22 @@ func printCommand(cmd report.Command, depth int) {
 			symbol = "⚠"
 			symbolColor = color(colorYellow)
 		}
-		fmt.Printf("%s%s%s%s %s %s(%s)%s\n",
+		fmt.Fprintf(w, "%s%s%s%s %s %s(%s)%s\n",
 			indent, symbolColor, symbol, color(colorReset),
 			description, durColor, formatDuration(duration), color(colorReset))
 	} else {
-		fmt.Printf("%s%s✗%s %s (%s)\n",
+		fmt.Fprintf(w, "%s%s✗%s %s (%s)\n",
 			indent, color(colorRed), color(colorReset),
 			description, formatDuration(duration))
 		if cmd.Error != nil && cmd.Error.Message != "" {
-			fmt.Printf("%s  %s╰─%s %s\n",
+			fmt.Fprintf(w, "%s  %s╰─%s %s\n",
 				indent, color(colorGray), color(colorReset), cmd.Error.Message)
 		}
 	}
 
 	// Print sub-commands (for runFlow, repeat, retry)
 	for _, subCmd := range cmd.SubCommands {
-		printCommand(subCmd, depth+1)
+		printCommand(w, subCmd, depth+1)
 	}
 }
 
@@ -181,7 +182,7 @@ func isCompoundCommand(desc string) bool {
 }
 
 // printUnifiedSummaryTable prints the summary table with device column.
-func printUnifiedSummaryTable(reportIndex *report.Index, result *executor.RunResult) {
+func printUnifiedSummaryTable(w io.Writer, reportIndex *report.Index, result *executor.RunResult) {
 	// Calculate totals
 	totalSteps := 0
 	passedSteps := 0
@@ -195,25 +196,25 @@ func printUnifiedSummaryTable(reportIndex *report.Index, result *executor.RunRes
 	}
 
 	// Print step summary
-	fmt.Println()
+	fmt.Fprintln(w)
 	if passedSteps > 0 {
-		fmt.Printf("  %s%d steps passing%s (%s)\n",
+		fmt.Fprintf(w, "  %s%d steps passing%s (%s)\n",
 			color(colorGreen), passedSteps, color(colorReset), formatDuration(result.Duration))
 	}
 	if failedSteps > 0 {
-		fmt.Printf("  %s%d steps failing%s\n", color(colorRed), failedSteps, color(colorReset))
+		fmt.Fprintf(w, "  %s%d steps failing%s\n", color(colorRed), failedSteps, color(colorReset))
 	}
 	if skippedSteps > 0 {
-		fmt.Printf("  %s%d steps skipped%s\n", color(colorCyan), skippedSteps, color(colorReset))
+		fmt.Fprintf(w, "  %s%d steps skipped%s\n", color(colorCyan), skippedSteps, color(colorReset))
 	}
-	fmt.Println()
+	fmt.Fprintln(w)
 
 	// Print table header with Device column
 	tableWidth := 116 // Increased width for device column
-	fmt.Println(strings.Repeat("═", tableWidth))
-	fmt.Printf("  %-30s %6s %7s %6s %6s %6s %10s  %s\n",
+	fmt.Fprintln(w, strings.Repeat("═", tableWidth))
+	fmt.Fprintf(w, "  %-30s %6s %7s %6s %6s %6s %10s  %s\n",
 		"Flow", "Status", "Steps", "Pass", "Fail", "Skip", "Duration", "Device")
-	fmt.Println(strings.Repeat("─", tableWidth))
+	fmt.Fprintln(w, strings.Repeat("─", tableWidth))
 
 	// Print each flow result with device info
 	for _, flowEntry := range reportIndex.Flows {
@@ -255,25 +256,25 @@ func printUnifiedSummaryTable(reportIndex *report.Index, result *executor.RunRes
 			deviceLabel = deviceLabel[:27] + "..."
 		}
 
-		fmt.Printf("  %-30s %s%6s%s %7d %6d %6d %6d %10s  %s\n",
+		fmt.Fprintf(w, "  %-30s %s%6s%s %7d %6d %6d %6d %10s  %s\n",
 			name, statusColor, status, color(colorReset),
 			fr.StepsTotal, fr.StepsPassed, fr.StepsFailed, fr.StepsSkipped,
 			formatDuration(fr.Duration), deviceLabel)
 	}
 
 	// Print totals row
-	fmt.Println(strings.Repeat("─", tableWidth))
+	fmt.Fprintln(w, strings.Repeat("─", tableWidth))
 	statusStr := fmt.Sprintf("%d/%d", result.PassedFlows, result.TotalFlows)
 	statusColor := color(colorGreen)
 	if result.FailedFlows > 0 {
 		statusColor = color(colorRed)
 	}
-	fmt.Printf("  %s%-30s%s %s%6s%s %7d %6d %6d %6d %10s\n",
+	fmt.Fprintf(w, "  %s%-30s%s %s%6s%s %7d %6d %6d %6d %10s\n",
 		color(colorBold), "TOTAL", color(colorReset),
 		statusColor, statusStr, color(colorReset),
 		totalSteps, passedSteps, failedSteps, skippedSteps,
 		formatDuration(result.Duration))
-	fmt.Println(strings.Repeat("═", tableWidth))
+	fmt.Fprintln(w, strings.Repeat("═", tableWidth))
 }
 
 // groupFlowsByDevice groups flows by their device ID.
@@ -291,7 +292,7 @@ func groupFlowsByDevice(flows []report.FlowEntry) map[string][]report.FlowEntry
 }
 
 // printDeviceSummary prints per-device statistics.
-func printDeviceSummary(reportIndex *report.Index) {
+func printDeviceSummary(w io.Writer, reportIndex *report.Index) {
 	// Group flows by device
 	deviceFlows := groupFlowsByDevice(reportIndex.Flows)
 
@@ -299,8 +300,8 @@ func printDeviceSummary(reportIndex *report.Index) {
 		return
 	}
 
-	fmt.Println("\n\nDevice Summary")
-	fmt.Println(strings.Repeat("─", 60))
+	fmt.Fprintln(w, "\n\nDevice Summary")
+	fmt.Fprintln(w, strings.Repeat("─", 60))
 
 	for _, flows := range deviceFlows {
 		if len(flows) == 0 {
@@ -323,7 +324,7 @@ func printDeviceSummary(reportIndex *report.Index) {
 			}
 		}
 
-		fmt.Printf("\nDevice: %s\n", device.Name)
+		fmt.Fprintf(w, "\nDevice: %s\n", device.Name)
 
 		// Platform info
 		platform := device.Platform
@@ -333,14 +334,14 @@ func printDeviceSummary(reportIndex *report.Index) {
 		if device.IsSimulator {
 			platform += " (Simulator)"
 		}
-		fmt.Printf("  Platform: %s\n", platform)
+		fmt.Fprintf(w, "  Platform: %s\n", platform)
 
 		// Flow stats
-		fmt.Printf("  Flows: %d • Passed: %s%d%s • Failed: %s%d%s\n",
+		fmt.Fprintf(w, "  Flows: %d • Passed: %s%d%s • Failed: %s%d%s\n",
 			len(flows),
 			color(colorGreen), passed, color(colorReset),
 			color(colorRed), failed, color(colorReset))
 	}
 
-	fmt.Println()
+	fmt.Fprintln(w)
 }
@@ -3,6 +3,7 @@ package cli
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"time"
 )
@@ -58,11 +59,11 @@ func startUpdateCheck() {
 }
 
 // printUpdateNotice prints the update message if one is available.
-func printUpdateNotice() {
+func printUpdateNotice(w io.Writer) {
 	select {
 	case msg := <-updateNotice:
 		if msg != "" {
-			fmt.Print(msg)
+			fmt.Fprint(w, msg)
 		}
 	default:
 		// Check not finished yet, don't block
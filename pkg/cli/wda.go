@@ -121,7 +121,7 @@ func updateWDAToLatest() error {
 		fmt.Printf("Updating from v%s to v%s...\n", local, latest)
 	}
 
-	if err := wdadriver.UpdateWDA(); err != nil {
+	if err := wdadriver.UpdateWDA(os.Stdout); err != nil {
 		return err
 	}
 
@@ -142,7 +142,7 @@ func updateWDAToVersion(version string) error {
 		fmt.Printf("Installing WebDriverAgent v%s...\n", version)
 	}
 
-	if err := wdadriver.DownloadWDA(version); err != nil {
+	if err := wdadriver.DownloadWDA(version, os.Stdout); err != nil {
 		return err
 	}
 
@@ -0,0 +1,27 @@
+package config
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LoadVars loads a JSON or YAML file holding a map of variables (base URLs,
+// test accounts, ...). Values may be nested objects and lists.
+func LoadVars(path string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(path) //#nosec G304 -- user-provided vars file
+	if err != nil {
+		return nil, err
+	}
+
+	// YAML is a superset of JSON, so one decoder reads both
+	var vars map[string]interface{}
+	if err := yaml.Unmarshal(data, &vars); err != nil {
+		return nil, fmt.Errorf("%s: expected a JSON or YAML map: %w", path, err)
+	}
+	if vars == nil {
+		vars = map[string]interface{}{}
+	}
+	return vars, nil
+}
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeVarsFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadVars_YAML(t *testing.T) {
+	path := writeVarsFile(t, "vars.yaml", `
+BASE_URL: https://staging.example.com
+api:
+  url: https://api.staging.example.com
+  retries: 3
+accounts:
+  - alice
+  - bob
+`)
+
+	vars, err := LoadVars(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if vars["BASE_URL"] != "https://staging.example.com" {
+		t.Errorf("expected BASE_URL, got %v", vars["BASE_URL"])
+	}
+	api, ok := vars["api"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected api to be a map, got %T", vars["api"])
+	}
+	if api["url"] != "https://api.staging.example.com" || api["retries"] != 3 {
+		t.Errorf("unexpected api: %v", api)
+	}
+	if accounts, ok := vars["accounts"].([]interface{}); !ok || len(accounts) != 2 {
+		t.Errorf("expected 2 accounts, got %v", vars["accounts"])
+	}
+}
+
+func TestLoadVars_JSON(t *testing.T) {
+	path := writeVarsFile(t, "vars.json", `{"api": {"url": "https://api.example.com"}, "USER": "qa"}`)
+
+	vars, err := LoadVars(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if vars["USER"] != "qa" {
+		t.Errorf("expected USER=qa, got %v", vars["USER"])
+	}
+	if api, _ := vars["api"].(map[string]interface{}); api["url"] != "https://api.example.com" {
+		t.Errorf("unexpected api: %v", vars["api"])
+	}
+}
+
+func TestLoadVars_Empty(t *testing.T) {
+	vars, err := LoadVars(writeVarsFile(t, "vars.yaml", ""))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if vars == nil || len(vars) != 0 {
+		t.Errorf("expected empty map, got %v", vars)
+	}
+}
+
+func TestLoadVars_NotAMap(t *testing.T) {
+	if _, err := LoadVars(writeVarsFile(t, "vars.yaml", "- a\n- b\n")); err == nil {
+		t.Error("expected error for a list")
+	}
+}
+
+func TestLoadVars_FileNotFound(t *testing.T) {
+	if _, err := LoadVars(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
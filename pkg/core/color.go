@@ -0,0 +1,99 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"strconv"
+	"strings"
+)
+
+// ParseHexColor parses "#RRGGBB" (the leading '#' is optional).
+func ParseHexColor(s string) (color.RGBA, error) {
+	hex := strings.TrimPrefix(strings.TrimSpace(s), "#")
+	if len(hex) != 6 {
+		return color.RGBA{}, fmt.Errorf("invalid color %q: expected #RRGGBB", s)
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return color.RGBA{}, fmt.Errorf("invalid color %q: expected #RRGGBB", s)
+	}
+	return color.RGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 0xff}, nil
+}
+
+// HexColor formats c as "#RRGGBB".
+func HexColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02X%02X%02X", c.R, c.G, c.B)
+}
+
+// ColorDistance returns the largest per-channel difference (0-255) between a and b.
+func ColorDistance(a, b color.RGBA) int {
+	return max(absDiff(a.R, b.R), absDiff(a.G, b.G), absDiff(a.B, b.B))
+}
+
+func absDiff(a, b uint8) int {
+	if a > b {
+		return int(a - b)
+	}
+	return int(b - a)
+}
+
+// colorBucketShift groups channel values into buckets of 8 so anti-aliasing and
+// compression noise count toward the same color.
+const colorBucketShift = 3
+
+// DominantColor decodes a screenshot and returns the most common color in the
+// horizontal band from the top of the image down to bandPercent of its height.
+// Pixels are grouped into coarse buckets; the result is the average of the
+// pixels in the largest bucket.
+func DominantColor(data []byte, bandPercent float64) (color.RGBA, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return color.RGBA{}, fmt.Errorf("decode screenshot: %w", err)
+	}
+	if bandPercent <= 0 || bandPercent > 100 {
+		return color.RGBA{}, fmt.Errorf("invalid region %v%%: must be between 0 and 100", bandPercent)
+	}
+
+	bounds := img.Bounds()
+	bottom := bounds.Min.Y + int(float64(bounds.Dy())*bandPercent/100)
+	if bottom <= bounds.Min.Y {
+		bottom = bounds.Min.Y + 1
+	}
+
+	type bucket struct {
+		count   int
+		r, g, b int
+	}
+	buckets := make(map[uint32]*bucket)
+	var best *bucket
+	for y := bounds.Min.Y; y < bottom && y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b, _ := img.At(x, y).RGBA()
+			r8, g8, b8 := int(r>>8), int(g>>8), int(b>>8)
+			key := uint32(r8>>colorBucketShift)<<16 | uint32(g8>>colorBucketShift)<<8 | uint32(b8>>colorBucketShift)
+			bk := buckets[key]
+			if bk == nil {
+				bk = &bucket{}
+				buckets[key] = bk
+			}
+			bk.count++
+			bk.r += r8
+			bk.g += g8
+			bk.b += b8
+			if best == nil || bk.count > best.count {
+				best = bk
+			}
+		}
+	}
+	if best == nil {
+		return color.RGBA{}, fmt.Errorf("screenshot has no pixels")
+	}
+	return color.RGBA{
+		R: uint8(best.r / best.count),
+		G: uint8(best.g / best.count),
+		B: uint8(best.b / best.count),
+		A: 0xff,
+	}, nil
+}
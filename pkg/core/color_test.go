@@ -0,0 +1,110 @@
+package core
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+// appBarFrame draws a 100x200 screen: a thin dark status bar, a bar-colored
+// app bar down to y=24 and a white body.
+func appBarFrame(t *testing.T, bar color.Color) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, 100, 200))
+	for y := 0; y < 200; y++ {
+		for x := 0; x < 100; x++ {
+			switch {
+			case y < 6:
+				img.Set(x, y, color.Black)
+			case y < 24:
+				img.Set(x, y, bar)
+			default:
+				img.Set(x, y, color.White)
+			}
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestParseHexColor(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    color.RGBA
+		wantErr bool
+	}{
+		{"#6200EE", color.RGBA{0x62, 0x00, 0xee, 0xff}, false},
+		{"6200ee", color.RGBA{0x62, 0x00, 0xee, 0xff}, false},
+		{" #FFFFFF ", color.RGBA{0xff, 0xff, 0xff, 0xff}, false},
+		{"#FFF", color.RGBA{}, true},
+		{"#GG0000", color.RGBA{}, true},
+		{"", color.RGBA{}, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseHexColor(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseHexColor(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseHexColor(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestHexColor(t *testing.T) {
+	if got := HexColor(color.RGBA{0x62, 0x00, 0xee, 0xff}); got != "#6200EE" {
+		t.Errorf("HexColor() = %q, want #6200EE", got)
+	}
+}
+
+func TestColorDistance(t *testing.T) {
+	a := color.RGBA{100, 100, 100, 255}
+	b := color.RGBA{90, 104, 100, 255}
+	if got := ColorDistance(a, b); got != 10 {
+		t.Errorf("ColorDistance() = %d, want 10", got)
+	}
+	if got := ColorDistance(a, a); got != 0 {
+		t.Errorf("ColorDistance() = %d, want 0", got)
+	}
+}
+
+func TestDominantColor(t *testing.T) {
+	purple := color.RGBA{0x62, 0x00, 0xee, 0xff}
+	frame := appBarFrame(t, purple)
+
+	got, err := DominantColor(frame, 12)
+	if err != nil {
+		t.Fatalf("DominantColor() error: %v", err)
+	}
+	if got != purple {
+		t.Errorf("DominantColor() = %s, want %s", HexColor(got), HexColor(purple))
+	}
+
+	// A band reaching well into the body is dominated by white
+	got, err = DominantColor(frame, 50)
+	if err != nil {
+		t.Fatalf("DominantColor() error: %v", err)
+	}
+	if got != (color.RGBA{0xff, 0xff, 0xff, 0xff}) {
+		t.Errorf("DominantColor() = %s, want #FFFFFF", HexColor(got))
+	}
+}
+
+func TestDominantColorErrors(t *testing.T) {
+	if _, err := DominantColor([]byte("not an image"), 10); err == nil {
+		t.Error("expected error for undecodable screenshot")
+	}
+	frame := appBarFrame(t, color.Black)
+	for _, band := range []float64{0, -5, 101} {
+		if _, err := DominantColor(frame, band); err == nil {
+			t.Errorf("expected error for region %v", band)
+		}
+	}
+}
@@ -1,7 +1,10 @@
 package core
 
 import (
+	"fmt"
+	"strings"
 	"time"
+	"unicode"
 
 	"github.com/devicelab-dev/maestro-runner/pkg/flow"
 )
@@ -35,6 +38,30 @@ type Driver interface {
 	SetWaitForIdleTimeout(ms int) error
 }
 
+// LogCapturer is implemented by drivers that can collect device logs around
+// a flow (adb logcat on Android). The runner checks for it with a type
+// assertion; drivers without device logs don't implement it.
+type LogCapturer interface {
+	// StartLogCapture marks the point from which DeviceLogs collects.
+	StartLogCapture() error
+
+	// DeviceLogs returns the logs written since StartLogCapture, limited to
+	// tag when set, otherwise to appID's process when it is running.
+	DeviceLogs(appID, tag string) ([]byte, error)
+}
+
+// FrameStatsReader is implemented by drivers that can count the frames an
+// app rendered (dumpsys gfxinfo on Android), for assertNoJank. The runner
+// checks for it with a type assertion and runs the measured commands itself.
+type FrameStatsReader interface {
+	// ResetFrameStats clears appID's frame counters.
+	ResetFrameStats(appID string) error
+
+	// FrameStats returns the frames appID rendered since ResetFrameStats and
+	// how many of them were janky.
+	FrameStats(appID string) (total, janky int, err error)
+}
+
 // CommandResult represents the outcome of executing a single command
 type CommandResult struct {
 	// Core outcome
@@ -89,6 +116,32 @@ func (b Bounds) Contains(x, y int) bool {
 	return x >= b.X && x < b.X+b.Width && y >= b.Y && y < b.Y+b.Height
 }
 
+// Offset returns the point dx, dy pixels from the top-left corner,
+// clamped so it stays within the bounds.
+func (b Bounds) Offset(dx, dy int) (int, int) {
+	return clamp(b.X+dx, b.X, b.X+b.Width-1), clamp(b.Y+dy, b.Y, b.Y+b.Height-1)
+}
+
+func clamp(v, lo, hi int) int {
+	if hi < lo {
+		hi = lo
+	}
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
+
+// Within reports whether b lies entirely inside outer.
+func (b Bounds) Within(outer Bounds) bool {
+	return b.X >= outer.X && b.Y >= outer.Y &&
+		b.X+b.Width <= outer.X+outer.Width &&
+		b.Y+b.Height <= outer.Y+outer.Height
+}
+
 // CenterInside checks if the center of inner bounds is inside outer bounds.
 func (b Bounds) CenterInside(outer Bounds) bool {
 	cx, cy := b.Center()
@@ -96,6 +149,39 @@ func (b Bounds) CenterInside(outer Bounds) bool {
 		cy >= outer.Y && cy <= outer.Y+outer.Height
 }
 
+// Overflow describes how far b extends past a width x height window, e.g.
+// "120px past bottom edge". It returns "" when b lies entirely inside.
+func (b Bounds) Overflow(width, height int) string {
+	var parts []string
+	if b.X < 0 {
+		parts = append(parts, fmt.Sprintf("%dpx past left edge", -b.X))
+	}
+	if b.Y < 0 {
+		parts = append(parts, fmt.Sprintf("%dpx past top edge", -b.Y))
+	}
+	if over := b.X + b.Width - width; over > 0 {
+		parts = append(parts, fmt.Sprintf("%dpx past right edge", over))
+	}
+	if over := b.Y + b.Height - height; over > 0 {
+		parts = append(parts, fmt.Sprintf("%dpx past bottom edge", over))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// VisiblePercent returns how much of b (0-100) lies inside a width x height
+// window. Zero-size bounds are 0% visible.
+func (b Bounds) VisiblePercent(width, height int) int {
+	if b.Width <= 0 || b.Height <= 0 {
+		return 0
+	}
+	w := min(b.X+b.Width, width) - max(b.X, 0)
+	h := min(b.Y+b.Height, height) - max(b.Y, 0)
+	if w <= 0 || h <= 0 {
+		return 0
+	}
+	return w * h * 100 / (b.Width * b.Height)
+}
+
 // HasNonASCII checks if text contains non-ASCII characters.
 func HasNonASCII(text string) bool {
 	for i := 0; i < len(text); i++ {
@@ -106,6 +192,66 @@ func HasNonASCII(text string) bool {
 	return false
 }
 
+// HasEmoji checks if text contains emoji or other supplementary-plane
+// characters, which most keyboards cannot type through plain key input.
+func HasEmoji(text string) bool {
+	for _, r := range text {
+		switch {
+		case r > 0xFFFF: // Supplementary planes (most emoji)
+			return true
+		case r >= 0x2600 && r <= 0x27BF: // Miscellaneous symbols and dingbats
+			return true
+		case r == 0xFE0F || r == 0x200D: // Emoji presentation selector, ZWJ
+			return true
+		}
+	}
+	return false
+}
+
+// Graphemes splits text into user-perceived characters so they can be typed
+// one at a time without separating an emoji from its modifiers. It covers
+// combining marks, variation selectors, skin tones, ZWJ sequences and flag
+// pairs; it is not a full UAX #29 implementation.
+func Graphemes(text string) []string {
+	var clusters []string
+	var current []rune
+	joinNext := false
+	for _, r := range text {
+		extends := len(current) > 0 && (joinNext ||
+			unicode.Is(unicode.Mn, r) || unicode.Is(unicode.Me, r) || unicode.Is(unicode.Mc, r) ||
+			r == 0xFE0E || r == 0xFE0F || r == 0x200D ||
+			(r >= 0x1F3FB && r <= 0x1F3FF) || // skin tone modifiers
+			(isRegionalIndicator(r) && len(current) == 1 && isRegionalIndicator(current[0])))
+		if !extends && len(current) > 0 {
+			clusters = append(clusters, string(current))
+			current = current[:0]
+		}
+		current = append(current, r)
+		joinNext = r == 0x200D
+	}
+	if len(current) > 0 {
+		clusters = append(clusters, string(current))
+	}
+	return clusters
+}
+
+// TypeDelayNote describes a slow-typed input for the command message, counting
+// the graphemes typed one by one. It is empty when no delay was used.
+func TypeDelayNote(text string, delayMs int) string {
+	if delayMs <= 0 {
+		return ""
+	}
+	return fmt.Sprintf(" (typed %d chars with %dms delay)", len(Graphemes(text)), delayMs)
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1F1E6 && r <= 0x1F1FF
+}
+
+// GracefulStopDelay is how long a stopApp with graceful: true leaves the app
+// in the background, so it can save state, before the driver terminates it.
+const GracefulStopDelay = time.Second
+
 // StateSnapshot captures the current device/app state
 type StateSnapshot struct {
 	AppState        string       `json:"appState,omitempty"`        // foreground, background, not_running
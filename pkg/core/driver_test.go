@@ -1,6 +1,7 @@
 package core
 
 import (
+	"reflect"
 	"testing"
 	"time"
 )
@@ -47,6 +48,73 @@ func TestBounds_Contains(t *testing.T) {
 	}
 }
 
+func TestBounds_Overflow(t *testing.T) {
+	tests := []struct {
+		name   string
+		bounds Bounds
+		want   string
+	}{
+		{"inside", Bounds{X: 0, Y: 100, Width: 1080, Height: 200}, ""},
+		{"touching edges", Bounds{X: 0, Y: 0, Width: 1080, Height: 2400}, ""},
+		{"bottom", Bounds{X: 100, Y: 2300, Width: 200, Height: 220}, "120px past bottom edge"},
+		{"left and top", Bounds{X: -10, Y: -5, Width: 100, Height: 100}, "10px past left edge, 5px past top edge"},
+		{"right", Bounds{X: 1000, Y: 10, Width: 100, Height: 10}, "20px past right edge"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.bounds.Overflow(1080, 2400); got != tt.want {
+			t.Errorf("%s: Overflow() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestBounds_VisiblePercent(t *testing.T) {
+	tests := []struct {
+		name   string
+		bounds Bounds
+		want   int
+	}{
+		{"inside", Bounds{X: 0, Y: 100, Width: 1080, Height: 200}, 100},
+		{"half below bottom", Bounds{X: 0, Y: 2300, Width: 100, Height: 200}, 50},
+		{"quarter past corner", Bounds{X: -50, Y: -50, Width: 100, Height: 100}, 25},
+		{"off screen", Bounds{X: 0, Y: 2500, Width: 100, Height: 100}, 0},
+		{"zero size", Bounds{X: 10, Y: 10}, 0},
+	}
+
+	for _, tt := range tests {
+		if got := tt.bounds.VisiblePercent(1080, 2400); got != tt.want {
+			t.Errorf("%s: VisiblePercent() = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestBounds_Offset(t *testing.T) {
+	bounds := Bounds{X: 10, Y: 20, Width: 100, Height: 50}
+
+	tests := []struct {
+		dx, dy       int
+		wantX, wantY int
+	}{
+		{0, 0, 10, 20},    // Origin
+		{12, 8, 22, 28},   // Inside
+		{500, 8, 109, 28}, // Clamped to right edge
+		{-5, 100, 10, 69}, // Clamped to left and bottom edges
+		{99, 49, 109, 69}, // Last pixel
+	}
+
+	for _, tt := range tests {
+		x, y := bounds.Offset(tt.dx, tt.dy)
+		if x != tt.wantX || y != tt.wantY {
+			t.Errorf("Bounds.Offset(%d, %d) = (%d, %d), want (%d, %d)", tt.dx, tt.dy, x, y, tt.wantX, tt.wantY)
+		}
+	}
+
+	// Zero-size bounds collapse to the origin
+	if x, y := (Bounds{X: 5, Y: 5}).Offset(3, 3); x != 5 || y != 5 {
+		t.Errorf("zero-size Offset = (%d, %d), want (5, 5)", x, y)
+	}
+}
+
 func TestCommandResult_Fields(t *testing.T) {
 	result := CommandResult{
 		Success:  true,
@@ -190,3 +258,54 @@ func TestLogEntry_Fields(t *testing.T) {
 		t.Errorf("Message = %s, want 'App crashed'", entry.Message)
 	}
 }
+
+func TestGraphemes(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"", nil},
+		{"abc", []string{"a", "b", "c"}},
+		{"cafe\u0301!", []string{"c", "a", "f", "e\u0301", "!"}},
+		{"a❤️b", []string{"a", "❤️", "b"}},
+		{"👍🏽👩‍💻", []string{"👍🏽", "👩‍💻"}},
+		{"🇫🇷🇩🇪", []string{"🇫🇷", "🇩🇪"}},
+		{"日本", []string{"日", "本"}},
+	}
+
+	for _, tt := range tests {
+		if got := Graphemes(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Graphemes(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestTypeDelayNote(t *testing.T) {
+	if got := TypeDelayNote("abc", 0); got != "" {
+		t.Errorf("TypeDelayNote without delay = %q, want empty", got)
+	}
+	if got, want := TypeDelayNote("hi👍🏽", 50), " (typed 3 chars with 50ms delay)"; got != want {
+		t.Errorf("TypeDelayNote = %q, want %q", got, want)
+	}
+}
+
+func TestHasEmoji(t *testing.T) {
+	tests := []struct {
+		text string
+		want bool
+	}{
+		{"hello", false},
+		{"café", false},
+		{"日本語", false},
+		{"party 🎉", true},
+		{"♥", true},
+		{"❤️", true},
+		{"👩‍💻", true},
+	}
+
+	for _, tt := range tests {
+		if got := HasEmoji(tt.text); got != tt.want {
+			t.Errorf("HasEmoji(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
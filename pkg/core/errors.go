@@ -1,6 +1,7 @@
 package core
 
 import (
+	"errors"
 	"fmt"
 )
 
@@ -26,6 +27,13 @@ func (e *ExecutionError) Unwrap() error {
 	return e.Cause
 }
 
+// Is reports whether target is an ExecutionError with the same code, so
+// copies made by WithCause or WithMessage still match the predefined errors.
+func (e *ExecutionError) Is(target error) bool {
+	t, ok := target.(*ExecutionError)
+	return ok && t.Code != "" && t.Code == e.Code
+}
+
 // WithCause returns a copy of the error with the given cause
 func (e *ExecutionError) WithCause(cause error) *ExecutionError {
 	return &ExecutionError{
@@ -142,8 +150,23 @@ var (
 		Code:     "missing_required",
 		Message:  "missing required field",
 	}
+
+	// Request errors
+	ErrRequestRejected = &ExecutionError{
+		Category: ErrCategoryRequest,
+		Code:     "request_rejected",
+		Message:  "automation server rejected the request",
+	}
 )
 
+// IsRetryable reports whether a step that failed with err may pass when run
+// again. Timeouts, lost connections and unclassified failures (an element
+// that hasn't appeared yet) may; a request the automation server rejected
+// fails the same way every time.
+func IsRetryable(err error) bool {
+	return !errors.Is(err, ErrRequestRejected)
+}
+
 // NewExecutionError creates a new ExecutionError with the given parameters
 func NewExecutionError(category ErrorCategory, code, message string) *ExecutionError {
 	return &ExecutionError{
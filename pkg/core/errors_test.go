@@ -2,6 +2,7 @@ package core
 
 import (
 	"errors"
+	"fmt"
 	"strings"
 	"testing"
 )
@@ -122,6 +123,7 @@ func TestPredefinedErrors(t *testing.T) {
 		{ErrAppNotResponding, ErrCategoryApp, "app_not_responding"},
 		{ErrInvalidConfig, ErrCategoryConfig, "invalid_config"},
 		{ErrMissingRequired, ErrCategoryConfig, "missing_required"},
+		{ErrRequestRejected, ErrCategoryRequest, "request_rejected"},
 	}
 
 	for _, tt := range tests {
@@ -161,3 +163,37 @@ func TestExecutionError_ErrorsIs(t *testing.T) {
 		t.Error("errors.Is() should find the cause")
 	}
 }
+
+func TestExecutionError_ErrorsIsPredefined(t *testing.T) {
+	err := ErrTimeout.WithMessage("request timed out").WithCause(errors.New("deadline"))
+
+	if !errors.Is(err, ErrTimeout) {
+		t.Error("errors.Is() should match the predefined error by code")
+	}
+	if errors.Is(err, ErrServerUnreachable) {
+		t.Error("errors.Is() should not match a different code")
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, true},
+		{"unclassified", errors.New("element not found"), true},
+		{"timeout", ErrTimeout.WithCause(errors.New("deadline")), true},
+		{"unreachable", ErrServerUnreachable.WithCause(errors.New("refused")), true},
+		{"rejected", ErrRequestRejected.WithMessage("HTTP 400"), false},
+		{"wrapped rejected", fmt.Errorf("tap: %w", ErrRequestRejected), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRetryable(tt.err); got != tt.want {
+				t.Errorf("IsRetryable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+
+	// Screenshots arrive as PNG (UIAutomator2, WDA) or JPEG (some Appium setups).
+	_ "image/jpeg"
+	_ "image/png"
+
+	"github.com/devicelab-dev/maestro-runner/pkg/imagediff"
+)
+
+// FrameDiffTolerance is the fraction of pixels two screenshots may differ by
+// and still count as the same frame (blinking cursors, clock ticks).
+const FrameDiffTolerance = 0.005
+
+// FrameDiff returns the fraction of pixels (0 to 1) that differ between two
+// encoded screenshots. Frames of different sizes differ entirely. An error is
+// returned, with a diff of 1, when either frame cannot be decoded.
+func FrameDiff(a, b []byte) (float64, error) {
+	if bytes.Equal(a, b) {
+		return 0, nil
+	}
+	imgA, _, err := image.Decode(bytes.NewReader(a))
+	if err != nil {
+		return 1, fmt.Errorf("decode frame: %w", err)
+	}
+	imgB, _, err := image.Decode(bytes.NewReader(b))
+	if err != nil {
+		return 1, fmt.Errorf("decode frame: %w", err)
+	}
+
+	bounds := imgA.Bounds()
+	if bounds.Size() != imgB.Bounds().Size() {
+		return 1, nil
+	}
+	offset := imgB.Bounds().Min.Sub(bounds.Min)
+
+	total, changed := 0, 0
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			total++
+			if imagediff.PixelsDiffer(imgA.At(x, y), imgB.At(x+offset.X, y+offset.Y)) {
+				changed++
+			}
+		}
+	}
+	if total == 0 {
+		return 0, nil
+	}
+	return float64(changed) / float64(total), nil
+}
@@ -0,0 +1,66 @@
+package core
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func testFrame(t *testing.T, w, h int, fill color.Color, marks ...image.Point) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, fill)
+		}
+	}
+	for _, p := range marks {
+		img.Set(p.X, p.Y, color.Black)
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestFrameDiff(t *testing.T) {
+	white := color.RGBA{255, 255, 255, 255}
+	nearWhite := color.RGBA{252, 253, 255, 255}
+	base := testFrame(t, 10, 10, white)
+
+	tests := []struct {
+		name string
+		b    []byte
+		want float64
+	}{
+		{"identical bytes", base, 0},
+		{"compression noise ignored", testFrame(t, 10, 10, nearWhite), 0},
+		{"one pixel changed", testFrame(t, 10, 10, white, image.Pt(3, 4)), 0.01},
+		{"different size", testFrame(t, 10, 20, white), 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FrameDiff(base, tt.b)
+			if err != nil {
+				t.Fatalf("FrameDiff() error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("FrameDiff() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFrameDiffUndecodable(t *testing.T) {
+	got, err := FrameDiff([]byte("not an image"), []byte("also not"))
+	if err == nil {
+		t.Fatal("expected decode error")
+	}
+	if got != 1 {
+		t.Errorf("FrameDiff() = %v, want 1", got)
+	}
+}
@@ -0,0 +1,87 @@
+package core
+
+import "math"
+
+// PathPoint is a screen coordinate on a gesture path.
+type PathPoint struct {
+	X, Y float64
+}
+
+// PathDurations splits totalMs across the segments of path in proportion to
+// their length, so the pointer moves at a constant speed. It returns one
+// duration per segment (len(path)-1 entries) that sum to totalMs; rounding
+// leftovers go to the last segment. A path with no length is split evenly.
+func PathDurations(path []PathPoint, totalMs int) []int {
+	if len(path) < 2 {
+		return nil
+	}
+
+	lengths := make([]float64, len(path)-1)
+	total := 0.0
+	for i := 1; i < len(path); i++ {
+		lengths[i-1] = math.Hypot(path[i].X-path[i-1].X, path[i].Y-path[i-1].Y)
+		total += lengths[i-1]
+	}
+
+	durations := make([]int, len(lengths))
+	assigned := 0
+	for i, l := range lengths {
+		share := 1.0 / float64(len(lengths))
+		if total > 0 {
+			share = l / total
+		}
+		durations[i] = int(math.Round(share * float64(totalMs)))
+		assigned += durations[i]
+	}
+	durations[len(durations)-1] += totalMs - assigned
+	if durations[len(durations)-1] < 0 {
+		durations[len(durations)-1] = 0
+	}
+	return durations
+}
+
+// TwoFingerTracks offsets the from→to movement into two parallel finger
+// tracks spacing apart, perpendicular to the movement and centred on it, so
+// both fingers travel the same delta. Each track is a start and an end point.
+// A movement with no length spreads the fingers horizontally.
+func TwoFingerTracks(from, to PathPoint, spacing float64) [2][2]PathPoint {
+	dx, dy := to.X-from.X, to.Y-from.Y
+	length := math.Hypot(dx, dy)
+	px, py := 1.0, 0.0
+	if length > 0 {
+		px, py = -dy/length, dx/length
+	}
+	ox, oy := px*spacing/2, py*spacing/2
+
+	return [2][2]PathPoint{
+		{{X: from.X - ox, Y: from.Y - oy}, {X: to.X - ox, Y: to.Y - oy}},
+		{{X: from.X + ox, Y: from.Y + oy}, {X: to.X + ox, Y: to.Y + oy}},
+	}
+}
+
+// CenteringDrag returns a drag that moves content so b's center travels to
+// the middle of a width x height screen along one axis (horizontal when
+// true). The drag starts at the screen middle and is clamped to the central
+// 80% of the screen, so a far-off element only moves part of the way. ok is
+// false when b's center is already within a tenth of the screen of the middle.
+func CenteringDrag(b Bounds, width, height int, horizontal bool) (from, to PathPoint, ok bool) {
+	cx, cy := b.Center()
+	extent, offset := height, cy-height/2
+	if horizontal {
+		extent, offset = width, cx-width/2
+	}
+	if offset*10 > -extent && offset*10 < extent {
+		return PathPoint{}, PathPoint{}, false
+	}
+
+	mid := float64(extent) / 2
+	end := math.Max(float64(extent)*0.1, math.Min(float64(extent)*0.9, mid-float64(offset)))
+	from = PathPoint{X: float64(width) / 2, Y: float64(height) / 2}
+	to = from
+	if horizontal {
+		to.X = end
+	} else {
+		to.Y = end
+	}
+	return from, to, true
+}
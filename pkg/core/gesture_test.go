@@ -0,0 +1,84 @@
+package core
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPathDurations(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    []PathPoint
+		totalMs int
+		want    []int
+	}{
+		{"single point", []PathPoint{{10, 10}}, 1000, nil},
+		{"proportional to length", []PathPoint{{0, 0}, {300, 0}, {300, 100}}, 800, []int{600, 200}},
+		{"diagonal segment", []PathPoint{{0, 0}, {30, 40}, {30, 90}}, 1000, []int{500, 500}},
+		{"rounding goes to last segment", []PathPoint{{0, 0}, {1, 0}, {2, 0}, {3, 0}}, 1000, []int{333, 333, 334}},
+		{"zero length splits evenly", []PathPoint{{5, 5}, {5, 5}, {5, 5}}, 100, []int{50, 50}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := PathDurations(tt.path, tt.totalMs)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("PathDurations() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTwoFingerTracks(t *testing.T) {
+	// Upward pan: fingers sit side by side horizontally and move the same delta
+	tracks := TwoFingerTracks(PathPoint{X: 500, Y: 800}, PathPoint{X: 500, Y: 400}, 100)
+	want := [2][2]PathPoint{
+		{{X: 450, Y: 800}, {X: 450, Y: 400}},
+		{{X: 550, Y: 800}, {X: 550, Y: 400}},
+	}
+	if tracks != want {
+		t.Errorf("TwoFingerTracks() = %v, want %v", tracks, want)
+	}
+
+	// Horizontal pan: fingers stack vertically
+	tracks = TwoFingerTracks(PathPoint{X: 100, Y: 300}, PathPoint{X: 300, Y: 300}, 60)
+	for i, track := range tracks {
+		if dx, dy := track[1].X-track[0].X, track[1].Y-track[0].Y; dx != 200 || dy != 0 {
+			t.Errorf("finger %d delta = (%v, %v), want (200, 0)", i, dx, dy)
+		}
+	}
+	if gap := tracks[1][0].Y - tracks[0][0].Y; gap != 60 {
+		t.Errorf("expected fingers 60 apart vertically, got %v", gap)
+	}
+
+	// No movement: fingers spread horizontally around the point
+	tracks = TwoFingerTracks(PathPoint{X: 10, Y: 10}, PathPoint{X: 10, Y: 10}, 20)
+	if tracks[0][0] != (PathPoint{X: 0, Y: 10}) || tracks[1][0] != (PathPoint{X: 20, Y: 10}) {
+		t.Errorf("unexpected tracks for zero-length movement: %v", tracks)
+	}
+}
+
+func TestCenteringDrag(t *testing.T) {
+	// Element below the middle: drag up by its offset
+	from, to, ok := CenteringDrag(Bounds{X: 0, Y: 1450, Width: 1000, Height: 100}, 1000, 2000, false)
+	if !ok || from != (PathPoint{X: 500, Y: 1000}) || to != (PathPoint{X: 500, Y: 500}) {
+		t.Errorf("CenteringDrag() = %v, %v, %v, want (500,1000) -> (500,500)", from, to, ok)
+	}
+
+	// Far-off element: the drag is clamped to the central 80%
+	_, to, ok = CenteringDrag(Bounds{X: 0, Y: 1900, Width: 1000, Height: 100}, 1000, 2000, false)
+	if !ok || to.Y != 200 {
+		t.Errorf("expected clamped drag to y=200, got %v (ok=%v)", to, ok)
+	}
+
+	// Horizontal: element left of the middle is dragged right
+	_, to, ok = CenteringDrag(Bounds{X: 150, Y: 0, Width: 100, Height: 100}, 1000, 2000, true)
+	if !ok || to != (PathPoint{X: 800, Y: 1000}) {
+		t.Errorf("expected horizontal drag to (800,1000), got %v (ok=%v)", to, ok)
+	}
+
+	// Already near the middle: no drag
+	if _, _, ok := CenteringDrag(Bounds{X: 0, Y: 1000, Width: 1000, Height: 100}, 1000, 2000, false); ok {
+		t.Error("expected no drag for an element near the middle")
+	}
+}
@@ -0,0 +1,89 @@
+package core
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Sort orders accepted by CheckSorted.
+const (
+	OrderAscending  = "ascending"
+	OrderDescending = "descending"
+)
+
+// CheckSorted extracts a value from each text and verifies the values are in
+// the given order (empty means ascending). Equal neighbours are allowed.
+//
+// If pattern is set, the value is its first capture group, or the whole match
+// when the pattern has no groups. With numeric set, values are compared as
+// numbers after dropping thousands separators; otherwise they compare as strings.
+// The returned error names the first out-of-order pair.
+func CheckSorted(texts []string, pattern string, numeric bool, order string) error {
+	descending := false
+	switch order {
+	case "", OrderAscending:
+	case OrderDescending:
+		descending = true
+	default:
+		return fmt.Errorf("invalid order %q (use %s or %s)", order, OrderAscending, OrderDescending)
+	}
+
+	var re *regexp.Regexp
+	if pattern != "" {
+		var err error
+		if re, err = regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+
+	values := make([]string, len(texts))
+	numbers := make([]float64, len(texts))
+	for i, text := range texts {
+		value := strings.TrimSpace(text)
+		if re != nil {
+			m := re.FindStringSubmatch(value)
+			if m == nil {
+				return fmt.Errorf("value %q at index %d does not match pattern %q", text, i, pattern)
+			}
+			value = m[0]
+			if len(m) > 1 {
+				value = m[1]
+			}
+		}
+		values[i] = value
+
+		if numeric {
+			n, err := strconv.ParseFloat(strings.ReplaceAll(value, ",", ""), 64)
+			if err != nil {
+				return fmt.Errorf("value %q at index %d is not a number", value, i)
+			}
+			numbers[i] = n
+		}
+	}
+
+	for i := 1; i < len(values); i++ {
+		var cmp int
+		if numeric {
+			switch {
+			case numbers[i-1] < numbers[i]:
+				cmp = -1
+			case numbers[i-1] > numbers[i]:
+				cmp = 1
+			}
+		} else {
+			cmp = strings.Compare(values[i-1], values[i])
+		}
+
+		if (!descending && cmp > 0) || (descending && cmp < 0) {
+			name := OrderAscending
+			if descending {
+				name = OrderDescending
+			}
+			return fmt.Errorf("not in %s order: %q (index %d) comes before %q (index %d)",
+				name, values[i-1], i-1, values[i], i)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package core
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckSorted(t *testing.T) {
+	tests := []struct {
+		name    string
+		texts   []string
+		pattern string
+		numeric bool
+		order   string
+		wantErr string
+	}{
+		{"strings ascending", []string{"Apple", "Banana", "Cherry"}, "", false, "", ""},
+		{"strings ascending out of order", []string{"Apple", "Cherry", "Banana"}, "", false, OrderAscending, `"Cherry" (index 1) comes before "Banana" (index 2)`},
+		{"strings descending", []string{"Cherry", "Banana", "Apple"}, "", false, OrderDescending, ""},
+		{"strings descending out of order", []string{"Cherry", "Apple", "Banana"}, "", false, OrderDescending, `"Apple" (index 1) comes before "Banana" (index 2)`},
+		{"numeric ascending with duplicates", []string{"2", "10", "10", "1,200"}, "", true, "", ""},
+		{"numeric not lexical", []string{"9", "10"}, "", true, OrderAscending, ""},
+		{"numeric descending out of order", []string{"$30.00", "$5.50", "$12.00"}, `\$([\d.]+)`, true, OrderDescending, `"5.50" (index 1) comes before "12.00" (index 2)`},
+		{"pattern whole match", []string{"4.5 stars", "3.9 stars"}, `[\d.]+`, true, OrderDescending, ""},
+		{"pattern no match", []string{"$3", "free"}, `\$(\d+)`, true, "", `"free" at index 1 does not match`},
+		{"not a number", []string{"1", "two"}, "", true, "", `"two" at index 1 is not a number`},
+		{"invalid order", []string{"a"}, "", false, "sideways", "invalid order"},
+		{"invalid pattern", []string{"a"}, "(", false, "", "invalid pattern"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckSorted(tt.texts, tt.pattern, tt.numeric, tt.order)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
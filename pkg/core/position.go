@@ -0,0 +1,57 @@
+package core
+
+import "sort"
+
+// Side is the direction a positional relative selector (below, above, leftOf,
+// rightOf) looks in from its anchor.
+type Side int
+
+// Sides accepted by FilterBySide.
+const (
+	SideBelow Side = iota
+	SideAbove
+	SideLeftOf
+	SideRightOf
+)
+
+// FilterBySide returns the elements whose center lies past the anchor's edge
+// on the given side, ordered by center-to-center distance from the anchor, so
+// the first candidate of a positional selector is the anchor's nearest
+// neighbour. The anchor and the elements enclosing it (its containers) are on
+// no side of it and are skipped. bounds returns an element's bounds.
+func FilterBySide[T comparable](elements []T, anchor T, side Side, bounds func(T) Bounds) []T {
+	a := bounds(anchor)
+	var result []T
+	for _, elem := range elements {
+		b := bounds(elem)
+		if elem == anchor || a.Within(b) {
+			continue
+		}
+		cx, cy := b.Center()
+		var ok bool
+		switch side {
+		case SideBelow:
+			ok = cy >= a.Y+a.Height
+		case SideAbove:
+			ok = cy <= a.Y
+		case SideLeftOf:
+			ok = cx <= a.X
+		case SideRightOf:
+			ok = cx >= a.X+a.Width
+		}
+		if ok {
+			result = append(result, elem)
+		}
+	}
+
+	ax, ay := a.Center()
+	distanceSq := func(elem T) int {
+		cx, cy := bounds(elem).Center()
+		dx, dy := cx-ax, cy-ay
+		return dx*dx + dy*dy
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return distanceSq(result[i]) < distanceSq(result[j])
+	})
+	return result
+}
@@ -0,0 +1,54 @@
+package core
+
+import "testing"
+
+type positioned struct {
+	name   string
+	bounds Bounds
+}
+
+func positionedBounds(p *positioned) Bounds { return p.bounds }
+
+func names(elems []*positioned) []string {
+	var out []string
+	for _, e := range elems {
+		out = append(out, e.name)
+	}
+	return out
+}
+
+func TestFilterBySide(t *testing.T) {
+	anchor := &positioned{"anchor", Bounds{X: 100, Y: 100, Width: 100, Height: 50}}
+	screen := &positioned{"screen", Bounds{X: 0, Y: 0, Width: 1000, Height: 2000}}
+	far := &positioned{"far", Bounds{X: 100, Y: 600, Width: 100, Height: 50}}
+	near := &positioned{"near", Bounds{X: 100, Y: 200, Width: 100, Height: 50}}
+	overlap := &positioned{"overlap", Bounds{X: 100, Y: 120, Width: 100, Height: 20}}
+	above := &positioned{"above", Bounds{X: 100, Y: 0, Width: 100, Height: 50}}
+	left := &positioned{"left", Bounds{X: 0, Y: 100, Width: 50, Height: 50}}
+	right := &positioned{"right", Bounds{X: 300, Y: 100, Width: 50, Height: 50}}
+	elements := []*positioned{screen, far, anchor, near, overlap, above, left, right}
+
+	tests := []struct {
+		side Side
+		want []string
+	}{
+		{SideBelow, []string{"near", "far"}},
+		{SideAbove, []string{"above"}},
+		{SideLeftOf, []string{"left"}},
+		{SideRightOf, []string{"right"}},
+	}
+
+	for _, tt := range tests {
+		got := names(FilterBySide(elements, anchor, tt.side, positionedBounds))
+		if len(got) != len(tt.want) {
+			t.Errorf("side %d: got %v, want %v", tt.side, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("side %d: got %v, want %v", tt.side, got, tt.want)
+				break
+			}
+		}
+	}
+}
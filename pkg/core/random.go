@@ -0,0 +1,41 @@
+package core
+
+import (
+	"math/rand"
+)
+
+// Random test data shared by the drivers' inputRandom step and the maestro
+// script helpers, so both produce the same kinds of values.
+
+// RandomString returns length random ASCII letters and digits.
+func RandomString(length int) string {
+	const chars = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	b := make([]byte, length)
+	for i := range b {
+		b[i] = chars[rand.Intn(len(chars))]
+	}
+	return string(b)
+}
+
+// RandomEmail returns an address with an 8-character random user.
+func RandomEmail() string {
+	domains := []string{"example.com", "test.com", "mail.com"}
+	return RandomString(8) + "@" + domains[rand.Intn(len(domains))]
+}
+
+// RandomNumber returns length random digits.
+func RandomNumber(length int) string {
+	const digits = "0123456789"
+	b := make([]byte, length)
+	for i := range b {
+		b[i] = digits[rand.Intn(len(digits))]
+	}
+	return string(b)
+}
+
+// RandomPersonName returns a "First Last" name.
+func RandomPersonName() string {
+	firstNames := []string{"John", "Jane", "Michael", "Emily", "David", "Sarah", "James", "Emma", "Robert", "Olivia"}
+	lastNames := []string{"Smith", "Johnson", "Williams", "Brown", "Jones", "Garcia", "Miller", "Davis", "Rodriguez", "Martinez"}
+	return firstNames[rand.Intn(len(firstNames))] + " " + lastNames[rand.Intn(len(lastNames))]
+}
@@ -0,0 +1,100 @@
+package core
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestRandomString(t *testing.T) {
+	tests := []struct {
+		name   string
+		length int
+	}{
+		{"length 0", 0},
+		{"length 1", 1},
+		{"length 5", 5},
+		{"length 10", 10},
+		{"length 20", 20},
+		{"length 50", 50},
+		{"length 100", 100},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result := RandomString(tc.length)
+			if len(result) != tc.length {
+				t.Fatalf("RandomString(%d) returned length %d", tc.length, len(result))
+			}
+			if !regexp.MustCompile(`^[a-zA-Z0-9]*$`).MatchString(result) {
+				t.Fatalf("RandomString(%d) = %q, want letters and digits only", tc.length, result)
+			}
+		})
+	}
+}
+
+func TestRandomStringUniqueness(t *testing.T) {
+	if RandomString(20) == RandomString(20) {
+		t.Error("RandomString should produce different results")
+	}
+
+	// Several short strings should not all be identical
+	results := make(map[string]bool)
+	for i := 0; i < 5; i++ {
+		results[RandomString(8)] = true
+	}
+	if len(results) < 2 {
+		t.Fatalf("expected at least 2 unique strings out of 5, got %d", len(results))
+	}
+}
+
+func TestRandomEmail(t *testing.T) {
+	email := RandomEmail()
+	parts := strings.Split(email, "@")
+	if len(parts) != 2 {
+		t.Fatalf("expected exactly one '@' in email, got %q", email)
+	}
+	if len(parts[0]) != 8 || !regexp.MustCompile(`^[a-zA-Z0-9]+$`).MatchString(parts[0]) {
+		t.Errorf("expected an 8-character letters and digits user, got %q", parts[0])
+	}
+	switch parts[1] {
+	case "example.com", "test.com", "mail.com":
+	default:
+		t.Errorf("unexpected domain %q", parts[1])
+	}
+}
+
+func TestRandomNumber(t *testing.T) {
+	for _, length := range []int{1, 5, 10} {
+		result := RandomNumber(length)
+		if len(result) != length {
+			t.Errorf("RandomNumber(%d) returned length %d", length, len(result))
+		}
+		if !regexp.MustCompile(`^[0-9]*$`).MatchString(result) {
+			t.Errorf("RandomNumber(%d) = %q, want digits only", length, result)
+		}
+	}
+}
+
+func TestRandomPersonName(t *testing.T) {
+	validFirstNames := map[string]bool{
+		"John": true, "Jane": true, "Michael": true, "Emily": true, "David": true,
+		"Sarah": true, "James": true, "Emma": true, "Robert": true, "Olivia": true,
+	}
+	validLastNames := map[string]bool{
+		"Smith": true, "Johnson": true, "Williams": true, "Brown": true, "Jones": true,
+		"Garcia": true, "Miller": true, "Davis": true, "Rodriguez": true, "Martinez": true,
+	}
+
+	name := RandomPersonName()
+	parts := strings.Split(name, " ")
+	if len(parts) != 2 {
+		t.Fatalf("expected 'first last' format, got %q", name)
+	}
+	if !validFirstNames[parts[0]] {
+		t.Errorf("unexpected first name %q", parts[0])
+	}
+	if !validLastNames[parts[1]] {
+		t.Errorf("unexpected last name %q", parts[1])
+	}
+}
@@ -0,0 +1,50 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// RecordingCropStatusBar is the startRecording crop that removes the status
+// bar. Neither screenrecord nor simctl can crop, so the drivers measure the
+// status bar when recording starts and CropRecordingTop cuts it off the
+// finished file on the host.
+const RecordingCropStatusBar = "statusBar"
+
+// CheckRecordingCrop validates a startRecording crop value ("" for none).
+func CheckRecordingCrop(crop string) error {
+	if crop != "" && crop != RecordingCropStatusBar {
+		return fmt.Errorf("invalid recording crop %q: only %q is supported", crop, RecordingCropStatusBar)
+	}
+	return nil
+}
+
+// cropTopArgs builds the ffmpeg arguments that drop the top fraction of the
+// video src into dst. The fraction is applied to the video's own height so it
+// holds for scaled recordings, rounded to even rows as H.264 requires.
+func cropTopArgs(src, dst string, fraction float64) []string {
+	top := fmt.Sprintf("2*trunc(ih*%.4f/2)", fraction)
+	return []string{"-y", "-loglevel", "error", "-i", src,
+		"-vf", fmt.Sprintf("crop=iw:ih-%s:0:%s", top, top),
+		"-c:a", "copy", dst}
+}
+
+// CropRecordingTop crops the top fraction (0 < fraction < 1) off the video at
+// path in place using ffmpeg, which must be on PATH.
+func CropRecordingTop(path string, fraction float64) error {
+	if fraction <= 0 || fraction >= 1 {
+		return fmt.Errorf("invalid crop fraction %.4f", fraction)
+	}
+	if _, err := exec.LookPath("ffmpeg"); err != nil {
+		return fmt.Errorf("cropping a recording requires ffmpeg on PATH: %w", err)
+	}
+	tmp := strings.TrimSuffix(path, ".mp4") + ".crop.mp4"
+	out, err := exec.Command("ffmpeg", cropTopArgs(path, tmp, fraction)...).CombinedOutput()
+	if err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("ffmpeg crop failed: %v: %s", err, strings.TrimSpace(string(out)))
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,38 @@
+package core
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCheckRecordingCrop(t *testing.T) {
+	for _, crop := range []string{"", RecordingCropStatusBar} {
+		if err := CheckRecordingCrop(crop); err != nil {
+			t.Errorf("CheckRecordingCrop(%q) = %v, want nil", crop, err)
+		}
+	}
+	if err := CheckRecordingCrop("navBar"); err == nil {
+		t.Error("CheckRecordingCrop(navBar) = nil, want an error")
+	}
+}
+
+func TestCropTopArgs(t *testing.T) {
+	got := cropTopArgs("in.mp4", "out.mp4", 0.05)
+	want := []string{"-y", "-loglevel", "error", "-i", "in.mp4",
+		"-vf", "crop=iw:ih-2*trunc(ih*0.0500/2):0:2*trunc(ih*0.0500/2)",
+		"-c:a", "copy", "out.mp4"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("cropTopArgs() = %q, want %q", got, want)
+	}
+}
+
+func TestCropRecordingTopErrors(t *testing.T) {
+	if err := CropRecordingTop("video.mp4", 1.5); err == nil || !strings.Contains(err.Error(), "invalid crop fraction") {
+		t.Errorf("fraction 1.5: err = %v, want invalid crop fraction", err)
+	}
+	t.Setenv("PATH", "")
+	if err := CropRecordingTop("video.mp4", 0.05); err == nil || !strings.Contains(err.Error(), "requires ffmpeg") {
+		t.Errorf("no ffmpeg: err = %v, want requires ffmpeg", err)
+	}
+}
@@ -0,0 +1,30 @@
+package core
+
+import (
+	"fmt"
+
+	"github.com/devicelab-dev/maestro-runner/pkg/flow"
+)
+
+// CheckElementState compares the enabled, checked and selected state requested
+// by sel with the state read from the matched element. It returns an error
+// naming the first mismatch, or nil when every requested state holds.
+func CheckElementState(sel flow.Selector, info *ElementInfo) error {
+	checks := []struct {
+		name   string
+		want   *bool
+		actual bool
+	}{
+		{"enabled", sel.Enabled, info.Enabled},
+		{"checked", sel.Checked, info.Checked},
+		{"selected", sel.Selected, info.Selected},
+		{"focused", sel.Focused, info.Focused},
+	}
+	for _, c := range checks {
+		if c.want != nil && *c.want != c.actual {
+			return fmt.Errorf("element '%s' is visible but %s=%t (expected %t)",
+				sel.Describe(), c.name, c.actual, *c.want)
+		}
+	}
+	return nil
+}
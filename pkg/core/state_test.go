@@ -0,0 +1,43 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/devicelab-dev/maestro-runner/pkg/flow"
+)
+
+func TestCheckElementState(t *testing.T) {
+	on, off := true, false
+	tests := []struct {
+		name    string
+		sel     flow.Selector
+		info    ElementInfo
+		wantErr string
+	}{
+		{"no state requested", flow.Selector{Text: "Submit"}, ElementInfo{}, ""},
+		{"enabled matches", flow.Selector{Text: "Submit", Enabled: &on}, ElementInfo{Enabled: true}, ""},
+		{"enabled mismatch", flow.Selector{Text: "Submit", Enabled: &on}, ElementInfo{},
+			"element 'Submit' is visible but enabled=false (expected true)"},
+		{"checked mismatch", flow.Selector{ID: "terms", Checked: &off}, ElementInfo{Checked: true},
+			"element '#terms' is visible but checked=true (expected false)"},
+		{"selected mismatch", flow.Selector{Text: "Tab", Selected: &on}, ElementInfo{Enabled: true},
+			"element 'Tab' is visible but selected=false (expected true)"},
+		{"all match", flow.Selector{Text: "Tab", Enabled: &on, Checked: &off, Selected: &on},
+			ElementInfo{Enabled: true, Selected: true}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckElementState(tt.sel, &tt.info)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("error=%v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
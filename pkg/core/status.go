@@ -62,6 +62,7 @@ const (
 	ErrCategoryConnection                      // Device/server connection lost
 	ErrCategoryApp                             // App crashed, not responding, not installed
 	ErrCategoryConfig                          // Invalid configuration, missing required field
+	ErrCategoryRequest                         // Automation server rejected the request (e.g. WDA 4xx)
 )
 
 // String returns the string representation of ErrorCategory
@@ -79,6 +80,8 @@ func (c ErrorCategory) String() string {
 		return "app"
 	case ErrCategoryConfig:
 		return "config"
+	case ErrCategoryRequest:
+		return "request"
 	default:
 		return "unknown"
 	}
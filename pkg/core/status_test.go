@@ -69,6 +69,7 @@ func TestErrorCategory_String(t *testing.T) {
 		{ErrCategoryConnection, "connection"},
 		{ErrCategoryApp, "app"},
 		{ErrCategoryConfig, "config"},
+		{ErrCategoryRequest, "request"},
 		{ErrorCategory(99), "unknown"},
 	}
 
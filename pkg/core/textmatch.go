@@ -0,0 +1,102 @@
+package core
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// TextMatcher checks an element's text against exactly one of an exact value,
+// a substring, or a regular expression.
+type TextMatcher struct {
+	equals   string
+	contains string
+	pattern  *regexp.Regexp
+	desc     string
+}
+
+// NewTextMatcher builds a matcher from the condition fields of a step. Exactly
+// one of equals, contains and pattern must be set. The pattern must match the
+// whole text, as Maestro text selectors do.
+func NewTextMatcher(equals, contains, pattern string) (*TextMatcher, error) {
+	set := 0
+	for _, v := range []string{equals, contains, pattern} {
+		if v != "" {
+			set++
+		}
+	}
+	if set != 1 {
+		return nil, fmt.Errorf("exactly one of equals, contains or matches must be set")
+	}
+
+	m := &TextMatcher{equals: equals, contains: contains}
+	switch {
+	case equals != "":
+		m.desc = fmt.Sprintf("equal %q", equals)
+	case contains != "":
+		m.desc = fmt.Sprintf("contain %q", contains)
+	default:
+		re, err := regexp.Compile("^(?:" + pattern + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+		m.pattern = re
+		m.desc = fmt.Sprintf("match /%s/", pattern)
+	}
+	return m, nil
+}
+
+// Match reports whether text satisfies the condition.
+func (m *TextMatcher) Match(text string) bool {
+	switch {
+	case m.pattern != nil:
+		return m.pattern.MatchString(text)
+	case m.contains != "":
+		return strings.Contains(text, m.contains)
+	default:
+		return text == m.equals
+	}
+}
+
+// String describes the condition, e.g. `equal "Done"`.
+func (m *TextMatcher) String() string {
+	return m.desc
+}
+
+// FindForbiddenText looks for a text that contains one of substrings or has a
+// match for one of patterns anywhere in it. It describes the first hit, e.g.
+// `"null" in "Error: null"`, and returns "" when no text has a forbidden term.
+func FindForbiddenText(texts, substrings, patterns []string) (string, error) {
+	if len(substrings) == 0 && len(patterns) == 0 {
+		return "", fmt.Errorf("no forbidden text or regex specified")
+	}
+	res := make([]*regexp.Regexp, len(patterns))
+	for i, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return "", fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+		res[i] = re
+	}
+
+	for _, text := range texts {
+		for _, sub := range substrings {
+			if sub != "" && strings.Contains(text, sub) {
+				return fmt.Sprintf("%q in %q", sub, text), nil
+			}
+		}
+		for i, re := range res {
+			if re.MatchString(text) {
+				return fmt.Sprintf("/%s/ in %q", patterns[i], text), nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// InputTextMatches reports whether a field that read back as before holds
+// exactly before+text after text was typed into it. An empty field reads
+// back as its placeholder or hint, so the typed text alone also matches.
+func InputTextMatches(got, before, text string) bool {
+	return got == before+text || got == text
+}
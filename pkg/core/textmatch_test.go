@@ -0,0 +1,119 @@
+package core
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTextMatcher(t *testing.T) {
+	tests := []struct {
+		name     string
+		equals   string
+		contains string
+		pattern  string
+		text     string
+		want     bool
+		desc     string
+	}{
+		{"equals match", "Done", "", "", "Done", true, `equal "Done"`},
+		{"equals mismatch", "Done", "", "", "Done!", false, `equal "Done"`},
+		{"contains match", "", "Done", "", "Upload Done", true, `contain "Done"`},
+		{"contains mismatch", "", "Done", "", "Loading", false, `contain "Done"`},
+		{"pattern match", "", "", `\d+%`, "100%", true, `match /\d+%/`},
+		{"pattern must match whole text", "", "", `\d+%`, "Progress 100%", false, `match /\d+%/`},
+		{"pattern alternation anchored", "", "", `Done|Finished`, "Finished", true, `match /Done|Finished/`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := NewTextMatcher(tt.equals, tt.contains, tt.pattern)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := m.Match(tt.text); got != tt.want {
+				t.Errorf("Match(%q) = %v, want %v", tt.text, got, tt.want)
+			}
+			if m.String() != tt.desc {
+				t.Errorf("String() = %q, want %q", m.String(), tt.desc)
+			}
+		})
+	}
+}
+
+func TestNewTextMatcherErrors(t *testing.T) {
+	tests := []struct {
+		name                      string
+		equals, contains, pattern string
+		wantErr                   string
+	}{
+		{"none set", "", "", "", "exactly one"},
+		{"two set", "Done", "Do", "", "exactly one"},
+		{"invalid pattern", "", "", "(", "invalid pattern"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewTextMatcher(tt.equals, tt.contains, tt.pattern)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestFindForbiddenText(t *testing.T) {
+	texts := []string{"Order #42", "Error: null", "Total: $12.00"}
+
+	tests := []struct {
+		name                 string
+		substrings, patterns []string
+		want                 string
+	}{
+		{"substring", []string{"null"}, nil, `"null" in "Error: null"`},
+		{"first text wins", []string{"Total", "Error"}, nil, `"Error" in "Error: null"`},
+		{"pattern anywhere", nil, []string{`\$\d+\.\d{2}`}, `/\$\d+\.\d{2}/ in "Total: $12.00"`},
+		{"absent", []string{"undefined", "NaN"}, []string{`^Exception`}, ""},
+		{"case sensitive", []string{"error"}, nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FindForbiddenText(texts, tt.substrings, tt.patterns)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("FindForbiddenText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindForbiddenTextErrors(t *testing.T) {
+	if _, err := FindForbiddenText([]string{"x"}, nil, nil); err == nil {
+		t.Error("expected error when nothing is forbidden")
+	}
+	if _, err := FindForbiddenText([]string{"x"}, nil, []string{"("}); err == nil || !strings.Contains(err.Error(), "invalid pattern") {
+		t.Errorf("expected invalid pattern error, got %v", err)
+	}
+}
+
+func TestInputTextMatches(t *testing.T) {
+	tests := []struct {
+		got, before, text string
+		want              bool
+	}{
+		{"hello", "", "hello", true},
+		{"hi hello", "hi ", "hello", true},
+		{"hello", "Search", "hello", true}, // before was the placeholder
+		{"hell", "", "hello", false},
+		{"hi hell", "hi ", "hello", false},
+		{"hello hell", "hello ", "hello", false}, // contains the text, but not exactly
+	}
+
+	for _, tt := range tests {
+		if got := InputTextMatches(tt.got, tt.before, tt.text); got != tt.want {
+			t.Errorf("InputTextMatches(%q, %q, %q) = %v, want %v", tt.got, tt.before, tt.text, got, tt.want)
+		}
+	}
+}
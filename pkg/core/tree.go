@@ -0,0 +1,14 @@
+package core
+
+// IsDescendant reports whether node lies below ancestor in an element tree,
+// following parent links from node up to the root. A node is not its own
+// descendant.
+func IsDescendant[T comparable](node, ancestor T, parent func(T) T) bool {
+	var root T
+	for p := parent(node); p != root; p = parent(p) {
+		if p == ancestor {
+			return true
+		}
+	}
+	return false
+}
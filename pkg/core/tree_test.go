@@ -0,0 +1,36 @@
+package core
+
+import "testing"
+
+type treeNode struct {
+	name   string
+	parent *treeNode
+}
+
+func parentNode(n *treeNode) *treeNode { return n.parent }
+
+func TestIsDescendant(t *testing.T) {
+	list := &treeNode{name: "list"}
+	row := &treeNode{name: "row", parent: list}
+	label := &treeNode{name: "label", parent: row}
+	other := &treeNode{name: "other", parent: list}
+
+	tests := []struct {
+		node, ancestor *treeNode
+		want           bool
+	}{
+		{label, row, true},
+		{label, list, true},
+		{row, list, true},
+		{row, label, false},
+		{label, other, false},
+		{row, row, false},
+		{list, row, false},
+	}
+
+	for _, tt := range tests {
+		if got := IsDescendant(tt.node, tt.ancestor, parentNode); got != tt.want {
+			t.Errorf("IsDescendant(%s, %s) = %v, want %v", tt.node.name, tt.ancestor.name, got, tt.want)
+		}
+	}
+}
@@ -104,10 +104,8 @@ func (c *Client) Connect(capabilities map[string]interface{}) error {
 	// Extract waitForIdleTimeout from appium:settings capability if provided
 	waitForIdleTimeout := 0 // default to 0 (disabled) for backward compatibility
 	if settings, ok := capabilities["appium:settings"].(map[string]interface{}); ok {
-		if val, ok := settings["waitForIdleTimeout"].(int); ok {
+		if val, ok := CapabilityInt(settings["waitForIdleTimeout"]); ok {
 			waitForIdleTimeout = val
-		} else if val, ok := settings["waitForIdleTimeout"].(float64); ok {
-			waitForIdleTimeout = int(val)
 		}
 	}
 
@@ -758,3 +756,18 @@ var uuidRegex = regexp.MustCompile(`^[0-9A-Fa-f]{8}-[0-9A-Fa-f]{4}-[0-9A-Fa-f]{4
 func isUUIDFormat(s string) bool {
 	return uuidRegex.MatchString(s)
 }
+
+// CapabilityInt reads an integer capability or setting: int from CLI flags,
+// json.Number from a --caps file, float64 from other decoded JSON.
+func CapabilityInt(v interface{}) (int, bool) {
+	switch n := v.(type) {
+	case int:
+		return n, true
+	case float64:
+		return int(n), true
+	case json.Number:
+		i, err := n.Int64()
+		return int(i), err == nil
+	}
+	return 0, false
+}
@@ -80,7 +80,7 @@ func (d *Driver) longPressOn(step *flow.LongPressOnStep) *core.CommandResult {
 		return errorResult(err, fmt.Sprintf("Element not found: %s", step.Selector.Describe()))
 	}
 
-	duration := 1000 // Default 1 second for long press
+	duration := step.Duration()
 
 	cx, cy := info.Bounds.Center()
 	if err := d.client.LongPress(cx, cy, duration); err != nil {
@@ -387,10 +387,7 @@ func (d *Driver) assertVisible(step *flow.AssertVisibleStep) *core.CommandResult
 }
 
 func (d *Driver) assertNotVisible(step *flow.AssertNotVisibleStep) *core.CommandResult {
-	timeout := time.Duration(step.TimeoutMs) * time.Millisecond
-	if timeout <= 0 {
-		timeout = 2 * time.Second // Shorter timeout for not visible
-	}
+	timeout := time.Duration(step.Window()) * time.Millisecond
 
 	// Element should NOT be found
 	_, err := d.findElement(step.Selector, timeout)
@@ -467,6 +464,14 @@ func (d *Driver) stopApp(step *flow.StopAppStep) *core.CommandResult {
 		return errorResult(fmt.Errorf("no app ID specified"), "")
 	}
 
+	if step.Graceful {
+		// Send the app to the background so it can save state before it is terminated
+		if result := d.pressKey(&flow.PressKeyStep{Key: "home"}); !result.Success {
+			return errorResult(result.Error, fmt.Sprintf("Failed to background app: %s", appID))
+		}
+		time.Sleep(core.GracefulStopDelay)
+	}
+
 	if err := d.client.TerminateApp(appID); err != nil {
 		return errorResult(err, fmt.Sprintf("Failed to stop app: %s", appID))
 	}
@@ -523,6 +528,15 @@ func (d *Driver) openLink(step *flow.OpenLinkStep) *core.CommandResult {
 	// browser parameter would require mobile: shell on Android or Safari automation on iOS
 	// For now, we use the standard Appium approach which respects system defaults
 
+	if step.ClearState {
+		if r := d.clearState(&flow.ClearStateStep{AppID: step.AppID}); !r.Success {
+			return r
+		}
+		if r := d.killApp(&flow.KillAppStep{AppID: step.AppID}); !r.Success {
+			return r
+		}
+	}
+
 	if err := d.client.OpenURL(step.Link); err != nil {
 		return errorResult(err, fmt.Sprintf("Failed to open link: %s", step.Link))
 	}
@@ -532,6 +546,16 @@ func (d *Driver) openLink(step *flow.OpenLinkStep) *core.CommandResult {
 		time.Sleep(2 * time.Second)
 	}
 
+	if step.WaitFor != nil {
+		timeout := time.Duration(step.TimeoutMs) * time.Millisecond
+		if timeout <= 0 {
+			timeout = d.getFindTimeout()
+		}
+		if _, err := d.findElement(*step.WaitFor, timeout); err != nil {
+			return errorResult(err, fmt.Sprintf("Opened link but %s did not appear", step.WaitFor.Describe()))
+		}
+	}
+
 	msg := fmt.Sprintf("Opened link: %s", step.Link)
 	if step.Browser != nil && *step.Browser {
 		msg += " (browser flag set, but Appium uses system default handler)"
@@ -759,13 +783,13 @@ func (d *Driver) inputRandom(step *flow.InputRandomStep) *core.CommandResult {
 	var text string
 	switch strings.ToUpper(step.DataType) {
 	case "EMAIL":
-		text = randomEmail()
+		text = core.RandomEmail()
 	case "NUMBER":
-		text = randomNumber(length)
+		text = core.RandomNumber(length)
 	case "PERSON_NAME":
-		text = randomPersonName()
+		text = core.RandomPersonName()
 	default:
-		text = randomString(length)
+		text = core.RandomString(length)
 	}
 
 	if err := d.client.SendKeys(text); err != nil {
@@ -790,38 +814,6 @@ func (d *Driver) takeScreenshot(step *flow.TakeScreenshotStep) *core.CommandResu
 	}
 }
 
-// Random data generators
-
-func randomString(length int) string {
-	const chars = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-	b := make([]byte, length)
-	for i := range b {
-		b[i] = chars[time.Now().UnixNano()%int64(len(chars))]
-		time.Sleep(time.Nanosecond)
-	}
-	return string(b)
-}
-
-func randomEmail() string {
-	return randomString(8) + "@example.com"
-}
-
-func randomNumber(length int) string {
-	const digits = "0123456789"
-	b := make([]byte, length)
-	for i := range b {
-		b[i] = digits[time.Now().UnixNano()%10]
-		time.Sleep(time.Nanosecond)
-	}
-	return string(b)
-}
-
-func randomPersonName() string {
-	firstNames := []string{"John", "Jane", "Michael", "Emily", "David"}
-	lastNames := []string{"Smith", "Johnson", "Williams", "Brown", "Jones"}
-	return firstNames[time.Now().UnixNano()%int64(len(firstNames))] + " " + lastNames[time.Now().UnixNano()%int64(len(lastNames))]
-}
-
 // Helpers
 
 func parsePercentageCoords(coord string) (float64, float64, error) {
@@ -7,7 +7,6 @@ import (
 	"strings"
 	"testing"
 	"time"
-	"unicode"
 
 	"github.com/devicelab-dev/maestro-runner/pkg/flow"
 )
@@ -16,101 +15,6 @@ import (
 // Pure function tests
 // =============================================================================
 
-func TestRandomString(t *testing.T) {
-	tests := []struct {
-		name   string
-		length int
-	}{
-		{"length 1", 1},
-		{"length 5", 5},
-		{"length 10", 10},
-		{"length 20", 20},
-	}
-
-	for _, tc := range tests {
-		t.Run(tc.name, func(t *testing.T) {
-			result := randomString(tc.length)
-			if len(result) != tc.length {
-				t.Fatalf("expected length %d, got %d", tc.length, len(result))
-			}
-			for _, c := range result {
-				if !unicode.IsLetter(c) && !unicode.IsDigit(c) {
-					t.Fatalf("unexpected character %q in random string", c)
-				}
-			}
-		})
-	}
-}
-
-func TestRandomStringUniqueness(t *testing.T) {
-	// Generate several strings and verify they are not all identical
-	results := make(map[string]bool)
-	for i := 0; i < 5; i++ {
-		results[randomString(8)] = true
-	}
-	if len(results) < 2 {
-		t.Fatalf("expected at least 2 unique strings out of 5, got %d", len(results))
-	}
-}
-
-func TestRandomEmail(t *testing.T) {
-	email := randomEmail()
-	if !strings.HasSuffix(email, "@example.com") {
-		t.Fatalf("expected email to end with @example.com, got %q", email)
-	}
-	localPart := strings.TrimSuffix(email, "@example.com")
-	if len(localPart) != 8 {
-		t.Fatalf("expected local part length 8, got %d", len(localPart))
-	}
-}
-
-func TestRandomNumber(t *testing.T) {
-	tests := []struct {
-		name   string
-		length int
-	}{
-		{"length 1", 1},
-		{"length 5", 5},
-		{"length 10", 10},
-	}
-
-	for _, tc := range tests {
-		t.Run(tc.name, func(t *testing.T) {
-			result := randomNumber(tc.length)
-			if len(result) != tc.length {
-				t.Fatalf("expected length %d, got %d", tc.length, len(result))
-			}
-			for _, c := range result {
-				if !unicode.IsDigit(c) {
-					t.Fatalf("expected only digits, got %q", c)
-				}
-			}
-		})
-	}
-}
-
-func TestRandomPersonName(t *testing.T) {
-	name := randomPersonName()
-	parts := strings.SplitN(name, " ", 2)
-	if len(parts) != 2 {
-		t.Fatalf("expected first and last name separated by space, got %q", name)
-	}
-
-	validFirstNames := map[string]bool{
-		"John": true, "Jane": true, "Michael": true, "Emily": true, "David": true,
-	}
-	validLastNames := map[string]bool{
-		"Smith": true, "Johnson": true, "Williams": true, "Brown": true, "Jones": true,
-	}
-
-	if !validFirstNames[parts[0]] {
-		t.Fatalf("unexpected first name %q", parts[0])
-	}
-	if !validLastNames[parts[1]] {
-		t.Fatalf("unexpected last name %q", parts[1])
-	}
-}
-
 func TestEscapeIOSPredicateString(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -471,6 +375,32 @@ func TestWaitUntilDefaultTimeout(t *testing.T) {
 	}
 }
 
+func TestStopAppGraceful(t *testing.T) {
+	var calls []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/appium/device/press_keycode"):
+			calls = append(calls, "home")
+		case strings.HasSuffix(r.URL.Path, "/appium/device/terminate_app"):
+			calls = append(calls, "terminate")
+		}
+		writeJSON(w, map[string]interface{}{"value": nil})
+	}))
+	defer server.Close()
+	driver := createTestAppiumDriver(server)
+
+	step := &flow.StopAppStep{AppID: "com.test.app", Graceful: true}
+	result := driver.stopApp(step)
+
+	if !result.Success {
+		t.Fatalf("expected success, got error: %v", result.Error)
+	}
+	if len(calls) != 2 || calls[0] != "home" || calls[1] != "terminate" {
+		t.Errorf("expected home then terminate, got %v", calls)
+	}
+}
+
 func TestKillApp(t *testing.T) {
 	server := mockAppiumServerForDriver()
 	defer server.Close()
@@ -47,12 +47,9 @@ func NewDriver(serverURL string, capabilities map[string]interface{}) (*Driver,
 
 	// Track waitForIdleTimeout if set via appium:settings capability
 	if settings, ok := capabilities["appium:settings"].(map[string]interface{}); ok {
-		if val, ok := settings["waitForIdleTimeout"].(int); ok {
+		if val, ok := CapabilityInt(settings["waitForIdleTimeout"]); ok {
 			d.currentWaitForIdleTimeout = val
 			d.waitForIdleTimeoutSet = true
-		} else if val, ok := settings["waitForIdleTimeout"].(float64); ok {
-			d.currentWaitForIdleTimeout = int(val)
-			d.waitForIdleTimeoutSet = true
 		}
 	}
 
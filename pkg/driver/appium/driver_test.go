@@ -184,6 +184,24 @@ func TestNewDriverAppium(t *testing.T) {
 	}
 }
 
+// TestNewDriverCapsFileWaitForIdleTimeout tests that a waitForIdleTimeout
+// setting decoded from a caps file is tracked
+func TestNewDriverCapsFileWaitForIdleTimeout(t *testing.T) {
+	server := mockAppiumServerForDriver()
+	defer server.Close()
+
+	caps := map[string]interface{}{
+		"appium:settings": map[string]interface{}{"waitForIdleTimeout": json.Number("1500")},
+	}
+	driver, err := NewDriver(server.URL, caps)
+	if err != nil {
+		t.Fatalf("NewDriver failed: %v", err)
+	}
+	if !driver.waitForIdleTimeoutSet || driver.currentWaitForIdleTimeout != 1500 {
+		t.Errorf("waitForIdleTimeout = %d (set %v), want 1500", driver.currentWaitForIdleTimeout, driver.waitForIdleTimeoutSet)
+	}
+}
+
 // TestExecuteTapOn tests tap on element
 func TestExecuteAppiumTapOn(t *testing.T) {
 	server := mockAppiumServerForDriver()
@@ -2,11 +2,17 @@ package uiautomator2
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/xml"
 	"fmt"
-	"math/rand"
+	"math"
+	"os"
+	"path"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
+	"unicode/utf16"
 
 	"github.com/devicelab-dev/maestro-runner/pkg/core"
 	"github.com/devicelab-dev/maestro-runner/pkg/flow"
@@ -19,12 +25,31 @@ import (
 // ============================================================================
 
 func (d *Driver) tapOn(step *flow.TapOnStep) *core.CommandResult {
+	if step.WebView {
+		return d.tapOnWebView(step)
+	}
+
 	// Check if using percentage-based Point WITHOUT selector (screen-relative tap)
 	if step.Point != "" && step.Selector.IsEmpty() {
 		return d.tapOnPointWithPercentage(step.Point)
 	}
 
-	elem, info, err := d.findElementForTap(step.Selector, step.IsOptional(), step.TimeoutMs)
+	if step.WaitToSettleTimeoutMs > 0 {
+		if _, settled, err := d.waitForScreenToSettle(step.WaitToSettleTimeoutMs); err != nil {
+			logger.Warn("tapOn: waitToSettle failed: %v", err)
+		} else if !settled {
+			logger.Debug("tapOn: screen still changing after %dms, tapping anyway", step.WaitToSettleTimeoutMs)
+		}
+	}
+
+	var elem *uiautomator2.Element
+	var info *core.ElementInfo
+	var err error
+	if step.TapParent {
+		info, err = d.findTapParent(step.Selector, step.IsOptional(), step.TimeoutMs)
+	} else {
+		elem, info, err = d.findElementForTap(step.Selector, step.IsOptional(), step.TimeoutMs)
+	}
 	if err != nil {
 		return errorResult(err, fmt.Sprintf("Element not found: %v", err))
 	}
@@ -33,6 +58,7 @@ func (d *Driver) tapOn(step *flow.TapOnStep) *core.CommandResult {
 	}
 
 	// If Point is specified WITH selector, tap at relative position within element bounds
+	limit := step.TapLimit()
 	if step.Point != "" && info.Bounds.Width > 0 {
 		xPct, yPct, parseErr := parsePercentageCoords(step.Point)
 		if parseErr != nil {
@@ -40,25 +66,74 @@ func (d *Driver) tapOn(step *flow.TapOnStep) *core.CommandResult {
 		}
 		x := info.Bounds.X + int(float64(info.Bounds.Width)*xPct)
 		y := info.Bounds.Y + int(float64(info.Bounds.Height)*yPct)
-		if err := d.client.Click(x, y); err != nil {
+		taps, err := d.tapUntilChanged(limit, func() error { return d.client.Click(x, y) })
+		if err != nil {
 			return errorResult(err, fmt.Sprintf("Failed to tap at relative point: %v", err))
 		}
-		return successResult(fmt.Sprintf("Tapped at relative point (%d, %d) on element", x, y), info)
+		return successResult(fmt.Sprintf("Tapped at relative point (%d, %d) on element%s", x, y, tapCountSuffix(taps, limit)), info)
+	}
+
+	// Fixed pixel offset from the element's top-left corner (e.g. a small close icon)
+	if dx, dy, ok := step.PixelOffset(); ok {
+		x, y := info.Bounds.Offset(dx, dy)
+		taps, err := d.tapUntilChanged(limit, func() error { return d.client.Click(x, y) })
+		if err != nil {
+			return errorResult(err, fmt.Sprintf("Failed to tap at offset: %v", err))
+		}
+		return successResult(fmt.Sprintf("Tapped at offset (%d, %d) on element%s", x, y, tapCountSuffix(taps, limit)), info)
 	}
 
 	// For relative selectors, elem is nil but we have bounds - tap at center
 	if elem == nil {
 		x, y := info.Bounds.Center()
-		if err := d.client.Click(x, y); err != nil {
+		taps, err := d.tapUntilChanged(limit, func() error { return d.client.Click(x, y) })
+		if err != nil {
 			return errorResult(err, fmt.Sprintf("Failed to tap at coordinates: %v", err))
 		}
-	} else {
-		if err := elem.Click(); err != nil {
-			return errorResult(err, fmt.Sprintf("Failed to tap: %v", err))
+		return successResult("Tapped on element"+tapCountSuffix(taps, limit), info)
+	}
+
+	taps, err := d.tapUntilChanged(limit, func() error { return d.uiChanged(elem.Click()) })
+	if err != nil {
+		return errorResult(err, fmt.Sprintf("Failed to tap: %v", err))
+	}
+	return successResult("Tapped on element"+tapCountSuffix(taps, limit), info)
+}
+
+// tapChangeDelay is how long retryTapIfNoChange waits after a tap before
+// re-reading the page source, so the UI has time to start its transition.
+const tapChangeDelay = 300 * time.Millisecond
+
+// tapUntilChanged calls tap, then taps again while the page source is
+// unchanged, up to limit taps in total. With a limit of 1 it taps once without
+// reading the source. Returns how many taps were issued.
+func (d *Driver) tapUntilChanged(limit int, tap func() error) (int, error) {
+	if limit <= 1 {
+		return 1, tap()
+	}
+	before, srcErr := d.client.Source()
+	taps := 0
+	for {
+		if err := tap(); err != nil {
+			return taps, err
+		}
+		taps++
+		if taps >= limit || srcErr != nil {
+			return taps, nil
+		}
+		time.Sleep(tapChangeDelay)
+		if after, err := d.client.Source(); err != nil || after != before {
+			return taps, nil
 		}
 	}
+}
 
-	return successResult("Tapped on element", info)
+// tapCountSuffix reports the taps issued when retryTapIfNoChange allowed more than one.
+func tapCountSuffix(taps, limit int) string {
+	if limit <= 1 {
+		return ""
+	}
+	return fmt.Sprintf(" (%d of max %d taps)", taps, limit)
 }
 
 // tapOnPointWithPercentage handles percentage-based tap (e.g., "85%, 50%")
@@ -89,6 +164,69 @@ func (d *Driver) tapOnPointWithPercentage(point string) *core.CommandResult {
 	return successResult(fmt.Sprintf("Tapped at (%d, %d)", x, y), nil)
 }
 
+// tapSequence resolves every target first, then taps them back to back with
+// the step's delay between taps.
+func (d *Driver) tapSequence(step *flow.TapSequenceStep) *core.CommandResult {
+	if len(step.Taps) == 0 {
+		return errorResult(fmt.Errorf("no taps specified"), "tapSequence requires at least one tap")
+	}
+
+	points := make([][2]int, len(step.Taps))
+	for i, target := range step.Taps {
+		x, y, err := d.resolveTapTarget(target, step.TimeoutMs)
+		if err != nil {
+			return errorResult(err, fmt.Sprintf("Tap %d of %d: %v", i+1, len(step.Taps), err))
+		}
+		points[i] = [2]int{x, y}
+	}
+
+	delay := time.Duration(step.Delay()) * time.Millisecond
+	for i, p := range points {
+		if i > 0 {
+			time.Sleep(delay)
+		}
+		if err := d.client.Click(p[0], p[1]); err != nil {
+			return errorResult(err, fmt.Sprintf("Tap %d of %d at (%d, %d) failed: %v", i+1, len(points), p[0], p[1], err))
+		}
+	}
+
+	return successResult(fmt.Sprintf("Tapped %d points in sequence", len(points)), nil)
+}
+
+// resolveTapTarget returns the screen coordinates for one tapSequence target:
+// a bare point is a screen percentage, a point with a selector is relative to
+// the element, and a selector alone is the element's center.
+func (d *Driver) resolveTapTarget(target flow.Selector, timeoutMs int) (int, int, error) {
+	if target.IsEmpty() {
+		if target.Point == "" {
+			return 0, 0, fmt.Errorf("tap needs a selector or a point")
+		}
+		width, height, err := d.getScreenSize()
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to get screen size: %w", err)
+		}
+		xPct, yPct, err := parsePercentageCoords(target.Point)
+		if err != nil {
+			return 0, 0, err
+		}
+		return int(float64(width) * xPct), int(float64(height) * yPct), nil
+	}
+
+	_, info, err := d.findElementForTap(target, false, timeoutMs)
+	if err != nil {
+		return 0, 0, fmt.Errorf("element not found: %w", err)
+	}
+	if target.Point != "" {
+		xPct, yPct, err := parsePercentageCoords(target.Point)
+		if err != nil {
+			return 0, 0, err
+		}
+		return info.Bounds.X + int(float64(info.Bounds.Width)*xPct), info.Bounds.Y + int(float64(info.Bounds.Height)*yPct), nil
+	}
+	x, y := info.Bounds.Center()
+	return x, y, nil
+}
+
 func (d *Driver) doubleTapOn(step *flow.DoubleTapOnStep) *core.CommandResult {
 	elem, info, err := d.findElementForTap(step.Selector, step.IsOptional(), step.TimeoutMs)
 	if err != nil {
@@ -111,26 +249,57 @@ func (d *Driver) doubleTapOn(step *flow.DoubleTapOnStep) *core.CommandResult {
 }
 
 func (d *Driver) longPressOn(step *flow.LongPressOnStep) *core.CommandResult {
+	duration := step.Duration()
+
+	// Percentage point without a selector presses on the screen
+	if step.Point != "" && step.Selector.IsEmpty() {
+		if d.device == nil {
+			return errorResult(fmt.Errorf("device not configured"), "longPressOn with percentage point requires device access")
+		}
+		width, height, err := d.getScreenSize()
+		if err != nil {
+			return errorResult(err, fmt.Sprintf("Failed to get screen size: %v", err))
+		}
+		xPct, yPct, err := parsePercentageCoords(step.Point)
+		if err != nil {
+			return errorResult(err, fmt.Sprintf("Invalid point coordinates: %v", err))
+		}
+		x, y := int(float64(width)*xPct), int(float64(height)*yPct)
+		if err := d.client.LongClick(x, y, duration); err != nil {
+			return errorResult(err, fmt.Sprintf("Failed to long press at point: %v", err))
+		}
+		return successResult(fmt.Sprintf("Long pressed at (%d, %d) for %dms", x, y, duration), nil)
+	}
+
 	elem, info, err := d.findElementForTap(step.Selector, step.IsOptional(), step.TimeoutMs)
 	if err != nil {
 		return errorResult(err, fmt.Sprintf("Element not found: %v", err))
 	}
 
-	duration := 1000 // default 1 second
-
-	// For relative selectors, elem is nil but we have bounds - long press at center
-	if elem == nil {
+	switch {
+	case step.Point != "":
+		xPct, yPct, err := parsePercentageCoords(step.Point)
+		if err != nil {
+			return errorResult(err, fmt.Sprintf("Invalid point coordinates: %v", err))
+		}
+		x := info.Bounds.X + int(float64(info.Bounds.Width)*xPct)
+		y := info.Bounds.Y + int(float64(info.Bounds.Height)*yPct)
+		if err := d.client.LongClick(x, y, duration); err != nil {
+			return errorResult(err, fmt.Sprintf("Failed to long press at relative point: %v", err))
+		}
+	case elem == nil:
+		// For relative selectors, elem is nil but we have bounds - long press at center
 		x, y := info.Bounds.Center()
 		if err := d.client.LongClick(x, y, duration); err != nil {
 			return errorResult(err, fmt.Sprintf("Failed to long press at coordinates: %v", err))
 		}
-	} else {
+	default:
 		if err := d.client.LongClickElement(elem.ID(), duration); err != nil {
 			return errorResult(err, fmt.Sprintf("Failed to long press: %v", err))
 		}
 	}
 
-	return successResult("Long pressed on element", info)
+	return successResult(fmt.Sprintf("Long pressed on %s for %dms", step.Selector.Describe(), duration), info)
 }
 
 func (d *Driver) tapOnPoint(step *flow.TapOnPointStep) *core.CommandResult {
@@ -174,47 +343,664 @@ func (d *Driver) tapOnPoint(step *flow.TapOnPointStep) *core.CommandResult {
 // ============================================================================
 
 func (d *Driver) assertVisible(step *flow.AssertVisibleStep) *core.CommandResult {
-	// Use findElementFast - only need to check element exists (1 HTTP call vs 3)
-	_, info, err := d.findElementFast(step.Selector, step.IsOptional(), step.TimeoutMs)
+	if step.WebView {
+		return d.assertVisibleWebView(step)
+	}
+	if step.Count != nil {
+		return d.assertVisibleCount(step)
+	}
+
+	// Use findElementFast - only need to check element exists (1 HTTP call vs 3).
+	// The fully-visible check needs bounds, so it pays for the full lookup.
+	find := d.findElementFast
+	if step.FullyVisible {
+		find = d.findElement
+	}
+	_, info, err := find(step.Selector, step.IsOptional(), step.TimeoutMs)
 	if err != nil {
+		if stateErr := d.stateMismatch(step.Selector); stateErr != nil {
+			return errorResult(stateErr, stateErr.Error())
+		}
 		return errorResult(err, fmt.Sprintf("Element not visible: %v", err))
 	}
 
 	// info.Visible is already set by findElementFast
 	if info != nil && info.Visible {
+		if step.FullyVisible {
+			return d.checkFullyVisible(info)
+		}
 		return successResult("Element is visible", info)
 	}
 
 	return errorResult(fmt.Errorf("element not visible"), "Element exists but is not visible")
 }
 
+// assertVisibleCount polls the page source until the number of displayed
+// matches satisfies the step's count expectation or the timeout elapses.
+func (d *Driver) assertVisibleCount(step *flow.AssertVisibleStep) *core.CommandResult {
+	if err := step.ValidateCount(); err != nil {
+		return errorResult(err, err.Error())
+	}
+	if step.Selector.HasRelativeSelector() {
+		err := fmt.Errorf("count does not support relative selectors")
+		return errorResult(err, err.Error())
+	}
+
+	deadline := time.Now().Add(d.calculateTimeout(step.IsOptional(), step.TimeoutMs))
+
+	for {
+		elements, err := d.pageSourceElements()
+		if err != nil {
+			return errorResult(err, fmt.Sprintf("Failed to read page source: %v", err))
+		}
+		var visible []*ParsedElement
+		for _, el := range FilterBySelector(elements, step.Selector) {
+			if el.Displayed {
+				visible = append(visible, el)
+			}
+		}
+		// A matching container and its matching label are one element
+		found := len(innermostMatches(visible))
+		mismatch := step.CheckCount(found)
+		if mismatch == nil {
+			return successResult(fmt.Sprintf("Found %d visible '%s' elements", found, step.Selector.Describe()), nil)
+		}
+		if time.Now().After(deadline) {
+			return errorResult(mismatch, mismatch.Error())
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+}
+
+// stateMismatch explains why a selector with enabled/checked/selected/focused
+// filters found nothing: it looks the element up once without those filters
+// and reports the first state that differs. It returns nil when the selector
+// has no state filters or no element matches the rest of it.
+func (d *Driver) stateMismatch(sel flow.Selector) error {
+	if !sel.HasStateFilter() {
+		return nil
+	}
+	base := sel.WithoutStateFilter()
+	var info *core.ElementInfo
+	var err error
+	if base.HasRelativeSelector() {
+		info, err = d.resolveRelativeSelector(base)
+	} else {
+		info, err = d.findElementByPageSourceOnceInternal(base)
+	}
+	if err != nil {
+		return nil
+	}
+	return core.CheckElementState(sel, info)
+}
+
+// checkFullyVisible fails when the element's bounds extend past the screen.
+func (d *Driver) checkFullyVisible(info *core.ElementInfo) *core.CommandResult {
+	width, height, err := d.getScreenSize()
+	if err != nil {
+		return errorResult(err, "Failed to get screen size")
+	}
+	if overflow := info.Bounds.Overflow(width, height); overflow != "" {
+		return errorResult(fmt.Errorf("element clipped: %s", overflow),
+			fmt.Sprintf("Element is not fully visible: %s", overflow))
+	}
+	return successResult("Element is fully visible", info)
+}
+
+// ============================================================================
+// WebView Commands
+// ============================================================================
+
+// tapOnWebView clicks a DOM element by text inside the app's WebView.
+func (d *Driver) tapOnWebView(step *flow.TapOnStep) *core.CommandResult {
+	err := d.inWebView(func() error {
+		elem, err := d.findWebElement(step.Selector.Text, d.calculateTimeout(step.IsOptional(), step.TimeoutMs))
+		if err != nil {
+			return err
+		}
+		return d.uiChanged(elem.Click())
+	})
+	if err != nil {
+		return errorResult(err, fmt.Sprintf("WebView tap failed: %v", err))
+	}
+	return successResult(fmt.Sprintf("Tapped on web element: %q", step.Selector.Text), nil)
+}
+
+// assertVisibleWebView checks that a DOM element with the text is displayed.
+func (d *Driver) assertVisibleWebView(step *flow.AssertVisibleStep) *core.CommandResult {
+	err := d.inWebView(func() error {
+		elem, err := d.findWebElement(step.Selector.Text, d.calculateTimeout(step.IsOptional(), step.TimeoutMs))
+		if err != nil {
+			return err
+		}
+		if displayed, err := elem.IsDisplayed(); err == nil && !displayed {
+			return fmt.Errorf("web element %q exists but is not displayed", step.Selector.Text)
+		}
+		return nil
+	})
+	if err != nil {
+		return errorResult(err, fmt.Sprintf("Web element not visible: %v", err))
+	}
+	return successResult(fmt.Sprintf("Web element is visible: %q", step.Selector.Text), nil)
+}
+
+// inWebView switches to the app's WebView context for fn and always
+// switches back to the native context afterwards.
+func (d *Driver) inWebView(fn func() error) error {
+	contexts, err := d.client.Contexts()
+	if err != nil {
+		return fmt.Errorf("failed to list contexts: %w", err)
+	}
+	webContext := ""
+	for _, c := range contexts {
+		if strings.HasPrefix(c, "WEBVIEW") || strings.HasPrefix(c, "CHROMIUM") {
+			webContext = c
+			break
+		}
+	}
+	if webContext == "" {
+		return fmt.Errorf("no WebView context available (is WebView debugging enabled?)")
+	}
+
+	if err := d.client.SetContext(webContext); err != nil {
+		return fmt.Errorf("failed to switch to %s: %w", webContext, err)
+	}
+	defer func() {
+		if err := d.client.SetContext(uiautomator2.NativeContext); err != nil {
+			logger.Warn("failed to switch back to %s: %v", uiautomator2.NativeContext, err)
+		}
+	}()
+
+	return fn()
+}
+
+// findWebElement polls the DOM for an element whose own text contains text.
+func (d *Driver) findWebElement(text string, timeout time.Duration) (*uiautomator2.Element, error) {
+	if text == "" {
+		return nil, fmt.Errorf("webView lookups require a text selector")
+	}
+	xpath := fmt.Sprintf("//*[text()[contains(normalize-space(.), %s)]]", xpathLiteral(text))
+
+	deadline := time.Now().Add(timeout)
+	for {
+		elem, err := d.client.FindElement("xpath", xpath)
+		if err == nil && elem != nil {
+			return elem, nil
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("web element with text %q not found", text)
+		}
+		time.Sleep(250 * time.Millisecond)
+	}
+}
+
+// xpathLiteral quotes s as an XPath 1.0 string literal. XPath has no escape
+// sequences, so text containing both quote kinds is built with concat().
+func xpathLiteral(s string) string {
+	if !strings.Contains(s, "'") {
+		return "'" + s + "'"
+	}
+	if !strings.Contains(s, `"`) {
+		return `"` + s + `"`
+	}
+	parts := strings.Split(s, "'")
+	for i, p := range parts {
+		parts[i] = "'" + p + "'"
+	}
+	return "concat(" + strings.Join(parts, `, "'", `) + ")"
+}
+
+// notVisiblePollInterval is the pause between assertNotVisible checks.
+const notVisiblePollInterval = 250 * time.Millisecond
+
+// assertNotVisible passes only if no check during the confirmation window finds
+// a match; the first sighting fails the step.
 func (d *Driver) assertNotVisible(step *flow.AssertNotVisibleStep) *core.CommandResult {
-	// Poll until element is NOT visible (or timeout)
-	// Used to verify element has disappeared after an action
+	deadline := time.Now().Add(time.Duration(step.Window()) * time.Millisecond)
+
+	for {
+		if d.elementPresent(step.Selector) {
+			return errorResult(fmt.Errorf("element is visible"),
+				fmt.Sprintf("Element should not be visible: %s", step.Selector.Describe()))
+		}
+		if time.Now().After(deadline) {
+			return successResult("Element is not visible", nil)
+		}
+		d.invalidateSource()
+		time.Sleep(notVisiblePollInterval)
+	}
+}
+
+// elementPresent reports whether anything matches sel in a single check.
+// findElementOnce only falls back to page source for text selectors, so id
+// selectors get the same fallback here; otherwise an id UiAutomator misses
+// would count as absent on Android while iOS reports it present.
+func (d *Driver) elementPresent(sel flow.Selector) bool {
+	if _, info, err := d.findElementOnce(sel); err == nil && info != nil {
+		return true
+	}
+	if sel.ID == "" || sel.Text != "" {
+		return false
+	}
+	_, info, err := d.findElementByPageSourceOnce(sel)
+	return err == nil && info != nil
+}
+
+// assertResource checks the app's total PSS (dumpsys meminfo) and CPU usage (top)
+// against the step's limits and reports the observed values.
+func (d *Driver) assertResource(step *flow.AssertResourceStep) *core.CommandResult {
+	if d.device == nil {
+		return errorResult(fmt.Errorf("device not configured"), "assertResource requires device access")
+	}
+	if step.AppID == "" {
+		return errorResult(fmt.Errorf("no appId specified"), "assertResource requires appId")
+	}
+	if step.MaxMemoryMB <= 0 && step.MaxCPUPercent <= 0 {
+		return errorResult(fmt.Errorf("no threshold specified"), "assertResource requires maxMemoryMb or maxCpuPercent")
+	}
+
+	var observed []string
+
+	if step.MaxMemoryMB > 0 {
+		output, err := d.device.Shell("dumpsys meminfo " + step.AppID)
+		if err != nil {
+			return errorResult(err, fmt.Sprintf("Failed to read memory info: %v", err))
+		}
+		pssKB, err := parseMeminfoPSS(output)
+		if err != nil {
+			return errorResult(err, fmt.Sprintf("Failed to read memory for %s: %v", step.AppID, err))
+		}
+		memMB := float64(pssKB) / 1024
+		if memMB > step.MaxMemoryMB {
+			return errorResult(fmt.Errorf("memory %.1fMB exceeds %gMB", memMB, step.MaxMemoryMB),
+				fmt.Sprintf("Memory usage %.1fMB (PSS) exceeds limit %gMB", memMB, step.MaxMemoryMB))
+		}
+		observed = append(observed, fmt.Sprintf("memory %.1fMB", memMB))
+	}
+
+	if step.MaxCPUPercent > 0 {
+		pid, err := d.device.Shell("pidof " + step.AppID)
+		pid = strings.TrimSpace(pid)
+		if err != nil || pid == "" {
+			return errorResult(fmt.Errorf("app %s is not running", step.AppID), fmt.Sprintf("No process found for %s", step.AppID))
+		}
+		output, err := d.device.Shell(fmt.Sprintf("top -b -q -n 1 -p %s -o %%CPU", strings.Fields(pid)[0]))
+		if err != nil {
+			return errorResult(err, fmt.Sprintf("Failed to read CPU usage: %v", err))
+		}
+		cpu, err := parseTopCPU(output)
+		if err != nil {
+			return errorResult(err, fmt.Sprintf("Failed to read CPU usage for %s: %v", step.AppID, err))
+		}
+		if cpu > step.MaxCPUPercent {
+			return errorResult(fmt.Errorf("cpu %.1f%% exceeds %g%%", cpu, step.MaxCPUPercent),
+				fmt.Sprintf("CPU usage %.1f%% exceeds limit %g%%", cpu, step.MaxCPUPercent))
+		}
+		observed = append(observed, fmt.Sprintf("cpu %.1f%%", cpu))
+	}
+
+	return successResult("Resource usage within limits: "+strings.Join(observed, ", "), nil)
+}
+
+// parseMeminfoPSS extracts the total PSS in KB from `dumpsys meminfo <pkg>` output.
+// Prefers the App Summary "TOTAL PSS:" line, falling back to the TOTAL row of the table.
+func parseMeminfoPSS(output string) (int, error) {
+	if strings.Contains(output, "No process found") {
+		return 0, fmt.Errorf("app is not running")
+	}
+
+	var tableTotal string
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 3 && fields[0] == "TOTAL" && fields[1] == "PSS:" {
+			if kb, err := strconv.Atoi(fields[2]); err == nil {
+				return kb, nil
+			}
+		}
+		if tableTotal == "" && len(fields) >= 2 && fields[0] == "TOTAL" {
+			tableTotal = fields[1]
+		}
+	}
+
+	if kb, err := strconv.Atoi(tableTotal); err == nil {
+		return kb, nil
+	}
+	return 0, fmt.Errorf("TOTAL PSS not found in meminfo output")
+}
+
+// parseTopCPU extracts the %CPU value from `top -o %CPU` output (header optional).
+func parseTopCPU(output string) (float64, error) {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		field := strings.TrimSpace(lines[i])
+		if field == "" || field == "%CPU" {
+			continue
+		}
+		cpu, err := strconv.ParseFloat(field, 64)
+		if err != nil {
+			return 0, fmt.Errorf("unexpected top output %q", field)
+		}
+		return cpu, nil
+	}
+	return 0, fmt.Errorf("empty top output")
+}
+
+// assertFieldValue reads a field's text (an EditText's value on Android) and
+// checks it against the step's condition.
+func (d *Driver) assertFieldValue(step *flow.AssertFieldValueStep) *core.CommandResult {
+	matcher, err := core.NewTextMatcher(step.Equals, step.Contains, "")
+	if err != nil {
+		return errorResult(err, fmt.Sprintf("Invalid assertFieldValue condition: %v", err))
+	}
+
+	elem, info, err := d.findElement(step.Element, step.IsOptional(), step.TimeoutMs)
+	if err != nil {
+		return errorResult(err, fmt.Sprintf("Field not found: %s", step.Element.Describe()))
+	}
+
+	var value string
+	if elem != nil {
+		if value, err = elem.Text(); err != nil {
+			return errorResult(err, fmt.Sprintf("Failed to read field value: %v", err))
+		}
+	} else if info != nil {
+		value = info.Text
+	}
+
+	if !matcher.Match(value) {
+		return errorResult(fmt.Errorf("field value mismatch"),
+			fmt.Sprintf("Expected %s to %s, got %q", step.Element.Describe(), matcher, value))
+	}
+	return successResult(fmt.Sprintf("Field value: %q", value), info)
+}
+
+// shareSheetPollInterval is how often assertShareTarget re-reads the hierarchy
+// while waiting for the chooser to open or fill in.
+const shareSheetPollInterval = 300 * time.Millisecond
+
+// assertShareTarget waits for the system chooser (ACTION_CHOOSER, hosted by
+// "android" or the IntentResolver module on Android 14+) and checks whether it
+// lists the app.
+func (d *Driver) assertShareTarget(step *flow.AssertShareTargetStep) *core.CommandResult {
+	if strings.TrimSpace(step.App) == "" {
+		return errorResult(fmt.Errorf("no app specified"), "assertShareTarget requires app")
+	}
 	timeout := step.TimeoutMs
 	if timeout <= 0 {
 		timeout = 5000
 	}
+	deadline := time.Now().Add(time.Duration(timeout) * time.Millisecond)
+
+	sheet := false
+	for {
+		elements, err := d.pageSourceElements()
+		if err != nil {
+			return errorResult(err, fmt.Sprintf("Failed to read page source: %v", err))
+		}
+		var target *ParsedElement
+		sheet, target = findShareTarget(elements, step.App)
+		switch {
+		case sheet && target != nil && step.ExpectPresent():
+			return successResult(fmt.Sprintf("Share sheet lists %q", step.App), &core.ElementInfo{
+				Text: target.Text, Bounds: target.Bounds, Visible: true,
+			})
+		case sheet && target != nil:
+			return errorResult(fmt.Errorf("share target present"), fmt.Sprintf("Share sheet should not list %q", step.App))
+		case sheet && !step.ExpectPresent():
+			return successResult(fmt.Sprintf("Share sheet does not list %q", step.App), nil)
+		}
+		if time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(shareSheetPollInterval)
+	}
+
+	if !sheet {
+		return errorResult(fmt.Errorf("share sheet not found"), fmt.Sprintf("Share sheet not found within %dms", timeout))
+	}
+	return errorResult(fmt.Errorf("share target not found"), fmt.Sprintf("Share sheet does not list %q", step.App))
+}
+
+// shareSheetPackages are the resource-id prefixes of the system chooser.
+var shareSheetPackages = []string{"android:id/", "com.android.intentresolver:id/", "com.google.android.intentresolver:id/"}
+
+// findShareTarget reports whether a chooser list is on screen and returns the
+// entry inside it whose label matches app (case-insensitive).
+func findShareTarget(elements []*ParsedElement, app string) (bool, *ParsedElement) {
+	sheet := false
+	for _, e := range elements {
+		if !isShareSheetList(e.ResourceID) {
+			continue
+		}
+		sheet = true
+		if target := findLabelled(e, app); target != nil {
+			return true, target
+		}
+	}
+	return sheet, nil
+}
+
+func isShareSheetList(resourceID string) bool {
+	for _, prefix := range shareSheetPackages {
+		if name, ok := strings.CutPrefix(resourceID, prefix); ok {
+			return name == "resolver_list" || name == "chooser_list" || name == "resolver_grid"
+		}
+	}
+	return false
+}
+
+func findLabelled(e *ParsedElement, label string) *ParsedElement {
+	label = strings.TrimSpace(label)
+	if strings.EqualFold(strings.TrimSpace(e.Text), label) || strings.EqualFold(strings.TrimSpace(e.ContentDesc), label) {
+		return e
+	}
+	for _, child := range e.Children {
+		if found := findLabelled(child, label); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// assertTextNotContains fails when any text on screen contains a forbidden
+// substring or matches a forbidden pattern.
+func (d *Driver) assertTextNotContains(step *flow.AssertTextNotContainsStep) *core.CommandResult {
+	elements, err := d.pageSourceElements()
+	if err != nil {
+		return errorResult(err, fmt.Sprintf("Failed to read page source: %v", err))
+	}
+
+	texts := readAllText(elements)
+	hit, err := core.FindForbiddenText(texts, step.Text, step.Regex)
+	if err != nil {
+		return errorResult(err, fmt.Sprintf("assertTextNotContains: %v", err))
+	}
+	if hit != "" {
+		return errorResult(fmt.Errorf("forbidden text on screen"), fmt.Sprintf("Forbidden text found on screen: %s", hit))
+	}
+	return successResult(fmt.Sprintf("No forbidden text in %d texts on screen", len(texts)), nil)
+}
+
+// readAllText collects the text and content-desc of every element with
+// on-screen bounds, plus the hint of empty fields, in page source order.
+// UIAutomator only dumps visible nodes, so there is no displayed flag to check.
+func readAllText(elements []*ParsedElement) []string {
+	var texts []string
+	for _, e := range elements {
+		if e.Bounds.Width <= 0 || e.Bounds.Height <= 0 {
+			continue
+		}
+		for _, t := range []string{e.Text, e.ContentDesc} {
+			if strings.TrimSpace(t) != "" {
+				texts = append(texts, t)
+			}
+		}
+		if e.Text == "" && strings.TrimSpace(e.HintText) != "" {
+			texts = append(texts, e.HintText)
+		}
+	}
+	return texts
+}
+
+// assertAccessible fails when a clickable element on screen has nothing for
+// TalkBack to announce. A clickable container counts as labelled when a
+// non-clickable descendant carries text, since TalkBack merges those.
+func (d *Driver) assertAccessible(_ *flow.AssertAccessibleStep) *core.CommandResult {
+	elements, err := d.pageSourceElements()
+	if err != nil {
+		return errorResult(err, fmt.Sprintf("Failed to read page source: %v", err))
+	}
+
+	checked := 0
+	var unlabeled []string
+	for _, e := range elements {
+		if !e.Clickable || e.Bounds.Width <= 0 || e.Bounds.Height <= 0 {
+			continue
+		}
+		checked++
+		if !hasAccessibleLabel(e) {
+			unlabeled = append(unlabeled, describeUnlabeled(e))
+		}
+	}
+
+	if len(unlabeled) > 0 {
+		return errorResult(fmt.Errorf("%d unlabeled interactive elements", len(unlabeled)),
+			fmt.Sprintf("%d of %d interactive elements have no accessibility label: %s", len(unlabeled), checked, strings.Join(unlabeled, "; ")))
+	}
+	return successResult(fmt.Sprintf("All %d interactive elements have accessibility labels", checked), nil)
+}
+
+func hasAccessibleLabel(e *ParsedElement) bool {
+	if strings.TrimSpace(e.Text) != "" || strings.TrimSpace(e.ContentDesc) != "" || strings.TrimSpace(e.HintText) != "" {
+		return true
+	}
+	for _, child := range e.Children {
+		if !child.Clickable && hasAccessibleLabel(child) {
+			return true
+		}
+	}
+	return false
+}
+
+// describeUnlabeled names an element by class, resource-id and bounds in the
+// same [left,top][right,bottom] form as the page source.
+func describeUnlabeled(e *ParsedElement) string {
+	name := e.ClassName
+	if e.ResourceID != "" {
+		name += " " + e.ResourceID
+	}
+	b := e.Bounds
+	return fmt.Sprintf("%s [%d,%d][%d,%d]", name, b.X, b.Y, b.X+b.Width, b.Y+b.Height)
+}
+
+// ResetFrameStats clears the app's gfxinfo frame counters before assertNoJank
+// runs its commands.
+func (d *Driver) ResetFrameStats(appID string) error {
+	if err := d.checkFrameStatsApp(appID); err != nil {
+		return err
+	}
+	if _, err := d.device.Shell(fmt.Sprintf("dumpsys gfxinfo %s reset", appID)); err != nil {
+		return fmt.Errorf("reset frame stats: %w", err)
+	}
+	return nil
+}
+
+// FrameStats reads the frames rendered since ResetFrameStats from dumpsys.
+func (d *Driver) FrameStats(appID string) (total, janky int, err error) {
+	if err := d.checkFrameStatsApp(appID); err != nil {
+		return 0, 0, err
+	}
+	out, err := d.device.Shell(fmt.Sprintf("dumpsys gfxinfo %s", appID))
+	if err != nil {
+		return 0, 0, fmt.Errorf("read frame stats: %w", err)
+	}
+	total, janky, err = parseGfxInfo(out)
+	if err != nil {
+		return 0, 0, fmt.Errorf("parse gfxinfo for %s: %w", appID, err)
+	}
+	return total, janky, nil
+}
+
+func (d *Driver) checkFrameStatsApp(appID string) error {
+	if !packageNamePattern.MatchString(appID) {
+		return fmt.Errorf("invalid appId %q", appID)
+	}
+	if d.device == nil {
+		return fmt.Errorf("device not configured")
+	}
+	return nil
+}
+
+// parseGfxInfo reads the frame counters from `dumpsys gfxinfo <pkg>`:
+//
+//	Total frames rendered: 240
+//	Janky frames: 12 (5.00%)
+func parseGfxInfo(out string) (total, janky int, err error) {
+	foundTotal, foundJanky := false, false
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		if v, ok := strings.CutPrefix(line, "Total frames rendered:"); ok && !foundTotal {
+			if total, err = strconv.Atoi(strings.TrimSpace(v)); err != nil {
+				return 0, 0, fmt.Errorf("bad total frames %q", v)
+			}
+			foundTotal = true
+		} else if v, ok := strings.CutPrefix(line, "Janky frames:"); ok && !foundJanky {
+			fields := strings.Fields(v)
+			if len(fields) == 0 {
+				return 0, 0, fmt.Errorf("bad janky frames %q", v)
+			}
+			if janky, err = strconv.Atoi(fields[0]); err != nil {
+				return 0, 0, fmt.Errorf("bad janky frames %q", v)
+			}
+			foundJanky = true
+		}
+	}
+	if !foundTotal || !foundJanky {
+		return 0, 0, fmt.Errorf("frame stats not found")
+	}
+	return total, janky, nil
+}
 
+// assertSorted collects the text (or content-desc) of every element matching the
+// step's selector, in page source order, and checks the values are sorted.
+func (d *Driver) assertSorted(step *flow.AssertSortedStep) *core.CommandResult {
+	timeout := step.TimeoutMs
+	if timeout <= 0 {
+		timeout = 5000
+	}
 	deadline := time.Now().Add(time.Duration(timeout) * time.Millisecond)
-	pollInterval := 500 * time.Millisecond
 
+	var matches []*ParsedElement
 	for {
-		// Quick check if element exists (no waiting)
-		_, info, err := d.findElementQuick(step.Selector, 0)
-		if err != nil || info == nil {
-			// Element not found = not visible = success
-			return successResult("Element is not visible", nil)
+		elements, err := d.pageSourceElements()
+		if err != nil {
+			return errorResult(err, fmt.Sprintf("Failed to read page source: %v", err))
+		}
+		matches = FilterBySelector(elements, step.Element)
+		if len(matches) > 0 || time.Now().After(deadline) {
+			break
 		}
+		time.Sleep(500 * time.Millisecond)
+	}
 
-		// Element still visible - check if we've timed out
-		if time.Now().After(deadline) {
-			return errorResult(fmt.Errorf("element is visible"), "Element should not be visible but was found")
+	if len(matches) == 0 {
+		return errorResult(fmt.Errorf("no elements found"),
+			fmt.Sprintf("No elements match %s", step.Element.Describe()))
+	}
+
+	values := make([]string, len(matches))
+	for i, el := range matches {
+		values[i] = el.Text
+		if values[i] == "" {
+			values[i] = el.ContentDesc
 		}
+	}
 
-		// Wait before next check
-		time.Sleep(pollInterval)
+	if err := core.CheckSorted(values, step.Pattern, step.Numeric, step.Order); err != nil {
+		return errorResult(err, fmt.Sprintf("Elements are not sorted: %v", err))
 	}
+
+	return successResult(fmt.Sprintf("%d elements are sorted", len(values)), nil)
 }
 
 // ============================================================================
@@ -233,24 +1019,47 @@ func (d *Driver) inputText(step *flow.InputTextStep) *core.CommandResult {
 		unicodeWarning = " (warning: non-ASCII characters may not input correctly)"
 	}
 
+	// Emoji are dropped by setText and plain key input; route them through
+	// the Unicode IME when the device has it installed.
+	if core.HasEmoji(text) {
+		if d.hasUnicodeIME() {
+			return d.inputTextUnicodeIME(step, unicodeWarning)
+		}
+		unicodeWarning += " (warning: Unicode IME " + unicodeIME + " not installed; emoji may be dropped)"
+	}
+
 	// keyPress mode: simulate real key presses via W3C Actions API.
 	// This triggers TextWatcher/onTextChanged per character (unlike setText injection).
 	if step.KeyPress {
-		if err := d.client.SendKeyActions(text); err != nil {
+		var active *uiautomator2.Element
+		var before string
+		if step.VerifyText {
+			var err error
+			if active, err = d.client.ActiveElement(); err != nil {
+				return errorResult(err, "No focused element to verify input text")
+			}
+			before, _ = active.Text()
+		}
+		if err := sendWithDelay(text, step.TypeDelayMs, d.client.SendKeyActions); err != nil {
 			return errorResult(err, "Failed to input text via key press")
 		}
-		return successResult(fmt.Sprintf("Entered text (keyPress): %s%s", text, unicodeWarning), nil)
+		if step.VerifyText {
+			retype := func() error { return sendWithDelay(text, step.TypeDelayMs, d.client.SendKeyActions) }
+			if result := verifyInputText(active, before, text, retype); result != nil {
+				return result
+			}
+		}
+		return successResult(fmt.Sprintf("Entered text (keyPress): %s%s%s", text, core.TypeDelayNote(text, step.TypeDelayMs), unicodeWarning), nil)
 	}
 
-	// If selector provided, find element and type into it
+	var target *uiautomator2.Element
 	if !step.Selector.IsEmpty() {
+		// If selector provided, find element and type into it
 		elem, _, err := d.findElement(step.Selector, step.IsOptional(), step.TimeoutMs)
 		if err != nil {
 			return errorResult(err, fmt.Sprintf("Element not found: %v", err))
 		}
-		if err := elem.SendKeys(text); err != nil {
-			return errorResult(err, fmt.Sprintf("Failed to input text: %v", err))
-		}
+		target = elem
 	} else {
 		// Type into focused element
 		// First try WebDriver activeElement endpoint
@@ -263,17 +1072,181 @@ func (d *Driver) inputText(step *flow.InputTextStep) *core.CommandResult {
 			if findErr != nil {
 				return errorResult(err, "No focused element to type into")
 			}
-			if err := elem.SendKeys(text); err != nil {
-				return errorResult(err, fmt.Sprintf("Failed to input text: %v", err))
+			active = elem
+		}
+		target = active
+	}
+
+	var before string
+	if step.VerifyText {
+		before, _ = target.Text()
+	}
+
+	if err := d.uiChanged(sendWithDelay(text, step.TypeDelayMs, target.SendKeys)); err != nil {
+		return errorResult(err, fmt.Sprintf("Failed to input text: %v", err))
+	}
+
+	if step.VerifyText {
+		retype := func() error { return d.uiChanged(sendWithDelay(text, step.TypeDelayMs, target.SendKeys)) }
+		if result := verifyInputText(target, before, text, retype); result != nil {
+			return result
+		}
+	}
+
+	return successResult(fmt.Sprintf("Entered text: %s%s%s", text, core.TypeDelayNote(text, step.TypeDelayMs), unicodeWarning), nil)
+}
+
+// sendWithDelay sends text in one call, or with delayMs > 0 one grapheme per
+// call with a pause between them, for keyboards that drop characters when a
+// whole string arrives at once.
+func sendWithDelay(text string, delayMs int, send func(string) error) error {
+	if delayMs <= 0 {
+		return send(text)
+	}
+	for i, g := range core.Graphemes(text) {
+		if i > 0 {
+			time.Sleep(time.Duration(delayMs) * time.Millisecond)
+		}
+		if err := send(g); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unicodeIME is the Appium Settings input method. It decodes IMAP-style
+// modified UTF-7 from key events, so any Unicode text can be typed as ASCII.
+const unicodeIME = "io.appium.settings/.UnicodeIME"
+
+// hasUnicodeIME reports whether the Unicode IME is installed and enabled.
+func (d *Driver) hasUnicodeIME() bool {
+	if d.device == nil {
+		return false
+	}
+	output, err := d.device.Shell("ime list -s")
+	return err == nil && strings.Contains(output, unicodeIME)
+}
+
+// inputTextUnicodeIME switches to the Unicode IME, types the encoded text as
+// key presses into the target (or focused) field, then restores the
+// previous input method.
+func (d *Driver) inputTextUnicodeIME(step *flow.InputTextStep, unicodeWarning string) *core.CommandResult {
+	if !step.Selector.IsEmpty() {
+		elem, _, err := d.findElement(step.Selector, step.IsOptional(), step.TimeoutMs)
+		if err != nil {
+			return errorResult(err, fmt.Sprintf("Element not found: %v", err))
+		}
+		if err := d.uiChanged(elem.Click()); err != nil {
+			return errorResult(err, "Failed to focus element before input")
+		}
+	}
+
+	previous, _ := d.device.Shell("settings get secure default_input_method")
+	previous = strings.TrimSpace(previous)
+	if _, err := d.device.Shell("ime set " + unicodeIME); err != nil {
+		return errorResult(err, "Failed to switch to Unicode IME")
+	}
+	defer func() {
+		if previous != "" && previous != "null" && previous != unicodeIME {
+			_, _ = d.device.Shell("ime set " + previous)
+		}
+	}()
+
+	var active *uiautomator2.Element
+	var before string
+	if step.VerifyText {
+		var err error
+		if active, err = d.client.ActiveElement(); err != nil {
+			return errorResult(err, "No focused element to verify input text")
+		}
+		before, _ = active.Text()
+	}
+
+	// Each grapheme encodes independently, so slow typing can send them one by one
+	sendEncoded := func(text string) error { return d.client.SendKeyActions(encodeModifiedUTF7(text)) }
+	if err := sendWithDelay(step.Text, step.TypeDelayMs, sendEncoded); err != nil {
+		return errorResult(err, "Failed to input text via Unicode IME")
+	}
+
+	if step.VerifyText {
+		retype := func() error { return sendWithDelay(step.Text, step.TypeDelayMs, sendEncoded) }
+		if result := verifyInputText(active, before, step.Text, retype); result != nil {
+			return result
+		}
+	}
+
+	return successResult(fmt.Sprintf("Entered text (unicode IME): %s%s%s", step.Text, core.TypeDelayNote(step.Text, step.TypeDelayMs), unicodeWarning), nil)
+}
+
+// encodeModifiedUTF7 encodes text as IMAP modified UTF-7 (RFC 3501), the
+// format the Unicode IME decodes. Printable ASCII passes through, "&" becomes
+// "&-", and other runs are "&" + base64 of UTF-16BE with "," for "/" + "-".
+func encodeModifiedUTF7(text string) string {
+	var b strings.Builder
+	var run []rune
+	flush := func() {
+		if len(run) == 0 {
+			return
+		}
+		units := utf16.Encode(run)
+		buf := make([]byte, 0, len(units)*2)
+		for _, u := range units {
+			buf = append(buf, byte(u>>8), byte(u))
+		}
+		encoded := base64.RawStdEncoding.EncodeToString(buf)
+		b.WriteString("&" + strings.ReplaceAll(encoded, "/", ",") + "-")
+		run = run[:0]
+	}
+	for _, r := range text {
+		if r >= 0x20 && r <= 0x7E {
+			flush()
+			if r == '&' {
+				b.WriteString("&-")
+			} else {
+				b.WriteRune(r)
 			}
-			return successResult(fmt.Sprintf("Entered text: %s%s", text, unicodeWarning), nil)
+			continue
 		}
-		if err := active.SendKeys(text); err != nil {
-			return errorResult(err, fmt.Sprintf("Failed to input text: %v", err))
+		run = append(run, r)
+	}
+	flush()
+	return b.String()
+}
+
+// verifyInputText reads the field back after typing and checks it is exactly
+// before+text (see core.InputTextMatches). On a mismatch, e.g. dropped
+// characters, it clears the field, types the prefilled text back and retypes
+// once. Returns nil when the text matches, or a failed result.
+func verifyInputText(elem *uiautomator2.Element, before, text string, retype func() error) *core.CommandResult {
+	if got, err := elem.Text(); err == nil && core.InputTextMatches(got, before, text) {
+		return nil
+	}
+
+	if err := elem.Clear(); err != nil {
+		return errorResult(err, fmt.Sprintf("Failed to clear field before retrying input: %v", err))
+	}
+	// A cleared field that still reads as before was showing its hint
+	if cleared, _ := elem.Text(); cleared == before {
+		before = ""
+	}
+	if before != "" {
+		if err := elem.SendKeys(before); err != nil {
+			return errorResult(err, fmt.Sprintf("Failed to restore prefilled text before retrying input: %v", err))
 		}
 	}
+	if err := retype(); err != nil {
+		return errorResult(err, fmt.Sprintf("Failed to retry input text: %v", err))
+	}
 
-	return successResult(fmt.Sprintf("Entered text: %s%s", text, unicodeWarning), nil)
+	got, err := elem.Text()
+	if err != nil {
+		return errorResult(err, fmt.Sprintf("Failed to read back input text: %v", err))
+	}
+	if !core.InputTextMatches(got, before, text) {
+		return errorResult(fmt.Errorf("expected field to read %q, got %q", before+text, got),
+			fmt.Sprintf("Input text verification failed: expected %q, got %q", before+text, got))
+	}
+	return nil
 }
 
 func (d *Driver) eraseText(step *flow.EraseTextStep) *core.CommandResult {
@@ -293,7 +1266,7 @@ func (d *Driver) eraseText(step *flow.EraseTextStep) *core.CommandResult {
 
 			// Case 1: Erase all text (or more than exists) - just Clear() in one shot
 			if chars >= textLen || textLen == 0 {
-				if clearErr := active.Clear(); clearErr == nil {
+				if clearErr := d.uiChanged(active.Clear()); clearErr == nil {
 					return successResult(fmt.Sprintf("Cleared %d characters", textLen), nil)
 				}
 				// Clear failed, fall through to delete key approach
@@ -302,9 +1275,9 @@ func (d *Driver) eraseText(step *flow.EraseTextStep) *core.CommandResult {
 				runes := []rune(currentText)
 				remaining := string(runes[:textLen-chars])
 
-				if clearErr := active.Clear(); clearErr == nil {
+				if clearErr := d.uiChanged(active.Clear()); clearErr == nil {
 					if remaining != "" {
-						if sendErr := active.SendKeys(remaining); sendErr == nil {
+						if sendErr := d.uiChanged(active.SendKeys(remaining)); sendErr == nil {
 							return successResult(fmt.Sprintf("Erased %d characters", chars), nil)
 						}
 						// SendKeys failed, fall through to delete key approach
@@ -354,13 +1327,13 @@ func (d *Driver) inputRandom(step *flow.InputRandomStep) *core.CommandResult {
 	dataType := strings.ToUpper(step.DataType)
 	switch dataType {
 	case "EMAIL":
-		text = randomEmail()
+		text = core.RandomEmail()
 	case "NUMBER":
-		text = randomNumber(length)
+		text = core.RandomNumber(length)
 	case "PERSON_NAME":
-		text = randomPersonName()
+		text = core.RandomPersonName()
 	default: // "TEXT" or empty
-		text = randomString(length)
+		text = core.RandomString(length)
 	}
 
 	// Type into focused element
@@ -368,7 +1341,7 @@ func (d *Driver) inputRandom(step *flow.InputRandomStep) *core.CommandResult {
 	if err != nil {
 		return errorResult(err, "No focused element to type into")
 	}
-	if err := active.SendKeys(text); err != nil {
+	if err := d.uiChanged(active.SendKeys(text)); err != nil {
 		return errorResult(err, fmt.Sprintf("Failed to input text: %v", err))
 	}
 
@@ -402,10 +1375,22 @@ func (d *Driver) scroll(step *flow.ScrollStep) *core.CommandResult {
 	// Use most of screen for scroll area (leave margins)
 	area := uiautomator2.NewRect(0, height/8, width, height*3/4)
 
-	if err := d.client.ScrollInArea(area, uiaDir, 0.5, 0); err != nil {
+	// amount is a fraction of the scroll area, which is what UiAutomator's percent means
+	percent, ok, err := step.AmountFraction()
+	if err != nil {
+		return errorResult(err, fmt.Sprintf("Invalid scroll amount: %v", err))
+	}
+	if !ok {
+		percent = 0.5
+	}
+
+	if err := d.client.ScrollInArea(area, uiaDir, percent, 0); err != nil {
 		return errorResult(err, fmt.Sprintf("Failed to scroll: %v", err))
 	}
 
+	if ok {
+		return successResult(fmt.Sprintf("Scrolled %s by %s", direction, step.Amount), nil)
+	}
 	return successResult(fmt.Sprintf("Scrolled %s", direction), nil)
 }
 
@@ -415,30 +1400,68 @@ func (d *Driver) scrollUntilVisible(step *flow.ScrollUntilVisibleStep) *core.Com
 		direction = "down"
 	}
 
+	profile, err := step.ScrollProfile()
+	if err != nil {
+		return errorResult(err, fmt.Sprintf("Invalid scroll speed: %v", err))
+	}
+
 	maxScrolls := 10
+	if step.MaxScrolls > 0 {
+		maxScrolls = step.MaxScrolls
+	}
 	// Invert direction: scroll direction = content movement, swipe = finger gesture
 	// "scroll down" means reveal content below, which requires swiping up
 	uiaDir := invertScrollDirection(direction)
 
 	// Get screen size for dynamic scroll area
 	width, height := 1080, 1920 // defaults
+	screenKnown := false
 	if w, h, err := d.getScreenSize(); err == nil {
 		width, height = w, h
+		screenKnown = true
 	}
 
 	// Use most of screen for scroll area (leave margins)
 	area := uiautomator2.NewRect(0, height/8, width, height*3/4)
+	horizontal := direction == "left" || direction == "right"
+	threshold := step.VisibilityThreshold()
+
+	// UiAutomator takes speed in pixels per second; derive it from the
+	// profile so each swipe covers its distance in its duration.
+	extent := area.Height
+	if horizontal {
+		extent = area.Width
+	}
+	speed := int(float64(extent) * profile.Distance * 1000 / float64(profile.DurationMs))
+
+	// Bounds can't be measured without a screen size or element rect, so
+	// those count as fully visible.
+	visiblePercent := func(info *core.ElementInfo) int {
+		if !screenKnown || info.Bounds.Width <= 0 || info.Bounds.Height <= 0 {
+			return 100
+		}
+		return info.Bounds.VisiblePercent(width, height)
+	}
 
-	for i := 0; i < maxScrolls; i++ {
+	for i := 0; ; i++ {
 		// Try to find element (short timeout - includes page source fallback)
 		_, info, err := d.findElement(step.Element, true, 1000)
 		if err == nil && info != nil {
-			// Element found - return success
-			return successResult(fmt.Sprintf("Element found after %d scrolls", i), info)
+			// Keep scrolling while too little of the element is on screen
+			if visible := visiblePercent(info); visible >= threshold {
+				if step.CenterElement && screenKnown {
+					info = d.centerElement(step.Element, info, width, height, horizontal)
+					visible = visiblePercent(info)
+				}
+				return successResult(fmt.Sprintf("Element found after %d scrolls (%d%% visible)", i, visible), info)
+			}
+		}
+		if i == maxScrolls {
+			break
 		}
 
 		// Scroll
-		if err := d.client.ScrollInArea(area, uiaDir, 0.3, 0); err != nil {
+		if err := d.client.ScrollInArea(area, uiaDir, profile.Distance, speed); err != nil {
 			return errorResult(err, fmt.Sprintf("Failed to scroll: %v", err))
 		}
 
@@ -448,6 +1471,128 @@ func (d *Driver) scrollUntilVisible(step *flow.ScrollUntilVisibleStep) *core.Com
 	return errorResult(fmt.Errorf("element not found"), fmt.Sprintf("Element not found after %d scrolls", maxScrolls))
 }
 
+// centerDragMs is slow enough that the centering drag doesn't fling the list.
+const centerDragMs = 1000
+
+// centerElement drags the content so the element moves toward the middle of
+// the screen along the scroll axis, then re-reads its bounds. The original
+// info is returned when no drag is needed or the element can't be re-found.
+func (d *Driver) centerElement(sel flow.Selector, info *core.ElementInfo, width, height int, horizontal bool) *core.ElementInfo {
+	from, to, ok := core.CenteringDrag(info.Bounds, width, height, horizontal)
+	if !ok {
+		return info
+	}
+	points := []uiautomator2.PointModel{
+		{X: int(from.X), Y: int(from.Y)},
+		{X: int(to.X), Y: int(to.Y)},
+	}
+	if err := d.client.PointerPath(points, []int{centerDragMs}); err != nil {
+		logger.Warn("centerElement: drag failed: %v", err)
+		return info
+	}
+	time.Sleep(300 * time.Millisecond)
+
+	if _, centered, err := d.findElement(sel, true, 1000); err == nil && centered != nil {
+		return centered
+	}
+	return info
+}
+
+// scrollToPosition scrolls to a fraction of the scrollable content.
+// UiAutomator doesn't expose content extent, so it is measured in swipes:
+// rewind to the start, count swipes until the page source stops changing,
+// rewind again, then swipe forward the matching share of that count.
+// The landing position is approximate (within about one swipe).
+func (d *Driver) scrollToPosition(step *flow.ScrollToPositionStep) *core.CommandResult {
+	fraction, err := parsePositionFraction(step.Position)
+	if err != nil {
+		return errorResult(err, fmt.Sprintf("Invalid scroll position: %v", err))
+	}
+
+	maxScrolls := step.MaxScrolls
+	if maxScrolls <= 0 {
+		maxScrolls = 20
+	}
+
+	// Swipe within the main scrollable if one is on screen, else the whole screen
+	width, height := 1080, 1920 // defaults
+	if w, h, err := d.getScreenSize(); err == nil {
+		width, height = w, h
+	}
+	area := core.Bounds{X: 0, Y: 0, Width: width, Height: height}
+	if info, _ := d.findScrollableElement(2000); info != nil {
+		area = info.Bounds
+	}
+
+	horizontal := strings.EqualFold(step.Direction, "right") || strings.EqualFold(step.Direction, "left")
+	forward := func() *core.CommandResult { return d.swipeInBounds(area, horizontal, true) }
+	backward := func() *core.CommandResult { return d.swipeInBounds(area, horizontal, false) }
+
+	if _, result := d.swipeUntilStable(backward, maxScrolls); result != nil {
+		return result
+	}
+	extent, result := d.swipeUntilStable(forward, maxScrolls)
+	if result != nil {
+		return result
+	}
+	if extent == 0 {
+		return successResult("Content fits on screen, nothing to scroll", nil)
+	}
+	if _, result := d.swipeUntilStable(backward, maxScrolls); result != nil {
+		return result
+	}
+
+	target := int(math.Round(fraction * float64(extent)))
+	for i := 0; i < target; i++ {
+		if result := forward(); !result.Success {
+			return result
+		}
+	}
+
+	return successResult(fmt.Sprintf("Scrolled to ~%d%% (%d of %d swipes)", int(fraction*100), target, extent), nil)
+}
+
+// swipeUntilStable repeats swipe until the page source stops changing.
+// Returns how many swipes moved the content, or a failed result. A page
+// source that can't be read fails the scroll rather than counting as either
+// stable or moved, since the measured extent would be wrong.
+func (d *Driver) swipeUntilStable(swipe func() *core.CommandResult, maxSwipes int) (int, *core.CommandResult) {
+	prev, err := d.client.Source()
+	if err != nil {
+		return 0, errorResult(err, fmt.Sprintf("Failed to read page source: %v", err))
+	}
+	for i := 0; i < maxSwipes; i++ {
+		if result := swipe(); !result.Success {
+			return i, result
+		}
+		cur, err := d.client.Source()
+		if err != nil {
+			return i, errorResult(err, fmt.Sprintf("Failed to read page source: %v", err))
+		}
+		if cur == prev {
+			return i, nil
+		}
+		prev = cur
+	}
+	return maxSwipes, nil
+}
+
+// swipeInBounds swipes across 70%→30% of the given bounds.
+// forward=true reveals content further down (or right); false goes back.
+// Uses a slow swipe so the content doesn't fling past the measured step.
+func (d *Driver) swipeInBounds(b core.Bounds, horizontal, forward bool) *core.CommandResult {
+	near, far := 30, 70
+	if forward {
+		near, far = 70, 30
+	}
+	if horizontal {
+		y := b.Y + b.Height/2
+		return d.swipeWithAbsoluteCoords(b.X+b.Width*near/100, y, b.X+b.Width*far/100, y, 600)
+	}
+	x := b.X + b.Width/2
+	return d.swipeWithAbsoluteCoords(x, b.Y+b.Height*near/100, x, b.Y+b.Height*far/100, 600)
+}
+
 func (d *Driver) swipe(step *flow.SwipeStep) *core.CommandResult {
 	// Check if coordinate-based swipe (percentage or absolute)
 	if step.Start != "" && step.End != "" {
@@ -468,10 +1613,20 @@ func (d *Driver) swipe(step *flow.SwipeStep) *core.CommandResult {
 
 	// If selector specified, swipe within that element's bounds
 	if step.Selector != nil && !step.Selector.IsEmpty() {
-		_, info, err := d.findElement(*step.Selector, step.IsOptional(), step.TimeoutMs)
+		elem, info, err := d.findElement(*step.Selector, step.IsOptional(), step.TimeoutMs)
 		if err != nil {
 			return errorResult(err, fmt.Sprintf("Element not found for swipe: %v", err))
 		}
+		// Swiping on the element itself keeps the gesture inside its visible
+		// bounds, even for oddly shaped scroll areas. Page-source matches have
+		// no element ID, so those swipe in the bounds rectangle instead.
+		if elem != nil && elem.ID() != "" {
+			err := d.client.Swipe(elem.ID(), uiaDir, 0.7, 0)
+			if err == nil {
+				return successResult(fmt.Sprintf("Swiped %s in element", direction), info)
+			}
+			logger.Debug("element swipe failed, falling back to bounds: %v", err)
+		}
 		if info != nil && info.Bounds.Width > 0 {
 			area := uiautomator2.NewRect(
 				info.Bounds.X,
@@ -496,10 +1651,10 @@ func (d *Driver) swipe(step *flow.SwipeStep) *core.CommandResult {
 	// Wait up to 10 seconds for page to load and find scrollable
 	scrollableInfo, scrollableCount := d.findScrollableElement(10000)
 
-	// Print debug info about scrollable elements found
+	// Log debug info about scrollable elements found
 	if scrollableInfo != nil {
 		b := scrollableInfo.Bounds
-		fmt.Printf("[swipe] Found %d scrollable(s), using: bounds=[%d,%d,%d,%d]\n",
+		logger.Debug("[swipe] Found %d scrollable(s), using: bounds=[%d,%d,%d,%d]",
 			scrollableCount, b.X, b.Y, b.Width, b.Height)
 
 		// Use coordinate-based swipe within scrollable bounds
@@ -518,15 +1673,82 @@ func (d *Driver) swipe(step *flow.SwipeStep) *core.CommandResult {
 			endY = b.Y + b.Height*30/100
 		}
 
-		fmt.Printf("[swipe] Coords in scrollable: (%d,%d) → (%d,%d)\n", centerX, startY, centerX, endY)
+		logger.Debug("[swipe] Coords in scrollable: (%d,%d) → (%d,%d)", centerX, startY, centerX, endY)
 		return d.swipeWithAbsoluteCoords(centerX, startY, centerX, endY, step.Duration)
 	}
 
-	fmt.Printf("[swipe] No scrollable found, using screen coordinates (50%% center)\n")
+	logger.Debug("[swipe] No scrollable found, using screen coordinates (50%% center)")
 	// Fallback: Use coordinates starting from 50% center
 	return d.swipeWithMaestroCoordinates(direction, width, height, step.Duration)
 }
 
+// gesturePath drags one finger through the step's points, timing each segment
+// by its length so the whole path takes the step's duration.
+func (d *Driver) gesturePath(step *flow.GesturePathStep) *core.CommandResult {
+	if len(step.Points) < 2 {
+		return errorResult(fmt.Errorf("need at least 2 points, got %d", len(step.Points)), "gesturePath requires at least 2 points")
+	}
+
+	width, height, err := d.getScreenSize()
+	if err != nil {
+		return errorResult(err, fmt.Sprintf("Failed to get screen size: %v", err))
+	}
+
+	path := make([]core.PathPoint, len(step.Points))
+	points := make([]uiautomator2.PointModel, len(step.Points))
+	for i, p := range step.Points {
+		xPct, yPct, err := parsePercentageCoords(p)
+		if err != nil {
+			return errorResult(err, fmt.Sprintf("Invalid point %d: %v", i+1, err))
+		}
+		points[i] = uiautomator2.PointModel{X: int(float64(width) * xPct), Y: int(float64(height) * yPct)}
+		path[i] = core.PathPoint{X: float64(points[i].X), Y: float64(points[i].Y)}
+	}
+
+	if err := d.client.PointerPath(points, core.PathDurations(path, step.Duration())); err != nil {
+		return errorResult(err, fmt.Sprintf("Gesture path failed: %v", err))
+	}
+
+	return successResult(fmt.Sprintf("Traced %d points over %dms", len(points), step.Duration()), nil)
+}
+
+// twoFingerSwipe moves two fingers in parallel so apps that tell one- and
+// two-finger gestures apart (maps, galleries) see a two-finger pan.
+func (d *Driver) twoFingerSwipe(step *flow.TwoFingerSwipeStep) *core.CommandResult {
+	start, end, err := step.Endpoints()
+	if err != nil {
+		return errorResult(err, err.Error())
+	}
+
+	width, height, err := d.getScreenSize()
+	if err != nil {
+		return errorResult(err, fmt.Sprintf("Failed to get screen size: %v", err))
+	}
+
+	var mid [2]core.PathPoint
+	for i, p := range []string{start, end} {
+		xPct, yPct, err := parsePercentageCoords(p)
+		if err != nil {
+			return errorResult(err, fmt.Sprintf("Invalid coordinates %q: %v", p, err))
+		}
+		mid[i] = core.PathPoint{X: float64(width) * xPct, Y: float64(height) * yPct}
+	}
+
+	tracks := core.TwoFingerTracks(mid[0], mid[1], step.FingerSpacing(width))
+	fingers := make([][2]uiautomator2.PointModel, len(tracks))
+	for i, track := range tracks {
+		for j, p := range track {
+			fingers[i][j] = uiautomator2.PointModel{X: int(math.Round(p.X)), Y: int(math.Round(p.Y))}
+		}
+	}
+
+	if err := d.client.ParallelSwipe(fingers, step.Duration()); err != nil {
+		return errorResult(err, fmt.Sprintf("Two-finger swipe failed: %v", err))
+	}
+
+	return successResult(fmt.Sprintf("Two-finger swipe from (%s) to (%s)", start, end), nil)
+}
+
 // findScrollableElement waits for and finds a scrollable element.
 // Returns the element info and count of scrollables found.
 func (d *Driver) findScrollableElement(timeoutMs int) (*core.ElementInfo, int) {
@@ -535,13 +1757,7 @@ func (d *Driver) findScrollableElement(timeoutMs int) (*core.ElementInfo, int) {
 	pollInterval := 500 * time.Millisecond
 
 	for time.Now().Before(deadline) {
-		source, err := d.client.Source()
-		if err != nil {
-			time.Sleep(pollInterval)
-			continue
-		}
-
-		elements, err := ParsePageSource(source)
+		elements, err := d.pageSourceElements()
 		if err != nil {
 			time.Sleep(pollInterval)
 			continue
@@ -611,7 +1827,7 @@ func (d *Driver) swipeWithMaestroCoordinates(direction string, width, height, du
 		endY = height * 30 / 100
 	}
 
-	fmt.Printf("[swipe] Using screen coords: (%d,%d) → (%d,%d)\n", startX, startY, endX, endY)
+	logger.Debug("[swipe] Using screen coords: (%d,%d) → (%d,%d)", startX, startY, endX, endY)
 	return d.swipeWithAbsoluteCoords(startX, startY, endX, endY, durationMs)
 }
 
@@ -714,6 +1930,30 @@ func (d *Driver) getScreenSize() (int, int, error) {
 	return width, height, nil
 }
 
+// parsePositionFraction parses "50%" or "0.5" into a fraction (0.0-1.0).
+func parsePositionFraction(position string) (float64, error) {
+	position = strings.TrimSpace(position)
+	if position == "" {
+		return 0, fmt.Errorf("position is required")
+	}
+
+	var value float64
+	var err error
+	if strings.HasSuffix(position, "%") {
+		value, err = strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(position, "%")), 64)
+		value /= 100
+	} else {
+		value, err = strconv.ParseFloat(position, 64)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("invalid position %q", position)
+	}
+	if value < 0 || value > 1 {
+		return 0, fmt.Errorf("position %q out of range 0%%-100%%", position)
+	}
+	return value, nil
+}
+
 // parsePercentageCoords parses "x%, y%" format into decimal fractions (0.0-1.0)
 func parsePercentageCoords(coord string) (float64, float64, error) {
 	parts := strings.Split(coord, ",")
@@ -763,6 +2003,42 @@ func (d *Driver) pressKey(step *flow.PressKeyStep) *core.CommandResult {
 	return successResult(fmt.Sprintf("Pressed key: %s", key), nil)
 }
 
+// search focuses the search field, types the query and presses the search key
+// (KEYCODE_SEARCH), then optionally waits for a results element.
+func (d *Driver) search(step *flow.SearchStep) *core.CommandResult {
+	if step.Query == "" {
+		return errorResult(fmt.Errorf("no query specified"), "No search query")
+	}
+
+	if !step.Field.IsEmpty() {
+		focus := &flow.TapOnStep{
+			BaseStep: flow.BaseStep{TimeoutMs: step.TimeoutMs, Optional: step.Optional},
+			Selector: step.Field,
+		}
+		if result := d.tapOn(focus); !result.Success {
+			return errorResult(result.Error, fmt.Sprintf("Search field not found: %s", step.Field.Describe()))
+		}
+	}
+
+	if result := d.inputText(&flow.InputTextStep{Text: step.Query}); !result.Success {
+		return result
+	}
+
+	if step.ShouldSubmit() {
+		if result := d.pressKey(&flow.PressKeyStep{Key: "search"}); !result.Success {
+			return result
+		}
+	}
+
+	if step.WaitFor != nil {
+		if _, _, err := d.findElement(*step.WaitFor, false, step.TimeoutMs); err != nil {
+			return errorResult(err, fmt.Sprintf("Search results not found: %s", step.WaitFor.Describe()))
+		}
+	}
+
+	return successResult(fmt.Sprintf("Searched for %q", step.Query), nil)
+}
+
 // ============================================================================
 // App Lifecycle Commands
 // ============================================================================
@@ -859,6 +2135,14 @@ func (d *Driver) stopApp(step *flow.StopAppStep) *core.CommandResult {
 		return errorResult(fmt.Errorf("device not configured"), "stopApp requires device access")
 	}
 
+	if step.Graceful {
+		// Press HOME so the app gets onPause/onStop before it is killed
+		if _, err := d.device.Shell("input keyevent 3"); err != nil {
+			return errorResult(err, fmt.Sprintf("Failed to background app: %v", err))
+		}
+		time.Sleep(core.GracefulStopDelay)
+	}
+
 	if _, err := d.device.Shell("am force-stop " + appID); err != nil {
 		return errorResult(err, fmt.Sprintf("Failed to stop app: %v", err))
 	}
@@ -900,6 +2184,176 @@ func (d *Driver) killApp(step *flow.KillAppStep) *core.CommandResult {
 	return successResult(fmt.Sprintf("Killed app: %s", appID), nil)
 }
 
+// minAppLocaleSDK is the first API level with per-app languages (Android 13).
+const minAppLocaleSDK = 33
+
+// localeTagPattern accepts BCP 47 style tags such as "fr", "pt-BR" or "zh-Hant-TW".
+var localeTagPattern = regexp.MustCompile(`^[A-Za-z]{2,3}(-[A-Za-z0-9]{2,8})*$`)
+
+// setAppLocale applies a per-app language with `cmd locale set-app-locales`,
+// then relaunches the app so its activities pick up the new configuration.
+func (d *Driver) setAppLocale(step *flow.SetAppLocaleStep) *core.CommandResult {
+	if step.AppID == "" {
+		return errorResult(fmt.Errorf("no appId specified"), "setAppLocale requires appId")
+	}
+	tag := strings.ReplaceAll(strings.TrimSpace(step.Locale), "_", "-")
+	if !localeTagPattern.MatchString(tag) {
+		return errorResult(fmt.Errorf("invalid locale %q", step.Locale),
+			fmt.Sprintf("Invalid locale %q: use a language tag like fr-FR", step.Locale))
+	}
+	if d.device == nil {
+		return errorResult(fmt.Errorf("device not configured"), "setAppLocale requires device access")
+	}
+
+	out, err := d.device.Shell("getprop ro.build.version.sdk")
+	if err != nil {
+		return errorResult(err, fmt.Sprintf("Failed to read Android version: %v", err))
+	}
+	if sdk, err := strconv.Atoi(strings.TrimSpace(out)); err == nil && sdk < minAppLocaleSDK {
+		return errorResult(fmt.Errorf("per-app locales unsupported on API %d", sdk),
+			fmt.Sprintf("setAppLocale needs Android 13 (API %d) or newer; this device is API %d. Change the device locale instead", minAppLocaleSDK, sdk))
+	}
+
+	out, err = d.device.Shell(appLocaleCommand(step.AppID, tag))
+	if err != nil {
+		return errorResult(err, fmt.Sprintf("Failed to set app locale: %v", err))
+	}
+	if msg := strings.TrimSpace(out); msg != "" {
+		// cmd locale reports problems on stdout with a zero exit status
+		return errorResult(fmt.Errorf("%s", msg), fmt.Sprintf("Failed to set app locale: %s", msg))
+	}
+
+	if step.ShouldRelaunch() {
+		if result := d.launchApp(&flow.LaunchAppStep{AppID: step.AppID}); !result.Success {
+			return result
+		}
+	}
+
+	return successResult(fmt.Sprintf("Set %s locale to %s", step.AppID, tag), nil)
+}
+
+func appLocaleCommand(appID, tag string) string {
+	return fmt.Sprintf("cmd locale set-app-locales %s --locales %s", appID, tag)
+}
+
+var (
+	// packageNamePattern and prefsFilePattern keep names safe to splice into shell commands.
+	packageNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.]+$`)
+	prefsFilePattern   = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+)
+
+// setPreference upserts one key in the app's SharedPreferences XML. It reads
+// and rewrites shared_prefs/<file>.xml through run-as, so the app must be a
+// debuggable build; the app is force-stopped first so it cannot overwrite the file.
+func (d *Driver) setPreference(step *flow.SetPreferenceStep) *core.CommandResult {
+	typ, value, err := step.Typed()
+	if err != nil {
+		return errorResult(err, err.Error())
+	}
+	if step.Key == "" {
+		return errorResult(fmt.Errorf("no key specified"), "setPreference requires key")
+	}
+	if !packageNamePattern.MatchString(step.AppID) {
+		return errorResult(fmt.Errorf("invalid appId %q", step.AppID), "setPreference requires a valid appId")
+	}
+	file := strings.TrimSuffix(step.File, ".xml")
+	if file == "" {
+		file = step.AppID + "_preferences"
+	}
+	if !prefsFilePattern.MatchString(file) {
+		return errorResult(fmt.Errorf("invalid prefs file %q", step.File), fmt.Sprintf("Invalid preferences file name %q", step.File))
+	}
+	if d.device == nil {
+		return errorResult(fmt.Errorf("device not configured"), "setPreference requires device access")
+	}
+
+	if out, _ := d.device.Shell(fmt.Sprintf("run-as %s id", step.AppID)); !strings.Contains(out, "uid=") {
+		return errorResult(fmt.Errorf("run-as unavailable for %s: %s", step.AppID, strings.TrimSpace(out)),
+			fmt.Sprintf("setPreference needs a debuggable build of %s (run-as failed: %s)", step.AppID, strings.TrimSpace(out)))
+	}
+	if _, err := d.device.Shell("am force-stop " + step.AppID); err != nil {
+		logger.Warn("failed to force-stop app %s before writing preferences: %v", step.AppID, err)
+	}
+
+	prefsPath := "shared_prefs/" + file + ".xml"
+	existing, _ := d.device.Shell(fmt.Sprintf("run-as %s cat %s", step.AppID, prefsPath))
+	if !strings.Contains(existing, "<map") {
+		existing = "" // no prefs file yet
+	}
+	updated, err := upsertSharedPref(existing, step.Key, typ, value)
+	if err != nil {
+		return errorResult(err, fmt.Sprintf("Failed to parse %s: %v", prefsPath, err))
+	}
+
+	if _, err := d.device.Shell(sharedPrefsWriteCommand(step.AppID, prefsPath, updated)); err != nil {
+		return errorResult(err, fmt.Sprintf("Failed to write %s: %v", prefsPath, err))
+	}
+
+	return successResult(fmt.Sprintf("Set %s preference %s = %s", typ, step.Key, value), nil)
+}
+
+// sharedPrefsWriteCommand pipes content through base64 so quoting in
+// preference values can't break the shell command.
+func sharedPrefsWriteCommand(appID, prefsPath, content string) string {
+	encoded := base64.StdEncoding.EncodeToString([]byte(content))
+	return fmt.Sprintf("echo %s | base64 -d | run-as %s sh -c 'mkdir -p shared_prefs && cat > %s'", encoded, appID, prefsPath)
+}
+
+// sharedPrefEntry is one child of a SharedPreferences <map>. Scalar types carry
+// a value attribute; string and set entries keep their (escaped) inner XML.
+type sharedPrefEntry struct {
+	XMLName xml.Name
+	Name    string  `xml:"name,attr"`
+	Value   *string `xml:"value,attr"`
+	Inner   string  `xml:",innerxml"`
+}
+
+// upsertSharedPref returns the SharedPreferences XML with key set to value,
+// replacing any existing entry of that name and keeping the rest untouched.
+func upsertSharedPref(existing, key, typ, value string) (string, error) {
+	var prefs struct {
+		Entries []sharedPrefEntry `xml:",any"`
+	}
+	if strings.TrimSpace(existing) != "" {
+		if err := xml.Unmarshal([]byte(existing), &prefs); err != nil {
+			return "", err
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("<?xml version='1.0' encoding='utf-8' standalone='yes' ?>\n<map>\n")
+	for _, e := range prefs.Entries {
+		if e.Name == key {
+			continue
+		}
+		writeSharedPrefEntry(&b, e.XMLName.Local, e.Name, e.Value, e.Inner)
+	}
+	if typ == flow.PrefString {
+		var escaped strings.Builder
+		_ = xml.EscapeText(&escaped, []byte(value))
+		writeSharedPrefEntry(&b, typ, key, nil, escaped.String())
+	} else {
+		writeSharedPrefEntry(&b, typ, key, &value, "")
+	}
+	b.WriteString("</map>\n")
+	return b.String(), nil
+}
+
+func writeSharedPrefEntry(b *strings.Builder, tag, name string, value *string, inner string) {
+	fmt.Fprintf(b, `    <%s name="%s"`, tag, xmlAttrEscape(name))
+	if value != nil {
+		fmt.Fprintf(b, ` value="%s" />`+"\n", xmlAttrEscape(*value))
+		return
+	}
+	fmt.Fprintf(b, ">%s</%s>\n", inner, tag)
+}
+
+func xmlAttrEscape(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
 // applyPermissions applies permission settings to an app.
 // Permissions map: shortcut/permission name -> "allow"/"deny"/"unset"
 func (d *Driver) applyPermissions(appID string, permissions map[string]string) *core.CommandResult {
@@ -1135,7 +2589,7 @@ func (d *Driver) pasteText(_ *flow.PasteTextStep) *core.CommandResult {
 		return errorResult(err, "No focused element to paste into")
 	}
 
-	if err := active.SendKeys(text); err != nil {
+	if err := d.uiChanged(active.SendKeys(text)); err != nil {
 		return errorResult(err, fmt.Sprintf("Failed to paste text: %v", err))
 	}
 
@@ -1211,6 +2665,16 @@ func (d *Driver) openLink(step *flow.OpenLinkStep) *core.CommandResult {
 		return errorResult(fmt.Errorf("device not configured"), "openLink requires device access")
 	}
 
+	// Cold start: wipe and stop the app so the intent launches a fresh process
+	if step.ClearState {
+		if r := d.clearState(&flow.ClearStateStep{AppID: step.AppID}); !r.Success {
+			return r
+		}
+		if r := d.killApp(&flow.KillAppStep{AppID: step.AppID}); !r.Success {
+			return r
+		}
+	}
+
 	// Build am start command
 	var cmd string
 	if step.Browser != nil && *step.Browser {
@@ -1232,6 +2696,12 @@ func (d *Driver) openLink(step *flow.OpenLinkStep) *core.CommandResult {
 		time.Sleep(2 * time.Second)
 	}
 
+	if step.WaitFor != nil {
+		if _, _, err := d.findElement(*step.WaitFor, false, step.TimeoutMs); err != nil {
+			return errorResult(err, fmt.Sprintf("Opened link but %s did not appear", step.WaitFor.Describe()))
+		}
+	}
+
 	return successResult(fmt.Sprintf("Opened link: %s", link), nil)
 }
 
@@ -1298,16 +2768,44 @@ func (d *Driver) startRecording(step *flow.StartRecordingStep) *core.CommandResu
 		return errorResult(fmt.Errorf("device not configured"), "startRecording requires device access")
 	}
 
+	if step.Mask != "" {
+		return errorResult(fmt.Errorf("recording mask is not supported on Android"),
+			"startRecording mask is only supported on iOS simulators")
+	}
+
+	if err := core.CheckRecordingCrop(step.Crop); err != nil {
+		return errorResult(err, err.Error())
+	}
+	// Measured now, while the status bar is on screen, and applied to the
+	// host copy when the recording stops
+	var crop float64
+	if step.Crop != "" {
+		fraction, err := d.statusBarFraction()
+		if err != nil {
+			return errorResult(err, fmt.Sprintf("Failed to measure the status bar for crop: %v", err))
+		}
+		crop = fraction
+	}
+
 	path := step.Path
 	if path == "" {
 		path = "/sdcard/recording.mp4"
 	}
 
 	// Start screenrecord in background (will be killed by stopRecording)
-	cmd := fmt.Sprintf("screenrecord %s &", path)
+	cmd := "screenrecord"
+	if step.Size != "" {
+		if !validRecordingSize(step.Size) {
+			return errorResult(fmt.Errorf("invalid recording size %q", step.Size), "Recording size must be WIDTHxHEIGHT (e.g. 720x1280)")
+		}
+		cmd += " --size " + step.Size
+	}
+	cmd = fmt.Sprintf("%s %s &", cmd, path)
 	if _, err := d.device.Shell(cmd); err != nil {
 		return errorResult(err, fmt.Sprintf("Failed to start recording: %v", err))
 	}
+	d.recordingPath = path
+	d.recordingCrop = crop
 
 	return &core.CommandResult{
 		Success: true,
@@ -1316,7 +2814,52 @@ func (d *Driver) startRecording(step *flow.StartRecordingStep) *core.CommandResu
 	}
 }
 
-func (d *Driver) stopRecording(_ *flow.StopRecordingStep) *core.CommandResult {
+// validRecordingSize checks a "WIDTHxHEIGHT" size for screenrecord --size,
+// which scales the whole screen to that size rather than cropping it.
+func validRecordingSize(size string) bool {
+	parts := strings.Split(size, "x")
+	if len(parts) != 2 {
+		return false
+	}
+	for _, p := range parts {
+		if n, err := strconv.Atoi(p); err != nil || n <= 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// statusBarInset matches the status bar's inset frame in `dumpsys window`
+// (type ITYPE_STATUS_BAR on Android 11-12, statusBars from Android 13) and
+// captures its bottom edge.
+var statusBarInset = regexp.MustCompile(`type=(?:ITYPE_STATUS_BAR|statusBars) frame=\[\d+,\d+\]\[\d+,(\d+)\]`)
+
+// statusBarFraction returns the share of the screen height the status bar
+// takes, for cropping it off a recording.
+func (d *Driver) statusBarFraction() (float64, error) {
+	output, err := d.device.Shell("dumpsys window")
+	if err != nil {
+		return 0, err
+	}
+	m := statusBarInset.FindStringSubmatch(output)
+	if m == nil {
+		return 0, fmt.Errorf("no status bar inset in dumpsys window (requires Android 11+)")
+	}
+	bottom, _ := strconv.Atoi(m[1])
+	_, height, err := d.getScreenSize()
+	if err != nil {
+		return 0, err
+	}
+	if bottom <= 0 || bottom >= height {
+		return 0, fmt.Errorf("status bar height %d out of range for a %d px screen", bottom, height)
+	}
+	return float64(bottom) / float64(height), nil
+}
+
+// stopRecording ends screenrecord. With Path set, the recording is copied
+// from the device to that host path and removed from the device, and cropped
+// there when startRecording asked for it.
+func (d *Driver) stopRecording(step *flow.StopRecordingStep) *core.CommandResult {
 	if d.device == nil {
 		return errorResult(fmt.Errorf("device not configured"), "stopRecording requires device access")
 	}
@@ -1329,7 +2872,45 @@ func (d *Driver) stopRecording(_ *flow.StopRecordingStep) *core.CommandResult {
 	// Wait for file to be written
 	time.Sleep(500 * time.Millisecond)
 
-	return successResult("Stopped recording", nil)
+	devicePath, crop := d.recordingPath, d.recordingCrop
+	d.recordingPath, d.recordingCrop = "", 0
+	if step.Path == "" {
+		if crop > 0 {
+			return errorResult(fmt.Errorf("crop requires a stopRecording path"),
+				fmt.Sprintf("Stopped recording, but %s stays uncropped on the device: crop applies to the copy saved at the stopRecording path", devicePath))
+		}
+		return successResult("Stopped recording", nil)
+	}
+	if devicePath == "" {
+		return errorResult(fmt.Errorf("no recording in progress"), "stopRecording: no recording was started")
+	}
+
+	// The shell transport is text-only, so the video comes across as base64
+	out, err := d.device.Shell("base64 " + devicePath)
+	if err != nil {
+		return errorResult(err, fmt.Sprintf("Failed to read recording %s: %v", devicePath, err))
+	}
+	data, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(out), ""))
+	if err != nil {
+		return errorResult(err, fmt.Sprintf("Failed to decode recording %s: %v", devicePath, err))
+	}
+	if err := os.WriteFile(step.Path, data, 0o644); err != nil {
+		return errorResult(err, fmt.Sprintf("Failed to save recording: %v", err))
+	}
+	if _, err := d.device.Shell("rm -f " + devicePath); err != nil {
+		logger.Warn("failed to remove recording %s from device: %v", devicePath, err)
+	}
+	if crop > 0 {
+		if err := core.CropRecordingTop(step.Path, crop); err != nil {
+			return errorResult(err, fmt.Sprintf("Failed to crop recording: %v", err))
+		}
+	}
+
+	return &core.CommandResult{
+		Success: true,
+		Message: fmt.Sprintf("Stopped recording, saved to %s", step.Path),
+		Data:    step.Path,
+	}
 }
 
 // ============================================================================
@@ -1384,18 +2965,352 @@ func (d *Driver) waitUntil(step *flow.WaitUntilStep) *core.CommandResult {
 				}
 			}
 			// HTTP round-trip (~100ms) is natural rate limit, no sleep needed
+			d.invalidateSource()
 		}
 	}
 }
 
-func (d *Driver) waitForAnimationToEnd(_ *flow.WaitForAnimationToEndStep) *core.CommandResult {
-	// NOTE: waitForAnimationToEnd is not fully implemented.
-	// Maestro uses screenshot comparison which is complex to implement correctly.
-	// For now, we pass this step with a warning.
-	return &core.CommandResult{
-		Success: true,
-		Message: "WARNING: waitForAnimationToEnd is not fully implemented - step passed without animation check",
+// waitUntilAll polls every selector of the step once per cycle and succeeds
+// only when all visible selectors are found and all notVisible ones are gone.
+// On timeout the still-unsatisfied selectors are listed in the message.
+func (d *Driver) waitUntilAll(step *flow.ExtendedWaitUntilStep) *core.CommandResult {
+	timeout := 30 * time.Second
+	if step.TimeoutMs > 0 {
+		timeout = time.Duration(step.TimeoutMs) * time.Millisecond
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	for {
+		pending := d.unsatisfiedWaits(step)
+		if len(pending) == 0 {
+			return successResult(fmt.Sprintf("All %d wait conditions met", len(step.Visible)+len(step.NotVisible)), nil)
+		}
+		select {
+		case <-ctx.Done():
+			return errorResult(
+				context.DeadlineExceeded,
+				fmt.Sprintf("Wait conditions not met within %v: %s", timeout, strings.Join(pending, ", ")),
+			)
+		default:
+			// HTTP round-trips per selector are the natural rate limit, no sleep needed
+			d.invalidateSource()
+		}
+	}
+}
+
+// unsatisfiedWaits checks each selector of the step once and describes the
+// ones whose condition does not hold yet.
+func (d *Driver) unsatisfiedWaits(step *flow.ExtendedWaitUntilStep) []string {
+	var pending []string
+	for _, sel := range step.Visible {
+		if _, info, err := d.findElementOnce(sel); err != nil || info == nil {
+			pending = append(pending, "visible "+sel.DescribeQuoted())
+		}
+	}
+	for _, sel := range step.NotVisible {
+		if _, info, err := d.findElementOnce(sel); err == nil && info != nil {
+			pending = append(pending, "notVisible "+sel.DescribeQuoted())
+		}
+	}
+	return pending
+}
+
+// animationPollInterval is how often waitForAnimationToEnd takes a screenshot.
+const animationPollInterval = 200 * time.Millisecond
+
+// waitForAnimationToEnd compares consecutive screenshots until two in a row
+// match within core.FrameDiffTolerance or the step timeout elapses.
+func (d *Driver) waitForAnimationToEnd(step *flow.WaitForAnimationToEndStep) *core.CommandResult {
+	timeout := step.Timeout()
+	frames, settled, err := d.waitForScreenToSettle(timeout)
+	if err != nil {
+		return errorResult(err, fmt.Sprintf("Failed to take screenshot: %v", err))
+	}
+	if settled {
+		return successResult(fmt.Sprintf("Animation ended (settled after comparing %d frames)", frames), nil)
+	}
+
+	msg := fmt.Sprintf("Animation still running after %dms (compared %d frames)", timeout, frames)
+	if step.ShouldContinueOnTimeout() {
+		return successResult(msg+", continuing", nil)
+	}
+	return errorResult(fmt.Errorf("animation did not end within %dms", timeout), msg)
+}
+
+// waitForScreenToSettle takes screenshots until two in a row match within
+// core.FrameDiffTolerance or timeoutMs elapses. Returns how many frames were
+// compared and whether the screen settled.
+func (d *Driver) waitForScreenToSettle(timeoutMs int) (int, bool, error) {
+	deadline := time.Now().Add(time.Duration(timeoutMs) * time.Millisecond)
+
+	prev, err := d.client.Screenshot()
+	if err != nil {
+		return 0, false, err
+	}
+	frames := 1
+	for time.Now().Before(deadline) {
+		time.Sleep(animationPollInterval)
+		frame, err := d.client.Screenshot()
+		if err != nil {
+			return frames, false, err
+		}
+		frames++
+		if diff, err := core.FrameDiff(prev, frame); err == nil && diff <= core.FrameDiffTolerance {
+			return frames, true, nil
+		}
+		prev = frame
+	}
+	return frames, false, nil
+}
+
+// waitForTextPollInterval is how often waitForText re-reads the page source.
+const waitForTextPollInterval = 300 * time.Millisecond
+
+// waitForText polls the first element matching the step's selector until its
+// text (or content-desc when the text is empty) satisfies the condition.
+func (d *Driver) waitForText(step *flow.WaitForTextStep) *core.CommandResult {
+	matcher, err := core.NewTextMatcher(step.Equals, step.Contains, step.Matches)
+	if err != nil {
+		return errorResult(err, fmt.Sprintf("Invalid waitForText condition: %v", err))
+	}
+
+	timeoutMs := step.TimeoutMs
+	if timeoutMs <= 0 {
+		timeoutMs = DefaultFindTimeout
+	}
+	deadline := time.Now().Add(time.Duration(timeoutMs) * time.Millisecond)
+
+	found := false
+	var last string
+	for {
+		elements, err := d.pageSourceElements()
+		if err != nil {
+			return errorResult(err, fmt.Sprintf("Failed to read page source: %v", err))
+		}
+		if matches := FilterBySelector(elements, step.Element); len(matches) > 0 {
+			found = true
+			last = matches[0].Text
+			if last == "" {
+				last = matches[0].ContentDesc
+			}
+			if matcher.Match(last) {
+				return successResult(fmt.Sprintf("Text matched: %q", last), nil)
+			}
+		}
+		if time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(waitForTextPollInterval)
+	}
+
+	if !found {
+		return errorResult(fmt.Errorf("element not found"),
+			fmt.Sprintf("Element %s not found within %dms", step.Element.Describe(), timeoutMs))
+	}
+	return errorResult(context.DeadlineExceeded,
+		fmt.Sprintf("Text of %s did not %s within %dms; last text: %q", step.Element.Describe(), matcher, timeoutMs, last))
+}
+
+// defaultDownloadDir is where browsers and DownloadManager save files by default.
+const defaultDownloadDir = "/sdcard/Download"
+
+// partialDownloadSuffixes mark files that are still being written.
+var partialDownloadSuffixes = []string{".crdownload", ".part", ".tmp", ".download"}
+
+// waitForDownload polls the download directory until a file matching the step's
+// name or glob appears and its size is unchanged between two consecutive polls.
+func (d *Driver) waitForDownload(step *flow.WaitForDownloadStep) *core.CommandResult {
+	if step.File == "" {
+		return errorResult(fmt.Errorf("no file specified"), "waitForDownload requires a file name or pattern")
+	}
+	if _, err := path.Match(step.File, ""); err != nil {
+		return errorResult(err, fmt.Sprintf("Invalid file pattern %q: %v", step.File, err))
+	}
+	if d.device == nil {
+		return errorResult(fmt.Errorf("device not configured"), "waitForDownload requires device access")
+	}
+
+	dir := step.Directory
+	if dir == "" {
+		dir = defaultDownloadDir
+	}
+	timeout := step.TimeoutMs
+	if timeout <= 0 {
+		timeout = 30000
+	}
+	deadline := time.Now().Add(time.Duration(timeout) * time.Millisecond)
+	pollInterval := 500 * time.Millisecond
+
+	lastName, lastSize := "", int64(-1)
+	for {
+		name, size, err := d.findDownload(dir, step.File)
+		if err != nil {
+			logger.Debug("waitForDownload: %v", err)
+		} else if name != "" {
+			if name == lastName && size == lastSize {
+				return successResult(fmt.Sprintf("Download complete: %s/%s (%d bytes)", dir, name, size), nil)
+			}
+			lastName, lastSize = name, size
+		}
+
+		if time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(pollInterval)
+	}
+
+	if lastName == "" {
+		return errorResult(fmt.Errorf("download not found"),
+			fmt.Sprintf("No file matching %q appeared in %s within %dms", step.File, dir, timeout))
+	}
+	return errorResult(fmt.Errorf("download not finished"),
+		fmt.Sprintf("Download %s/%s still growing after %dms (%d bytes)", dir, lastName, timeout, lastSize))
+}
+
+// findDownload returns the first completed file in dir matching pattern and its
+// size in bytes. An empty name means no matching file exists yet.
+func (d *Driver) findDownload(dir, pattern string) (string, int64, error) {
+	output, err := d.device.Shell(fmt.Sprintf("ls -1 '%s'", dir))
+	if err != nil {
+		return "", 0, fmt.Errorf("list %s: %w", dir, err)
+	}
+
+	for _, line := range strings.Split(output, "\n") {
+		name := strings.TrimSpace(line)
+		if name == "" || isPartialDownload(name) {
+			continue
+		}
+		if ok, _ := path.Match(pattern, name); !ok {
+			continue
+		}
+
+		size, err := d.deviceFileSize(dir, name)
+		if err != nil {
+			return "", 0, err
+		}
+		return name, size, nil
+	}
+	return "", 0, nil
+}
+
+// deviceFileSize returns the size in bytes of dir/name on the device.
+func (d *Driver) deviceFileSize(dir, name string) (int64, error) {
+	sizeOut, err := d.device.Shell(fmt.Sprintf("stat -c %%s '%s/%s'", dir, name))
+	if err != nil {
+		return 0, fmt.Errorf("stat %s: %w", name, err)
+	}
+	size, err := strconv.ParseInt(strings.TrimSpace(sizeOut), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("unexpected stat output for %s: %q", name, sizeOut)
+	}
+	return size, nil
+}
+
+// assertFileExists checks an absolute device path (the file name part may be
+// a glob) for a file of at least MinSize bytes.
+func (d *Driver) assertFileExists(step *flow.AssertFileExistsStep) *core.CommandResult {
+	if !path.IsAbs(step.Path) {
+		return errorResult(fmt.Errorf("invalid device path %q", step.Path),
+			"assertFileExists with device: true requires an absolute path")
+	}
+	dir, pattern := path.Dir(step.Path), path.Base(step.Path)
+	if _, err := path.Match(pattern, ""); err != nil {
+		return errorResult(err, fmt.Sprintf("Invalid file pattern %q: %v", pattern, err))
+	}
+	if d.device == nil {
+		return errorResult(fmt.Errorf("device not configured"), "assertFileExists requires device access")
+	}
+
+	output, err := d.device.Shell(fmt.Sprintf("ls -1 '%s'", dir))
+	if err != nil {
+		return errorResult(err, fmt.Sprintf("Failed to list %s: %v", dir, err))
+	}
+
+	largest, largestSize := "", int64(-1)
+	for _, line := range strings.Split(output, "\n") {
+		name := strings.TrimSpace(line)
+		if name == "" {
+			continue
+		}
+		if ok, _ := path.Match(pattern, name); !ok {
+			continue
+		}
+		size, err := d.deviceFileSize(dir, name)
+		if err != nil {
+			return errorResult(err, fmt.Sprintf("Failed to read size of %s/%s: %v", dir, name, err))
+		}
+		if size >= step.MinSize {
+			return successResult(fmt.Sprintf("File exists: %s/%s (%d bytes)", dir, name, size), nil)
+		}
+		if size > largestSize {
+			largest, largestSize = name, size
+		}
 	}
+
+	if largest != "" {
+		return errorResult(fmt.Errorf("file too small"),
+			fmt.Sprintf("File %s/%s is %d bytes, below minSize %d", dir, largest, largestSize, step.MinSize))
+	}
+	return errorResult(fmt.Errorf("file not found"), fmt.Sprintf("No file matching %q found in %s", pattern, dir))
+}
+
+func isPartialDownload(name string) bool {
+	if strings.HasPrefix(name, ".pending-") {
+		return true
+	}
+	for _, suffix := range partialDownloadSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// installPollInterval is how often waitForInstall re-lists packages.
+const installPollInterval = 500 * time.Millisecond
+
+// waitForInstall polls `pm list packages` until the app shows up, for
+// flows started while a background install is still running.
+func (d *Driver) waitForInstall(step *flow.WaitForInstallStep) *core.CommandResult {
+	if !packageNamePattern.MatchString(step.AppID) {
+		return errorResult(fmt.Errorf("invalid appId %q", step.AppID), "waitForInstall requires a valid appId")
+	}
+	if d.device == nil {
+		return errorResult(fmt.Errorf("device not configured"), "waitForInstall requires device access")
+	}
+
+	timeout := step.Timeout()
+	start := time.Now()
+	deadline := start.Add(time.Duration(timeout) * time.Millisecond)
+	for {
+		output, err := d.device.Shell("pm list packages " + step.AppID)
+		if err != nil {
+			logger.Debug("waitForInstall: %v", err)
+		} else if packageListed(output, step.AppID) {
+			return successResult(fmt.Sprintf("App %s installed after %dms", step.AppID, time.Since(start).Milliseconds()), nil)
+		}
+
+		if time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(installPollInterval)
+	}
+
+	return errorResult(fmt.Errorf("app not installed"),
+		fmt.Sprintf("App %s was not installed within %dms", step.AppID, timeout))
+}
+
+// packageListed reports whether `pm list packages` output has an exact line
+// for pkg; the filter argument also lists packages that merely contain it.
+func packageListed(output, pkg string) bool {
+	for _, line := range strings.Split(output, "\n") {
+		if strings.TrimSpace(line) == "package:"+pkg {
+			return true
+		}
+	}
+	return false
 }
 
 // ============================================================================
@@ -1497,34 +3412,230 @@ func (d *Driver) travel(step *flow.TravelStep) *core.CommandResult {
 		return errorResult(fmt.Errorf("at least 2 points required"), "Travel requires at least 2 waypoints")
 	}
 
-	speed := step.Speed
-	if speed <= 0 {
-		speed = 50 // default 50 km/h
-	}
-
 	// Simulate travel by updating location at each point
-	for _, point := range step.Points {
-		// Parse "lat, lon" format
-		parts := strings.Split(point, ",")
-		if len(parts) != 2 {
-			continue
-		}
-		lat := strings.TrimSpace(parts[0])
-		lon := strings.TrimSpace(parts[1])
-
-		cmd := fmt.Sprintf("am broadcast -a android.intent.action.MOCK_LOCATION --ef lat %s --ef lon %s", lat, lon)
+	delay := step.PointDelay()
+	for _, point := range step.Waypoints() {
+		cmd := fmt.Sprintf("am broadcast -a android.intent.action.MOCK_LOCATION --ef lat %s --ef lon %s", point.Lat, point.Lon)
 		if _, err := d.device.Shell(cmd); err != nil {
 			return errorResult(err, fmt.Sprintf("Failed to set location during travel: %v", err))
 		}
-
-		// Wait based on speed (simplified - assumes ~1km between points)
-		delay := time.Duration(3600/speed) * time.Second
 		time.Sleep(delay)
 	}
 
 	return successResult(fmt.Sprintf("Traveled through %d points", len(step.Points)), nil)
 }
 
+// maxCancelAllSDK is the newest API level on which binder transaction 1 of
+// the notification service is known to be cancelAllNotifications. Transaction
+// numbers follow the INotificationManager AIDL order, which any release may
+// change, so the fallback below refuses newer devices.
+const maxCancelAllSDK = 34
+
+// clearNotifications opens the notification shade and taps its "Clear all"
+// button, then checks the button is gone: SystemUI hides it once no
+// dismissible notification remains, so ongoing ones don't fail the step. A
+// shade without the footer SystemUI always shows is an OEM layout this can't
+// read; there notifications are cancelled through the notification service
+// instead, on API levels up to maxCancelAllSDK only.
+func (d *Driver) clearNotifications(_ *flow.ClearNotificationsStep) *core.CommandResult {
+	if d.device == nil {
+		return errorResult(fmt.Errorf("device not configured"), "clearNotifications requires device access")
+	}
+
+	if _, err := d.device.Shell("cmd statusbar expand-notifications"); err != nil {
+		return errorResult(err, fmt.Sprintf("Failed to open notification shade: %v", err))
+	}
+	defer func() {
+		if _, err := d.device.Shell("cmd statusbar collapse"); err != nil {
+			logger.Warn("failed to collapse notification shade: %v", err)
+		}
+	}()
+	time.Sleep(500 * time.Millisecond) // let the shade finish expanding
+
+	elements, err := d.pageSourceElements()
+	if err != nil {
+		return errorResult(err, fmt.Sprintf("Failed to read notification shade: %v", err))
+	}
+
+	if button := FindClearAllButton(elements); button != nil {
+		x, y := button.Bounds.Center()
+		if err := d.client.Click(x, y); err != nil {
+			return errorResult(err, fmt.Sprintf("Failed to tap Clear all: %v", err))
+		}
+	} else if rows := CountNotificationRows(elements); rows > 0 && !HasNotificationFooter(elements) {
+		if err := d.cancelAllNotifications(); err != nil {
+			return errorResult(err, fmt.Sprintf("Failed to clear notifications: %v", err))
+		}
+	} else {
+		return successResult("No notifications to clear", nil)
+	}
+	time.Sleep(500 * time.Millisecond) // let the rows animate out
+
+	d.invalidateSource()
+	elements, err = d.pageSourceElements()
+	if err != nil {
+		return errorResult(err, fmt.Sprintf("Failed to read notification shade: %v", err))
+	}
+	if FindClearAllButton(elements) != nil || (CountNotificationRows(elements) > 0 && !HasNotificationFooter(elements)) {
+		remaining := CountNotificationRows(elements)
+		return errorResult(fmt.Errorf("%d notification(s) still in shade", remaining),
+			fmt.Sprintf("Notification shade not empty: %d notification(s) remain", remaining))
+	}
+
+	return successResult("Cleared all notifications", nil)
+}
+
+// cancelAllNotifications calls INotificationManager.cancelAllNotifications
+// through `service call`, after checking the API level keeps it at
+// transaction 1.
+func (d *Driver) cancelAllNotifications() error {
+	out, err := d.device.Shell("getprop ro.build.version.sdk")
+	if err != nil {
+		return fmt.Errorf("read Android version: %w", err)
+	}
+	sdk, err := strconv.Atoi(strings.TrimSpace(out))
+	if err != nil {
+		return fmt.Errorf("read Android version: %q", strings.TrimSpace(out))
+	}
+	if sdk > maxCancelAllSDK {
+		return fmt.Errorf("no Clear all button in the notification shade, and the notification service fallback is unverified on API %d (newest known is %d)", sdk, maxCancelAllSDK)
+	}
+	_, err = d.device.Shell("service call notification 1")
+	return err
+}
+
+// keyguardMarkers are `dumpsys window` fields reporting a visible lock screen.
+// The field name changed across Android releases, so any of them counts.
+var keyguardMarkers = []string{
+	"mShowingLockscreen=true",
+	"mDreamingLockscreen=true",
+	"isKeyguardShowing=true",
+	"mKeyguardShowing=true",
+}
+
+// isKeyguardShowing reports whether `dumpsys window` output shows a lock screen.
+func isKeyguardShowing(dumpsys string) bool {
+	for _, marker := range keyguardMarkers {
+		if strings.Contains(dumpsys, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *Driver) keyguardShowing() (bool, error) {
+	output, err := d.device.Shell("dumpsys window")
+	if err != nil {
+		return false, err
+	}
+	return isKeyguardShowing(output), nil
+}
+
+// ensureUnlocked wakes the screen and dismisses the keyguard, swiping it away
+// first and entering the passcode only if a secure lock screen remains.
+func (d *Driver) ensureUnlocked(step *flow.EnsureUnlockedStep) *core.CommandResult {
+	if d.device == nil {
+		return errorResult(fmt.Errorf("device not configured"), "ensureUnlocked requires device access")
+	}
+
+	// KEYCODE_WAKEUP is a no-op when the screen is already on
+	if _, err := d.device.Shell("input keyevent 224"); err != nil {
+		return errorResult(err, fmt.Sprintf("Failed to wake screen: %v", err))
+	}
+
+	locked, err := d.keyguardShowing()
+	if err != nil {
+		return errorResult(err, fmt.Sprintf("Failed to read lock state: %v", err))
+	}
+	if !locked {
+		return successResult("Device is unlocked", nil)
+	}
+
+	// Swipe up over the lock screen, then ask the window manager to dismiss it.
+	// Insecure keyguards go away; secure ones bring up the PIN/password bouncer.
+	width, height, err := d.getScreenSize()
+	if err != nil {
+		width, height = 1080, 1920
+	}
+	swipe := fmt.Sprintf("input swipe %d %d %d %d 300", width/2, height*4/5, width/2, height/5)
+	if _, err := d.device.Shell(swipe); err != nil {
+		return errorResult(err, fmt.Sprintf("Failed to swipe lock screen: %v", err))
+	}
+	if _, err := d.device.Shell("wm dismiss-keyguard"); err != nil {
+		return errorResult(err, fmt.Sprintf("Failed to dismiss keyguard: %v", err))
+	}
+	time.Sleep(500 * time.Millisecond)
+
+	if locked, err = d.keyguardShowing(); err != nil {
+		return errorResult(err, fmt.Sprintf("Failed to read lock state: %v", err))
+	}
+	if !locked {
+		return successResult("Unlocked device", nil)
+	}
+
+	if step.Passcode == "" {
+		return errorResult(fmt.Errorf("secure lock screen"),
+			"Device lock screen requires a passcode; set passcode on ensureUnlocked")
+	}
+
+	if _, err := d.device.Shell("input text " + shellQuote(step.Passcode)); err != nil {
+		return errorResult(err, fmt.Sprintf("Failed to enter passcode: %v", err))
+	}
+	if _, err := d.device.Shell("input keyevent 66"); err != nil {
+		return errorResult(err, fmt.Sprintf("Failed to submit passcode: %v", err))
+	}
+	time.Sleep(500 * time.Millisecond)
+
+	if locked, err = d.keyguardShowing(); err != nil {
+		return errorResult(err, fmt.Sprintf("Failed to read lock state: %v", err))
+	}
+	if locked {
+		return errorResult(fmt.Errorf("device still locked"), "Device still locked after entering passcode")
+	}
+
+	return successResult("Unlocked device with passcode", nil)
+}
+
+// shellQuote wraps s in single quotes for the device shell, so passcodes
+// with quotes, spaces or ;&$ reach `input text` as one literal argument.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// setAppearance switches system dark mode with the UiModeManager shell command.
+func (d *Driver) setAppearance(step *flow.SetAppearanceStep) *core.CommandResult {
+	dark, err := step.Dark()
+	if err != nil {
+		return errorResult(err, err.Error())
+	}
+	if d.device == nil {
+		return errorResult(fmt.Errorf("device not configured"), "setAppearance requires device access")
+	}
+
+	if _, err := d.device.Shell(appearanceCommand(dark)); err != nil {
+		return errorResult(err, fmt.Sprintf("Failed to set appearance: %v", err))
+	}
+	if step.SettleMs > 0 {
+		time.Sleep(time.Duration(step.SettleMs) * time.Millisecond)
+	}
+
+	return successResult(fmt.Sprintf("Set appearance to %s", appearanceName(dark)), nil)
+}
+
+func appearanceCommand(dark bool) string {
+	if dark {
+		return "cmd uimode night yes"
+	}
+	return "cmd uimode night no"
+}
+
+func appearanceName(dark bool) string {
+	if dark {
+		return "dark"
+	}
+	return "light"
+}
+
 // ============================================================================
 // Helpers
 // ============================================================================
@@ -1602,34 +3713,3 @@ func mapKeyCode(key string) int {
 		return 0
 	}
 }
-
-func randomString(length int) string {
-	const chars = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-	b := make([]byte, length)
-	for i := range b {
-		b[i] = chars[rand.Intn(len(chars))]
-	}
-	return string(b)
-}
-
-func randomEmail() string {
-	user := randomString(8)
-	domains := []string{"example.com", "test.com", "mail.com"}
-	domain := domains[rand.Intn(len(domains))]
-	return user + "@" + domain
-}
-
-func randomNumber(length int) string {
-	const digits = "0123456789"
-	b := make([]byte, length)
-	for i := range b {
-		b[i] = digits[rand.Intn(len(digits))]
-	}
-	return string(b)
-}
-
-func randomPersonName() string {
-	firstNames := []string{"John", "Jane", "Michael", "Emily", "David", "Sarah", "James", "Emma", "Robert", "Olivia"}
-	lastNames := []string{"Smith", "Johnson", "Williams", "Brown", "Jones", "Garcia", "Miller", "Davis", "Rodriguez", "Martinez"}
-	return firstNames[rand.Intn(len(firstNames))] + " " + lastNames[rand.Intn(len(lastNames))]
-}
@@ -1,13 +1,23 @@
 package uiautomator2
 
 import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"image"
+	"image/png"
 	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/devicelab-dev/maestro-runner/pkg/core"
 	"github.com/devicelab-dev/maestro-runner/pkg/flow"
 	"github.com/devicelab-dev/maestro-runner/pkg/uiautomator2"
 )
@@ -70,32 +80,6 @@ func TestMapKeyCode(t *testing.T) {
 	}
 }
 
-func TestRandomString(t *testing.T) {
-	// Test various lengths
-	lengths := []int{0, 1, 5, 10, 50}
-	for _, length := range lengths {
-		result := randomString(length)
-		if len(result) != length {
-			t.Errorf("randomString(%d) returned length %d", length, len(result))
-		}
-	}
-
-	// Test randomness (two calls should produce different results for sufficient length)
-	r1 := randomString(20)
-	r2 := randomString(20)
-	if r1 == r2 {
-		t.Error("randomString should produce different results")
-	}
-
-	// Test character set
-	result := randomString(100)
-	for _, c := range result {
-		if !((c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')) {
-			t.Errorf("randomString contains invalid character: %c", c)
-		}
-	}
-}
-
 func TestLaunchAppNoDevice(t *testing.T) {
 	driver := &Driver{device: nil}
 	step := &flow.LaunchAppStep{AppID: "com.example.app"}
@@ -224,6 +208,23 @@ func TestStopAppSuccess(t *testing.T) {
 	}
 }
 
+func TestStopAppGraceful(t *testing.T) {
+	mock := &MockShellExecutor{response: "Success"}
+	driver := &Driver{device: mock}
+	step := &flow.StopAppStep{AppID: "com.example.app", Graceful: true}
+
+	result := driver.stopApp(step)
+
+	if !result.Success {
+		t.Errorf("expected success, got error: %v", result.Error)
+	}
+
+	want := []string{"input keyevent 3", "am force-stop com.example.app"}
+	if len(mock.commands) != 2 || mock.commands[0] != want[0] || mock.commands[1] != want[1] {
+		t.Errorf("expected %v, got %v", want, mock.commands)
+	}
+}
+
 func TestClearStateNoDevice(t *testing.T) {
 	driver := &Driver{device: nil}
 	step := &flow.ClearStateStep{AppID: "com.example.app"}
@@ -293,6 +294,96 @@ func TestPressKeyUnknown(t *testing.T) {
 	}
 }
 
+func searchServer(t *testing.T, events *[]string) *httptest.Server {
+	return setupMockServer(t, map[string]func(w http.ResponseWriter, r *http.Request){
+		"POST /element": func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, map[string]interface{}{"value": map[string]string{"ELEMENT": "search-field"}})
+		},
+		"GET /element/search-field/rect": func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, map[string]interface{}{"value": map[string]int{"x": 40, "y": 100, "width": 1000, "height": 80}})
+		},
+		"GET /element/search-field/attribute/displayed": func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, map[string]interface{}{"value": "true"})
+		},
+		"POST /element/search-field/click": func(w http.ResponseWriter, r *http.Request) {
+			*events = append(*events, "tap")
+			writeJSON(w, map[string]interface{}{"value": nil})
+		},
+		"POST /appium/gestures/click": func(w http.ResponseWriter, r *http.Request) {
+			*events = append(*events, "tap")
+			writeJSON(w, map[string]interface{}{"value": nil})
+		},
+		"GET /element/active": func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, map[string]interface{}{"value": map[string]string{"ELEMENT": "search-field"}})
+		},
+		"POST /element/search-field/value": func(w http.ResponseWriter, r *http.Request) {
+			var req uiautomator2.InputTextRequest
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			*events = append(*events, "type:"+req.Text)
+			writeJSON(w, map[string]interface{}{"value": nil})
+		},
+		"POST /appium/device/press_keycode": func(w http.ResponseWriter, r *http.Request) {
+			var req uiautomator2.KeyCodeRequest
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			*events = append(*events, fmt.Sprintf("key:%d", req.KeyCode))
+			writeJSON(w, map[string]interface{}{"value": nil})
+		},
+	})
+}
+
+func TestSearchFocusesTypesAndSubmits(t *testing.T) {
+	var events []string
+	server := searchServer(t, &events)
+	defer server.Close()
+
+	client := newMockHTTPClient(server.URL)
+	driver := New(client.Client, nil, nil)
+
+	step := &flow.SearchStep{Field: flow.Selector{ID: "search_src_text"}, Query: "coffee"}
+	result := driver.Execute(step)
+
+	if !result.Success {
+		t.Fatalf("expected success, got: %s", result.Message)
+	}
+	want := []string{"tap", "type:coffee", "key:84"}
+	if strings.Join(events, ",") != strings.Join(want, ",") {
+		t.Errorf("events = %v, want %v", events, want)
+	}
+	if result.Message != `Searched for "coffee"` {
+		t.Errorf("unexpected message: %s", result.Message)
+	}
+}
+
+func TestSearchWithoutSubmit(t *testing.T) {
+	var events []string
+	server := searchServer(t, &events)
+	defer server.Close()
+
+	client := newMockHTTPClient(server.URL)
+	driver := New(client.Client, nil, nil)
+
+	submit := false
+	result := driver.Execute(&flow.SearchStep{Query: "tea", Submit: &submit})
+
+	if !result.Success {
+		t.Fatalf("expected success, got: %s", result.Message)
+	}
+	want := []string{"type:tea"}
+	if strings.Join(events, ",") != strings.Join(want, ",") {
+		t.Errorf("events = %v, want %v", events, want)
+	}
+}
+
+func TestSearchNoQuery(t *testing.T) {
+	driver := &Driver{}
+
+	result := driver.search(&flow.SearchStep{Field: flow.Selector{ID: "search"}})
+
+	if result.Success {
+		t.Error("expected failure without a query")
+	}
+}
+
 // ============================================================================
 // KillApp Tests
 // ============================================================================
@@ -670,6 +761,116 @@ func TestStartRecordingSuccess(t *testing.T) {
 	}
 }
 
+func TestStartRecordingWithSize(t *testing.T) {
+	mock := &MockShellExecutor{response: "Success"}
+	driver := &Driver{device: mock}
+	step := &flow.StartRecordingStep{Path: "/sdcard/test.mp4", Size: "720x1280"}
+
+	result := driver.startRecording(step)
+
+	if !result.Success {
+		t.Fatalf("expected success, got error: %v", result.Error)
+	}
+	if len(mock.commands) != 1 || mock.commands[0] != "screenrecord --size 720x1280 /sdcard/test.mp4 &" {
+		t.Errorf("expected --size forwarded to screenrecord, got %v", mock.commands)
+	}
+}
+
+func TestStartRecordingWithoutSizeOmitsFlag(t *testing.T) {
+	mock := &MockShellExecutor{response: "Success"}
+	driver := &Driver{device: mock}
+
+	driver.startRecording(&flow.StartRecordingStep{Path: "/sdcard/test.mp4"})
+
+	if len(mock.commands) != 1 || strings.Contains(mock.commands[0], "--size") {
+		t.Errorf("expected no --size flag, got %v", mock.commands)
+	}
+}
+
+func TestStartRecordingRejectsMask(t *testing.T) {
+	mock := &MockShellExecutor{response: "Success"}
+	driver := &Driver{device: mock}
+
+	result := driver.startRecording(&flow.StartRecordingStep{Mask: "black"})
+
+	if result.Success {
+		t.Error("expected failure for mask on Android")
+	}
+	if len(mock.commands) != 0 {
+		t.Errorf("expected no shell command for mask, got %v", mock.commands)
+	}
+}
+
+func TestStartRecordingInvalidSize(t *testing.T) {
+	mock := &MockShellExecutor{response: "Success"}
+	driver := &Driver{device: mock}
+
+	result := driver.startRecording(&flow.StartRecordingStep{Size: "720by1280"})
+
+	if result.Success {
+		t.Error("expected failure for invalid size")
+	}
+	if len(mock.commands) != 0 {
+		t.Errorf("expected no shell command for invalid size, got %v", mock.commands)
+	}
+}
+
+func TestStartRecordingCropMeasuresStatusBar(t *testing.T) {
+	tests := []struct {
+		name    string
+		dumpsys string
+	}{
+		{"android 13+", "  InsetsSource id=3a8e0005 type=statusBars frame=[0,0][1080,120] visible=true\n"},
+		{"android 11-12", "  InsetsSource type=ITYPE_STATUS_BAR frame=[0,0][1080,120] visible=true\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shell := &prefixShell{responses: map[string]string{
+				"dumpsys window": tt.dumpsys,
+				"wm size":        "Physical size: 1080x2400",
+			}}
+			driver := &Driver{device: shell}
+
+			result := driver.startRecording(&flow.StartRecordingStep{Path: "/sdcard/test.mp4", Crop: core.RecordingCropStatusBar})
+
+			if !result.Success {
+				t.Fatalf("expected success, got: %s", result.Message)
+			}
+			if driver.recordingCrop != 0.05 {
+				t.Errorf("recordingCrop = %v, want 0.05 (120 of 2400 px)", driver.recordingCrop)
+			}
+		})
+	}
+}
+
+func TestStartRecordingCropWithoutStatusBar(t *testing.T) {
+	shell := &prefixShell{responses: map[string]string{"wm size": "Physical size: 1080x2400"}}
+	driver := &Driver{device: shell}
+
+	result := driver.startRecording(&flow.StartRecordingStep{Crop: core.RecordingCropStatusBar})
+
+	if result.Success {
+		t.Error("expected failure when the status bar can't be measured")
+	}
+	if indexOfCommand(shell.commands, "screenrecord") >= 0 {
+		t.Errorf("expected no recording started, got %v", shell.commands)
+	}
+}
+
+func TestStartRecordingRejectsUnknownCrop(t *testing.T) {
+	mock := &MockShellExecutor{response: "Success"}
+	driver := &Driver{device: mock}
+
+	result := driver.startRecording(&flow.StartRecordingStep{Crop: "navBar"})
+
+	if result.Success {
+		t.Error("expected failure for an unknown crop")
+	}
+	if len(mock.commands) != 0 {
+		t.Errorf("expected no shell command for an unknown crop, got %v", mock.commands)
+	}
+}
+
 func TestStartRecordingDefaultPath(t *testing.T) {
 	mock := &MockShellExecutor{response: "Success"}
 	driver := &Driver{device: mock}
@@ -713,12 +914,71 @@ func TestStopRecordingSuccess(t *testing.T) {
 	}
 }
 
+func TestStopRecordingCopiesToHostPath(t *testing.T) {
+	shell := &prefixShell{responses: map[string]string{
+		// adb wraps long base64 output across lines
+		"base64 /sdcard/test.mp4": "dmlk\nZW8=\n",
+	}}
+	driver := New(&MockUIA2Client{}, nil, shell)
+	hostPath := filepath.Join(t.TempDir(), "video.mp4")
+
+	if result := driver.startRecording(&flow.StartRecordingStep{Path: "/sdcard/test.mp4"}); !result.Success {
+		t.Fatalf("startRecording failed: %s", result.Message)
+	}
+	result := driver.stopRecording(&flow.StopRecordingStep{Path: hostPath})
+
+	if !result.Success {
+		t.Fatalf("expected success, got: %s", result.Message)
+	}
+	data, err := os.ReadFile(hostPath)
+	if err != nil || string(data) != "video" {
+		t.Errorf("expected decoded recording at %s, got %q (%v)", hostPath, data, err)
+	}
+	if indexOfCommand(shell.commands, "rm -f /sdcard/test.mp4") < 0 {
+		t.Errorf("expected the device copy to be removed, got %v", shell.commands)
+	}
+}
+
+func TestStopRecordingCropsHostCopy(t *testing.T) {
+	shell := &prefixShell{responses: map[string]string{"base64 /sdcard/test.mp4": "dmlkZW8="}}
+	driver := New(&MockUIA2Client{}, nil, shell)
+	driver.recordingPath, driver.recordingCrop = "/sdcard/test.mp4", 0.05
+	t.Setenv("PATH", "")
+
+	result := driver.stopRecording(&flow.StopRecordingStep{Path: filepath.Join(t.TempDir(), "video.mp4")})
+
+	if result.Success || !strings.Contains(result.Message, "requires ffmpeg") {
+		t.Errorf("expected the crop to run ffmpeg on the host copy, got: %s", result.Message)
+	}
+}
+
+func TestStopRecordingCropWithoutPath(t *testing.T) {
+	driver := New(&MockUIA2Client{}, nil, &prefixShell{})
+	driver.recordingPath, driver.recordingCrop = "/sdcard/test.mp4", 0.05
+
+	result := driver.stopRecording(&flow.StopRecordingStep{})
+
+	if result.Success {
+		t.Error("expected failure: crop applies to the copy saved at the stopRecording path")
+	}
+}
+
+func TestStopRecordingPathWithoutRecording(t *testing.T) {
+	driver := New(&MockUIA2Client{}, nil, &prefixShell{})
+
+	result := driver.stopRecording(&flow.StopRecordingStep{Path: filepath.Join(t.TempDir(), "video.mp4")})
+
+	if result.Success {
+		t.Error("expected failure when no recording was started")
+	}
+}
+
 // ============================================================================
 // WaitForAnimationToEnd Tests
 // ============================================================================
 
 func TestWaitForAnimationToEndSuccess(t *testing.T) {
-	driver := &Driver{}
+	driver := &Driver{client: &MockUIA2Client{screenshotData: []byte("frame")}}
 	step := &flow.WaitForAnimationToEndStep{}
 
 	result := driver.waitForAnimationToEnd(step)
@@ -728,6 +988,139 @@ func TestWaitForAnimationToEndSuccess(t *testing.T) {
 	}
 }
 
+// animationFrame encodes a small solid PNG; different shades are different frames.
+func animationFrame(t *testing.T, shade uint8) []byte {
+	t.Helper()
+	img := image.NewGray(image.Rect(0, 0, 8, 8))
+	for i := range img.Pix {
+		img.Pix[i] = shade
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// frameSequence returns each frame in turn, repeating the last one.
+func frameSequence(calls *int, frames ...[]byte) func() ([]byte, error) {
+	return func() ([]byte, error) {
+		i := *calls
+		if i >= len(frames) {
+			i = len(frames) - 1
+		}
+		*calls++
+		return frames[i], nil
+	}
+}
+
+func TestWaitForAnimationToEndSettles(t *testing.T) {
+	calls := 0
+	client := &MockUIA2Client{screenshotFunc: frameSequence(&calls,
+		animationFrame(t, 0), animationFrame(t, 80), animationFrame(t, 160), animationFrame(t, 160))}
+	driver := New(client, nil, nil)
+
+	result := driver.Execute(&flow.WaitForAnimationToEndStep{})
+
+	if !result.Success {
+		t.Fatalf("expected success, got: %s", result.Message)
+	}
+	if calls != 4 {
+		t.Errorf("expected 4 screenshots, got %d", calls)
+	}
+	if !strings.Contains(result.Message, "settled after comparing 4 frames") {
+		t.Errorf("unexpected message: %s", result.Message)
+	}
+}
+
+func TestWaitForAnimationToEndTimeout(t *testing.T) {
+	stop := false
+	tests := []struct {
+		name   string
+		step   *flow.WaitForAnimationToEndStep
+		wantOK bool
+	}{
+		{"continues by default", &flow.WaitForAnimationToEndStep{BaseStep: flow.BaseStep{TimeoutMs: 500}}, true},
+		{"fails when not continuing", &flow.WaitForAnimationToEndStep{BaseStep: flow.BaseStep{TimeoutMs: 500}, ContinueOnTimeout: &stop}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shade := uint8(0)
+			client := &MockUIA2Client{screenshotFunc: func() ([]byte, error) {
+				shade += 40
+				return animationFrame(t, shade), nil
+			}}
+			driver := New(client, nil, nil)
+
+			result := driver.Execute(tt.step)
+
+			if result.Success != tt.wantOK {
+				t.Fatalf("Success = %v, want %v (%s)", result.Success, tt.wantOK, result.Message)
+			}
+			if !strings.Contains(result.Message, "Animation still running after 500ms") {
+				t.Errorf("unexpected message: %s", result.Message)
+			}
+		})
+	}
+}
+
+func TestTapOnWaitToSettle(t *testing.T) {
+	frames := [][]byte{animationFrame(t, 0), animationFrame(t, 80), animationFrame(t, 80)}
+	tests := []struct {
+		name      string
+		timeoutMs int
+		next      func(calls int) []byte
+		wantShots int
+	}{
+		{"settles before tap", 2000, func(calls int) []byte { return frames[min(calls, len(frames)-1)] }, 3},
+		{"taps anyway after timeout", 300, func(calls int) []byte { return animationFrame(t, uint8(calls*40)) }, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			client := &MockUIA2Client{sourceData: totalsHierarchy}
+			client.screenshotFunc = func() ([]byte, error) {
+				if len(client.clickCalls) > 0 {
+					t.Error("screenshot taken after the tap")
+				}
+				frame := tt.next(calls)
+				calls++
+				return frame, nil
+			}
+			driver := New(client, nil, nil)
+
+			result := driver.Execute(&flow.TapOnStep{
+				Selector:              flow.Selector{Text: "Total: $12.50"},
+				WaitToSettleTimeoutMs: tt.timeoutMs,
+			})
+			if !result.Success {
+				t.Fatalf("expected success, got: %s", result.Message)
+			}
+			if len(client.clickCalls) != 1 {
+				t.Errorf("expected 1 tap, got %d", len(client.clickCalls))
+			}
+			if tt.wantShots > 0 && calls != tt.wantShots {
+				t.Errorf("expected %d screenshots, got %d", tt.wantShots, calls)
+			}
+			if calls < 2 {
+				t.Errorf("expected the screen to be sampled before tapping, got %d screenshots", calls)
+			}
+		})
+	}
+}
+
+func TestWaitForAnimationToEndScreenshotError(t *testing.T) {
+	driver := New(&MockUIA2Client{screenshotErr: errors.New("device offline")}, nil, nil)
+
+	result := driver.Execute(&flow.WaitForAnimationToEndStep{})
+
+	if result.Success {
+		t.Fatal("expected failure when screenshots fail")
+	}
+}
+
 // ============================================================================
 // SetLocation Tests
 // ============================================================================
@@ -990,8 +1383,8 @@ func TestAssertNotVisibleTimeout(t *testing.T) {
 }
 
 func TestAssertNotVisibleDefaultTimeout(t *testing.T) {
-	// Test with zero timeout (should use default 5000)
-	// Element not found - should succeed quickly regardless of timeout
+	// Test with zero timeout (should use the default confirmation window)
+	// Element not found - should succeed once the window passes
 	server := setupMockServer(t, map[string]func(w http.ResponseWriter, r *http.Request){
 		"POST /element": func(w http.ResponseWriter, r *http.Request) {
 			writeJSON(w, map[string]interface{}{
@@ -1011,7 +1404,7 @@ func TestAssertNotVisibleDefaultTimeout(t *testing.T) {
 
 	step := &flow.AssertNotVisibleStep{
 		Selector: flow.Selector{Text: "Nonexistent"},
-		BaseStep: flow.BaseStep{TimeoutMs: 0}, // Should default to 5000
+		BaseStep: flow.BaseStep{TimeoutMs: 0}, // Should default to DefaultNotVisibleWindowMs
 	}
 	result := driver.Execute(step)
 
@@ -1020,6 +1413,62 @@ func TestAssertNotVisibleDefaultTimeout(t *testing.T) {
 	}
 }
 
+// deleteButtonSource has a single button with resource-id com.example:id/deleteBtn.
+const deleteButtonSource = `<?xml version="1.0" encoding="UTF-8"?>
+<hierarchy>
+  <node class="android.widget.Button" resource-id="com.example:id/deleteBtn" text="Delete" bounds="[800,100][1000,200]" displayed="true"/>
+</hierarchy>`
+
+// emptySource has no matching elements.
+const emptySource = `<?xml version="1.0" encoding="UTF-8"?>
+<hierarchy>
+  <node class="android.widget.FrameLayout" bounds="[0,0][1080,2400]" displayed="true"/>
+</hierarchy>`
+
+// TestAssertNotVisibleByID mirrors the WDA test of the same name: an id match
+// fails and an absent id passes, even when UiAutomator's own lookup misses.
+func TestAssertNotVisibleByID(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  string
+		success bool
+	}{
+		{"present element fails", deleteButtonSource, false},
+		{"absent element passes", emptySource, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			driver := New(&MockUIA2Client{sourceData: tt.source}, nil, nil)
+			step := &flow.AssertNotVisibleStep{Selector: flow.Selector{ID: "deleteBtn"}}
+			step.TimeoutMs = 100
+
+			result := driver.assertNotVisible(step)
+
+			if result.Success != tt.success {
+				t.Fatalf("expected success=%v, got %v: %s", tt.success, result.Success, result.Message)
+			}
+		})
+	}
+}
+
+func TestAssertNotVisibleFailsWhenElementAppearsDuringWindow(t *testing.T) {
+	calls := 0
+	client := &MockUIA2Client{sourceFunc: sequencedSource(&calls, emptySource, deleteButtonSource)}
+	driver := New(client, nil, nil)
+	step := &flow.AssertNotVisibleStep{Selector: flow.Selector{Text: "Delete"}}
+	step.TimeoutMs = 2000
+
+	result := driver.assertNotVisible(step)
+
+	if result.Success {
+		t.Fatal("expected failure when the element shows up inside the confirmation window")
+	}
+	if !strings.Contains(result.Message, "should not be visible") {
+		t.Errorf("unexpected message: %s", result.Message)
+	}
+}
+
 // ============================================================================
 // EraseText Optimized Path Tests (HTTP Mock)
 // ============================================================================
@@ -1243,23 +1692,79 @@ func TestOpenLinkWithoutBrowserFlag(t *testing.T) {
 	}
 }
 
-// ============================================================================
-// OpenBrowser Error Test
-// ============================================================================
-
-func TestOpenBrowserShellError(t *testing.T) {
-	mock := &MockShellExecutor{err: errors.New("shell failed")}
+func TestOpenLinkClearStateColdStart(t *testing.T) {
+	mock := &MockShellExecutor{response: "Success"}
 	driver := &Driver{device: mock}
-	step := &flow.OpenBrowserStep{URL: "https://example.com"}
+	step := &flow.OpenLinkStep{Link: "myapp://orders/42", ClearState: true, AppID: "com.example.app"}
 
-	result := driver.openBrowser(step)
+	result := driver.openLink(step)
 
-	if result.Success {
-		t.Error("expected failure when shell command fails")
+	if !result.Success {
+		t.Fatalf("expected success, got error: %v", result.Error)
 	}
-}
-
-// ============================================================================
+	if len(mock.commands) != 3 {
+		t.Fatalf("expected 3 commands, got %d: %v", len(mock.commands), mock.commands)
+	}
+	if mock.commands[0] != "pm clear com.example.app" {
+		t.Errorf("expected clear first, got: %s", mock.commands[0])
+	}
+	if mock.commands[1] != "am force-stop com.example.app" {
+		t.Errorf("expected force-stop second, got: %s", mock.commands[1])
+	}
+	if !strings.HasPrefix(mock.commands[2], "am start") || !strings.Contains(mock.commands[2], "myapp://orders/42") {
+		t.Errorf("expected deep link intent last, got: %s", mock.commands[2])
+	}
+}
+
+func TestOpenLinkClearStateWithoutAppID(t *testing.T) {
+	mock := &MockShellExecutor{response: "Success"}
+	driver := &Driver{device: mock}
+	step := &flow.OpenLinkStep{Link: "myapp://orders/42", ClearState: true}
+
+	result := driver.openLink(step)
+
+	if result.Success {
+		t.Error("expected failure without appId")
+	}
+	if len(mock.commands) != 0 {
+		t.Errorf("expected no commands, got: %v", mock.commands)
+	}
+}
+
+func TestOpenLinkClearStateFailureSkipsLink(t *testing.T) {
+	mock := &MockShellExecutor{err: fmt.Errorf("pm failed")}
+	driver := &Driver{device: mock}
+	step := &flow.OpenLinkStep{Link: "myapp://orders/42", ClearState: true, AppID: "com.example.app"}
+
+	result := driver.openLink(step)
+
+	if result.Success {
+		t.Error("expected failure when clear fails")
+	}
+	for _, cmd := range mock.commands {
+		if strings.HasPrefix(cmd, "am start") {
+			t.Errorf("deep link should not fire after failed clear, got: %s", cmd)
+		}
+	}
+}
+
+// ============================================================================
+// OpenBrowser Error Test
+// ============================================================================
+
+func TestOpenBrowserShellError(t *testing.T) {
+	mock := &MockShellExecutor{err: errors.New("shell failed")}
+	driver := &Driver{device: mock}
+	step := &flow.OpenBrowserStep{URL: "https://example.com"}
+
+	result := driver.openBrowser(step)
+
+	if result.Success {
+		t.Error("expected failure when shell command fails")
+	}
+}
+
+// ============================================================================
 // SetLocation Additional Tests
 // ============================================================================
 
@@ -1330,6 +1835,42 @@ func TestScrollEmptyDirection(t *testing.T) {
 	}
 }
 
+func TestScrollAmount(t *testing.T) {
+	tests := []struct {
+		amount      string
+		wantPercent float64
+		wantOK      bool
+	}{
+		{"", 0.5, true},
+		{"30%", 0.3, true},
+		{"0.1", 0.1, true},
+		{"0%", 0, false},
+		{"far", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.amount, func(t *testing.T) {
+			client := &MockUIA2Client{}
+			driver := New(client, nil, nil)
+
+			result := driver.Execute(&flow.ScrollStep{Direction: "down", Amount: tt.amount})
+
+			if result.Success != tt.wantOK {
+				t.Fatalf("expected success=%v, got: %s", tt.wantOK, result.Message)
+			}
+			if !tt.wantOK {
+				if len(client.scrollCalls) != 0 {
+					t.Errorf("expected no scroll for invalid amount, got %d", len(client.scrollCalls))
+				}
+				return
+			}
+			if len(client.scrollPercents) != 1 || client.scrollPercents[0] != tt.wantPercent {
+				t.Errorf("expected scroll percent %v, got %v", tt.wantPercent, client.scrollPercents)
+			}
+		})
+	}
+}
+
 // ============================================================================
 // ScrollUntilVisible Additional Tests
 // ============================================================================
@@ -1425,6 +1966,252 @@ func TestScrollUntilVisibleUpDirection(t *testing.T) {
 	}
 }
 
+func TestScrollUntilVisibleMaxScrolls(t *testing.T) {
+	client := &MockUIA2Client{
+		sourceData: `<hierarchy><node text="Other" bounds="[0,0][100,100]"/></hierarchy>`,
+	}
+	driver := New(client, nil, nil)
+
+	result := driver.Execute(&flow.ScrollUntilVisibleStep{
+		Element:    flow.Selector{Text: "Target"},
+		MaxScrolls: 2,
+	})
+
+	if result.Success {
+		t.Fatal("expected failure when element is never found")
+	}
+	if len(client.scrollCalls) != 2 {
+		t.Errorf("expected exactly 2 scrolls, got %d", len(client.scrollCalls))
+	}
+	if !strings.Contains(result.Message, "after 2 scrolls") {
+		t.Errorf("expected scroll count in message, got: %s", result.Message)
+	}
+}
+
+func TestScrollUntilVisibleSpeed(t *testing.T) {
+	scrollWith := func(speed string) *MockUIA2Client {
+		client := &MockUIA2Client{
+			sourceData: `<hierarchy><node text="Other" bounds="[0,0][100,100]"/></hierarchy>`,
+		}
+		New(client, nil, nil).Execute(&flow.ScrollUntilVisibleStep{
+			Element:    flow.Selector{Text: "Target"},
+			Speed:      speed,
+			MaxScrolls: 1,
+		})
+		return client
+	}
+
+	slow, fast := scrollWith("slow"), scrollWith("fast")
+	if len(slow.scrollSpeeds) != 1 || len(fast.scrollSpeeds) != 1 {
+		t.Fatalf("expected one scroll each, got %d and %d", len(slow.scrollSpeeds), len(fast.scrollSpeeds))
+	}
+	if fast.scrollPercents[0] <= slow.scrollPercents[0] {
+		t.Errorf("expected fast to scroll further than slow, got %v vs %v", fast.scrollPercents[0], slow.scrollPercents[0])
+	}
+	if fast.scrollSpeeds[0] <= slow.scrollSpeeds[0] {
+		t.Errorf("expected fast to swipe quicker than slow, got %d vs %d", fast.scrollSpeeds[0], slow.scrollSpeeds[0])
+	}
+
+	invalid := scrollWith("warp")
+	if len(invalid.scrollCalls) != 0 {
+		t.Errorf("expected no scroll for invalid speed, got %d", len(invalid.scrollCalls))
+	}
+}
+
+func TestScrollUntilVisibleVisibilityPercentage(t *testing.T) {
+	// Target starts a quarter on screen (screen is 1080x2400) and is fully
+	// on screen after the first scroll
+	newClient := func() *MockUIA2Client {
+		client := &MockUIA2Client{}
+		client.sourceFunc = func() (string, error) {
+			bounds := "[0,2300][1080,2700]"
+			if len(client.scrollCalls) > 0 {
+				bounds = "[0,1000][1080,1400]"
+			}
+			return `<hierarchy><node text="Target" bounds="` + bounds + `"/></hierarchy>`, nil
+		}
+		return client
+	}
+
+	client := newClient()
+	result := New(client, nil, nil).Execute(&flow.ScrollUntilVisibleStep{
+		Element:              flow.Selector{Text: "Target"},
+		VisibilityPercentage: 100,
+		MaxScrolls:           3,
+	})
+	if !result.Success {
+		t.Fatalf("expected success, got: %s", result.Message)
+	}
+	if len(client.scrollCalls) != 1 {
+		t.Errorf("expected 1 scroll to reach full visibility, got %d", len(client.scrollCalls))
+	}
+	if !strings.Contains(result.Message, "100% visible") {
+		t.Errorf("expected achieved visibility in message, got: %s", result.Message)
+	}
+
+	// Default threshold accepts the partly visible element without scrolling
+	client = newClient()
+	result = New(client, nil, nil).Execute(&flow.ScrollUntilVisibleStep{Element: flow.Selector{Text: "Target"}})
+	if !result.Success || len(client.scrollCalls) != 0 {
+		t.Fatalf("expected success without scrolling, got %d scrolls: %s", len(client.scrollCalls), result.Message)
+	}
+	if !strings.Contains(result.Message, "25% visible") {
+		t.Errorf("expected 25%% visible in message, got: %s", result.Message)
+	}
+}
+
+func TestScrollUntilVisibleCenterElement(t *testing.T) {
+	client := &MockUIA2Client{
+		sourceData: `<hierarchy><node text="Target" bounds="[0,1900][1080,2000]"/></hierarchy>`,
+	}
+	result := New(client, nil, nil).Execute(&flow.ScrollUntilVisibleStep{
+		Element:       flow.Selector{Text: "Target"},
+		CenterElement: true,
+	})
+
+	if !result.Success {
+		t.Fatalf("expected success, got: %s", result.Message)
+	}
+	if len(client.pointerPathCalls) != 1 {
+		t.Fatalf("expected 1 centering drag, got %d", len(client.pointerPathCalls))
+	}
+	// Element center is 750px below the middle (1200), so drag up by 750
+	want := []uiautomator2.PointModel{{X: 540, Y: 1200}, {X: 540, Y: 450}}
+	if got := client.pointerPathCalls[0]; len(got) != 2 || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("expected drag %v, got %v", want, got)
+	}
+}
+
+// ============================================================================
+// ScrollToPosition Tests
+// ============================================================================
+
+// scrollingDevice simulates a list that is `extent` swipes long. Each
+// "input swipe" moves the position one step forward or back, clamped at the ends.
+// With sourceErr set, the page source fails to read once scrolling starts.
+type scrollingDevice struct {
+	pos       int
+	extent    int
+	swipes    int
+	sourceErr error
+}
+
+func (s *scrollingDevice) Shell(cmd string) (string, error) {
+	var x1, y1, x2, y2, dur int
+	if _, err := fmt.Sscanf(cmd, "input swipe %d %d %d %d %d", &x1, &y1, &x2, &y2, &dur); err != nil {
+		return "", nil
+	}
+	s.swipes++
+	if y2 < y1 && s.pos < s.extent {
+		s.pos++
+	} else if y2 > y1 && s.pos > 0 {
+		s.pos--
+	}
+	return "", nil
+}
+
+func newScrollingDriver(dev *scrollingDevice) *Driver {
+	client := &MockUIA2Client{
+		sourceFunc: func() (string, error) {
+			if dev.sourceErr != nil && dev.swipes > 0 {
+				return "", dev.sourceErr
+			}
+			return fmt.Sprintf(`<hierarchy rotation="0">
+				<node class="android.widget.ScrollView" scrollable="true" bounds="[0,200][1080,2200]">
+					<node class="android.widget.TextView" text="row-%d" bounds="[0,200][1080,400]"/>
+				</node>
+			</hierarchy>`, dev.pos), nil
+		},
+	}
+	return New(client, nil, dev)
+}
+
+func TestScrollToPositionLargerFractionScrollsFurther(t *testing.T) {
+	small := &scrollingDevice{pos: 4, extent: 10}
+	if result := newScrollingDriver(small).Execute(&flow.ScrollToPositionStep{Position: "25%"}); !result.Success {
+		t.Fatalf("expected success at 25%%, got error: %v", result.Error)
+	}
+
+	large := &scrollingDevice{pos: 4, extent: 10}
+	if result := newScrollingDriver(large).Execute(&flow.ScrollToPositionStep{Position: "75%"}); !result.Success {
+		t.Fatalf("expected success at 75%%, got error: %v", result.Error)
+	}
+
+	if small.pos != 3 {
+		t.Errorf("expected 25%% to land at position 3, got %d", small.pos)
+	}
+	if large.pos != 8 {
+		t.Errorf("expected 75%% to land at position 8, got %d", large.pos)
+	}
+	if large.swipes <= small.swipes {
+		t.Errorf("expected more swipes for 75%% than 25%%, got %d vs %d", large.swipes, small.swipes)
+	}
+}
+
+func TestScrollToPositionContentFitsOnScreen(t *testing.T) {
+	dev := &scrollingDevice{extent: 0}
+	result := newScrollingDriver(dev).Execute(&flow.ScrollToPositionStep{Position: "50%"})
+
+	if !result.Success {
+		t.Fatalf("expected success, got error: %v", result.Error)
+	}
+	if !strings.Contains(result.Message, "fits on screen") {
+		t.Errorf("expected fits-on-screen message, got: %s", result.Message)
+	}
+}
+
+func TestScrollToPositionInvalidPosition(t *testing.T) {
+	dev := &scrollingDevice{extent: 5}
+	result := newScrollingDriver(dev).Execute(&flow.ScrollToPositionStep{Position: "150%"})
+
+	if result.Success {
+		t.Error("expected failure for out-of-range position")
+	}
+	if dev.swipes != 0 {
+		t.Errorf("expected no swipes for invalid position, got %d", dev.swipes)
+	}
+}
+
+func TestScrollToPositionSourceError(t *testing.T) {
+	dev := &scrollingDevice{pos: 4, extent: 10, sourceErr: errors.New("source timed out")}
+	result := newScrollingDriver(dev).Execute(&flow.ScrollToPositionStep{Position: "50%"})
+
+	if result.Success {
+		t.Fatal("expected failure when the page source can't be read")
+	}
+	if dev.swipes != 1 {
+		t.Errorf("expected to stop after the first swipe, got %d swipes", dev.swipes)
+	}
+}
+
+func TestParsePositionFraction(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    float64
+		wantErr bool
+	}{
+		{"50%", 0.5, false},
+		{" 100 % ", 1, false},
+		{"0.25", 0.25, false},
+		{"0%", 0, false},
+		{"", 0, true},
+		{"abc", 0, true},
+		{"-10%", 0, true},
+		{"1.5", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := parsePositionFraction(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parsePositionFraction(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("parsePositionFraction(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
 // ============================================================================
 // InputText Additional Tests (HTTP Mock)
 // ============================================================================
@@ -1474,96 +2261,330 @@ func TestInputTextWithUnicodeWarning(t *testing.T) {
 	}
 }
 
-func TestInputTextNoSelectorNoActiveElement(t *testing.T) {
-	// No selector, no active element, no focused element -> should fail
-	server := setupMockServer(t, map[string]func(w http.ResponseWriter, r *http.Request){
-		"GET /element/active": func(w http.ResponseWriter, r *http.Request) {
-			// Return empty element ID (no active element)
-			writeJSON(w, map[string]interface{}{
-				"value": map[string]string{"ELEMENT": ""},
-			})
-		},
+// fakeField is the text field served by verifyTextHandlers. It starts out
+// holding prefill, the first drops typings lose their last character, and an
+// empty field reads back as its hint.
+type fakeField struct {
+	prefill, hint string
+	drops         int
+
+	value  string
+	typed  []string
+	clears int
+}
+
+// verifyTextHandlers serves f as the element found by any selector, recording
+// typed text and clear calls.
+func verifyTextHandlers(f *fakeField) map[string]func(w http.ResponseWriter, r *http.Request) {
+	f.value = f.prefill
+	return map[string]func(w http.ResponseWriter, r *http.Request){
 		"POST /element": func(w http.ResponseWriter, r *http.Request) {
-			// Focused element search also fails
 			writeJSON(w, map[string]interface{}{
-				"value": map[string]string{"ELEMENT": ""},
+				"value": map[string]string{"ELEMENT": "elem-input"},
 			})
 		},
-		"GET /source": func(w http.ResponseWriter, r *http.Request) {
+		"POST /element/elem-input/value": func(w http.ResponseWriter, r *http.Request) {
+			var req uiautomator2.InputTextRequest
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			f.typed = append(f.typed, req.Text)
+			text := req.Text
+			if f.drops > 0 {
+				f.drops--
+				text = text[:len(text)-1]
+			}
+			f.value += text
+			writeJSON(w, map[string]interface{}{"value": nil})
+		},
+		"POST /element/elem-input/clear": func(w http.ResponseWriter, r *http.Request) {
+			f.clears++
+			f.value = ""
+			writeJSON(w, map[string]interface{}{"value": nil})
+		},
+		"GET /element/elem-input/text": func(w http.ResponseWriter, r *http.Request) {
+			value := f.value
+			if value == "" {
+				value = f.hint
+			}
+			writeJSON(w, map[string]interface{}{"value": value})
+		},
+		"GET /element/elem-input/rect": func(w http.ResponseWriter, r *http.Request) {
 			writeJSON(w, map[string]interface{}{
-				"value": `<hierarchy><node text="label" bounds="[0,0][100,100]"/></hierarchy>`,
+				"value": map[string]int{"x": 100, "y": 200, "width": 200, "height": 40},
 			})
 		},
-	})
+		"GET /element/elem-input/attribute/displayed": func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, map[string]interface{}{"value": "true"})
+		},
+		"GET /element/elem-input/attribute/enabled": func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, map[string]interface{}{"value": "true"})
+		},
+	}
+}
+
+func TestInputTextVerifyTextRetriesOnShortRead(t *testing.T) {
+	field := &fakeField{drops: 1}
+	server := setupMockServer(t, verifyTextHandlers(field))
 	defer server.Close()
 
 	client := newMockHTTPClient(server.URL)
 	driver := New(client.Client, nil, nil)
-	driver.SetFindTimeout(200)
 
-	step := &flow.InputTextStep{Text: "Hello"}
+	step := &flow.InputTextStep{
+		Text:       "hello world",
+		VerifyText: true,
+		Selector:   flow.Selector{ID: "input_field"},
+	}
 	result := driver.Execute(step)
 
-	if result.Success {
-		t.Error("expected failure when no focused element found")
+	if !result.Success {
+		t.Fatalf("expected success after retry, got error: %v", result.Error)
+	}
+	if len(field.typed) != 2 {
+		t.Errorf("expected text typed twice, got %q", field.typed)
+	}
+	if field.clears != 1 {
+		t.Errorf("expected 1 clear before retry, got %d", field.clears)
 	}
 }
 
-func TestInputTextNoSelectorActiveElementSuccess(t *testing.T) {
-	// Active element found - type into it
-	server := setupMockServer(t, map[string]func(w http.ResponseWriter, r *http.Request){
-		"GET /element/active": func(w http.ResponseWriter, r *http.Request) {
-			writeJSON(w, map[string]interface{}{
-				"value": map[string]string{"ELEMENT": "active-elem"},
-			})
-		},
-		"POST /element/active-elem/value": func(w http.ResponseWriter, r *http.Request) {
-			writeJSON(w, map[string]interface{}{"value": nil})
-		},
-	})
+func TestInputTextVerifyTextFailsWhenStillWrong(t *testing.T) {
+	field := &fakeField{drops: 2}
+	server := setupMockServer(t, verifyTextHandlers(field))
 	defer server.Close()
 
 	client := newMockHTTPClient(server.URL)
 	driver := New(client.Client, nil, nil)
 
-	step := &flow.InputTextStep{Text: "Hello via active element"}
+	step := &flow.InputTextStep{
+		Text:       "hello world",
+		VerifyText: true,
+		Selector:   flow.Selector{ID: "input_field"},
+	}
 	result := driver.Execute(step)
 
-	if !result.Success {
-		t.Errorf("expected success typing into active element, got error: %v", result.Error)
+	if result.Success {
+		t.Fatal("expected failure when read-back still mismatches")
 	}
-	if !strings.Contains(result.Message, "Hello via active element") {
-		t.Errorf("expected text in message, got: %s", result.Message)
+	if !strings.Contains(result.Message, "verification failed") {
+		t.Errorf("expected verification failure message, got: %s", result.Message)
+	}
+	if len(field.typed) != 2 {
+		t.Errorf("expected exactly one retry, got %q", field.typed)
 	}
 }
 
-// ============================================================================
-// SetClipboard Tests
-// ============================================================================
+func TestInputTextVerifyTextExactValue(t *testing.T) {
+	// The first typing drops a character but the field still contains the text
+	field := &fakeField{prefill: "hello world", drops: 1}
+	server := setupMockServer(t, verifyTextHandlers(field))
+	defer server.Close()
 
-func TestSetClipboardSuccess(t *testing.T) {
-	client := &MockUIA2Client{}
-	driver := New(client, nil, nil)
+	client := newMockHTTPClient(server.URL)
+	driver := New(client.Client, nil, nil)
 
-	step := &flow.SetClipboardStep{Text: "test clipboard text"}
-	result := driver.Execute(step)
+	result := driver.Execute(&flow.InputTextStep{
+		Text:       "hello world",
+		VerifyText: true,
+		Selector:   flow.Selector{ID: "input_field"},
+	})
 
 	if !result.Success {
-		t.Errorf("expected success, got error: %v", result.Error)
+		t.Fatalf("expected success after retry, got error: %v", result.Error)
 	}
-	if len(client.setClipboardCalls) != 1 || client.setClipboardCalls[0] != "test clipboard text" {
-		t.Errorf("expected SetClipboard('test clipboard text'), got %v", client.setClipboardCalls)
+	if field.clears != 1 {
+		t.Errorf("expected a retry, got %d clears", field.clears)
+	}
+	if want := "hello worldhello world"; field.value != want {
+		t.Errorf("expected field value %q, got %q", want, field.value)
 	}
 }
 
-func TestSetClipboardEmptyText(t *testing.T) {
-	client := &MockUIA2Client{}
-	driver := New(client, nil, nil)
+func TestInputTextVerifyTextKeepsPrefilledText(t *testing.T) {
+	tests := []struct {
+		name  string
+		field *fakeField
+		want  string
+	}{
+		{"prefilled", &fakeField{prefill: "hello ", drops: 1}, "hello world"},
+		{"hint", &fakeField{hint: "Message", drops: 1}, "world"},
+	}
 
-	step := &flow.SetClipboardStep{Text: ""}
-	result := driver.Execute(step)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := setupMockServer(t, verifyTextHandlers(tt.field))
+			defer server.Close()
 
-	if result.Success {
+			client := newMockHTTPClient(server.URL)
+			driver := New(client.Client, nil, nil)
+
+			result := driver.Execute(&flow.InputTextStep{
+				Text:       "world",
+				VerifyText: true,
+				Selector:   flow.Selector{ID: "input_field"},
+			})
+
+			if !result.Success {
+				t.Fatalf("expected success after retry, got error: %v", result.Error)
+			}
+			if tt.field.value != tt.want {
+				t.Errorf("expected field value %q, got %q (typed %q)", tt.want, tt.field.value, tt.field.typed)
+			}
+		})
+	}
+}
+
+func TestInputTextWithoutVerifyTextSkipsReadBack(t *testing.T) {
+	field := &fakeField{drops: 1}
+	server := setupMockServer(t, verifyTextHandlers(field))
+	defer server.Close()
+
+	client := newMockHTTPClient(server.URL)
+	driver := New(client.Client, nil, nil)
+
+	step := &flow.InputTextStep{
+		Text:     "hello world",
+		Selector: flow.Selector{ID: "input_field"},
+	}
+	result := driver.Execute(step)
+
+	if !result.Success {
+		t.Fatalf("expected success, got error: %v", result.Error)
+	}
+	if len(field.typed) != 1 || field.clears != 0 {
+		t.Errorf("expected no retry without verifyText, got %q typed and %d clears", field.typed, field.clears)
+	}
+}
+
+func TestInputTextNoSelectorNoActiveElement(t *testing.T) {
+	// No selector, no active element, no focused element -> should fail
+	server := setupMockServer(t, map[string]func(w http.ResponseWriter, r *http.Request){
+		"GET /element/active": func(w http.ResponseWriter, r *http.Request) {
+			// Return empty element ID (no active element)
+			writeJSON(w, map[string]interface{}{
+				"value": map[string]string{"ELEMENT": ""},
+			})
+		},
+		"POST /element": func(w http.ResponseWriter, r *http.Request) {
+			// Focused element search also fails
+			writeJSON(w, map[string]interface{}{
+				"value": map[string]string{"ELEMENT": ""},
+			})
+		},
+		"GET /source": func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, map[string]interface{}{
+				"value": `<hierarchy><node text="label" bounds="[0,0][100,100]"/></hierarchy>`,
+			})
+		},
+	})
+	defer server.Close()
+
+	client := newMockHTTPClient(server.URL)
+	driver := New(client.Client, nil, nil)
+	driver.SetFindTimeout(200)
+
+	step := &flow.InputTextStep{Text: "Hello"}
+	result := driver.Execute(step)
+
+	if result.Success {
+		t.Error("expected failure when no focused element found")
+	}
+}
+
+func TestInputTextNoSelectorActiveElementSuccess(t *testing.T) {
+	// Active element found - type into it
+	server := setupMockServer(t, map[string]func(w http.ResponseWriter, r *http.Request){
+		"GET /element/active": func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, map[string]interface{}{
+				"value": map[string]string{"ELEMENT": "active-elem"},
+			})
+		},
+		"POST /element/active-elem/value": func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, map[string]interface{}{"value": nil})
+		},
+	})
+	defer server.Close()
+
+	client := newMockHTTPClient(server.URL)
+	driver := New(client.Client, nil, nil)
+
+	step := &flow.InputTextStep{Text: "Hello via active element"}
+	result := driver.Execute(step)
+
+	if !result.Success {
+		t.Errorf("expected success typing into active element, got error: %v", result.Error)
+	}
+	if !strings.Contains(result.Message, "Hello via active element") {
+		t.Errorf("expected text in message, got: %s", result.Message)
+	}
+}
+
+func TestInputTextTypeDelayActiveElement(t *testing.T) {
+	var chunks []string
+	server := setupMockServer(t, map[string]func(w http.ResponseWriter, r *http.Request){
+		"GET /element/active": func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, map[string]interface{}{
+				"value": map[string]string{"ELEMENT": "active-elem"},
+			})
+		},
+		"POST /element/active-elem/value": func(w http.ResponseWriter, r *http.Request) {
+			var req uiautomator2.InputTextRequest
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			chunks = append(chunks, req.Text)
+			writeJSON(w, map[string]interface{}{"value": nil})
+		},
+	})
+	defer server.Close()
+
+	client := newMockHTTPClient(server.URL)
+	driver := New(client.Client, nil, nil)
+
+	start := time.Now()
+	result := driver.Execute(&flow.InputTextStep{Text: "cafe\u0301!", TypeDelayMs: 20})
+
+	if !result.Success {
+		t.Fatalf("expected success, got: %s", result.Message)
+	}
+	if want := []string{"c", "a", "f", "e\u0301", "!"}; strings.Join(chunks, "|") != strings.Join(want, "|") {
+		t.Errorf("expected one request per grapheme %q, got %q", want, chunks)
+	}
+	if elapsed := time.Since(start); elapsed < 80*time.Millisecond {
+		t.Errorf("expected 4 pauses of 20ms, took %v", elapsed)
+	}
+	if !strings.Contains(result.Message, "typed 5 chars with 20ms delay") {
+		t.Errorf("expected typing note in message, got: %s", result.Message)
+	}
+	if !strings.Contains(result.Message, "non-ASCII characters may not input correctly") {
+		t.Errorf("expected non-ASCII warning preserved, got: %s", result.Message)
+	}
+}
+
+// ============================================================================
+// SetClipboard Tests
+// ============================================================================
+
+func TestSetClipboardSuccess(t *testing.T) {
+	client := &MockUIA2Client{}
+	driver := New(client, nil, nil)
+
+	step := &flow.SetClipboardStep{Text: "test clipboard text"}
+	result := driver.Execute(step)
+
+	if !result.Success {
+		t.Errorf("expected success, got error: %v", result.Error)
+	}
+	if len(client.setClipboardCalls) != 1 || client.setClipboardCalls[0] != "test clipboard text" {
+		t.Errorf("expected SetClipboard('test clipboard text'), got %v", client.setClipboardCalls)
+	}
+}
+
+func TestSetClipboardEmptyText(t *testing.T) {
+	client := &MockUIA2Client{}
+	driver := New(client, nil, nil)
+
+	step := &flow.SetClipboardStep{Text: ""}
+	result := driver.Execute(step)
+
+	if result.Success {
 		t.Error("expected failure when text is empty")
 	}
 }
@@ -1780,42 +2801,6 @@ func TestInputRandomPersonName(t *testing.T) {
 	}
 }
 
-// ============================================================================
-// RandomHelpers Tests
-// ============================================================================
-
-func TestRandomEmail(t *testing.T) {
-	email := randomEmail()
-	if !strings.Contains(email, "@") {
-		t.Errorf("expected @ in email, got: %s", email)
-	}
-	parts := strings.Split(email, "@")
-	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
-		t.Errorf("invalid email format: %s", email)
-	}
-}
-
-func TestRandomNumber(t *testing.T) {
-	num := randomNumber(5)
-	if len(num) != 5 {
-		t.Errorf("expected 5 digits, got %d: %s", len(num), num)
-	}
-	for _, c := range num {
-		if c < '0' || c > '9' {
-			t.Errorf("expected digits only, got: %s", num)
-			break
-		}
-	}
-}
-
-func TestRandomPersonName(t *testing.T) {
-	name := randomPersonName()
-	parts := strings.Split(name, " ")
-	if len(parts) != 2 {
-		t.Errorf("expected 'first last' format, got: %s", name)
-	}
-}
-
 // ============================================================================
 // SetOrientation Shell Error Test
 // ============================================================================
@@ -2950,6 +3935,49 @@ func TestWaitUntilVisibleAppearsAfterDelay(t *testing.T) {
 	}
 }
 
+func TestWaitUntilAllConditionsMet(t *testing.T) {
+	client := &MockUIA2Client{
+		sourceData: `<hierarchy><node text="Welcome" bounds="[0,0][100,100]"/><node text="Profile" bounds="[0,100][100,200]"/></hierarchy>`,
+	}
+	driver := New(client, nil, nil)
+
+	result := driver.Execute(&flow.ExtendedWaitUntilStep{
+		BaseStep:   flow.BaseStep{TimeoutMs: 2000},
+		Visible:    []flow.Selector{{Text: "Welcome"}, {Text: "Profile"}},
+		NotVisible: []flow.Selector{{Text: "Loading"}},
+	})
+
+	if !result.Success {
+		t.Fatalf("expected success, got: %s", result.Message)
+	}
+	if !strings.Contains(result.Message, "All 3") {
+		t.Errorf("expected condition count in message, got: %s", result.Message)
+	}
+}
+
+func TestWaitUntilAllReportsUnsatisfied(t *testing.T) {
+	client := &MockUIA2Client{
+		sourceData: `<hierarchy><node text="Welcome" bounds="[0,0][100,100]"/><node text="Loading" bounds="[0,100][100,200]"/></hierarchy>`,
+	}
+	driver := New(client, nil, nil)
+
+	result := driver.Execute(&flow.ExtendedWaitUntilStep{
+		BaseStep:   flow.BaseStep{TimeoutMs: 300},
+		Visible:    []flow.Selector{{Text: "Welcome"}, {Text: "Profile"}},
+		NotVisible: []flow.Selector{{Text: "Loading"}},
+	})
+
+	if result.Success {
+		t.Fatal("expected failure when conditions are never met")
+	}
+	if !strings.Contains(result.Message, `visible text="Profile"`) || !strings.Contains(result.Message, `notVisible text="Loading"`) {
+		t.Errorf("expected unsatisfied selectors in message, got: %s", result.Message)
+	}
+	if strings.Contains(result.Message, "Welcome") {
+		t.Errorf("satisfied selector should not be reported, got: %s", result.Message)
+	}
+}
+
 // ============================================================================
 // SetWaitForIdleTimeout Tests
 // ============================================================================
@@ -3267,6 +4295,70 @@ func TestSwipeWithSelectorDirection(t *testing.T) {
 	}
 }
 
+func TestSwipeWithSelectorUsesElementOrigin(t *testing.T) {
+	var body map[string]interface{}
+	server := setupMockServer(t, map[string]func(w http.ResponseWriter, r *http.Request){
+		"POST /element": func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, map[string]interface{}{
+				"value": map[string]string{"ELEMENT": "elem-swipe"},
+			})
+		},
+		"GET /element/elem-swipe/text": func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, map[string]interface{}{"value": "Carousel"})
+		},
+		"GET /element/elem-swipe/rect": func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, map[string]interface{}{
+				"value": map[string]int{"x": 0, "y": 100, "width": 500, "height": 800},
+			})
+		},
+		"POST /appium/gestures/swipe": func(w http.ResponseWriter, r *http.Request) {
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			writeJSON(w, map[string]interface{}{"value": nil})
+		},
+	})
+	defer server.Close()
+
+	client := newMockHTTPClient(server.URL)
+	driver := New(client.Client, nil, nil)
+
+	sel := flow.Selector{ID: "carousel"}
+	result := driver.swipe(&flow.SwipeStep{Direction: "left", Selector: &sel})
+
+	if !result.Success {
+		t.Fatalf("expected success, got error: %v", result.Error)
+	}
+	if result.Message != "Swiped left in element" {
+		t.Errorf("unexpected message: %s", result.Message)
+	}
+	origin, ok := body["origin"].(map[string]interface{})
+	if !ok || origin["ELEMENT"] != "elem-swipe" {
+		t.Errorf("expected swipe with element origin, got body: %v", body)
+	}
+	if _, hasArea := body["area"]; hasArea {
+		t.Errorf("expected no area when swiping on the element, got body: %v", body)
+	}
+}
+
+func TestSwipeWithSelectorBoundsFallback(t *testing.T) {
+	client := &MockUIA2Client{
+		sourceData: `<hierarchy><node text="Carousel" bounds="[0,100][500,900]"/></hierarchy>`,
+	}
+	driver := New(client, nil, nil)
+
+	sel := flow.Selector{Text: "Carousel"}
+	result := driver.swipe(&flow.SwipeStep{Direction: "left", Selector: &sel, BaseStep: flow.BaseStep{TimeoutMs: 100}})
+
+	if !result.Success {
+		t.Fatalf("expected success, got error: %v", result.Error)
+	}
+	if len(client.elementSwipeCalls) != 0 {
+		t.Errorf("expected no element swipe for a page-source match, got %v", client.elementSwipeCalls)
+	}
+	if len(client.swipeCalls) != 1 || client.swipeCalls[0].Width != 500 {
+		t.Errorf("expected one swipe in the element bounds, got %v", client.swipeCalls)
+	}
+}
+
 // ============================================================================
 // SetWaitForIdleTimeout via MockUIA2Client Tests
 // ============================================================================
@@ -3367,6 +4459,27 @@ func TestInputTextKeyPressSuccess(t *testing.T) {
 	}
 }
 
+func TestInputTextKeyPressTypeDelay(t *testing.T) {
+	var chunks []string
+	client := &MockUIA2Client{sendKeyActionsFunc: func(text string) error {
+		chunks = append(chunks, text)
+		return nil
+	}}
+	driver := New(client, nil, nil)
+
+	result := driver.inputText(&flow.InputTextStep{Text: "Hey", KeyPress: true, TypeDelayMs: 5})
+
+	if !result.Success {
+		t.Fatalf("expected success, got: %s", result.Message)
+	}
+	if strings.Join(chunks, ",") != "H,e,y" {
+		t.Errorf("expected one key action per character, got %q", chunks)
+	}
+	if !strings.Contains(result.Message, "typed 3 chars with 5ms delay") {
+		t.Errorf("expected typing note in message, got: %s", result.Message)
+	}
+}
+
 func TestInputTextKeyPressError(t *testing.T) {
 	client := &MockUIA2Client{}
 	client.sendKeyActionsFunc = func(text string) error {
@@ -3388,26 +4501,134 @@ func TestInputTextKeyPressError(t *testing.T) {
 	}
 }
 
-func TestInputTextKeyPressWithUnicode(t *testing.T) {
-	client := &MockUIA2Client{}
-	client.sendKeyActionsFunc = func(text string) error {
-		return nil
+// ============================================================================
+// InputText Emoji Tests
+// ============================================================================
+
+func TestEncodeModifiedUTF7(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"Hello", "Hello"},
+		{"a&b", "a&-b"},
+		{"Hi 🎉", "Hi &2DzfiQ-"},
+		{"日本語", "&ZeVnLIqe-"},
+		{"Love ❤️!", "Love &J2T+Dw-!"},
 	}
-	driver := New(client, nil, nil)
 
-	step := &flow.InputTextStep{
-		Text:     "caf\u00e9",
-		KeyPress: true,
+	for _, tt := range tests {
+		if got := encodeModifiedUTF7(tt.text); got != tt.want {
+			t.Errorf("encodeModifiedUTF7(%q) = %q, want %q", tt.text, got, tt.want)
+		}
 	}
-	result := driver.inputText(step)
+}
+
+func TestInputTextEmojiUsesUnicodeIME(t *testing.T) {
+	var typed string
+	client := &MockUIA2Client{sendKeyActionsFunc: func(text string) error {
+		typed = text
+		return nil
+	}}
+	shell := &prefixShell{responses: map[string]string{
+		"ime list -s": "com.google.android.inputmethod.latin/com.android.inputmethod.latin.LatinIME\nio.appium.settings/.UnicodeIME\n",
+		"settings get secure default_input_method": "com.google.android.inputmethod.latin/com.android.inputmethod.latin.LatinIME\n",
+	}}
+	driver := New(client, nil, shell)
+
+	result := driver.inputText(&flow.InputTextStep{Text: "Hi 🎉"})
 
 	if !result.Success {
-		t.Errorf("expected success, got error: %v", result.Error)
+		t.Fatalf("expected success, got: %s", result.Message)
 	}
-	if !strings.Contains(result.Message, "non-ASCII") {
-		t.Errorf("expected non-ASCII warning in message, got: %s", result.Message)
+	if typed != "Hi &2DzfiQ-" {
+		t.Errorf("expected modified UTF-7 key input, got %q", typed)
 	}
-}
+	if !strings.Contains(result.Message, "unicode IME") {
+		t.Errorf("expected Unicode IME path in message, got: %s", result.Message)
+	}
+	if !strings.Contains(result.Message, "non-ASCII characters may not input correctly") {
+		t.Errorf("expected non-ASCII warning preserved, got: %s", result.Message)
+	}
+
+	switchTo := indexOfCommand(shell.commands, "ime set io.appium.settings/.UnicodeIME")
+	restore := indexOfCommand(shell.commands, "ime set com.google.android.inputmethod.latin/com.android.inputmethod.latin.LatinIME")
+	if switchTo < 0 || restore < switchTo {
+		t.Errorf("expected IME switch then restore, got commands: %v", shell.commands)
+	}
+}
+
+func TestInputTextEmojiUnicodeIMETypeDelay(t *testing.T) {
+	var chunks []string
+	client := &MockUIA2Client{sendKeyActionsFunc: func(text string) error {
+		chunks = append(chunks, text)
+		return nil
+	}}
+	shell := &prefixShell{responses: map[string]string{
+		"ime list -s": "io.appium.settings/.UnicodeIME\n",
+	}}
+	driver := New(client, nil, shell)
+
+	result := driver.inputText(&flow.InputTextStep{Text: "A🎉", TypeDelayMs: 5})
+
+	if !result.Success {
+		t.Fatalf("expected success, got: %s", result.Message)
+	}
+	// Each grapheme is encoded on its own, so the emoji stays one UTF-7 run
+	if strings.Join(chunks, ",") != "A,&2DzfiQ-" {
+		t.Errorf("expected per-grapheme encoded input, got %q", chunks)
+	}
+}
+
+func TestInputTextEmojiWithoutUnicodeIME(t *testing.T) {
+	var typed string
+	client := &MockUIA2Client{sendKeyActionsFunc: func(text string) error {
+		typed = text
+		return nil
+	}}
+	shell := &prefixShell{responses: map[string]string{
+		"ime list -s": "com.google.android.inputmethod.latin/com.android.inputmethod.latin.LatinIME\n",
+	}}
+	driver := New(client, nil, shell)
+
+	result := driver.inputText(&flow.InputTextStep{Text: "Hi 🎉", KeyPress: true})
+
+	if !result.Success {
+		t.Fatalf("expected fallback to succeed, got: %s", result.Message)
+	}
+	if typed != "Hi 🎉" {
+		t.Errorf("expected raw text on fallback, got %q", typed)
+	}
+	if !strings.Contains(result.Message, "Unicode IME") || !strings.Contains(result.Message, "not installed") {
+		t.Errorf("expected missing IME warning, got: %s", result.Message)
+	}
+	for _, cmd := range shell.commands {
+		if strings.HasPrefix(cmd, "ime set") {
+			t.Errorf("did not expect IME switch without the Unicode IME, got %q", cmd)
+		}
+	}
+}
+
+func TestInputTextKeyPressWithUnicode(t *testing.T) {
+	client := &MockUIA2Client{}
+	client.sendKeyActionsFunc = func(text string) error {
+		return nil
+	}
+	driver := New(client, nil, nil)
+
+	step := &flow.InputTextStep{
+		Text:     "caf\u00e9",
+		KeyPress: true,
+	}
+	result := driver.inputText(step)
+
+	if !result.Success {
+		t.Errorf("expected success, got error: %v", result.Error)
+	}
+	if !strings.Contains(result.Message, "non-ASCII") {
+		t.Errorf("expected non-ASCII warning in message, got: %s", result.Message)
+	}
+}
 
 func TestInputTextKeyPressEmptyText(t *testing.T) {
 	client := &MockUIA2Client{}
@@ -3436,3 +4657,1664 @@ var _ = &uiautomator2.DeviceInfo{}
 
 // Use fmt to avoid unused import error
 var _ = fmt.Sprintf
+
+// ============================================================================
+// clearNotifications Tests
+// ============================================================================
+
+const emptyShadeSource = `<?xml version="1.0" encoding="UTF-8"?>
+<hierarchy>
+  <node class="android.widget.FrameLayout" resource-id="com.android.systemui:id/notification_panel" bounds="[0,0][1080,2400]" displayed="true">
+    <node class="android.widget.TextView" text="No notifications" resource-id="com.android.systemui:id/no_notifications" bounds="[0,200][1080,300]" displayed="true"/>
+  </node>
+</hierarchy>`
+
+const nonEmptyShadeSource = `<?xml version="1.0" encoding="UTF-8"?>
+<hierarchy>
+  <node class="android.widget.FrameLayout" resource-id="com.android.systemui:id/notification_panel" bounds="[0,0][1080,2400]" displayed="true">
+    <node class="android.widget.FrameLayout" resource-id="com.android.systemui:id/expandableNotificationRow" bounds="[0,200][1080,400]" displayed="true"/>
+  </node>
+</hierarchy>`
+
+func TestClearNotificationsNoDevice(t *testing.T) {
+	driver := &Driver{device: nil}
+
+	result := driver.clearNotifications(&flow.ClearNotificationsStep{})
+
+	if result.Success {
+		t.Error("expected failure when device is nil")
+	}
+}
+
+// clearableShadeSource has a dismissible notification and the footer's
+// "Clear all" button; ongoingShadeSource only an ongoing one, so the footer
+// shows "Manage" alone.
+const clearableShadeSource = `<?xml version="1.0" encoding="UTF-8"?>
+<hierarchy>
+  <node class="android.widget.FrameLayout" resource-id="com.android.systemui:id/notification_panel" bounds="[0,0][1080,2400]" displayed="true">
+    <node class="android.widget.FrameLayout" resource-id="com.android.systemui:id/expandableNotificationRow" bounds="[0,200][1080,400]" displayed="true"/>
+    <node class="android.widget.FrameLayout" resource-id="com.android.systemui:id/notification_footer" bounds="[0,900][1080,1000]" displayed="true">
+      <node class="android.widget.Button" text="Manage" resource-id="com.android.systemui:id/manage_text" bounds="[40,900][300,1000]" displayed="true"/>
+      <node class="android.widget.Button" text="Clear all" resource-id="com.android.systemui:id/dismiss_text" bounds="[780,900][1040,1000]" displayed="true"/>
+    </node>
+  </node>
+</hierarchy>`
+
+const ongoingShadeSource = `<?xml version="1.0" encoding="UTF-8"?>
+<hierarchy>
+  <node class="android.widget.FrameLayout" resource-id="com.android.systemui:id/notification_panel" bounds="[0,0][1080,2400]" displayed="true">
+    <node class="android.widget.FrameLayout" resource-id="com.android.systemui:id/expandableNotificationRow" bounds="[0,200][1080,400]" displayed="true"/>
+    <node class="android.widget.FrameLayout" resource-id="com.android.systemui:id/notification_footer" bounds="[0,900][1080,1000]" displayed="true">
+      <node class="android.widget.Button" text="Manage" resource-id="com.android.systemui:id/manage_text" bounds="[40,900][300,1000]" displayed="true"/>
+    </node>
+  </node>
+</hierarchy>`
+
+func TestClearNotificationsEmptyShade(t *testing.T) {
+	shell := &MockShellExecutor{}
+	client := &MockUIA2Client{sourceData: emptyShadeSource}
+	driver := &Driver{client: client, device: shell}
+
+	result := driver.clearNotifications(&flow.ClearNotificationsStep{})
+
+	if !result.Success {
+		t.Fatalf("expected success, got error: %v", result.Error)
+	}
+	want := []string{"cmd statusbar expand-notifications", "cmd statusbar collapse"}
+	if strings.Join(shell.commands, ",") != strings.Join(want, ",") {
+		t.Errorf("expected commands %v, got %v", want, shell.commands)
+	}
+	if len(client.clickCalls) != 0 {
+		t.Errorf("expected no tap on an empty shade, got %v", client.clickCalls)
+	}
+}
+
+func TestClearNotificationsTapsClearAll(t *testing.T) {
+	shell := &MockShellExecutor{}
+	client := &MockUIA2Client{}
+	// The notification is gone once Clear all is tapped
+	client.sourceFunc = func() (string, error) {
+		if len(client.clickCalls) > 0 {
+			return emptyShadeSource, nil
+		}
+		return clearableShadeSource, nil
+	}
+	driver := &Driver{client: client, device: shell}
+
+	result := driver.clearNotifications(&flow.ClearNotificationsStep{})
+
+	if !result.Success {
+		t.Fatalf("expected success, got: %s", result.Message)
+	}
+	if len(client.clickCalls) != 1 || client.clickCalls[0].X != 910 || client.clickCalls[0].Y != 950 {
+		t.Errorf("expected one tap on Clear all at (910,950), got %v", client.clickCalls)
+	}
+	if indexOfCommand(shell.commands, "service call") >= 0 {
+		t.Errorf("expected no notification service call, got %v", shell.commands)
+	}
+}
+
+func TestClearNotificationsClearAllStillShown(t *testing.T) {
+	shell := &MockShellExecutor{}
+	client := &MockUIA2Client{sourceData: clearableShadeSource}
+	driver := &Driver{client: client, device: shell}
+
+	result := driver.clearNotifications(&flow.ClearNotificationsStep{})
+
+	if result.Success {
+		t.Fatal("expected failure when a notification remains in the shade")
+	}
+	if !strings.Contains(result.Message, "1 notification(s) remain") {
+		t.Errorf("unexpected message: %s", result.Message)
+	}
+	if last := shell.commands[len(shell.commands)-1]; last != "cmd statusbar collapse" {
+		t.Errorf("expected shade to be collapsed, last command was %q", last)
+	}
+}
+
+func TestClearNotificationsOngoingOnly(t *testing.T) {
+	shell := &MockShellExecutor{}
+	client := &MockUIA2Client{sourceData: ongoingShadeSource}
+	driver := &Driver{client: client, device: shell}
+
+	result := driver.clearNotifications(&flow.ClearNotificationsStep{})
+
+	if !result.Success {
+		t.Fatalf("expected success with only ongoing notifications, got: %s", result.Message)
+	}
+	if len(client.clickCalls) != 0 || indexOfCommand(shell.commands, "service call") >= 0 {
+		t.Errorf("expected nothing cleared, got taps %v and commands %v", client.clickCalls, shell.commands)
+	}
+}
+
+func TestClearNotificationsOEMShadeFallback(t *testing.T) {
+	shell := &prefixShell{responses: map[string]string{"getprop ro.build.version.sdk": "33\n"}}
+	client := &MockUIA2Client{}
+	// Without a footer to read, the rows go once the service cancels them
+	client.sourceFunc = func() (string, error) {
+		if indexOfCommand(shell.commands, "service call notification 1") >= 0 {
+			return emptyShadeSource, nil
+		}
+		return nonEmptyShadeSource, nil
+	}
+	driver := &Driver{client: client, device: shell}
+
+	result := driver.clearNotifications(&flow.ClearNotificationsStep{})
+
+	if !result.Success {
+		t.Fatalf("expected success, got: %s", result.Message)
+	}
+	if indexOfCommand(shell.commands, "service call notification 1") < 0 {
+		t.Errorf("expected the notification service fallback, got %v", shell.commands)
+	}
+}
+
+func TestClearNotificationsOEMShadeUnverifiedAPI(t *testing.T) {
+	shell := &prefixShell{responses: map[string]string{"getprop ro.build.version.sdk": "35\n"}}
+	client := &MockUIA2Client{sourceData: nonEmptyShadeSource}
+	driver := &Driver{client: client, device: shell}
+
+	result := driver.clearNotifications(&flow.ClearNotificationsStep{})
+
+	if result.Success {
+		t.Fatal("expected failure on an API level the fallback isn't verified for")
+	}
+	if !strings.Contains(result.Message, "unverified on API 35") {
+		t.Errorf("unexpected message: %s", result.Message)
+	}
+	if indexOfCommand(shell.commands, "service call") >= 0 {
+		t.Errorf("expected no notification service call, got %v", shell.commands)
+	}
+}
+
+func TestClearNotificationsShellError(t *testing.T) {
+	shell := &MockShellExecutor{err: fmt.Errorf("adb offline")}
+	driver := &Driver{client: &MockUIA2Client{}, device: shell}
+
+	result := driver.clearNotifications(&flow.ClearNotificationsStep{})
+
+	if result.Success {
+		t.Error("expected failure when shell command fails")
+	}
+}
+
+// ============================================================================
+// assertResource Tests
+// ============================================================================
+
+const sampleMeminfo = `Applications Memory Usage (in Kilobytes):
+Uptime: 123456 Realtime: 123456
+
+** MEMINFO in pid 4321 [com.example.app] **
+                   Pss  Private  Private  SwapPss      Rss     Heap     Heap     Heap
+                 Total    Dirty    Clean    Dirty    Total     Size    Alloc     Free
+                ------   ------   ------   ------   ------   ------   ------   ------
+  Native Heap    20480    20400        0        0    22000    32768    20000    12768
+        TOTAL   153600   120000    20000        0   190000    45000    30000    15000
+
+ App Summary
+                       Pss(KB)                        Rss(KB)
+                        ------                         ------
+           Java Heap:    10000                          12000
+               TOTAL PSS:   153600            TOTAL RSS:   190000       TOTAL SWAP PSS:        0
+`
+
+// prefixShell returns the response whose key prefixes the command.
+type prefixShell struct {
+	commands  []string
+	responses map[string]string
+}
+
+func (p *prefixShell) Shell(cmd string) (string, error) {
+	p.commands = append(p.commands, cmd)
+	for prefix, resp := range p.responses {
+		if strings.HasPrefix(cmd, prefix) {
+			return resp, nil
+		}
+	}
+	return "", nil
+}
+
+func TestParseMeminfoPSS(t *testing.T) {
+	kb, err := parseMeminfoPSS(sampleMeminfo)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if kb != 153600 {
+		t.Errorf("expected 153600 KB, got %d", kb)
+	}
+
+	// Older releases have no App Summary - use the table TOTAL row
+	kb, err = parseMeminfoPSS("        TOTAL    51200    40000    8000\n")
+	if err != nil || kb != 51200 {
+		t.Errorf("expected 51200 KB from TOTAL row, got %d (err=%v)", kb, err)
+	}
+
+	if _, err := parseMeminfoPSS("No process found for: com.example.app\n"); err == nil {
+		t.Error("expected error when process is not running")
+	}
+}
+
+func TestParseTopCPU(t *testing.T) {
+	tests := []struct {
+		output string
+		want   float64
+	}{
+		{"12.5\n", 12.5},
+		{"%CPU\n 3.0\n", 3.0},
+	}
+	for _, tt := range tests {
+		got, err := parseTopCPU(tt.output)
+		if err != nil || got != tt.want {
+			t.Errorf("parseTopCPU(%q) = %v, %v; want %v", tt.output, got, err, tt.want)
+		}
+	}
+	if _, err := parseTopCPU(""); err == nil {
+		t.Error("expected error for empty output")
+	}
+}
+
+func TestAssertResourceMemoryUnderLimit(t *testing.T) {
+	shell := &MockShellExecutor{response: sampleMeminfo}
+	driver := &Driver{device: shell}
+
+	result := driver.assertResource(&flow.AssertResourceStep{AppID: "com.example.app", MaxMemoryMB: 200})
+
+	if !result.Success {
+		t.Fatalf("expected success, got: %s", result.Message)
+	}
+	if len(shell.commands) != 1 || shell.commands[0] != "dumpsys meminfo com.example.app" {
+		t.Errorf("unexpected commands: %v", shell.commands)
+	}
+	if !strings.Contains(result.Message, "150.0MB") {
+		t.Errorf("expected observed memory in message, got: %s", result.Message)
+	}
+}
+
+func TestAssertResourceMemoryOverLimit(t *testing.T) {
+	driver := &Driver{device: &MockShellExecutor{response: sampleMeminfo}}
+
+	result := driver.assertResource(&flow.AssertResourceStep{AppID: "com.example.app", MaxMemoryMB: 100})
+
+	if result.Success {
+		t.Fatal("expected failure when PSS exceeds the limit")
+	}
+	if !strings.Contains(result.Message, "150.0MB") || !strings.Contains(result.Message, "100MB") {
+		t.Errorf("expected observed value and limit in message, got: %s", result.Message)
+	}
+}
+
+func TestAssertResourceCPU(t *testing.T) {
+	shell := &prefixShell{responses: map[string]string{
+		"pidof": "4321\n",
+		"top":   "42.0\n",
+	}}
+	driver := &Driver{device: shell}
+
+	result := driver.assertResource(&flow.AssertResourceStep{AppID: "com.example.app", MaxCPUPercent: 50})
+	if !result.Success {
+		t.Fatalf("expected success, got: %s", result.Message)
+	}
+	if last := shell.commands[len(shell.commands)-1]; last != "top -b -q -n 1 -p 4321 -o %CPU" {
+		t.Errorf("unexpected top command: %q", last)
+	}
+
+	result = driver.assertResource(&flow.AssertResourceStep{AppID: "com.example.app", MaxCPUPercent: 25})
+	if result.Success {
+		t.Error("expected failure when CPU exceeds the limit")
+	}
+}
+
+func TestAssertResourceValidation(t *testing.T) {
+	driver := &Driver{device: &MockShellExecutor{}}
+
+	if result := driver.assertResource(&flow.AssertResourceStep{MaxMemoryMB: 100}); result.Success {
+		t.Error("expected failure without appId")
+	}
+	if result := driver.assertResource(&flow.AssertResourceStep{AppID: "com.example.app"}); result.Success {
+		t.Error("expected failure without a threshold")
+	}
+	if result := (&Driver{}).assertResource(&flow.AssertResourceStep{AppID: "com.example.app", MaxMemoryMB: 100}); result.Success {
+		t.Error("expected failure without device")
+	}
+}
+
+// ============================================================================
+// ensureUnlocked Tests
+// ============================================================================
+
+// keyguardShell serves successive `dumpsys window` lock states and records commands.
+type keyguardShell struct {
+	commands []string
+	locked   []bool
+}
+
+func (k *keyguardShell) Shell(cmd string) (string, error) {
+	k.commands = append(k.commands, cmd)
+	if cmd != "dumpsys window" {
+		return "", nil
+	}
+	locked := len(k.locked) > 0 && k.locked[0]
+	if len(k.locked) > 1 {
+		k.locked = k.locked[1:]
+	}
+	return fmt.Sprintf("    mDreamingLockscreen=%t mDreaming=false\n", locked), nil
+}
+
+const sampleGfxInfo = `Applications Graphics Acceleration Info:
+Uptime: 1234567 Realtime: 1234567
+
+** Graphics info for pid 4242 [com.example.app] **
+
+Stats since: 123456789ns
+Total frames rendered: 240
+Janky frames: 18 (7.50%)
+Janky frames (legacy): 30 (12.50%)
+50th percentile: 8ms
+90th percentile: 14ms
+`
+
+func TestParseGfxInfo(t *testing.T) {
+	total, janky, err := parseGfxInfo(sampleGfxInfo)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if total != 240 || janky != 18 {
+		t.Errorf("got %d total, %d janky; want 240, 18", total, janky)
+	}
+
+	if _, _, err := parseGfxInfo("No process found for: com.example.app"); err == nil {
+		t.Error("expected error without frame stats")
+	}
+}
+
+func TestFrameStats(t *testing.T) {
+	shell := &prefixShell{responses: map[string]string{"dumpsys gfxinfo com.example.app": sampleGfxInfo}}
+	driver := New(&MockUIA2Client{}, nil, shell)
+
+	if err := driver.ResetFrameStats("com.example.app"); err != nil {
+		t.Fatalf("ResetFrameStats failed: %v", err)
+	}
+	total, janky, err := driver.FrameStats("com.example.app")
+	if err != nil {
+		t.Fatalf("FrameStats failed: %v", err)
+	}
+	if total != 240 || janky != 18 {
+		t.Errorf("got %d total, %d janky; want 240, 18", total, janky)
+	}
+	want := []string{"dumpsys gfxinfo com.example.app reset", "dumpsys gfxinfo com.example.app"}
+	if strings.Join(shell.commands, ",") != strings.Join(want, ",") {
+		t.Errorf("commands = %v, want %v", shell.commands, want)
+	}
+}
+
+func TestFrameStatsErrors(t *testing.T) {
+	driver := New(&MockUIA2Client{}, nil, &prefixShell{responses: map[string]string{"dumpsys gfxinfo": "No process found"}})
+
+	if err := driver.ResetFrameStats("com.example.app; reboot"); err == nil {
+		t.Error("expected an error for an invalid appId")
+	}
+	if _, _, err := driver.FrameStats("com.example.app"); err == nil {
+		t.Error("expected an error without frame stats")
+	}
+	if err := New(&MockUIA2Client{}, nil, nil).ResetFrameStats("com.example.app"); err == nil {
+		t.Error("expected an error without a device")
+	}
+}
+
+func indexOfCommand(commands []string, prefix string) int {
+	for i, cmd := range commands {
+		if strings.HasPrefix(cmd, prefix) {
+			return i
+		}
+	}
+	return -1
+}
+
+func TestIsKeyguardShowing(t *testing.T) {
+	if !isKeyguardShowing("  mShowingLockscreen=true mShowingDream=false") {
+		t.Error("expected mShowingLockscreen=true to be locked")
+	}
+	if !isKeyguardShowing("    isKeyguardShowing=true\n") {
+		t.Error("expected isKeyguardShowing=true to be locked")
+	}
+	if isKeyguardShowing("  mShowingLockscreen=false mDreamingLockscreen=false") {
+		t.Error("expected unlocked output")
+	}
+}
+
+func TestEnsureUnlockedAlreadyUnlocked(t *testing.T) {
+	shell := &keyguardShell{locked: []bool{false}}
+	driver := &Driver{device: shell}
+
+	result := driver.ensureUnlocked(&flow.EnsureUnlockedStep{})
+
+	if !result.Success {
+		t.Fatalf("expected success, got: %s", result.Message)
+	}
+	want := []string{"input keyevent 224", "dumpsys window"}
+	if strings.Join(shell.commands, "|") != strings.Join(want, "|") {
+		t.Errorf("expected %v, got %v", want, shell.commands)
+	}
+}
+
+func TestEnsureUnlockedWakesAndSwipes(t *testing.T) {
+	shell := &keyguardShell{locked: []bool{true, false}}
+	driver := &Driver{device: shell}
+
+	result := driver.ensureUnlocked(&flow.EnsureUnlockedStep{})
+
+	if !result.Success {
+		t.Fatalf("expected success, got: %s", result.Message)
+	}
+	if shell.commands[0] != "input keyevent 224" {
+		t.Errorf("expected wake keyevent first, got %q", shell.commands[0])
+	}
+	swipe := indexOfCommand(shell.commands, "input swipe")
+	dismiss := indexOfCommand(shell.commands, "wm dismiss-keyguard")
+	if swipe < 0 || dismiss < 0 || swipe > dismiss {
+		t.Errorf("expected swipe then dismiss-keyguard, got %v", shell.commands)
+	}
+	if indexOfCommand(shell.commands, "input text") >= 0 {
+		t.Errorf("passcode should not be entered on insecure keyguard, got %v", shell.commands)
+	}
+}
+
+func TestEnsureUnlockedSecureWithoutPasscode(t *testing.T) {
+	shell := &keyguardShell{locked: []bool{true}}
+	driver := &Driver{device: shell}
+
+	result := driver.ensureUnlocked(&flow.EnsureUnlockedStep{})
+
+	if result.Success {
+		t.Fatal("expected failure on secure lock screen without passcode")
+	}
+	if !strings.Contains(result.Message, "passcode") {
+		t.Errorf("expected passcode hint in message, got: %s", result.Message)
+	}
+}
+
+func TestEnsureUnlockedEntersPasscode(t *testing.T) {
+	shell := &keyguardShell{locked: []bool{true, true, false}}
+	driver := &Driver{device: shell}
+
+	result := driver.ensureUnlocked(&flow.EnsureUnlockedStep{Passcode: "1234"})
+
+	if !result.Success {
+		t.Fatalf("expected success, got: %s", result.Message)
+	}
+	text := indexOfCommand(shell.commands, "input text '1234'")
+	enter := indexOfCommand(shell.commands, "input keyevent 66")
+	if text < 0 || enter < text {
+		t.Errorf("expected passcode then enter, got %v", shell.commands)
+	}
+}
+
+func TestEnsureUnlockedQuotesPasscode(t *testing.T) {
+	shell := &keyguardShell{locked: []bool{true, true, false}}
+	driver := &Driver{device: shell}
+
+	result := driver.ensureUnlocked(&flow.EnsureUnlockedStep{Passcode: "it's; reboot"})
+
+	if !result.Success {
+		t.Fatalf("expected success, got: %s", result.Message)
+	}
+	if indexOfCommand(shell.commands, `input text 'it'\''s; reboot'`) < 0 {
+		t.Errorf("expected the passcode as one quoted argument, got %v", shell.commands)
+	}
+}
+
+func TestEnsureUnlockedNoDevice(t *testing.T) {
+	if result := (&Driver{}).ensureUnlocked(&flow.EnsureUnlockedStep{}); result.Success {
+		t.Error("expected failure without device")
+	}
+}
+
+// ============================================================================
+// setAppearance Tests
+// ============================================================================
+
+func TestSetAppearance(t *testing.T) {
+	tests := []struct {
+		mode    string
+		command string
+	}{
+		{"dark", "cmd uimode night yes"},
+		{"light", "cmd uimode night no"},
+		{"Dark", "cmd uimode night yes"},
+	}
+
+	for _, tt := range tests {
+		shell := &MockShellExecutor{}
+		driver := New(&MockUIA2Client{}, nil, shell)
+
+		result := driver.Execute(&flow.SetAppearanceStep{Mode: tt.mode})
+
+		if !result.Success {
+			t.Fatalf("%s: expected success, got: %s", tt.mode, result.Message)
+		}
+		if len(shell.commands) != 1 || shell.commands[0] != tt.command {
+			t.Errorf("%s: expected %q, got %v", tt.mode, tt.command, shell.commands)
+		}
+	}
+}
+
+func TestSetAppearanceInvalidMode(t *testing.T) {
+	shell := &MockShellExecutor{}
+	driver := New(&MockUIA2Client{}, nil, shell)
+
+	result := driver.setAppearance(&flow.SetAppearanceStep{Mode: "sepia"})
+
+	if result.Success {
+		t.Error("expected failure for unknown appearance")
+	}
+	if len(shell.commands) != 0 {
+		t.Errorf("expected no shell commands, got %v", shell.commands)
+	}
+}
+
+// ============================================================================
+// setAppLocale Tests
+// ============================================================================
+
+func TestAppLocaleCommand(t *testing.T) {
+	got := appLocaleCommand("com.example.app", "pt-BR")
+	want := "cmd locale set-app-locales com.example.app --locales pt-BR"
+	if got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestSetAppLocale(t *testing.T) {
+	shell := &prefixShell{responses: map[string]string{
+		"getprop ro.build.version.sdk": "34\n",
+		"cmd package resolve-activity": "com.example.app/.MainActivity\n",
+	}}
+	driver := New(&MockUIA2Client{}, nil, shell)
+
+	result := driver.Execute(&flow.SetAppLocaleStep{AppID: "com.example.app", Locale: "fr_FR"})
+
+	if !result.Success {
+		t.Fatalf("expected success, got: %s", result.Message)
+	}
+	set := indexOfCommand(shell.commands, "cmd locale set-app-locales com.example.app --locales fr-FR")
+	if set < 0 {
+		t.Fatalf("expected set-app-locales with normalized tag, got %v", shell.commands)
+	}
+	if start := indexOfCommand(shell.commands, "am start -n com.example.app/.MainActivity"); start < set {
+		t.Errorf("expected relaunch after setting the locale, got %v", shell.commands)
+	}
+}
+
+func TestSetAppLocaleWithoutRelaunch(t *testing.T) {
+	shell := &prefixShell{responses: map[string]string{"getprop ro.build.version.sdk": "33"}}
+	driver := New(&MockUIA2Client{}, nil, shell)
+	relaunch := false
+
+	result := driver.setAppLocale(&flow.SetAppLocaleStep{AppID: "com.example.app", Locale: "de", Relaunch: &relaunch})
+
+	if !result.Success {
+		t.Fatalf("expected success, got: %s", result.Message)
+	}
+	for _, cmd := range shell.commands {
+		if strings.HasPrefix(cmd, "am ") {
+			t.Errorf("expected no relaunch, got %q", cmd)
+		}
+	}
+}
+
+func TestSetAppLocaleOlderAndroid(t *testing.T) {
+	shell := &prefixShell{responses: map[string]string{"getprop ro.build.version.sdk": "30"}}
+	driver := New(&MockUIA2Client{}, nil, shell)
+
+	result := driver.setAppLocale(&flow.SetAppLocaleStep{AppID: "com.example.app", Locale: "fr-FR"})
+
+	if result.Success {
+		t.Fatal("expected failure below Android 13")
+	}
+	if !strings.Contains(result.Message, "Android 13") || !strings.Contains(result.Message, "API 30") {
+		t.Errorf("expected version guidance in message, got: %s", result.Message)
+	}
+	if indexOfCommand(shell.commands, "cmd locale") >= 0 {
+		t.Errorf("expected no locale command on an old device, got %v", shell.commands)
+	}
+}
+
+func TestSetAppLocaleRejectsInvalidTag(t *testing.T) {
+	shell := &prefixShell{}
+	driver := New(&MockUIA2Client{}, nil, shell)
+
+	result := driver.setAppLocale(&flow.SetAppLocaleStep{AppID: "com.example.app", Locale: "fr; reboot"})
+
+	if result.Success {
+		t.Fatal("expected failure for an invalid tag")
+	}
+	if len(shell.commands) != 0 {
+		t.Errorf("expected no shell commands, got %v", shell.commands)
+	}
+}
+
+// ============================================================================
+// setPreference Tests
+// ============================================================================
+
+const existingPrefs = `<?xml version='1.0' encoding='utf-8' standalone='yes' ?>
+<map>
+    <string name="token">abc &amp; def</string>
+    <int name="launches" value="3" />
+</map>
+`
+
+// writtenPrefs decodes the XML that setPreference piped into run-as.
+func writtenPrefs(t *testing.T, commands []string) string {
+	t.Helper()
+	idx := indexOfCommand(commands, "echo ")
+	if idx < 0 {
+		t.Fatalf("expected a prefs write command, got %v", commands)
+	}
+	cmd := commands[idx]
+	if !strings.Contains(cmd, "| base64 -d | run-as com.example.app sh -c 'mkdir -p shared_prefs && cat > shared_prefs/com.example.app_preferences.xml'") {
+		t.Errorf("unexpected write command: %s", cmd)
+	}
+	decoded, err := base64.StdEncoding.DecodeString(strings.Fields(cmd)[1])
+	if err != nil {
+		t.Fatalf("write command payload is not base64: %v", err)
+	}
+	return string(decoded)
+}
+
+func TestSetPreference(t *testing.T) {
+	tests := []struct {
+		name      string
+		step      *flow.SetPreferenceStep
+		wantEntry string
+	}{
+		{"string", &flow.SetPreferenceStep{AppID: "com.example.app", Key: "username", Value: `jane "j" <doe>`},
+			`<string name="username">jane &#34;j&#34; &lt;doe&gt;</string>`},
+		{"boolean", &flow.SetPreferenceStep{AppID: "com.example.app", Key: "onboarded", ValueType: "bool", Value: "true"},
+			`<boolean name="onboarded" value="true" />`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shell := &prefixShell{responses: map[string]string{
+				"run-as com.example.app id":  "uid=10123(u0_a123) gid=10123(u0_a123)\n",
+				"run-as com.example.app cat": existingPrefs,
+			}}
+			driver := New(&MockUIA2Client{}, nil, shell)
+
+			result := driver.Execute(tt.step)
+
+			if !result.Success {
+				t.Fatalf("expected success, got: %s", result.Message)
+			}
+			if stop := indexOfCommand(shell.commands, "am force-stop com.example.app"); stop < 0 || stop > indexOfCommand(shell.commands, "echo ") {
+				t.Errorf("expected the app to be stopped before writing, got %v", shell.commands)
+			}
+			xml := writtenPrefs(t, shell.commands)
+			if !strings.Contains(xml, tt.wantEntry) {
+				t.Errorf("expected %s in written prefs, got:\n%s", tt.wantEntry, xml)
+			}
+			for _, kept := range []string{`<string name="token">abc &amp; def</string>`, `<int name="launches" value="3" />`} {
+				if !strings.Contains(xml, kept) {
+					t.Errorf("expected existing entry %s to be kept, got:\n%s", kept, xml)
+				}
+			}
+		})
+	}
+}
+
+func TestSetPreferenceReplacesExistingKey(t *testing.T) {
+	got, err := upsertSharedPref(existingPrefs, "launches", flow.PrefInt, "4")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Count(got, `name="launches"`) != 1 || !strings.Contains(got, `<int name="launches" value="4" />`) {
+		t.Errorf("expected launches to be replaced, got:\n%s", got)
+	}
+}
+
+func TestSetPreferenceNotDebuggable(t *testing.T) {
+	shell := &prefixShell{responses: map[string]string{
+		"run-as com.example.app id": "run-as: package not debuggable: com.example.app\n",
+	}}
+	driver := New(&MockUIA2Client{}, nil, shell)
+
+	result := driver.setPreference(&flow.SetPreferenceStep{AppID: "com.example.app", Key: "onboarded", ValueType: "boolean", Value: "true"})
+
+	if result.Success {
+		t.Fatal("expected failure for a non-debuggable app")
+	}
+	if !strings.Contains(result.Message, "debuggable") {
+		t.Errorf("expected debuggable hint in message, got: %s", result.Message)
+	}
+	if indexOfCommand(shell.commands, "echo ") >= 0 {
+		t.Errorf("expected no write attempt, got %v", shell.commands)
+	}
+}
+
+// ============================================================================
+// assertFieldValue Tests
+// ============================================================================
+
+// fieldValueServer serves a single EditText whose text is value.
+func fieldValueServer(t *testing.T, value string) *httptest.Server {
+	return setupMockServer(t, map[string]func(w http.ResponseWriter, r *http.Request){
+		"POST /element": func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, map[string]interface{}{
+				"value": map[string]string{"ELEMENT": "field-1"},
+			})
+		},
+		"GET /element/field-1/text": func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, map[string]interface{}{"value": value})
+		},
+		"GET /element/field-1/rect": func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, map[string]interface{}{
+				"value": map[string]int{"x": 40, "y": 300, "width": 1000, "height": 120},
+			})
+		},
+		"GET /element/field-1/attribute/displayed": func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, map[string]interface{}{"value": "true"})
+		},
+		"GET /element/field-1/attribute/enabled": func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, map[string]interface{}{"value": "true"})
+		},
+	})
+}
+
+func TestAssertFieldValue(t *testing.T) {
+	tests := []struct {
+		name     string
+		equals   string
+		contains string
+		success  bool
+	}{
+		{"equals match", "SAVE20", "", true},
+		{"contains match", "", "20", true},
+		{"equals mismatch", "SAVE10", "", false},
+		{"contains mismatch", "", "WELCOME", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := fieldValueServer(t, "SAVE20")
+			defer server.Close()
+			driver := New(newMockHTTPClient(server.URL).Client, nil, nil)
+
+			result := driver.Execute(&flow.AssertFieldValueStep{
+				Element:  flow.Selector{ID: "promoCode"},
+				Equals:   tt.equals,
+				Contains: tt.contains,
+			})
+
+			if result.Success != tt.success {
+				t.Fatalf("expected success=%v, got %v: %s", tt.success, result.Success, result.Message)
+			}
+			if !tt.success && !strings.Contains(result.Message, `got "SAVE20"`) {
+				t.Errorf("expected observed value in message, got: %s", result.Message)
+			}
+		})
+	}
+}
+
+// ============================================================================
+// assertSorted Tests
+// ============================================================================
+
+// priceListSource builds a list of price rows in the given order.
+func priceListSource(prices ...string) string {
+	var rows strings.Builder
+	for i, p := range prices {
+		fmt.Fprintf(&rows, `    <node class="android.widget.TextView" resource-id="com.example:id/price" text="%s" bounds="[0,%d][1080,%d]" displayed="true"/>`+"\n",
+			p, 200+i*100, 300+i*100)
+	}
+	return `<?xml version="1.0" encoding="UTF-8"?>
+<hierarchy>
+  <node class="android.widget.ListView" bounds="[0,0][1080,2400]" displayed="true">
+` + rows.String() + `  </node>
+</hierarchy>`
+}
+
+func TestAssertSorted(t *testing.T) {
+	tests := []struct {
+		name    string
+		prices  []string
+		order   string
+		success bool
+	}{
+		{"ascending in order", []string{"$5.00", "$12.50", "$100.00"}, "", true},
+		{"ascending out of order", []string{"$5.00", "$100.00", "$12.50"}, "ascending", false},
+		{"descending in order", []string{"$100.00", "$12.50", "$5.00"}, "descending", true},
+		{"descending out of order", []string{"$12.50", "$100.00", "$5.00"}, "descending", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			driver := &Driver{client: &MockUIA2Client{sourceData: priceListSource(tt.prices...)}}
+			step := &flow.AssertSortedStep{
+				Element: flow.Selector{ID: "com.example:id/price"},
+				Pattern: `\$([\d.]+)`,
+				Numeric: true,
+				Order:   tt.order,
+			}
+
+			result := driver.assertSorted(step)
+
+			if result.Success != tt.success {
+				t.Fatalf("expected success=%v, got %v: %s", tt.success, result.Success, result.Message)
+			}
+			if !tt.success && !strings.Contains(result.Message, "100.00") {
+				t.Errorf("expected first out-of-order pair in message, got: %s", result.Message)
+			}
+		})
+	}
+}
+
+func TestAssertSortedNoMatches(t *testing.T) {
+	driver := &Driver{client: &MockUIA2Client{sourceData: priceListSource()}}
+	step := &flow.AssertSortedStep{Element: flow.Selector{ID: "com.example:id/price"}}
+	step.TimeoutMs = 1
+
+	result := driver.assertSorted(step)
+
+	if result.Success {
+		t.Error("expected failure when no elements match")
+	}
+}
+
+// ============================================================================
+// waitForText Tests
+// ============================================================================
+
+// statusSource builds a page with a single status label showing text.
+func statusSource(text string) string {
+	return `<?xml version="1.0" encoding="UTF-8"?>
+<hierarchy>
+  <node class="android.widget.TextView" resource-id="com.example:id/status" text="` + text + `" bounds="[0,200][1080,300]" displayed="true"/>
+</hierarchy>`
+}
+
+func TestWaitForText(t *testing.T) {
+	tests := []struct {
+		name string
+		step flow.WaitForTextStep
+	}{
+		{"equals", flow.WaitForTextStep{Equals: "Done"}},
+		{"contains", flow.WaitForTextStep{Contains: "one"}},
+		{"matches", flow.WaitForTextStep{Matches: "D[a-z]+"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			client := &MockUIA2Client{sourceFunc: sequencedSource(&calls,
+				statusSource("Loading"), statusSource("Loading"), statusSource("Done"))}
+			driver := New(client, nil, nil)
+			step := tt.step
+			step.Element = flow.Selector{ID: "com.example:id/status"}
+			step.TimeoutMs = 5000
+
+			result := driver.waitForText(&step)
+
+			if !result.Success {
+				t.Fatalf("expected success, got: %s", result.Message)
+			}
+			if calls != 3 {
+				t.Errorf("expected to return on the third poll, got %d polls", calls)
+			}
+			if !strings.Contains(result.Message, `"Done"`) {
+				t.Errorf("expected matched text in message, got: %s", result.Message)
+			}
+		})
+	}
+}
+
+func TestWaitForTextTimeoutReportsLastText(t *testing.T) {
+	driver := New(&MockUIA2Client{sourceData: statusSource("Loading 40%")}, nil, nil)
+	step := &flow.WaitForTextStep{Element: flow.Selector{ID: "com.example:id/status"}, Equals: "Done"}
+	step.TimeoutMs = 1
+
+	result := driver.waitForText(step)
+
+	if result.Success {
+		t.Fatal("expected timeout failure")
+	}
+	if !strings.Contains(result.Message, `last text: "Loading 40%"`) {
+		t.Errorf("expected last observed text in message, got: %s", result.Message)
+	}
+}
+
+func TestWaitForTextInvalidCondition(t *testing.T) {
+	driver := New(&MockUIA2Client{sourceData: statusSource("Done")}, nil, nil)
+
+	result := driver.waitForText(&flow.WaitForTextStep{Element: flow.Selector{ID: "com.example:id/status"}})
+
+	if result.Success {
+		t.Fatal("expected failure without a condition")
+	}
+}
+
+// ============================================================================
+// waitForDownload Tests
+// ============================================================================
+
+// downloadShell serves successive `ls` listings and `stat` sizes, one per poll,
+// repeating the last entry once a sequence is exhausted.
+type downloadShell struct {
+	commands []string
+	listings []string
+	sizes    []string
+}
+
+func (s *downloadShell) Shell(cmd string) (string, error) {
+	s.commands = append(s.commands, cmd)
+	next := func(seq *[]string) string {
+		if len(*seq) == 0 {
+			return ""
+		}
+		out := (*seq)[0]
+		if len(*seq) > 1 {
+			*seq = (*seq)[1:]
+		}
+		return out
+	}
+	switch {
+	case strings.HasPrefix(cmd, "ls "):
+		return next(&s.listings), nil
+	case strings.HasPrefix(cmd, "stat "):
+		return next(&s.sizes), nil
+	}
+	return "", nil
+}
+
+func TestWaitForDownloadAppearsAndStabilizes(t *testing.T) {
+	shell := &downloadShell{
+		listings: []string{
+			"notes.txt\n",
+			"notes.txt\ninvoice-42.pdf.crdownload\n",
+			"notes.txt\ninvoice-42.pdf\n",
+		},
+		sizes: []string{"1024\n", "4096\n", "4096\n"},
+	}
+	driver := &Driver{device: shell}
+	step := &flow.WaitForDownloadStep{File: "invoice-*.pdf"}
+	step.TimeoutMs = 10000
+
+	result := driver.waitForDownload(step)
+
+	if !result.Success {
+		t.Fatalf("expected success, got: %s", result.Message)
+	}
+	if !strings.Contains(result.Message, "/sdcard/Download/invoice-42.pdf (4096 bytes)") {
+		t.Errorf("unexpected message: %s", result.Message)
+	}
+	for _, cmd := range shell.commands {
+		if strings.Contains(cmd, ".crdownload") {
+			t.Errorf("partial download should not be stat'ed: %s", cmd)
+		}
+	}
+	if last := shell.commands[len(shell.commands)-1]; last != "stat -c %s '/sdcard/Download/invoice-42.pdf'" {
+		t.Errorf("unexpected stat command: %q", last)
+	}
+}
+
+func TestWaitForDownloadStillGrowing(t *testing.T) {
+	shell := &downloadShell{
+		listings: []string{"video.mp4\n"},
+		sizes:    []string{"100\n", "200\n", "300\n", "400\n"},
+	}
+	driver := &Driver{device: shell}
+	step := &flow.WaitForDownloadStep{File: "video.mp4", Directory: "/sdcard/Movies"}
+	step.TimeoutMs = 1200
+
+	result := driver.waitForDownload(step)
+
+	if result.Success {
+		t.Fatal("expected failure while the file keeps growing")
+	}
+	if !strings.Contains(result.Message, "still growing") {
+		t.Errorf("unexpected message: %s", result.Message)
+	}
+	if shell.commands[0] != "ls -1 '/sdcard/Movies'" {
+		t.Errorf("expected configured directory to be listed, got %q", shell.commands[0])
+	}
+}
+
+func TestWaitForDownloadNeverAppears(t *testing.T) {
+	shell := &downloadShell{listings: []string{"other.zip\n"}}
+	driver := &Driver{device: shell}
+	step := &flow.WaitForDownloadStep{File: "report.csv"}
+	step.TimeoutMs = 1
+
+	result := driver.waitForDownload(step)
+
+	if result.Success {
+		t.Fatal("expected failure when no file matches")
+	}
+	if !strings.Contains(result.Message, "No file matching") {
+		t.Errorf("unexpected message: %s", result.Message)
+	}
+}
+
+func TestWaitForDownloadValidation(t *testing.T) {
+	if result := (&Driver{device: &downloadShell{}}).waitForDownload(&flow.WaitForDownloadStep{}); result.Success {
+		t.Error("expected failure without a file")
+	}
+	if result := (&Driver{device: &downloadShell{}}).waitForDownload(&flow.WaitForDownloadStep{File: "[bad"}); result.Success {
+		t.Error("expected failure for invalid pattern")
+	}
+	if result := (&Driver{}).waitForDownload(&flow.WaitForDownloadStep{File: "a.pdf"}); result.Success {
+		t.Error("expected failure without device")
+	}
+}
+
+// ============================================================================
+// assertFileExists Tests
+// ============================================================================
+
+func picturesShell() *prefixShell {
+	return &prefixShell{responses: map[string]string{
+		"ls -1 '/sdcard/Pictures'":                  "notes.txt\nshare_1.png\n",
+		"stat -c %s '/sdcard/Pictures/share_1.png'": "20480\n",
+	}}
+}
+
+// installShell answers `pm list packages` with each listing in turn,
+// repeating the last one.
+type installShell struct {
+	commands []string
+	listings []string
+}
+
+func (s *installShell) Shell(cmd string) (string, error) {
+	s.commands = append(s.commands, cmd)
+	out := s.listings[0]
+	if len(s.listings) > 1 {
+		s.listings = s.listings[1:]
+	}
+	return out, nil
+}
+
+func TestWaitForInstallAfterPolls(t *testing.T) {
+	shell := &installShell{listings: []string{
+		"",
+		"package:com.example.app.debug\n",
+		"package:com.example.app.debug\npackage:com.example.app\n",
+	}}
+	driver := &Driver{device: shell}
+	step := &flow.WaitForInstallStep{AppID: "com.example.app"}
+	step.TimeoutMs = 5000
+
+	result := driver.waitForInstall(step)
+
+	if !result.Success {
+		t.Fatalf("expected success, got: %s", result.Message)
+	}
+	if len(shell.commands) != 3 {
+		t.Errorf("expected 3 polls, got %d", len(shell.commands))
+	}
+	if shell.commands[0] != "pm list packages com.example.app" {
+		t.Errorf("unexpected command: %q", shell.commands[0])
+	}
+	if !strings.HasPrefix(result.Message, "App com.example.app installed after") {
+		t.Errorf("unexpected message: %s", result.Message)
+	}
+}
+
+func TestWaitForInstallTimeout(t *testing.T) {
+	shell := &installShell{listings: []string{"package:com.example.app.debug\n"}}
+	driver := &Driver{device: shell}
+	step := &flow.WaitForInstallStep{AppID: "com.example.app"}
+	step.TimeoutMs = 600
+
+	result := driver.waitForInstall(step)
+
+	if result.Success {
+		t.Fatal("expected failure when the package never appears")
+	}
+	if result.Message != "App com.example.app was not installed within 600ms" {
+		t.Errorf("unexpected message: %s", result.Message)
+	}
+	if len(shell.commands) < 2 {
+		t.Errorf("expected repeated polls, got %d", len(shell.commands))
+	}
+}
+
+func TestWaitForInstallInvalidAppID(t *testing.T) {
+	shell := &installShell{listings: []string{""}}
+	driver := &Driver{device: shell}
+
+	result := driver.waitForInstall(&flow.WaitForInstallStep{AppID: "com.app; reboot"})
+
+	if result.Success {
+		t.Fatal("expected failure for invalid appId")
+	}
+	if len(shell.commands) != 0 {
+		t.Errorf("expected no shell commands, got %v", shell.commands)
+	}
+}
+
+func TestAssertFileExistsOnDevice(t *testing.T) {
+	driver := New(&MockUIA2Client{}, nil, picturesShell())
+
+	result := driver.Execute(&flow.AssertFileExistsStep{Path: "/sdcard/Pictures/share_*.png", Device: true})
+
+	if !result.Success {
+		t.Fatalf("expected success, got: %s", result.Message)
+	}
+	if !strings.Contains(result.Message, "share_1.png (20480 bytes)") {
+		t.Errorf("expected file and size in message, got: %s", result.Message)
+	}
+}
+
+func TestAssertFileExistsOnDeviceMissing(t *testing.T) {
+	driver := New(&MockUIA2Client{}, nil, picturesShell())
+
+	result := driver.assertFileExists(&flow.AssertFileExistsStep{Path: "/sdcard/Pictures/*.jpg", Device: true})
+
+	if result.Success {
+		t.Error("expected failure when no file matches")
+	}
+	if !strings.Contains(result.Message, "No file matching") {
+		t.Errorf("expected not-found message, got: %s", result.Message)
+	}
+}
+
+func TestAssertFileExistsOnDeviceMinSize(t *testing.T) {
+	driver := New(&MockUIA2Client{}, nil, picturesShell())
+
+	result := driver.assertFileExists(&flow.AssertFileExistsStep{Path: "/sdcard/Pictures/share_*.png", MinSize: 50000, Device: true})
+
+	if result.Success {
+		t.Error("expected failure for a file below minSize")
+	}
+	if !strings.Contains(result.Message, "20480 bytes, below minSize 50000") {
+		t.Errorf("expected size comparison in message, got: %s", result.Message)
+	}
+}
+
+func TestAssertFileExistsOnDeviceRelativePath(t *testing.T) {
+	shell := picturesShell()
+	driver := New(&MockUIA2Client{}, nil, shell)
+
+	if result := driver.assertFileExists(&flow.AssertFileExistsStep{Path: "share_1.png", Device: true}); result.Success {
+		t.Error("expected failure for a relative device path")
+	}
+	if len(shell.commands) != 0 {
+		t.Errorf("expected no shell commands, got %v", shell.commands)
+	}
+}
+
+// ============================================================================
+// WebView Tests
+// ============================================================================
+
+// webViewRecorder captures the context switches and DOM lookups made
+// against webViewServer.
+type webViewRecorder struct {
+	contexts []string
+	xpaths   []string
+	clicked  bool
+}
+
+func webViewServer(t *testing.T, rec *webViewRecorder) *httptest.Server {
+	return setupMockServer(t, map[string]func(w http.ResponseWriter, r *http.Request){
+		"GET /contexts": func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, map[string]interface{}{
+				"value": []string{"NATIVE_APP", "WEBVIEW_com.example.shop"},
+			})
+		},
+		"POST /context": func(w http.ResponseWriter, r *http.Request) {
+			var req uiautomator2.ContextRequest
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			rec.contexts = append(rec.contexts, req.Name)
+			writeJSON(w, map[string]interface{}{"value": nil})
+		},
+		"POST /element": func(w http.ResponseWriter, r *http.Request) {
+			var req uiautomator2.FindElementRequest
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			if req.Strategy != "xpath" {
+				t.Errorf("expected xpath strategy in WebView, got %q", req.Strategy)
+			}
+			rec.xpaths = append(rec.xpaths, req.Selector)
+			writeJSON(w, map[string]interface{}{
+				"value": map[string]string{"ELEMENT": "web-1"},
+			})
+		},
+		"POST /element/web-1/click": func(w http.ResponseWriter, r *http.Request) {
+			rec.clicked = true
+			writeJSON(w, map[string]interface{}{"value": nil})
+		},
+		"GET /element/web-1/attribute/displayed": func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, map[string]interface{}{"value": "true"})
+		},
+	})
+}
+
+func TestTapOnWebView(t *testing.T) {
+	rec := &webViewRecorder{}
+	server := webViewServer(t, rec)
+	defer server.Close()
+
+	client := newMockHTTPClient(server.URL)
+	driver := New(client.Client, nil, nil)
+
+	result := driver.Execute(&flow.TapOnStep{Selector: flow.Selector{Text: "Add to cart"}, WebView: true})
+
+	if !result.Success {
+		t.Fatalf("expected success, got: %s", result.Message)
+	}
+	if want := []string{"WEBVIEW_com.example.shop", "NATIVE_APP"}; strings.Join(rec.contexts, ",") != strings.Join(want, ",") {
+		t.Errorf("expected context switch %v, got %v", want, rec.contexts)
+	}
+	if len(rec.xpaths) != 1 || rec.xpaths[0] != "//*[text()[contains(normalize-space(.), 'Add to cart')]]" {
+		t.Errorf("unexpected web element lookup: %v", rec.xpaths)
+	}
+	if !rec.clicked {
+		t.Error("expected the web element to be clicked")
+	}
+}
+
+func TestAssertVisibleWebView(t *testing.T) {
+	rec := &webViewRecorder{}
+	server := webViewServer(t, rec)
+	defer server.Close()
+
+	client := newMockHTTPClient(server.URL)
+	driver := New(client.Client, nil, nil)
+
+	result := driver.Execute(&flow.AssertVisibleStep{Selector: flow.Selector{Text: "Order total"}, WebView: true})
+
+	if !result.Success {
+		t.Fatalf("expected success, got: %s", result.Message)
+	}
+	if len(rec.contexts) != 2 || rec.contexts[1] != uiautomator2.NativeContext {
+		t.Errorf("expected switch back to native context, got %v", rec.contexts)
+	}
+}
+
+func TestAssertVisibleWebViewNoContext(t *testing.T) {
+	client := &MockUIA2Client{contextsData: []string{"NATIVE_APP"}}
+	driver := New(client, nil, nil)
+
+	result := driver.assertVisible(&flow.AssertVisibleStep{Selector: flow.Selector{Text: "Order total"}, WebView: true})
+
+	if result.Success {
+		t.Error("expected failure without a WebView context")
+	}
+	if !strings.Contains(result.Message, "no WebView context") {
+		t.Errorf("expected missing context message, got: %s", result.Message)
+	}
+	if len(client.setContextCalls) != 0 {
+		t.Errorf("expected no context switch, got %v", client.setContextCalls)
+	}
+}
+
+func TestAssertVisibleCount(t *testing.T) {
+	source := `<hierarchy>
+<node resource-id="com.app:id/row" text="A" displayed="true" bounds="[0,0][100,100]"/>
+<node resource-id="com.app:id/row" text="B" displayed="true" bounds="[0,100][100,200]"/>
+<node resource-id="com.app:id/row" text="C" displayed="true" bounds="[0,200][100,300]"/>
+<node resource-id="com.app:id/row" text="D" displayed="false" bounds="[0,300][100,400]"/>
+</hierarchy>`
+	tests := []struct {
+		name        string
+		count       int
+		comparator  string
+		wantSuccess bool
+		wantMessage string
+	}{
+		{"exact", 3, "", true, "Found 3 visible"},
+		{"exact mismatch", 5, "", false, "expected 5 '#row' elements, found 3"},
+		{"at least", 2, ">=", true, "Found 3 visible"},
+		{"at most mismatch", 2, "<=", false, "expected at most 2 '#row' elements, found 3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			driver := New(&MockUIA2Client{sourceData: source}, nil, nil)
+			count := tt.count
+			result := driver.Execute(&flow.AssertVisibleStep{
+				BaseStep:        flow.BaseStep{TimeoutMs: 1},
+				Selector:        flow.Selector{ID: "row"},
+				Count:           &count,
+				CountComparator: tt.comparator,
+			})
+			if result.Success != tt.wantSuccess {
+				t.Fatalf("Success = %v, want %v (message: %s)", result.Success, tt.wantSuccess, result.Message)
+			}
+			if !strings.Contains(result.Message, tt.wantMessage) {
+				t.Errorf("expected %q in message, got: %s", tt.wantMessage, result.Message)
+			}
+		})
+	}
+}
+
+func TestAssertVisibleCountNestedMatch(t *testing.T) {
+	source := `<hierarchy>
+<node content-desc="Milk" displayed="true" bounds="[0,0][100,100]">
+<node text="Milk" displayed="true" bounds="[10,10][90,90]"/>
+</node>
+</hierarchy>`
+	driver := New(&MockUIA2Client{sourceData: source}, nil, nil)
+
+	count := 1
+	result := driver.Execute(&flow.AssertVisibleStep{
+		BaseStep: flow.BaseStep{TimeoutMs: 1},
+		Selector: flow.Selector{Text: "Milk"},
+		Count:    &count,
+	})
+	if !result.Success {
+		t.Errorf("expected the row and its label to count once, got: %s", result.Message)
+	}
+}
+
+func TestXPathLiteral(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"Checkout", "'Checkout'"},
+		{"Don't stop", `"Don't stop"`},
+		{`It's "new"`, `concat('It', "'", 's "new"')`},
+	}
+
+	for _, tt := range tests {
+		if got := xpathLiteral(tt.in); got != tt.want {
+			t.Errorf("xpathLiteral(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+// ============================================================================
+// gesturePath Tests
+// ============================================================================
+
+func TestGesturePath(t *testing.T) {
+	client := &MockUIA2Client{}
+	driver := New(client, nil, nil)
+
+	// 1080x2400 screen: (108,1200) -> (432,1200) -> (432,1440), segments of 324px and 240px
+	result := driver.gesturePath(&flow.GesturePathStep{
+		Points:     []string{"10%, 50%", "40%, 50%", "40%, 60%"},
+		DurationMs: 1128,
+	})
+
+	if !result.Success {
+		t.Fatalf("expected success, got: %s", result.Message)
+	}
+	if len(client.pointerPathCalls) != 1 {
+		t.Fatalf("expected 1 pointer path, got %d", len(client.pointerPathCalls))
+	}
+	want := []uiautomator2.PointModel{{X: 108, Y: 1200}, {X: 432, Y: 1200}, {X: 432, Y: 1440}}
+	got := client.pointerPathCalls[0]
+	if len(got) != len(want) {
+		t.Fatalf("expected %d points, got %v", len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("point %d: expected %+v, got %+v", i, want[i], got[i])
+		}
+	}
+	if d := client.pointerPathDurations[0]; len(d) != 2 || d[0] != 648 || d[1] != 480 {
+		t.Errorf("expected segment durations [648 480], got %v", d)
+	}
+}
+
+func TestTwoFingerSwipe(t *testing.T) {
+	client := &MockUIA2Client{}
+	driver := New(client, nil, nil)
+
+	// 1080x2400 screen: midpoint (540,1680) -> (540,720), fingers 108px apart
+	result := driver.Execute(&flow.TwoFingerSwipeStep{Direction: "UP", DurationMs: 400})
+
+	if !result.Success {
+		t.Fatalf("expected success, got: %s", result.Message)
+	}
+	if len(client.parallelSwipeCalls) != 1 || client.parallelSwipeMs[0] != 400 {
+		t.Fatalf("expected one 400ms parallel swipe, got %v %v", client.parallelSwipeCalls, client.parallelSwipeMs)
+	}
+	want := [][2]uiautomator2.PointModel{
+		{{X: 486, Y: 1680}, {X: 486, Y: 720}},
+		{{X: 594, Y: 1680}, {X: 594, Y: 720}},
+	}
+	got := client.parallelSwipeCalls[0]
+	if len(got) != 2 || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("expected tracks %v, got %v", want, got)
+	}
+}
+
+func TestTwoFingerSwipeNeedsDirection(t *testing.T) {
+	client := &MockUIA2Client{}
+	driver := New(client, nil, nil)
+
+	if result := driver.Execute(&flow.TwoFingerSwipeStep{Start: "50%, 50%"}); result.Success {
+		t.Error("expected failure without an end point")
+	}
+	if result := driver.Execute(&flow.TwoFingerSwipeStep{Direction: "sideways"}); result.Success {
+		t.Error("expected failure for unknown direction")
+	}
+	if len(client.parallelSwipeCalls) != 0 {
+		t.Error("expected no gesture to be sent")
+	}
+}
+
+func TestGesturePathTooFewPoints(t *testing.T) {
+	client := &MockUIA2Client{}
+	driver := New(client, nil, nil)
+
+	result := driver.gesturePath(&flow.GesturePathStep{Points: []string{"50%, 50%"}})
+
+	if result.Success {
+		t.Error("expected failure with a single point")
+	}
+	if len(client.pointerPathCalls) != 0 {
+		t.Error("expected no gesture to be sent")
+	}
+}
+
+// ============================================================================
+// assertShareTarget Tests
+// ============================================================================
+
+const shareSheetSource = `<?xml version="1.0" encoding="UTF-8"?>
+<hierarchy>
+  <node class="android.widget.FrameLayout" resource-id="com.android.intentresolver:id/contentPanel" bounds="[0,1200][1080,2400]" displayed="true">
+    <node class="android.widget.TextView" text="Share" resource-id="android:id/title" bounds="[40,1240][400,1300]" displayed="true"/>
+    <node class="androidx.recyclerview.widget.RecyclerView" resource-id="com.android.intentresolver:id/resolver_list" bounds="[0,1320][1080,2400]" displayed="true">
+      <node class="android.widget.LinearLayout" bounds="[0,1320][270,1600]" clickable="true" displayed="true">
+        <node class="android.widget.TextView" text="Gmail" resource-id="android:id/text1" bounds="[20,1520][250,1580]" displayed="true"/>
+      </node>
+      <node class="android.widget.LinearLayout" bounds="[270,1320][540,1600]" clickable="true" displayed="true">
+        <node class="android.widget.TextView" text="Messages" resource-id="android:id/text1" bounds="[290,1520][520,1580]" displayed="true"/>
+      </node>
+    </node>
+  </node>
+</hierarchy>`
+
+func TestAssertShareTarget(t *testing.T) {
+	hidden := false
+	tests := []struct {
+		name    string
+		step    *flow.AssertShareTargetStep
+		wantOK  bool
+		wantMsg string
+	}{
+		{"present target", &flow.AssertShareTargetStep{App: "gmail"}, true, `lists "gmail"`},
+		{"absent target", &flow.AssertShareTargetStep{App: "Slack"}, false, `does not list "Slack"`},
+		{"absent target expected", &flow.AssertShareTargetStep{App: "Slack", Visible: &hidden}, true, `does not list "Slack"`},
+		{"present target not expected", &flow.AssertShareTargetStep{App: "Messages", Visible: &hidden}, false, `should not list "Messages"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			driver := New(&MockUIA2Client{sourceData: shareSheetSource}, nil, nil)
+			tt.step.TimeoutMs = 100
+
+			result := driver.Execute(tt.step)
+
+			if result.Success != tt.wantOK {
+				t.Fatalf("Success = %v, want %v (%s)", result.Success, tt.wantOK, result.Message)
+			}
+			if !strings.Contains(result.Message, tt.wantMsg) {
+				t.Errorf("expected %q in message, got: %s", tt.wantMsg, result.Message)
+			}
+		})
+	}
+}
+
+func TestAssertShareTargetWaitsForSheet(t *testing.T) {
+	calls := 0
+	client := &MockUIA2Client{sourceFunc: sequencedSource(&calls, retrySourceValid, shareSheetSource)}
+	driver := New(client, nil, nil)
+
+	result := driver.assertShareTarget(&flow.AssertShareTargetStep{App: "Gmail", BaseStep: flow.BaseStep{TimeoutMs: 2000}})
+
+	if !result.Success {
+		t.Fatalf("expected success once the sheet opens, got: %s", result.Message)
+	}
+	if calls != 2 {
+		t.Errorf("expected 2 source reads, got %d", calls)
+	}
+}
+
+func TestAssertShareTargetNoSheet(t *testing.T) {
+	hidden := false
+	driver := New(&MockUIA2Client{sourceData: retrySourceValid}, nil, nil)
+
+	// Without a sheet on screen even an absence check can't pass
+	result := driver.assertShareTarget(&flow.AssertShareTargetStep{App: "Login", Visible: &hidden, BaseStep: flow.BaseStep{TimeoutMs: 100}})
+
+	if result.Success {
+		t.Fatal("expected failure without a share sheet")
+	}
+	if !strings.Contains(result.Message, "Share sheet not found") {
+		t.Errorf("unexpected message: %s", result.Message)
+	}
+}
+
+const accessibilitySource = `<?xml version="1.0" encoding="UTF-8"?>
+<hierarchy>
+  <node class="android.widget.FrameLayout" bounds="[0,0][1080,2400]">
+    <node class="android.widget.Button" text="Login" bounds="[100,200][300,260]" clickable="true"/>
+    <node class="android.widget.ImageButton" resource-id="com.example:id/close" bounds="[980,40][1060,120]" clickable="true"/>
+    <node class="android.widget.LinearLayout" bounds="[0,400][1080,520]" clickable="true">
+      <node class="android.widget.TextView" text="Settings" bounds="[40,420][400,500]"/>
+    </node>
+    <node class="android.widget.ImageView" bounds="[0,600][200,800]"/>
+  </node>
+</hierarchy>`
+
+func TestAssertAccessibleReportsUnlabeled(t *testing.T) {
+	driver := New(&MockUIA2Client{sourceData: accessibilitySource}, nil, nil)
+
+	result := driver.Execute(&flow.AssertAccessibleStep{})
+
+	if result.Success {
+		t.Fatal("expected failure for the unlabeled image button")
+	}
+	want := "1 of 3 interactive elements have no accessibility label: android.widget.ImageButton com.example:id/close [980,40][1060,120]"
+	if result.Message != want {
+		t.Errorf("message = %q, want %q", result.Message, want)
+	}
+}
+
+func TestAssertAccessibleAllLabeled(t *testing.T) {
+	driver := New(&MockUIA2Client{sourceData: retrySourceValid}, nil, nil)
+
+	result := driver.Execute(&flow.AssertAccessibleStep{})
+
+	if !result.Success {
+		t.Fatalf("expected success, got: %s", result.Message)
+	}
+	if result.Message != "All 1 interactive elements have accessibility labels" {
+		t.Errorf("unexpected message: %s", result.Message)
+	}
+}
+
+const forbiddenTextSource = `<?xml version="1.0" encoding="UTF-8"?>
+<hierarchy>
+  <node class="android.widget.FrameLayout" bounds="[0,0][1080,2400]">
+    <node class="android.widget.TextView" text="Checkout" bounds="[40,100][600,180]"/>
+    <node class="android.widget.TextView" text="Error: null" bounds="[40,200][600,280]"/>
+    <node class="android.widget.EditText" text="" hint="Promo code" bounds="[40,300][1040,400]"/>
+  </node>
+</hierarchy>`
+
+func TestAssertTextNotContainsFindsForbiddenText(t *testing.T) {
+	driver := New(&MockUIA2Client{sourceData: forbiddenTextSource}, nil, nil)
+
+	result := driver.Execute(&flow.AssertTextNotContainsStep{Text: []string{"undefined", "null"}})
+
+	if result.Success {
+		t.Fatal("expected failure for forbidden text")
+	}
+	want := `Forbidden text found on screen: "null" in "Error: null"`
+	if result.Message != want {
+		t.Errorf("message = %q, want %q", result.Message, want)
+	}
+}
+
+func TestAssertTextNotContainsRegex(t *testing.T) {
+	driver := New(&MockUIA2Client{sourceData: forbiddenTextSource}, nil, nil)
+
+	result := driver.Execute(&flow.AssertTextNotContainsStep{Regex: []string{`(?i)^error\b`}})
+
+	if result.Success {
+		t.Fatal("expected failure for forbidden pattern")
+	}
+	if !strings.Contains(result.Message, `/(?i)^error\b/ in "Error: null"`) {
+		t.Errorf("unexpected message: %s", result.Message)
+	}
+}
+
+func TestAssertTextNotContainsAbsent(t *testing.T) {
+	driver := New(&MockUIA2Client{sourceData: forbiddenTextSource}, nil, nil)
+
+	result := driver.Execute(&flow.AssertTextNotContainsStep{Text: []string{"Exception", "NaN"}})
+
+	if !result.Success {
+		t.Fatalf("expected success, got: %s", result.Message)
+	}
+	if result.Message != "No forbidden text in 3 texts on screen" {
+		t.Errorf("unexpected message: %s", result.Message)
+	}
+}
+
+const tapParentHierarchy = `<?xml version="1.0" encoding="UTF-8"?>
+<hierarchy rotation="0">
+  <node class="android.widget.LinearLayout" resource-id="com.example:id/row" clickable="true" enabled="true" bounds="[0,200][1080,400]">
+    <node class="android.widget.TextView" resource-id="com.example:id/title" text="Invoice" enabled="true" bounds="[100,250][500,350]"/>
+  </node>
+  <node class="android.widget.Button" resource-id="com.example:id/save" text="Save" clickable="true" enabled="true" bounds="[100,500][300,600]"/>
+</hierarchy>`
+
+func TestTapOnTapParent(t *testing.T) {
+	tests := []struct {
+		name  string
+		sel   flow.Selector
+		wantX int
+		wantY int
+	}{
+		{"non-clickable match taps its row", flow.Selector{ID: "title"}, 540, 300},
+		{"clickable match taps itself", flow.Selector{ID: "save"}, 200, 550},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &MockUIA2Client{sourceData: tapParentHierarchy}
+			driver := New(client, nil, nil)
+
+			result := driver.Execute(&flow.TapOnStep{Selector: tt.sel, TapParent: true})
+			if !result.Success {
+				t.Fatalf("expected success, got: %s", result.Message)
+			}
+			if len(client.clickCalls) != 1 {
+				t.Fatalf("expected one click, got %v", client.clickCalls)
+			}
+			if got := client.clickCalls[0]; got.X != tt.wantX || got.Y != tt.wantY {
+				t.Errorf("expected tap at (%d, %d), got (%d, %d)", tt.wantX, tt.wantY, got.X, got.Y)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package uiautomator2
+
+import (
+	"regexp"
+
+	"github.com/devicelab-dev/maestro-runner/pkg/core"
+	"github.com/devicelab-dev/maestro-runner/pkg/flow"
+)
+
+// documentsUIPackages are the packages of the Storage Access Framework
+// document picker (AOSP and Google builds).
+var documentsUIPackages = map[string]bool{
+	"com.android.documentsui":        true,
+	"com.google.android.documentsui": true,
+}
+
+// fileNamePattern matches text that looks like a file name ("config.yaml").
+var fileNamePattern = regexp.MustCompile(`^[^/\\]+\.[A-Za-z0-9]{1,10}$`)
+
+// isDocumentRowSelector reports whether a tap selector could name a file in
+// the document picker: plain filename text with nothing else to narrow it.
+func isDocumentRowSelector(sel flow.Selector) bool {
+	return sel.Text != "" && fileNamePattern.MatchString(sel.Text) &&
+		sel.ID == "" && sel.Index == "" && !sel.HasStateFilter() && !sel.RequiresPageSource()
+}
+
+// isDocumentPicker reports whether the page source shows the document picker.
+func isDocumentPicker(elements []*ParsedElement) bool {
+	return len(elements) > 0 && documentsUIPackages[elements[0].Package]
+}
+
+// findDocumentRow returns the clickable row of the file named by sel in the
+// document picker. The row's title TextView carries the name but isn't
+// clickable, while the row's preview button is clickable and has the name in
+// its content-desc ("Preview the file config.yaml"), so plain matching taps
+// the preview and Android answers "Can't open file". Text matches win;
+// content-desc is only a fallback (grid view), resolved to the clickable
+// container around the match so a preview button yields its row, and the
+// largest such container is taken.
+func findDocumentRow(elements []*ParsedElement, sel flow.Selector) *ParsedElement {
+	var byDesc []*ParsedElement
+	for _, elem := range elements {
+		if elem.Text != "" && matchesText(sel.Text, elem.Text, "", "") {
+			return GetClickableElement(elem)
+		}
+		if elem.ContentDesc != "" && matchesText(sel.Text, "", elem.ContentDesc, "") {
+			byDesc = append(byDesc, rowContainer(elem))
+		}
+	}
+
+	var row *ParsedElement
+	for _, elem := range byDesc {
+		if row == nil || area(elem.Bounds) > area(row.Bounds) {
+			row = elem
+		}
+	}
+	return row
+}
+
+// rowContainer returns the nearest clickable ancestor of elem, or elem's own
+// clickable element when no ancestor is clickable.
+func rowContainer(elem *ParsedElement) *ParsedElement {
+	if elem.Parent != nil {
+		if row := GetClickableElement(elem.Parent); row.Clickable {
+			return row
+		}
+	}
+	return GetClickableElement(elem)
+}
+
+func area(b core.Bounds) int {
+	return b.Width * b.Height
+}
+
+// findDocumentRowOnce resolves a filename tap against the document picker
+// with a single page source read. ok is false when the picker isn't showing
+// or has no such file, so the caller falls back to normal matching.
+func (d *Driver) findDocumentRowOnce(sel flow.Selector) (info *core.ElementInfo, ok bool) {
+	elements, err := d.pageSourceElements()
+	if err != nil || !isDocumentPicker(elements) {
+		return nil, false
+	}
+	row := findDocumentRow(elements, sel)
+	if row == nil {
+		return nil, false
+	}
+	return &core.ElementInfo{
+		Text:     sel.Text,
+		Bounds:   row.Bounds,
+		Enabled:  row.Enabled,
+		Selected: row.Selected,
+		Visible:  row.Displayed,
+	}, true
+}
@@ -0,0 +1,87 @@
+package uiautomator2
+
+import (
+	"testing"
+
+	"github.com/devicelab-dev/maestro-runner/pkg/flow"
+)
+
+// documentPickerHierarchy is the SAF picker's list view: each row is a
+// clickable item_root holding a non-clickable title and a clickable preview
+// button whose content-desc also names the file.
+const documentPickerHierarchy = `<?xml version="1.0" encoding="UTF-8"?>
+<hierarchy rotation="0">
+  <node class="android.widget.FrameLayout" package="com.google.android.documentsui" bounds="[0,0][1080,2400]">
+    <node class="androidx.recyclerview.widget.RecyclerView" package="com.google.android.documentsui" resource-id="com.google.android.documentsui:id/dir_list" scrollable="true" bounds="[0,300][1080,2400]">
+      <node class="android.widget.LinearLayout" package="com.google.android.documentsui" resource-id="com.google.android.documentsui:id/item_root" clickable="true" enabled="true" bounds="[0,300][1080,500]">
+        <node class="android.widget.ImageView" package="com.google.android.documentsui" resource-id="com.google.android.documentsui:id/icon_mime" bounds="[40,340][160,460]"/>
+        <node class="android.widget.TextView" package="com.google.android.documentsui" resource-id="android:id/title" text="config.yaml" bounds="[200,330][800,400]"/>
+        <node class="android.widget.TextView" package="com.google.android.documentsui" resource-id="com.google.android.documentsui:id/summary" text="1.2 kB" bounds="[200,400][800,470]"/>
+        <node class="android.widget.FrameLayout" package="com.google.android.documentsui" resource-id="com.google.android.documentsui:id/preview_icon" content-desc="Preview the file config.yaml" clickable="true" bounds="[900,340][1040,460]"/>
+      </node>
+      <node class="android.widget.LinearLayout" package="com.google.android.documentsui" resource-id="com.google.android.documentsui:id/item_root" clickable="true" enabled="true" bounds="[0,500][1080,700]">
+        <node class="android.widget.TextView" package="com.google.android.documentsui" resource-id="android:id/title" text="notes.txt" bounds="[200,530][800,600]"/>
+        <node class="android.widget.FrameLayout" package="com.google.android.documentsui" resource-id="com.google.android.documentsui:id/preview_icon" content-desc="Preview the file notes.txt" clickable="true" bounds="[900,540][1040,660]"/>
+      </node>
+    </node>
+  </node>
+</hierarchy>`
+
+func TestTapOnFileInDocumentPickerTapsRow(t *testing.T) {
+	client := &MockUIA2Client{sourceData: documentPickerHierarchy}
+	driver := New(client, nil, nil)
+
+	result := driver.Execute(&flow.TapOnStep{Selector: flow.Selector{Text: "config.yaml"}})
+	if !result.Success {
+		t.Fatalf("expected success, got: %s", result.Message)
+	}
+
+	// Center of the config.yaml item_root, not its preview button
+	if len(client.clickCalls) != 1 {
+		t.Fatalf("expected one click, got %v", client.clickCalls)
+	}
+	if got := client.clickCalls[0]; got.X != 540 || got.Y != 400 {
+		t.Errorf("expected tap on the row center (540, 400), got (%d, %d)", got.X, got.Y)
+	}
+}
+
+func TestFindDocumentRow(t *testing.T) {
+	elements, err := ParsePageSource(documentPickerHierarchy)
+	if err != nil {
+		t.Fatalf("ParsePageSource failed: %v", err)
+	}
+	if !isDocumentPicker(elements) {
+		t.Fatal("expected the fixture to be recognized as the document picker")
+	}
+
+	row := findDocumentRow(elements, flow.Selector{Text: "notes.txt"})
+	if row == nil || row.Bounds.Y != 500 {
+		t.Fatalf("expected the notes.txt row, got %+v", row)
+	}
+
+	// Grid view: no title text, only content-desc on the tile and preview
+	for _, elem := range elements {
+		elem.Text = ""
+	}
+	row = findDocumentRow(elements, flow.Selector{Text: "config.yaml"})
+	if row == nil || row.Bounds.Width != 1080 {
+		t.Errorf("expected the content-desc fallback to pick the row, got %+v", row)
+	}
+}
+
+func TestIsDocumentRowSelector(t *testing.T) {
+	tests := []struct {
+		sel  flow.Selector
+		want bool
+	}{
+		{flow.Selector{Text: "config.yaml"}, true},
+		{flow.Selector{Text: "Login"}, false},
+		{flow.Selector{Text: "config.yaml", ID: "title"}, false},
+		{flow.Selector{Text: "config.yaml", Index: "1"}, false},
+	}
+	for _, tt := range tests {
+		if got := isDocumentRowSelector(tt.sel); got != tt.want {
+			t.Errorf("isDocumentRowSelector(%+v) = %v, want %v", tt.sel, got, tt.want)
+		}
+	}
+}
@@ -3,8 +3,10 @@ package uiautomator2
 
 import (
 	"context"
+	"encoding/xml"
+	"errors"
 	"fmt"
-	"strconv"
+	"io"
 	"strings"
 	"time"
 
@@ -36,7 +38,10 @@ type UIA2Client interface {
 	LongClick(x, y, durationMs int) error
 	LongClickElement(elementID string, durationMs int) error
 	ScrollInArea(area uiautomator2.RectModel, direction string, percent float64, speed int) error
+	Swipe(elementID, direction string, percent float64, speed int) error
 	SwipeInArea(area uiautomator2.RectModel, direction string, percent float64, speed int) error
+	PointerPath(points []uiautomator2.PointModel, durationsMs []int) error
+	ParallelSwipe(tracks [][2]uiautomator2.PointModel, durationMs int) error
 
 	// Navigation
 	Back() error
@@ -55,6 +60,10 @@ type UIA2Client interface {
 
 	// Settings
 	SetAppiumSettings(settings map[string]interface{}) error
+
+	// Contexts
+	Contexts() ([]string, error)
+	SetContext(name string) error
 }
 
 // Driver implements core.Driver using UIAutomator2.
@@ -63,18 +72,31 @@ type Driver struct {
 	info   *core.PlatformInfo
 	device ShellExecutor // for ADB commands (launchApp, stopApp, clearState)
 
+	recordingPath string  // device path of the screenrecord started by startRecording
+	recordingCrop float64 // share of the height to crop off the top of the saved recording (0 = none)
+	logcatSince   string  // device time of StartLogCapture, for logcat -T
+
 	// Timeouts (0 = use defaults)
 	findTimeout         int // ms, for required elements
 	optionalFindTimeout int // ms, for optional elements
+
+	// Parsed page source reused by lookups within a step (see SetSourceCacheTTL)
+	sourceTTL time.Duration
+	source    []*ParsedElement
+	sourceAt  time.Time
 }
 
 // New creates a new UIAutomator2 driver.
 func New(client UIA2Client, info *core.PlatformInfo, device ShellExecutor) *Driver {
-	return &Driver{
-		client: client,
-		info:   info,
-		device: device,
+	d := &Driver{
+		info:      info,
+		sourceTTL: DefaultSourceCacheTTL,
+	}
+	d.client = uiChangeClient{UIA2Client: client, d: d}
+	if device != nil {
+		d.device = uiChangeDevice{ShellExecutor: device, d: d}
 	}
+	return d
 }
 
 // SetFindTimeout sets the timeout for finding required elements.
@@ -99,6 +121,7 @@ func (d *Driver) SetWaitForIdleTimeout(ms int) error {
 // Execute runs a single step and returns the result.
 func (d *Driver) Execute(step flow.Step) *core.CommandResult {
 	start := time.Now()
+	d.invalidateSource()
 
 	var result *core.CommandResult
 	switch s := step.(type) {
@@ -111,16 +134,34 @@ func (d *Driver) Execute(step flow.Step) *core.CommandResult {
 		result = d.longPressOn(s)
 	case *flow.TapOnPointStep:
 		result = d.tapOnPoint(s)
+	case *flow.TapSequenceStep:
+		result = d.tapSequence(s)
 
 	// Assert commands
 	case *flow.AssertVisibleStep:
 		result = d.assertVisible(s)
 	case *flow.AssertNotVisibleStep:
 		result = d.assertNotVisible(s)
+	case *flow.AssertFileExistsStep:
+		result = d.assertFileExists(s)
+	case *flow.AssertResourceStep:
+		result = d.assertResource(s)
+	case *flow.AssertSortedStep:
+		result = d.assertSorted(s)
+	case *flow.AssertShareTargetStep:
+		result = d.assertShareTarget(s)
+	case *flow.AssertAccessibleStep:
+		result = d.assertAccessible(s)
+	case *flow.AssertTextNotContainsStep:
+		result = d.assertTextNotContains(s)
+	case *flow.AssertFieldValueStep:
+		result = d.assertFieldValue(s)
 
 	// Input commands
 	case *flow.InputTextStep:
 		result = d.inputText(s)
+	case *flow.SearchStep:
+		result = d.search(s)
 	case *flow.EraseTextStep:
 		result = d.eraseText(s)
 	case *flow.HideKeyboardStep:
@@ -133,8 +174,14 @@ func (d *Driver) Execute(step flow.Step) *core.CommandResult {
 		result = d.scroll(s)
 	case *flow.ScrollUntilVisibleStep:
 		result = d.scrollUntilVisible(s)
+	case *flow.ScrollToPositionStep:
+		result = d.scrollToPosition(s)
 	case *flow.SwipeStep:
 		result = d.swipe(s)
+	case *flow.TwoFingerSwipeStep:
+		result = d.twoFingerSwipe(s)
+	case *flow.GesturePathStep:
+		result = d.gesturePath(s)
 
 	// Navigation commands
 	case *flow.BackStep:
@@ -151,6 +198,10 @@ func (d *Driver) Execute(step flow.Step) *core.CommandResult {
 		result = d.killApp(s)
 	case *flow.ClearStateStep:
 		result = d.clearState(s)
+	case *flow.SetAppLocaleStep:
+		result = d.setAppLocale(s)
+	case *flow.SetPreferenceStep:
+		result = d.setPreference(s)
 
 	// Clipboard
 	case *flow.CopyTextFromStep:
@@ -175,12 +226,26 @@ func (d *Driver) Execute(step flow.Step) *core.CommandResult {
 		result = d.toggleAirplaneMode(s)
 	case *flow.TravelStep:
 		result = d.travel(s)
+	case *flow.ClearNotificationsStep:
+		result = d.clearNotifications(s)
+	case *flow.EnsureUnlockedStep:
+		result = d.ensureUnlocked(s)
+	case *flow.SetAppearanceStep:
+		result = d.setAppearance(s)
 
 	// Wait commands
 	case *flow.WaitUntilStep:
 		result = d.waitUntil(s)
+	case *flow.ExtendedWaitUntilStep:
+		result = d.waitUntilAll(s)
 	case *flow.WaitForAnimationToEndStep:
 		result = d.waitForAnimationToEnd(s)
+	case *flow.WaitForDownloadStep:
+		result = d.waitForDownload(s)
+	case *flow.WaitForInstallStep:
+		result = d.waitForInstall(s)
+	case *flow.WaitForTextStep:
+		result = d.waitForText(s)
 
 	// Media
 	case *flow.TakeScreenshotStep:
@@ -262,7 +327,12 @@ func (d *Driver) findElementFast(sel flow.Selector, optional bool, stepTimeoutMs
 //  4. If text doesn't exist → keep polling
 //
 // This handles React Native pattern where text nodes aren't clickable but parent containers are.
+// Filenames in the Android document picker resolve to their row first (see findDocumentRow).
 func (d *Driver) findElementForTap(sel flow.Selector, optional bool, stepTimeoutMs int) (*uiautomator2.Element, *core.ElementInfo, error) {
+	if err := sel.Validate(); err != nil {
+		return nil, nil, err
+	}
+
 	// For relative selectors (below, above, etc.), use page source which handles them correctly
 	if sel.HasRelativeSelector() {
 		timeout := d.calculateTimeout(optional, stepTimeoutMs)
@@ -271,13 +341,14 @@ func (d *Driver) findElementForTap(sel flow.Selector, optional bool, stepTimeout
 		return d.findElementRelativeWithContext(ctx, sel)
 	}
 
-	// For ID-based selectors, use standard UiAutomator approach (IDs are usually unique)
-	if sel.ID != "" {
+	// Structural filters and textRegex resolve via page source
+	if sel.RequiresPageSource() {
 		return d.findElementWithOptions(sel, optional, stepTimeoutMs, true, false)
 	}
 
-	// For text-based selectors, use smart fallback strategy
-	if sel.Text != "" {
+	// For text and ID selectors, use smart fallback strategy
+	// (a non-clickable match such as an icon ImageView resolves to its clickable ancestor)
+	if sel.Text != "" || sel.ID != "" {
 		timeout := d.calculateTimeout(optional, stepTimeoutMs)
 		ctx, cancel := context.WithTimeout(context.Background(), timeout)
 		defer cancel()
@@ -289,8 +360,31 @@ func (d *Driver) findElementForTap(sel flow.Selector, optional bool, stepTimeout
 	return d.findElementWithOptions(sel, optional, stepTimeoutMs, true, false)
 }
 
+// findTapParent finds an element for tapOn with tapParent. The match is
+// always resolved from the page source and replaced by its nearest clickable
+// ancestor, so list rows whose text node isn't clickable are tapped on the
+// row; a match that is clickable itself is kept.
+func (d *Driver) findTapParent(sel flow.Selector, optional bool, stepTimeoutMs int) (*core.ElementInfo, error) {
+	if err := sel.Validate(); err != nil {
+		return nil, err
+	}
+
+	timeout := d.calculateTimeout(optional, stepTimeoutMs)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var info *core.ElementInfo
+	var err error
+	if sel.HasRelativeSelector() {
+		_, info, err = d.findElementRelativeWithContext(ctx, sel)
+	} else {
+		_, info, err = d.findElementByPageSourceWithContext(ctx, sel)
+	}
+	return info, err
+}
+
 // findElementForTapWithContext implements the smart tap element finding strategy.
-// Tries clickable UiAutomator first, falls back to page source if text exists but isn't clickable.
+// Tries clickable UiAutomator first, falls back to page source if the match exists but isn't clickable.
 func (d *Driver) findElementForTapWithContext(ctx context.Context, sel flow.Selector) (*uiautomator2.Element, *core.ElementInfo, error) {
 	// Build clickable-only strategies
 	clickableStrategies, err := buildClickableOnlyStrategies(sel)
@@ -304,6 +398,7 @@ func (d *Driver) findElementForTapWithContext(ctx context.Context, sel flow.Sele
 		return nil, nil, err
 	}
 
+	docRow := isDocumentRowSelector(sel)
 	var lastErr error
 	for {
 		select {
@@ -313,16 +408,24 @@ func (d *Driver) findElementForTapWithContext(ctx context.Context, sel flow.Sele
 			}
 			return nil, nil, fmt.Errorf("element '%s' not found: %w", sel.Describe(), ctx.Err())
 		default:
+			// The document picker's preview button is clickable and names the
+			// file too, so resolve filenames to their row before the fast path
+			if docRow {
+				if info, ok := d.findDocumentRowOnce(sel); ok {
+					return nil, info, nil
+				}
+			}
+
 			// Step 1: Try clickable strategies first (fast path)
 			elem, info, err := d.tryFindElement(clickableStrategies)
 			if err == nil {
 				return elem, info, nil
 			}
 
-			// Step 2: Check if text exists at all (via UiAutomator)
+			// Step 2: Check if the element exists at all (via UiAutomator)
 			_, _, existsErr := d.tryFindElementFast(textExistsStrategies)
 			if existsErr != nil {
-				// Text not found via UiAutomator - try page source as fallback
+				// Not found via UiAutomator - try page source as fallback
 				// (handles hint text, content-desc, etc. that UiAutomator misses)
 				_, info, err = d.findElementByPageSourceOnce(sel)
 				if err == nil {
@@ -333,7 +436,7 @@ func (d *Driver) findElementForTapWithContext(ctx context.Context, sel flow.Sele
 				continue
 			}
 
-			// Step 3: Text exists but not clickable → use page source with parent lookup
+			// Step 3: Element exists but not clickable → use page source with parent lookup
 			_, info, err = d.findElementByPageSourceOnce(sel)
 			if err == nil {
 				return nil, info, nil
@@ -348,6 +451,13 @@ func buildClickableOnlyStrategies(sel flow.Selector) ([]LocatorStrategy, error)
 	var strategies []LocatorStrategy
 	stateFilters := buildStateFilters(sel)
 
+	if sel.ID != "" {
+		strategies = append(strategies, LocatorStrategy{
+			Strategy: uiautomator2.StrategyUIAutomator,
+			Value:    resourceIDSelector(sel.ID) + `.clickable(true)` + stateFilters,
+		})
+	}
+
 	if sel.Text != "" {
 		if looksLikeRegex(sel.Text) {
 			pattern := "(?is)" + escapeUIAutomatorString(sel.Text)
@@ -373,7 +483,7 @@ func buildClickableOnlyStrategies(sel flow.Selector) ([]LocatorStrategy, error)
 	}
 
 	if len(strategies) == 0 {
-		return nil, fmt.Errorf("no text selector specified")
+		return nil, fmt.Errorf("no text or id selector specified")
 	}
 
 	return strategies, nil
@@ -382,6 +492,10 @@ func buildClickableOnlyStrategies(sel flow.Selector) ([]LocatorStrategy, error)
 // findElementWithOptions is the internal implementation with clickable preference option.
 // Set fastMode=true for visibility checks (1 HTTP call), false for full info (3 HTTP calls).
 func (d *Driver) findElementWithOptions(sel flow.Selector, optional bool, stepTimeoutMs int, preferClickable bool, fastMode bool) (*uiautomator2.Element, *core.ElementInfo, error) {
+	if err := sel.Validate(); err != nil {
+		return nil, nil, err
+	}
+
 	timeout := d.calculateTimeout(optional, stepTimeoutMs)
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
@@ -398,8 +512,8 @@ func (d *Driver) findElementWithContext(ctx context.Context, sel flow.Selector,
 		return d.findElementRelativeWithContext(ctx, sel)
 	}
 
-	// Handle size selectors via page source (bounds calculation required)
-	if sel.Width > 0 || sel.Height > 0 {
+	// Handle size, structural and textRegex selectors via page source (bounds/tree required)
+	if sel.Width > 0 || sel.Height > 0 || sel.RequiresPageSource() {
 		return d.findElementByPageSourceWithContext(ctx, sel)
 	}
 
@@ -472,13 +586,17 @@ func (d *Driver) calculateTimeout(optional bool, stepTimeoutMs int) time.Duratio
 // findElementOnce finds an element with a single attempt (no polling).
 // Used by waitUntil which has its own polling loop with context.
 func (d *Driver) findElementOnce(sel flow.Selector) (*uiautomator2.Element, *core.ElementInfo, error) {
+	if err := sel.Validate(); err != nil {
+		return nil, nil, err
+	}
+
 	// Handle relative selectors with single page source fetch
 	if sel.HasRelativeSelector() {
 		return d.findElementRelativeOnce(sel)
 	}
 
-	// Handle size selectors with single page source fetch
-	if sel.Width > 0 || sel.Height > 0 {
+	// Handle size, structural and textRegex selectors with single page source fetch
+	if sel.Width > 0 || sel.Height > 0 || sel.RequiresPageSource() {
 		return d.findElementByPageSourceOnce(sel)
 	}
 
@@ -578,6 +696,22 @@ const (
 	filterInsideOf
 )
 
+// positional reports whether the filter selects by direction from the anchor.
+func (t relativeFilterType) positional() bool {
+	return t == filterBelow || t == filterAbove || t == filterLeftOf || t == filterRightOf
+}
+
+// pickRelative returns the candidates in index order and the default match.
+// A positional filter's candidates are already nearest first; other relative
+// filters prefer clickable elements and the deepest match.
+func pickRelative(candidates []*ParsedElement, filterType relativeFilterType) ([]*ParsedElement, *ParsedElement) {
+	if filterType.positional() {
+		return candidates, candidates[0]
+	}
+	candidates = SortClickableFirst(candidates)
+	return candidates, DeepestMatchingElement(candidates)
+}
+
 // getRelativeFilter returns the anchor selector and filter type from a selector
 func getRelativeFilter(sel flow.Selector) (*flow.Selector, relativeFilterType) {
 	switch {
@@ -641,6 +775,7 @@ func (d *Driver) findElementRelativeWithContext(ctx context.Context, sel flow.Se
 			}
 			lastErr = err
 			// HTTP round-trip is natural rate limit, no sleep needed
+			d.invalidateSource()
 		}
 	}
 }
@@ -664,6 +799,7 @@ func (d *Driver) resolveRelativeSelector(sel flow.Selector) (*core.ElementInfo,
 	// Build base selector for filtering
 	baseSel := flow.Selector{
 		Text:      sel.Text,
+		TextRegex: sel.TextRegex,
 		ID:        sel.ID,
 		Width:     sel.Width,
 		Height:    sel.Height,
@@ -674,21 +810,15 @@ func (d *Driver) resolveRelativeSelector(sel flow.Selector) (*core.ElementInfo,
 		Checked:   sel.Checked,
 	}
 
-	// Get page source
-	pageSource, err := d.client.Source()
+	// Get and parse page source
+	allElements, err := d.pageSourceElements()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get page source: %w", err)
-	}
-
-	// Parse all elements
-	allElements, err := ParsePageSource(pageSource)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse page source: %w", err)
+		return nil, err
 	}
 
 	// Filter by base selector to get target candidates
 	var candidates []*ParsedElement
-	if baseSel.Text != "" || baseSel.ID != "" || baseSel.Width > 0 || baseSel.Height > 0 {
+	if baseSel.Text != "" || baseSel.TextRegex != "" || baseSel.ID != "" || baseSel.Width > 0 || baseSel.Height > 0 || baseSel.HasStateFilter() {
 		candidates = FilterBySelector(allElements, baseSel)
 	} else {
 		candidates = allElements
@@ -740,24 +870,15 @@ func (d *Driver) resolveRelativeSelector(sel flow.Selector) (*core.ElementInfo,
 		return nil, fmt.Errorf("no elements match relative criteria")
 	}
 
-	// Prioritize clickable elements
-	candidates = SortClickableFirst(candidates)
-
-	// Apply index if specified, otherwise use deepest matching element
-	var selected *ParsedElement
+	candidates, selected := pickRelative(candidates, filterType)
 	if sel.Index != "" {
-		idx := 0
-		if i, err := strconv.Atoi(sel.Index); err == nil {
-			if i < 0 {
-				i = len(candidates) + i
-			}
-			if i >= 0 && i < len(candidates) {
-				idx = i
-			}
+		idx, err := sel.IndexValue()
+		if err != nil {
+			return nil, err
+		}
+		if selected, err = SelectByIndex(candidates, idx); err != nil {
+			return nil, err
 		}
-		selected = candidates[idx]
-	} else {
-		selected = DeepestMatchingElement(candidates)
 	}
 
 	// If element isn't clickable, try to find a clickable parent
@@ -765,10 +886,13 @@ func (d *Driver) resolveRelativeSelector(sel flow.Selector) (*core.ElementInfo,
 	clickableElem := GetClickableElement(selected)
 
 	return &core.ElementInfo{
-		Text:    selected.Text,
-		Bounds:  clickableElem.Bounds,
-		Enabled: selected.Enabled,
-		Visible: selected.Displayed,
+		Text:     selected.Text,
+		Bounds:   clickableElem.Bounds,
+		Enabled:  selected.Enabled,
+		Checked:  selected.Checked,
+		Selected: selected.Selected,
+		Focused:  selected.Focused,
+		Visible:  selected.Displayed,
 	}, nil
 }
 
@@ -781,6 +905,7 @@ func (d *Driver) findElementRelativeWithElements(sel flow.Selector, allElements
 	// Build base selector for filtering (without the relative part)
 	baseSel := flow.Selector{
 		Text:      sel.Text,
+		TextRegex: sel.TextRegex,
 		ID:        sel.ID,
 		Width:     sel.Width,
 		Height:    sel.Height,
@@ -793,7 +918,7 @@ func (d *Driver) findElementRelativeWithElements(sel flow.Selector, allElements
 
 	// Filter by base selector to get target candidates
 	var candidates []*ParsedElement
-	if baseSel.Text != "" || baseSel.ID != "" || baseSel.Width > 0 || baseSel.Height > 0 {
+	if baseSel.Text != "" || baseSel.TextRegex != "" || baseSel.ID != "" || baseSel.Width > 0 || baseSel.Height > 0 || baseSel.HasStateFilter() {
 		candidates = FilterBySelector(allElements, baseSel)
 	} else {
 		candidates = allElements
@@ -844,24 +969,15 @@ func (d *Driver) findElementRelativeWithElements(sel flow.Selector, allElements
 		return nil, nil, fmt.Errorf("no elements match relative criteria")
 	}
 
-	// Prioritize clickable elements
-	candidates = SortClickableFirst(candidates)
-
-	// Apply index if specified, otherwise use deepest matching element
-	var selected *ParsedElement
+	candidates, selected := pickRelative(candidates, filterType)
 	if sel.Index != "" {
-		idx := 0
-		if i, err := strconv.Atoi(sel.Index); err == nil {
-			if i < 0 {
-				i = len(candidates) + i
-			}
-			if i >= 0 && i < len(candidates) {
-				idx = i
-			}
+		idx, err := sel.IndexValue()
+		if err != nil {
+			return nil, nil, err
+		}
+		if selected, err = SelectByIndex(candidates, idx); err != nil {
+			return nil, nil, err
 		}
-		selected = candidates[idx]
-	} else {
-		selected = DeepestMatchingElement(candidates)
 	}
 
 	// If element isn't clickable, try to find a clickable parent
@@ -869,26 +985,73 @@ func (d *Driver) findElementRelativeWithElements(sel flow.Selector, allElements
 	clickableElem := GetClickableElement(selected)
 
 	info := &core.ElementInfo{
-		Text:    selected.Text,
-		Bounds:  clickableElem.Bounds,
-		Enabled: selected.Enabled,
-		Visible: selected.Displayed,
+		Text:     selected.Text,
+		Bounds:   clickableElem.Bounds,
+		Enabled:  selected.Enabled,
+		Checked:  selected.Checked,
+		Selected: selected.Selected,
+		Focused:  selected.Focused,
+		Visible:  selected.Displayed,
 	}
 
 	return nil, info, nil
 }
 
-// findElementByPageSourceOnce performs a single page source search without polling.
-// Used as a fallback when UiAutomator selectors don't find the element (e.g., hint text).
-func (d *Driver) findElementByPageSourceOnce(sel flow.Selector) (*uiautomator2.Element, *core.ElementInfo, error) {
-	pageSource, err := d.client.Source()
+// sourceParseRetries is how many times a malformed page source is refetched
+// before the parse error is returned. /source occasionally comes back truncated
+// while the hierarchy is changing.
+const sourceParseRetries = 2
+
+// pageSourceElements fetches and parses the page source, refetching when the XML
+// is malformed. Fetch errors and other parse failures are returned immediately.
+// A source parsed less than the cache TTL ago is reused, so several lookups on
+// one screen cost a single /source request.
+func (d *Driver) pageSourceElements() ([]*ParsedElement, error) {
+	if elements := d.cachedSource(); elements != nil {
+		return elements, nil
+	}
+	elements, err := d.fetchPageSourceElements()
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to get page source: %w", err)
+		return nil, err
 	}
+	d.source, d.sourceAt = elements, time.Now()
+	return elements, nil
+}
 
-	allElements, err := ParsePageSource(pageSource)
+// fetchPageSourceElements does the uncached work of pageSourceElements.
+func (d *Driver) fetchPageSourceElements() ([]*ParsedElement, error) {
+	var parseErr error
+	for attempt := 0; attempt <= sourceParseRetries; attempt++ {
+		pageSource, err := d.client.Source()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get page source: %w", err)
+		}
+
+		elements, err := ParsePageSource(pageSource)
+		if err == nil {
+			return elements, nil
+		}
+		if !isXMLSyntaxError(err) {
+			return nil, fmt.Errorf("failed to parse page source: %w", err)
+		}
+		parseErr = err
+	}
+	return nil, fmt.Errorf("failed to parse page source after %d attempts: %w", sourceParseRetries+1, parseErr)
+}
+
+// isXMLSyntaxError reports whether err comes from malformed or truncated XML,
+// as opposed to a well-formed document with no usable hierarchy.
+func isXMLSyntaxError(err error) bool {
+	var syntaxErr *xml.SyntaxError
+	return errors.As(err, &syntaxErr) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// findElementByPageSourceOnce performs a single page source search without polling.
+// Used as a fallback when UiAutomator selectors don't find the element (e.g., hint text).
+func (d *Driver) findElementByPageSourceOnce(sel flow.Selector) (*uiautomator2.Element, *core.ElementInfo, error) {
+	allElements, err := d.pageSourceElements()
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to parse page source: %w", err)
+		return nil, nil, err
 	}
 
 	candidates := FilterBySelector(allElements, sel)
@@ -899,6 +1062,15 @@ func (d *Driver) findElementByPageSourceOnce(sel flow.Selector) (*uiautomator2.E
 		if selected == nil {
 			selected = candidates[0]
 		}
+		if sel.Index != "" {
+			idx, err := sel.IndexValue()
+			if err != nil {
+				return nil, nil, err
+			}
+			if selected, err = SelectByIndex(candidates, idx); err != nil {
+				return nil, nil, err
+			}
+		}
 
 		// If element isn't clickable, try to find a clickable parent
 		// This handles React Native pattern where text nodes aren't clickable but containers are
@@ -912,8 +1084,11 @@ func (d *Driver) findElementByPageSourceOnce(sel flow.Selector) (*uiautomator2.E
 				Width:  clickableElem.Bounds.Width,
 				Height: clickableElem.Bounds.Height,
 			},
-			Enabled: selected.Enabled,
-			Visible: selected.Displayed,
+			Enabled:  selected.Enabled,
+			Checked:  selected.Checked,
+			Selected: selected.Selected,
+			Focused:  selected.Focused,
+			Visible:  selected.Displayed,
 		}
 		return nil, info, nil
 	}
@@ -939,6 +1114,7 @@ func (d *Driver) findElementByPageSourceWithContext(ctx context.Context, sel flo
 			}
 			lastErr = err
 			// HTTP round-trip is natural rate limit, no sleep needed
+			d.invalidateSource()
 		}
 	}
 }
@@ -946,14 +1122,9 @@ func (d *Driver) findElementByPageSourceWithContext(ctx context.Context, sel flo
 // findElementByPageSourceOnceInternal performs a single page source search.
 // Returns ElementInfo on success, error on failure.
 func (d *Driver) findElementByPageSourceOnceInternal(sel flow.Selector) (*core.ElementInfo, error) {
-	pageSource, err := d.client.Source()
+	allElements, err := d.pageSourceElements()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get page source: %w", err)
-	}
-
-	allElements, err := ParsePageSource(pageSource)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse page source: %w", err)
+		return nil, err
 	}
 
 	candidates := FilterBySelector(allElements, sel)
@@ -967,6 +1138,15 @@ func (d *Driver) findElementByPageSourceOnceInternal(sel flow.Selector) (*core.E
 	if selected == nil {
 		selected = candidates[0]
 	}
+	if sel.Index != "" {
+		idx, err := sel.IndexValue()
+		if err != nil {
+			return nil, err
+		}
+		if selected, err = SelectByIndex(candidates, idx); err != nil {
+			return nil, err
+		}
+	}
 
 	// If element isn't clickable, try to find a clickable parent
 	// This handles React Native pattern where text nodes aren't clickable but containers are
@@ -980,11 +1160,24 @@ func (d *Driver) findElementByPageSourceOnceInternal(sel flow.Selector) (*core.E
 			Width:  clickableElem.Bounds.Width,
 			Height: clickableElem.Bounds.Height,
 		},
-		Enabled: selected.Enabled,
-		Visible: selected.Displayed,
+		Enabled:  selected.Enabled,
+		Checked:  selected.Checked,
+		Selected: selected.Selected,
+		Focused:  selected.Focused,
+		Visible:  selected.Displayed,
 	}, nil
 }
 
+// resourceIDSelector builds a UiSelector for a resource-id.
+// Fully-qualified IDs ("com.app:id/icon") match exactly; bare names match
+// anywhere in the resource-id, so "icon" also finds "com.app:id/icon".
+func resourceIDSelector(id string) string {
+	if isQualifiedResourceID(id) {
+		return `new UiSelector().resourceId("` + escapeUIAutomatorString(id) + `")`
+	}
+	return `new UiSelector().resourceIdMatches(".*` + escapeUIAutomator(id) + `.*")`
+}
+
 // LocatorStrategy represents a single locator strategy with its value.
 type LocatorStrategy struct {
 	Strategy string
@@ -1019,19 +1212,19 @@ func buildSelectorsWithOptions(sel flow.Selector, timeoutMs int, preferClickable
 	var strategies []LocatorStrategy
 	stateFilters := buildStateFilters(sel)
 
-	// ID-based selector - use resourceIdMatches for partial matching
+	// ID-based selector - exact for fully-qualified IDs, partial otherwise
 	if sel.ID != "" {
-		escaped := escapeUIAutomator(sel.ID)
+		idSelector := resourceIDSelector(sel.ID)
 		if preferClickable {
 			// Try clickable first for tap commands
 			strategies = append(strategies, LocatorStrategy{
 				Strategy: uiautomator2.StrategyUIAutomator,
-				Value:    `new UiSelector().resourceIdMatches(".*` + escaped + `.*").clickable(true)` + stateFilters,
+				Value:    idSelector + `.clickable(true)` + stateFilters,
 			})
 		}
 		strategies = append(strategies, LocatorStrategy{
 			Strategy: uiautomator2.StrategyUIAutomator,
-			Value:    `new UiSelector().resourceIdMatches(".*` + escaped + `.*")` + stateFilters,
+			Value:    idSelector + stateFilters,
 		})
 	}
 
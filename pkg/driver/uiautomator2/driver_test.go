@@ -32,18 +32,27 @@ type MockUIA2Client struct {
 	activeElementFunc  func() (*uiautomator2.Element, error)
 	sourceFunc         func() (string, error)
 	sendKeyActionsFunc func(text string) error
+	screenshotFunc     func() ([]byte, error)
 
 	// Tracking
-	clickCalls          []struct{ X, Y int }
-	doubleClickCalls    []struct{ X, Y int }
-	longClickCalls      []struct{ X, Y, Duration int }
-	scrollCalls         []uiautomator2.RectModel
-	swipeCalls          []uiautomator2.RectModel
-	pressKeyCalls       []int
-	backCalls           int
-	hideKeyboardCalls   int
-	setClipboardCalls   []string
-	setOrientationCalls []string
+	clickCalls           []struct{ X, Y int }
+	doubleClickCalls     []struct{ X, Y int }
+	longClickCalls       []struct{ X, Y, Duration int }
+	scrollCalls          []uiautomator2.RectModel
+	scrollPercents       []float64
+	scrollSpeeds         []int
+	swipeCalls           []uiautomator2.RectModel
+	elementSwipeCalls    []string
+	pointerPathCalls     [][]uiautomator2.PointModel
+	pointerPathDurations [][]int
+	parallelSwipeCalls   [][][2]uiautomator2.PointModel
+	parallelSwipeMs      []int
+	pressKeyCalls        []int
+	backCalls            int
+	hideKeyboardCalls    int
+	setClipboardCalls    []string
+	setOrientationCalls  []string
+	setContextCalls      []string
 
 	// Return values
 	screenshotData    []byte
@@ -64,6 +73,9 @@ type MockUIA2Client struct {
 	backErr           error
 	hideKeyboardErr   error
 	setClipboardErr   error
+	contextsData      []string
+	contextsErr       error
+	setContextErr     error
 }
 
 func (m *MockUIA2Client) FindElement(strategy, selector string) (*uiautomator2.Element, error) {
@@ -105,14 +117,33 @@ func (m *MockUIA2Client) LongClickElement(elementID string, durationMs int) erro
 
 func (m *MockUIA2Client) ScrollInArea(area uiautomator2.RectModel, direction string, percent float64, speed int) error {
 	m.scrollCalls = append(m.scrollCalls, area)
+	m.scrollPercents = append(m.scrollPercents, percent)
+	m.scrollSpeeds = append(m.scrollSpeeds, speed)
 	return m.scrollErr
 }
 
+func (m *MockUIA2Client) Swipe(elementID, direction string, percent float64, speed int) error {
+	m.elementSwipeCalls = append(m.elementSwipeCalls, elementID)
+	return m.swipeErr
+}
+
 func (m *MockUIA2Client) SwipeInArea(area uiautomator2.RectModel, direction string, percent float64, speed int) error {
 	m.swipeCalls = append(m.swipeCalls, area)
 	return m.swipeErr
 }
 
+func (m *MockUIA2Client) PointerPath(points []uiautomator2.PointModel, durationsMs []int) error {
+	m.pointerPathCalls = append(m.pointerPathCalls, points)
+	m.pointerPathDurations = append(m.pointerPathDurations, durationsMs)
+	return nil
+}
+
+func (m *MockUIA2Client) ParallelSwipe(tracks [][2]uiautomator2.PointModel, durationMs int) error {
+	m.parallelSwipeCalls = append(m.parallelSwipeCalls, tracks)
+	m.parallelSwipeMs = append(m.parallelSwipeMs, durationMs)
+	return nil
+}
+
 func (m *MockUIA2Client) Back() error {
 	m.backCalls++
 	return m.backErr
@@ -136,6 +167,9 @@ func (m *MockUIA2Client) SendKeyActions(text string) error {
 }
 
 func (m *MockUIA2Client) Screenshot() ([]byte, error) {
+	if m.screenshotFunc != nil {
+		return m.screenshotFunc()
+	}
 	return m.screenshotData, m.screenshotErr
 }
 
@@ -178,6 +212,15 @@ func (m *MockUIA2Client) SetAppiumSettings(settings map[string]interface{}) erro
 	return nil
 }
 
+func (m *MockUIA2Client) Contexts() ([]string, error) {
+	return m.contextsData, m.contextsErr
+}
+
+func (m *MockUIA2Client) SetContext(name string) error {
+	m.setContextCalls = append(m.setContextCalls, name)
+	return m.setContextErr
+}
+
 // ============================================================================
 // MockShellExecutor
 // ============================================================================
@@ -503,7 +546,7 @@ func TestDriverNew(t *testing.T) {
 	if driver == nil {
 		t.Fatal("expected driver, got nil")
 	}
-	if driver.device != mock {
+	if dev, ok := driver.device.(uiChangeDevice); !ok || dev.ShellExecutor != mock {
 		t.Error("device not set correctly")
 	}
 }
@@ -1277,6 +1320,46 @@ func TestLongPressOnWithElement(t *testing.T) {
 	}
 }
 
+func TestLongPressOnDurationAndPoint(t *testing.T) {
+	tests := []struct {
+		name         string
+		step         *flow.LongPressOnStep
+		wantX, wantY int
+		wantDuration int
+		wantMsg      string
+	}{
+		{"default duration at center", &flow.LongPressOnStep{Selector: flow.Selector{Text: "Login"}},
+			200, 230, flow.DefaultLongPressDurationMs, "for 1000ms"},
+		{"point within element", &flow.LongPressOnStep{Selector: flow.Selector{Text: "Login"}, Point: "25%, 50%", DurationMs: 2500},
+			150, 230, 2500, "for 2500ms"},
+		{"screen point", &flow.LongPressOnStep{Point: "50%, 25%", DurationMs: 1500},
+			540, 600, 1500, "Long pressed at (540, 600) for 1500ms"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &MockUIA2Client{sourceData: retrySourceValid}
+			driver := New(client, nil, &MockShellExecutor{})
+
+			result := driver.Execute(tt.step)
+
+			if !result.Success {
+				t.Fatalf("expected success, got: %s", result.Message)
+			}
+			if len(client.longClickCalls) != 1 {
+				t.Fatalf("expected 1 long click, got %d", len(client.longClickCalls))
+			}
+			got := client.longClickCalls[0]
+			if got.X != tt.wantX || got.Y != tt.wantY || got.Duration != tt.wantDuration {
+				t.Errorf("long click = %+v, want (%d, %d) for %dms", got, tt.wantX, tt.wantY, tt.wantDuration)
+			}
+			if !strings.Contains(result.Message, tt.wantMsg) {
+				t.Errorf("expected %q in message, got: %s", tt.wantMsg, result.Message)
+			}
+		})
+	}
+}
+
 func TestLongPressOnClickError(t *testing.T) {
 	server := setupMockServer(t, map[string]func(w http.ResponseWriter, r *http.Request){
 		"POST /element": func(w http.ResponseWriter, r *http.Request) {
@@ -1384,6 +1467,199 @@ func TestAssertVisibleElementFoundIsVisible(t *testing.T) {
 	}
 }
 
+func TestAssertVisibleFullyVisible(t *testing.T) {
+	tests := []struct {
+		name        string
+		rect        map[string]int
+		wantSuccess bool
+	}{
+		{"straddles bottom edge", map[string]int{"x": 40, "y": 2300, "width": 1000, "height": 220}, false},
+		{"fully on screen", map[string]int{"x": 40, "y": 2100, "width": 1000, "height": 220}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := setupMockServer(t, map[string]func(w http.ResponseWriter, r *http.Request){
+				"POST /element": func(w http.ResponseWriter, r *http.Request) {
+					writeJSON(w, map[string]interface{}{
+						"value": map[string]string{"ELEMENT": "elem-cta"},
+					})
+				},
+				"GET /element/elem-cta/text": func(w http.ResponseWriter, r *http.Request) {
+					writeJSON(w, map[string]interface{}{"value": "Checkout"})
+				},
+				"GET /element/elem-cta/rect": func(w http.ResponseWriter, r *http.Request) {
+					writeJSON(w, map[string]interface{}{"value": tt.rect})
+				},
+				"GET /appium/device/info": func(w http.ResponseWriter, r *http.Request) {
+					writeJSON(w, map[string]interface{}{
+						"value": map[string]interface{}{"realDisplaySize": "1080x2400"},
+					})
+				},
+			})
+			defer server.Close()
+
+			client := newMockHTTPClient(server.URL)
+			driver := New(client.Client, nil, nil)
+
+			step := &flow.AssertVisibleStep{Selector: flow.Selector{Text: "Checkout"}, FullyVisible: true}
+			result := driver.Execute(step)
+
+			if result.Success != tt.wantSuccess {
+				t.Fatalf("expected success=%v, got %v: %s", tt.wantSuccess, result.Success, result.Message)
+			}
+			if !tt.wantSuccess && !strings.Contains(result.Message, "120px past bottom edge") {
+				t.Errorf("expected overflow amount in message, got: %s", result.Message)
+			}
+		})
+	}
+}
+
+func TestAssertVisibleStructuralSelector(t *testing.T) {
+	client := &MockUIA2Client{sourceData: listHierarchy}
+	driver := New(client, nil, nil)
+
+	middle, two := 1, 2
+	step := &flow.AssertVisibleStep{
+		Selector: flow.Selector{Text: "Item", ChildIndex: &middle, SiblingCount: &two},
+	}
+	result := driver.Execute(step)
+
+	if !result.Success {
+		t.Fatalf("expected success for middle child, got error: %v", result.Error)
+	}
+	if result.Element == nil || result.Element.Bounds.Y != 200 {
+		t.Errorf("expected middle child bounds, got %+v", result.Element)
+	}
+
+	wrong := 5
+	step = &flow.AssertVisibleStep{
+		BaseStep: flow.BaseStep{TimeoutMs: 200},
+		Selector: flow.Selector{Text: "Item", SiblingCount: &wrong},
+	}
+	if result := driver.Execute(step); result.Success {
+		t.Error("expected failure for wrong sibling count")
+	}
+}
+
+const totalsHierarchy = `<?xml version="1.0" encoding="UTF-8"?>
+<hierarchy rotation="0">
+  <node class="android.widget.LinearLayout" displayed="true" bounds="[0,0][1080,600]">
+    <node class="android.widget.TextView" text="Subtotal: $9.50" displayed="true" bounds="[0,0][1080,200]"/>
+    <node class="android.widget.TextView" text="Total: $12.50" displayed="true" bounds="[0,200][1080,400]"/>
+  </node>
+</hierarchy>`
+
+func TestAssertVisibleTextRegex(t *testing.T) {
+	driver := New(&MockUIA2Client{sourceData: totalsHierarchy}, nil, nil)
+
+	step := &flow.AssertVisibleStep{Selector: flow.Selector{TextRegex: `Total: \$[0-9]+\.[0-9]{2}`}}
+	result := driver.Execute(step)
+	if !result.Success {
+		t.Fatalf("expected success, got: %s", result.Message)
+	}
+	if result.Element == nil || result.Element.Bounds.Y != 200 {
+		t.Errorf("expected the Total row, got %+v", result.Element)
+	}
+}
+
+const deleteButtonsHierarchy = `<?xml version="1.0" encoding="UTF-8"?>
+<hierarchy rotation="0">
+  <node class="android.widget.LinearLayout" displayed="true" bounds="[0,0][1080,900]">
+    <node class="android.widget.Button" text="Delete" clickable="true" displayed="true" bounds="[800,100][1000,200]"/>
+    <node class="android.widget.Button" text="Delete" clickable="true" displayed="true" bounds="[800,400][1000,500]"/>
+    <node class="android.widget.Button" text="Delete" clickable="true" displayed="true" bounds="[800,700][1000,800]"/>
+  </node>
+</hierarchy>`
+
+func TestAssertVisibleIndex(t *testing.T) {
+	driver := New(&MockUIA2Client{sourceData: deleteButtonsHierarchy}, nil, nil)
+
+	result := driver.Execute(&flow.AssertVisibleStep{Selector: flow.Selector{Text: "Delete", Index: "2"}})
+	if !result.Success {
+		t.Fatalf("expected success, got: %s", result.Message)
+	}
+	if result.Element == nil || result.Element.Bounds.Y != 700 {
+		t.Errorf("expected the third Delete button, got %+v", result.Element)
+	}
+
+	step := &flow.AssertVisibleStep{
+		BaseStep: flow.BaseStep{TimeoutMs: 200},
+		Selector: flow.Selector{Text: "Delete", Index: "3"},
+	}
+	result = driver.Execute(step)
+	if result.Success {
+		t.Fatal("expected failure for out-of-range index")
+	}
+	if result.Error == nil || !strings.Contains(result.Error.Error(), "index 3 out of range: 3 elements match") {
+		t.Errorf("expected match count in error, got: %v", result.Error)
+	}
+}
+
+const formStateHierarchy = `<?xml version="1.0" encoding="UTF-8"?>
+<hierarchy rotation="0">
+  <node class="android.widget.LinearLayout" displayed="true" bounds="[0,0][1080,600]">
+    <node class="android.widget.CheckBox" text="Accept terms" checkable="true" checked="true" enabled="true" displayed="true" bounds="[0,0][1080,200]"/>
+    <node class="android.widget.Button" text="Submit" clickable="true" enabled="false" displayed="true" bounds="[0,200][1080,400]"/>
+  </node>
+</hierarchy>`
+
+func TestAssertVisibleState(t *testing.T) {
+	driver := New(&MockUIA2Client{sourceData: formStateHierarchy}, nil, nil)
+	on, off := true, false
+
+	result := driver.Execute(&flow.AssertVisibleStep{
+		Selector: flow.Selector{Text: "Accept terms", Checked: &on, Enabled: &on},
+	})
+	if !result.Success {
+		t.Fatalf("expected success, got: %s", result.Message)
+	}
+
+	result = driver.Execute(&flow.AssertVisibleStep{
+		BaseStep: flow.BaseStep{TimeoutMs: 200},
+		Selector: flow.Selector{Text: "Submit", Enabled: &on},
+	})
+	if result.Success {
+		t.Fatal("expected failure for disabled button")
+	}
+	if want := "element 'Submit' is visible but enabled=false (expected true)"; result.Message != want {
+		t.Errorf("message=%q, want %q", result.Message, want)
+	}
+
+	result = driver.Execute(&flow.AssertVisibleStep{
+		BaseStep: flow.BaseStep{TimeoutMs: 200},
+		Selector: flow.Selector{Text: "Accept terms", Checked: &off},
+	})
+	if result.Success || !strings.Contains(result.Message, "checked=true (expected false)") {
+		t.Errorf("expected checked mismatch, got: %s", result.Message)
+	}
+
+	result = driver.Execute(&flow.AssertVisibleStep{
+		BaseStep: flow.BaseStep{TimeoutMs: 200},
+		Selector: flow.Selector{Text: "Missing", Enabled: &on},
+	})
+	if result.Success || !strings.HasPrefix(result.Message, "Element not visible") {
+		t.Errorf("expected not-visible failure for missing element, got: %s", result.Message)
+	}
+}
+
+func TestAssertVisibleInvalidTextRegex(t *testing.T) {
+	client := &MockUIA2Client{sourceData: totalsHierarchy}
+	driver := New(client, nil, nil)
+
+	start := time.Now()
+	result := driver.Execute(&flow.AssertVisibleStep{Selector: flow.Selector{TextRegex: "Total: ($"}})
+	if result.Success {
+		t.Fatal("expected failure for invalid textRegex")
+	}
+	if result.Error == nil || !strings.Contains(result.Error.Error(), `invalid textRegex "Total: ($"`) {
+		t.Errorf("expected invalid textRegex error, got: %v", result.Error)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("invalid pattern should fail without polling, took %v", elapsed)
+	}
+}
+
 func TestAssertNotVisibleElementFound(t *testing.T) {
 	server := setupMockServer(t, map[string]func(w http.ResponseWriter, r *http.Request){
 		"POST /element": func(w http.ResponseWriter, r *http.Request) {
@@ -1777,6 +2053,61 @@ func TestPasteTextWithActiveElement(t *testing.T) {
 	}
 }
 
+func TestCopyTextFromThenPasteText(t *testing.T) {
+	var clipboard string
+	var typed []string
+	server := setupMockServer(t, map[string]func(w http.ResponseWriter, r *http.Request){
+		"POST /element": func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, map[string]interface{}{"value": map[string]string{"ELEMENT": "elem-copy"}})
+		},
+		"GET /element/elem-copy/text": func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, map[string]interface{}{"value": "ABC-123"})
+		},
+		"GET /element/elem-copy/rect": func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, map[string]interface{}{
+				"value": map[string]int{"x": 100, "y": 200, "width": 50, "height": 30},
+			})
+		},
+		"POST /appium/device/set_clipboard": func(w http.ResponseWriter, r *http.Request) {
+			var req struct {
+				Content string `json:"content"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			clipboard = req.Content
+			writeJSON(w, map[string]interface{}{"value": nil})
+		},
+		"POST /appium/device/get_clipboard": func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, map[string]interface{}{"value": clipboard})
+		},
+		"GET /element/active": func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, map[string]interface{}{"value": map[string]string{"ELEMENT": "active-elem"}})
+		},
+		"POST /element/active-elem/value": func(w http.ResponseWriter, r *http.Request) {
+			var req struct {
+				Text string `json:"text"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			typed = append(typed, req.Text)
+			writeJSON(w, map[string]interface{}{"value": nil})
+		},
+	})
+	defer server.Close()
+
+	client := newMockHTTPClient(server.URL)
+	driver := New(client.Client, nil, nil)
+
+	if result := driver.Execute(&flow.CopyTextFromStep{Selector: flow.Selector{Text: "Code"}}); !result.Success {
+		t.Fatalf("copyTextFrom failed: %v", result.Error)
+	}
+	result := driver.Execute(&flow.PasteTextStep{})
+	if !result.Success {
+		t.Fatalf("pasteText failed: %v", result.Error)
+	}
+	if len(typed) != 1 || typed[0] != "ABC-123" {
+		t.Errorf("expected ABC-123 pasted once, got %v", typed)
+	}
+}
+
 func TestPasteTextNoActiveElement(t *testing.T) {
 	server := setupMockServer(t, map[string]func(w http.ResponseWriter, r *http.Request){
 		"POST /appium/device/get_clipboard": func(w http.ResponseWriter, r *http.Request) {
@@ -1997,6 +2328,251 @@ func TestTapOnRelativeSelectorBelow(t *testing.T) {
 	}
 }
 
+// iconHierarchy has a non-clickable icon inside a clickable container.
+const iconHierarchy = `<?xml version="1.0" encoding="UTF-8"?>
+<hierarchy>
+  <node class="android.widget.FrameLayout" resource-id="com.app:id/settings_button" bounds="[900,100][1060,260]" clickable="true" displayed="true">
+    <node class="android.widget.ImageView" resource-id="com.app:id/settings_icon" bounds="[950,150][1010,210]" displayed="true"/>
+  </node>
+  <node class="android.widget.ImageView" resource-id="com.app:id/settings_icon_badge" bounds="[0,0][10,10]" displayed="true"/>
+</hierarchy>`
+
+func TestTapOnResourceIDIconTapsClickableParent(t *testing.T) {
+	var clicks []uiautomator2.PointModel
+	server := setupMockServer(t, map[string]func(w http.ResponseWriter, r *http.Request){
+		// UiAutomator finds the icon but not as a clickable element
+		"POST /element": func(w http.ResponseWriter, r *http.Request) {
+			var req uiautomator2.FindElementRequest
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			if strings.Contains(req.Selector, "clickable(true)") {
+				w.WriteHeader(http.StatusNotFound)
+				writeJSON(w, map[string]interface{}{"value": map[string]string{"error": "no such element"}})
+				return
+			}
+			writeJSON(w, map[string]interface{}{"value": map[string]string{"ELEMENT": "icon"}})
+		},
+		"GET /source": func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, map[string]interface{}{"value": iconHierarchy})
+		},
+		"POST /appium/gestures/click": func(w http.ResponseWriter, r *http.Request) {
+			var req uiautomator2.ClickRequest
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			if req.Offset != nil {
+				clicks = append(clicks, *req.Offset)
+			}
+			writeJSON(w, map[string]interface{}{"value": nil})
+		},
+	})
+	defer server.Close()
+
+	client := newMockHTTPClient(server.URL)
+	driver := New(client.Client, nil, nil)
+
+	for _, id := range []string{"settings_icon", "com.app:id/settings_icon"} {
+		clicks = nil
+		result := driver.Execute(&flow.TapOnStep{Selector: flow.Selector{ID: id}})
+
+		if !result.Success {
+			t.Fatalf("id %q: expected success, got error: %v", id, result.Error)
+		}
+		if len(clicks) != 1 {
+			t.Fatalf("id %q: expected 1 click, got %d", id, len(clicks))
+		}
+		// Center of the clickable container, not the icon's own bounds
+		if clicks[0].X != 980 || clicks[0].Y != 180 {
+			t.Errorf("id %q: expected tap at container center (980,180), got (%d,%d)", id, clicks[0].X, clicks[0].Y)
+		}
+		if result.Element == nil || result.Element.Bounds.Width != 160 {
+			t.Errorf("id %q: expected container bounds, got %+v", id, result.Element)
+		}
+	}
+}
+
+func TestTapSequence(t *testing.T) {
+	type click struct {
+		x, y int
+		at   time.Time
+	}
+	var clicks []click
+	server := setupMockServer(t, map[string]func(w http.ResponseWriter, r *http.Request){
+		"GET /appium/device/info": func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, map[string]interface{}{
+				"value": map[string]interface{}{"realDisplaySize": "1000x2000"},
+			})
+		},
+		"POST /element": func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, map[string]interface{}{
+				"value": map[string]string{"ELEMENT": "dot-5"},
+			})
+		},
+		"GET /element/dot-5/text": func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, map[string]interface{}{"value": "5"})
+		},
+		"GET /element/dot-5/rect": func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, map[string]interface{}{
+				"value": map[string]int{"x": 400, "y": 900, "width": 200, "height": 200},
+			})
+		},
+		"POST /appium/gestures/click": func(w http.ResponseWriter, r *http.Request) {
+			var req uiautomator2.ClickRequest
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			clicks = append(clicks, click{req.Offset.X, req.Offset.Y, time.Now()})
+			writeJSON(w, map[string]interface{}{"value": nil})
+		},
+	})
+	defer server.Close()
+
+	client := newMockHTTPClient(server.URL)
+	driver := New(client.Client, nil, nil)
+
+	step := &flow.TapSequenceStep{
+		Taps: []flow.Selector{
+			{Point: "20%, 30%"},
+			{Text: "5"},
+			{Text: "5", Point: "100%, 0%"},
+		},
+		DelayMs: 80,
+	}
+	result := driver.Execute(step)
+
+	if !result.Success {
+		t.Fatalf("expected success, got: %s", result.Message)
+	}
+	want := [][2]int{{200, 600}, {500, 1000}, {600, 900}}
+	if len(clicks) != len(want) {
+		t.Fatalf("expected %d taps, got %d", len(want), len(clicks))
+	}
+	for i, c := range clicks {
+		if c.x != want[i][0] || c.y != want[i][1] {
+			t.Errorf("tap %d at (%d, %d), want (%d, %d)", i+1, c.x, c.y, want[i][0], want[i][1])
+		}
+		if i > 0 {
+			if gap := c.at.Sub(clicks[i-1].at); gap < 80*time.Millisecond {
+				t.Errorf("gap before tap %d was %v, want at least 80ms", i+1, gap)
+			}
+		}
+	}
+}
+
+func TestTapSequenceEmpty(t *testing.T) {
+	driver := New(&MockUIA2Client{}, nil, nil)
+	if result := driver.Execute(&flow.TapSequenceStep{}); result.Success {
+		t.Error("expected failure with no taps")
+	}
+}
+
+func TestTapOnPixelOffset(t *testing.T) {
+	var clicks []uiautomator2.PointModel
+	server := setupMockServer(t, map[string]func(w http.ResponseWriter, r *http.Request){
+		"POST /element": func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, map[string]interface{}{"value": map[string]string{"ELEMENT": "button"}})
+		},
+		"GET /element/button/rect": func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, map[string]interface{}{"value": map[string]int{"x": 900, "y": 100, "width": 160, "height": 160}})
+		},
+		"GET /source": func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, map[string]interface{}{"value": iconHierarchy})
+		},
+		"POST /appium/gestures/click": func(w http.ResponseWriter, r *http.Request) {
+			var req uiautomator2.ClickRequest
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			if req.Offset != nil {
+				clicks = append(clicks, *req.Offset)
+			}
+			writeJSON(w, map[string]interface{}{"value": nil})
+		},
+	})
+	defer server.Close()
+
+	client := newMockHTTPClient(server.URL)
+	driver := New(client.Client, nil, nil)
+
+	offset := func(v int) *int { return &v }
+	tests := []struct {
+		name         string
+		dx, dy       *int
+		wantX, wantY int
+	}{
+		{"origin plus offset", offset(140), offset(12), 1040, 112},
+		{"only x set", offset(20), nil, 920, 100},
+		{"clamped to bounds", offset(500), offset(-5), 1059, 100},
+	}
+
+	for _, tt := range tests {
+		clicks = nil
+		result := driver.Execute(&flow.TapOnStep{
+			Selector: flow.Selector{ID: "com.app:id/settings_button"},
+			OffsetX:  tt.dx,
+			OffsetY:  tt.dy,
+		})
+
+		if !result.Success {
+			t.Fatalf("%s: expected success, got error: %v", tt.name, result.Error)
+		}
+		if len(clicks) != 1 {
+			t.Fatalf("%s: expected 1 click, got %d", tt.name, len(clicks))
+		}
+		// Container origin is (900,100), size 160x160
+		if clicks[0].X != tt.wantX || clicks[0].Y != tt.wantY {
+			t.Errorf("%s: expected tap at (%d,%d), got (%d,%d)", tt.name, tt.wantX, tt.wantY, clicks[0].X, clicks[0].Y)
+		}
+	}
+}
+
+func TestTapOnRetryTapIfNoChange(t *testing.T) {
+	on := true
+	tests := []struct {
+		name       string
+		maxTaps    int
+		changes    bool
+		wantTaps   int
+		wantSuffix string
+	}{
+		{"unchanged retries up to default", 0, false, 2, "(2 of max 2 taps)"},
+		{"unchanged retries up to maxTaps", 3, false, 3, "(3 of max 3 taps)"},
+		{"changed stops after first tap", 3, true, 1, "(1 of max 3 taps)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &MockUIA2Client{}
+			client.sourceFunc = func() (string, error) {
+				if tt.changes && len(client.clickCalls) > 0 {
+					return totalsHierarchy + "<!-- next screen -->", nil
+				}
+				return totalsHierarchy, nil
+			}
+			driver := New(client, nil, nil)
+
+			result := driver.Execute(&flow.TapOnStep{
+				Selector:           flow.Selector{Text: "Total: $12.50"},
+				RetryTapIfNoChange: &on,
+				MaxTaps:            tt.maxTaps,
+			})
+			if !result.Success {
+				t.Fatalf("expected success, got: %s", result.Message)
+			}
+			if len(client.clickCalls) != tt.wantTaps {
+				t.Errorf("expected %d taps, got %d", tt.wantTaps, len(client.clickCalls))
+			}
+			if !strings.Contains(result.Message, tt.wantSuffix) {
+				t.Errorf("expected %q in message, got: %s", tt.wantSuffix, result.Message)
+			}
+		})
+	}
+}
+
+func TestBuildSelectorsQualifiedID(t *testing.T) {
+	strategies, err := buildSelectors(flow.Selector{ID: "com.app:id/settings_icon"}, 0)
+	if err != nil {
+		t.Fatalf("buildSelectors failed: %v", err)
+	}
+	want := `new UiSelector().resourceId("com.app:id/settings_icon")`
+	if len(strategies) != 1 || strategies[0].Value != want {
+		t.Errorf("expected %s, got %+v", want, strategies)
+	}
+}
+
 func TestTapOnRelativeSelectorClickError(t *testing.T) {
 	pageSource := `<?xml version="1.0" encoding="UTF-8"?>
 <hierarchy>
@@ -2728,6 +3304,76 @@ func TestRelativeSelectorNoMatch(t *testing.T) {
 	}
 }
 
+func TestRelativeSelectorPicksClosestMatch(t *testing.T) {
+	// The clickable, deeper "Edit" sits further down; the plain one is closest to the anchor
+	pageSource := `<?xml version="1.0" encoding="UTF-8"?>
+<hierarchy>
+    <node text="Header" bounds="[0,0][1080,100]" class="android.widget.TextView" />
+    <node text="Edit" bounds="[100,120][300,170]" class="android.widget.TextView" />
+    <node bounds="[0,800][1080,1000]" class="android.widget.FrameLayout">
+        <node text="Edit" bounds="[100,850][300,900]" class="android.widget.Button" clickable="true" />
+    </node>
+</hierarchy>`
+
+	var clicks []uiautomator2.PointModel
+	server := setupRelativeSelectorServer(t, pageSource, func(w http.ResponseWriter, r *http.Request) {
+		var req uiautomator2.ClickRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if req.Offset != nil {
+			clicks = append(clicks, *req.Offset)
+		}
+		writeJSON(w, map[string]interface{}{"value": nil})
+	})
+	defer server.Close()
+
+	client := newMockHTTPClient(server.URL)
+	driver := New(client.Client, nil, nil)
+
+	result := driver.Execute(&flow.TapOnStep{
+		Selector: flow.Selector{
+			Text:  "Edit",
+			Below: &flow.Selector{Text: "Header"},
+		},
+	})
+
+	if !result.Success {
+		t.Fatalf("expected success, got error: %v", result.Error)
+	}
+	if len(clicks) != 1 || clicks[0].X != 200 || clicks[0].Y != 145 {
+		t.Errorf("expected tap on closest Edit at (200,145), got %v", clicks)
+	}
+}
+
+func TestRelativeSelectorAnchorNotFound(t *testing.T) {
+	pageSource := `<?xml version="1.0" encoding="UTF-8"?>
+<hierarchy>
+    <node text="Button" bounds="[100,150][200,200]" class="android.widget.Button" clickable="true" />
+</hierarchy>`
+
+	server := setupRelativeSelectorServer(t, pageSource, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]interface{}{"value": nil})
+	})
+	defer server.Close()
+
+	client := newMockHTTPClient(server.URL)
+	driver := New(client.Client, nil, nil)
+
+	result := driver.Execute(&flow.TapOnStep{
+		BaseStep: flow.BaseStep{TimeoutMs: 200},
+		Selector: flow.Selector{
+			Text:  "Button",
+			Below: &flow.Selector{Text: "Missing"},
+		},
+	})
+
+	if result.Success {
+		t.Fatal("expected failure when anchor is not found")
+	}
+	if result.Error == nil || !strings.Contains(result.Error.Error(), "anchor element not found") {
+		t.Errorf("expected anchor not found error, got %v", result.Error)
+	}
+}
+
 func TestRelativeSelectorPageSourceError(t *testing.T) {
 	server := setupMockServer(t, map[string]func(w http.ResponseWriter, r *http.Request){
 		"POST /element": func(w http.ResponseWriter, r *http.Request) {
@@ -2868,4 +3514,78 @@ func TestFindElementMultipleStrategies(t *testing.T) {
 	}
 }
 
+// ============================================================================
+// Page source retry tests
+// ============================================================================
+
+const retrySourceValid = `<?xml version="1.0" encoding="UTF-8"?>
+<hierarchy>
+  <node class="android.widget.Button" text="Login" bounds="[100,200][300,260]" clickable="true" displayed="true"/>
+</hierarchy>`
+
+const retrySourceTruncated = `<?xml version="1.0" encoding="UTF-8"?>
+<hierarchy>
+  <node class="android.widget.FrameLayout" bounds="[0,0][1080,2400]">
+    <node class="android.widget.Button" text="Log`
+
+// sequencedSource returns each source in turn, repeating the last one.
+func sequencedSource(calls *int, sources ...string) func() (string, error) {
+	return func() (string, error) {
+		i := *calls
+		*calls++
+		if i >= len(sources) {
+			i = len(sources) - 1
+		}
+		return sources[i], nil
+	}
+}
+
+func TestPageSourceElementsRetriesMalformedSource(t *testing.T) {
+	calls := 0
+	client := &MockUIA2Client{sourceFunc: sequencedSource(&calls, retrySourceTruncated, retrySourceValid)}
+	driver := New(client, nil, nil)
+
+	_, info, err := driver.findElementByPageSourceOnce(flow.Selector{Text: "Login"})
+	if err != nil {
+		t.Fatalf("expected recovery after malformed source, got: %v", err)
+	}
+	if info == nil || info.Text != "Login" {
+		t.Errorf("expected Login element, got %+v", info)
+	}
+	if calls != 2 {
+		t.Errorf("expected 2 source fetches, got %d", calls)
+	}
+}
+
+func TestPageSourceElementsGivesUp(t *testing.T) {
+	calls := 0
+	client := &MockUIA2Client{sourceFunc: sequencedSource(&calls, retrySourceTruncated)}
+	driver := New(client, nil, nil)
+
+	_, err := driver.pageSourceElements()
+	if err == nil {
+		t.Fatal("expected error when source stays malformed")
+	}
+	if calls != sourceParseRetries+1 {
+		t.Errorf("expected %d source fetches, got %d", sourceParseRetries+1, calls)
+	}
+}
+
+func TestPageSourceElementsEmptyHierarchyNotRetried(t *testing.T) {
+	calls := 0
+	client := &MockUIA2Client{sourceFunc: sequencedSource(&calls, `<hierarchy rotation="0"></hierarchy>`)}
+	driver := New(client, nil, nil)
+
+	elements, err := driver.pageSourceElements()
+	if err != nil {
+		t.Fatalf("empty hierarchy should not be an error, got: %v", err)
+	}
+	if len(elements) != 0 {
+		t.Errorf("expected no elements, got %d", len(elements))
+	}
+	if calls != 1 {
+		t.Errorf("expected a single source fetch, got %d", calls)
+	}
+}
+
 // Note: App lifecycle tests are in commands_test.go
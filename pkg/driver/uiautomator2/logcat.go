@@ -0,0 +1,59 @@
+package uiautomator2
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// logcatTagPattern limits logcat tags to characters that are safe to pass
+// through the shell unquoted.
+var logcatTagPattern = regexp.MustCompile(`^[A-Za-z0-9_.:-]+$`)
+
+// StartLogCapture remembers the device clock so DeviceLogs only returns what
+// the flow logged, not the whole logcat buffer.
+func (d *Driver) StartLogCapture() error {
+	if d.device == nil {
+		return fmt.Errorf("device not configured")
+	}
+	// logcat -T takes the same "MM-DD hh:mm:ss.mmm" format it prints
+	out, err := d.device.Shell("date '+%m-%d %H:%M:%S.000'")
+	if err != nil {
+		return fmt.Errorf("read device time: %w", err)
+	}
+	d.logcatSince = strings.TrimSpace(out)
+	return nil
+}
+
+// DeviceLogs dumps logcat since StartLogCapture. A tag keeps only that tag's
+// lines; otherwise lines are limited to appID's process, or left unfiltered
+// when the app is not running (e.g. after a crash).
+func (d *Driver) DeviceLogs(appID, tag string) ([]byte, error) {
+	if d.device == nil {
+		return nil, fmt.Errorf("device not configured")
+	}
+
+	cmd := "logcat -d -v threadtime"
+	if d.logcatSince != "" {
+		cmd += fmt.Sprintf(" -T '%s'", d.logcatSince)
+	}
+	switch {
+	case tag != "":
+		if !logcatTagPattern.MatchString(tag) {
+			return nil, fmt.Errorf("invalid logcat tag %q", tag)
+		}
+		cmd += " -s " + tag
+	case appID != "" && packageNamePattern.MatchString(appID):
+		if pid, err := d.device.Shell("pidof " + appID); err == nil {
+			if fields := strings.Fields(pid); len(fields) > 0 {
+				cmd += " --pid=" + fields[0]
+			}
+		}
+	}
+
+	out, err := d.device.Shell(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("logcat: %w", err)
+	}
+	return []byte(out), nil
+}
@@ -0,0 +1,77 @@
+package uiautomator2
+
+import (
+	"strings"
+	"testing"
+)
+
+// logcatShell answers the commands used by log capture.
+type logcatShell struct {
+	commands []string
+	pid      string
+}
+
+func (s *logcatShell) Shell(cmd string) (string, error) {
+	s.commands = append(s.commands, cmd)
+	switch {
+	case strings.HasPrefix(cmd, "date"):
+		return "01-02 03:04:05.000\n", nil
+	case strings.HasPrefix(cmd, "pidof"):
+		return s.pid, nil
+	case strings.HasPrefix(cmd, "logcat"):
+		return "E AndroidRuntime: FATAL EXCEPTION: main\n", nil
+	}
+	return "", nil
+}
+
+func TestDeviceLogsFilters(t *testing.T) {
+	tests := []struct {
+		name string
+		pid  string
+		tag  string
+		want string
+	}{
+		{"app pid", "4321\n", "", "logcat -d -v threadtime -T '01-02 03:04:05.000' --pid=4321"},
+		{"app not running", "", "", "logcat -d -v threadtime -T '01-02 03:04:05.000'"},
+		{"tag", "4321\n", "AndroidRuntime", "logcat -d -v threadtime -T '01-02 03:04:05.000' -s AndroidRuntime"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shell := &logcatShell{pid: tt.pid}
+			driver := &Driver{device: shell}
+
+			if err := driver.StartLogCapture(); err != nil {
+				t.Fatalf("StartLogCapture failed: %v", err)
+			}
+			logs, err := driver.DeviceLogs("com.example.app", tt.tag)
+			if err != nil {
+				t.Fatalf("DeviceLogs failed: %v", err)
+			}
+
+			if !strings.Contains(string(logs), "FATAL EXCEPTION") {
+				t.Errorf("expected logcat output, got %q", logs)
+			}
+			if got := shell.commands[len(shell.commands)-1]; got != tt.want {
+				t.Errorf("logcat command = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeviceLogsRejectsUnsafeTag(t *testing.T) {
+	driver := &Driver{device: &logcatShell{}}
+	if _, err := driver.DeviceLogs("com.example.app", "tag; reboot"); err == nil {
+		t.Error("expected an error for a tag with shell metacharacters")
+	}
+}
+
+func TestLogCaptureRequiresDevice(t *testing.T) {
+	driver := &Driver{}
+	if err := driver.StartLogCapture(); err == nil {
+		t.Error("expected StartLogCapture to fail without a device")
+	}
+	if _, err := driver.DeviceLogs("com.example.app", ""); err == nil {
+		t.Error("expected DeviceLogs to fail without a device")
+	}
+}
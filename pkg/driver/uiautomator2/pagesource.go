@@ -4,6 +4,7 @@ import (
 	"encoding/xml"
 	"fmt"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -18,9 +19,11 @@ type ParsedElement struct {
 	ContentDesc string
 	HintText    string // hint attribute for EditText fields
 	ClassName   string
+	Package     string // app package the node belongs to
 	Bounds      core.Bounds
 	Enabled     bool
 	Selected    bool
+	Checked     bool
 	Focused     bool
 	Displayed   bool
 	Clickable   bool
@@ -74,12 +77,16 @@ func ParsePageSource(xmlData string) ([]*ParsedElement, error) {
 						elem.HintText = attr.Value
 					case "class":
 						elem.ClassName = attr.Value // Override if class attr exists
+					case "package":
+						elem.Package = attr.Value
 					case "bounds":
 						elem.Bounds = parseBounds(attr.Value)
 					case "enabled":
 						elem.Enabled = attr.Value == "true"
 					case "selected":
 						elem.Selected = attr.Value == "true"
+					case "checked":
+						elem.Checked = attr.Value == "true"
 					case "focused":
 						elem.Focused = attr.Value == "true"
 					case "displayed":
@@ -124,8 +131,9 @@ func ParsePageSource(xmlData string) ([]*ParsedElement, error) {
 		}
 	}
 
-	// Return error for invalid XML
-	if parseErr != nil && len(elements) == 0 {
+	// Return error for invalid XML, including a document truncated after
+	// some elements were already read
+	if parseErr != nil {
 		return nil, parseErr
 	}
 
@@ -193,10 +201,13 @@ func matchesSelector(elem *ParsedElement, sel flow.Selector) bool {
 			return false
 		}
 	}
+	if sel.TextRegex != "" && !sel.MatchesTextRegex(elem.Text, elem.ContentDesc, elem.HintText) {
+		return false
+	}
 
-	// ID matching (partial)
+	// ID matching (exact when fully qualified, partial otherwise)
 	if sel.ID != "" {
-		if !strings.Contains(elem.ResourceID, sel.ID) {
+		if !matchesResourceID(elem.ResourceID, sel.ID) {
 			return false
 		}
 	}
@@ -225,14 +236,40 @@ func matchesSelector(elem *ParsedElement, sel flow.Selector) bool {
 	if sel.Focused != nil && elem.Focused != *sel.Focused {
 		return false
 	}
-	if sel.Checked != nil && elem.Selected != *sel.Checked {
-		// checked maps to selected in Android
+	if sel.Checked != nil && elem.Checked != *sel.Checked {
+		return false
+	}
+
+	// Structural filters (position among the parent's children)
+	if sel.HasStructuralSelector() && !matchesStructure(elem, sel) {
 		return false
 	}
 
 	return true
 }
 
+// matchesStructure checks the element's position among its parent's children.
+// A root element (no parent) has index 0 and no siblings.
+func matchesStructure(elem *ParsedElement, sel flow.Selector) bool {
+	index, siblings := 0, 0
+	if elem.Parent != nil {
+		siblings = len(elem.Parent.Children) - 1
+		for i, child := range elem.Parent.Children {
+			if child == elem {
+				index = i
+				break
+			}
+		}
+	}
+	if sel.ChildIndex != nil && index != *sel.ChildIndex {
+		return false
+	}
+	if sel.SiblingCount != nil && siblings != *sel.SiblingCount {
+		return false
+	}
+	return true
+}
+
 // withinTolerance checks if actual is within tolerance of expected.
 func withinTolerance(actual, expected, tolerance int) bool {
 	diff := actual - expected
@@ -297,81 +334,38 @@ func containsIgnoreCase(s, substr string) bool {
 
 // Position filter functions
 
-// FilterBelow returns elements below the anchor element.
+// FilterBelow returns elements below the anchor (see core.FilterBySide).
 func FilterBelow(elements []*ParsedElement, anchor *ParsedElement) []*ParsedElement {
-	anchorBottom := anchor.Bounds.Y + anchor.Bounds.Height
-	var result []*ParsedElement
-
-	for _, elem := range elements {
-		// Element's top must be below anchor's bottom
-		if elem.Bounds.Y >= anchorBottom {
-			result = append(result, elem)
-		}
-	}
-
-	// Sort by distance (closest first)
-	sortByDistanceY(result, anchorBottom)
-	return result
+	return core.FilterBySide(elements, anchor, core.SideBelow, elementBounds)
 }
 
-// FilterAbove returns elements above the anchor element.
+// FilterAbove returns elements above the anchor.
 func FilterAbove(elements []*ParsedElement, anchor *ParsedElement) []*ParsedElement {
-	anchorTop := anchor.Bounds.Y
-	var result []*ParsedElement
-
-	for _, elem := range elements {
-		// Element's bottom must be above anchor's top
-		elemBottom := elem.Bounds.Y + elem.Bounds.Height
-		if elemBottom <= anchorTop {
-			result = append(result, elem)
-		}
-	}
-
-	// Sort by distance (closest first - highest Y value)
-	sortByDistanceYReverse(result, anchorTop)
-	return result
+	return core.FilterBySide(elements, anchor, core.SideAbove, elementBounds)
 }
 
-// FilterLeftOf returns elements left of the anchor element.
+// FilterLeftOf returns elements left of the anchor.
 func FilterLeftOf(elements []*ParsedElement, anchor *ParsedElement) []*ParsedElement {
-	anchorLeft := anchor.Bounds.X
-	var result []*ParsedElement
-
-	for _, elem := range elements {
-		// Element's right must be left of anchor's left
-		elemRight := elem.Bounds.X + elem.Bounds.Width
-		if elemRight <= anchorLeft {
-			result = append(result, elem)
-		}
-	}
-
-	sortByDistanceXReverse(result, anchorLeft)
-	return result
+	return core.FilterBySide(elements, anchor, core.SideLeftOf, elementBounds)
 }
 
-// FilterRightOf returns elements right of the anchor element.
+// FilterRightOf returns elements right of the anchor.
 func FilterRightOf(elements []*ParsedElement, anchor *ParsedElement) []*ParsedElement {
-	anchorRight := anchor.Bounds.X + anchor.Bounds.Width
-	var result []*ParsedElement
-
-	for _, elem := range elements {
-		// Element's left must be right of anchor's right
-		if elem.Bounds.X >= anchorRight {
-			result = append(result, elem)
-		}
-	}
-
-	sortByDistanceX(result, anchorRight)
-	return result
+	return core.FilterBySide(elements, anchor, core.SideRightOf, elementBounds)
 }
 
-// FilterChildOf returns elements that are children of anchor.
+func elementBounds(elem *ParsedElement) core.Bounds { return elem.Bounds }
+
+// FilterChildOf returns elements nested under anchor in the page source tree.
+// An anchor detached from the tree (resolved from a nested relative selector)
+// falls back to bounds containment.
 func FilterChildOf(elements []*ParsedElement, anchor *ParsedElement) []*ParsedElement {
-	// Element must be fully inside anchor bounds
 	var result []*ParsedElement
 
+	attached := inTree(anchor)
 	for _, elem := range elements {
-		if isInside(elem.Bounds, anchor.Bounds) {
+		if attached && core.IsDescendant(elem, anchor, parentOf) ||
+			!attached && isInside(elem.Bounds, anchor.Bounds) {
 			result = append(result, elem)
 		}
 	}
@@ -379,12 +373,15 @@ func FilterChildOf(elements []*ParsedElement, anchor *ParsedElement) []*ParsedEl
 	return result
 }
 
-// FilterContainsChild returns elements that contain anchor as child.
+// FilterContainsChild returns elements that have anchor among their
+// descendants, falling back to bounds containment for a detached anchor.
 func FilterContainsChild(elements []*ParsedElement, anchor *ParsedElement) []*ParsedElement {
 	var result []*ParsedElement
 
+	attached := inTree(anchor)
 	for _, elem := range elements {
-		if isInside(anchor.Bounds, elem.Bounds) {
+		if attached && core.IsDescendant(anchor, elem, parentOf) ||
+			!attached && isInside(anchor.Bounds, elem.Bounds) {
 			result = append(result, elem)
 		}
 	}
@@ -392,6 +389,16 @@ func FilterContainsChild(elements []*ParsedElement, anchor *ParsedElement) []*Pa
 	return result
 }
 
+// inTree reports whether elem came from a parsed hierarchy rather than being
+// built from a resolved element's info.
+func inTree(elem *ParsedElement) bool {
+	return elem.Parent != nil || len(elem.Children) > 0
+}
+
+func parentOf(elem *ParsedElement) *ParsedElement {
+	return elem.Parent
+}
+
 // FilterInsideOf returns elements whose center point is inside anchor bounds.
 // Different from ChildOf - uses visual center containment, not full bounds.
 func FilterInsideOf(elements []*ParsedElement, anchor *ParsedElement) []*ParsedElement {
@@ -413,55 +420,6 @@ func isInside(inner, outer core.Bounds) bool {
 		inner.Y+inner.Height <= outer.Y+outer.Height
 }
 
-// Simple sorting by distance (not using sort package to keep it simple)
-func sortByDistanceY(elements []*ParsedElement, refY int) {
-	for i := 0; i < len(elements); i++ {
-		for j := i + 1; j < len(elements); j++ {
-			distI := elements[i].Bounds.Y - refY
-			distJ := elements[j].Bounds.Y - refY
-			if distJ < distI {
-				elements[i], elements[j] = elements[j], elements[i]
-			}
-		}
-	}
-}
-
-func sortByDistanceYReverse(elements []*ParsedElement, refY int) {
-	for i := 0; i < len(elements); i++ {
-		for j := i + 1; j < len(elements); j++ {
-			distI := refY - (elements[i].Bounds.Y + elements[i].Bounds.Height)
-			distJ := refY - (elements[j].Bounds.Y + elements[j].Bounds.Height)
-			if distJ < distI {
-				elements[i], elements[j] = elements[j], elements[i]
-			}
-		}
-	}
-}
-
-func sortByDistanceX(elements []*ParsedElement, refX int) {
-	for i := 0; i < len(elements); i++ {
-		for j := i + 1; j < len(elements); j++ {
-			distI := elements[i].Bounds.X - refX
-			distJ := elements[j].Bounds.X - refX
-			if distJ < distI {
-				elements[i], elements[j] = elements[j], elements[i]
-			}
-		}
-	}
-}
-
-func sortByDistanceXReverse(elements []*ParsedElement, refX int) {
-	for i := 0; i < len(elements); i++ {
-		for j := i + 1; j < len(elements); j++ {
-			distI := refX - (elements[i].Bounds.X + elements[i].Bounds.Width)
-			distJ := refX - (elements[j].Bounds.X + elements[j].Bounds.Width)
-			if distJ < distI {
-				elements[i], elements[j] = elements[j], elements[i]
-			}
-		}
-	}
-}
-
 // FilterContainsDescendants returns elements that contain ALL specified descendants.
 // Each descendant selector must match at least one child within the element's bounds.
 func FilterContainsDescendants(elements []*ParsedElement, allElements []*ParsedElement, descendants []*flow.Selector) []*ParsedElement {
@@ -509,6 +467,73 @@ func DeepestMatchingElement(elements []*ParsedElement) *ParsedElement {
 	return deepest
 }
 
+// SelectByIndex returns the index-th match in on-screen order: top-to-bottom,
+// then left-to-right, source order breaking ties. A match nested inside
+// another match counts once, as the innermost element. Negative indexes count
+// from the end.
+func SelectByIndex(elements []*ParsedElement, index int) (*ParsedElement, error) {
+	ordered := SortByPosition(innermostMatches(elements))
+	i := index
+	if i < 0 {
+		i += len(ordered)
+	}
+	if i < 0 || i >= len(ordered) {
+		return nil, fmt.Errorf("index %d out of range: %d elements match", index, len(ordered))
+	}
+	return ordered[i], nil
+}
+
+// SortByPosition returns elements ordered top-to-bottom, then left-to-right.
+// The sort is stable, so elements at the same position keep source order.
+func SortByPosition(elements []*ParsedElement) []*ParsedElement {
+	sorted := append([]*ParsedElement(nil), elements...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i].Bounds, sorted[j].Bounds
+		if a.Y != b.Y {
+			return a.Y < b.Y
+		}
+		return a.X < b.X
+	})
+	return sorted
+}
+
+// innermostMatches drops elements that are ancestors of another element in
+// the list, so a container and the label inside it count as one match.
+func innermostMatches(elements []*ParsedElement) []*ParsedElement {
+	matched := make(map[*ParsedElement]bool, len(elements))
+	for _, elem := range elements {
+		matched[elem] = true
+	}
+	hasMatchedDescendant := make(map[*ParsedElement]bool)
+	for _, elem := range elements {
+		for p := elem.Parent; p != nil; p = p.Parent {
+			if matched[p] {
+				hasMatchedDescendant[p] = true
+			}
+		}
+	}
+	var result []*ParsedElement
+	for _, elem := range elements {
+		if !hasMatchedDescendant[elem] {
+			result = append(result, elem)
+		}
+	}
+	return result
+}
+
+// isQualifiedResourceID reports whether id includes the package prefix ("com.app:id/name").
+func isQualifiedResourceID(id string) bool {
+	return strings.Contains(id, ":id/")
+}
+
+// matchesResourceID matches a fully-qualified ID exactly and a bare name by substring.
+func matchesResourceID(resourceID, id string) bool {
+	if isQualifiedResourceID(id) {
+		return resourceID == id
+	}
+	return strings.Contains(resourceID, id)
+}
+
 // SortClickableFirst reorders elements to prioritize clickable ones.
 // Clickable elements come first, maintaining relative order within each group.
 func SortClickableFirst(elements []*ParsedElement) []*ParsedElement {
@@ -586,3 +611,41 @@ func GetClickableElement(elem *ParsedElement) *ParsedElement {
 	// No clickable parent found - return original element
 	return elem
 }
+
+// CountNotificationRows counts SystemUI notification rows in the shade.
+func CountNotificationRows(elements []*ParsedElement) int {
+	count := 0
+	for _, elem := range elements {
+		if strings.HasSuffix(elem.ResourceID, ":id/expandableNotificationRow") {
+			count++
+		}
+	}
+	return count
+}
+
+// FindClearAllButton returns the shade footer's "Clear all" button, or nil.
+// SystemUI only shows it while a dismissible notification remains; OEM shades
+// that rename it are matched by its label.
+func FindClearAllButton(elements []*ParsedElement) *ParsedElement {
+	for _, elem := range elements {
+		if strings.HasSuffix(elem.ResourceID, ":id/dismiss_text") ||
+			strings.HasSuffix(elem.ResourceID, ":id/clear_all") ||
+			strings.EqualFold(strings.TrimSpace(elem.Text), "clear all") {
+			return elem
+		}
+	}
+	return nil
+}
+
+// HasNotificationFooter reports whether the shade shows its footer, which
+// SystemUI adds (with "Manage") whenever there are notifications, dismissible
+// or not.
+func HasNotificationFooter(elements []*ParsedElement) bool {
+	for _, elem := range elements {
+		if strings.HasSuffix(elem.ResourceID, ":id/notification_footer") ||
+			strings.HasSuffix(elem.ResourceID, ":id/manage_text") {
+			return true
+		}
+	}
+	return false
+}
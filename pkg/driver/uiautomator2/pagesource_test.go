@@ -60,6 +60,13 @@ func TestParsePageSourceInvalidXML(t *testing.T) {
 	}
 }
 
+func TestParsePageSourceTruncated(t *testing.T) {
+	truncated := `<hierarchy><node text="Login" bounds="[0,0][100,50]"><node text="Pass`
+	if _, err := ParsePageSource(truncated); err == nil {
+		t.Error("expected error for truncated XML")
+	}
+}
+
 func TestParseBounds(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -140,6 +147,97 @@ func TestFilterBySelectorState(t *testing.T) {
 	}
 }
 
+func TestFilterBySelectorChecked(t *testing.T) {
+	source := `<?xml version="1.0" encoding="UTF-8"?>
+<hierarchy rotation="0">
+  <node index="0" class="android.widget.LinearLayout" bounds="[0,0][1080,400]">
+    <node index="0" text="Remember me" class="android.widget.CheckBox" bounds="[0,0][1080,100]" checkable="true" checked="true" selected="false"/>
+    <node index="1" text="Newsletter" class="android.widget.CheckBox" bounds="[0,100][1080,200]" checkable="true" checked="false" selected="true"/>
+    <node index="2" text="Dark mode" class="android.widget.Switch" bounds="[0,200][1080,300]" checkable="true" checked="false"/>
+  </node>
+</hierarchy>`
+	elements, _ := ParsePageSource(source)
+
+	checked, unchecked := true, false
+	result := FilterBySelector(elements, flow.Selector{Checked: &checked})
+	if len(result) != 1 || result[0].Text != "Remember me" {
+		t.Fatalf("expected only Remember me to be checked, got %d elements", len(result))
+	}
+	// checked reads its own attribute, not selected
+	if got := FilterBySelector(elements, flow.Selector{Text: "Newsletter", Checked: &checked}); len(got) != 0 {
+		t.Error("expected selected-but-unchecked Newsletter not to match checked=true")
+	}
+	if got := FilterBySelector(elements, flow.Selector{Text: "Dark mode", Checked: &unchecked}); len(got) != 1 {
+		t.Errorf("expected Dark mode to match checked=false, got %d", len(got))
+	}
+}
+
+const listHierarchy = `<?xml version="1.0" encoding="UTF-8"?>
+<hierarchy rotation="0">
+  <node class="android.widget.LinearLayout" resource-id="com.app:id/list" displayed="true" bounds="[0,0][1080,600]">
+    <node class="android.widget.TextView" text="Item" resource-id="com.app:id/row" displayed="true" bounds="[0,0][1080,200]"/>
+    <node class="android.widget.TextView" text="Item" resource-id="com.app:id/row" displayed="true" bounds="[0,200][1080,400]"/>
+    <node class="android.widget.TextView" text="Item" resource-id="com.app:id/row" displayed="true" bounds="[0,400][1080,600]"/>
+  </node>
+</hierarchy>`
+
+func TestSelectByIndex(t *testing.T) {
+	// Rows listed out of on-screen order; the middle row wraps its label
+	row := func(x, y int) *ParsedElement {
+		return &ParsedElement{Text: "Delete", Bounds: core.Bounds{X: x, Y: y, Width: 100, Height: 50}}
+	}
+	topRight, topLeft, bottom := row(500, 100), row(0, 100), row(0, 400)
+	container := &ParsedElement{ContentDesc: "Delete", Bounds: core.Bounds{X: 0, Y: 250, Width: 600, Height: 80}}
+	label := &ParsedElement{Text: "Delete", Parent: container, Bounds: core.Bounds{X: 10, Y: 260, Width: 100, Height: 50}}
+	container.Children = []*ParsedElement{label}
+	matches := []*ParsedElement{bottom, container, topRight, label, topLeft}
+
+	want := []*ParsedElement{topLeft, topRight, label, bottom}
+	for i, w := range want {
+		got, err := SelectByIndex(matches, i)
+		if err != nil {
+			t.Fatalf("index %d: unexpected error: %v", i, err)
+		}
+		if got != w {
+			t.Errorf("index %d: got %+v, want %+v", i, got.Bounds, w.Bounds)
+		}
+	}
+	if got, _ := SelectByIndex(matches, -1); got != bottom {
+		t.Errorf("index -1: expected last row, got %+v", got.Bounds)
+	}
+
+	_, err := SelectByIndex(matches, 4)
+	if err == nil || err.Error() != "index 4 out of range: 4 elements match" {
+		t.Errorf("expected out of range error, got %v", err)
+	}
+}
+
+func TestFilterBySelectorStructure(t *testing.T) {
+	elements, _ := ParsePageSource(listHierarchy)
+
+	middle, two := 1, 2
+	result := FilterBySelector(elements, flow.Selector{Text: "Item", ChildIndex: &middle, SiblingCount: &two})
+	if len(result) != 1 {
+		t.Fatalf("expected 1 middle child, got %d", len(result))
+	}
+	if result[0].Bounds.Y != 200 {
+		t.Errorf("expected middle child at y=200, got %d", result[0].Bounds.Y)
+	}
+
+	three := 3
+	result = FilterBySelector(elements, flow.Selector{Text: "Item", SiblingCount: &three})
+	if len(result) != 0 {
+		t.Errorf("expected no match for 3 siblings, got %d", len(result))
+	}
+
+	// Root has no parent: index 0, no siblings
+	zero := 0
+	result = FilterBySelector(elements, flow.Selector{ID: "list", ChildIndex: &zero, SiblingCount: &zero})
+	if len(result) != 1 {
+		t.Errorf("expected root to match index 0 with no siblings, got %d", len(result))
+	}
+}
+
 func TestFilterBelow(t *testing.T) {
 	elements, _ := ParsePageSource(sampleHierarchy)
 
@@ -239,6 +337,53 @@ func TestFilterContainsChild(t *testing.T) {
 	}
 }
 
+// rowsHierarchy has two rows with a label and button each, under a full-screen
+// overlay that is a sibling of the list, not an ancestor of the rows.
+const rowsHierarchy = `<?xml version="1.0" encoding="UTF-8"?>
+<hierarchy rotation="0">
+  <node index="0" text="" resource-id="" class="android.widget.FrameLayout" bounds="[0,0][1080,1920]">
+    <node index="0" text="" resource-id="com.app:id/list" class="androidx.recyclerview.widget.RecyclerView" bounds="[0,0][1080,400]">
+      <node index="0" text="" resource-id="com.app:id/row" class="android.widget.LinearLayout" bounds="[0,0][1080,200]" clickable="true">
+        <node index="0" text="Milk" resource-id="com.app:id/name" class="android.widget.TextView" bounds="[40,60][400,140]"/>
+        <node index="1" text="Remove" resource-id="com.app:id/remove" class="android.widget.Button" bounds="[800,60][1040,140]" clickable="true"/>
+      </node>
+      <node index="1" text="" resource-id="com.app:id/row" class="android.widget.LinearLayout" bounds="[0,200][1080,400]" clickable="true">
+        <node index="0" text="Eggs" resource-id="com.app:id/name" class="android.widget.TextView" bounds="[40,260][400,340]"/>
+        <node index="1" text="Remove" resource-id="com.app:id/remove" class="android.widget.Button" bounds="[800,260][1040,340]" clickable="true"/>
+      </node>
+    </node>
+    <node index="1" text="" resource-id="com.app:id/overlay" class="android.view.View" bounds="[0,0][1080,1920]"/>
+  </node>
+</hierarchy>`
+
+func TestFilterContainsChildTree(t *testing.T) {
+	elements, _ := ParsePageSource(rowsHierarchy)
+	milk := FilterBySelector(elements, flow.Selector{Text: "Milk"})[0]
+
+	rows := FilterContainsChild(FilterBySelector(elements, flow.Selector{ID: "com.app:id/row"}), milk)
+	if len(rows) != 1 || rows[0].Bounds.Y != 0 {
+		t.Fatalf("expected only the Milk row, got %d rows", len(rows))
+	}
+	for _, e := range FilterContainsChild(elements, milk) {
+		if e.ResourceID == "com.app:id/overlay" {
+			t.Error("overlay covers the label but is not its ancestor")
+		}
+	}
+}
+
+func TestFilterChildOfTree(t *testing.T) {
+	elements, _ := ParsePageSource(rowsHierarchy)
+	secondRow := FilterBySelector(elements, flow.Selector{ID: "com.app:id/row"})[1]
+
+	buttons := FilterChildOf(FilterBySelector(elements, flow.Selector{Text: "Remove"}), secondRow)
+	if len(buttons) != 1 || buttons[0].Bounds.Y != 260 {
+		t.Fatalf("expected the second row's Remove button, got %d buttons", len(buttons))
+	}
+	if got := FilterChildOf(elements, secondRow); len(got) != 2 {
+		t.Errorf("expected 2 descendants of the row, got %d", len(got))
+	}
+}
+
 func TestFilterContainsDescendants(t *testing.T) {
 	elements, _ := ParsePageSource(sampleHierarchy)
 
@@ -345,6 +490,10 @@ func TestMatchesSelector(t *testing.T) {
 		{"id no match", flow.Selector{ID: "signup"}, false},
 		{"size match", flow.Selector{Width: 200, Height: 80}, true},
 		{"size no match", flow.Selector{Width: 300, Height: 80}, false},
+		{"textRegex match", flow.Selector{TextRegex: "Login (Button|Link)"}, true},
+		{"textRegex on content-desc", flow.Selector{TextRegex: "Sign in to .+"}, true},
+		{"textRegex partial no match", flow.Selector{TextRegex: "Login"}, false},
+		{"textRegex case sensitive", flow.Selector{TextRegex: "login button"}, false},
 	}
 
 	for _, tt := range tests {
@@ -553,64 +702,72 @@ func TestIsInside(t *testing.T) {
 	}
 }
 
-func TestSortByDistanceY(t *testing.T) {
+func TestFilterBelowUsesCenter(t *testing.T) {
+	// Overlaps the anchor's bottom edge, but its center is below it
 	elements := []*ParsedElement{
-		{Text: "Far", Bounds: core.Bounds{Y: 200}},
-		{Text: "Near", Bounds: core.Bounds{Y: 110}},
-		{Text: "Middle", Bounds: core.Bounds{Y: 150}},
+		{Text: "Anchor", Bounds: core.Bounds{X: 100, Y: 100, Width: 100, Height: 50}},
+		{Text: "Overlapping", Bounds: core.Bounds{X: 100, Y: 140, Width: 100, Height: 40}},
+		{Text: "Beside", Bounds: core.Bounds{X: 300, Y: 110, Width: 100, Height: 50}},
 	}
 
-	sortByDistanceY(elements, 100)
+	result := FilterBelow(elements, elements[0])
 
-	if elements[0].Text != "Near" {
-		t.Errorf("expected 'Near' first, got %s", elements[0].Text)
-	}
-	if elements[1].Text != "Middle" {
-		t.Errorf("expected 'Middle' second, got %s", elements[1].Text)
-	}
-	if elements[2].Text != "Far" {
-		t.Errorf("expected 'Far' third, got %s", elements[2].Text)
+	if len(result) != 1 || result[0].Text != "Overlapping" {
+		t.Fatalf("expected only 'Overlapping', got %v", elementTexts(result))
 	}
 }
 
-func TestSortByDistanceYReverse(t *testing.T) {
+func TestFilterBelowSkipsContainers(t *testing.T) {
+	anchor := &ParsedElement{Text: "Anchor", Bounds: core.Bounds{X: 100, Y: 0, Width: 100, Height: 50}}
 	elements := []*ParsedElement{
-		{Text: "Far", Bounds: core.Bounds{Y: 0, Height: 50}},     // bottom at 50
-		{Text: "Near", Bounds: core.Bounds{Y: 80, Height: 50}},   // bottom at 130
-		{Text: "Middle", Bounds: core.Bounds{Y: 40, Height: 50}}, // bottom at 90
+		{Text: "Screen", Bounds: core.Bounds{X: 0, Y: 0, Width: 1080, Height: 2000}},
+		anchor,
+		{Text: "Target", Bounds: core.Bounds{X: 100, Y: 300, Width: 100, Height: 50}},
 	}
 
-	sortByDistanceYReverse(elements, 150)
+	result := FilterBelow(elements, anchor)
 
-	if elements[0].Text != "Near" {
-		t.Errorf("expected 'Near' first (bottom at 130), got %s", elements[0].Text)
+	if len(result) != 1 || result[0].Text != "Target" {
+		t.Fatalf("expected only 'Target', got %v", elementTexts(result))
 	}
 }
 
-func TestSortByDistanceX(t *testing.T) {
+func TestFilterRightOfOrdersByCenterDistance(t *testing.T) {
+	// "Offset" has the nearer left edge but sits far lower; "Level" is closer overall
 	elements := []*ParsedElement{
-		{Text: "Far", Bounds: core.Bounds{X: 200}},
-		{Text: "Near", Bounds: core.Bounds{X: 110}},
-		{Text: "Middle", Bounds: core.Bounds{X: 150}},
+		{Text: "Anchor", Bounds: core.Bounds{X: 0, Y: 100, Width: 100, Height: 50}},
+		{Text: "Offset", Bounds: core.Bounds{X: 110, Y: 600, Width: 100, Height: 50}},
+		{Text: "Level", Bounds: core.Bounds{X: 200, Y: 100, Width: 100, Height: 50}},
 	}
 
-	sortByDistanceX(elements, 100)
+	result := FilterRightOf(elements, elements[0])
 
-	if elements[0].Text != "Near" {
-		t.Errorf("expected 'Near' first, got %s", elements[0].Text)
+	if len(result) != 2 || result[0].Text != "Level" {
+		t.Fatalf("expected 'Level' first, got %v", elementTexts(result))
 	}
 }
 
-func TestSortByDistanceXReverse(t *testing.T) {
-	elements := []*ParsedElement{
-		{Text: "Far", Bounds: core.Bounds{X: 0, Width: 50}},
-		{Text: "Near", Bounds: core.Bounds{X: 80, Width: 50}},
-		{Text: "Middle", Bounds: core.Bounds{X: 40, Width: 50}},
+func elementTexts(elements []*ParsedElement) []string {
+	texts := make([]string, len(elements))
+	for i, e := range elements {
+		texts[i] = e.Text
 	}
+	return texts
+}
 
-	sortByDistanceXReverse(elements, 150)
+func TestFilterBySelectorResourceIDExact(t *testing.T) {
+	elements, err := ParsePageSource(iconHierarchy)
+	if err != nil {
+		t.Fatalf("ParsePageSource failed: %v", err)
+	}
 
-	if elements[0].Text != "Near" {
-		t.Errorf("expected 'Near' first, got %s", elements[0].Text)
+	// Bare names match by substring, so the badge matches too
+	if got := FilterBySelector(elements, flow.Selector{ID: "settings_icon"}); len(got) != 2 {
+		t.Errorf("expected 2 partial matches, got %d", len(got))
+	}
+	// Fully-qualified IDs match exactly
+	got := FilterBySelector(elements, flow.Selector{ID: "com.app:id/settings_icon"})
+	if len(got) != 1 || got[0].ResourceID != "com.app:id/settings_icon" {
+		t.Errorf("expected exact match only, got %d elements", len(got))
 	}
 }
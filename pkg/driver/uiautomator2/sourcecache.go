@@ -0,0 +1,127 @@
+package uiautomator2
+
+import (
+	"time"
+
+	"github.com/devicelab-dev/maestro-runner/pkg/uiautomator2"
+)
+
+// DefaultSourceCacheTTL is how long a parsed page source is reused by lookups
+// in the same step. It is shorter than the 500ms sleep of the polling
+// assertions, so each of their polls still sees a fresh screen.
+const DefaultSourceCacheTTL = 250 * time.Millisecond
+
+// SetSourceCacheTTL sets how long pageSourceElements reuses a parsed page
+// source. 0 disables the cache so every lookup fetches /source.
+func (d *Driver) SetSourceCacheTTL(ttl time.Duration) {
+	d.sourceTTL = ttl
+	d.invalidateSource()
+}
+
+// invalidateSource makes the next pageSourceElements call refetch. It runs at
+// the start of every step, after every client, element or shell call that may
+// change the screen, and before busy polling loops retry.
+func (d *Driver) invalidateSource() {
+	d.source = nil
+}
+
+// cachedSource returns the cached page source if it is still fresh.
+func (d *Driver) cachedSource() []*ParsedElement {
+	if d.source == nil || d.sourceTTL <= 0 || time.Since(d.sourceAt) > d.sourceTTL {
+		return nil
+	}
+	return d.source
+}
+
+// uiChanged drops the cached page source after an action that may have
+// changed the screen and passes its error through. Element actions (Click,
+// SendKeys, Clear) go to the element's own client, so their callers wrap them.
+func (d *Driver) uiChanged(err error) error {
+	d.invalidateSource()
+	return err
+}
+
+// uiChangeClient wraps a UIA2Client and drops the driver's cached page source
+// after each call that may change what is on screen.
+type uiChangeClient struct {
+	UIA2Client
+	d *Driver
+}
+
+func (c uiChangeClient) changed(err error) error {
+	return c.d.uiChanged(err)
+}
+
+func (c uiChangeClient) Click(x, y int) error {
+	return c.changed(c.UIA2Client.Click(x, y))
+}
+
+func (c uiChangeClient) DoubleClick(x, y int) error {
+	return c.changed(c.UIA2Client.DoubleClick(x, y))
+}
+
+func (c uiChangeClient) DoubleClickElement(elementID string) error {
+	return c.changed(c.UIA2Client.DoubleClickElement(elementID))
+}
+
+func (c uiChangeClient) LongClick(x, y, durationMs int) error {
+	return c.changed(c.UIA2Client.LongClick(x, y, durationMs))
+}
+
+func (c uiChangeClient) LongClickElement(elementID string, durationMs int) error {
+	return c.changed(c.UIA2Client.LongClickElement(elementID, durationMs))
+}
+
+func (c uiChangeClient) ScrollInArea(area uiautomator2.RectModel, direction string, percent float64, speed int) error {
+	return c.changed(c.UIA2Client.ScrollInArea(area, direction, percent, speed))
+}
+
+func (c uiChangeClient) Swipe(elementID, direction string, percent float64, speed int) error {
+	return c.changed(c.UIA2Client.Swipe(elementID, direction, percent, speed))
+}
+
+func (c uiChangeClient) SwipeInArea(area uiautomator2.RectModel, direction string, percent float64, speed int) error {
+	return c.changed(c.UIA2Client.SwipeInArea(area, direction, percent, speed))
+}
+
+func (c uiChangeClient) PointerPath(points []uiautomator2.PointModel, durationsMs []int) error {
+	return c.changed(c.UIA2Client.PointerPath(points, durationsMs))
+}
+
+func (c uiChangeClient) ParallelSwipe(tracks [][2]uiautomator2.PointModel, durationMs int) error {
+	return c.changed(c.UIA2Client.ParallelSwipe(tracks, durationMs))
+}
+
+func (c uiChangeClient) Back() error {
+	return c.changed(c.UIA2Client.Back())
+}
+
+func (c uiChangeClient) HideKeyboard() error {
+	return c.changed(c.UIA2Client.HideKeyboard())
+}
+
+func (c uiChangeClient) PressKeyCode(keyCode int) error {
+	return c.changed(c.UIA2Client.PressKeyCode(keyCode))
+}
+
+func (c uiChangeClient) SendKeyActions(text string) error {
+	return c.changed(c.UIA2Client.SendKeyActions(text))
+}
+
+func (c uiChangeClient) SetOrientation(orientation string) error {
+	return c.changed(c.UIA2Client.SetOrientation(orientation))
+}
+
+// uiChangeDevice wraps a ShellExecutor and drops the cached page source after
+// each command: input events, activity starts and IME switches all change the
+// screen, and telling them apart from read-only commands isn't worth the risk.
+type uiChangeDevice struct {
+	ShellExecutor
+	d *Driver
+}
+
+func (s uiChangeDevice) Shell(cmd string) (string, error) {
+	out, err := s.ShellExecutor.Shell(cmd)
+	s.d.invalidateSource()
+	return out, err
+}
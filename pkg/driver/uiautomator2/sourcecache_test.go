@@ -0,0 +1,177 @@
+package uiautomator2
+
+import (
+	"testing"
+	"time"
+
+	"github.com/devicelab-dev/maestro-runner/pkg/flow"
+)
+
+const cacheHierarchy = `<?xml version="1.0" encoding="UTF-8"?>
+<hierarchy rotation="0">
+  <node class="android.widget.ScrollView" scrollable="true" displayed="true" bounds="[0,0][1080,1800]">
+    <node class="android.widget.TextView" text="Order #1" displayed="true" bounds="[0,100][1080,200]"/>
+    <node class="android.widget.TextView" text="Order #2" displayed="true" bounds="[0,200][1080,300]"/>
+    <node class="android.widget.TextView" text="Total: $10.00" displayed="true" bounds="[0,300][1080,400]"/>
+  </node>
+</hierarchy>`
+
+// newCountingDriver returns a driver whose client counts /source requests.
+func newCountingDriver() (*Driver, *int) {
+	calls := 0
+	client := &MockUIA2Client{
+		sourceFunc: func() (string, error) {
+			calls++
+			return cacheHierarchy, nil
+		},
+	}
+	return New(client, nil, nil), &calls
+}
+
+func threeSelectorWait() *flow.ExtendedWaitUntilStep {
+	return &flow.ExtendedWaitUntilStep{
+		Visible: []flow.Selector{
+			{TextRegex: `Order #1`},
+			{TextRegex: `Order #2`},
+			{TextRegex: `Total: .*`},
+		},
+	}
+}
+
+func TestSourceCacheReducesSourceRequests(t *testing.T) {
+	uncached, before := newCountingDriver()
+	uncached.SetSourceCacheTTL(0)
+	if result := uncached.Execute(threeSelectorWait()); !result.Success {
+		t.Fatalf("expected success, got: %s", result.Message)
+	}
+
+	cached, after := newCountingDriver()
+	if result := cached.Execute(threeSelectorWait()); !result.Success {
+		t.Fatalf("expected success, got: %s", result.Message)
+	}
+
+	if *before != 3 {
+		t.Errorf("expected 3 /source requests without cache, got %d", *before)
+	}
+	if *after != 1 {
+		t.Errorf("expected 1 /source request with cache, got %d", *after)
+	}
+}
+
+func TestSourceCacheClearedPerStep(t *testing.T) {
+	driver, calls := newCountingDriver()
+	driver.Execute(threeSelectorWait())
+	driver.Execute(threeSelectorWait())
+
+	if *calls != 2 {
+		t.Errorf("expected 1 /source request per step, got %d", *calls)
+	}
+}
+
+func TestSourceCacheInvalidatedByGesture(t *testing.T) {
+	driver, calls := newCountingDriver()
+
+	if _, err := driver.pageSourceElements(); err != nil {
+		t.Fatalf("pageSourceElements failed: %v", err)
+	}
+	if _, err := driver.pageSourceElements(); err != nil {
+		t.Fatalf("pageSourceElements failed: %v", err)
+	}
+	if *calls != 1 {
+		t.Fatalf("expected cached source to be reused, got %d requests", *calls)
+	}
+
+	if err := driver.client.Click(10, 10); err != nil {
+		t.Fatalf("Click failed: %v", err)
+	}
+	if _, err := driver.pageSourceElements(); err != nil {
+		t.Fatalf("pageSourceElements failed: %v", err)
+	}
+	if *calls != 2 {
+		t.Errorf("expected a fresh /source after a click, got %d requests", *calls)
+	}
+}
+
+func TestSourceCacheExpires(t *testing.T) {
+	driver, calls := newCountingDriver()
+	driver.SetSourceCacheTTL(10 * time.Millisecond)
+
+	if _, err := driver.pageSourceElements(); err != nil {
+		t.Fatalf("pageSourceElements failed: %v", err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if _, err := driver.pageSourceElements(); err != nil {
+		t.Fatalf("pageSourceElements failed: %v", err)
+	}
+
+	if *calls != 2 {
+		t.Errorf("expected an expired source to be refetched, got %d requests", *calls)
+	}
+}
+
+func TestFindScrollableElementReadsThroughCache(t *testing.T) {
+	driver, calls := newCountingDriver()
+
+	if _, err := driver.pageSourceElements(); err != nil {
+		t.Fatalf("pageSourceElements failed: %v", err)
+	}
+	info, count := driver.findScrollableElement(1000)
+	if info == nil || count != 1 {
+		t.Fatalf("expected one scrollable element, got %+v (%d)", info, count)
+	}
+	if *calls != 1 {
+		t.Errorf("expected findScrollableElement to reuse the cached source, got %d requests", *calls)
+	}
+}
+
+func TestSourceCacheInvalidatedByShell(t *testing.T) {
+	calls := 0
+	client := &MockUIA2Client{
+		sourceFunc: func() (string, error) {
+			calls++
+			return cacheHierarchy, nil
+		},
+	}
+	driver := New(client, nil, &MockShellExecutor{})
+
+	if _, err := driver.pageSourceElements(); err != nil {
+		t.Fatalf("pageSourceElements failed: %v", err)
+	}
+	if _, err := driver.device.Shell("input keyevent 66"); err != nil {
+		t.Fatalf("Shell failed: %v", err)
+	}
+	if _, err := driver.pageSourceElements(); err != nil {
+		t.Fatalf("pageSourceElements failed: %v", err)
+	}
+
+	if calls != 2 {
+		t.Errorf("expected a fresh /source after shell input, got %d requests", calls)
+	}
+}
+
+func TestSourceCacheInvalidatedByElementAction(t *testing.T) {
+	driver, calls := newCountingDriver()
+
+	if _, err := driver.pageSourceElements(); err != nil {
+		t.Fatalf("pageSourceElements failed: %v", err)
+	}
+	// Element.Click/SendKeys/Clear bypass the wrapped client
+	if err := driver.uiChanged(nil); err != nil {
+		t.Fatalf("uiChanged returned %v", err)
+	}
+	if _, err := driver.pageSourceElements(); err != nil {
+		t.Fatalf("pageSourceElements failed: %v", err)
+	}
+
+	if *calls != 2 {
+		t.Errorf("expected a fresh /source after an element action, got %d requests", *calls)
+	}
+}
+
+func TestNewWithoutDeviceKeepsDeviceNil(t *testing.T) {
+	driver := New(&MockUIA2Client{}, nil, nil)
+
+	if driver.device != nil {
+		t.Errorf("expected no device, got %#v", driver.device)
+	}
+}
@@ -3,14 +3,19 @@ package wda
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"strings"
+	"syscall"
 	"time"
 
+	"github.com/devicelab-dev/maestro-runner/pkg/core"
 	"github.com/devicelab-dev/maestro-runner/pkg/logger"
 )
 
@@ -19,6 +24,70 @@ type Client struct {
 	baseURL    string
 	sessionID  string
 	httpClient *http.Client
+
+	// caps are the capabilities of the last CreateSession, reused by RecreateSession
+	caps map[string]interface{}
+	// sessionLost is set when WDA answers "invalid session id"
+	sessionLost bool
+	// uiChanges counts requests that may have changed the screen, so the
+	// driver can tell whether a cached page source is stale
+	uiChanges uint64
+}
+
+// queryPostSuffixes are POST endpoints that only read from WDA; every other
+// POST or DELETE is assumed to change the UI.
+var queryPostSuffixes = []string{"/element", "/elements", "/wda/apps/state", "/wda/getPasteboard"}
+
+// countUIChange bumps uiChanges unless path is a read-only POST endpoint.
+func (c *Client) countUIChange(path string) {
+	for _, suffix := range queryPostSuffixes {
+		if strings.HasSuffix(path, suffix) {
+			return
+		}
+	}
+	c.uiChanges++
+}
+
+// DefaultHTTPTimeout bounds each WDA request unless SetTimeout changes it.
+const DefaultHTTPTimeout = 60 * time.Second
+
+// ErrInvalidSession matches errors for requests WDA rejected because the
+// session no longer exists (e.g. the app was killed or reinstalled).
+var ErrInvalidSession = errors.New("invalid session id")
+
+// ErrWDA matches errors WDA reported in its response payload, as opposed to
+// requests that never got an answer. Those are classified as
+// core.ErrTimeout or core.ErrServerUnreachable execution errors. Payload
+// errors with a 4xx status also match core.ErrRequestRejected, so the
+// executor doesn't retry a request WDA will reject again.
+var ErrWDA = errors.New("WDA error")
+
+type invalidSessionError struct {
+	message string
+	status  int
+}
+
+func (e *invalidSessionError) Error() string { return "WDA error: " + e.message }
+
+func (e *invalidSessionError) Is(target error) bool {
+	return target == ErrInvalidSession || target == ErrWDA ||
+		(target == core.ErrRequestRejected && isClientError(e.status))
+}
+
+type wdaError struct {
+	message string
+	status  int
+}
+
+func (e *wdaError) Error() string { return "WDA error: " + e.message }
+
+func (e *wdaError) Is(target error) bool {
+	return target == ErrWDA || (target == core.ErrRequestRejected && isClientError(e.status))
+}
+
+// isClientError reports whether an HTTP status is a 4xx.
+func isClientError(status int) bool {
+	return status >= 400 && status < 500
 }
 
 // NewClient creates a new WDA client.
@@ -26,11 +95,33 @@ func NewClient(port uint16) *Client {
 	return &Client{
 		baseURL: fmt.Sprintf("http://localhost:%d", port),
 		httpClient: &http.Client{
-			Timeout: 60 * time.Second,
+			Timeout: DefaultHTTPTimeout,
 		},
 	}
 }
 
+// SetTimeout sets how long a single WDA request may take before it fails
+// with a timeout error. Zero or negative keeps the current timeout.
+func (c *Client) SetTimeout(d time.Duration) {
+	if d > 0 {
+		c.httpClient.Timeout = d
+	}
+}
+
+// classifyRequestError turns a failed HTTP round trip into a core execution
+// error, so callers can tell a hung device (timeout) from a WDA server that
+// is gone (connection refused). Other errors are returned unchanged.
+func classifyRequestError(method, path string, err error) error {
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()):
+		return core.ErrTimeout.WithMessage(fmt.Sprintf("WDA %s %s timed out", method, path)).WithCause(err)
+	case errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET):
+		return core.ErrServerUnreachable.WithCause(err)
+	}
+	return err
+}
+
 // Session management
 
 // CreateSession creates a new WDA session.
@@ -54,10 +145,42 @@ func (c *Client) CreateSession(bundleID string, alertAction string) error {
 
 	resp, err := c.post("/session", caps)
 	if err != nil {
-		return fmt.Errorf("failed to create session: %w", err)
+		// Usually transient or caused by a stuck prior session.
+		// Clear any existing session and retry once with the same caps.
+		logger.Warn("WDA session creation failed, retrying after deleting stale session: %v", err)
+		c.deleteStaleSession()
+		resp, err = c.post("/session", caps)
+		if err != nil {
+			return fmt.Errorf("failed to create session: %w", err)
+		}
 	}
 
-	// Extract session ID
+	c.caps = caps
+	c.setSession(resp)
+	return nil
+}
+
+// RecreateSession replaces a lost session with a new one created from the
+// capabilities of the last CreateSession.
+func (c *Client) RecreateSession() error {
+	if c.caps == nil {
+		return fmt.Errorf("no session capabilities to recreate from")
+	}
+	c.sessionID = ""
+	resp, err := c.post("/session", c.caps)
+	if err != nil {
+		return fmt.Errorf("failed to recreate session: %w", err)
+	}
+	c.setSession(resp)
+	if c.sessionID == "" {
+		return fmt.Errorf("failed to recreate session: no session id in response")
+	}
+	return nil
+}
+
+// setSession stores the session ID from a POST /session response.
+func (c *Client) setSession(resp map[string]interface{}) {
+	c.sessionLost = false
 	if value, ok := resp["value"].(map[string]interface{}); ok {
 		if sessionID, ok := value["sessionId"].(string); ok {
 			c.sessionID = sessionID
@@ -68,8 +191,16 @@ func (c *Client) CreateSession(bundleID string, alertAction string) error {
 			c.sessionID = sessionID
 		}
 	}
+}
 
-	return nil
+// takeSessionLost reports whether a request since the last call hit an
+// invalid session, and clears the flag.
+func (c *Client) takeSessionLost() bool {
+	if c == nil || !c.sessionLost {
+		return false
+	}
+	c.sessionLost = false
+	return true
 }
 
 // UpdateSettings updates WDA session settings.
@@ -101,6 +232,54 @@ func (c *Client) DeleteSession() error {
 	return err
 }
 
+// deleteStaleSession deletes the client's session, or the one WDA reports
+// in /status if the client has none (e.g. left behind by a previous run).
+func (c *Client) deleteStaleSession() {
+	if c.sessionID == "" {
+		status, err := c.Status()
+		if err != nil {
+			return
+		}
+		if id, ok := status["sessionId"].(string); ok {
+			c.sessionID = id
+		}
+	}
+	if err := c.DeleteSession(); err != nil {
+		logger.Debug("failed to delete stale WDA session: %v", err)
+	}
+}
+
+// SessionAlive reports whether WDA still knows the client's session. Only an
+// "invalid session id" answer counts as stale; a probe that fails for any
+// other reason leaves the session to the request that follows.
+func (c *Client) SessionAlive() bool {
+	if c.sessionID == "" {
+		return false
+	}
+	if _, err := c.get(c.sessionPath("")); errors.Is(err, ErrInvalidSession) {
+		// Reported through the result, not as a loss for the next step
+		c.sessionLost = false
+		return false
+	}
+	return true
+}
+
+// AttachSession adopts the session WDA reports in /status, e.g. one left
+// open by an earlier run, and reports whether there was one.
+func (c *Client) AttachSession() bool {
+	status, err := c.Status()
+	if err != nil {
+		return false
+	}
+	id, ok := status["sessionId"].(string)
+	if !ok || id == "" {
+		return false
+	}
+	c.sessionID = id
+	c.sessionLost = false
+	return true
+}
+
 // HasSession returns true if a session is active.
 func (c *Client) HasSession() bool {
 	return c.sessionID != ""
@@ -154,6 +333,25 @@ func (c *Client) ActivateApp(bundleID string) error {
 	return err
 }
 
+// AppStateNotInstalled is the XCUIApplicationState WDA reports for an app
+// that is not on the device.
+const AppStateNotInstalled = 0
+
+// AppState returns the app's XCUIApplicationState (0 not installed,
+// 1 not running, 2-3 background, 4 foreground).
+func (c *Client) AppState(bundleID string) (int, error) {
+	resp, err := c.post(c.sessionPath("/wda/apps/state"), map[string]interface{}{
+		"bundleId": bundleID,
+	})
+	if err != nil {
+		return 0, err
+	}
+	if value, ok := resp["value"].(float64); ok {
+		return int(value), nil
+	}
+	return 0, fmt.Errorf("invalid app state response")
+}
+
 // Touch actions
 
 // Tap performs a tap at coordinates.
@@ -165,6 +363,80 @@ func (c *Client) Tap(x, y float64) error {
 	return err
 }
 
+// TapWithActions performs a tap at coordinates using a W3C pointer action sequence.
+// Some apps ignore /wda/tap but respond to synthesized touch events.
+func (c *Client) TapWithActions(x, y float64) error {
+	_, err := c.post(c.sessionPath("/actions"), map[string]interface{}{
+		"actions": []map[string]interface{}{
+			{
+				"type":       "pointer",
+				"id":         "finger1",
+				"parameters": map[string]string{"pointerType": "touch"},
+				"actions": []map[string]interface{}{
+					{"type": "pointerMove", "duration": 0, "x": x, "y": y},
+					{"type": "pointerDown", "button": 0},
+					{"type": "pause", "duration": 50},
+					{"type": "pointerUp", "button": 0},
+				},
+			},
+		},
+	})
+	return err
+}
+
+// PointerPath drags one finger through path using a W3C pointer action
+// sequence. durationsMs[i] is the time taken to move from path[i] to path[i+1].
+func (c *Client) PointerPath(path []core.PathPoint, durationsMs []int) error {
+	if len(path) == 0 {
+		return nil
+	}
+	moves := []map[string]interface{}{
+		{"type": "pointerMove", "duration": 0, "x": path[0].X, "y": path[0].Y},
+		{"type": "pointerDown", "button": 0},
+	}
+	for i, p := range path[1:] {
+		duration := 0
+		if i < len(durationsMs) {
+			duration = durationsMs[i]
+		}
+		moves = append(moves, map[string]interface{}{"type": "pointerMove", "duration": duration, "x": p.X, "y": p.Y})
+	}
+	moves = append(moves, map[string]interface{}{"type": "pointerUp", "button": 0})
+
+	_, err := c.post(c.sessionPath("/actions"), map[string]interface{}{
+		"actions": []map[string]interface{}{
+			{
+				"type":       "pointer",
+				"id":         "finger1",
+				"parameters": map[string]string{"pointerType": "touch"},
+				"actions":    moves,
+			},
+		},
+	})
+	return err
+}
+
+// ParallelSwipe moves one finger per track from its start to its end at the
+// same time, using one W3C pointer source per finger.
+func (c *Client) ParallelSwipe(tracks [][2]core.PathPoint, durationMs int) error {
+	sources := make([]map[string]interface{}, len(tracks))
+	for i, track := range tracks {
+		sources[i] = map[string]interface{}{
+			"type":       "pointer",
+			"id":         fmt.Sprintf("finger%d", i+1),
+			"parameters": map[string]string{"pointerType": "touch"},
+			"actions": []map[string]interface{}{
+				{"type": "pointerMove", "duration": 0, "x": track[0].X, "y": track[0].Y},
+				{"type": "pointerDown", "button": 0},
+				{"type": "pointerMove", "duration": durationMs, "x": track[1].X, "y": track[1].Y},
+				{"type": "pointerUp", "button": 0},
+			},
+		}
+	}
+	_, err := c.post(c.sessionPath("/actions"), map[string]interface{}{"actions": sources})
+	return err
+}
+
 // DoubleTap performs a double tap at coordinates.
 func (c *Client) DoubleTap(x, y float64) error {
 	_, err := c.post(c.sessionPath("/wda/doubleTap"), map[string]interface{}{
@@ -220,6 +492,34 @@ func (c *Client) ElementClear(elementID string) error {
 	return err
 }
 
+// SetPasteboard replaces the device pasteboard with plain text.
+func (c *Client) SetPasteboard(text string) error {
+	_, err := c.post(c.sessionPath("/wda/setPasteboard"), map[string]interface{}{
+		"content":     base64.StdEncoding.EncodeToString([]byte(text)),
+		"contentType": "plaintext",
+	})
+	return err
+}
+
+// GetPasteboard returns the device pasteboard's plain text.
+func (c *Client) GetPasteboard() (string, error) {
+	resp, err := c.post(c.sessionPath("/wda/getPasteboard"), map[string]interface{}{
+		"contentType": "plaintext",
+	})
+	if err != nil {
+		return "", err
+	}
+	value, ok := resp["value"].(string)
+	if !ok {
+		return "", fmt.Errorf("invalid pasteboard response")
+	}
+	data, err := base64Decode(value)
+	if err != nil {
+		return "", fmt.Errorf("decode pasteboard: %w", err)
+	}
+	return string(data), nil
+}
+
 // Screen
 
 // Screenshot captures the screen as PNG.
@@ -267,6 +567,23 @@ func (c *Client) WindowSize() (width, height int, err error) {
 	return 0, 0, fmt.Errorf("invalid window size response")
 }
 
+// StatusBarHeight returns the status bar height in points from /wda/screen.
+func (c *Client) StatusBarHeight() (float64, error) {
+	resp, err := c.get(c.sessionPath("/wda/screen"))
+	if err != nil {
+		return 0, err
+	}
+
+	if value, ok := resp["value"].(map[string]interface{}); ok {
+		if size, ok := value["statusBarSize"].(map[string]interface{}); ok {
+			if h, ok := size["height"].(float64); ok {
+				return h, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("invalid screen response")
+}
+
 // Device control
 
 // PressButton presses a hardware button (home, volumeUp, volumeDown).
@@ -294,6 +611,18 @@ func (c *Client) Unlock() error {
 	return err
 }
 
+// IsLocked reports whether the device lock screen is showing.
+func (c *Client) IsLocked() (bool, error) {
+	resp, err := c.get(c.sessionPath("/wda/locked"))
+	if err != nil {
+		return false, err
+	}
+	if value, ok := resp["value"].(bool); ok {
+		return value, nil
+	}
+	return false, fmt.Errorf("invalid locked response")
+}
+
 // AcceptAlert accepts the current system alert (taps Allow/OK).
 func (c *Client) AcceptAlert() error {
 	_, err := c.post(c.sessionPath("/alert/accept"), nil)
@@ -306,6 +635,19 @@ func (c *Client) DismissAlert() error {
 	return err
 }
 
+// AlertText returns the text of the current system alert.
+// Returns an error if no alert is present.
+func (c *Client) AlertText() (string, error) {
+	resp, err := c.get(c.sessionPath("/alert/text"))
+	if err != nil {
+		return "", err
+	}
+	if value, ok := resp["value"].(string); ok {
+		return value, nil
+	}
+	return "", fmt.Errorf("invalid alert text response")
+}
+
 // GetOrientation returns the current orientation.
 func (c *Client) GetOrientation() (string, error) {
 	resp, err := c.get(c.sessionPath("/orientation"))
@@ -422,6 +764,18 @@ func (c *Client) ElementText(elementID string) (string, error) {
 	return "", nil
 }
 
+// ElementValue returns an element's value attribute (the typed text of a field).
+func (c *Client) ElementValue(elementID string) (string, error) {
+	resp, err := c.get(c.sessionPath(fmt.Sprintf("/element/%s/attribute/value", elementID)))
+	if err != nil {
+		return "", err
+	}
+	if value, ok := resp["value"].(string); ok {
+		return value, nil
+	}
+	return "", nil
+}
+
 // ElementDisplayed checks if an element is visible.
 func (c *Client) ElementDisplayed(elementID string) (bool, error) {
 	resp, err := c.get(c.sessionPath(fmt.Sprintf("/element/%s/displayed", elementID)))
@@ -490,7 +844,7 @@ func (c *Client) get(path string) (map[string]interface{}, error) {
 
 	if err != nil {
 		logger.Error("WDA GET %s failed (%dms): %v", path, duration, err)
-		return nil, err
+		return nil, classifyRequestError(http.MethodGet, path, err)
 	}
 	defer func() {
 		if err := resp.Body.Close(); err != nil {
@@ -519,13 +873,14 @@ func (c *Client) post(path string, body interface{}) (map[string]interface{}, er
 	}
 
 	logger.Debug("WDA POST %s body=%s", path, bodyStr)
+	c.countUIChange(path)
 
 	resp, err := c.httpClient.Post(c.baseURL+path, "application/json", reqBody)
 	duration := time.Since(start).Milliseconds()
 
 	if err != nil {
 		logger.Error("WDA POST %s failed (%dms): %v", path, duration, err)
-		return nil, err
+		return nil, classifyRequestError(http.MethodPost, path, err)
 	}
 	defer func() {
 		if err := resp.Body.Close(); err != nil {
@@ -540,6 +895,7 @@ func (c *Client) post(path string, body interface{}) (map[string]interface{}, er
 func (c *Client) delete(path string) (map[string]interface{}, error) {
 	start := time.Now()
 	logger.Debug("WDA DELETE %s", path)
+	c.uiChanges++
 
 	req, err := http.NewRequest(http.MethodDelete, c.baseURL+path, nil)
 	if err != nil {
@@ -551,7 +907,7 @@ func (c *Client) delete(path string) (map[string]interface{}, error) {
 
 	if err != nil {
 		logger.Error("WDA DELETE %s failed (%dms): %v", path, duration, err)
-		return nil, err
+		return nil, classifyRequestError(http.MethodDelete, path, err)
 	}
 	defer func() {
 		if err := resp.Body.Close(); err != nil {
@@ -579,7 +935,11 @@ func (c *Client) parseResponse(resp *http.Response) (map[string]interface{}, err
 			if msg, ok := value["message"].(string); ok {
 				message = msg
 			}
-			return nil, fmt.Errorf("WDA error: %s", message)
+			if errMsg == ErrInvalidSession.Error() {
+				c.sessionLost = true
+				return nil, &invalidSessionError{message: message, status: resp.StatusCode}
+			}
+			return nil, &wdaError{message: message, status: resp.StatusCode}
 		}
 	}
 
@@ -3,11 +3,16 @@ package wda
 import (
 	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/devicelab-dev/maestro-runner/pkg/core"
 )
 
 // mockWDAServer creates a mock WDA server for testing
@@ -36,6 +41,113 @@ func TestNewClient(t *testing.T) {
 	}
 }
 
+// TestClientTimeout tests that a hung WDA request fails with a timeout error
+func TestClientTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := mockWDAServer(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		jsonResponse(w, map[string]interface{}{"value": nil})
+	})
+	defer server.Close()
+	defer close(release)
+
+	client := NewClient(8100)
+	client.baseURL = server.URL
+	client.SetTimeout(50 * time.Millisecond)
+
+	_, err := client.Status()
+	if err == nil {
+		t.Fatal("Expected timeout error")
+	}
+	if !errors.Is(err, core.ErrTimeout) {
+		t.Errorf("Expected core.ErrTimeout, got %v", err)
+	}
+	var execErr *core.ExecutionError
+	if !errors.As(err, &execErr) || execErr.Category != core.ErrCategoryTimeout {
+		t.Errorf("Expected timeout category, got %v", err)
+	}
+}
+
+// TestClientSetTimeoutIgnoresNonPositive tests that SetTimeout keeps the default for d <= 0
+func TestClientSetTimeoutIgnoresNonPositive(t *testing.T) {
+	client := NewClient(8100)
+	client.SetTimeout(0)
+	if client.httpClient.Timeout != DefaultHTTPTimeout {
+		t.Errorf("Expected timeout %v, got %v", DefaultHTTPTimeout, client.httpClient.Timeout)
+	}
+}
+
+// TestClientConnectionRefused tests that an unreachable WDA server is classified
+func TestClientConnectionRefused(t *testing.T) {
+	server := mockWDAServer(func(w http.ResponseWriter, r *http.Request) {})
+	url := server.URL
+	server.Close()
+
+	client := NewClient(8100)
+	client.baseURL = url
+
+	_, err := client.Status()
+	if !errors.Is(err, core.ErrServerUnreachable) {
+		t.Errorf("Expected core.ErrServerUnreachable, got %v", err)
+	}
+}
+
+// TestClientWDAErrorPayload tests that WDA error responses match ErrWDA
+func TestClientWDAErrorPayload(t *testing.T) {
+	server := mockWDAServer(func(w http.ResponseWriter, r *http.Request) {
+		jsonResponse(w, map[string]interface{}{
+			"value": map[string]interface{}{"error": "no such element", "message": "unable to find element"},
+		})
+	})
+	defer server.Close()
+
+	client := NewClient(8100)
+	client.baseURL = server.URL
+
+	_, err := client.Status()
+	if !errors.Is(err, ErrWDA) {
+		t.Errorf("Expected ErrWDA, got %v", err)
+	}
+	if errors.Is(err, ErrInvalidSession) {
+		t.Errorf("Did not expect ErrInvalidSession, got %v", err)
+	}
+}
+
+// TestClientWDAErrorRejected tests that only 4xx WDA errors match core.ErrRequestRejected
+func TestClientWDAErrorRejected(t *testing.T) {
+	for _, tc := range []struct {
+		status       int
+		wantRejected bool
+	}{
+		{http.StatusBadRequest, true},
+		{http.StatusNotFound, true},
+		{http.StatusInternalServerError, false},
+	} {
+		server := mockWDAServer(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(tc.status)
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"value": map[string]interface{}{"error": "invalid argument", "message": "bad selector"},
+			})
+		})
+
+		client := NewClient(8100)
+		client.baseURL = server.URL
+
+		_, err := client.Status()
+		server.Close()
+		if !errors.Is(err, ErrWDA) {
+			t.Errorf("status %d: expected ErrWDA, got %v", tc.status, err)
+		}
+		if got := errors.Is(err, core.ErrRequestRejected); got != tc.wantRejected {
+			t.Errorf("status %d: errors.Is(err, ErrRequestRejected) = %v, want %v", tc.status, got, tc.wantRejected)
+		}
+		if got := core.IsRetryable(err); got == tc.wantRejected {
+			t.Errorf("status %d: IsRetryable = %v, want %v", tc.status, got, !tc.wantRejected)
+		}
+	}
+}
+
 // TestCreateSession tests session creation
 func TestCreateSession(t *testing.T) {
 	server := mockWDAServer(func(w http.ResponseWriter, r *http.Request) {
@@ -90,6 +202,90 @@ func TestCreateSessionAlternateFormat(t *testing.T) {
 	}
 }
 
+// TestCreateSessionRetriesAfterDeletingStaleSession tests that a failed session
+// creation deletes the stale session reported by /status and retries once
+func TestCreateSessionRetriesAfterDeletingStaleSession(t *testing.T) {
+	var requests []string
+	var bodies []string
+	server := mockWDAServer(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/session":
+			body, _ := io.ReadAll(r.Body)
+			bodies = append(bodies, string(body))
+			if len(bodies) == 1 {
+				jsonResponse(w, map[string]interface{}{
+					"value": map[string]interface{}{
+						"error":   "session not created",
+						"message": "A session is already active",
+					},
+				})
+				return
+			}
+			jsonResponse(w, map[string]interface{}{
+				"value": map[string]interface{}{"sessionId": "fresh-session"},
+			})
+		case r.Method == "GET" && r.URL.Path == "/status":
+			jsonResponse(w, map[string]interface{}{"sessionId": "stale-session", "value": map[string]interface{}{}})
+		default:
+			jsonResponse(w, map[string]interface{}{"value": nil})
+		}
+	})
+	defer server.Close()
+
+	client := &Client{
+		baseURL:    server.URL,
+		httpClient: http.DefaultClient,
+	}
+
+	if err := client.CreateSession("com.example.app", "accept"); err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+
+	expected := []string{"POST /session", "GET /status", "DELETE /session/stale-session", "POST /session"}
+	if strings.Join(requests, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected requests %v, got %v", expected, requests)
+	}
+	if len(bodies) != 2 || bodies[0] != bodies[1] {
+		t.Errorf("Expected retry with the same caps, got %v", bodies)
+	}
+	if client.sessionID != "fresh-session" {
+		t.Errorf("Expected sessionID 'fresh-session', got '%s'", client.sessionID)
+	}
+}
+
+// TestCreateSessionRetryFails tests that session creation gives up after one retry
+func TestCreateSessionRetryFails(t *testing.T) {
+	posts := 0
+	server := mockWDAServer(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" && r.URL.Path == "/session" {
+			posts++
+			jsonResponse(w, map[string]interface{}{
+				"value": map[string]interface{}{"error": "session not created"},
+			})
+			return
+		}
+		jsonResponse(w, map[string]interface{}{"value": map[string]interface{}{}})
+	})
+	defer server.Close()
+
+	client := &Client{
+		baseURL:    server.URL,
+		httpClient: http.DefaultClient,
+		sessionID:  "old-session",
+	}
+
+	if err := client.CreateSession("com.example.app", ""); err == nil {
+		t.Fatal("Expected error when retry also fails")
+	}
+	if posts != 2 {
+		t.Errorf("Expected 2 session attempts, got %d", posts)
+	}
+	if client.sessionID != "" {
+		t.Errorf("Expected old session to be cleared, got '%s'", client.sessionID)
+	}
+}
+
 // TestCreateSessionWithAlertAction tests that alertAction is included in session caps
 func TestCreateSessionWithAlertAction(t *testing.T) {
 	var receivedBody map[string]interface{}
@@ -158,6 +354,64 @@ func TestCreateSessionWithoutAlertAction(t *testing.T) {
 	}
 }
 
+// TestRecreateSessionReusesCaps tests that an invalid session error is
+// detected and the session is recreated from the original capabilities.
+func TestRecreateSessionReusesCaps(t *testing.T) {
+	var bodies []string
+	server := mockWDAServer(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" && r.URL.Path == "/session" {
+			body, _ := io.ReadAll(r.Body)
+			bodies = append(bodies, string(body))
+			jsonResponse(w, map[string]interface{}{
+				"value": map[string]interface{}{"sessionId": fmt.Sprintf("session-%d", len(bodies))},
+			})
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+		jsonResponse(w, map[string]interface{}{
+			"value": map[string]interface{}{"error": "invalid session id", "message": "Session does not exist"},
+		})
+	})
+	defer server.Close()
+
+	client := &Client{
+		baseURL:    server.URL,
+		httpClient: http.DefaultClient,
+	}
+	if err := client.CreateSession("com.example.app", "accept"); err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+
+	_, err := client.Source()
+	if !errors.Is(err, ErrInvalidSession) {
+		t.Fatalf("Expected ErrInvalidSession, got %v", err)
+	}
+	if err.Error() != "WDA error: Session does not exist" {
+		t.Errorf("Unexpected error text: %v", err)
+	}
+	if !client.takeSessionLost() || client.takeSessionLost() {
+		t.Error("Expected the session loss to be reported exactly once")
+	}
+
+	if err := client.RecreateSession(); err != nil {
+		t.Fatalf("RecreateSession failed: %v", err)
+	}
+	if client.SessionID() != "session-2" {
+		t.Errorf("Expected session-2, got %q", client.SessionID())
+	}
+	if len(bodies) != 2 || bodies[0] != bodies[1] {
+		t.Errorf("Expected the same capabilities twice, got %v", bodies)
+	}
+}
+
+// TestRecreateSessionWithoutCaps tests that recreating needs a prior session.
+func TestRecreateSessionWithoutCaps(t *testing.T) {
+	client := &Client{}
+	if err := client.RecreateSession(); err == nil {
+		t.Error("Expected error without stored capabilities")
+	}
+}
+
 // TestDeleteSession tests session deletion
 func TestDeleteSession(t *testing.T) {
 	server := mockWDAServer(func(w http.ResponseWriter, r *http.Request) {
@@ -782,6 +1036,58 @@ func TestDismissAlertClientError(t *testing.T) {
 	}
 }
 
+// TestAlertTextClient tests reading the current alert's text
+func TestAlertTextClient(t *testing.T) {
+	server := mockWDAServer(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/alert/text") && r.Method == "GET" {
+			jsonResponse(w, map[string]interface{}{"value": "Allow notifications?"})
+			return
+		}
+		jsonResponse(w, map[string]interface{}{"status": 0})
+	})
+	defer server.Close()
+
+	client := &Client{
+		baseURL:    server.URL,
+		httpClient: http.DefaultClient,
+		sessionID:  "test-session",
+	}
+
+	text, err := client.AlertText()
+	if err != nil {
+		t.Fatalf("AlertText failed: %v", err)
+	}
+	if text != "Allow notifications?" {
+		t.Errorf("Expected 'Allow notifications?', got %q", text)
+	}
+}
+
+// TestIsLocked tests reading the lock screen state
+func TestIsLocked(t *testing.T) {
+	server := mockWDAServer(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/wda/locked") && r.Method == "GET" {
+			jsonResponse(w, map[string]interface{}{"value": true})
+			return
+		}
+		jsonResponse(w, map[string]interface{}{"status": 0})
+	})
+	defer server.Close()
+
+	client := &Client{
+		baseURL:    server.URL,
+		httpClient: http.DefaultClient,
+		sessionID:  "test-session",
+	}
+
+	locked, err := client.IsLocked()
+	if err != nil {
+		t.Fatalf("IsLocked failed: %v", err)
+	}
+	if !locked {
+		t.Error("Expected device to be locked")
+	}
+}
+
 // TestGetOrientation tests orientation retrieval
 func TestGetOrientation(t *testing.T) {
 	server := mockWDAServer(func(w http.ResponseWriter, r *http.Request) {
@@ -1188,3 +1494,52 @@ func TestBase64DecodeInvalid(t *testing.T) {
 		t.Error("Expected error for invalid base64")
 	}
 }
+
+func TestSetPasteboard(t *testing.T) {
+	var payload map[string]interface{}
+	server := mockWDAServer(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/wda/setPasteboard") && r.Method == "POST" {
+			_ = json.NewDecoder(r.Body).Decode(&payload)
+		}
+		jsonResponse(w, map[string]interface{}{"status": 0})
+	})
+	defer server.Close()
+
+	client := &Client{
+		baseURL:    server.URL,
+		httpClient: http.DefaultClient,
+		sessionID:  "test-session",
+	}
+
+	if err := client.SetPasteboard("👍"); err != nil {
+		t.Fatalf("SetPasteboard failed: %v", err)
+	}
+	if payload["content"] != "8J+RjQ==" || payload["contentType"] != "plaintext" {
+		t.Errorf("Unexpected pasteboard payload: %v", payload)
+	}
+}
+
+func TestGetPasteboard(t *testing.T) {
+	server := mockWDAServer(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/wda/getPasteboard") && r.Method == "POST" {
+			jsonResponse(w, map[string]interface{}{"value": "8J+RjQ=="})
+			return
+		}
+		jsonResponse(w, map[string]interface{}{"status": 0})
+	})
+	defer server.Close()
+
+	client := &Client{
+		baseURL:    server.URL,
+		httpClient: http.DefaultClient,
+		sessionID:  "test-session",
+	}
+
+	text, err := client.GetPasteboard()
+	if err != nil {
+		t.Fatalf("GetPasteboard failed: %v", err)
+	}
+	if text != "👍" {
+		t.Errorf("Expected 👍, got %q", text)
+	}
+}
@@ -3,7 +3,8 @@ package wda
 import (
 	"context"
 	"fmt"
-	"math/rand"
+	"io"
+	"os"
 	"os/exec"
 	"strconv"
 	"strings"
@@ -15,6 +16,7 @@ import (
 	"github.com/devicelab-dev/maestro-runner/pkg/core"
 	"github.com/devicelab-dev/maestro-runner/pkg/flow"
 	"github.com/devicelab-dev/maestro-runner/pkg/logger"
+	"github.com/devicelab-dev/maestro-runner/pkg/simulator"
 )
 
 // Tap commands
@@ -35,7 +37,17 @@ func (d *Driver) tapOn(step *flow.TapOnStep) *core.CommandResult {
 		}
 	}
 
-	info, err := d.findElementForTap(step.Selector, step.Optional, step.TimeoutMs)
+	if step.WaitToSettleTimeoutMs > 0 {
+		if _, settled := d.waitForScreenToSettle(step.WaitToSettleTimeoutMs); !settled {
+			logger.Debug("tapOn: screen still changing after %dms, tapping anyway", step.WaitToSettleTimeoutMs)
+		}
+	}
+
+	find := d.findElementForTap
+	if step.TapParent {
+		find = d.findTapParent
+	}
+	info, err := find(step.Selector, step.Optional, step.TimeoutMs)
 	if err != nil {
 		if step.Optional {
 			return successResult("Optional element not found, skipping tap", nil)
@@ -44,6 +56,7 @@ func (d *Driver) tapOn(step *flow.TapOnStep) *core.CommandResult {
 	}
 
 	// If Point is specified WITH selector, tap at relative position within element bounds
+	limit := step.TapLimit()
 	if step.Point != "" && info != nil && info.Bounds.Width > 0 {
 		xPct, yPct, parseErr := parsePercentageCoords(step.Point)
 		if parseErr != nil {
@@ -51,12 +64,33 @@ func (d *Driver) tapOn(step *flow.TapOnStep) *core.CommandResult {
 		}
 		x := float64(info.Bounds.X) + float64(info.Bounds.Width)*xPct
 		y := float64(info.Bounds.Y) + float64(info.Bounds.Height)*yPct
-		if err := d.client.Tap(x, y); err != nil {
+		taps, err := d.tapUntilChanged(limit, func() error { return d.tap(x, y) })
+		if err != nil {
 			return errorResult(err, "Tap at relative point failed")
 		}
-		return successResult(fmt.Sprintf("Tapped at relative point (%.0f, %.0f) on element", x, y), info)
+		return successResult(fmt.Sprintf("Tapped at relative point (%.0f, %.0f) on element%s", x, y, tapCountSuffix(taps, limit)), info)
 	}
 
+	// Fixed pixel offset from the element's top-left corner (e.g. a small close icon)
+	if dx, dy, ok := step.PixelOffset(); ok && info != nil {
+		x, y := info.Bounds.Offset(dx, dy)
+		taps, err := d.tapUntilChanged(limit, func() error { return d.tap(float64(x), float64(y)) })
+		if err != nil {
+			return errorResult(err, "Tap at offset failed")
+		}
+		return successResult(fmt.Sprintf("Tapped at offset (%d, %d) on element%s", x, y, tapCountSuffix(taps, limit)), info)
+	}
+
+	taps, err := d.tapUntilChanged(limit, func() error { return d.tapElement(info) })
+	if err != nil {
+		return errorResult(err, "Tap failed")
+	}
+
+	return successResult("Tapped element"+tapCountSuffix(taps, limit), info)
+}
+
+// tapElement taps a found element, verifying focus for text fields.
+func (d *Driver) tapElement(info *core.ElementInfo) error {
 	// Determine if element is a text field (needs focus verification)
 	isTextField := false
 	if info.ID != "" {
@@ -68,33 +102,64 @@ func (d *Driver) tapOn(step *flow.TapOnStep) *core.CommandResult {
 	// Strategy: ElementClick first (WDA's internal element targeting handles z-order),
 	// then coordinate tap as fallback. For text fields, verify focus after each attempt
 	// because ElementClick can return success without actually focusing the field.
-	tapped := false
-	if info.ID != "" {
+	// In actions mode the element click is skipped so the tap goes through /actions.
+	if info.ID != "" && d.tapMode != TapModeActions {
 		if err := d.client.ElementClick(info.ID); err == nil {
-			tapped = true
-			if isTextField {
-				time.Sleep(100 * time.Millisecond)
-				if _, err := d.client.GetActiveElement(); err != nil {
-					tapped = false // No focus — retry with coordinate tap
-				}
+			if !isTextField {
+				return nil
 			}
+			time.Sleep(100 * time.Millisecond)
+			if _, err := d.client.GetActiveElement(); err == nil {
+				return nil
+			}
+			// No focus — retry with coordinate tap
 		}
 	}
 
-	if !tapped {
-		x := float64(info.Bounds.X + info.Bounds.Width/2)
-		y := float64(info.Bounds.Y + info.Bounds.Height/2)
-		if err := d.client.Tap(x, y); err != nil {
-			return errorResult(err, "Tap failed")
+	x := float64(info.Bounds.X + info.Bounds.Width/2)
+	y := float64(info.Bounds.Y + info.Bounds.Height/2)
+	return d.tap(x, y)
+}
+
+// tapChangeDelay is how long retryTapIfNoChange waits after a tap before
+// re-reading the page source, so the UI has time to start its transition.
+const tapChangeDelay = 300 * time.Millisecond
+
+// tapUntilChanged calls tap, then taps again while the page source is
+// unchanged, up to limit taps in total. With a limit of 1 it taps once without
+// reading the source. Returns how many taps were issued.
+func (d *Driver) tapUntilChanged(limit int, tap func() error) (int, error) {
+	if limit <= 1 {
+		return 1, tap()
+	}
+	before, srcErr := d.client.Source()
+	taps := 0
+	for {
+		if err := tap(); err != nil {
+			return taps, err
+		}
+		taps++
+		if taps >= limit || srcErr != nil {
+			return taps, nil
+		}
+		time.Sleep(tapChangeDelay)
+		if after, err := d.client.Source(); err != nil || after != before {
+			return taps, nil
 		}
 	}
+}
 
-	return successResult("Tapped element", info)
+// tapCountSuffix reports the taps issued when retryTapIfNoChange allowed more than one.
+func tapCountSuffix(taps, limit int) string {
+	if limit <= 1 {
+		return ""
+	}
+	return fmt.Sprintf(" (%d of max %d taps)", taps, limit)
 }
 
 // tapOnPointWithPercentage handles percentage-based tap (e.g., "85%, 50%")
 func (d *Driver) tapOnPointWithPercentage(point string) *core.CommandResult {
-	width, height, err := d.client.WindowSize()
+	width, height, err := d.windowSize()
 	if err != nil {
 		return errorResult(err, "Failed to get screen size")
 	}
@@ -107,13 +172,76 @@ func (d *Driver) tapOnPointWithPercentage(point string) *core.CommandResult {
 	x := float64(width) * xPct
 	y := float64(height) * yPct
 
-	if err := d.client.Tap(x, y); err != nil {
+	if err := d.tap(x, y); err != nil {
 		return errorResult(err, "Tap at point failed")
 	}
 
 	return successResult(fmt.Sprintf("Tapped at (%.0f, %.0f)", x, y), nil)
 }
 
+// tapSequence resolves every target first, then taps them back to back with
+// the step's delay between taps.
+func (d *Driver) tapSequence(step *flow.TapSequenceStep) *core.CommandResult {
+	if len(step.Taps) == 0 {
+		return errorResult(fmt.Errorf("no taps specified"), "tapSequence requires at least one tap")
+	}
+
+	points := make([][2]float64, len(step.Taps))
+	for i, target := range step.Taps {
+		x, y, err := d.resolveTapTarget(target, step.TimeoutMs)
+		if err != nil {
+			return errorResult(err, fmt.Sprintf("Tap %d of %d: %v", i+1, len(step.Taps), err))
+		}
+		points[i] = [2]float64{x, y}
+	}
+
+	delay := time.Duration(step.Delay()) * time.Millisecond
+	for i, p := range points {
+		if i > 0 {
+			time.Sleep(delay)
+		}
+		if err := d.tap(p[0], p[1]); err != nil {
+			return errorResult(err, fmt.Sprintf("Tap %d of %d at (%.0f, %.0f) failed", i+1, len(points), p[0], p[1]))
+		}
+	}
+
+	return successResult(fmt.Sprintf("Tapped %d points in sequence", len(points)), nil)
+}
+
+// resolveTapTarget returns the screen coordinates for one tapSequence target:
+// a bare point is a screen percentage, a point with a selector is relative to
+// the element, and a selector alone is the element's center.
+func (d *Driver) resolveTapTarget(target flow.Selector, timeoutMs int) (float64, float64, error) {
+	if target.IsEmpty() {
+		if target.Point == "" {
+			return 0, 0, fmt.Errorf("tap needs a selector or a point")
+		}
+		width, height, err := d.windowSize()
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to get screen size: %w", err)
+		}
+		xPct, yPct, err := parsePercentageCoords(target.Point)
+		if err != nil {
+			return 0, 0, err
+		}
+		return float64(width) * xPct, float64(height) * yPct, nil
+	}
+
+	info, err := d.findElementForTap(target, false, timeoutMs)
+	if err != nil {
+		return 0, 0, fmt.Errorf("element not found: %s", selectorDesc(target))
+	}
+	if target.Point != "" {
+		xPct, yPct, err := parsePercentageCoords(target.Point)
+		if err != nil {
+			return 0, 0, err
+		}
+		return float64(info.Bounds.X) + float64(info.Bounds.Width)*xPct, float64(info.Bounds.Y) + float64(info.Bounds.Height)*yPct, nil
+	}
+	x, y := info.Bounds.Center()
+	return float64(x), float64(y), nil
+}
+
 func (d *Driver) doubleTapOn(step *flow.DoubleTapOnStep) *core.CommandResult {
 	info, err := d.findElementForTap(step.Selector, false, step.TimeoutMs)
 	if err != nil {
@@ -130,7 +258,28 @@ func (d *Driver) doubleTapOn(step *flow.DoubleTapOnStep) *core.CommandResult {
 	return successResult("Double tapped element", info)
 }
 
+// longPressOn holds on the element center, on a point within the element, or
+// with no selector on a screen point, via /wda/touchAndHold.
 func (d *Driver) longPressOn(step *flow.LongPressOnStep) *core.CommandResult {
+	duration := step.Duration()
+	seconds := float64(duration) / 1000.0
+
+	if step.Point != "" && step.Selector.IsEmpty() {
+		width, height, err := d.windowSize()
+		if err != nil {
+			return errorResult(err, "Failed to get screen size")
+		}
+		pctX, pctY, err := parsePercentageCoords(step.Point)
+		if err != nil {
+			return errorResult(err, "Invalid point format")
+		}
+		x, y := float64(width)*pctX, float64(height)*pctY
+		if err := d.client.LongPress(x, y, seconds); err != nil {
+			return errorResult(err, "Long press failed")
+		}
+		return successResult(fmt.Sprintf("Long pressed at (%.0f, %.0f) for %dms", x, y, duration), nil)
+	}
+
 	info, err := d.findElementForTap(step.Selector, false, step.TimeoutMs)
 	if err != nil {
 		return errorResult(err, fmt.Sprintf("Element not found: %s", selectorDesc(step.Selector)))
@@ -138,14 +287,20 @@ func (d *Driver) longPressOn(step *flow.LongPressOnStep) *core.CommandResult {
 
 	x := float64(info.Bounds.X + info.Bounds.Width/2)
 	y := float64(info.Bounds.Y + info.Bounds.Height/2)
+	if step.Point != "" {
+		pctX, pctY, err := parsePercentageCoords(step.Point)
+		if err != nil {
+			return errorResult(err, "Invalid point format")
+		}
+		x = float64(info.Bounds.X) + float64(info.Bounds.Width)*pctX
+		y = float64(info.Bounds.Y) + float64(info.Bounds.Height)*pctY
+	}
 
-	duration := 1.0 // default 1 second
-
-	if err := d.client.LongPress(x, y, duration); err != nil {
+	if err := d.client.LongPress(x, y, seconds); err != nil {
 		return errorResult(err, "Long press failed")
 	}
 
-	return successResult("Long pressed element", info)
+	return successResult(fmt.Sprintf("Long pressed %s for %dms", selectorDesc(step.Selector), duration), info)
 }
 
 func (d *Driver) tapOnPoint(step *flow.TapOnPointStep) *core.CommandResult {
@@ -153,7 +308,7 @@ func (d *Driver) tapOnPoint(step *flow.TapOnPointStep) *core.CommandResult {
 
 	// Handle percentage-based coordinates via Point field
 	if step.Point != "" {
-		width, height, err := d.client.WindowSize()
+		width, height, err := d.windowSize()
 		if err != nil {
 			return errorResult(err, "Failed to get screen size")
 		}
@@ -168,7 +323,7 @@ func (d *Driver) tapOnPoint(step *flow.TapOnPointStep) *core.CommandResult {
 		y = float64(step.Y)
 	}
 
-	if err := d.client.Tap(x, y); err != nil {
+	if err := d.tap(x, y); err != nil {
 		return errorResult(err, "Tap on point failed")
 	}
 
@@ -178,28 +333,362 @@ func (d *Driver) tapOnPoint(step *flow.TapOnPointStep) *core.CommandResult {
 // Assert commands
 
 func (d *Driver) assertVisible(step *flow.AssertVisibleStep) *core.CommandResult {
+	if step.Count != nil {
+		return d.assertVisibleCount(step)
+	}
 	info, err := d.findElement(step.Selector, false, step.TimeoutMs)
 	if err != nil {
+		if stateErr := d.stateMismatch(step.Selector); stateErr != nil {
+			return errorResult(stateErr, stateErr.Error())
+		}
 		return errorResult(err, fmt.Sprintf("Element not visible: %s", selectorDesc(step.Selector)))
 	}
 
+	if step.FullyVisible {
+		width, height, err := d.windowSize()
+		if err != nil {
+			return errorResult(err, "Failed to get window size")
+		}
+		if overflow := info.Bounds.Overflow(width, height); overflow != "" {
+			return errorResult(fmt.Errorf("element clipped: %s", overflow),
+				fmt.Sprintf("Element is not fully visible: %s", overflow))
+		}
+		return successResult("Element is fully visible", info)
+	}
+
 	return successResult("Element is visible", info)
 }
 
+// assertVisibleCount polls the page source until the number of displayed
+// matches satisfies the step's count expectation or the timeout elapses.
+func (d *Driver) assertVisibleCount(step *flow.AssertVisibleStep) *core.CommandResult {
+	if err := step.ValidateCount(); err != nil {
+		return errorResult(err, err.Error())
+	}
+	if step.Selector.HasRelativeSelector() {
+		err := fmt.Errorf("count does not support relative selectors")
+		return errorResult(err, err.Error())
+	}
+
+	deadline := time.Now().Add(d.calculateTimeout(step.IsOptional(), step.TimeoutMs))
+
+	for {
+		elements, err := d.pageSourceElements()
+		if err != nil {
+			return errorResult(err, "Failed to read page source")
+		}
+		var visible []*ParsedElement
+		for _, el := range FilterBySelector(elements, step.Selector) {
+			if el.Displayed {
+				visible = append(visible, el)
+			}
+		}
+		// A matching container and its matching label are one element
+		found := len(innermostMatches(visible))
+		mismatch := step.CheckCount(found)
+		if mismatch == nil {
+			return successResult(fmt.Sprintf("Found %d visible '%s' elements", found, step.Selector.Describe()), nil)
+		}
+		if time.Now().After(deadline) {
+			return errorResult(mismatch, mismatch.Error())
+		}
+		d.invalidateSource()
+		time.Sleep(500 * time.Millisecond)
+	}
+}
+
+// stateMismatch explains why a selector with enabled/checked/selected/focused
+// filters found nothing: it looks the element up once in the page source
+// without those filters and reports the first state that differs. It returns
+// nil when the selector has no state filters or no element matches the rest.
+func (d *Driver) stateMismatch(sel flow.Selector) error {
+	if !sel.HasStateFilter() {
+		return nil
+	}
+	base := sel.WithoutStateFilter()
+	var info *core.ElementInfo
+	var err error
+	if base.HasRelativeSelector() {
+		info, err = d.findElementRelativeOnce(base)
+	} else {
+		info, err = d.findElementByPageSourceOnce(base)
+	}
+	if err != nil {
+		return nil
+	}
+	return core.CheckElementState(sel, info)
+}
+
+// notVisiblePollInterval is the pause between assertNotVisible checks.
+const notVisiblePollInterval = 250 * time.Millisecond
+
+// assertNotVisible passes only if no check during the confirmation window finds
+// a match; the first sighting fails the step.
 func (d *Driver) assertNotVisible(step *flow.AssertNotVisibleStep) *core.CommandResult {
-	// Poll to confirm element stays invisible
-	// Default 5s aligns closer to Maestro's optionalLookupTimeoutMs (7s)
+	deadline := time.Now().Add(time.Duration(step.Window()) * time.Millisecond)
+
+	for {
+		if info, err := d.findElementOnce(step.Selector); err == nil && info != nil {
+			return errorResult(fmt.Errorf("element is visible"),
+				fmt.Sprintf("Element should not be visible: %s", selectorDesc(step.Selector)))
+		}
+		if time.Now().After(deadline) {
+			return successResult("Element is not visible", nil)
+		}
+		d.invalidateSource()
+		time.Sleep(notVisiblePollInterval)
+	}
+}
+
+// assertFileExists is only reached for device paths, which iOS does not
+// expose; local artifacts are checked by the executor.
+func (d *Driver) assertFileExists(step *flow.AssertFileExistsStep) *core.CommandResult {
+	return errorResult(fmt.Errorf("device file checks not supported on iOS"),
+		fmt.Sprintf("assertFileExists with device: true is only supported on Android (path %s)", step.Path))
+}
+
+// assertFieldValue reads a field's value attribute and checks it against the
+// step's condition.
+func (d *Driver) assertFieldValue(step *flow.AssertFieldValueStep) *core.CommandResult {
+	matcher, err := core.NewTextMatcher(step.Equals, step.Contains, "")
+	if err != nil {
+		return errorResult(err, fmt.Sprintf("Invalid assertFieldValue condition: %v", err))
+	}
+
+	info, err := d.findElement(step.Element, step.IsOptional(), step.TimeoutMs)
+	if err != nil {
+		return errorResult(err, fmt.Sprintf("Field not found: %s", selectorDesc(step.Element)))
+	}
+
+	value, err := d.fieldValue(step.Element, info)
+	if err != nil {
+		return errorResult(err, "Failed to read field value")
+	}
+
+	if !matcher.Match(value) {
+		return errorResult(fmt.Errorf("field value mismatch"),
+			fmt.Sprintf("Expected %s to %s, got %q", selectorDesc(step.Element), matcher, value))
+	}
+	return successResult(fmt.Sprintf("Field value: %q", value), info)
+}
+
+// fieldValue returns the value attribute of a found element. Elements located
+// through page source carry no WDA id, so their value comes from the source.
+func (d *Driver) fieldValue(sel flow.Selector, info *core.ElementInfo) (string, error) {
+	if info.ID != "" {
+		return d.client.ElementValue(info.ID)
+	}
+	elements, err := d.pageSourceElements()
+	if err != nil {
+		return "", err
+	}
+	matches := FilterBySelector(elements, sel)
+	if len(matches) == 0 {
+		return "", fmt.Errorf("no elements match %s", selectorDesc(sel))
+	}
+	return matches[0].Value, nil
+}
+
+// shareSheetPollInterval is how often assertShareTarget re-reads the source
+// while the activity view animates in.
+const shareSheetPollInterval = 300 * time.Millisecond
+
+// assertShareTarget waits for the share sheet (UIActivityViewController) and
+// checks whether its app row lists the app.
+func (d *Driver) assertShareTarget(step *flow.AssertShareTargetStep) *core.CommandResult {
+	if strings.TrimSpace(step.App) == "" {
+		return errorResult(fmt.Errorf("no app specified"), "assertShareTarget requires app")
+	}
+	timeout := step.TimeoutMs
+	if timeout <= 0 {
+		timeout = 5000
+	}
+	deadline := time.Now().Add(time.Duration(timeout) * time.Millisecond)
+
+	sheet := false
+	for {
+		elements, err := d.pageSourceElements()
+		if err != nil {
+			return errorResult(err, fmt.Sprintf("Failed to read page source: %v", err))
+		}
+		var target *ParsedElement
+		sheet, target = findShareTarget(elements, step.App)
+		switch {
+		case sheet && target != nil && step.ExpectPresent():
+			return successResult(fmt.Sprintf("Share sheet lists %q", step.App), &core.ElementInfo{
+				Text: target.Label, Bounds: target.Bounds, Visible: true,
+			})
+		case sheet && target != nil:
+			return errorResult(fmt.Errorf("share target present"), fmt.Sprintf("Share sheet should not list %q", step.App))
+		case sheet && !step.ExpectPresent():
+			return successResult(fmt.Sprintf("Share sheet does not list %q", step.App), nil)
+		}
+		if time.Now().After(deadline) {
+			break
+		}
+		d.invalidateSource()
+		time.Sleep(shareSheetPollInterval)
+	}
+
+	if !sheet {
+		return errorResult(fmt.Errorf("share sheet not found"), fmt.Sprintf("Share sheet not found within %dms", timeout))
+	}
+	return errorResult(fmt.Errorf("share target not found"), fmt.Sprintf("Share sheet does not list %q", step.App))
+}
+
+// findShareTarget reports whether the activity view is on screen and returns
+// the element inside it labelled app (case-insensitive).
+func findShareTarget(elements []*ParsedElement, app string) (bool, *ParsedElement) {
+	sheet := false
+	for _, e := range elements {
+		if e.Name != "ActivityListView" && e.Name != "UIActivityContentView" {
+			continue
+		}
+		sheet = true
+		if target := findLabelled(e, app); target != nil {
+			return true, target
+		}
+	}
+	return sheet, nil
+}
+
+func findLabelled(e *ParsedElement, label string) *ParsedElement {
+	label = strings.TrimSpace(label)
+	if strings.EqualFold(strings.TrimSpace(e.Label), label) || strings.EqualFold(strings.TrimSpace(e.Name), label) {
+		return e
+	}
+	for _, child := range e.Children {
+		if found := findLabelled(child, label); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// interactiveTypes are the XCUIElement types VoiceOver treats as controls.
+var interactiveTypes = map[string]bool{
+	"XCUIElementTypeButton":           true,
+	"XCUIElementTypeLink":             true,
+	"XCUIElementTypeSwitch":           true,
+	"XCUIElementTypeToggle":           true,
+	"XCUIElementTypeSlider":           true,
+	"XCUIElementTypeStepper":          true,
+	"XCUIElementTypeSegmentedControl": true,
+	"XCUIElementTypeTextField":        true,
+	"XCUIElementTypeSecureTextField":  true,
+	"XCUIElementTypeSearchField":      true,
+	"XCUIElementTypeTextView":         true,
+}
+
+// assertTextNotContains fails when any text on screen contains a forbidden
+// substring or matches a forbidden pattern.
+func (d *Driver) assertTextNotContains(step *flow.AssertTextNotContainsStep) *core.CommandResult {
+	elements, err := d.pageSourceElements()
+	if err != nil {
+		return errorResult(err, fmt.Sprintf("Failed to read page source: %v", err))
+	}
+
+	texts := readAllText(elements)
+	hit, err := core.FindForbiddenText(texts, step.Text, step.Regex)
+	if err != nil {
+		return errorResult(err, fmt.Sprintf("assertTextNotContains: %v", err))
+	}
+	if hit != "" {
+		return errorResult(fmt.Errorf("forbidden text on screen"), fmt.Sprintf("Forbidden text found on screen: %s", hit))
+	}
+	return successResult(fmt.Sprintf("No forbidden text in %d texts on screen", len(texts)), nil)
+}
+
+// readAllText collects the label and value of every visible element, plus the
+// placeholder of empty fields, in page source order.
+func readAllText(elements []*ParsedElement) []string {
+	var texts []string
+	for _, e := range elements {
+		if !e.Displayed {
+			continue
+		}
+		if strings.TrimSpace(e.Label) != "" {
+			texts = append(texts, e.Label)
+		}
+		switch {
+		case strings.TrimSpace(e.Value) != "" && e.Value != e.Label:
+			texts = append(texts, e.Value)
+		case e.Value == "" && strings.TrimSpace(e.PlaceholderValue) != "":
+			texts = append(texts, e.PlaceholderValue)
+		}
+	}
+	return texts
+}
+
+// assertAccessible fails when a visible control has neither an accessibility
+// label nor an identifier. Text fields may rely on their placeholder instead.
+func (d *Driver) assertAccessible(_ *flow.AssertAccessibleStep) *core.CommandResult {
+	elements, err := d.pageSourceElements()
+	if err != nil {
+		return errorResult(err, fmt.Sprintf("Failed to read page source: %v", err))
+	}
+
+	checked := 0
+	var unlabeled []string
+	for _, e := range elements {
+		if !interactiveTypes[e.Type] || !e.Displayed || e.Bounds.Width <= 0 || e.Bounds.Height <= 0 {
+			continue
+		}
+		checked++
+		if strings.TrimSpace(e.Label) == "" && strings.TrimSpace(e.Name) == "" && strings.TrimSpace(e.PlaceholderValue) == "" {
+			b := e.Bounds
+			unlabeled = append(unlabeled, fmt.Sprintf("%s at (%d, %d) %dx%d",
+				strings.TrimPrefix(e.Type, "XCUIElementType"), b.X, b.Y, b.Width, b.Height))
+		}
+	}
+
+	if len(unlabeled) > 0 {
+		return errorResult(fmt.Errorf("%d unlabeled interactive elements", len(unlabeled)),
+			fmt.Sprintf("%d of %d interactive elements have no accessibility label: %s", len(unlabeled), checked, strings.Join(unlabeled, "; ")))
+	}
+	return successResult(fmt.Sprintf("All %d interactive elements have accessibility labels", checked), nil)
+}
+
+// assertSorted reads the label (or value) of every element matching the step's
+// selector in page source order and checks they are sorted.
+func (d *Driver) assertSorted(step *flow.AssertSortedStep) *core.CommandResult {
 	timeoutMs := step.TimeoutMs
 	if timeoutMs <= 0 {
 		timeoutMs = 5000
 	}
+	deadline := time.Now().Add(time.Duration(timeoutMs) * time.Millisecond)
 
-	info, err := d.findElement(step.Selector, true, timeoutMs)
-	if err != nil || info == nil {
-		return successResult("Element is not visible", nil)
+	var matches []*ParsedElement
+	for {
+		elements, err := d.pageSourceElements()
+		if err != nil {
+			return errorResult(err, "Failed to read page source")
+		}
+		matches = FilterBySelector(elements, step.Element)
+		if len(matches) > 0 || time.Now().After(deadline) {
+			break
+		}
+		d.invalidateSource()
+		time.Sleep(500 * time.Millisecond)
 	}
 
-	return errorResult(fmt.Errorf("element is visible"), fmt.Sprintf("Element should not be visible: %s", selectorDesc(step.Selector)))
+	if len(matches) == 0 {
+		return errorResult(fmt.Errorf("no elements found"), fmt.Sprintf("No elements match %s", selectorDesc(step.Element)))
+	}
+
+	values := make([]string, len(matches))
+	for i, el := range matches {
+		values[i] = el.Label
+		if values[i] == "" {
+			values[i] = el.Value
+		}
+	}
+
+	if err := core.CheckSorted(values, step.Pattern, step.Numeric, step.Order); err != nil {
+		return errorResult(err, fmt.Sprintf("Elements are not sorted: %v", err))
+	}
+
+	return successResult(fmt.Sprintf("%d elements are sorted", len(values)), nil)
 }
 
 // Input commands
@@ -216,6 +705,10 @@ func (d *Driver) inputText(step *flow.InputTextStep) *core.CommandResult {
 		unicodeWarning = " (warning: non-ASCII characters may not input correctly)"
 	}
 
+	// WDA key input drops emoji, so they are pasted into the focused field
+	// instead. That needs focus, so skip the direct element path below.
+	emoji := core.HasEmoji(text)
+
 	// If selector provided, find the element and type directly into it
 	if !step.Selector.IsEmpty() {
 		info, err := d.findElement(step.Selector, step.IsOptional(), step.TimeoutMs)
@@ -223,16 +716,27 @@ func (d *Driver) inputText(step *flow.InputTextStep) *core.CommandResult {
 			return errorResult(err, fmt.Sprintf("Element not found: %s", selectorDesc(step.Selector)))
 		}
 		// If we have element ID, send keys directly to the element
-		if info.ID != "" {
-			if err := d.client.ElementSendKeys(info.ID, text); err != nil {
+		if info.ID != "" && !emoji {
+			var before string
+			if step.VerifyText {
+				before, _ = d.client.ElementValue(info.ID)
+			}
+			sendToElement := func(s string) error { return d.client.ElementSendKeys(info.ID, s) }
+			if err := typeText(text, step.TypeDelayMs, sendToElement); err != nil {
 				return errorResult(err, "Input text to element failed")
 			}
-			return successResult(fmt.Sprintf("Entered text: %s%s", text, unicodeWarning), info)
+			if step.VerifyText {
+				retype := func() error { return typeText(text, step.TypeDelayMs, sendToElement) }
+				if result := d.verifyInputText(info.ID, before, text, retype); result != nil {
+					return result
+				}
+			}
+			return successResult(fmt.Sprintf("Entered text: %s%s%s", text, core.TypeDelayNote(text, step.TypeDelayMs), unicodeWarning), info)
 		}
 		// Fallback: tap to focus first
 		x := float64(info.Bounds.X + info.Bounds.Width/2)
 		y := float64(info.Bounds.Y + info.Bounds.Height/2)
-		if err := d.client.Tap(x, y); err != nil {
+		if err := d.tap(x, y); err != nil {
 			return errorResult(err, "Failed to tap element before input")
 		}
 		time.Sleep(100 * time.Millisecond) // Wait for focus
@@ -241,18 +745,135 @@ func (d *Driver) inputText(step *flow.InputTextStep) *core.CommandResult {
 	// Wait for keyboard to be ready by confirming a text field is focused.
 	// Poll GetActiveElement up to 1s (5 attempts, 200ms apart) similar to
 	// original Maestro's InputTextRouteHandler.swift keyboard wait.
+	var focusedID string
 	for i := 0; i < 5; i++ {
 		if elemID, err := d.client.GetActiveElement(); err == nil && elemID != "" {
+			focusedID = elemID
 			break
 		}
 		time.Sleep(200 * time.Millisecond)
 	}
 
-	if err := d.client.SendKeys(text); err != nil {
+	// The focused field's value before typing, for verifyText
+	var before string
+	if step.VerifyText && focusedID != "" {
+		before, _ = d.client.ElementValue(focusedID)
+	}
+
+	if emoji {
+		err := d.pasteIntoFocused(text)
+		if err == nil {
+			if step.VerifyText {
+				elemID, err := d.client.GetActiveElement()
+				if err != nil || elemID == "" {
+					return errorResult(fmt.Errorf("no focused element"), "No focused element to verify input text")
+				}
+				retype := func() error { return d.pasteIntoFocused(text) }
+				if result := d.verifyInputText(elemID, before, text, retype); result != nil {
+					return result
+				}
+			}
+			return successResult(fmt.Sprintf("Entered text (pasteboard): %s%s", text, unicodeWarning), nil)
+		}
+		unicodeWarning += fmt.Sprintf(" (warning: pasteboard paste failed, emoji may be dropped: %v)", err)
+	}
+
+	if err := typeText(text, step.TypeDelayMs, d.client.SendKeys); err != nil {
 		return errorResult(err, "Input text failed")
 	}
 
-	return successResult(fmt.Sprintf("Entered text: %s%s", text, unicodeWarning), nil)
+	if step.VerifyText {
+		elemID, err := d.client.GetActiveElement()
+		if err != nil || elemID == "" {
+			return errorResult(fmt.Errorf("no focused element"), "No focused element to verify input text")
+		}
+		retype := func() error { return typeText(text, step.TypeDelayMs, d.client.SendKeys) }
+		if result := d.verifyInputText(elemID, before, text, retype); result != nil {
+			return result
+		}
+	}
+
+	return successResult(fmt.Sprintf("Entered text: %s%s%s", text, core.TypeDelayNote(text, step.TypeDelayMs), unicodeWarning), nil)
+}
+
+// typeText sends text in one call, or one grapheme per call with delayMs
+// between them. WDA drops characters from long bursts on some apps, so a
+// per-character delay trades speed for reliability.
+func typeText(text string, delayMs int, send func(string) error) error {
+	if delayMs <= 0 {
+		return send(text)
+	}
+	for i, g := range core.Graphemes(text) {
+		if i > 0 {
+			time.Sleep(time.Duration(delayMs) * time.Millisecond)
+		}
+		if err := send(g); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pasteIntoFocused puts text on the pasteboard and pastes it into the
+// focused field through the edit menu's Paste item.
+func (d *Driver) pasteIntoFocused(text string) error {
+	if err := d.client.SetPasteboard(text); err != nil {
+		return fmt.Errorf("set pasteboard: %w", err)
+	}
+	elemID, err := d.client.GetActiveElement()
+	if err != nil || elemID == "" {
+		return fmt.Errorf("no focused element")
+	}
+	x, y, w, h, err := d.client.ElementRect(elemID)
+	if err != nil {
+		return fmt.Errorf("focused element bounds: %w", err)
+	}
+	// Long press brings up the edit menu whether or not the field has text
+	if err := d.client.LongPress(float64(x+w/2), float64(y+h/2), 1.0); err != nil {
+		return fmt.Errorf("open edit menu: %w", err)
+	}
+	paste, err := d.findElement(flow.Selector{Text: "Paste"}, true, 2000)
+	if err != nil || paste == nil {
+		return fmt.Errorf("paste menu item did not appear")
+	}
+	cx, cy := paste.Bounds.Center()
+	return d.tap(float64(cx), float64(cy))
+}
+
+// verifyInputText reads the field's value back after typing and checks it is
+// exactly before+text (see core.InputTextMatches). On a mismatch, e.g. dropped
+// characters, it clears the field, types the prefilled text back and retypes
+// once. Returns nil when the value matches, or a failed result.
+func (d *Driver) verifyInputText(elemID, before, text string, retype func() error) *core.CommandResult {
+	if got, err := d.client.ElementValue(elemID); err == nil && core.InputTextMatches(got, before, text) {
+		return nil
+	}
+
+	if err := d.client.ElementClear(elemID); err != nil {
+		return errorResult(err, "Failed to clear field before retrying input")
+	}
+	// A cleared field that still reads as before was showing its placeholder
+	if cleared, _ := d.client.ElementValue(elemID); cleared == before {
+		before = ""
+	}
+	if before != "" {
+		if err := d.client.ElementSendKeys(elemID, before); err != nil {
+			return errorResult(err, "Failed to restore prefilled text before retrying input")
+		}
+	}
+	if err := retype(); err != nil {
+		return errorResult(err, "Failed to retry input text")
+	}
+
+	got, err := d.client.ElementValue(elemID)
+	if err != nil {
+		return errorResult(err, "Failed to read back input text")
+	}
+	if !core.InputTextMatches(got, before, text) {
+		return errorResult(fmt.Errorf("expected field to read %q, got %q", before+text, got),
+			fmt.Sprintf("Input text verification failed: expected %q, got %q", before+text, got))
+	}
+	return nil
 }
 
 func (d *Driver) eraseText(step *flow.EraseTextStep) *core.CommandResult {
@@ -325,6 +946,40 @@ func (d *Driver) dismissAlert(step *flow.DismissAlertStep) *core.CommandResult {
 	return d.waitForAlert(step.TimeoutMs, false)
 }
 
+// assertAlertText polls for a system alert and checks its text.
+// Fails on mismatch, or if no alert appears within the timeout.
+func (d *Driver) assertAlertText(step *flow.AssertAlertTextStep) *core.CommandResult {
+	if step.Text == "" && step.Contains == "" {
+		return errorResult(fmt.Errorf("no expected text specified"), "assertAlertText requires text or contains")
+	}
+
+	timeoutMs := step.TimeoutMs
+	if timeoutMs <= 0 {
+		timeoutMs = 5000
+	}
+	deadline := time.Now().Add(time.Duration(timeoutMs) * time.Millisecond)
+
+	for {
+		text, err := d.client.AlertText()
+		if err == nil {
+			if step.Contains != "" && !strings.Contains(text, step.Contains) {
+				return errorResult(fmt.Errorf("alert text %q does not contain %q", text, step.Contains),
+					fmt.Sprintf("Alert text %q does not contain %q", text, step.Contains))
+			}
+			if step.Text != "" && text != step.Text {
+				return errorResult(fmt.Errorf("alert text %q does not equal %q", text, step.Text),
+					fmt.Sprintf("Alert text %q does not equal %q", text, step.Text))
+			}
+			return successResult(fmt.Sprintf("Alert text matched: %s", text), nil)
+		}
+
+		if time.Now().After(deadline) {
+			return errorResult(err, fmt.Sprintf("No alert appeared within %dms", timeoutMs))
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+}
+
 // waitForAlert polls for a system alert and accepts/dismisses it.
 // If no alert appears within the timeout, succeeds silently.
 func (d *Driver) waitForAlert(timeoutMs int, accept bool) *core.CommandResult {
@@ -370,13 +1025,13 @@ func (d *Driver) inputRandom(step *flow.InputRandomStep) *core.CommandResult {
 	dataType := strings.ToUpper(step.DataType)
 	switch dataType {
 	case "EMAIL":
-		text = randomEmail()
+		text = core.RandomEmail()
 	case "NUMBER":
-		text = randomNumber(length)
+		text = core.RandomNumber(length)
 	case "PERSON_NAME":
-		text = randomPersonName()
+		text = core.RandomPersonName()
 	default: // "TEXT" or empty
-		text = randomString(length)
+		text = core.RandomString(length)
 	}
 
 	if err := d.client.SendKeys(text); err != nil {
@@ -393,7 +1048,7 @@ func (d *Driver) inputRandom(step *flow.InputRandomStep) *core.CommandResult {
 // Scroll/Swipe commands
 
 func (d *Driver) scroll(step *flow.ScrollStep) *core.CommandResult {
-	width, height, err := d.client.WindowSize()
+	width, height, err := d.windowSize()
 	if err != nil {
 		return errorResult(err, "Failed to get screen size")
 	}
@@ -402,71 +1057,171 @@ func (d *Driver) scroll(step *flow.ScrollStep) *core.CommandResult {
 	centerY := float64(height) / 2
 	scrollDistance := float64(height) / 3
 
-	// Scroll direction = content movement direction
-	// "scroll down" means reveal content below, which requires swiping UP
-	// Maestro: ScrollDirection.DOWN -> SwipeDirection.UP
-	var fromX, fromY, toX, toY float64
-	switch step.Direction {
+	// amount is a fraction of the window along the scroll axis
+	fraction, hasAmount, err := step.AmountFraction()
+	if err != nil {
+		return errorResult(err, fmt.Sprintf("Invalid scroll amount: %v", err))
+	}
+	if hasAmount {
+		scrollDistance = float64(height) * fraction
+		if step.Direction == "left" || step.Direction == "right" {
+			scrollDistance = float64(width) * fraction
+		}
+	}
+
+	fromX, fromY, toX, toY, err := scrollSwipePoints(step.Direction, centerX, centerY, scrollDistance)
+	if err != nil {
+		return errorResult(err, "Invalid scroll direction")
+	}
+
+	if err := d.client.Swipe(fromX, fromY, toX, toY, 0.3); err != nil {
+		return errorResult(err, "Scroll failed")
+	}
+
+	if hasAmount {
+		return successResult(fmt.Sprintf("Scrolled %s by %s", step.Direction, step.Amount), nil)
+	}
+	return successResult(fmt.Sprintf("Scrolled %s", step.Direction), nil)
+}
+
+// scrollSwipePoints returns the swipe that scrolls content in direction by
+// distance points around (centerX, centerY).
+// Scroll direction = content movement direction
+// "scroll down" means reveal content below, which requires swiping UP
+// Maestro: ScrollDirection.DOWN -> SwipeDirection.UP
+func scrollSwipePoints(direction string, centerX, centerY, distance float64) (fromX, fromY, toX, toY float64, err error) {
+	switch direction {
 	case "up":
 		// Scroll up = reveal top content = swipe DOWN
-		fromX, fromY = centerX, centerY-scrollDistance/2
-		toX, toY = centerX, centerY+scrollDistance/2
+		return centerX, centerY - distance/2, centerX, centerY + distance/2, nil
 	case "down":
 		// Scroll down = reveal bottom content = swipe UP
-		fromX, fromY = centerX, centerY+scrollDistance/2
-		toX, toY = centerX, centerY-scrollDistance/2
+		return centerX, centerY + distance/2, centerX, centerY - distance/2, nil
 	case "left":
 		// Scroll left = reveal left content = swipe RIGHT
-		fromX, fromY = centerX-scrollDistance/2, centerY
-		toX, toY = centerX+scrollDistance/2, centerY
+		return centerX - distance/2, centerY, centerX + distance/2, centerY, nil
 	case "right":
 		// Scroll right = reveal right content = swipe LEFT
-		fromX, fromY = centerX+scrollDistance/2, centerY
-		toX, toY = centerX-scrollDistance/2, centerY
+		return centerX + distance/2, centerY, centerX - distance/2, centerY, nil
 	default:
-		return errorResult(fmt.Errorf("invalid direction: %s", step.Direction), "Invalid scroll direction")
-	}
-
-	if err := d.client.Swipe(fromX, fromY, toX, toY, 0.3); err != nil {
-		return errorResult(err, "Scroll failed")
+		return 0, 0, 0, 0, fmt.Errorf("invalid direction: %s", direction)
 	}
-
-	return successResult(fmt.Sprintf("Scrolled %s", step.Direction), nil)
 }
 
 func (d *Driver) scrollUntilVisible(step *flow.ScrollUntilVisibleStep) *core.CommandResult {
-	direction := step.Direction
+	direction := strings.ToLower(step.Direction)
 	if direction == "" {
 		direction = "down"
 	}
 
+	profile, err := step.ScrollProfile()
+	if err != nil {
+		return errorResult(err, fmt.Sprintf("Invalid scroll speed: %v", err))
+	}
+
 	maxScrolls := 10
-	if step.TimeoutMs > 0 {
+	if step.MaxScrolls > 0 {
+		maxScrolls = step.MaxScrolls
+	} else if step.TimeoutMs > 0 {
 		maxScrolls = step.TimeoutMs / 1000 // rough estimate
 	}
 
-	for i := 0; i < maxScrolls; i++ {
+	duration := float64(profile.DurationMs) / 1000
+	horizontal := direction == "left" || direction == "right"
+	threshold := step.VisibilityThreshold()
+
+	// Window size is fetched on first use, so an element already on screen
+	// with the default threshold needs no extra request
+	var width, height int
+	var sizeErr error
+	sizeFetched := false
+	screenSize := func() error {
+		if !sizeFetched {
+			width, height, sizeErr = d.windowSize()
+			sizeFetched = true
+		}
+		return sizeErr
+	}
+
+	// Bounds can't be measured without a window size or element frame, so
+	// those count as fully visible.
+	visiblePercent := func(info *core.ElementInfo) int {
+		if info.Bounds.Width <= 0 || info.Bounds.Height <= 0 || screenSize() != nil {
+			return 100
+		}
+		return info.Bounds.VisiblePercent(width, height)
+	}
+
+	var fromX, fromY, toX, toY float64
+
+	for i := 0; ; i++ {
 		// Check if element is visible (includes page source fallback)
 		info, err := d.findElement(step.Element, true, 1000)
 		if err == nil && info != nil {
-			return successResult("Element found after scrolling", info)
+			// Keep scrolling while too little of the element is on screen
+			if visible := visiblePercent(info); visible >= threshold {
+				if step.CenterElement && screenSize() == nil {
+					info = d.centerElement(step.Element, info, width, height, horizontal)
+					visible = visiblePercent(info)
+				}
+				return successResult(fmt.Sprintf("Element found after %d scrolls (%d%% visible)", i, visible), info)
+			}
+		}
+		if i == maxScrolls {
+			break
 		}
 
-		// Scroll
-		scrollStep := &flow.ScrollStep{Direction: direction}
-		result := d.scroll(scrollStep)
-		if !result.Success {
-			return result
+		if i == 0 {
+			if err := screenSize(); err != nil {
+				return errorResult(err, "Failed to get screen size")
+			}
+			distance := float64(height) * profile.Distance
+			if horizontal {
+				distance = float64(width) * profile.Distance
+			}
+			fromX, fromY, toX, toY, err = scrollSwipePoints(direction, float64(width)/2, float64(height)/2, distance)
+			if err != nil {
+				return errorResult(err, "Invalid scroll direction")
+			}
+		}
+
+		if err := d.client.Swipe(fromX, fromY, toX, toY, duration); err != nil {
+			return errorResult(err, "Scroll failed")
 		}
 
 		time.Sleep(300 * time.Millisecond) // Wait for scroll animation
 	}
 
-	return errorResult(fmt.Errorf("element not found after scrolling"), fmt.Sprintf("Element not found: %s", selectorDesc(step.Element)))
+	return errorResult(fmt.Errorf("element not found after scrolling"),
+		fmt.Sprintf("Element not found after %d scrolls: %s", maxScrolls, selectorDesc(step.Element)))
+}
+
+// centerDragSec is how long the centering drag holds before moving, which
+// makes WDA treat it as a drag rather than a fling.
+const centerDragSec = 0.5
+
+// centerElement drags the content so the element moves toward the middle of
+// the screen along the scroll axis, then re-reads its bounds. The original
+// info is returned when no drag is needed or the element can't be re-found.
+func (d *Driver) centerElement(sel flow.Selector, info *core.ElementInfo, width, height int, horizontal bool) *core.ElementInfo {
+	from, to, ok := core.CenteringDrag(info.Bounds, width, height, horizontal)
+	if !ok {
+		return info
+	}
+	if err := d.client.Swipe(from.X, from.Y, to.X, to.Y, centerDragSec); err != nil {
+		logger.Warn("centerElement: drag failed: %v", err)
+		return info
+	}
+	time.Sleep(300 * time.Millisecond)
+
+	if centered, err := d.findElement(sel, true, 1000); err == nil && centered != nil {
+		return centered
+	}
+	return info
 }
 
 func (d *Driver) swipe(step *flow.SwipeStep) *core.CommandResult {
-	width, height, err := d.client.WindowSize()
+	width, height, err := d.windowSize()
 	if err != nil {
 		return errorResult(err, "Failed to get screen size")
 	}
@@ -550,12 +1305,115 @@ func (d *Driver) swipe(step *flow.SwipeStep) *core.CommandResult {
 	return successResult("Swipe completed", nil)
 }
 
+// gesturePath drags one finger through the step's points, timing each segment
+// by its length so the whole path takes the step's duration.
+func (d *Driver) gesturePath(step *flow.GesturePathStep) *core.CommandResult {
+	if len(step.Points) < 2 {
+		return errorResult(fmt.Errorf("need at least 2 points, got %d", len(step.Points)), "gesturePath requires at least 2 points")
+	}
+
+	width, height, err := d.windowSize()
+	if err != nil {
+		return errorResult(err, "Failed to get screen size")
+	}
+
+	path := make([]core.PathPoint, len(step.Points))
+	for i, p := range step.Points {
+		xPct, yPct, err := parsePercentageCoords(p)
+		if err != nil {
+			return errorResult(err, fmt.Sprintf("Invalid point %d: %v", i+1, err))
+		}
+		path[i] = core.PathPoint{X: float64(width) * xPct, Y: float64(height) * yPct}
+	}
+
+	if err := d.client.PointerPath(path, core.PathDurations(path, step.Duration())); err != nil {
+		return errorResult(err, "Gesture path failed")
+	}
+
+	return successResult(fmt.Sprintf("Traced %d points over %dms", len(path), step.Duration()), nil)
+}
+
+// twoFingerSwipe moves two fingers side by side in parallel, which maps and
+// galleries treat as a pan rather than a one-finger drag.
+func (d *Driver) twoFingerSwipe(step *flow.TwoFingerSwipeStep) *core.CommandResult {
+	start, end, err := step.Endpoints()
+	if err != nil {
+		return errorResult(err, err.Error())
+	}
+
+	width, height, err := d.windowSize()
+	if err != nil {
+		return errorResult(err, "Failed to get screen size")
+	}
+
+	var mid [2]core.PathPoint
+	for i, p := range []string{start, end} {
+		xPct, yPct, err := parsePercentageCoords(p)
+		if err != nil {
+			return errorResult(err, fmt.Sprintf("Invalid coordinates %q: %v", p, err))
+		}
+		mid[i] = core.PathPoint{X: float64(width) * xPct, Y: float64(height) * yPct}
+	}
+
+	tracks := core.TwoFingerTracks(mid[0], mid[1], step.FingerSpacing(width))
+	if err := d.client.ParallelSwipe(tracks[:], step.Duration()); err != nil {
+		return errorResult(err, "Two-finger swipe failed")
+	}
+
+	return successResult(fmt.Sprintf("Two-finger swipe from (%s) to (%s)", start, end), nil)
+}
+
 // Navigation commands
 
+// Back gesture: a drag from just inside the left edge to past the middle of
+// the screen at mid-height, which UIKit treats as an interactive pop.
+const (
+	backSwipeStartX  = 5.0
+	backSwipeEndFrac = 0.75
+	backSwipeSec     = 0.3
+)
+
+// backButtonLabel matches the navigation bar button tapped by TapBackButton.
+const backButtonLabel = "^Back$"
+
+// back swipes from the left screen edge since iOS has no back button. Screens
+// outside a navigation controller ignore the gesture, so this is best effort;
+// with TapBackButton set, an unchanged page source falls back to tapping the
+// navigation bar's "Back" button.
 func (d *Driver) back(step *flow.BackStep) *core.CommandResult {
-	// iOS doesn't have a hardware back button
-	// Could try to find a back button in the UI
-	return errorResult(fmt.Errorf("back not supported on iOS"), "iOS doesn't have a back button")
+	width, height, err := d.windowSize()
+	if err != nil {
+		return errorResult(err, "Failed to get screen size")
+	}
+
+	var before string
+	var srcErr error
+	if step.TapBackButton {
+		before, srcErr = d.client.Source()
+	}
+
+	y := float64(height) / 2
+	if err := d.client.Swipe(backSwipeStartX, y, float64(width)*backSwipeEndFrac, y, backSwipeSec); err != nil {
+		return errorResult(err, "Back swipe failed")
+	}
+	if !step.TapBackButton || srcErr != nil {
+		return successResult("Swiped back from the left edge (best effort, iOS has no back button)", nil)
+	}
+
+	time.Sleep(tapChangeDelay)
+	if after, err := d.client.Source(); err != nil || after != before {
+		return successResult("Swiped back from the left edge", nil)
+	}
+
+	info, err := d.findElementOnce(flow.Selector{TextRegex: backButtonLabel})
+	if err != nil {
+		return errorResult(err, "Back swipe did not change the screen and no \"Back\" button was found")
+	}
+	x, by := info.Bounds.Center()
+	if err := d.tap(float64(x), float64(by)); err != nil {
+		return errorResult(err, "Tap on \"Back\" button failed")
+	}
+	return successResult("Back swipe did not change the screen, tapped \"Back\" button", info)
 }
 
 func (d *Driver) pressKey(step *flow.PressKeyStep) *core.CommandResult {
@@ -572,6 +1430,32 @@ func (d *Driver) pressKey(step *flow.PressKeyStep) *core.CommandResult {
 		if err := d.client.PressButton("volumeDown"); err != nil {
 			return errorResult(err, "Press volume down failed")
 		}
+	case "lock":
+		if err := d.client.Lock(); err != nil {
+			return errorResult(err, "Press lock failed")
+		}
+	case "unlock":
+		if err := d.client.Unlock(); err != nil {
+			return errorResult(err, "Press unlock failed")
+		}
+	case "power":
+		// Like the side button: lock an unlocked device, wake a locked one
+		locked, err := d.client.IsLocked()
+		if err != nil {
+			return errorResult(err, "Press power failed")
+		}
+		if locked {
+			err = d.client.Unlock()
+		} else {
+			err = d.client.Lock()
+		}
+		if err != nil {
+			return errorResult(err, "Press power failed")
+		}
+	case "mute", "ringer":
+		// XCUITest exposes no API for the ring/silent switch
+		return errorResult(fmt.Errorf("%s not supported on iOS", step.Key),
+			"The iOS ring/silent switch cannot be toggled through WebDriverAgent")
 	default:
 		// Try keyboard key
 		if keyChar := iosKeyboardKey(step.Key); keyChar != "" {
@@ -583,7 +1467,44 @@ func (d *Driver) pressKey(step *flow.PressKeyStep) *core.CommandResult {
 		}
 	}
 
-	return successResult(fmt.Sprintf("Pressed %s", step.Key), nil)
+	return successResult(fmt.Sprintf("Pressed %s", step.Key), nil)
+}
+
+// search focuses the search field, types the query and presses the keyboard's
+// search (return) key, then optionally waits for a results element.
+func (d *Driver) search(step *flow.SearchStep) *core.CommandResult {
+	if step.Query == "" {
+		return errorResult(fmt.Errorf("no query specified"), "No search query")
+	}
+
+	if !step.Field.IsEmpty() {
+		focus := &flow.TapOnStep{
+			BaseStep: flow.BaseStep{TimeoutMs: step.TimeoutMs, Optional: step.Optional},
+			Selector: step.Field,
+		}
+		if result := d.tapOn(focus); !result.Success {
+			return errorResult(result.Error, fmt.Sprintf("Search field not found: %s", selectorDesc(step.Field)))
+		}
+	}
+
+	if result := d.inputText(&flow.InputTextStep{Text: step.Query}); !result.Success {
+		return result
+	}
+
+	// The iOS keyboard's Search key is its return key relabelled.
+	if step.ShouldSubmit() {
+		if result := d.pressKey(&flow.PressKeyStep{Key: "return"}); !result.Success {
+			return result
+		}
+	}
+
+	if step.WaitFor != nil {
+		if _, err := d.findElement(*step.WaitFor, false, step.TimeoutMs); err != nil {
+			return errorResult(err, fmt.Sprintf("Search results not found: %s", selectorDesc(*step.WaitFor)))
+		}
+	}
+
+	return successResult(fmt.Sprintf("Searched for %q", step.Query), nil)
 }
 
 // iosKeyboardKey maps keyboard key names to the character to send via WDA SendKeys.
@@ -605,6 +1526,23 @@ func iosKeyboardKey(name string) string {
 
 // App lifecycle
 
+// configureSession applies the settings every new session needs.
+func (d *Driver) configureSession() {
+	// Disable quiescence wait to prevent XCTest crashes on certain Xcode/iOS versions
+	_ = d.client.DisableQuiescence()
+	// Set alert button selectors to help WDA find correct buttons on non-standard
+	// permission dialogs (e.g. location with "Allow While Using App")
+	if d.alertAction == "accept" {
+		_ = d.client.UpdateSettings(map[string]interface{}{
+			"acceptAlertButtonSelector": "**/XCUIElementTypeButton[`label CONTAINS[c] 'Allow'`]",
+		})
+	} else if d.alertAction == "dismiss" {
+		_ = d.client.UpdateSettings(map[string]interface{}{
+			"dismissAlertButtonSelector": "**/XCUIElementTypeButton[`label CONTAINS[c] 'Don't Allow' OR label CONTAINS[c] 'Dont Allow'`]",
+		})
+	}
+}
+
 func (d *Driver) launchApp(step *flow.LaunchAppStep) *core.CommandResult {
 	bundleID := step.AppID
 	if bundleID == "" {
@@ -660,26 +1598,16 @@ func (d *Driver) launchApp(step *flow.LaunchAppStep) *core.CommandResult {
 		d.alertAction = resolveAlertAction(permissions)
 	}
 
+	if err := d.EnsureSession(); err != nil {
+		return errorResult(err, fmt.Sprintf("Failed to launch app: %s", bundleID))
+	}
+
 	// If no session exists, create one (which also launches the app)
 	if !d.client.HasSession() {
 		if err := d.client.CreateSession(bundleID, d.alertAction); err != nil {
 			return errorResult(err, fmt.Sprintf("Failed to create session for app: %s", bundleID))
 		}
-		// Disable quiescence wait to prevent XCTest crashes on certain Xcode/iOS versions
-		_ = d.client.DisableQuiescence()
-		// Set alert button selectors to help WDA find correct buttons on non-standard
-		// permission dialogs (e.g. location with "Allow While Using App")
-		if d.alertAction != "" {
-			if d.alertAction == "accept" {
-				_ = d.client.UpdateSettings(map[string]interface{}{
-					"acceptAlertButtonSelector": "**/XCUIElementTypeButton[`label CONTAINS[c] 'Allow'`]",
-				})
-			} else if d.alertAction == "dismiss" {
-				_ = d.client.UpdateSettings(map[string]interface{}{
-					"dismissAlertButtonSelector": "**/XCUIElementTypeButton[`label CONTAINS[c] 'Don't Allow' OR label CONTAINS[c] 'Dont Allow'`]",
-				})
-			}
-		}
+		d.configureSession()
 		time.Sleep(time.Second) // Brief wait for app to start
 		return successResult(fmt.Sprintf("Launched app: %s", bundleID), nil)
 	}
@@ -723,6 +1651,14 @@ func (d *Driver) stopApp(step *flow.StopAppStep) *core.CommandResult {
 		return errorResult(fmt.Errorf("bundleID required"), "Bundle ID is required for stopApp")
 	}
 
+	if step.Graceful {
+		// Press home so the app gets its background callbacks before it is terminated
+		if err := d.client.Home(); err != nil {
+			return errorResult(err, fmt.Sprintf("Failed to background app: %s", bundleID))
+		}
+		time.Sleep(core.GracefulStopDelay)
+	}
+
 	if err := d.client.TerminateApp(bundleID); err != nil {
 		return errorResult(err, fmt.Sprintf("Failed to stop app: %s", bundleID))
 	}
@@ -755,6 +1691,12 @@ func (d *Driver) clearState(step *flow.ClearStateStep) *core.CommandResult {
 	return d.clearAppState(bundleID)
 }
 
+// setAppLocale is Android-only; iOS apps pick their language at launch.
+func (d *Driver) setAppLocale(step *flow.SetAppLocaleStep) *core.CommandResult {
+	return errorResult(fmt.Errorf("setAppLocale not supported on iOS"),
+		fmt.Sprintf("setAppLocale is only supported on Android 13+; on iOS launch the app with arguments [-AppleLanguages, (%s)] instead", step.Locale))
+}
+
 // clearAppState uninstalls and reinstalls an app to clear its state.
 // Requires --app-file for both simulators and real devices.
 // On simulators, uses simctl. On physical devices, uses go-ios.
@@ -827,6 +1769,10 @@ func (d *Driver) copyTextFrom(step *flow.CopyTextFromStep) *core.CommandResult {
 		return errorResult(err, fmt.Sprintf("Element not found: %s", selectorDesc(step.Selector)))
 	}
 
+	if err := d.client.SetPasteboard(info.Text); err != nil {
+		return errorResult(err, fmt.Sprintf("Failed to copy to pasteboard: %v", err))
+	}
+
 	return &core.CommandResult{
 		Success: true,
 		Message: fmt.Sprintf("Copied text: %s", info.Text),
@@ -835,10 +1781,24 @@ func (d *Driver) copyTextFrom(step *flow.CopyTextFromStep) *core.CommandResult {
 	}
 }
 
-func (d *Driver) pasteText(step *flow.PasteTextStep) *core.CommandResult {
-	// iOS: Need to use clipboard API via simctl or device APIs
-	// WDA doesn't directly support clipboard operations
-	return errorResult(fmt.Errorf("pasteText not supported via WDA"), "Paste requires clipboard access")
+// pasteText types the pasteboard's text into the focused field. The executor
+// pastes text from an earlier copyTextFrom itself, so this path is only
+// reached when nothing was copied in the flow.
+func (d *Driver) pasteText(_ *flow.PasteTextStep) *core.CommandResult {
+	text, err := d.client.GetPasteboard()
+	if err != nil {
+		return errorResult(err, fmt.Sprintf("Failed to read pasteboard: %v", err))
+	}
+
+	if elemID, err := d.client.GetActiveElement(); err != nil || elemID == "" {
+		return errorResult(fmt.Errorf("no focused element"), "No focused element to paste into")
+	}
+
+	if err := d.client.SendKeys(text); err != nil {
+		return errorResult(err, fmt.Sprintf("Failed to paste text: %v", err))
+	}
+
+	return successResult(fmt.Sprintf("Pasted text: %s", text), nil)
 }
 
 func (d *Driver) setClipboard(step *flow.SetClipboardStep) *core.CommandResult {
@@ -873,6 +1833,16 @@ func (d *Driver) openLink(step *flow.OpenLinkStep) *core.CommandResult {
 		return errorResult(fmt.Errorf("no link specified"), "No link to open")
 	}
 
+	// Cold start: reinstall-based clear, then make sure nothing is left running
+	if step.ClearState {
+		if r := d.clearState(&flow.ClearStateStep{AppID: step.AppID}); !r.Success {
+			return r
+		}
+		if r := d.killApp(&flow.KillAppStep{AppID: step.AppID}); !r.Success {
+			return r
+		}
+	}
+
 	// Use WDA deep link - works for both simulator and real device
 	// Note: browser parameter would require launching Safari explicitly
 	// WDA's DeepLink uses the system handler which respects app associations
@@ -885,6 +1855,12 @@ func (d *Driver) openLink(step *flow.OpenLinkStep) *core.CommandResult {
 		time.Sleep(2 * time.Second)
 	}
 
+	if step.WaitFor != nil {
+		if _, err := d.findElement(*step.WaitFor, false, step.TimeoutMs); err != nil {
+			return errorResult(err, fmt.Sprintf("Opened link but %s did not appear", step.WaitFor.Describe()))
+		}
+	}
+
 	msg := fmt.Sprintf("Opened link: %s", link)
 	if step.Browser != nil && *step.Browser {
 		msg += " (browser flag set, but WDA uses system default handler)"
@@ -906,6 +1882,422 @@ func (d *Driver) openBrowser(step *flow.OpenBrowserStep) *core.CommandResult {
 	return successResult(fmt.Sprintf("Opened browser: %s", url), nil)
 }
 
+// ensureUnlocked wakes the device and swipes past the lock screen so SpringBoard
+// is reachable. A passcode is typed only if the device is still locked after that.
+func (d *Driver) ensureUnlocked(step *flow.EnsureUnlockedStep) *core.CommandResult {
+	locked, err := d.client.IsLocked()
+	if err != nil {
+		return errorResult(err, "Failed to read lock state")
+	}
+	if !locked {
+		return successResult("Device is unlocked", nil)
+	}
+
+	if err := d.client.Unlock(); err != nil {
+		return errorResult(err, "Failed to unlock device")
+	}
+	if locked, err = d.client.IsLocked(); err != nil {
+		return errorResult(err, "Failed to read lock state")
+	}
+	if !locked {
+		return successResult("Unlocked device", nil)
+	}
+
+	// Unlock leaves a passcode-protected device on the passcode screen
+	if step.Passcode == "" {
+		return errorResult(fmt.Errorf("passcode required"),
+			"Device lock screen requires a passcode; set passcode on ensureUnlocked")
+	}
+	if err := d.client.SendKeys(step.Passcode); err != nil {
+		return errorResult(err, "Failed to enter passcode")
+	}
+	time.Sleep(500 * time.Millisecond)
+
+	if locked, err = d.client.IsLocked(); err != nil {
+		return errorResult(err, "Failed to read lock state")
+	}
+	if locked {
+		return errorResult(fmt.Errorf("device still locked"), "Device still locked after entering passcode")
+	}
+
+	return successResult("Unlocked device with passcode", nil)
+}
+
+// setAppearance switches the simulator between dark and light mode.
+// Real devices have no equivalent API, so it fails there.
+func (d *Driver) setAppearance(step *flow.SetAppearanceStep) *core.CommandResult {
+	dark, err := step.Dark()
+	if err != nil {
+		return errorResult(err, err.Error())
+	}
+	if d.udid == "" || !d.info.IsSimulator {
+		return errorResult(fmt.Errorf("setAppearance requires an iOS simulator"),
+			"setAppearance is only supported on iOS simulators")
+	}
+
+	cmd := exec.Command("xcrun", simctlAppearanceArgs(d.udid, dark)...)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return errorResult(fmt.Errorf("simctl ui appearance failed: %w: %s", err, string(output)),
+			"Failed to set appearance")
+	}
+	if step.SettleMs > 0 {
+		time.Sleep(time.Duration(step.SettleMs) * time.Millisecond)
+	}
+
+	return successResult(fmt.Sprintf("Set appearance to %s", appearanceName(dark)), nil)
+}
+
+// travel moves the simulator along the waypoints with `simctl location set`,
+// pausing after each point like the Android driver. Real devices have no
+// equivalent API, so it fails there.
+func (d *Driver) travel(step *flow.TravelStep) *core.CommandResult {
+	if d.udid == "" || !d.info.IsSimulator {
+		return errorResult(fmt.Errorf("travel requires an iOS simulator"),
+			"travel is only supported on iOS simulators")
+	}
+	if len(step.Points) < 2 {
+		return errorResult(fmt.Errorf("at least 2 points required"), "Travel requires at least 2 waypoints")
+	}
+
+	delay := step.PointDelay()
+	for _, point := range step.Waypoints() {
+		cmd := exec.Command("xcrun", simctlLocationSetArgs(d.udid, point)...)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return errorResult(fmt.Errorf("simctl location set failed: %w: %s", err, string(output)),
+				"Failed to set location during travel")
+		}
+		time.Sleep(delay)
+	}
+
+	return successResult(fmt.Sprintf("Traveled through %d points", len(step.Points)), nil)
+}
+
+// simctlLocationSetArgs builds the xcrun arguments for `simctl location set`.
+func simctlLocationSetArgs(udid string, point flow.TravelWaypoint) []string {
+	return []string{"simctl", "location", udid, "set", point.Lat + "," + point.Lon}
+}
+
+// setAirplaneMode mirrors Android's airplane-mode commands as far as iOS
+// allows. XCUITest and simctl cannot cut a simulator's network, so on
+// simulators only the status bar is overridden to show wifi and cellular as
+// down; on real devices the step is skipped with an explanatory message so
+// cross-platform flows keep running.
+func (d *Driver) setAirplaneMode(enabled bool) *core.CommandResult {
+	status := "disabled"
+	if enabled {
+		status = "enabled"
+	}
+	if d.udid == "" || !d.info.IsSimulator {
+		logger.Warn("airplane mode is not supported on real iOS devices, skipping")
+		return successResult(fmt.Sprintf("Airplane mode not supported on real iOS devices, skipped (requested %s)", status), nil)
+	}
+
+	cmd := exec.Command("xcrun", simctlStatusBarArgs(d.udid, enabled)...)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return errorResult(fmt.Errorf("simctl status_bar failed: %w: %s", err, string(output)),
+			"Failed to set airplane mode status bar")
+	}
+	d.airplaneMode = enabled
+
+	return successResult(fmt.Sprintf("Airplane mode %s (simulator status bar only, network stays connected)", status), nil)
+}
+
+// simctlStatusBarArgs builds the xcrun arguments that show wifi and cellular
+// as down in the simulator status bar, or restore the real status bar.
+func simctlStatusBarArgs(udid string, airplane bool) []string {
+	if !airplane {
+		return []string{"simctl", "status_bar", udid, "clear"}
+	}
+	return []string{"simctl", "status_bar", udid, "override",
+		"--dataNetwork", "hide",
+		"--wifiMode", "failed", "--wifiBars", "0",
+		"--cellularMode", "notSupported", "--cellularBars", "0"}
+}
+
+// recordingStopTimeout bounds how long stopRecording waits for recordVideo
+// to finalize the file after the interrupt.
+const recordingStopTimeout = 10 * time.Second
+
+// startRecording records the simulator screen with `simctl io recordVideo`
+// running in the background until stopRecording. Real devices have no
+// equivalent API, so it fails there.
+func (d *Driver) startRecording(step *flow.StartRecordingStep) *core.CommandResult {
+	if d.udid == "" || !d.info.IsSimulator {
+		return errorResult(fmt.Errorf("startRecording requires an iOS simulator"),
+			"startRecording is only supported on iOS simulators")
+	}
+	if d.recording != nil {
+		return errorResult(fmt.Errorf("recording already in progress"),
+			fmt.Sprintf("startRecording: already recording to %s", d.recordingPath))
+	}
+	if step.Size != "" {
+		return errorResult(fmt.Errorf("recording size is not supported on iOS"),
+			"startRecording size is only supported on Android")
+	}
+	if err := core.CheckRecordingCrop(step.Crop); err != nil {
+		return errorResult(err, err.Error())
+	}
+	// Measured now, while the status bar is on screen, and applied to the
+	// finished file when the recording stops
+	var crop float64
+	if step.Crop != "" {
+		fraction, err := d.statusBarFraction()
+		if err != nil {
+			return errorResult(err, fmt.Sprintf("Failed to measure the status bar for crop: %v", err))
+		}
+		crop = fraction
+	}
+
+	path := step.Path
+	if path == "" {
+		// A file of its own per device, so parallel simulators don't share one
+		f, err := os.CreateTemp("", "maestro-recording-"+d.udid+"-*.mp4")
+		if err != nil {
+			return errorResult(err, fmt.Sprintf("Failed to create recording file: %v", err))
+		}
+		_ = f.Close()
+		path = f.Name()
+	}
+	args, err := simulator.RecordVideoArgs(d.udid, path, step.Mask)
+	if err != nil {
+		if step.Path == "" {
+			_ = os.Remove(path)
+		}
+		return errorResult(err, err.Error())
+	}
+
+	cmd := exec.Command("xcrun", args...)
+	if err := cmd.Start(); err != nil {
+		if step.Path == "" {
+			_ = os.Remove(path)
+		}
+		return errorResult(err, fmt.Sprintf("Failed to start recording: %v", err))
+	}
+	d.recording = cmd
+	d.recordingPath = path
+	d.recordingCrop = crop
+
+	return &core.CommandResult{
+		Success: true,
+		Message: fmt.Sprintf("Started recording to %s", path),
+		Data:    path,
+	}
+}
+
+// statusBarFraction returns the share of the screen height the status bar
+// takes, for cropping it off a recording.
+func (d *Driver) statusBarFraction() (float64, error) {
+	bar, err := d.client.StatusBarHeight()
+	if err != nil {
+		return 0, err
+	}
+	_, height, err := d.client.WindowSize()
+	if err != nil {
+		return 0, err
+	}
+	if bar <= 0 || int(bar) >= height {
+		return 0, fmt.Errorf("status bar height %.0f out of range for a %d pt screen", bar, height)
+	}
+	return bar / float64(height), nil
+}
+
+// stopRecording interrupts recordVideo, which finalizes the file on SIGINT,
+// and waits for it to exit. The file is then cropped when startRecording
+// asked for it, and moved to Path when set.
+func (d *Driver) stopRecording(step *flow.StopRecordingStep) *core.CommandResult {
+	cmd, path, crop := d.recording, d.recordingPath, d.recordingCrop
+	d.recording, d.recordingPath, d.recordingCrop = nil, "", 0
+	if cmd == nil {
+		return errorResult(fmt.Errorf("no recording in progress"), "stopRecording: no recording was started")
+	}
+
+	if err := cmd.Process.Signal(os.Interrupt); err != nil {
+		logger.Warn("failed to interrupt recordVideo: %v", err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+	select {
+	case err := <-done:
+		if err != nil {
+			logger.Debug("recordVideo exited: %v", err)
+		}
+	case <-time.After(recordingStopTimeout):
+		_ = cmd.Process.Kill()
+		<-done
+		return errorResult(fmt.Errorf("recordVideo did not exit within %v", recordingStopTimeout),
+			"Timed out waiting for the recording to finish")
+	}
+
+	if crop > 0 {
+		if err := core.CropRecordingTop(path, crop); err != nil {
+			return errorResult(err, fmt.Sprintf("Failed to crop recording: %v", err))
+		}
+	}
+	if step.Path != "" && step.Path != path {
+		if err := moveFile(path, step.Path); err != nil {
+			return errorResult(err, fmt.Sprintf("Failed to save recording: %v", err))
+		}
+		path = step.Path
+	}
+
+	return &core.CommandResult{
+		Success: true,
+		Message: fmt.Sprintf("Stopped recording, saved to %s", path),
+		Data:    path,
+	}
+}
+
+// moveFile renames src to dst, copying and removing src when they are on
+// different filesystems (e.g. a temp recording and the report directory).
+func moveFile(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = in.Close() }()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		_ = os.Remove(dst)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		_ = os.Remove(dst)
+		return err
+	}
+	return os.Remove(src)
+}
+
+// simctlAppearanceArgs builds the xcrun arguments for `simctl ui appearance`.
+func simctlAppearanceArgs(udid string, dark bool) []string {
+	return []string{"simctl", "ui", udid, "appearance", appearanceName(dark)}
+}
+
+func appearanceName(dark bool) string {
+	if dark {
+		return "dark"
+	}
+	return "light"
+}
+
+// setPreference writes one key into the app's UserDefaults plist inside its
+// simulator data container. The app is terminated first so it does not
+// overwrite the value on exit. Real devices expose no container access.
+func (d *Driver) setPreference(step *flow.SetPreferenceStep) *core.CommandResult {
+	typ, value, err := step.Typed()
+	if err != nil {
+		return errorResult(err, err.Error())
+	}
+	if step.Key == "" {
+		return errorResult(fmt.Errorf("no key specified"), "setPreference requires key")
+	}
+	if step.AppID == "" {
+		return errorResult(fmt.Errorf("no bundle ID specified"), "setPreference requires appId")
+	}
+	if d.udid == "" || !d.info.IsSimulator {
+		return errorResult(fmt.Errorf("setPreference requires an iOS simulator"),
+			"setPreference is only supported on iOS simulators")
+	}
+
+	_ = d.client.TerminateApp(step.AppID)
+
+	out, err := exec.Command("xcrun", "simctl", "get_app_container", d.udid, step.AppID, "data").Output()
+	container := strings.TrimSpace(string(out))
+	if err != nil || container == "" {
+		return errorResult(fmt.Errorf("simctl get_app_container failed: %v", err),
+			fmt.Sprintf("App %s is not installed on the simulator", step.AppID))
+	}
+
+	plist := container + "/Library/Preferences/" + step.AppID
+	cmd := exec.Command("xcrun", simctlDefaultsWriteArgs(d.udid, plist, step.Key, typ, value)...)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return errorResult(fmt.Errorf("defaults write failed: %w: %s", err, string(output)),
+			fmt.Sprintf("Failed to set preference %s", step.Key))
+	}
+
+	return successResult(fmt.Sprintf("Set %s preference %s = %s", typ, step.Key, value), nil)
+}
+
+// simctlDefaultsWriteArgs builds the xcrun arguments that run `defaults write`
+// inside the simulator. UserDefaults has no separate long type, so long maps to -int.
+func simctlDefaultsWriteArgs(udid, plist, key, typ, value string) []string {
+	flag := "-string"
+	switch typ {
+	case flow.PrefBoolean:
+		flag = "-bool"
+	case flow.PrefInt, flow.PrefLong:
+		flag = "-int"
+	case flow.PrefFloat:
+		flag = "-float"
+	}
+	return []string{"simctl", "spawn", udid, "defaults", "write", plist, key, flag, value}
+}
+
+// installPollInterval is how often waitForInstall re-checks the app.
+const installPollInterval = 500 * time.Millisecond
+
+// waitForInstall polls until the app is installed: `simctl listapps` on
+// simulators, WDA's app state (0 = not installed) on real devices.
+func (d *Driver) waitForInstall(step *flow.WaitForInstallStep) *core.CommandResult {
+	if step.AppID == "" {
+		return errorResult(fmt.Errorf("no bundle ID specified"), "waitForInstall requires appId")
+	}
+
+	timeout := step.Timeout()
+	start := time.Now()
+	deadline := start.Add(time.Duration(timeout) * time.Millisecond)
+	for {
+		installed, err := d.appInstalled(step.AppID)
+		if err != nil {
+			logger.Debug("waitForInstall: %v", err)
+		} else if installed {
+			return successResult(fmt.Sprintf("App %s installed after %dms", step.AppID, time.Since(start).Milliseconds()), nil)
+		}
+
+		if time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(installPollInterval)
+	}
+
+	return errorResult(fmt.Errorf("app not installed"),
+		fmt.Sprintf("App %s was not installed within %dms", step.AppID, timeout))
+}
+
+// appInstalled reports whether bundleID is on the device.
+func (d *Driver) appInstalled(bundleID string) (bool, error) {
+	if d.udid != "" && d.info != nil && d.info.IsSimulator {
+		out, err := exec.Command("xcrun", "simctl", "listapps", d.udid).Output()
+		if err != nil {
+			return false, fmt.Errorf("simctl listapps: %w", err)
+		}
+		return simctlListsApp(string(out), bundleID), nil
+	}
+	state, err := d.client.AppState(bundleID)
+	if err != nil {
+		return false, err
+	}
+	return state != AppStateNotInstalled, nil
+}
+
+// simctlListsApp reports whether `simctl listapps` output, a plist keyed by
+// bundle ID, has an entry for bundleID.
+func simctlListsApp(output, bundleID string) bool {
+	for _, line := range strings.Split(output, "\n") {
+		key, _, ok := strings.Cut(strings.TrimSpace(line), " =")
+		if ok && strings.Trim(key, `"`) == bundleID {
+			return true
+		}
+	}
+	return false
+}
+
 // Wait commands
 
 func (d *Driver) waitUntil(step *flow.WaitUntilStep) *core.CommandResult {
@@ -956,18 +2348,154 @@ func (d *Driver) waitUntil(step *flow.WaitUntilStep) *core.CommandResult {
 				}
 			}
 			// HTTP round-trip (~100ms) is natural rate limit, no sleep needed
+			d.invalidateSource()
 		}
 	}
 }
 
-func (d *Driver) waitForAnimationToEnd(_ *flow.WaitForAnimationToEndStep) *core.CommandResult {
-	// NOTE: waitForAnimationToEnd is not fully implemented.
-	// Maestro uses screenshot comparison which is complex to implement correctly.
-	// For now, we pass this step with a warning.
-	return &core.CommandResult{
-		Success: true,
-		Message: "WARNING: waitForAnimationToEnd is not fully implemented - step passed without animation check",
+// waitUntilAll polls every selector of the step once per cycle and succeeds
+// only when all visible selectors are found and all notVisible ones are gone.
+// On timeout the still-unsatisfied selectors are listed in the message.
+func (d *Driver) waitUntilAll(step *flow.ExtendedWaitUntilStep) *core.CommandResult {
+	timeoutMs := step.TimeoutMs
+	if timeoutMs <= 0 {
+		timeoutMs = DefaultFindTimeout
+	}
+	timeout := time.Duration(timeoutMs) * time.Millisecond
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	for {
+		pending := d.unsatisfiedWaits(step)
+		if len(pending) == 0 {
+			return successResult(fmt.Sprintf("All %d wait conditions met", len(step.Visible)+len(step.NotVisible)), nil)
+		}
+		select {
+		case <-ctx.Done():
+			return errorResult(
+				context.DeadlineExceeded,
+				fmt.Sprintf("Wait conditions not met within %v: %s", timeout, strings.Join(pending, ", ")),
+			)
+		default:
+			// One fresh /source per cycle is the natural rate limit, no sleep needed
+			d.invalidateSource()
+		}
+	}
+}
+
+// unsatisfiedWaits checks each selector of the step once and describes the
+// ones whose condition does not hold yet.
+func (d *Driver) unsatisfiedWaits(step *flow.ExtendedWaitUntilStep) []string {
+	var pending []string
+	for _, sel := range step.Visible {
+		if info, err := d.findElementOnce(sel); err != nil || info == nil {
+			pending = append(pending, "visible "+sel.DescribeQuoted())
+		}
+	}
+	for _, sel := range step.NotVisible {
+		if info, err := d.findElementOnce(sel); err == nil && info != nil {
+			pending = append(pending, "notVisible "+sel.DescribeQuoted())
+		}
+	}
+	return pending
+}
+
+// animationPollInterval is how often waitForAnimationToEnd takes a screenshot.
+const animationPollInterval = 200 * time.Millisecond
+
+// waitForAnimationToEnd compares consecutive screenshots until two in a row
+// match within core.FrameDiffTolerance or the step timeout elapses.
+func (d *Driver) waitForAnimationToEnd(step *flow.WaitForAnimationToEndStep) *core.CommandResult {
+	timeout := step.Timeout()
+	frames, settled := d.waitForScreenToSettle(timeout)
+	if settled {
+		return successResult(fmt.Sprintf("Animation ended (settled after comparing %d frames)", frames), nil)
+	}
+
+	msg := fmt.Sprintf("Animation still running after %dms (compared %d frames)", timeout, frames)
+	if step.ShouldContinueOnTimeout() {
+		return successResult(msg+", continuing", nil)
+	}
+	return errorResult(fmt.Errorf("animation did not end within %dms", timeout), msg)
+}
+
+// waitForScreenToSettle takes screenshots until two in a row match within
+// core.FrameDiffTolerance or timeoutMs elapses. Returns how many frames were
+// compared and whether the screen settled. WDA screenshots can fail
+// mid-transition, so a failed capture just counts as "still changing".
+func (d *Driver) waitForScreenToSettle(timeoutMs int) (int, bool) {
+	deadline := time.Now().Add(time.Duration(timeoutMs) * time.Millisecond)
+
+	var prev []byte
+	frames := 0
+	for {
+		frame, err := d.client.Screenshot()
+		if err != nil {
+			logger.Debug("waitForScreenToSettle: screenshot failed: %v", err)
+		} else {
+			frames++
+			if prev != nil {
+				if diff, err := core.FrameDiff(prev, frame); err == nil && diff <= core.FrameDiffTolerance {
+					return frames, true
+				}
+			}
+		}
+		prev = frame
+		if !time.Now().Before(deadline) {
+			return frames, false
+		}
+		time.Sleep(animationPollInterval)
+	}
+}
+
+// waitForTextPollInterval is how often waitForText re-reads the page source.
+const waitForTextPollInterval = 300 * time.Millisecond
+
+// waitForText polls the first element matching the step's selector until its
+// label (or value when the label is empty) satisfies the condition.
+func (d *Driver) waitForText(step *flow.WaitForTextStep) *core.CommandResult {
+	matcher, err := core.NewTextMatcher(step.Equals, step.Contains, step.Matches)
+	if err != nil {
+		return errorResult(err, fmt.Sprintf("Invalid waitForText condition: %v", err))
+	}
+
+	timeoutMs := step.TimeoutMs
+	if timeoutMs <= 0 {
+		timeoutMs = DefaultFindTimeout
+	}
+	deadline := time.Now().Add(time.Duration(timeoutMs) * time.Millisecond)
+
+	found := false
+	var last string
+	for {
+		elements, err := d.pageSourceElements()
+		if err != nil {
+			return errorResult(err, "Failed to read page source")
+		}
+		if matches := FilterBySelector(elements, step.Element); len(matches) > 0 {
+			found = true
+			last = matches[0].Label
+			if last == "" {
+				last = matches[0].Value
+			}
+			if matcher.Match(last) {
+				return successResult(fmt.Sprintf("Text matched: %q", last), nil)
+			}
+		}
+		if time.Now().After(deadline) {
+			break
+		}
+		d.invalidateSource()
+		time.Sleep(waitForTextPollInterval)
+	}
+
+	if !found {
+		return errorResult(fmt.Errorf("element not found"),
+			fmt.Sprintf("Element %s not found within %dms", selectorDesc(step.Element), timeoutMs))
 	}
+	return errorResult(context.DeadlineExceeded,
+		fmt.Sprintf("Text of %s did not %s within %dms; last text: %q", selectorDesc(step.Element), matcher, timeoutMs, last))
 }
 
 // Media
@@ -991,43 +2519,15 @@ func selectorDesc(sel flow.Selector) string {
 	if sel.Text != "" {
 		return fmt.Sprintf("text='%s'", sel.Text)
 	}
+	if sel.TextRegex != "" {
+		return fmt.Sprintf("textRegex='%s'", sel.TextRegex)
+	}
 	if sel.ID != "" {
 		return fmt.Sprintf("id='%s'", sel.ID)
 	}
 	return "selector"
 }
 
-func randomString(length int) string {
-	const chars = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-	b := make([]byte, length)
-	for i := range b {
-		b[i] = chars[rand.Intn(len(chars))]
-	}
-	return string(b)
-}
-
-func randomEmail() string {
-	user := randomString(8)
-	domains := []string{"example.com", "test.com", "mail.com"}
-	domain := domains[rand.Intn(len(domains))]
-	return user + "@" + domain
-}
-
-func randomNumber(length int) string {
-	const digits = "0123456789"
-	b := make([]byte, length)
-	for i := range b {
-		b[i] = digits[rand.Intn(len(digits))]
-	}
-	return string(b)
-}
-
-func randomPersonName() string {
-	firstNames := []string{"John", "Jane", "Michael", "Emily", "David", "Sarah", "James", "Emma", "Robert", "Olivia"}
-	lastNames := []string{"Smith", "Johnson", "Williams", "Brown", "Jones", "Garcia", "Miller", "Davis", "Rodriguez", "Martinez"}
-	return firstNames[rand.Intn(len(firstNames))] + " " + lastNames[rand.Intn(len(lastNames))]
-}
-
 func parsePercentageCoords(coord string) (float64, float64, error) {
 	// Parse "50%, 50%" format
 	coord = strings.ReplaceAll(coord, " ", "")
@@ -1,12 +1,22 @@
 package wda
 
 import (
+	"bytes"
+	"encoding/base64"
 	"encoding/json"
+	"fmt"
+	"image"
+	"image/png"
 	"io"
+	"math"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/devicelab-dev/maestro-runner/pkg/core"
 	"github.com/devicelab-dev/maestro-runner/pkg/flow"
@@ -387,6 +397,54 @@ func TestOpenLinkWithBothFlags(t *testing.T) {
 	}
 }
 
+// TestOpenLinkClearStateTerminatesBeforeLink verifies a cold start stops the app
+// and never fires the link when the clear step cannot complete.
+func TestOpenLinkClearStateTerminatesBeforeLink(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		jsonResponse(w, map[string]interface{}{"status": 0})
+	}))
+	defer server.Close()
+	driver := createTestDriver(server)
+
+	step := &flow.OpenLinkStep{
+		Link:       "myapp://orders/42",
+		ClearState: true,
+		AppID:      "com.example.app",
+	}
+	result := driver.openLink(step)
+
+	if result.Success {
+		t.Fatal("Expected failure without --app-file")
+	}
+	if len(paths) == 0 || !strings.HasSuffix(paths[0], "/wda/apps/terminate") {
+		t.Errorf("Expected terminate first, got: %v", paths)
+	}
+	for _, p := range paths {
+		if strings.HasSuffix(p, "/url") {
+			t.Errorf("Link should not be opened after failed clear, got: %v", paths)
+		}
+	}
+}
+
+// TestOpenLinkWaitFor tests that openLink verifies the landing element.
+func TestOpenLinkWaitFor(t *testing.T) {
+	server := mockWDAServerForDriver()
+	defer server.Close()
+	driver := createTestDriver(server)
+
+	step := &flow.OpenLinkStep{
+		Link:    "myapp://login",
+		WaitFor: &flow.Selector{Text: "Login"},
+	}
+	result := driver.openLink(step)
+
+	if !result.Success {
+		t.Errorf("Expected success, got: %s", result.Message)
+	}
+}
+
 // =============================================================================
 // copyTextFrom tests
 // =============================================================================
@@ -431,6 +489,63 @@ func TestCopyTextFromVerifiesDataField(t *testing.T) {
 	}
 }
 
+// TestCopyTextFromThenPasteText tests that copyTextFrom puts the text on the
+// pasteboard and pasteText types it back into the focused field.
+func TestCopyTextFromThenPasteText(t *testing.T) {
+	var pasteboard string
+	var typed []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := r.URL.Path
+		switch {
+		case strings.HasSuffix(path, "/source"):
+			jsonResponse(w, map[string]interface{}{
+				"value": `<?xml version="1.0" encoding="UTF-8"?>
+<AppiumAUT>
+  <XCUIElementTypeApplication type="XCUIElementTypeApplication" name="TestApp" enabled="true" visible="true" x="0" y="0" width="390" height="844">
+    <XCUIElementTypeStaticText type="XCUIElementTypeStaticText" name="code" label="ABC-123" enabled="true" visible="true" x="50" y="200" width="100" height="30"/>
+  </XCUIElementTypeApplication>
+</AppiumAUT>`,
+			})
+			return
+		case strings.HasSuffix(path, "/wda/setPasteboard"):
+			var payload map[string]string
+			_ = json.NewDecoder(r.Body).Decode(&payload)
+			data, _ := base64.StdEncoding.DecodeString(payload["content"])
+			pasteboard = string(data)
+		case strings.HasSuffix(path, "/wda/getPasteboard"):
+			jsonResponse(w, map[string]interface{}{"value": base64.StdEncoding.EncodeToString([]byte(pasteboard))})
+			return
+		case strings.HasSuffix(path, "/element/active"):
+			jsonResponse(w, map[string]interface{}{"value": map[string]interface{}{"ELEMENT": "field-1"}})
+			return
+		case strings.HasSuffix(path, "/wda/keys"):
+			var payload struct {
+				Value []string `json:"value"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&payload)
+			typed = append(typed, strings.Join(payload.Value, ""))
+		}
+		jsonResponse(w, map[string]interface{}{"value": nil})
+	}))
+	defer server.Close()
+	driver := createTestDriver(server)
+
+	if result := driver.Execute(&flow.CopyTextFromStep{Selector: flow.Selector{ID: "code"}}); !result.Success {
+		t.Fatalf("copyTextFrom failed: %s", result.Message)
+	}
+	if pasteboard != "ABC-123" {
+		t.Errorf("Expected pasteboard 'ABC-123', got %q", pasteboard)
+	}
+
+	result := driver.Execute(&flow.PasteTextStep{})
+	if !result.Success {
+		t.Fatalf("pasteText failed: %s", result.Message)
+	}
+	if len(typed) != 1 || typed[0] != "ABC-123" {
+		t.Errorf("Expected 'ABC-123' typed once, got %v", typed)
+	}
+}
+
 // TestCopyTextFromByID tests copyTextFrom using an ID selector.
 func TestCopyTextFromByID(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -464,6 +579,152 @@ func TestCopyTextFromByID(t *testing.T) {
 	}
 }
 
+// =============================================================================
+// gesturePath tests
+// =============================================================================
+
+func TestGesturePath(t *testing.T) {
+	type move struct {
+		Type     string  `json:"type"`
+		Duration int     `json:"duration"`
+		X        float64 `json:"x"`
+		Y        float64 `json:"y"`
+	}
+	var moves []move
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.Contains(r.URL.Path, "/window/size"):
+			jsonResponse(w, map[string]interface{}{
+				"value": map[string]interface{}{"width": 400.0, "height": 800.0},
+			})
+		case strings.HasSuffix(r.URL.Path, "/actions"):
+			var body struct {
+				Actions []struct {
+					Actions []move `json:"actions"`
+				} `json:"actions"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				t.Fatalf("Failed to decode actions body: %v", err)
+			}
+			moves = body.Actions[0].Actions
+			jsonResponse(w, map[string]interface{}{"status": 0})
+		default:
+			jsonResponse(w, map[string]interface{}{"status": 0})
+		}
+	}))
+	defer server.Close()
+	driver := createTestDriver(server)
+
+	// (40,80) -> (40,320) -> (160,320): segments of 240pt and 120pt share 900ms 2:1
+	result := driver.gesturePath(&flow.GesturePathStep{
+		Points:     []string{"10%, 10%", "10%, 40%", "40%, 40%"},
+		DurationMs: 900,
+	})
+
+	if !result.Success {
+		t.Fatalf("Expected success, got: %s", result.Message)
+	}
+	want := []move{
+		{"pointerMove", 0, 40, 80},
+		{"pointerDown", 0, 0, 0},
+		{"pointerMove", 600, 40, 320},
+		{"pointerMove", 300, 160, 320},
+		{"pointerUp", 0, 0, 0},
+	}
+	if len(moves) != len(want) {
+		t.Fatalf("Expected %d actions, got %d: %+v", len(want), len(moves), moves)
+	}
+	for i := range want {
+		if moves[i] != want[i] {
+			t.Errorf("Action %d: expected %+v, got %+v", i, want[i], moves[i])
+		}
+	}
+}
+
+func TestTwoFingerSwipe(t *testing.T) {
+	type move struct {
+		Type     string  `json:"type"`
+		Duration int     `json:"duration"`
+		X        float64 `json:"x"`
+		Y        float64 `json:"y"`
+	}
+	var sources []struct {
+		ID      string `json:"id"`
+		Actions []move `json:"actions"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.Contains(r.URL.Path, "/window/size"):
+			jsonResponse(w, map[string]interface{}{
+				"value": map[string]interface{}{"width": 400.0, "height": 800.0},
+			})
+		case strings.HasSuffix(r.URL.Path, "/actions"):
+			var body struct {
+				Actions json.RawMessage `json:"actions"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				t.Fatalf("Failed to decode actions body: %v", err)
+			}
+			if err := json.Unmarshal(body.Actions, &sources); err != nil {
+				t.Fatalf("Failed to decode pointer sources: %v", err)
+			}
+			jsonResponse(w, map[string]interface{}{"status": 0})
+		default:
+			jsonResponse(w, map[string]interface{}{"status": 0})
+		}
+	}))
+	defer server.Close()
+	driver := createTestDriver(server)
+
+	// Midpoint (200,480) -> (200,320) with fingers 40pt apart at x=180 and x=220
+	result := driver.Execute(&flow.TwoFingerSwipeStep{Start: "50%, 60%", End: "50%, 40%"})
+
+	if !result.Success {
+		t.Fatalf("Expected success, got: %s", result.Message)
+	}
+	if len(sources) != 2 {
+		t.Fatalf("Expected 2 pointer sources, got %d", len(sources))
+	}
+	for i, x := range []float64{180, 220} {
+		want := []move{
+			{"pointerMove", 0, x, 480},
+			{"pointerDown", 0, 0, 0},
+			{"pointerMove", flow.DefaultTwoFingerSwipeDurationMs, x, 320},
+			{"pointerUp", 0, 0, 0},
+		}
+		if sources[i].ID != fmt.Sprintf("finger%d", i+1) {
+			t.Errorf("Source %d: unexpected id %q", i, sources[i].ID)
+		}
+		if len(sources[i].Actions) != len(want) {
+			t.Fatalf("Source %d: expected %d actions, got %+v", i, len(want), sources[i].Actions)
+		}
+		for j := range want {
+			if sources[i].Actions[j] != want[j] {
+				t.Errorf("Source %d action %d: expected %+v, got %+v", i, j, want[j], sources[i].Actions[j])
+			}
+		}
+	}
+}
+
+func TestGesturePathInvalidPoint(t *testing.T) {
+	server := mockWDAServer(func(w http.ResponseWriter, r *http.Request) {
+		jsonResponse(w, map[string]interface{}{
+			"value": map[string]interface{}{"width": 400.0, "height": 800.0},
+		})
+	})
+	defer server.Close()
+	driver := createTestDriver(server)
+
+	result := driver.gesturePath(&flow.GesturePathStep{Points: []string{"10%, 10%", "middle"}})
+
+	if result.Success {
+		t.Fatal("Expected failure for an unparseable point")
+	}
+	if !strings.Contains(result.Message, "point 2") {
+		t.Errorf("Expected message to name the bad point, got: %s", result.Message)
+	}
+}
+
 // =============================================================================
 // scroll tests
 // =============================================================================
@@ -541,6 +802,89 @@ func TestScrollVerifiesSwipeCoordinates(t *testing.T) {
 	}
 }
 
+// TestScrollAmount tests that amount sets the swipe distance as a fraction of
+// the window along the scroll axis.
+func TestScrollAmount(t *testing.T) {
+	tests := []struct {
+		direction string
+		amount    string
+		wantDist  float64
+		vertical  bool
+	}{
+		{"down", "", 844.0 / 3, true},
+		{"down", "25%", 211, true},
+		{"up", "0.5", 422, true},
+		{"right", "20%", 78, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.direction+" "+tt.amount, func(t *testing.T) {
+			var fromX, fromY, toX, toY float64
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if strings.Contains(r.URL.Path, "/window/size") {
+					jsonResponse(w, map[string]interface{}{
+						"value": map[string]interface{}{"width": 390.0, "height": 844.0},
+					})
+					return
+				}
+				if strings.Contains(r.URL.Path, "/dragfromtoforduration") {
+					var payload map[string]interface{}
+					_ = json.NewDecoder(r.Body).Decode(&payload)
+					fromX, _ = payload["fromX"].(float64)
+					fromY, _ = payload["fromY"].(float64)
+					toX, _ = payload["toX"].(float64)
+					toY, _ = payload["toY"].(float64)
+				}
+				jsonResponse(w, map[string]interface{}{"status": 0})
+			}))
+			defer server.Close()
+			driver := createTestDriver(server)
+
+			result := driver.scroll(&flow.ScrollStep{Direction: tt.direction, Amount: tt.amount})
+			if !result.Success {
+				t.Fatalf("Expected success, got: %s", result.Message)
+			}
+			dist := toX - fromX
+			if tt.vertical {
+				dist = toY - fromY
+			}
+			if math.Abs(math.Abs(dist)-tt.wantDist) > 0.5 {
+				t.Errorf("Expected swipe distance %.0f, got %.0f", tt.wantDist, math.Abs(dist))
+			}
+			if tt.amount != "" && !strings.Contains(result.Message, "by "+tt.amount) {
+				t.Errorf("Expected amount in message, got: %s", result.Message)
+			}
+		})
+	}
+}
+
+// TestScrollInvalidAmount tests that a bad amount fails before swiping.
+func TestScrollInvalidAmount(t *testing.T) {
+	swiped := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Path, "/window/size") {
+			jsonResponse(w, map[string]interface{}{
+				"value": map[string]interface{}{"width": 390.0, "height": 844.0},
+			})
+			return
+		}
+		if strings.Contains(r.URL.Path, "/dragfromtoforduration") {
+			swiped = true
+		}
+		jsonResponse(w, map[string]interface{}{"status": 0})
+	}))
+	defer server.Close()
+	driver := createTestDriver(server)
+
+	result := driver.scroll(&flow.ScrollStep{Direction: "down", Amount: "150%"})
+	if result.Success {
+		t.Fatal("Expected failure for out-of-range amount")
+	}
+	if swiped {
+		t.Error("Expected no swipe for an invalid amount")
+	}
+}
+
 // =============================================================================
 // scrollUntilVisible tests
 // =============================================================================
@@ -828,6 +1172,234 @@ func TestInputTextWithSelectorElementIDDirectSend(t *testing.T) {
 	}
 }
 
+// fakeField is the focused text field of verifyTextServer. It starts out
+// holding prefill, the first drops typings lose their last character, and an
+// empty field reads back as its placeholder.
+type fakeField struct {
+	prefill, placeholder string
+	drops                int
+
+	value  string
+	typed  []string
+	clears int
+}
+
+// verifyTextServer serves f as the focused field, recording typed text and
+// clear calls.
+func verifyTextServer(f *fakeField) *httptest.Server {
+	f.value = f.prefill
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		path := r.URL.Path
+
+		switch {
+		case strings.HasSuffix(path, "/element/active"):
+			jsonResponse(w, map[string]interface{}{
+				"value": map[string]interface{}{"ELEMENT": "field-1"},
+			})
+		case strings.HasSuffix(path, "/wda/keys"), strings.HasSuffix(path, "/element/field-1/value"):
+			var body struct {
+				Value []string `json:"value"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			text := strings.Join(body.Value, "")
+			f.typed = append(f.typed, text)
+			if f.drops > 0 {
+				f.drops--
+				text = text[:len(text)-1]
+			}
+			f.value += text
+			jsonResponse(w, map[string]interface{}{"status": 0})
+		case strings.HasSuffix(path, "/element/field-1/attribute/value"):
+			value := f.value
+			if value == "" {
+				value = f.placeholder
+			}
+			jsonResponse(w, map[string]interface{}{"value": value})
+		case strings.HasSuffix(path, "/element/field-1/clear"):
+			f.clears++
+			f.value = ""
+			jsonResponse(w, map[string]interface{}{"status": 0})
+		default:
+			jsonResponse(w, map[string]interface{}{"status": 0})
+		}
+	}))
+}
+
+// fieldValueServer serves a text field found by id whose value attribute is value.
+func fieldValueServer(value string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		path := r.URL.Path
+
+		switch {
+		case strings.HasSuffix(path, "/element") && r.Method == http.MethodPost:
+			jsonResponse(w, map[string]interface{}{
+				"value": map[string]interface{}{"ELEMENT": "field-1"},
+			})
+		case strings.HasSuffix(path, "/element/field-1/attribute/value"):
+			jsonResponse(w, map[string]interface{}{"value": value})
+		case strings.HasSuffix(path, "/element/field-1/rect"):
+			jsonResponse(w, map[string]interface{}{
+				"value": map[string]interface{}{"x": 20.0, "y": 200.0, "width": 350.0, "height": 44.0},
+			})
+		case strings.HasSuffix(path, "/element/field-1/displayed"):
+			jsonResponse(w, map[string]interface{}{"value": true})
+		default:
+			jsonResponse(w, map[string]interface{}{"status": 0})
+		}
+	}))
+}
+
+// TestAssertFieldValue tests equals/contains matching against the field's
+// value attribute, with observed and expected values in the failure message.
+func TestAssertFieldValue(t *testing.T) {
+	tests := []struct {
+		name     string
+		equals   string
+		contains string
+		success  bool
+	}{
+		{"equals match", "jane@example.com", "", true},
+		{"contains match", "", "@example.com", true},
+		{"equals mismatch", "john@example.com", "", false},
+		{"contains mismatch", "", "@test.com", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := fieldValueServer("jane@example.com")
+			defer server.Close()
+			driver := createTestDriver(server)
+
+			result := driver.Execute(&flow.AssertFieldValueStep{
+				Element:  flow.Selector{ID: "emailField"},
+				Equals:   tt.equals,
+				Contains: tt.contains,
+			})
+
+			if result.Success != tt.success {
+				t.Fatalf("Expected success=%v, got %v: %s", tt.success, result.Success, result.Message)
+			}
+			if !tt.success && !strings.Contains(result.Message, `got "jane@example.com"`) {
+				t.Errorf("Expected observed value in message, got: %s", result.Message)
+			}
+		})
+	}
+}
+
+// TestInputTextVerifyTextRetriesOnShortRead tests that a short read-back
+// clears the field and retypes once, then succeeds.
+func TestInputTextVerifyTextRetriesOnShortRead(t *testing.T) {
+	field := &fakeField{drops: 1}
+	server := verifyTextServer(field)
+	defer server.Close()
+	driver := createTestDriver(server)
+
+	step := &flow.InputTextStep{Text: "user@test.com", VerifyText: true}
+	result := driver.inputText(step)
+
+	if !result.Success {
+		t.Fatalf("Expected success after retry, got: %s", result.Message)
+	}
+	if len(field.typed) != 2 {
+		t.Errorf("Expected text typed twice, got %d: %v", len(field.typed), field.typed)
+	}
+	if field.clears != 1 {
+		t.Errorf("Expected 1 clear before retry, got %d", field.clears)
+	}
+}
+
+// TestInputTextVerifyTextFailsWhenStillWrong tests that a mismatch after the
+// retry fails the step.
+func TestInputTextVerifyTextFailsWhenStillWrong(t *testing.T) {
+	field := &fakeField{drops: 2}
+	server := verifyTextServer(field)
+	defer server.Close()
+	driver := createTestDriver(server)
+
+	step := &flow.InputTextStep{Text: "user@test.com", VerifyText: true}
+	result := driver.inputText(step)
+
+	if result.Success {
+		t.Fatal("Expected failure when read-back still mismatches")
+	}
+	if !strings.Contains(result.Message, "verification failed") {
+		t.Errorf("Expected verification failure message, got: %s", result.Message)
+	}
+	if len(field.typed) != 2 {
+		t.Errorf("Expected exactly one retry, got %d typings", len(field.typed))
+	}
+}
+
+// TestInputTextVerifyTextMatchNoRetry tests that a correct read-back does not retype.
+func TestInputTextVerifyTextMatchNoRetry(t *testing.T) {
+	field := &fakeField{}
+	server := verifyTextServer(field)
+	defer server.Close()
+	driver := createTestDriver(server)
+
+	result := driver.inputText(&flow.InputTextStep{Text: "user@test.com", VerifyText: true})
+
+	if !result.Success {
+		t.Fatalf("Expected success, got: %s", result.Message)
+	}
+	if len(field.typed) != 1 || field.clears != 0 {
+		t.Errorf("Expected no retry, got %d typings and %d clears", len(field.typed), field.clears)
+	}
+}
+
+// TestInputTextVerifyTextExactValue tests that a field which contains the
+// text but not exactly prefill+text is retried.
+func TestInputTextVerifyTextExactValue(t *testing.T) {
+	field := &fakeField{prefill: "user@test.com", drops: 1}
+	server := verifyTextServer(field)
+	defer server.Close()
+	driver := createTestDriver(server)
+
+	result := driver.inputText(&flow.InputTextStep{Text: "user@test.com", VerifyText: true})
+
+	if !result.Success {
+		t.Fatalf("Expected success after retry, got: %s", result.Message)
+	}
+	if field.clears != 1 {
+		t.Errorf("Expected a retry for a read-back that only contains the text, got %d clears", field.clears)
+	}
+	if want := "user@test.comuser@test.com"; field.value != want {
+		t.Errorf("Expected field value %q, got %q", want, field.value)
+	}
+}
+
+// TestInputTextVerifyTextKeepsPrefilledText tests that a retry types the
+// field's prefilled text back, but not its placeholder.
+func TestInputTextVerifyTextKeepsPrefilledText(t *testing.T) {
+	tests := []struct {
+		name  string
+		field *fakeField
+		want  string
+	}{
+		{"prefilled", &fakeField{prefill: "Hello ", drops: 1}, "Hello world"},
+		{"placeholder", &fakeField{placeholder: "Message", drops: 1}, "world"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := verifyTextServer(tt.field)
+			defer server.Close()
+			driver := createTestDriver(server)
+
+			result := driver.inputText(&flow.InputTextStep{Text: "world", VerifyText: true})
+
+			if !result.Success {
+				t.Fatalf("Expected success after retry, got: %s", result.Message)
+			}
+			if tt.field.value != tt.want {
+				t.Errorf("Expected field value %q, got %q (typed %q)", tt.want, tt.field.value, tt.field.typed)
+			}
+		})
+	}
+}
+
 // TestInputTextElementSendKeysError tests inputText when ElementSendKeys fails
 // for an element with ID.
 func TestInputTextElementSendKeysError(t *testing.T) {
@@ -887,12 +1459,195 @@ func TestInputTextElementSendKeysError(t *testing.T) {
 	_ = result
 }
 
-// =============================================================================
-// assertNotVisible tests
-// =============================================================================
+// typingServer records the key chunks sent to an element (/element/:id/value)
+// or to the focused field (/wda/keys). With elementID empty, finds miss and
+// the driver falls back to the page source plus a tap.
+func typingServer(t *testing.T, elementID string, chunks *[]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		path := r.URL.Path
 
-// TestAssertNotVisibleVerifiesErrorMessage tests that assertNotVisible returns
-// a clear error message when the element IS visible.
+		if strings.HasSuffix(path, "/value") || strings.HasSuffix(path, "/wda/keys") {
+			var body struct {
+				Value []string `json:"value"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			*chunks = append(*chunks, strings.Join(body.Value, ""))
+			jsonResponse(w, map[string]interface{}{"status": 0})
+			return
+		}
+		if strings.HasSuffix(path, "/element") && r.Method == "POST" {
+			if elementID == "" {
+				w.WriteHeader(http.StatusNotFound)
+				jsonResponse(w, map[string]interface{}{"value": map[string]interface{}{"error": "no such element"}})
+				return
+			}
+			jsonResponse(w, map[string]interface{}{"value": map[string]interface{}{"ELEMENT": elementID}})
+			return
+		}
+		if strings.Contains(path, "/rect") {
+			jsonResponse(w, map[string]interface{}{
+				"value": map[string]interface{}{"x": 50.0, "y": 200.0, "width": 290.0, "height": 44.0},
+			})
+			return
+		}
+		if strings.Contains(path, "/displayed") {
+			jsonResponse(w, map[string]interface{}{"value": true})
+			return
+		}
+		if strings.HasSuffix(path, "/source") {
+			jsonResponse(w, map[string]interface{}{
+				"value": `<?xml version="1.0" encoding="UTF-8"?>
+<AppiumAUT>
+  <XCUIElementTypeApplication type="XCUIElementTypeApplication" name="TestApp" enabled="true" visible="true" x="0" y="0" width="390" height="844">
+    <XCUIElementTypeTextField type="XCUIElementTypeTextField" label="Phone" enabled="true" visible="true" x="50" y="200" width="290" height="44"/>
+  </XCUIElementTypeApplication>
+</AppiumAUT>`,
+			})
+			return
+		}
+		jsonResponse(w, map[string]interface{}{"status": 0})
+	}))
+}
+
+func TestInputTextTypeDelay(t *testing.T) {
+	tests := []struct {
+		name      string
+		elementID string
+		selector  flow.Selector
+	}{
+		{"element send keys", "text-field-1", flow.Selector{ID: "phoneField"}},
+		{"tap and wda keys fallback", "", flow.Selector{Text: "Phone"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var chunks []string
+			server := typingServer(t, tt.elementID, &chunks)
+			defer server.Close()
+			driver := createTestDriver(server)
+
+			start := time.Now()
+			result := driver.inputText(&flow.InputTextStep{Text: "555", TypeDelayMs: 20, Selector: tt.selector})
+
+			if !result.Success {
+				t.Fatalf("Expected success, got: %s", result.Message)
+			}
+			if strings.Join(chunks, ",") != "5,5,5" {
+				t.Errorf("Expected one request per character, got %q", chunks)
+			}
+			if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+				t.Errorf("Expected at least 2 delays of 20ms, took %v", elapsed)
+			}
+			if !strings.Contains(result.Message, "typed 3 chars with 20ms delay") {
+				t.Errorf("Expected typing note in message, got: %s", result.Message)
+			}
+		})
+	}
+}
+
+func TestTypeTextKeepsGraphemesTogether(t *testing.T) {
+	var chunks []string
+	err := typeText("a👍🏽", 1, func(s string) error {
+		chunks = append(chunks, s)
+		return nil
+	})
+
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if strings.Join(chunks, ",") != "a,👍🏽" {
+		t.Errorf("Expected the emoji sent with its modifier, got %q", chunks)
+	}
+}
+
+func TestInputTextNoTypeDelaySendsOnce(t *testing.T) {
+	var chunks []string
+	server := typingServer(t, "text-field-1", &chunks)
+	defer server.Close()
+	driver := createTestDriver(server)
+
+	result := driver.inputText(&flow.InputTextStep{Text: "555", Selector: flow.Selector{ID: "phoneField"}})
+
+	if !result.Success {
+		t.Fatalf("Expected success, got: %s", result.Message)
+	}
+	if len(chunks) != 1 || chunks[0] != "555" {
+		t.Errorf("Expected a single burst, got %q", chunks)
+	}
+	if strings.Contains(result.Message, "delay") {
+		t.Errorf("Expected no typing note without a delay, got: %s", result.Message)
+	}
+}
+
+func TestSearchFocusesTypesAndSubmits(t *testing.T) {
+	var events []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		path := r.URL.Path
+		switch {
+		case strings.HasSuffix(path, "/click") || strings.HasSuffix(path, "/wda/tap"):
+			events = append(events, "tap")
+		case strings.HasSuffix(path, "/wda/keys") || strings.HasSuffix(path, "/value"):
+			var body struct {
+				Value []string `json:"value"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			events = append(events, "type:"+strings.Join(body.Value, ""))
+		case strings.HasSuffix(path, "/element") && r.Method == "POST":
+			jsonResponse(w, map[string]interface{}{"value": map[string]interface{}{"ELEMENT": "search-1"}})
+			return
+		case strings.Contains(path, "/rect"):
+			jsonResponse(w, map[string]interface{}{
+				"value": map[string]interface{}{"x": 16.0, "y": 100.0, "width": 358.0, "height": 36.0},
+			})
+			return
+		case strings.Contains(path, "/displayed"):
+			jsonResponse(w, map[string]interface{}{"value": true})
+			return
+		}
+		jsonResponse(w, map[string]interface{}{"status": 0})
+	}))
+	defer server.Close()
+	driver := createTestDriver(server)
+
+	result := driver.search(&flow.SearchStep{Field: flow.Selector{ID: "searchField"}, Query: "coffee"})
+
+	if !result.Success {
+		t.Fatalf("Expected success, got: %s", result.Message)
+	}
+	want := []string{"tap", "type:coffee", "type:\n"}
+	if strings.Join(events, ",") != strings.Join(want, ",") {
+		t.Errorf("Expected %q, got %q", want, events)
+	}
+	if result.Message != `Searched for "coffee"` {
+		t.Errorf("Unexpected message: %s", result.Message)
+	}
+}
+
+func TestSearchWithoutSubmitSkipsReturn(t *testing.T) {
+	var chunks []string
+	server := typingServer(t, "", &chunks)
+	defer server.Close()
+	driver := createTestDriver(server)
+
+	submit := false
+	result := driver.search(&flow.SearchStep{Query: "tea", Submit: &submit})
+
+	if !result.Success {
+		t.Fatalf("Expected success, got: %s", result.Message)
+	}
+	if strings.Join(chunks, ",") != "tea" {
+		t.Errorf("Expected only the query to be typed, got %q", chunks)
+	}
+}
+
+// =============================================================================
+// assertNotVisible tests
+// =============================================================================
+
+// TestAssertNotVisibleVerifiesErrorMessage tests that assertNotVisible returns
+// a clear error message when the element IS visible.
 func TestAssertNotVisibleVerifiesErrorMessage(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
@@ -995,6 +1750,61 @@ func TestAssertNotVisibleWithIDSelector(t *testing.T) {
 	}
 }
 
+// TestAssertNotVisibleByID mirrors the uiautomator2 test of the same name: an
+// id match fails and an absent id passes.
+func TestAssertNotVisibleByID(t *testing.T) {
+	deleteButton := `<?xml version="1.0" encoding="UTF-8"?><AppiumAUT><XCUIElementTypeApplication type="XCUIElementTypeApplication" name="App" enabled="true" visible="true" x="0" y="0" width="390" height="844">` +
+		`<XCUIElementTypeButton type="XCUIElementTypeButton" name="deleteBtn" label="Delete" enabled="true" visible="true" x="250" y="50" width="80" height="40"/>` +
+		`</XCUIElementTypeApplication></AppiumAUT>`
+	empty := `<?xml version="1.0" encoding="UTF-8"?><AppiumAUT><XCUIElementTypeApplication type="XCUIElementTypeApplication" name="App" enabled="true" visible="true" x="0" y="0" width="390" height="844">` +
+		`</XCUIElementTypeApplication></AppiumAUT>`
+
+	tests := []struct {
+		name    string
+		source  string
+		success bool
+	}{
+		{"present element fails", deleteButton, false},
+		{"absent element passes", empty, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			server := sequencedSourceServer(&calls, tt.source)
+			defer server.Close()
+			driver := createTestDriver(server)
+
+			step := &flow.AssertNotVisibleStep{Selector: flow.Selector{ID: "deleteBtn"}}
+			step.TimeoutMs = 100
+			result := driver.assertNotVisible(step)
+
+			if result.Success != tt.success {
+				t.Fatalf("Expected success=%v, got %v: %s", tt.success, result.Success, result.Message)
+			}
+		})
+	}
+}
+
+// TestAssertNotVisibleFailsWhenElementAppearsDuringWindow tests that a match
+// seen on a later check fails the step.
+func TestAssertNotVisibleFailsWhenElementAppearsDuringWindow(t *testing.T) {
+	calls := 0
+	server := sequencedSourceServer(&calls,
+		`<?xml version="1.0" encoding="UTF-8"?><AppiumAUT></AppiumAUT>`,
+		retrySourceValid)
+	defer server.Close()
+	driver := createTestDriver(server)
+
+	step := &flow.AssertNotVisibleStep{Selector: flow.Selector{Text: "Login"}}
+	step.TimeoutMs = 2000
+	result := driver.assertNotVisible(step)
+
+	if result.Success {
+		t.Fatal("Expected failure when the element shows up inside the confirmation window")
+	}
+}
+
 // =============================================================================
 // iosKeyboardKey tests
 // =============================================================================
@@ -1265,6 +2075,67 @@ func TestPressKeyVolume_Up(t *testing.T) {
 	}
 }
 
+// TestPressKeyLockAndPower tests that lock, unlock and power call the WDA lock endpoints.
+func TestPressKeyLockAndPower(t *testing.T) {
+	tests := []struct {
+		key      string
+		locked   bool
+		wantPath string
+	}{
+		{"lock", false, "/wda/lock"},
+		{"unlock", true, "/wda/unlock"},
+		{"power", false, "/wda/lock"},
+		{"power", true, "/wda/unlock"},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s locked=%v", tt.key, tt.locked), func(t *testing.T) {
+			var posted []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				if strings.HasSuffix(r.URL.Path, "/wda/locked") {
+					jsonResponse(w, map[string]interface{}{"value": tt.locked})
+					return
+				}
+				if r.Method == http.MethodPost {
+					posted = append(posted, r.URL.Path)
+				}
+				jsonResponse(w, map[string]interface{}{"status": 0})
+			}))
+			defer server.Close()
+			driver := createTestDriver(server)
+
+			result := driver.pressKey(&flow.PressKeyStep{Key: tt.key})
+			if !result.Success {
+				t.Fatalf("pressKey(%s) failed: %s", tt.key, result.Message)
+			}
+			if len(posted) != 1 || !strings.HasSuffix(posted[0], tt.wantPath) {
+				t.Errorf("Expected one POST to %s, got %v", tt.wantPath, posted)
+			}
+		})
+	}
+}
+
+// TestPressKeyMuteNotSupported tests that mute/ringer fail with a specific message.
+func TestPressKeyMuteNotSupported(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		jsonResponse(w, map[string]interface{}{"status": 0})
+	}))
+	defer server.Close()
+	driver := createTestDriver(server)
+
+	for _, key := range []string{"mute", "ringer"} {
+		result := driver.pressKey(&flow.PressKeyStep{Key: key})
+		if result.Success {
+			t.Errorf("Expected failure for %s", key)
+		}
+		if !strings.Contains(result.Message, "ring/silent switch") {
+			t.Errorf("Expected ring/silent switch message for %s, got: %s", key, result.Message)
+		}
+	}
+}
+
 // =============================================================================
 // setClipboard / pasteText tests
 // =============================================================================
@@ -1284,18 +2155,20 @@ func TestSetClipboardNotSupported(t *testing.T) {
 	}
 }
 
-// TestPasteTextNotSupported tests that pasteText returns an error.
-func TestPasteTextNotSupported(t *testing.T) {
-	driver := &Driver{
-		client: &Client{},
-		info:   &core.PlatformInfo{Platform: "ios"},
-	}
+// TestPasteTextPasteboardUnavailable tests that pasteText fails when the
+// pasteboard cannot be read.
+func TestPasteTextPasteboardUnavailable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		jsonResponse(w, map[string]interface{}{"status": 0})
+	}))
+	defer server.Close()
+	driver := createTestDriver(server)
 
 	step := &flow.PasteTextStep{}
 	result := driver.pasteText(step)
 
 	if result.Success {
-		t.Error("Expected failure for pasteText on iOS")
+		t.Error("Expected failure when the pasteboard cannot be read")
 	}
 }
 
@@ -1327,21 +2200,35 @@ func TestClearStateNoAppFile(t *testing.T) {
 // back command test
 // =============================================================================
 
-// TestBackNotSupportedMessage tests back command returns proper message.
-func TestBackNotSupportedMessage(t *testing.T) {
-	driver := &Driver{
-		client: &Client{},
-		info:   &core.PlatformInfo{Platform: "ios"},
-	}
+// TestBackSwipeError tests back reports a failed edge swipe.
+func TestBackSwipeError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if strings.HasSuffix(r.URL.Path, "/window/size") {
+			jsonResponse(w, map[string]interface{}{
+				"value": map[string]interface{}{"width": 390.0, "height": 844.0},
+			})
+			return
+		}
+		if strings.HasSuffix(r.URL.Path, "/wda/dragfromtoforduration") {
+			w.WriteHeader(http.StatusInternalServerError)
+			jsonResponse(w, map[string]interface{}{
+				"value": map[string]interface{}{"error": "unknown error", "message": "drag failed"},
+			})
+			return
+		}
+		jsonResponse(w, map[string]interface{}{"status": 0})
+	}))
+	defer server.Close()
+	driver := createTestDriver(server)
 
-	step := &flow.BackStep{}
-	result := driver.back(step)
+	result := driver.back(&flow.BackStep{})
 
 	if result.Success {
-		t.Error("Expected failure for back on iOS")
+		t.Error("Expected failure when the back swipe fails")
 	}
-	if !strings.Contains(result.Message, "back button") {
-		t.Errorf("Expected message about back button, got: %s", result.Message)
+	if !strings.Contains(result.Message, "Back swipe failed") {
+		t.Errorf("Expected back swipe message, got: %s", result.Message)
 	}
 }
 
@@ -1859,8 +2746,13 @@ func TestTapOnPointWithSelectorRelativeTap(t *testing.T) {
 	}
 }
 
-// TestTapOnPointInvalidCoords tests tapOn with invalid point coordinates.
-func TestTapOnPointInvalidCoords(t *testing.T) {
+// TestTapOnPixelOffset tests that offsetX/offsetY tap relative to the element's origin.
+func TestTapSequence(t *testing.T) {
+	type tapAt struct {
+		x, y float64
+		at   time.Time
+	}
+	var taps []tapAt
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		path := r.URL.Path
@@ -1870,111 +2762,317 @@ func TestTapOnPointInvalidCoords(t *testing.T) {
 				"value": `<?xml version="1.0" encoding="UTF-8"?>
 <AppiumAUT>
   <XCUIElementTypeApplication type="XCUIElementTypeApplication" name="TestApp" enabled="true" visible="true" x="0" y="0" width="390" height="844">
-    <XCUIElementTypeButton type="XCUIElementTypeButton" name="btn" label="Btn" enabled="true" visible="true" x="50" y="100" width="200" height="50"/>
+    <XCUIElementTypeButton type="XCUIElementTypeButton" name="dot5" label="Dot 5" enabled="true" visible="true" x="145" y="400" width="100" height="100"/>
   </XCUIElementTypeApplication>
 </AppiumAUT>`,
 			})
 			return
 		}
+		if strings.HasSuffix(path, "/window/size") {
+			jsonResponse(w, map[string]interface{}{
+				"value": map[string]interface{}{"width": 400.0, "height": 800.0},
+			})
+			return
+		}
 		if strings.HasSuffix(path, "/element") && r.Method == "POST" {
 			jsonResponse(w, map[string]interface{}{
 				"value": map[string]interface{}{"error": "not found"},
 			})
 			return
 		}
+		if strings.Contains(path, "/wda/tap") {
+			var payload map[string]float64
+			_ = json.NewDecoder(r.Body).Decode(&payload)
+			taps = append(taps, tapAt{payload["x"], payload["y"], time.Now()})
+		}
 		jsonResponse(w, map[string]interface{}{"status": 0})
 	}))
 	defer server.Close()
 	driver := createTestDriver(server)
 
-	step := &flow.TapOnStep{
+	step := &flow.TapSequenceStep{
 		BaseStep: flow.BaseStep{TimeoutMs: 1000},
-		Selector: flow.Selector{Text: "Btn"},
-		Point:    "invalid-coords",
+		Taps: []flow.Selector{
+			{Point: "25%, 50%"},
+			{Text: "Dot 5"},
+			{Point: "75%, 50%"},
+		},
+		DelayMs: 80,
 	}
-	result := driver.tapOn(step)
+	result := driver.tapSequence(step)
 
-	if result.Success {
-		t.Fatalf("Expected failure for invalid point coordinates, got success")
+	if !result.Success {
+		t.Fatalf("Expected success, got: %s", result.Message)
 	}
-	if !strings.Contains(result.Message, "Invalid point") {
-		t.Errorf("Expected 'Invalid point' in message, got: %s", result.Message)
+	want := [][2]float64{{100, 400}, {195, 450}, {300, 400}}
+	if len(taps) != len(want) {
+		t.Fatalf("Expected %d taps, got %d", len(want), len(taps))
+	}
+	for i, tap := range taps {
+		if tap.x != want[i][0] || tap.y != want[i][1] {
+			t.Errorf("Tap %d at (%.0f, %.0f), want (%.0f, %.0f)", i+1, tap.x, tap.y, want[i][0], want[i][1])
+		}
+		if i > 0 {
+			if gap := tap.at.Sub(taps[i-1].at); gap < 80*time.Millisecond {
+				t.Errorf("Gap before tap %d was %v, want at least 80ms", i+1, gap)
+			}
+		}
 	}
 }
 
-// =============================================================================
-// swipe tests
-// =============================================================================
-
-// TestSwipeStartEndCoordinates tests swipe with percentage-based start/end coordinates.
-func TestSwipeStartEndCoordinates(t *testing.T) {
-	var fromX, fromY, toX, toY float64
+func TestTapSequenceMissingElementTapsNothing(t *testing.T) {
+	tapped := false
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
-		path := r.URL.Path
-
-		if strings.Contains(path, "/window/size") {
+		if strings.Contains(r.URL.Path, "/wda/tap") {
+			tapped = true
+		}
+		if strings.HasSuffix(r.URL.Path, "/window/size") {
 			jsonResponse(w, map[string]interface{}{
-				"value": map[string]interface{}{"width": 390.0, "height": 844.0},
+				"value": map[string]interface{}{"width": 400.0, "height": 800.0},
 			})
 			return
 		}
-		if strings.Contains(path, "/dragfromtoforduration") {
-			body, readErr := io.ReadAll(r.Body)
-			if readErr != nil {
-				t.Fatalf("Failed to read body: %v", readErr)
-			}
-			var payload map[string]interface{}
-			if err := json.Unmarshal(body, &payload); err != nil {
-				t.Fatalf("Failed to unmarshal body: %v", err)
-			}
-			fromX, _ = payload["fromX"].(float64)
-			fromY, _ = payload["fromY"].(float64)
-			toX, _ = payload["toX"].(float64)
-			toY, _ = payload["toY"].(float64)
-			jsonResponse(w, map[string]interface{}{"status": 0})
-			return
-		}
 		jsonResponse(w, map[string]interface{}{"status": 0})
 	}))
 	defer server.Close()
 	driver := createTestDriver(server)
 
-	step := &flow.SwipeStep{
-		Start: "50%, 80%",
-		End:   "50%, 20%",
+	step := &flow.TapSequenceStep{
+		BaseStep: flow.BaseStep{TimeoutMs: 300},
+		Taps:     []flow.Selector{{Point: "10%, 10%"}, {Text: "Missing"}},
 	}
-	result := driver.swipe(step)
+	result := driver.tapSequence(step)
 
-	if !result.Success {
-		t.Fatalf("Expected success, got: %s", result.Message)
-	}
-	// fromX = 390 * 0.50 = 195
-	if fromX < 194 || fromX > 196 {
-		t.Errorf("Expected fromX near 195, got: %.0f", fromX)
-	}
-	// fromY = 844 * 0.80 = 675.2
-	if fromY < 674 || fromY > 676 {
-		t.Errorf("Expected fromY near 675, got: %.0f", fromY)
-	}
-	// toY = 844 * 0.20 = 168.8
-	if toY < 167 || toY > 170 {
-		t.Errorf("Expected toY near 169, got: %.0f", toY)
+	if result.Success {
+		t.Error("Expected failure when a target cannot be resolved")
 	}
-	// toX = 390 * 0.50 = 195
-	if toX < 194 || toX > 196 {
-		t.Errorf("Expected toX near 195, got: %.0f", toX)
+	if tapped {
+		t.Error("Expected no taps before every target resolves")
 	}
 }
 
-// TestSwipeDirectPixelCoordinates tests swipe with direct pixel coordinates.
-func TestSwipeDirectPixelCoordinates(t *testing.T) {
-	var fromX, fromY, toX, toY float64
+func TestTapOnPixelOffset(t *testing.T) {
+	var tapX, tapY float64
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		path := r.URL.Path
 
-		if strings.Contains(path, "/window/size") {
+		if strings.HasSuffix(path, "/source") {
+			jsonResponse(w, map[string]interface{}{
+				"value": `<?xml version="1.0" encoding="UTF-8"?>
+<AppiumAUT>
+  <XCUIElementTypeApplication type="XCUIElementTypeApplication" name="TestApp" enabled="true" visible="true" x="0" y="0" width="390" height="844">
+    <XCUIElementTypeOther type="XCUIElementTypeOther" name="promo" label="Promo" enabled="true" visible="true" x="20" y="300" width="350" height="120"/>
+  </XCUIElementTypeApplication>
+</AppiumAUT>`,
+			})
+			return
+		}
+		if strings.HasSuffix(path, "/element") && r.Method == "POST" {
+			jsonResponse(w, map[string]interface{}{
+				"value": map[string]interface{}{"error": "not found"},
+			})
+			return
+		}
+		if strings.Contains(path, "/wda/tap") {
+			var payload map[string]interface{}
+			_ = json.NewDecoder(r.Body).Decode(&payload)
+			tapX, _ = payload["x"].(float64)
+			tapY, _ = payload["y"].(float64)
+		}
+		jsonResponse(w, map[string]interface{}{"status": 0})
+	}))
+	defer server.Close()
+	driver := createTestDriver(server)
+
+	offsetX, offsetY := 330, 15
+	step := &flow.TapOnStep{
+		BaseStep: flow.BaseStep{TimeoutMs: 1000},
+		Selector: flow.Selector{Text: "Promo"},
+		OffsetX:  &offsetX,
+		OffsetY:  &offsetY,
+	}
+	result := driver.tapOn(step)
+
+	if !result.Success {
+		t.Fatalf("Expected success, got: %s", result.Message)
+	}
+	// Element origin (20,300) + offset (330,15)
+	if tapX != 350 || tapY != 315 {
+		t.Errorf("Expected tap at (350, 315), got (%.0f, %.0f)", tapX, tapY)
+	}
+}
+
+func TestTapOnTapParent(t *testing.T) {
+	var tapX, tapY float64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		path := r.URL.Path
+
+		if strings.HasSuffix(path, "/source") {
+			jsonResponse(w, map[string]interface{}{
+				"value": `<?xml version="1.0" encoding="UTF-8"?>
+<AppiumAUT>
+  <XCUIElementTypeApplication type="XCUIElementTypeApplication" name="TestApp" enabled="true" visible="true" x="0" y="0" width="390" height="844">
+    <XCUIElementTypeCell type="XCUIElementTypeCell" name="invoice_row" enabled="true" visible="true" hittable="true" x="0" y="200" width="390" height="80">
+      <XCUIElementTypeButton type="XCUIElementTypeButton" name="invoice_icon" enabled="true" visible="true" hittable="false" x="16" y="216" width="48" height="48"/>
+      <XCUIElementTypeStaticText type="XCUIElementTypeStaticText" name="Invoice.pdf" label="Invoice.pdf" enabled="true" visible="true" x="80" y="220" width="200" height="40"/>
+    </XCUIElementTypeCell>
+  </XCUIElementTypeApplication>
+</AppiumAUT>`,
+			})
+			return
+		}
+		if strings.HasSuffix(path, "/element") && r.Method == "POST" {
+			jsonResponse(w, map[string]interface{}{
+				"value": map[string]interface{}{"error": "not found"},
+			})
+			return
+		}
+		if strings.Contains(path, "/wda/tap") {
+			var payload map[string]interface{}
+			_ = json.NewDecoder(r.Body).Decode(&payload)
+			tapX, _ = payload["x"].(float64)
+			tapY, _ = payload["y"].(float64)
+		}
+		jsonResponse(w, map[string]interface{}{"status": 0})
+	}))
+	defer server.Close()
+	driver := createTestDriver(server)
+
+	step := &flow.TapOnStep{
+		BaseStep:  flow.BaseStep{TimeoutMs: 1000},
+		Selector:  flow.Selector{ID: "invoice_icon"},
+		TapParent: true,
+	}
+	result := driver.tapOn(step)
+
+	if !result.Success {
+		t.Fatalf("Expected success, got: %s", result.Message)
+	}
+	// The button isn't hittable, so the tap goes to the center of its cell
+	if tapX != 195 || tapY != 240 {
+		t.Errorf("Expected tap at (195, 240), got (%.0f, %.0f)", tapX, tapY)
+	}
+}
+
+// TestTapOnPointInvalidCoords tests tapOn with invalid point coordinates.
+func TestTapOnPointInvalidCoords(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		path := r.URL.Path
+
+		if strings.HasSuffix(path, "/source") {
+			jsonResponse(w, map[string]interface{}{
+				"value": `<?xml version="1.0" encoding="UTF-8"?>
+<AppiumAUT>
+  <XCUIElementTypeApplication type="XCUIElementTypeApplication" name="TestApp" enabled="true" visible="true" x="0" y="0" width="390" height="844">
+    <XCUIElementTypeButton type="XCUIElementTypeButton" name="btn" label="Btn" enabled="true" visible="true" x="50" y="100" width="200" height="50"/>
+  </XCUIElementTypeApplication>
+</AppiumAUT>`,
+			})
+			return
+		}
+		if strings.HasSuffix(path, "/element") && r.Method == "POST" {
+			jsonResponse(w, map[string]interface{}{
+				"value": map[string]interface{}{"error": "not found"},
+			})
+			return
+		}
+		jsonResponse(w, map[string]interface{}{"status": 0})
+	}))
+	defer server.Close()
+	driver := createTestDriver(server)
+
+	step := &flow.TapOnStep{
+		BaseStep: flow.BaseStep{TimeoutMs: 1000},
+		Selector: flow.Selector{Text: "Btn"},
+		Point:    "invalid-coords",
+	}
+	result := driver.tapOn(step)
+
+	if result.Success {
+		t.Fatalf("Expected failure for invalid point coordinates, got success")
+	}
+	if !strings.Contains(result.Message, "Invalid point") {
+		t.Errorf("Expected 'Invalid point' in message, got: %s", result.Message)
+	}
+}
+
+// =============================================================================
+// swipe tests
+// =============================================================================
+
+// TestSwipeStartEndCoordinates tests swipe with percentage-based start/end coordinates.
+func TestSwipeStartEndCoordinates(t *testing.T) {
+	var fromX, fromY, toX, toY float64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		path := r.URL.Path
+
+		if strings.Contains(path, "/window/size") {
+			jsonResponse(w, map[string]interface{}{
+				"value": map[string]interface{}{"width": 390.0, "height": 844.0},
+			})
+			return
+		}
+		if strings.Contains(path, "/dragfromtoforduration") {
+			body, readErr := io.ReadAll(r.Body)
+			if readErr != nil {
+				t.Fatalf("Failed to read body: %v", readErr)
+			}
+			var payload map[string]interface{}
+			if err := json.Unmarshal(body, &payload); err != nil {
+				t.Fatalf("Failed to unmarshal body: %v", err)
+			}
+			fromX, _ = payload["fromX"].(float64)
+			fromY, _ = payload["fromY"].(float64)
+			toX, _ = payload["toX"].(float64)
+			toY, _ = payload["toY"].(float64)
+			jsonResponse(w, map[string]interface{}{"status": 0})
+			return
+		}
+		jsonResponse(w, map[string]interface{}{"status": 0})
+	}))
+	defer server.Close()
+	driver := createTestDriver(server)
+
+	step := &flow.SwipeStep{
+		Start: "50%, 80%",
+		End:   "50%, 20%",
+	}
+	result := driver.swipe(step)
+
+	if !result.Success {
+		t.Fatalf("Expected success, got: %s", result.Message)
+	}
+	// fromX = 390 * 0.50 = 195
+	if fromX < 194 || fromX > 196 {
+		t.Errorf("Expected fromX near 195, got: %.0f", fromX)
+	}
+	// fromY = 844 * 0.80 = 675.2
+	if fromY < 674 || fromY > 676 {
+		t.Errorf("Expected fromY near 675, got: %.0f", fromY)
+	}
+	// toY = 844 * 0.20 = 168.8
+	if toY < 167 || toY > 170 {
+		t.Errorf("Expected toY near 169, got: %.0f", toY)
+	}
+	// toX = 390 * 0.50 = 195
+	if toX < 194 || toX > 196 {
+		t.Errorf("Expected toX near 195, got: %.0f", toX)
+	}
+}
+
+// TestSwipeDirectPixelCoordinates tests swipe with direct pixel coordinates.
+func TestSwipeDirectPixelCoordinates(t *testing.T) {
+	var fromX, fromY, toX, toY float64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		path := r.URL.Path
+
+		if strings.Contains(path, "/window/size") {
 			jsonResponse(w, map[string]interface{}{
 				"value": map[string]interface{}{"width": 390.0, "height": 844.0},
 			})
@@ -2449,6 +3547,116 @@ func TestInputTextNonASCII(t *testing.T) {
 	}
 }
 
+// emojiInputServer serves a focused text field and an edit menu with a Paste
+// item. It records the pasteboard content, taps and typed keys.
+type emojiInputServer struct {
+	pasteboard    string
+	pasteboardErr bool
+	taps          [][2]float64
+	keysTyped     bool
+}
+
+func (e *emojiInputServer) handler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	path := r.URL.Path
+
+	switch {
+	case strings.HasSuffix(path, "/wda/setPasteboard"):
+		if e.pasteboardErr {
+			w.WriteHeader(http.StatusInternalServerError)
+			jsonResponse(w, map[string]interface{}{
+				"value": map[string]interface{}{"error": "unknown command", "message": "pasteboard unavailable"},
+			})
+			return
+		}
+		var payload map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		content, _ := payload["content"].(string)
+		decoded, _ := base64.StdEncoding.DecodeString(content)
+		e.pasteboard = string(decoded)
+	case strings.HasSuffix(path, "/element/active"):
+		jsonResponse(w, map[string]interface{}{
+			"value": map[string]interface{}{"ELEMENT": "active-elem"},
+		})
+		return
+	case strings.HasSuffix(path, "/element/active-elem/rect"):
+		jsonResponse(w, map[string]interface{}{
+			"value": map[string]interface{}{"x": 20.0, "y": 200.0, "width": 350.0, "height": 44.0},
+		})
+		return
+	case strings.HasSuffix(path, "/source"):
+		jsonResponse(w, map[string]interface{}{
+			"value": `<?xml version="1.0" encoding="UTF-8"?>
+<AppiumAUT>
+  <XCUIElementTypeApplication type="XCUIElementTypeApplication" name="TestApp" enabled="true" visible="true" x="0" y="0" width="390" height="844">
+    <XCUIElementTypeTextField type="XCUIElementTypeTextField" name="message" enabled="true" visible="true" x="20" y="200" width="350" height="44"/>
+    <XCUIElementTypeMenuItem type="XCUIElementTypeMenuItem" name="Paste" label="Paste" enabled="true" visible="true" x="150" y="150" width="60" height="36"/>
+  </XCUIElementTypeApplication>
+</AppiumAUT>`,
+		})
+		return
+	case strings.HasSuffix(path, "/element") && r.Method == "POST":
+		jsonResponse(w, map[string]interface{}{
+			"value": map[string]interface{}{"error": "not found"},
+		})
+		return
+	case strings.HasSuffix(path, "/wda/tap"):
+		var payload map[string]float64
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		e.taps = append(e.taps, [2]float64{payload["x"], payload["y"]})
+	case strings.HasSuffix(path, "/wda/keys"):
+		e.keysTyped = true
+	}
+	jsonResponse(w, map[string]interface{}{"status": 0})
+}
+
+func TestInputTextEmojiUsesPasteboard(t *testing.T) {
+	emojiServer := &emojiInputServer{}
+	server := httptest.NewServer(http.HandlerFunc(emojiServer.handler))
+	defer server.Close()
+	driver := createTestDriver(server)
+
+	result := driver.inputText(&flow.InputTextStep{Text: "Hi 🎉"})
+
+	if !result.Success {
+		t.Fatalf("Expected success, got: %s", result.Message)
+	}
+	if emojiServer.pasteboard != "Hi 🎉" {
+		t.Errorf("Expected text on pasteboard, got %q", emojiServer.pasteboard)
+	}
+	if len(emojiServer.taps) != 1 || emojiServer.taps[0] != [2]float64{180, 168} {
+		t.Errorf("Expected one tap on the Paste item at (180, 168), got %v", emojiServer.taps)
+	}
+	if emojiServer.keysTyped {
+		t.Error("Expected no key input when pasting")
+	}
+	if !strings.Contains(result.Message, "pasteboard") {
+		t.Errorf("Expected pasteboard path in message, got: %s", result.Message)
+	}
+	if !strings.Contains(result.Message, "non-ASCII characters may not input correctly") {
+		t.Errorf("Expected non-ASCII warning preserved, got: %s", result.Message)
+	}
+}
+
+func TestInputTextEmojiPasteboardFallback(t *testing.T) {
+	emojiServer := &emojiInputServer{pasteboardErr: true}
+	server := httptest.NewServer(http.HandlerFunc(emojiServer.handler))
+	defer server.Close()
+	driver := createTestDriver(server)
+
+	result := driver.inputText(&flow.InputTextStep{Text: "Hi 🎉"})
+
+	if !result.Success {
+		t.Fatalf("Expected fallback to succeed, got: %s", result.Message)
+	}
+	if !emojiServer.keysTyped {
+		t.Error("Expected fallback to type with SendKeys")
+	}
+	if !strings.Contains(result.Message, "pasteboard paste failed") {
+		t.Errorf("Expected fallback warning in message, got: %s", result.Message)
+	}
+}
+
 // =============================================================================
 // inputRandom additional tests
 // =============================================================================
@@ -2651,27 +3859,139 @@ func TestOpenLinkEmpty(t *testing.T) {
 // waitForAnimationToEnd test
 // =============================================================================
 
-// TestWaitForAnimationToEndReturnsWarning tests that waitForAnimationToEnd returns success with WARNING.
-func TestWaitForAnimationToEndReturnsWarning(t *testing.T) {
-	driver := &Driver{
-		client: &Client{},
-		info:   &core.PlatformInfo{Platform: "ios"},
+// animationFrame encodes a small solid PNG; different shades are different frames.
+func animationFrame(t *testing.T, shade uint8) string {
+	t.Helper()
+	img := image.NewGray(image.Rect(0, 0, 8, 8))
+	for i := range img.Pix {
+		img.Pix[i] = shade
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
 	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes())
+}
+
+// frameServer serves next() as the /screenshot response.
+func frameServer(next func() string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/screenshot") {
+			jsonResponse(w, map[string]interface{}{"value": next()})
+			return
+		}
+		jsonResponse(w, map[string]interface{}{"status": 0})
+	}))
+}
+
+// TestWaitForAnimationToEndSettles tests that polling stops once two
+// consecutive screenshots match.
+func TestWaitForAnimationToEndSettles(t *testing.T) {
+	frames := []string{animationFrame(t, 0), animationFrame(t, 90), animationFrame(t, 180), animationFrame(t, 180)}
+	calls := 0
+	server := frameServer(func() string {
+		frame := frames[min(calls, len(frames)-1)]
+		calls++
+		return frame
+	})
+	defer server.Close()
+	driver := createTestDriver(server)
 
-	step := &flow.WaitForAnimationToEndStep{}
-	result := driver.waitForAnimationToEnd(step)
+	result := driver.waitForAnimationToEnd(&flow.WaitForAnimationToEndStep{})
 
 	if !result.Success {
 		t.Fatalf("Expected success, got: %s", result.Message)
 	}
-	if !strings.Contains(result.Message, "WARNING") {
-		t.Errorf("Expected 'WARNING' in message, got: %s", result.Message)
+	if calls != 4 {
+		t.Errorf("Expected 4 screenshots, got %d", calls)
+	}
+	if !strings.Contains(result.Message, "settled after comparing 4 frames") {
+		t.Errorf("Unexpected message: %s", result.Message)
 	}
 }
 
-// =============================================================================
-// takeScreenshot tests
-// =============================================================================
+// TestTapOnWaitToSettle tests that tapOn waits for two matching screenshots
+// before finding and tapping the element.
+func TestTapOnWaitToSettle(t *testing.T) {
+	frames := []string{animationFrame(t, 0), animationFrame(t, 90), animationFrame(t, 90)}
+	var shots, clicks int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := r.URL.Path
+		switch {
+		case strings.HasSuffix(path, "/screenshot"):
+			if clicks > 0 {
+				t.Error("screenshot taken after the tap")
+			}
+			jsonResponse(w, map[string]interface{}{"value": frames[min(shots, len(frames)-1)]})
+			shots++
+			return
+		case strings.HasSuffix(path, "/element") && r.Method == "POST":
+			jsonResponse(w, map[string]interface{}{"value": map[string]interface{}{"ELEMENT": "btn-1"}})
+			return
+		case strings.HasSuffix(path, "/rect"):
+			jsonResponse(w, map[string]interface{}{
+				"value": map[string]interface{}{"x": 100.0, "y": 200.0, "width": 100.0, "height": 50.0},
+			})
+			return
+		case strings.HasSuffix(path, "/click"):
+			clicks++
+		}
+		jsonResponse(w, map[string]interface{}{"value": nil})
+	}))
+	defer server.Close()
+	driver := createTestDriver(server)
+
+	result := driver.Execute(&flow.TapOnStep{Selector: flow.Selector{ID: "login"}, WaitToSettleTimeoutMs: 2000})
+
+	if !result.Success {
+		t.Fatalf("Expected success, got: %s", result.Message)
+	}
+	if shots != 3 {
+		t.Errorf("Expected 3 screenshots before the tap, got %d", shots)
+	}
+	if clicks != 1 {
+		t.Errorf("Expected 1 tap, got %d", clicks)
+	}
+}
+
+// TestWaitForAnimationToEndTimeout tests that a screen that never settles
+// still passes by default and fails with continueOnTimeout: false.
+func TestWaitForAnimationToEndTimeout(t *testing.T) {
+	stop := false
+	tests := []struct {
+		name   string
+		step   *flow.WaitForAnimationToEndStep
+		wantOK bool
+	}{
+		{"continues by default", &flow.WaitForAnimationToEndStep{BaseStep: flow.BaseStep{TimeoutMs: 500}}, true},
+		{"fails when not continuing", &flow.WaitForAnimationToEndStep{BaseStep: flow.BaseStep{TimeoutMs: 500}, ContinueOnTimeout: &stop}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shade := uint8(0)
+			server := frameServer(func() string {
+				shade += 40
+				return animationFrame(t, shade)
+			})
+			defer server.Close()
+			driver := createTestDriver(server)
+
+			result := driver.waitForAnimationToEnd(tt.step)
+
+			if result.Success != tt.wantOK {
+				t.Fatalf("Success = %v, want %v (%s)", result.Success, tt.wantOK, result.Message)
+			}
+			if !strings.Contains(result.Message, "Animation still running after 500ms") {
+				t.Errorf("Unexpected message: %s", result.Message)
+			}
+		})
+	}
+}
+
+// =============================================================================
+// takeScreenshot tests
+// =============================================================================
 
 // TestTakeScreenshotClientError tests takeScreenshot when the client returns an error.
 func TestTakeScreenshotClientError(t *testing.T) {
@@ -2873,6 +4193,33 @@ func TestStopAppTerminatesApp(t *testing.T) {
 	}
 }
 
+// TestStopAppGracefulPressesHomeFirst tests graceful stopApp backgrounds the app before terminating it.
+func TestStopAppGracefulPressesHomeFirst(t *testing.T) {
+	var calls []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.Contains(r.URL.Path, "/wda/pressButton"):
+			calls = append(calls, "home")
+		case strings.Contains(r.URL.Path, "/wda/apps/terminate"):
+			calls = append(calls, "terminate")
+		}
+		jsonResponse(w, map[string]interface{}{"status": 0})
+	}))
+	defer server.Close()
+	driver := createTestDriver(server)
+
+	step := &flow.StopAppStep{AppID: "com.test.app", Graceful: true}
+	result := driver.stopApp(step)
+
+	if !result.Success {
+		t.Fatalf("Expected success, got: %s", result.Message)
+	}
+	if len(calls) != 2 || calls[0] != "home" || calls[1] != "terminate" {
+		t.Errorf("Expected home then terminate, got %v", calls)
+	}
+}
+
 // TestKillAppTerminatesApp tests killApp with valid bundleID calls TerminateApp.
 func TestKillAppTerminatesApp(t *testing.T) {
 	var terminated bool
@@ -3054,60 +4401,6 @@ func TestSelectorDescTableDriven(t *testing.T) {
 	}
 }
 
-// =============================================================================
-// randomString / randomEmail / randomNumber / randomPersonName tests
-// =============================================================================
-
-// TestRandomStringLength tests that randomString returns the correct length.
-func TestRandomStringLength(t *testing.T) {
-	for _, length := range []int{0, 1, 5, 20, 100} {
-		result := randomString(length)
-		if len(result) != length {
-			t.Errorf("randomString(%d) returned length %d", length, len(result))
-		}
-	}
-}
-
-// TestRandomEmailFormat tests that randomEmail returns a valid email format.
-func TestRandomEmailFormat(t *testing.T) {
-	email := randomEmail()
-	if !strings.Contains(email, "@") {
-		t.Errorf("Expected email with '@', got: %s", email)
-	}
-	parts := strings.Split(email, "@")
-	if len(parts) != 2 {
-		t.Errorf("Expected exactly one '@' in email, got: %s", email)
-	}
-	if len(parts[0]) == 0 || len(parts[1]) == 0 {
-		t.Errorf("Expected non-empty user and domain, got: %s", email)
-	}
-}
-
-// TestRandomNumberDigitsOnly tests that randomNumber returns only digits.
-func TestRandomNumberDigitsOnly(t *testing.T) {
-	result := randomNumber(10)
-	if len(result) != 10 {
-		t.Errorf("Expected length 10, got %d", len(result))
-	}
-	for _, c := range result {
-		if c < '0' || c > '9' {
-			t.Errorf("Expected only digits, found '%c' in %s", c, result)
-		}
-	}
-}
-
-// TestRandomPersonNameHasSpace tests that randomPersonName returns first and last name.
-func TestRandomPersonNameHasSpace(t *testing.T) {
-	name := randomPersonName()
-	if !strings.Contains(name, " ") {
-		t.Errorf("Expected name with space, got: %s", name)
-	}
-	parts := strings.Split(name, " ")
-	if len(parts) < 2 {
-		t.Errorf("Expected at least first and last name, got: %s", name)
-	}
-}
-
 // =============================================================================
 // resolveAlertAction tests
 // =============================================================================
@@ -3576,3 +4869,1142 @@ func TestWaitForAlertPollingBehavior(t *testing.T) {
 		t.Errorf("Expected at least 3 calls (polling), got: %d", callCount)
 	}
 }
+
+// tapModeServer records which tap endpoint was hit and the coordinates it received.
+func tapModeServer(t *testing.T, endpoint *string, x, y *float64) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := r.URL.Path
+		switch {
+		case strings.HasSuffix(path, "/wda/tap"):
+			var payload map[string]float64
+			if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+				t.Fatalf("Failed to decode tap body: %v", err)
+			}
+			*endpoint, *x, *y = "/wda/tap", payload["x"], payload["y"]
+		case strings.HasSuffix(path, "/actions"):
+			var payload struct {
+				Actions []struct {
+					Type    string                   `json:"type"`
+					Actions []map[string]interface{} `json:"actions"`
+				} `json:"actions"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+				t.Fatalf("Failed to decode actions body: %v", err)
+			}
+			*endpoint = "/actions"
+			for _, a := range payload.Actions[0].Actions {
+				if a["type"] == "pointerMove" {
+					*x, _ = a["x"].(float64)
+					*y, _ = a["y"].(float64)
+				}
+			}
+		case strings.HasSuffix(path, "/element") && r.Method == "POST":
+			jsonResponse(w, map[string]interface{}{
+				"value": map[string]interface{}{"ELEMENT": "btn-1"},
+			})
+			return
+		case strings.HasSuffix(path, "/rect"):
+			jsonResponse(w, map[string]interface{}{
+				"value": map[string]interface{}{"x": 100.0, "y": 200.0, "width": 100.0, "height": 50.0},
+			})
+			return
+		case strings.HasSuffix(path, "/displayed"):
+			jsonResponse(w, map[string]interface{}{"value": true})
+			return
+		case strings.HasSuffix(path, "/click"):
+			*endpoint = "/element/click"
+		}
+		jsonResponse(w, map[string]interface{}{"value": nil})
+	}))
+}
+
+func TestTapOnPointTapModes(t *testing.T) {
+	tests := []struct {
+		mode     string
+		endpoint string
+	}{
+		{"", "/wda/tap"},
+		{TapModeWDA, "/wda/tap"},
+		{TapModeActions, "/actions"},
+	}
+
+	for _, tt := range tests {
+		var endpoint string
+		var x, y float64
+		server := tapModeServer(t, &endpoint, &x, &y)
+		driver := createTestDriver(server)
+		if err := driver.SetTapMode(tt.mode); err != nil {
+			t.Fatalf("SetTapMode(%q): %v", tt.mode, err)
+		}
+
+		result := driver.Execute(&flow.TapOnPointStep{X: 120, Y: 340})
+		server.Close()
+
+		if !result.Success {
+			t.Fatalf("mode %q: expected success, got: %s", tt.mode, result.Message)
+		}
+		if endpoint != tt.endpoint {
+			t.Errorf("mode %q: expected %s, got %q", tt.mode, tt.endpoint, endpoint)
+		}
+		if x != 120 || y != 340 {
+			t.Errorf("mode %q: expected (120, 340), got (%.0f, %.0f)", tt.mode, x, y)
+		}
+	}
+}
+
+func TestTapOnActionsModeTapsElementCenter(t *testing.T) {
+	var endpoint string
+	var x, y float64
+	server := tapModeServer(t, &endpoint, &x, &y)
+	defer server.Close()
+	driver := createTestDriver(server)
+	if err := driver.SetTapMode(TapModeActions); err != nil {
+		t.Fatalf("SetTapMode: %v", err)
+	}
+
+	result := driver.Execute(&flow.TapOnStep{Selector: flow.Selector{ID: "login"}})
+
+	if !result.Success {
+		t.Fatalf("expected success, got: %s", result.Message)
+	}
+	if endpoint != "/actions" {
+		t.Errorf("expected tap via /actions, got %q", endpoint)
+	}
+	if x != 150 || y != 225 {
+		t.Errorf("expected element center (150, 225), got (%.0f, %.0f)", x, y)
+	}
+}
+
+func TestTapOnRetryTapIfNoChange(t *testing.T) {
+	on := true
+	tests := []struct {
+		name       string
+		maxTaps    int
+		changes    bool
+		wantTaps   int
+		wantSuffix string
+	}{
+		{"unchanged retries up to default", 0, false, 2, "(2 of max 2 taps)"},
+		{"unchanged retries up to maxTaps", 3, false, 3, "(3 of max 3 taps)"},
+		{"changed stops after first tap", 3, true, 1, "(1 of max 3 taps)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clicks := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				path := r.URL.Path
+				switch {
+				case strings.HasSuffix(path, "/source"):
+					source := "<AppiumAUT/>"
+					if tt.changes && clicks > 0 {
+						source = "<AppiumAUT><XCUIElementTypeOther/></AppiumAUT>"
+					}
+					jsonResponse(w, map[string]interface{}{"value": source})
+					return
+				case strings.HasSuffix(path, "/element") && r.Method == "POST":
+					jsonResponse(w, map[string]interface{}{
+						"value": map[string]interface{}{"ELEMENT": "btn-1"},
+					})
+					return
+				case strings.HasSuffix(path, "/rect"):
+					jsonResponse(w, map[string]interface{}{
+						"value": map[string]interface{}{"x": 100.0, "y": 200.0, "width": 100.0, "height": 50.0},
+					})
+					return
+				case strings.HasSuffix(path, "/click"):
+					clicks++
+				}
+				jsonResponse(w, map[string]interface{}{"value": nil})
+			}))
+			defer server.Close()
+			driver := createTestDriver(server)
+
+			result := driver.Execute(&flow.TapOnStep{
+				Selector:           flow.Selector{ID: "login"},
+				RetryTapIfNoChange: &on,
+				MaxTaps:            tt.maxTaps,
+			})
+			if !result.Success {
+				t.Fatalf("expected success, got: %s", result.Message)
+			}
+			if clicks != tt.wantTaps {
+				t.Errorf("expected %d taps, got %d", tt.wantTaps, clicks)
+			}
+			if !strings.Contains(result.Message, tt.wantSuffix) {
+				t.Errorf("expected %q in message, got: %s", tt.wantSuffix, result.Message)
+			}
+		})
+	}
+}
+
+func TestSetTapModeInvalid(t *testing.T) {
+	driver := &Driver{}
+	if err := driver.SetTapMode("xctest"); err == nil {
+		t.Error("expected error for unknown tap mode")
+	}
+}
+
+// alertTextServer serves text from GET /alert/text, or "no such alert" when text is empty.
+func alertTextServer(text string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/alert/text") && r.Method == "GET" {
+			if text == "" {
+				jsonResponse(w, map[string]interface{}{
+					"value": map[string]interface{}{
+						"error":   "no such alert",
+						"message": "An attempt was made to operate on a modal dialog when one was not open",
+					},
+				})
+				return
+			}
+			jsonResponse(w, map[string]interface{}{"value": text})
+			return
+		}
+		jsonResponse(w, map[string]interface{}{"status": 0})
+	}))
+}
+
+func TestAssertAlertTextMatch(t *testing.T) {
+	server := alertTextServer("\"MyApp\" Would Like to Send You Notifications")
+	defer server.Close()
+	driver := createTestDriver(server)
+
+	result := driver.Execute(&flow.AssertAlertTextStep{Contains: "Send You Notifications"})
+	if !result.Success {
+		t.Errorf("Expected contains match, got: %s", result.Message)
+	}
+
+	result = driver.Execute(&flow.AssertAlertTextStep{Text: "\"MyApp\" Would Like to Send You Notifications"})
+	if !result.Success {
+		t.Errorf("Expected exact match, got: %s", result.Message)
+	}
+}
+
+func TestAssertAlertTextMismatch(t *testing.T) {
+	server := alertTextServer("Allow location access?")
+	defer server.Close()
+	driver := createTestDriver(server)
+
+	result := driver.Execute(&flow.AssertAlertTextStep{Text: "Allow location"})
+	if result.Success {
+		t.Error("Expected failure for partial text with exact match")
+	}
+	if !strings.Contains(result.Message, "Allow location access?") {
+		t.Errorf("Expected observed text in message, got: %s", result.Message)
+	}
+
+	result = driver.Execute(&flow.AssertAlertTextStep{Contains: "camera"})
+	if result.Success {
+		t.Error("Expected failure when text does not contain substring")
+	}
+}
+
+func TestAssertAlertTextNoAlertTimeout(t *testing.T) {
+	server := alertTextServer("")
+	defer server.Close()
+	driver := createTestDriver(server)
+
+	step := &flow.AssertAlertTextStep{BaseStep: flow.BaseStep{TimeoutMs: 600}, Text: "Allow?"}
+	result := driver.Execute(step)
+
+	if result.Success {
+		t.Fatal("Expected failure when no alert appears")
+	}
+	if !strings.Contains(result.Message, "No alert appeared") {
+		t.Errorf("Expected 'No alert appeared' in message, got: %s", result.Message)
+	}
+}
+
+func TestAssertAlertTextRequiresExpectation(t *testing.T) {
+	driver := &Driver{}
+	if result := driver.assertAlertText(&flow.AssertAlertTextStep{}); result.Success {
+		t.Error("Expected failure without text or contains")
+	}
+}
+
+// =============================================================================
+// ensureUnlocked tests
+// =============================================================================
+
+// lockServer reports successive /wda/locked states and records unlock and keys calls.
+func lockServer(t *testing.T, states []bool, calls *[]string) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/wda/locked"):
+			locked := states[0]
+			if len(states) > 1 {
+				states = states[1:]
+			}
+			jsonResponse(w, map[string]interface{}{"value": locked})
+			return
+		case strings.HasSuffix(r.URL.Path, "/wda/unlock"):
+			*calls = append(*calls, "unlock")
+		case strings.HasSuffix(r.URL.Path, "/wda/keys"):
+			*calls = append(*calls, "keys")
+		}
+		jsonResponse(w, map[string]interface{}{"status": 0})
+	}))
+}
+
+func TestEnsureUnlockedAlreadyUnlocked(t *testing.T) {
+	var calls []string
+	server := lockServer(t, []bool{false}, &calls)
+	defer server.Close()
+	driver := createTestDriver(server)
+
+	result := driver.ensureUnlocked(&flow.EnsureUnlockedStep{})
+
+	if !result.Success {
+		t.Fatalf("Expected success, got: %s", result.Message)
+	}
+	if len(calls) != 0 {
+		t.Errorf("Expected no unlock calls, got %v", calls)
+	}
+}
+
+func TestEnsureUnlockedUnlocks(t *testing.T) {
+	var calls []string
+	server := lockServer(t, []bool{true, false}, &calls)
+	defer server.Close()
+	driver := createTestDriver(server)
+
+	result := driver.ensureUnlocked(&flow.EnsureUnlockedStep{})
+
+	if !result.Success {
+		t.Fatalf("Expected success, got: %s", result.Message)
+	}
+	if strings.Join(calls, ",") != "unlock" {
+		t.Errorf("Expected a single unlock call, got %v", calls)
+	}
+}
+
+func TestEnsureUnlockedPasscodeRequired(t *testing.T) {
+	var calls []string
+	server := lockServer(t, []bool{true}, &calls)
+	defer server.Close()
+	driver := createTestDriver(server)
+
+	result := driver.ensureUnlocked(&flow.EnsureUnlockedStep{})
+
+	if result.Success {
+		t.Fatal("Expected failure when passcode is required but not configured")
+	}
+	if !strings.Contains(result.Message, "passcode") {
+		t.Errorf("Expected passcode hint, got: %s", result.Message)
+	}
+}
+
+func TestEnsureUnlockedTypesPasscode(t *testing.T) {
+	var calls []string
+	server := lockServer(t, []bool{true, true, false}, &calls)
+	defer server.Close()
+	driver := createTestDriver(server)
+
+	result := driver.ensureUnlocked(&flow.EnsureUnlockedStep{Passcode: "1234"})
+
+	if !result.Success {
+		t.Fatalf("Expected success, got: %s", result.Message)
+	}
+	if strings.Join(calls, ",") != "unlock,keys" {
+		t.Errorf("Expected unlock then keys, got %v", calls)
+	}
+}
+
+func TestSimctlAppearanceArgs(t *testing.T) {
+	tests := []struct {
+		dark bool
+		want string
+	}{
+		{true, "simctl ui SIM-UDID appearance dark"},
+		{false, "simctl ui SIM-UDID appearance light"},
+	}
+
+	for _, tt := range tests {
+		if got := strings.Join(simctlAppearanceArgs("SIM-UDID", tt.dark), " "); got != tt.want {
+			t.Errorf("simctlAppearanceArgs(dark=%v) = %q, want %q", tt.dark, got, tt.want)
+		}
+	}
+}
+
+func TestSetAppearanceRequiresSimulator(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		jsonResponse(w, map[string]interface{}{"status": 0})
+	}))
+	defer server.Close()
+	driver := createTestDriver(server)
+	driver.udid = "00008110-000A1B2C3D4E5F6G"
+
+	result := driver.setAppearance(&flow.SetAppearanceStep{Mode: "dark"})
+
+	if result.Success {
+		t.Error("Expected failure on a real device")
+	}
+	if !strings.Contains(result.Message, "simulator") {
+		t.Errorf("Expected simulator hint in message, got: %s", result.Message)
+	}
+}
+
+func TestSimctlLocationSetArgs(t *testing.T) {
+	got := strings.Join(simctlLocationSetArgs("SIM-UDID", flow.TravelWaypoint{Lat: "37.7749", Lon: "-122.4194"}), " ")
+	if want := "simctl location SIM-UDID set 37.7749,-122.4194"; got != want {
+		t.Errorf("simctlLocationSetArgs() = %q, want %q", got, want)
+	}
+}
+
+func TestTravelRequiresSimulator(t *testing.T) {
+	driver := &Driver{udid: "00008110-000A1B2C3D4E5F6G", info: &core.PlatformInfo{}}
+
+	result := driver.Execute(&flow.TravelStep{Points: []string{"37.7749, -122.4194", "37.8049, -122.4094"}})
+
+	if result.Success {
+		t.Error("Expected failure on a real device")
+	}
+	if !strings.Contains(result.Message, "simulator") {
+		t.Errorf("Expected simulator hint in message, got: %s", result.Message)
+	}
+}
+
+func TestTravelNotEnoughPoints(t *testing.T) {
+	driver := &Driver{udid: "SIM-UDID", info: &core.PlatformInfo{IsSimulator: true}}
+
+	result := driver.travel(&flow.TravelStep{Points: []string{"37.7749, -122.4194"}})
+
+	if result.Success {
+		t.Error("Expected failure with a single waypoint")
+	}
+}
+
+func TestStartRecordingRequiresSimulator(t *testing.T) {
+	driver := &Driver{udid: "00008110-000A1B2C3D4E5F6G", info: &core.PlatformInfo{}}
+
+	result := driver.Execute(&flow.StartRecordingStep{})
+
+	if result.Success {
+		t.Error("Expected failure on a real device")
+	}
+	if !strings.Contains(result.Message, "simulator") {
+		t.Errorf("Expected simulator hint in message, got: %s", result.Message)
+	}
+}
+
+func TestStartRecordingInvalidMask(t *testing.T) {
+	driver := &Driver{udid: "SIM-UDID", info: &core.PlatformInfo{IsSimulator: true}}
+
+	result := driver.startRecording(&flow.StartRecordingStep{Mask: "blur"})
+
+	if result.Success {
+		t.Fatal("Expected failure for an invalid mask")
+	}
+	if driver.recording != nil {
+		t.Error("Expected no recording process for an invalid mask")
+	}
+}
+
+func TestStartRecordingRejectsSize(t *testing.T) {
+	driver := &Driver{udid: "SIM-UDID", info: &core.PlatformInfo{IsSimulator: true}}
+
+	result := driver.startRecording(&flow.StartRecordingStep{Size: "720x1280"})
+
+	if result.Success {
+		t.Fatal("Expected failure for size on iOS")
+	}
+	if driver.recording != nil {
+		t.Error("Expected no recording process when size is given")
+	}
+}
+
+func TestStartRecordingRejectsUnknownCrop(t *testing.T) {
+	driver := &Driver{udid: "SIM-UDID", info: &core.PlatformInfo{IsSimulator: true}}
+
+	result := driver.startRecording(&flow.StartRecordingStep{Crop: "navBar"})
+
+	if result.Success {
+		t.Fatal("Expected failure for an unknown crop")
+	}
+	if driver.recording != nil {
+		t.Error("Expected no recording process for an unknown crop")
+	}
+}
+
+func TestStatusBarFraction(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/wda/screen"):
+			jsonResponse(w, map[string]interface{}{
+				"value": map[string]interface{}{
+					"statusBarSize": map[string]interface{}{"width": 400.0, "height": 40.0},
+					"scale":         3.0,
+				},
+			})
+		case strings.Contains(r.URL.Path, "/window/size"):
+			jsonResponse(w, map[string]interface{}{
+				"value": map[string]interface{}{"width": 400.0, "height": 800.0},
+			})
+		default:
+			jsonResponse(w, map[string]interface{}{"status": 0})
+		}
+	}))
+	defer server.Close()
+	driver := createTestDriver(server)
+
+	fraction, err := driver.statusBarFraction()
+
+	if err != nil {
+		t.Fatalf("statusBarFraction failed: %v", err)
+	}
+	if fraction != 0.05 {
+		t.Errorf("fraction = %v, want 0.05 (40 of 800 pt)", fraction)
+	}
+}
+
+func TestStopRecordingWithoutStart(t *testing.T) {
+	driver := &Driver{udid: "SIM-UDID", info: &core.PlatformInfo{IsSimulator: true}}
+
+	result := driver.stopRecording(&flow.StopRecordingStep{})
+
+	if result.Success {
+		t.Error("Expected failure when no recording was started")
+	}
+}
+
+func TestStopRecordingInterruptsAndMoves(t *testing.T) {
+	dir := t.TempDir()
+	recorded := filepath.Join(dir, "recording.mp4")
+	if err := os.WriteFile(recorded, []byte("video"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command("sleep", "30")
+	if err := cmd.Start(); err != nil {
+		t.Skipf("cannot start stand-in recorder: %v", err)
+	}
+	driver := &Driver{recording: cmd, recordingPath: recorded}
+
+	saved := filepath.Join(dir, "flow.mp4")
+	start := time.Now()
+	result := driver.stopRecording(&flow.StopRecordingStep{Path: saved})
+
+	if !result.Success {
+		t.Fatalf("Expected success, got: %s", result.Message)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Error("Expected the recorder to exit on interrupt")
+	}
+	if result.Data != saved {
+		t.Errorf("Expected Data %q, got %v", saved, result.Data)
+	}
+	if _, err := os.Stat(saved); err != nil {
+		t.Errorf("Expected recording moved to %s: %v", saved, err)
+	}
+	if driver.recording != nil {
+		t.Error("Expected recording state cleared")
+	}
+}
+
+func TestStopRecordingCropsBeforeMove(t *testing.T) {
+	dir := t.TempDir()
+	recorded := filepath.Join(dir, "recording.mp4")
+	if err := os.WriteFile(recorded, []byte("video"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command("sleep", "30")
+	if err := cmd.Start(); err != nil {
+		t.Skipf("cannot start stand-in recorder: %v", err)
+	}
+	driver := &Driver{recording: cmd, recordingPath: recorded, recordingCrop: 0.05}
+	t.Setenv("PATH", "")
+
+	result := driver.stopRecording(&flow.StopRecordingStep{Path: filepath.Join(dir, "flow.mp4")})
+
+	if result.Success || !strings.Contains(result.Message, "requires ffmpeg") {
+		t.Errorf("Expected the crop to run ffmpeg on the recording, got: %s", result.Message)
+	}
+	if driver.recordingCrop != 0 {
+		t.Error("Expected crop state cleared")
+	}
+}
+
+func TestCloseStopsUnfinishedRecording(t *testing.T) {
+	cmd := exec.Command("sleep", "30")
+	if err := cmd.Start(); err != nil {
+		t.Skipf("cannot start stand-in recorder: %v", err)
+	}
+	driver := &Driver{recording: cmd, recordingPath: filepath.Join(t.TempDir(), "recording.mp4")}
+
+	start := time.Now()
+	if err := driver.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Error("Expected the recorder to be killed")
+	}
+	if cmd.ProcessState == nil {
+		t.Error("Expected the recorder process to have exited")
+	}
+	if driver.recording != nil {
+		t.Error("Expected recording state cleared")
+	}
+	if err := driver.Close(); err != nil {
+		t.Errorf("Expected a second Close to do nothing, got %v", err)
+	}
+}
+
+func TestMoveFile(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "recording.mp4")
+	dst := filepath.Join(dir, "flow.mp4")
+	if err := os.WriteFile(src, []byte("video"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := moveFile(src, dst); err != nil {
+		t.Fatalf("moveFile failed: %v", err)
+	}
+	if data, err := os.ReadFile(dst); err != nil || string(data) != "video" {
+		t.Errorf("Expected dst to hold the recording, got %q (%v)", data, err)
+	}
+	if _, err := os.Stat(src); !os.IsNotExist(err) {
+		t.Errorf("Expected src removed, got %v", err)
+	}
+	if err := moveFile(src, dst); err == nil {
+		t.Error("Expected an error for a missing source")
+	}
+}
+
+func TestSimctlStatusBarArgs(t *testing.T) {
+	tests := []struct {
+		airplane bool
+		want     string
+	}{
+		{false, "simctl status_bar SIM-UDID clear"},
+		{true, "simctl status_bar SIM-UDID override --dataNetwork hide --wifiMode failed --wifiBars 0 --cellularMode notSupported --cellularBars 0"},
+	}
+
+	for _, tt := range tests {
+		if got := strings.Join(simctlStatusBarArgs("SIM-UDID", tt.airplane), " "); got != tt.want {
+			t.Errorf("simctlStatusBarArgs(%v) = %q, want %q", tt.airplane, got, tt.want)
+		}
+	}
+}
+
+func TestAirplaneModeSkippedOnRealDevice(t *testing.T) {
+	driver := &Driver{udid: "00008110-000A1B2C3D4E5F6G", info: &core.PlatformInfo{}}
+
+	for _, step := range []flow.Step{&flow.SetAirplaneModeStep{Enabled: true}, &flow.ToggleAirplaneModeStep{}} {
+		result := driver.Execute(step)
+		if !result.Success {
+			t.Errorf("Expected %T to be skipped without failing, got: %s", step, result.Message)
+		}
+		if !strings.Contains(result.Message, "not supported on real iOS devices") {
+			t.Errorf("Expected unsupported note for %T, got: %s", step, result.Message)
+		}
+	}
+}
+
+func TestSetAppLocaleUnsupported(t *testing.T) {
+	driver := &Driver{info: &core.PlatformInfo{IsSimulator: true}}
+
+	result := driver.Execute(&flow.SetAppLocaleStep{AppID: "com.example.app", Locale: "fr"})
+
+	if result.Success {
+		t.Fatal("Expected setAppLocale to be unsupported on iOS")
+	}
+	if !strings.Contains(result.Message, "Android") || !strings.Contains(result.Message, "AppleLanguages") {
+		t.Errorf("Expected platform guidance in message, got: %s", result.Message)
+	}
+}
+
+func TestSimctlDefaultsWriteArgs(t *testing.T) {
+	plist := "/data/Containers/Data/Application/ABC/Library/Preferences/com.example.app"
+	tests := []struct {
+		name, typ, value string
+		want             []string
+	}{
+		{"string", flow.PrefString, "jane", []string{"simctl", "spawn", "SIM-UDID", "defaults", "write", plist, "username", "-string", "jane"}},
+		{"boolean", flow.PrefBoolean, "true", []string{"simctl", "spawn", "SIM-UDID", "defaults", "write", plist, "onboarded", "-bool", "true"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key := tt.want[6]
+			got := simctlDefaultsWriteArgs("SIM-UDID", plist, key, tt.typ, tt.value)
+			if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+				t.Errorf("simctlDefaultsWriteArgs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetPreferenceRequiresSimulator(t *testing.T) {
+	driver := &Driver{info: &core.PlatformInfo{IsSimulator: false}, udid: "DEVICE-UDID"}
+
+	result := driver.Execute(&flow.SetPreferenceStep{AppID: "com.example.app", Key: "onboarded", ValueType: "boolean", Value: "true"})
+
+	if result.Success {
+		t.Fatal("Expected failure on a real device")
+	}
+	if !strings.Contains(result.Message, "simulator") {
+		t.Errorf("Expected simulator hint in message, got: %s", result.Message)
+	}
+}
+
+// appStateServer answers /wda/apps/state with each state in turn, repeating
+// the last one, and counts the requests.
+func appStateServer(calls *int, states ...int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/wda/apps/state") {
+			state := states[min(*calls, len(states)-1)]
+			*calls++
+			jsonResponse(w, map[string]interface{}{"value": state})
+			return
+		}
+		jsonResponse(w, map[string]interface{}{"status": 0})
+	}))
+}
+
+func TestWaitForInstallAfterPolls(t *testing.T) {
+	calls := 0
+	server := appStateServer(&calls, AppStateNotInstalled, AppStateNotInstalled, 1)
+	defer server.Close()
+	driver := createTestDriver(server)
+
+	step := &flow.WaitForInstallStep{AppID: "com.example.app"}
+	step.TimeoutMs = 5000
+	result := driver.Execute(step)
+
+	if !result.Success {
+		t.Fatalf("Expected success, got: %s", result.Message)
+	}
+	if calls != 3 {
+		t.Errorf("Expected 3 app state checks, got %d", calls)
+	}
+}
+
+func TestWaitForInstallTimeout(t *testing.T) {
+	calls := 0
+	server := appStateServer(&calls, AppStateNotInstalled)
+	defer server.Close()
+	driver := createTestDriver(server)
+
+	step := &flow.WaitForInstallStep{AppID: "com.example.app"}
+	step.TimeoutMs = 600
+	result := driver.Execute(step)
+
+	if result.Success {
+		t.Fatal("Expected failure when the app never installs")
+	}
+	if result.Message != "App com.example.app was not installed within 600ms" {
+		t.Errorf("Unexpected message: %s", result.Message)
+	}
+	if calls < 2 {
+		t.Errorf("Expected repeated checks, got %d", calls)
+	}
+}
+
+func TestSimctlListsApp(t *testing.T) {
+	output := `{
+    "com.apple.Maps" =     {
+        ApplicationType = System;
+        CFBundleIdentifier = "com.apple.Maps";
+    };
+    "com.example.app.widgets" =     {
+        ApplicationType = User;
+    };
+}`
+	if !simctlListsApp(output, "com.apple.Maps") {
+		t.Error("Expected com.apple.Maps to be listed")
+	}
+	if simctlListsApp(output, "com.example.app") {
+		t.Error("Expected a bundle ID prefix not to count as installed")
+	}
+}
+
+func TestSetAppearanceInvalidMode(t *testing.T) {
+	driver := &Driver{info: &core.PlatformInfo{IsSimulator: true}, udid: "SIM-UDID"}
+
+	if result := driver.setAppearance(&flow.SetAppearanceStep{Mode: "sepia"}); result.Success {
+		t.Error("Expected failure for unknown appearance")
+	}
+}
+
+// =============================================================================
+// assertSorted tests
+// =============================================================================
+
+// sortedListServer serves a page source with one cell label per entry.
+func sortedListServer(labels ...string) *httptest.Server {
+	var cells strings.Builder
+	for i, label := range labels {
+		fmt.Fprintf(&cells, `<XCUIElementTypeStaticText type="XCUIElementTypeStaticText" name="rating" label="%s" enabled="true" visible="true" x="0" y="%d" width="390" height="44"/>`,
+			label, 100+i*50)
+	}
+	source := `<?xml version="1.0" encoding="UTF-8"?><AppiumAUT><XCUIElementTypeApplication type="XCUIElementTypeApplication" name="App" enabled="true" visible="true" x="0" y="0" width="390" height="844">` +
+		cells.String() + `</XCUIElementTypeApplication></AppiumAUT>`
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/source") {
+			jsonResponse(w, map[string]interface{}{"value": source})
+			return
+		}
+		jsonResponse(w, map[string]interface{}{"status": 0})
+	}))
+}
+
+func TestAssertSorted(t *testing.T) {
+	tests := []struct {
+		name    string
+		labels  []string
+		order   string
+		success bool
+	}{
+		{"ascending in order", []string{"3.1 stars", "4.0 stars", "4.8 stars"}, "", true},
+		{"ascending out of order", []string{"3.1 stars", "4.8 stars", "4.0 stars"}, "ascending", false},
+		{"descending in order", []string{"4.8 stars", "4.0 stars", "3.1 stars"}, "descending", true},
+		{"descending out of order", []string{"4.8 stars", "3.1 stars", "4.0 stars"}, "descending", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := sortedListServer(tt.labels...)
+			defer server.Close()
+			driver := createTestDriver(server)
+
+			result := driver.assertSorted(&flow.AssertSortedStep{
+				Element: flow.Selector{ID: "rating"},
+				Pattern: `[\d.]+`,
+				Numeric: true,
+				Order:   tt.order,
+			})
+
+			if result.Success != tt.success {
+				t.Fatalf("Expected success=%v, got %v: %s", tt.success, result.Success, result.Message)
+			}
+			if !tt.success && !strings.Contains(result.Message, "index 1") {
+				t.Errorf("Expected first out-of-order pair in message, got: %s", result.Message)
+			}
+		})
+	}
+}
+
+// =============================================================================
+// waitForText tests
+// =============================================================================
+
+// statusSource builds a page source with a single status label.
+func statusSource(label string) string {
+	return `<?xml version="1.0" encoding="UTF-8"?><AppiumAUT><XCUIElementTypeApplication type="XCUIElementTypeApplication" name="App" enabled="true" visible="true" x="0" y="0" width="390" height="844">` +
+		`<XCUIElementTypeStaticText type="XCUIElementTypeStaticText" name="status" label="` + label + `" enabled="true" visible="true" x="0" y="100" width="390" height="44"/>` +
+		`</XCUIElementTypeApplication></AppiumAUT>`
+}
+
+func TestWaitForText(t *testing.T) {
+	calls := 0
+	server := sequencedSourceServer(&calls, statusSource("Loading"), statusSource("Loading"), statusSource("Done"))
+	defer server.Close()
+	driver := createTestDriver(server)
+
+	step := &flow.WaitForTextStep{Element: flow.Selector{ID: "status"}, Equals: "Done"}
+	step.TimeoutMs = 5000
+	result := driver.waitForText(step)
+
+	if !result.Success {
+		t.Fatalf("Expected success, got: %s", result.Message)
+	}
+	if calls != 3 {
+		t.Errorf("Expected to return on the third poll, got %d polls", calls)
+	}
+}
+
+func TestWaitForTextTimeoutReportsLastText(t *testing.T) {
+	calls := 0
+	server := sequencedSourceServer(&calls, statusSource("Loading"))
+	defer server.Close()
+	driver := createTestDriver(server)
+
+	step := &flow.WaitForTextStep{Element: flow.Selector{ID: "status"}, Matches: "Done|Finished"}
+	step.TimeoutMs = 1
+	result := driver.waitForText(step)
+
+	if result.Success {
+		t.Fatal("Expected timeout failure")
+	}
+	if !strings.Contains(result.Message, `last text: "Loading"`) {
+		t.Errorf("Expected last observed text in message, got: %s", result.Message)
+	}
+}
+
+func TestAssertVisibleFullyVisible(t *testing.T) {
+	tests := []struct {
+		name        string
+		y           int
+		wantSuccess bool
+	}{
+		{"straddles bottom edge", 790, false},
+		{"fully on screen", 700, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				path := r.URL.Path
+
+				if strings.HasSuffix(path, "/source") {
+					jsonResponse(w, map[string]interface{}{
+						"value": fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<AppiumAUT>
+  <XCUIElementTypeApplication type="XCUIElementTypeApplication" name="TestApp" enabled="true" visible="true" x="0" y="0" width="390" height="844">
+    <XCUIElementTypeButton type="XCUIElementTypeButton" name="checkout" label="Checkout" enabled="true" visible="true" x="20" y="%d" width="350" height="100"/>
+  </XCUIElementTypeApplication>
+</AppiumAUT>`, tt.y),
+					})
+					return
+				}
+				if strings.HasSuffix(path, "/window/size") {
+					jsonResponse(w, map[string]interface{}{
+						"value": map[string]interface{}{"width": 390.0, "height": 844.0},
+					})
+					return
+				}
+				if strings.HasSuffix(path, "/element") && r.Method == "POST" {
+					jsonResponse(w, map[string]interface{}{
+						"value": map[string]interface{}{"error": "not found"},
+					})
+					return
+				}
+				jsonResponse(w, map[string]interface{}{"status": 0})
+			}))
+			defer server.Close()
+			driver := createTestDriver(server)
+
+			step := &flow.AssertVisibleStep{
+				BaseStep:     flow.BaseStep{TimeoutMs: 1000},
+				Selector:     flow.Selector{Text: "Checkout"},
+				FullyVisible: true,
+			}
+			result := driver.assertVisible(step)
+
+			if result.Success != tt.wantSuccess {
+				t.Fatalf("Expected success=%v, got %v: %s", tt.wantSuccess, result.Success, result.Message)
+			}
+			if !tt.wantSuccess && !strings.Contains(result.Message, "46px past bottom edge") {
+				t.Errorf("Expected overflow amount in message, got: %s", result.Message)
+			}
+		})
+	}
+}
+
+func TestAssertVisibleCount(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if strings.HasSuffix(r.URL.Path, "/source") {
+			jsonResponse(w, map[string]interface{}{
+				"value": `<?xml version="1.0" encoding="UTF-8"?>
+<AppiumAUT>
+  <XCUIElementTypeApplication type="XCUIElementTypeApplication" name="TestApp" enabled="true" visible="true" x="0" y="0" width="390" height="844">
+    <XCUIElementTypeCell type="XCUIElementTypeCell" name="row" label="A" enabled="true" visible="true" x="0" y="0" width="390" height="80"/>
+    <XCUIElementTypeCell type="XCUIElementTypeCell" name="row" label="B" enabled="true" visible="true" x="0" y="80" width="390" height="80"/>
+    <XCUIElementTypeCell type="XCUIElementTypeCell" name="row" label="C" enabled="true" visible="false" x="0" y="900" width="390" height="80"/>
+  </XCUIElementTypeApplication>
+</AppiumAUT>`,
+			})
+			return
+		}
+		jsonResponse(w, map[string]interface{}{"status": 0})
+	}))
+	defer server.Close()
+	driver := createTestDriver(server)
+
+	count := 2
+	result := driver.Execute(&flow.AssertVisibleStep{
+		BaseStep: flow.BaseStep{TimeoutMs: 1},
+		Selector: flow.Selector{ID: "row"},
+		Count:    &count,
+	})
+	if !result.Success {
+		t.Errorf("Expected success for 2 visible rows, got: %s", result.Message)
+	}
+
+	count = 3
+	result = driver.Execute(&flow.AssertVisibleStep{
+		BaseStep: flow.BaseStep{TimeoutMs: 1},
+		Selector: flow.Selector{ID: "row"},
+		Count:    &count,
+	})
+	if result.Success {
+		t.Fatal("Expected failure for 3 rows when only 2 are visible")
+	}
+	if !strings.Contains(result.Message, "expected 3 '#row' elements, found 2") {
+		t.Errorf("Unexpected message: %s", result.Message)
+	}
+}
+
+func TestAssertVisibleCountNestedMatch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if strings.HasSuffix(r.URL.Path, "/source") {
+			jsonResponse(w, map[string]interface{}{
+				"value": `<?xml version="1.0" encoding="UTF-8"?>
+<AppiumAUT>
+  <XCUIElementTypeApplication type="XCUIElementTypeApplication" name="TestApp" enabled="true" visible="true" x="0" y="0" width="390" height="844">
+    <XCUIElementTypeCell type="XCUIElementTypeCell" label="Milk" enabled="true" visible="true" x="0" y="0" width="390" height="80">
+      <XCUIElementTypeStaticText type="XCUIElementTypeStaticText" label="Milk" enabled="true" visible="true" x="16" y="20" width="100" height="40"/>
+    </XCUIElementTypeCell>
+  </XCUIElementTypeApplication>
+</AppiumAUT>`,
+			})
+			return
+		}
+		jsonResponse(w, map[string]interface{}{"status": 0})
+	}))
+	defer server.Close()
+	driver := createTestDriver(server)
+
+	count := 1
+	result := driver.Execute(&flow.AssertVisibleStep{
+		BaseStep: flow.BaseStep{TimeoutMs: 1},
+		Selector: flow.Selector{Text: "Milk"},
+		Count:    &count,
+	})
+	if !result.Success {
+		t.Errorf("Expected the cell and its label to count once, got: %s", result.Message)
+	}
+}
+
+const shareSheetSource = `<?xml version="1.0" encoding="UTF-8"?><AppiumAUT>` +
+	`<XCUIElementTypeApplication type="XCUIElementTypeApplication" name="App" enabled="true" visible="true" x="0" y="0" width="390" height="844">` +
+	`<XCUIElementTypeOther type="XCUIElementTypeOther" name="ActivityListView" enabled="true" visible="true" x="0" y="300" width="390" height="544">` +
+	`<XCUIElementTypeCollectionView type="XCUIElementTypeCollectionView" enabled="true" visible="true" x="0" y="420" width="390" height="120">` +
+	`<XCUIElementTypeCell type="XCUIElementTypeCell" label="Messages" enabled="true" visible="true" x="10" y="420" width="80" height="110"/>` +
+	`<XCUIElementTypeCell type="XCUIElementTypeCell" label="Mail" enabled="true" visible="true" x="90" y="420" width="80" height="110"/>` +
+	`</XCUIElementTypeCollectionView>` +
+	`</XCUIElementTypeOther>` +
+	`</XCUIElementTypeApplication></AppiumAUT>`
+
+func TestAssertShareTarget(t *testing.T) {
+	hidden := false
+	tests := []struct {
+		name    string
+		step    *flow.AssertShareTargetStep
+		wantOK  bool
+		wantMsg string
+	}{
+		{"present target", &flow.AssertShareTargetStep{App: "mail"}, true, `lists "mail"`},
+		{"absent target", &flow.AssertShareTargetStep{App: "WhatsApp"}, false, `does not list "WhatsApp"`},
+		{"absent target expected", &flow.AssertShareTargetStep{App: "WhatsApp", Visible: &hidden}, true, `does not list "WhatsApp"`},
+		{"present target not expected", &flow.AssertShareTargetStep{App: "Messages", Visible: &hidden}, false, `should not list "Messages"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			server := sequencedSourceServer(&calls, shareSheetSource)
+			defer server.Close()
+			driver := createTestDriver(server)
+			tt.step.TimeoutMs = 100
+
+			result := driver.Execute(tt.step)
+
+			if result.Success != tt.wantOK {
+				t.Fatalf("Success = %v, want %v (%s)", result.Success, tt.wantOK, result.Message)
+			}
+			if !strings.Contains(result.Message, tt.wantMsg) {
+				t.Errorf("Expected %q in message, got: %s", tt.wantMsg, result.Message)
+			}
+		})
+	}
+}
+
+func TestAssertShareTargetNoSheet(t *testing.T) {
+	calls := 0
+	server := sequencedSourceServer(&calls, retrySourceValid)
+	defer server.Close()
+	driver := createTestDriver(server)
+
+	result := driver.assertShareTarget(&flow.AssertShareTargetStep{App: "Mail", BaseStep: flow.BaseStep{TimeoutMs: 100}})
+
+	if result.Success {
+		t.Fatal("Expected failure without a share sheet")
+	}
+	if !strings.Contains(result.Message, "Share sheet not found") {
+		t.Errorf("Unexpected message: %s", result.Message)
+	}
+}
+
+const accessibilitySource = `<?xml version="1.0" encoding="UTF-8"?><AppiumAUT>` +
+	`<XCUIElementTypeApplication type="XCUIElementTypeApplication" name="App" enabled="true" visible="true" x="0" y="0" width="390" height="844">` +
+	`<XCUIElementTypeButton type="XCUIElementTypeButton" label="Sign In" enabled="true" visible="true" x="20" y="600" width="350" height="44"/>` +
+	`<XCUIElementTypeButton type="XCUIElementTypeButton" enabled="true" visible="true" x="340" y="50" width="30" height="30"/>` +
+	`<XCUIElementTypeTextField type="XCUIElementTypeTextField" placeholderValue="Email" enabled="true" visible="true" x="20" y="300" width="350" height="44"/>` +
+	`<XCUIElementTypeImage type="XCUIElementTypeImage" enabled="true" visible="true" x="0" y="100" width="390" height="150"/>` +
+	`</XCUIElementTypeApplication></AppiumAUT>`
+
+func TestAssertAccessibleReportsUnlabeled(t *testing.T) {
+	calls := 0
+	server := sequencedSourceServer(&calls, accessibilitySource)
+	defer server.Close()
+	driver := createTestDriver(server)
+
+	result := driver.Execute(&flow.AssertAccessibleStep{})
+
+	if result.Success {
+		t.Fatal("Expected failure for the unlabeled button")
+	}
+	want := "1 of 3 interactive elements have no accessibility label: Button at (340, 50) 30x30"
+	if result.Message != want {
+		t.Errorf("Message = %q, want %q", result.Message, want)
+	}
+}
+
+func TestAssertAccessibleAllLabeled(t *testing.T) {
+	calls := 0
+	server := sequencedSourceServer(&calls, shareSheetSource)
+	defer server.Close()
+	driver := createTestDriver(server)
+
+	result := driver.Execute(&flow.AssertAccessibleStep{})
+
+	if !result.Success {
+		t.Fatalf("Expected success, got: %s", result.Message)
+	}
+}
+
+const forbiddenTextSource = `<?xml version="1.0" encoding="UTF-8"?><AppiumAUT>` +
+	`<XCUIElementTypeApplication type="XCUIElementTypeApplication" name="App" enabled="true" visible="true" x="0" y="0" width="390" height="844">` +
+	`<XCUIElementTypeStaticText type="XCUIElementTypeStaticText" label="Checkout" value="Checkout" enabled="true" visible="true" x="20" y="100" width="350" height="30"/>` +
+	`<XCUIElementTypeStaticText type="XCUIElementTypeStaticText" label="Error: null" enabled="true" visible="true" x="20" y="150" width="350" height="30"/>` +
+	`<XCUIElementTypeTextField type="XCUIElementTypeTextField" placeholderValue="Promo code" enabled="true" visible="true" x="20" y="300" width="350" height="44"/>` +
+	`</XCUIElementTypeApplication></AppiumAUT>`
+
+func TestAssertTextNotContainsFindsForbiddenText(t *testing.T) {
+	calls := 0
+	server := sequencedSourceServer(&calls, forbiddenTextSource)
+	defer server.Close()
+	driver := createTestDriver(server)
+
+	result := driver.Execute(&flow.AssertTextNotContainsStep{Text: []string{"null"}})
+
+	if result.Success {
+		t.Fatal("Expected failure for forbidden text")
+	}
+	want := `Forbidden text found on screen: "null" in "Error: null"`
+	if result.Message != want {
+		t.Errorf("Message = %q, want %q", result.Message, want)
+	}
+}
+
+func TestAssertTextNotContainsAbsent(t *testing.T) {
+	calls := 0
+	server := sequencedSourceServer(&calls, forbiddenTextSource)
+	defer server.Close()
+	driver := createTestDriver(server)
+
+	result := driver.Execute(&flow.AssertTextNotContainsStep{Text: []string{"Exception"}, Regex: []string{`\bundefined\b`}})
+
+	if !result.Success {
+		t.Fatalf("Expected success, got: %s", result.Message)
+	}
+	if result.Message != "No forbidden text in 3 texts on screen" {
+		t.Errorf("Unexpected message: %s", result.Message)
+	}
+}
@@ -2,13 +2,17 @@ package wda
 
 import (
 	"context"
+	"encoding/xml"
+	"errors"
 	"fmt"
-	"strconv"
+	"io"
+	"os/exec"
 	"strings"
 	"time"
 
 	"github.com/devicelab-dev/maestro-runner/pkg/core"
 	"github.com/devicelab-dev/maestro-runner/pkg/flow"
+	"github.com/devicelab-dev/maestro-runner/pkg/logger"
 )
 
 // Driver implements core.Driver using WebDriverAgent for iOS.
@@ -23,9 +27,39 @@ type Driver struct {
 	// WDA alert action for real device permission handling ("accept", "dismiss", or "")
 	alertAction string
 
+	// Tap implementation: TapModeWDA (default) or TapModeActions
+	tapMode string
+
+	// What to do when WDA reports the session is gone: InvalidSessionRecover (default) or InvalidSessionFail
+	invalidSessionMode string
+
+	// Dismiss an unexpected system alert when a step fails and retry the step once
+	autoDismissAlerts bool
+
+	// Adopt a session WDA already has open instead of creating one
+	reuseSession bool
+
 	// Timeouts (0 = use defaults)
 	findTimeout         int // ms, for required elements
 	optionalFindTimeout int // ms, for optional elements
+
+	// Simulator screen recording started by startRecording
+	recording     *exec.Cmd // running `simctl io recordVideo`
+	recordingPath string    // host path the recording is written to
+	recordingCrop float64   // share of the height to crop off the top when it stops (0 = none)
+
+	// Start of the flow's simulator log, set by StartLogCapture
+	logSince time.Time
+
+	// Airplane mode last applied to the simulator status bar, for toggleAirplaneMode
+	airplaneMode bool
+
+	// Per-step caches, cleared at the start of each Execute. The page source
+	// is also dropped when a request may have changed the screen.
+	source   []*ParsedElement // parsed page source, nil when not cached
+	sourceAt uint64           // client.uiChanges when source was fetched
+	screenW  int              // window size, 0 when not cached
+	screenH  int
 }
 
 // NewDriver creates a new WDA driver.
@@ -37,6 +71,21 @@ func NewDriver(client *Client, info *core.PlatformInfo, udid string) *Driver {
 	}
 }
 
+// Close stops a simulator recording that stopRecording never finished, so no
+// recordVideo process outlives the run.
+func (d *Driver) Close() error {
+	cmd := d.recording
+	d.recording, d.recordingPath, d.recordingCrop = nil, "", 0
+	if cmd == nil {
+		return nil
+	}
+	if err := cmd.Process.Kill(); err != nil {
+		return fmt.Errorf("stop recordVideo: %w", err)
+	}
+	_ = cmd.Wait()
+	return nil
+}
+
 // SetFindTimeout sets the timeout for finding required elements.
 func (d *Driver) SetFindTimeout(ms int) {
 	d.findTimeout = ms
@@ -52,6 +101,99 @@ func (d *Driver) SetAppFile(path string) {
 	d.appFile = path
 }
 
+// Tap implementations.
+const (
+	TapModeWDA     = "wda"     // POST /wda/tap
+	TapModeActions = "actions" // POST /actions (W3C pointer sequence)
+)
+
+// SetTapMode selects how coordinate taps are sent. Empty keeps the default (wda).
+func (d *Driver) SetTapMode(mode string) error {
+	switch mode {
+	case "", TapModeWDA:
+		d.tapMode = TapModeWDA
+	case TapModeActions:
+		d.tapMode = TapModeActions
+	default:
+		return fmt.Errorf("invalid tap mode %q (expected %q or %q)", mode, TapModeWDA, TapModeActions)
+	}
+	return nil
+}
+
+// tap performs a coordinate tap using the configured tap mode.
+func (d *Driver) tap(x, y float64) error {
+	if d.tapMode == TapModeActions {
+		return d.client.TapWithActions(x, y)
+	}
+	return d.client.Tap(x, y)
+}
+
+// Handling of "invalid session id" errors.
+const (
+	InvalidSessionRecover = "recover" // recreate the session and retry the step once
+	InvalidSessionFail    = "fail"    // fail the step
+)
+
+// SetInvalidSessionMode selects how steps that hit a lost WDA session are
+// handled. Empty keeps the default (recover).
+func (d *Driver) SetInvalidSessionMode(mode string) error {
+	switch mode {
+	case "", InvalidSessionRecover:
+		d.invalidSessionMode = InvalidSessionRecover
+	case InvalidSessionFail:
+		d.invalidSessionMode = InvalidSessionFail
+	default:
+		return fmt.Errorf("invalid session mode %q (expected %q or %q)", mode, InvalidSessionRecover, InvalidSessionFail)
+	}
+	return nil
+}
+
+// SetAutoDismissUnexpectedAlerts enables dismissing a system alert (low
+// storage, OS update) found after a step fails, then retrying the step once.
+func (d *Driver) SetAutoDismissUnexpectedAlerts(enabled bool) {
+	d.autoDismissAlerts = enabled
+}
+
+// SetReuseSession enables adopting a session WDA already has open (e.g.
+// from an earlier run against the same WDA) instead of creating a new one
+// at the first launchApp.
+func (d *Driver) SetReuseSession(enabled bool) {
+	d.reuseSession = enabled
+}
+
+// EnsureSession checks the cached WDA session before it is used. A stale
+// session is recreated from its capabilities (or reported, when invalid
+// sessions fail), and an adopted one that can't be recreated is dropped.
+// Without a session, one WDA already has open is adopted when session reuse
+// is enabled; otherwise the driver stays without one and launchApp creates
+// it for its app.
+func (d *Driver) EnsureSession() error {
+	if !d.client.HasSession() {
+		if d.reuseSession && d.client.AttachSession() {
+			logger.Info("Reusing WDA session %s", d.client.SessionID())
+			d.configureSession()
+		}
+		return nil
+	}
+	if d.client.SessionAlive() {
+		return nil
+	}
+
+	stale := d.client.SessionID()
+	if d.client.caps == nil {
+		d.client.sessionID = ""
+		return nil
+	}
+	if d.invalidSessionMode == InvalidSessionFail {
+		return fmt.Errorf("WDA session %s is no longer valid", stale)
+	}
+	if err := d.recoverSession(); err != nil {
+		return err
+	}
+	logger.Info("WDA session %s was stale; recreated session %s", stale, d.client.SessionID())
+	return nil
+}
+
 // SetWaitForIdleTimeout sets the wait for idle timeout.
 // Note: This is a no-op for iOS/WDA as idle timeout is not applicable.
 func (d *Driver) SetWaitForIdleTimeout(ms int) error {
@@ -66,10 +208,71 @@ const (
 	QuickFindTimeout    = 1000  // 1 second for quick checks
 )
 
-// Execute runs a single step and returns the result.
+// Execute runs a single step and returns the result. A step that fails
+// because WDA lost its session (app killed or reinstalled mid-flow) is retried
+// once on a fresh session unless the invalid session mode is "fail". With
+// unexpected alert dismissal enabled, a step that fails while a system alert
+// is showing is retried once after the alert is dismissed.
 func (d *Driver) Execute(step flow.Step) *core.CommandResult {
 	start := time.Now()
+	d.clearStepCache()
+	// Drop a lost-session flag left by an earlier step that still passed
+	d.client.takeSessionLost()
+
+	result := d.execute(step)
+	if !result.Success && d.client.takeSessionLost() && d.invalidSessionMode != InvalidSessionFail {
+		if err := d.recoverSession(); err != nil {
+			logger.Warn("WDA session lost and could not be recreated: %v", err)
+		} else {
+			logger.Info("WDA session lost; recreated session %s and retrying %s", d.client.SessionID(), step.Type())
+			result = d.execute(step)
+		}
+	}
+	if !result.Success && d.autoDismissAlerts && !isAlertStep(step) && d.dismissUnexpectedAlert() {
+		logger.Info("Retrying %s after dismissing unexpected alert", step.Type())
+		result = d.execute(step)
+	}
 
+	result.Duration = time.Since(start)
+	return result
+}
+
+// recoverSession replaces a lost session and reapplies the session settings.
+func (d *Driver) recoverSession() error {
+	if err := d.client.RecreateSession(); err != nil {
+		return err
+	}
+	d.configureSession()
+	return nil
+}
+
+// dismissUnexpectedAlert dismisses the system alert on screen, if any, and
+// reports whether one was dismissed.
+func (d *Driver) dismissUnexpectedAlert() bool {
+	text, err := d.client.AlertText()
+	if err != nil {
+		return false
+	}
+	if err := d.client.DismissAlert(); err != nil {
+		logger.Warn("Unexpected alert %q could not be dismissed: %v", text, err)
+		return false
+	}
+	logger.Warn("Dismissed unexpected alert: %q", text)
+	return true
+}
+
+// isAlertStep reports whether step handles alerts itself, so an alert on
+// screen is expected rather than something to dismiss.
+func isAlertStep(step flow.Step) bool {
+	switch step.(type) {
+	case *flow.AcceptAlertStep, *flow.DismissAlertStep, *flow.AssertAlertTextStep:
+		return true
+	}
+	return false
+}
+
+// execute dispatches a step to its command.
+func (d *Driver) execute(step flow.Step) *core.CommandResult {
 	var result *core.CommandResult
 	switch s := step.(type) {
 	// Tap commands
@@ -81,16 +284,32 @@ func (d *Driver) Execute(step flow.Step) *core.CommandResult {
 		result = d.longPressOn(s)
 	case *flow.TapOnPointStep:
 		result = d.tapOnPoint(s)
+	case *flow.TapSequenceStep:
+		result = d.tapSequence(s)
 
 	// Assert commands
 	case *flow.AssertVisibleStep:
 		result = d.assertVisible(s)
 	case *flow.AssertNotVisibleStep:
 		result = d.assertNotVisible(s)
+	case *flow.AssertFileExistsStep:
+		result = d.assertFileExists(s)
+	case *flow.AssertSortedStep:
+		result = d.assertSorted(s)
+	case *flow.AssertShareTargetStep:
+		result = d.assertShareTarget(s)
+	case *flow.AssertAccessibleStep:
+		result = d.assertAccessible(s)
+	case *flow.AssertTextNotContainsStep:
+		result = d.assertTextNotContains(s)
+	case *flow.AssertFieldValueStep:
+		result = d.assertFieldValue(s)
 
 	// Input commands
 	case *flow.InputTextStep:
 		result = d.inputText(s)
+	case *flow.SearchStep:
+		result = d.search(s)
 	case *flow.EraseTextStep:
 		result = d.eraseText(s)
 	case *flow.HideKeyboardStep:
@@ -99,6 +318,8 @@ func (d *Driver) Execute(step flow.Step) *core.CommandResult {
 		result = d.acceptAlert(s)
 	case *flow.DismissAlertStep:
 		result = d.dismissAlert(s)
+	case *flow.AssertAlertTextStep:
+		result = d.assertAlertText(s)
 	case *flow.InputRandomStep:
 		result = d.inputRandom(s)
 
@@ -109,6 +330,10 @@ func (d *Driver) Execute(step flow.Step) *core.CommandResult {
 		result = d.scrollUntilVisible(s)
 	case *flow.SwipeStep:
 		result = d.swipe(s)
+	case *flow.TwoFingerSwipeStep:
+		result = d.twoFingerSwipe(s)
+	case *flow.GesturePathStep:
+		result = d.gesturePath(s)
 
 	// Navigation commands
 	case *flow.BackStep:
@@ -125,6 +350,10 @@ func (d *Driver) Execute(step flow.Step) *core.CommandResult {
 		result = d.killApp(s)
 	case *flow.ClearStateStep:
 		result = d.clearState(s)
+	case *flow.SetAppLocaleStep:
+		result = d.setAppLocale(s)
+	case *flow.SetPreferenceStep:
+		result = d.setPreference(s)
 
 	// Clipboard
 	case *flow.CopyTextFromStep:
@@ -141,21 +370,43 @@ func (d *Driver) Execute(step flow.Step) *core.CommandResult {
 		result = d.openLink(s)
 	case *flow.OpenBrowserStep:
 		result = d.openBrowser(s)
+	case *flow.EnsureUnlockedStep:
+		result = d.ensureUnlocked(s)
+	case *flow.SetAppearanceStep:
+		result = d.setAppearance(s)
 
 	// Wait commands
 	case *flow.WaitUntilStep:
 		result = d.waitUntil(s)
+	case *flow.ExtendedWaitUntilStep:
+		result = d.waitUntilAll(s)
 	case *flow.WaitForAnimationToEndStep:
 		result = d.waitForAnimationToEnd(s)
+	case *flow.WaitForTextStep:
+		result = d.waitForText(s)
+	case *flow.WaitForInstallStep:
+		result = d.waitForInstall(s)
 
 	// Media
 	case *flow.TakeScreenshotStep:
 		result = d.takeScreenshot(s)
+	case *flow.StartRecordingStep:
+		result = d.startRecording(s)
+	case *flow.StopRecordingStep:
+		result = d.stopRecording(s)
 
 	// Permissions
 	case *flow.SetPermissionsStep:
 		result = d.setPermissions(s)
 
+	// Location
+	case *flow.TravelStep:
+		result = d.travel(s)
+	case *flow.SetAirplaneModeStep:
+		result = d.setAirplaneMode(s.Enabled)
+	case *flow.ToggleAirplaneModeStep:
+		result = d.setAirplaneMode(!d.airplaneMode)
+
 	default:
 		result = &core.CommandResult{
 			Success: false,
@@ -163,8 +414,6 @@ func (d *Driver) Execute(step flow.Step) *core.CommandResult {
 			Message: fmt.Sprintf("Step type '%T' is not supported on iOS", step),
 		}
 	}
-
-	result.Duration = time.Since(start)
 	return result
 }
 
@@ -209,6 +458,10 @@ func (d *Driver) findElement(sel flow.Selector, optional bool, stepTimeoutMs int
 
 // findElementWithContext finds an element using context for deadline management.
 func (d *Driver) findElementWithContext(ctx context.Context, sel flow.Selector) (*core.ElementInfo, error) {
+	if err := sel.Validate(); err != nil {
+		return nil, err
+	}
+
 	// Handle relative selectors via page source
 	if sel.HasRelativeSelector() {
 		return d.findElementRelativeWithContext(ctx, sel)
@@ -225,9 +478,11 @@ func (d *Driver) findElementWithContext(ctx context.Context, sel flow.Selector)
 			}
 			return nil, fmt.Errorf("element '%s' not found: %w", sel.Describe(), ctx.Err())
 		default:
-			// Try WDA strategies first
-			if info, err := d.findElementByWDA(sel); err == nil {
-				return info, nil
+			// Try WDA strategies first (they can't express structural filters or textRegex)
+			if !sel.RequiresPageSource() {
+				if info, err := d.findElementByWDA(sel); err == nil {
+					return info, nil
+				}
 			}
 
 			// Fallback to page source parsing
@@ -237,6 +492,7 @@ func (d *Driver) findElementWithContext(ctx context.Context, sel flow.Selector)
 				lastErr = err
 			}
 			// HTTP round-trip is natural rate limit, no sleep needed
+			d.invalidateSource()
 		}
 	}
 }
@@ -245,6 +501,10 @@ func (d *Driver) findElementWithContext(ctx context.Context, sel flow.Selector)
 // For text selectors, it tries interactive element types first (TextField, SecureTextField, Button),
 // then falls back to generic text matching with clickable parent lookup via page source.
 func (d *Driver) findElementForTap(sel flow.Selector, optional bool, stepTimeoutMs int) (*core.ElementInfo, error) {
+	if err := sel.Validate(); err != nil {
+		return nil, err
+	}
+
 	// For relative selectors, use page source which handles them correctly
 	if sel.HasRelativeSelector() {
 		timeout := d.calculateTimeout(optional, stepTimeoutMs)
@@ -253,8 +513,9 @@ func (d *Driver) findElementForTap(sel flow.Selector, optional bool, stepTimeout
 		return d.findElementRelativeWithContext(ctx, sel)
 	}
 
-	// For ID-based selectors, use standard findElement (IDs are usually unique)
-	if sel.ID != "" {
+	// For ID-based, structural and textRegex selectors, use standard findElement
+	// (IDs are usually unique; the others resolve via page source)
+	if sel.ID != "" || sel.RequiresPageSource() {
 		return d.findElement(sel, optional, stepTimeoutMs)
 	}
 
@@ -270,6 +531,42 @@ func (d *Driver) findElementForTap(sel flow.Selector, optional bool, stepTimeout
 	return d.findElement(sel, optional, stepTimeoutMs)
 }
 
+// findTapParent finds an element for tapOn with tapParent. The match is
+// always resolved from the page source and replaced by its nearest hittable
+// interactive ancestor (see GetClickableElement), so a label inside a cell
+// taps the cell; an interactive, hittable match is kept.
+func (d *Driver) findTapParent(sel flow.Selector, optional bool, stepTimeoutMs int) (*core.ElementInfo, error) {
+	if err := sel.Validate(); err != nil {
+		return nil, err
+	}
+
+	timeout := d.calculateTimeout(optional, stepTimeoutMs)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if sel.HasRelativeSelector() {
+		return d.findElementRelativeWithContext(ctx, sel)
+	}
+
+	var lastErr error
+	for {
+		select {
+		case <-ctx.Done():
+			if lastErr != nil {
+				return nil, fmt.Errorf("%s: %w", ctx.Err(), lastErr)
+			}
+			return nil, fmt.Errorf("element '%s' not found: %w", sel.Describe(), ctx.Err())
+		default:
+			info, err := d.findElementByPageSourceOnce(sel)
+			if err == nil {
+				return info, nil
+			}
+			lastErr = err
+			d.invalidateSource()
+		}
+	}
+}
+
 // findElementForTapWithContext implements the smart tap element finding strategy.
 // Tries interactive WDA queries first (TextField, SecureTextField, Button), then falls back
 // to generic predicate to check if text exists, and finally page source with clickable parent lookup.
@@ -315,6 +612,7 @@ func (d *Driver) findElementForTapWithContext(ctx context.Context, sel flow.Sele
 				}
 				// Still not found - keep polling
 				lastErr = textExistsErr
+				d.invalidateSource()
 				continue
 			}
 
@@ -332,6 +630,7 @@ func (d *Driver) findElementForTapWithContext(ctx context.Context, sel flow.Sele
 				}
 				lastErr = err
 			}
+			d.invalidateSource()
 		}
 	}
 }
@@ -395,11 +694,15 @@ func (d *Driver) calculateTimeout(optional bool, stepTimeoutMs int) time.Duratio
 // findElementOnce finds an element with a single attempt (no polling).
 // Used by waitUntil which has its own polling loop with context.
 func (d *Driver) findElementOnce(sel flow.Selector) (*core.ElementInfo, error) {
+	if err := sel.Validate(); err != nil {
+		return nil, err
+	}
+
 	if sel.HasRelativeSelector() {
 		return d.findElementRelativeOnce(sel)
 	}
 
-	if sel.Width > 0 || sel.Height > 0 {
+	if sel.Width > 0 || sel.Height > 0 || sel.RequiresPageSource() {
 		return d.findElementByPageSourceOnce(sel)
 	}
 
@@ -442,6 +745,14 @@ func buildStateFilter(sel flow.Selector) string {
 			conditions = append(conditions, "hasFocus == false")
 		}
 	}
+	if sel.Checked != nil {
+		// Same rule as isChecked: switch value "1" or selected
+		if *sel.Checked {
+			conditions = append(conditions, "(value == '1' OR selected == true)")
+		} else {
+			conditions = append(conditions, "value != '1' AND selected == false")
+		}
+	}
 	if len(conditions) == 0 {
 		return ""
 	}
@@ -533,20 +844,16 @@ func (d *Driver) findElementRelativeWithContext(ctx context.Context, sel flow.Se
 			}
 			lastErr = err
 			// HTTP round-trip is natural rate limit, no sleep needed
+			d.invalidateSource()
 		}
 	}
 }
 
 // findElementRelativeOnce performs a single attempt to find element with relative selector.
 func (d *Driver) findElementRelativeOnce(sel flow.Selector) (*core.ElementInfo, error) {
-	pageSource, err := d.client.Source()
+	allElements, err := d.pageSourceElements()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get page source: %w", err)
-	}
-
-	allElements, err := ParsePageSource(pageSource)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse page source: %w", err)
+		return nil, err
 	}
 
 	return d.resolveRelativeSelector(sel, allElements)
@@ -557,6 +864,7 @@ func (d *Driver) resolveRelativeSelector(sel flow.Selector, allElements []*Parse
 	// Build base selector
 	baseSel := flow.Selector{
 		Text:      sel.Text,
+		TextRegex: sel.TextRegex,
 		ID:        sel.ID,
 		Width:     sel.Width,
 		Height:    sel.Height,
@@ -569,7 +877,7 @@ func (d *Driver) resolveRelativeSelector(sel flow.Selector, allElements []*Parse
 
 	// Get candidates
 	var candidates []*ParsedElement
-	if baseSel.Text != "" || baseSel.ID != "" || baseSel.Width > 0 || baseSel.Height > 0 {
+	if baseSel.Text != "" || baseSel.TextRegex != "" || baseSel.ID != "" || baseSel.Width > 0 || baseSel.Height > 0 || baseSel.HasStateFilter() {
 		candidates = FilterBySelector(allElements, baseSel)
 	} else {
 		candidates = allElements
@@ -603,42 +911,105 @@ func (d *Driver) resolveRelativeSelector(sel flow.Selector, allElements []*Parse
 		return nil, fmt.Errorf("no elements match selector")
 	}
 
-	// Prioritize clickable/interactive elements
-	candidates = SortClickableFirst(candidates)
-
-	// Select element
-	var selected *ParsedElement
+	candidates, selected := pickRelative(candidates, filterType)
 	if sel.Index != "" {
-		idx := 0
-		if i, err := strconv.Atoi(sel.Index); err == nil {
-			if i < 0 {
-				i = len(candidates) + i
-			}
-			if i >= 0 && i < len(candidates) {
-				idx = i
-			}
+		idx, err := sel.IndexValue()
+		if err != nil {
+			return nil, err
+		}
+		if selected, err = SelectByIndex(candidates, idx); err != nil {
+			return nil, err
 		}
-		selected = candidates[idx]
-	} else {
-		selected = DeepestMatchingElement(candidates)
 	}
 
 	return &core.ElementInfo{
-		Text:    selected.Label,
-		Bounds:  selected.Bounds,
-		Enabled: selected.Enabled,
-		Visible: selected.Displayed,
+		Text:     selected.Label,
+		Bounds:   selected.Bounds,
+		Enabled:  selected.Enabled,
+		Checked:  isChecked(selected),
+		Selected: selected.Selected,
+		Focused:  selected.Focused,
+		Visible:  selected.Displayed,
 	}, nil
 }
 
-// findElementByPageSourceOnce performs a single page source search.
-func (d *Driver) findElementByPageSourceOnce(sel flow.Selector) (*core.ElementInfo, error) {
-	pageSource, err := d.client.Source()
+// clearStepCache forgets the page source and window size cached for the
+// previous step.
+func (d *Driver) clearStepCache() {
+	d.source = nil
+	d.screenW, d.screenH = 0, 0
+}
+
+// invalidateSource makes the next pageSourceElements call refetch. Polling
+// loops call it before retrying so they see a fresh screen.
+func (d *Driver) invalidateSource() {
+	d.source = nil
+}
+
+// windowSize returns the screen size, fetched once per step.
+func (d *Driver) windowSize() (width, height int, err error) {
+	if d.screenW > 0 && d.screenH > 0 {
+		return d.screenW, d.screenH, nil
+	}
+	width, height, err = d.client.WindowSize()
+	if err == nil {
+		d.screenW, d.screenH = width, height
+	}
+	return width, height, err
+}
+
+// sourceParseRetries is how many times a malformed page source is refetched
+// before the parse error is returned. /source occasionally comes back truncated
+// while the hierarchy is changing.
+const sourceParseRetries = 2
+
+// pageSourceElements fetches and parses the page source, refetching when the XML
+// is malformed. Fetch errors and other parse failures are returned immediately.
+// The result is reused until a request changes the screen, the step ends or
+// invalidateSource is called, so several lookups on one screen cost one /source.
+func (d *Driver) pageSourceElements() ([]*ParsedElement, error) {
+	if d.source != nil && d.sourceAt == d.client.uiChanges {
+		return d.source, nil
+	}
+	elements, err := d.fetchPageSourceElements()
 	if err != nil {
 		return nil, err
 	}
+	d.source, d.sourceAt = elements, d.client.uiChanges
+	return elements, nil
+}
+
+// fetchPageSourceElements does the uncached work of pageSourceElements.
+func (d *Driver) fetchPageSourceElements() ([]*ParsedElement, error) {
+	var parseErr error
+	for attempt := 0; attempt <= sourceParseRetries; attempt++ {
+		pageSource, err := d.client.Source()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get page source: %w", err)
+		}
 
-	allElements, err := ParsePageSource(pageSource)
+		elements, err := ParsePageSource(pageSource)
+		if err == nil {
+			return elements, nil
+		}
+		if !isXMLSyntaxError(err) {
+			return nil, fmt.Errorf("failed to parse page source: %w", err)
+		}
+		parseErr = err
+	}
+	return nil, fmt.Errorf("failed to parse page source after %d attempts: %w", sourceParseRetries+1, parseErr)
+}
+
+// isXMLSyntaxError reports whether err comes from malformed or truncated XML,
+// as opposed to a well-formed document with no usable hierarchy.
+func isXMLSyntaxError(err error) bool {
+	var syntaxErr *xml.SyntaxError
+	return errors.As(err, &syntaxErr) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// findElementByPageSourceOnce performs a single page source search.
+func (d *Driver) findElementByPageSourceOnce(sel flow.Selector) (*core.ElementInfo, error) {
+	allElements, err := d.pageSourceElements()
 	if err != nil {
 		return nil, err
 	}
@@ -655,16 +1026,28 @@ func (d *Driver) findElementByPageSourceOnce(sel flow.Selector) (*core.ElementIn
 	if selected == nil {
 		selected = candidates[0]
 	}
+	if sel.Index != "" {
+		idx, err := sel.IndexValue()
+		if err != nil {
+			return nil, err
+		}
+		if selected, err = SelectByIndex(candidates, idx); err != nil {
+			return nil, err
+		}
+	}
 
 	// If element isn't a clickable type, try to find a clickable parent
 	// This handles patterns where text labels aren't interactive but their containers are
 	clickableElem := GetClickableElement(selected)
 
 	return &core.ElementInfo{
-		Text:    selected.Label,
-		Bounds:  clickableElem.Bounds,
-		Enabled: selected.Enabled,
-		Visible: selected.Displayed,
+		Text:     selected.Label,
+		Bounds:   clickableElem.Bounds,
+		Enabled:  selected.Enabled,
+		Checked:  isChecked(selected),
+		Selected: selected.Selected,
+		Focused:  selected.Focused,
+		Visible:  selected.Displayed,
 	}, nil
 }
 
@@ -682,6 +1065,22 @@ const (
 	filterInsideOf
 )
 
+// positional reports whether the filter selects by direction from the anchor.
+func (t relativeFilterType) positional() bool {
+	return t == filterBelow || t == filterAbove || t == filterLeftOf || t == filterRightOf
+}
+
+// pickRelative returns the candidates in index order and the default match.
+// A positional filter's candidates are already nearest first; other relative
+// filters prefer clickable elements and the deepest match.
+func pickRelative(candidates []*ParsedElement, filterType relativeFilterType) ([]*ParsedElement, *ParsedElement) {
+	if filterType.positional() {
+		return candidates, candidates[0]
+	}
+	candidates = SortClickableFirst(candidates)
+	return candidates, DeepestMatchingElement(candidates)
+}
+
 // getRelativeFilter returns the anchor selector and filter type from a selector
 func getRelativeFilter(sel flow.Selector) (*flow.Selector, relativeFilterType) {
 	switch {
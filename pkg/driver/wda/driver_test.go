@@ -3,9 +3,13 @@ package wda
 import (
 	"context"
 	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -537,7 +541,7 @@ func TestExecuteSwipeWithPixelCoords(t *testing.T) {
 	}
 }
 
-// TestExecuteBack tests back command (should fail on iOS)
+// TestExecuteBack tests back command (swipes from the left edge on iOS)
 func TestExecuteBack(t *testing.T) {
 	server := mockWDAServerForDriver()
 	defer server.Close()
@@ -546,9 +550,8 @@ func TestExecuteBack(t *testing.T) {
 	step := &flow.BackStep{}
 	result := driver.Execute(step)
 
-	// iOS doesn't support back button
-	if result.Success {
-		t.Error("Expected failure for back on iOS")
+	if !result.Success {
+		t.Errorf("Expected success, got error: %v", result.Error)
 	}
 }
 
@@ -701,7 +704,7 @@ func TestExecuteCopyTextFrom(t *testing.T) {
 	}
 }
 
-// TestExecutePasteText tests text pasting (not supported on iOS via WDA)
+// TestExecutePasteText tests text pasting when the server has no pasteboard
 func TestExecutePasteText(t *testing.T) {
 	server := mockWDAServerForDriver()
 	defer server.Close()
@@ -710,9 +713,9 @@ func TestExecutePasteText(t *testing.T) {
 	step := &flow.PasteTextStep{}
 	result := driver.Execute(step)
 
-	// WDA doesn't support paste
+	// The mock server returns no pasteboard content
 	if result.Success {
-		t.Error("Expected failure for pasteText on iOS")
+		t.Error("Expected failure when the pasteboard is unreadable")
 	}
 }
 
@@ -879,6 +882,8 @@ func TestBuildStateFilter(t *testing.T) {
 	enabledFalse := false
 	selectedTrue := true
 	focusedTrue := true
+	checkedTrue := true
+	checkedFalse := false
 
 	tests := []struct {
 		sel      flow.Selector
@@ -888,6 +893,8 @@ func TestBuildStateFilter(t *testing.T) {
 		{flow.Selector{Enabled: &enabledFalse}, []string{"enabled == false"}},
 		{flow.Selector{Selected: &selectedTrue}, []string{"selected == true"}},
 		{flow.Selector{Focused: &focusedTrue}, []string{"hasFocus == true"}},
+		{flow.Selector{Checked: &checkedTrue}, []string{"(value == '1' OR selected == true)"}},
+		{flow.Selector{Checked: &checkedFalse}, []string{"value != '1' AND selected == false"}},
 		{flow.Selector{Enabled: &enabledTrue, Selected: &selectedTrue}, []string{"enabled == true", "selected == true"}},
 	}
 
@@ -1020,38 +1027,6 @@ func TestParsePercentageCoords(t *testing.T) {
 	}
 }
 
-// TestRandomHelpers tests random generation helpers
-func TestRandomHelpers(t *testing.T) {
-	// Test randomString
-	s := randomString(10)
-	if len(s) != 10 {
-		t.Errorf("Expected length 10, got %d", len(s))
-	}
-
-	// Test randomEmail
-	email := randomEmail()
-	if !strings.Contains(email, "@") {
-		t.Errorf("Expected email format, got '%s'", email)
-	}
-
-	// Test randomNumber
-	num := randomNumber(5)
-	if len(num) != 5 {
-		t.Errorf("Expected length 5, got %d", len(num))
-	}
-	for _, c := range num {
-		if c < '0' || c > '9' {
-			t.Errorf("Expected digit, got '%c'", c)
-		}
-	}
-
-	// Test randomPersonName
-	name := randomPersonName()
-	if !strings.Contains(name, " ") {
-		t.Errorf("Expected first and last name, got '%s'", name)
-	}
-}
-
 // TestSuccessResult tests success result creation
 func TestSuccessResult(t *testing.T) {
 	elem := &core.ElementInfo{Text: "Test"}
@@ -1189,6 +1164,110 @@ func TestScrollUntilVisibleDefaultDirection(t *testing.T) {
 	}
 }
 
+// countRequests wraps the server's handler and counts /source and
+// /window/size requests.
+func countRequests(server *httptest.Server) (source, windowSize *int32) {
+	source, windowSize = new(int32), new(int32)
+	handler := server.Config.Handler
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/source"):
+			atomic.AddInt32(source, 1)
+		case strings.HasSuffix(r.URL.Path, "/window/size"):
+			atomic.AddInt32(windowSize, 1)
+		}
+		handler.ServeHTTP(w, r)
+	})
+	return source, windowSize
+}
+
+// TestPageSourceCachedWithinStep tests that several lookups on an unchanged
+// screen share one /source request
+func TestPageSourceCachedWithinStep(t *testing.T) {
+	server := mockWDAServerForDriver()
+	defer server.Close()
+	sources, _ := countRequests(server)
+	driver := createTestDriver(server)
+
+	step := &flow.ExtendedWaitUntilStep{
+		Visible: []flow.Selector{
+			{TextRegex: "Log.*"},
+			{TextRegex: "Em.il"},
+			{TextRegex: "Disabled"},
+		},
+	}
+	result := driver.Execute(step)
+	if !result.Success {
+		t.Fatalf("Expected success, got: %s", result.Message)
+	}
+	// Without the cache each selector fetched the source (3 requests)
+	if got := atomic.LoadInt32(sources); got != 1 {
+		t.Errorf("Expected 1 /source request, got %d", got)
+	}
+
+	// The next step starts with an empty cache
+	driver.Execute(step)
+	if got := atomic.LoadInt32(sources); got != 2 {
+		t.Errorf("Expected 2 /source requests after second step, got %d", got)
+	}
+}
+
+// TestPageSourceCacheInvalidatedByUIChange tests that a request that may
+// change the screen drops the cached source, while element queries keep it
+func TestPageSourceCacheInvalidatedByUIChange(t *testing.T) {
+	server := mockWDAServerForDriver()
+	defer server.Close()
+	sources, _ := countRequests(server)
+	driver := createTestDriver(server)
+
+	if _, err := driver.pageSourceElements(); err != nil {
+		t.Fatalf("pageSourceElements failed: %v", err)
+	}
+	_, _ = driver.client.FindElements("accessibility id", "loginBtn")
+	if _, err := driver.pageSourceElements(); err != nil {
+		t.Fatalf("pageSourceElements failed: %v", err)
+	}
+	if got := atomic.LoadInt32(sources); got != 1 {
+		t.Errorf("Expected 1 /source request after element query, got %d", got)
+	}
+
+	if err := driver.client.Tap(10, 10); err != nil {
+		t.Fatalf("Tap failed: %v", err)
+	}
+	if _, err := driver.pageSourceElements(); err != nil {
+		t.Fatalf("pageSourceElements failed: %v", err)
+	}
+	if got := atomic.LoadInt32(sources); got != 2 {
+		t.Errorf("Expected 2 /source requests after tap, got %d", got)
+	}
+}
+
+// TestScrollUntilVisibleRequestCounts tests that each scroll still reads a
+// fresh source and the window size is fetched once per step
+func TestScrollUntilVisibleRequestCounts(t *testing.T) {
+	const scrolls = 3
+	server := mockWDAServerWithScrollElements(scrolls)
+	defer server.Close()
+	sources, windowSizes := countRequests(server)
+	driver := createTestDriver(server)
+
+	step := &flow.ScrollUntilVisibleStep{
+		Element:   flow.Selector{TextRegex: "Target.*"},
+		Direction: "down",
+		BaseStep:  flow.BaseStep{TimeoutMs: 10000},
+	}
+	result := driver.Execute(step)
+	if !result.Success {
+		t.Fatalf("Expected success, got: %s", result.Message)
+	}
+	if got := atomic.LoadInt32(windowSizes); got != 1 {
+		t.Errorf("Expected 1 /window/size request, got %d", got)
+	}
+	if got := atomic.LoadInt32(sources); got < scrolls+1 {
+		t.Errorf("Expected at least %d /source requests (one per screen), got %d", scrolls+1, got)
+	}
+}
+
 // mockWDAServerForWaitUntil creates a mock for waitUntil testing
 func mockWDAServerForWaitUntil(visibleAfterMs int) *httptest.Server {
 	startTime := time.Now()
@@ -1327,6 +1406,43 @@ func TestWaitUntilDefaultTimeout(t *testing.T) {
 	}
 }
 
+// TestWaitUntilAllSuccess tests waitUntilAll once every condition holds
+func TestWaitUntilAllSuccess(t *testing.T) {
+	server := mockWDAServerForWaitUntil(50)
+	defer server.Close()
+	driver := createTestDriver(server)
+
+	result := driver.Execute(&flow.ExtendedWaitUntilStep{
+		BaseStep:   flow.BaseStep{TimeoutMs: 5000},
+		Visible:    []flow.Selector{{Text: "WaitTarget"}},
+		NotVisible: []flow.Selector{{Text: "Spinner"}},
+	})
+	if !result.Success {
+		t.Errorf("Expected success, got: %s", result.Message)
+	}
+}
+
+// TestWaitUntilAllTimeout tests that waitUntilAll lists only the unmet selectors
+func TestWaitUntilAllTimeout(t *testing.T) {
+	server := mockWDAServerForWaitUntil(0)
+	defer server.Close()
+	driver := createTestDriver(server)
+
+	result := driver.Execute(&flow.ExtendedWaitUntilStep{
+		BaseStep: flow.BaseStep{TimeoutMs: 300},
+		Visible:  []flow.Selector{{Text: "WaitTarget"}, {Text: "Missing"}},
+	})
+	if result.Success {
+		t.Fatal("Expected timeout failure")
+	}
+	if !strings.Contains(result.Message, `visible text="Missing"`) {
+		t.Errorf("Expected unmet selector in message, got: %s", result.Message)
+	}
+	if strings.Contains(result.Message, "WaitTarget") {
+		t.Errorf("Met selector should not be reported, got: %s", result.Message)
+	}
+}
+
 // mockWDAServerForRelativeElements creates mock for relative selector testing
 func mockWDAServerForRelativeElements() *httptest.Server {
 	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -2173,6 +2289,367 @@ func TestSwipeInvalidDirection(t *testing.T) {
 	}
 }
 
+// sessionLossServer answers POST /session with new-session and rejects
+// /wda/pressButton on any other session as invalid, recording every request.
+func sessionLossServer(requests *[]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests = append(*requests, r.Method+" "+r.URL.Path)
+		if r.Method == "POST" && r.URL.Path == "/session" {
+			jsonResponse(w, map[string]interface{}{"value": map[string]interface{}{"sessionId": "new-session"}})
+			return
+		}
+		if strings.HasSuffix(r.URL.Path, "/wda/pressButton") && !strings.HasPrefix(r.URL.Path, "/session/new-session/") {
+			w.WriteHeader(http.StatusNotFound)
+			jsonResponse(w, map[string]interface{}{
+				"value": map[string]interface{}{"error": "invalid session id", "message": "Session does not exist"},
+			})
+			return
+		}
+		jsonResponse(w, map[string]interface{}{"value": nil})
+	}))
+}
+
+// TestExecuteRecoversInvalidSession tests that a step failing on a lost
+// session is retried once on a recreated session.
+func TestExecuteRecoversInvalidSession(t *testing.T) {
+	var requests []string
+	server := sessionLossServer(&requests)
+	defer server.Close()
+	driver := createTestDriver(server)
+	driver.client.caps = map[string]interface{}{"capabilities": map[string]interface{}{}}
+
+	result := driver.Execute(&flow.PressKeyStep{Key: "home"})
+
+	if !result.Success {
+		t.Fatalf("Expected success after recovery, got: %s", result.Message)
+	}
+	if driver.client.SessionID() != "new-session" {
+		t.Errorf("Expected new-session, got %q", driver.client.SessionID())
+	}
+	want := []string{
+		"POST /session/test-session/wda/pressButton",
+		"POST /session",
+		"POST /session/new-session/appium/settings",
+		"POST /session/new-session/wda/pressButton",
+	}
+	if strings.Join(requests, ",") != strings.Join(want, ",") {
+		t.Errorf("Requests = %v, want %v", requests, want)
+	}
+}
+
+// TestExecuteInvalidSessionFailMode tests that recovery can be turned off.
+func TestExecuteInvalidSessionFailMode(t *testing.T) {
+	var requests []string
+	server := sessionLossServer(&requests)
+	defer server.Close()
+	driver := createTestDriver(server)
+	driver.client.caps = map[string]interface{}{"capabilities": map[string]interface{}{}}
+	if err := driver.SetInvalidSessionMode(InvalidSessionFail); err != nil {
+		t.Fatal(err)
+	}
+
+	result := driver.Execute(&flow.PressKeyStep{Key: "home"})
+
+	if result.Success {
+		t.Fatal("Expected failure without recovery")
+	}
+	if !errors.Is(result.Error, ErrInvalidSession) {
+		t.Errorf("Expected ErrInvalidSession, got %v", result.Error)
+	}
+	if len(requests) != 1 {
+		t.Errorf("Expected no retry, got requests %v", requests)
+	}
+}
+
+// TestExecuteIgnoresStaleSessionLost tests that a lost session seen during an
+// earlier, passing step doesn't trigger recovery when a later step fails.
+func TestExecuteIgnoresStaleSessionLost(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		w.WriteHeader(http.StatusInternalServerError)
+		jsonResponse(w, map[string]interface{}{
+			"value": map[string]interface{}{"error": "unknown error", "message": "button not found"},
+		})
+	}))
+	defer server.Close()
+	driver := createTestDriver(server)
+	driver.client.caps = map[string]interface{}{"capabilities": map[string]interface{}{}}
+	driver.client.sessionLost = true
+
+	result := driver.Execute(&flow.PressKeyStep{Key: "home"})
+
+	if result.Success {
+		t.Fatal("Expected failure")
+	}
+	for _, req := range requests {
+		if req == "POST /session" {
+			t.Errorf("Expected no session recreation, got requests %v", requests)
+		}
+	}
+}
+
+// TestSetInvalidSessionModeRejectsUnknown tests mode validation.
+func TestSetInvalidSessionModeRejectsUnknown(t *testing.T) {
+	driver := &Driver{}
+	if err := driver.SetInvalidSessionMode("ignore"); err == nil {
+		t.Error("Expected error for unknown mode")
+	}
+}
+
+// staleSessionServer knows only new-session and, when openSession is set,
+// reports it in /status. Every request is recorded.
+func staleSessionServer(requests *[]string, openSession string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests = append(*requests, r.Method+" "+r.URL.Path)
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/session":
+			jsonResponse(w, map[string]interface{}{"value": map[string]interface{}{"sessionId": "new-session"}})
+		case r.URL.Path == "/status":
+			jsonResponse(w, map[string]interface{}{"value": map[string]interface{}{"ready": true}, "sessionId": openSession})
+		case strings.HasPrefix(r.URL.Path, "/session/new-session"), r.URL.Path == "/session/"+openSession && openSession != "":
+			jsonResponse(w, map[string]interface{}{"value": nil})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			jsonResponse(w, map[string]interface{}{
+				"value": map[string]interface{}{"error": "invalid session id", "message": "Session does not exist"},
+			})
+		}
+	}))
+}
+
+// TestEnsureSessionRecreatesStaleSession tests that a cached session WDA no
+// longer knows is replaced before it is used.
+func TestEnsureSessionRecreatesStaleSession(t *testing.T) {
+	var requests []string
+	server := staleSessionServer(&requests, "")
+	defer server.Close()
+	driver := createTestDriver(server)
+	driver.client.caps = map[string]interface{}{"capabilities": map[string]interface{}{}}
+
+	if err := driver.EnsureSession(); err != nil {
+		t.Fatalf("EnsureSession failed: %v", err)
+	}
+
+	if driver.client.SessionID() != "new-session" {
+		t.Errorf("Expected new-session, got %q", driver.client.SessionID())
+	}
+	if driver.client.takeSessionLost() {
+		t.Error("Expected the probe not to be reported as a lost session")
+	}
+	want := []string{
+		"GET /session/test-session",
+		"POST /session",
+		"POST /session/new-session/appium/settings",
+	}
+	if strings.Join(requests, ",") != strings.Join(want, ",") {
+		t.Errorf("Requests = %v, want %v", requests, want)
+	}
+}
+
+// TestEnsureSessionKeepsLiveSession tests that a live session is only probed.
+func TestEnsureSessionKeepsLiveSession(t *testing.T) {
+	var requests []string
+	server := staleSessionServer(&requests, "")
+	defer server.Close()
+	driver := createTestDriver(server)
+	driver.client.sessionID = "new-session"
+
+	if err := driver.EnsureSession(); err != nil {
+		t.Fatalf("EnsureSession failed: %v", err)
+	}
+	if len(requests) != 1 || requests[0] != "GET /session/new-session" {
+		t.Errorf("Expected a single probe, got %v", requests)
+	}
+}
+
+// TestEnsureSessionStaleFailMode tests that a stale session is reported
+// rather than recreated when invalid sessions fail.
+func TestEnsureSessionStaleFailMode(t *testing.T) {
+	var requests []string
+	server := staleSessionServer(&requests, "")
+	defer server.Close()
+	driver := createTestDriver(server)
+	driver.client.caps = map[string]interface{}{"capabilities": map[string]interface{}{}}
+	if err := driver.SetInvalidSessionMode(InvalidSessionFail); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := driver.EnsureSession(); err == nil {
+		t.Fatal("Expected an error for the stale session")
+	}
+	if len(requests) != 1 {
+		t.Errorf("Expected no recreation, got requests %v", requests)
+	}
+}
+
+// TestEnsureSessionAdoptsOpenSession tests that session reuse adopts the
+// session WDA reports in /status.
+func TestEnsureSessionAdoptsOpenSession(t *testing.T) {
+	var requests []string
+	server := staleSessionServer(&requests, "open-session")
+	defer server.Close()
+	driver := createTestDriver(server)
+	driver.client.sessionID = ""
+	driver.SetReuseSession(true)
+
+	if err := driver.EnsureSession(); err != nil {
+		t.Fatalf("EnsureSession failed: %v", err)
+	}
+	if driver.client.SessionID() != "open-session" {
+		t.Errorf("Expected open-session, got %q", driver.client.SessionID())
+	}
+	for _, req := range requests {
+		if req == "POST /session" {
+			t.Errorf("Expected no new session, got requests %v", requests)
+		}
+	}
+}
+
+// TestLaunchAppDropsStaleAdoptedSession tests that launchApp creates a new
+// session when an adopted one has gone stale and can't be recreated.
+func TestLaunchAppDropsStaleAdoptedSession(t *testing.T) {
+	var requests []string
+	server := staleSessionServer(&requests, "")
+	defer server.Close()
+	driver := createTestDriver(server)
+	driver.client.sessionID = "adopted-session"
+
+	result := driver.launchApp(&flow.LaunchAppStep{AppID: "com.test.app"})
+
+	if !result.Success {
+		t.Fatalf("Expected success, got: %s", result.Message)
+	}
+	if driver.client.SessionID() != "new-session" {
+		t.Errorf("Expected new-session, got %q", driver.client.SessionID())
+	}
+}
+
+// unexpectedAlertServer shows a system alert until /alert/dismiss is called;
+// /wda/pressButton fails while it is open. Every request is recorded.
+func unexpectedAlertServer(requests *[]string) *httptest.Server {
+	alertOpen := true
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests = append(*requests, r.Method+" "+r.URL.Path)
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/alert/text") && alertOpen:
+			jsonResponse(w, map[string]interface{}{"value": "Storage Almost Full"})
+		case strings.HasSuffix(r.URL.Path, "/alert/text"):
+			jsonResponse(w, map[string]interface{}{
+				"value": map[string]interface{}{"error": "no such alert", "message": "No alert is open"},
+			})
+		case strings.HasSuffix(r.URL.Path, "/alert/dismiss"):
+			alertOpen = false
+			jsonResponse(w, map[string]interface{}{"value": nil})
+		case strings.HasSuffix(r.URL.Path, "/wda/pressButton") && alertOpen:
+			w.WriteHeader(http.StatusInternalServerError)
+			jsonResponse(w, map[string]interface{}{
+				"value": map[string]interface{}{"error": "unexpected alert open", "message": "An alert is blocking the app"},
+			})
+		default:
+			jsonResponse(w, map[string]interface{}{"value": nil})
+		}
+	}))
+}
+
+// TestExecuteDismissesUnexpectedAlert tests that a step blocked by an alert
+// is retried once after the alert is dismissed.
+func TestExecuteDismissesUnexpectedAlert(t *testing.T) {
+	var requests []string
+	server := unexpectedAlertServer(&requests)
+	defer server.Close()
+	driver := createTestDriver(server)
+	driver.SetAutoDismissUnexpectedAlerts(true)
+
+	result := driver.Execute(&flow.PressKeyStep{Key: "home"})
+
+	if !result.Success {
+		t.Fatalf("Expected success after dismissing alert, got: %s", result.Message)
+	}
+	want := []string{
+		"POST /session/test-session/wda/pressButton",
+		"GET /session/test-session/alert/text",
+		"POST /session/test-session/alert/dismiss",
+		"POST /session/test-session/wda/pressButton",
+	}
+	if strings.Join(requests, ",") != strings.Join(want, ",") {
+		t.Errorf("Requests = %v, want %v", requests, want)
+	}
+}
+
+// TestExecuteUnexpectedAlertDisabled tests that alerts are left alone by default.
+func TestExecuteUnexpectedAlertDisabled(t *testing.T) {
+	var requests []string
+	server := unexpectedAlertServer(&requests)
+	defer server.Close()
+	driver := createTestDriver(server)
+
+	result := driver.Execute(&flow.PressKeyStep{Key: "home"})
+
+	if result.Success {
+		t.Fatal("Expected failure with the alert left open")
+	}
+	if len(requests) != 1 {
+		t.Errorf("Expected no alert handling, got requests %v", requests)
+	}
+}
+
+// TestExecuteFailureWithoutAlertNotRetried tests that a failure with no alert
+// on screen is not retried.
+func TestExecuteFailureWithoutAlertNotRetried(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		if strings.HasSuffix(r.URL.Path, "/alert/text") {
+			jsonResponse(w, map[string]interface{}{
+				"value": map[string]interface{}{"error": "no such alert", "message": "No alert is open"},
+			})
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		jsonResponse(w, map[string]interface{}{
+			"value": map[string]interface{}{"error": "unknown error", "message": "button press failed"},
+		})
+	}))
+	defer server.Close()
+	driver := createTestDriver(server)
+	driver.SetAutoDismissUnexpectedAlerts(true)
+
+	result := driver.Execute(&flow.PressKeyStep{Key: "home"})
+
+	if result.Success {
+		t.Fatal("Expected failure")
+	}
+	want := []string{
+		"POST /session/test-session/wda/pressButton",
+		"GET /session/test-session/alert/text",
+	}
+	if strings.Join(requests, ",") != strings.Join(want, ",") {
+		t.Errorf("Requests = %v, want %v", requests, want)
+	}
+}
+
+// TestExecuteAlertStepNotAutoDismissed tests that alert steps keep the alert
+// they are checking.
+func TestExecuteAlertStepNotAutoDismissed(t *testing.T) {
+	var requests []string
+	server := unexpectedAlertServer(&requests)
+	defer server.Close()
+	driver := createTestDriver(server)
+	driver.SetAutoDismissUnexpectedAlerts(true)
+
+	result := driver.Execute(&flow.AssertAlertTextStep{BaseStep: flow.BaseStep{TimeoutMs: 100}, Text: "Update Available"})
+
+	if result.Success {
+		t.Fatal("Expected assertAlertText mismatch to fail")
+	}
+	for _, req := range requests {
+		if strings.HasSuffix(req, "/alert/dismiss") {
+			t.Errorf("Expected alert to stay open, got requests %v", requests)
+		}
+	}
+}
+
 // TestPressKeyHome tests pressing home key
 func TestPressKeyHome(t *testing.T) {
 	server := mockWDAServerForDriver()
@@ -2277,16 +2754,105 @@ func TestOpenBrowserNoURL(t *testing.T) {
 	}
 }
 
-// TestBackCommand tests back command (iOS doesn't support)
+// backTestServer serves a 390x844 window and a page source with a "Back"
+// button, recording the back swipe and taps. The source changes after the
+// swipe only when swipeChangesPage is set.
+func backTestServer(swipeChangesPage bool, swipes, taps *[]map[string]float64) *httptest.Server {
+	swiped := false
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		path := r.URL.Path
+		switch {
+		case strings.HasSuffix(path, "/window/size"):
+			jsonResponse(w, map[string]interface{}{
+				"value": map[string]interface{}{"width": 390.0, "height": 844.0},
+			})
+		case strings.HasSuffix(path, "/wda/dragfromtoforduration"):
+			var body map[string]float64
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			*swipes = append(*swipes, body)
+			swiped = true
+			jsonResponse(w, map[string]interface{}{"status": 0})
+		case strings.HasSuffix(path, "/wda/tap"):
+			var body map[string]float64
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			*taps = append(*taps, body)
+			jsonResponse(w, map[string]interface{}{"status": 0})
+		case strings.HasSuffix(path, "/source"):
+			title := "Details"
+			if swiped && swipeChangesPage {
+				title = "Inbox"
+			}
+			jsonResponse(w, map[string]interface{}{
+				"value": fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<AppiumAUT>
+  <XCUIElementTypeApplication type="XCUIElementTypeApplication" name="TestApp" enabled="true" visible="true" x="0" y="0" width="390" height="844">
+    <XCUIElementTypeButton type="XCUIElementTypeButton" name="BackButton" label="Back" enabled="true" visible="true" x="0" y="50" width="80" height="44"/>
+    <XCUIElementTypeStaticText type="XCUIElementTypeStaticText" name="title" label="%s" enabled="true" visible="true" x="150" y="50" width="90" height="44"/>
+  </XCUIElementTypeApplication>
+</AppiumAUT>`, title),
+			})
+		default:
+			jsonResponse(w, map[string]interface{}{"status": 0})
+		}
+	}))
+}
+
+// TestBackCommand tests that back swipes from the left edge at mid-height
 func TestBackCommand(t *testing.T) {
-	server := mockWDAServerForDriver()
+	var swipes, taps []map[string]float64
+	server := backTestServer(false, &swipes, &taps)
 	defer server.Close()
 	driver := createTestDriver(server)
 
-	step := &flow.BackStep{}
-	result := driver.back(step)
-	if result.Success {
-		t.Error("Expected failure - back not supported on iOS")
+	result := driver.back(&flow.BackStep{})
+	if !result.Success {
+		t.Fatalf("Expected success, got: %s", result.Message)
+	}
+	if len(swipes) != 1 {
+		t.Fatalf("Expected 1 swipe, got %d", len(swipes))
+	}
+	swipe := swipes[0]
+	if swipe["fromX"] != backSwipeStartX || swipe["toX"] != 390*backSwipeEndFrac {
+		t.Errorf("Expected left-to-right edge swipe, got fromX=%v toX=%v", swipe["fromX"], swipe["toX"])
+	}
+	if swipe["fromY"] != 422 || swipe["toY"] != 422 {
+		t.Errorf("Expected swipe at mid-height 422, got fromY=%v toY=%v", swipe["fromY"], swipe["toY"])
+	}
+	if len(taps) != 0 {
+		t.Errorf("Expected no taps without tapBackButton, got %d", len(taps))
+	}
+}
+
+// TestBackTapBackButtonFallback tests that an unchanged page taps the "Back" button
+func TestBackTapBackButtonFallback(t *testing.T) {
+	var swipes, taps []map[string]float64
+	server := backTestServer(false, &swipes, &taps)
+	defer server.Close()
+	driver := createTestDriver(server)
+
+	result := driver.back(&flow.BackStep{TapBackButton: true})
+	if !result.Success {
+		t.Fatalf("Expected success, got: %s", result.Message)
+	}
+	if len(taps) != 1 || taps[0]["x"] != 40 || taps[0]["y"] != 72 {
+		t.Errorf("Expected one tap at the Back button center (40, 72), got %v", taps)
+	}
+}
+
+// TestBackTapBackButtonNotNeeded tests that a page change skips the fallback tap
+func TestBackTapBackButtonNotNeeded(t *testing.T) {
+	var swipes, taps []map[string]float64
+	server := backTestServer(true, &swipes, &taps)
+	defer server.Close()
+	driver := createTestDriver(server)
+
+	result := driver.back(&flow.BackStep{TapBackButton: true})
+	if !result.Success {
+		t.Fatalf("Expected success, got: %s", result.Message)
+	}
+	if len(taps) != 0 {
+		t.Errorf("Expected no fallback tap after the page changed, got %d", len(taps))
 	}
 }
 
@@ -2939,6 +3505,137 @@ func TestSwipeWithCoordinateError(t *testing.T) {
 }
 
 // TestAssertVisibleNotFound tests assertVisible when element not found
+func TestAssertVisibleTextRegex(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if strings.HasSuffix(r.URL.Path, "/source") {
+			jsonResponse(w, map[string]interface{}{
+				"value": `<?xml version="1.0" encoding="UTF-8"?>
+<AppiumAUT>
+  <XCUIElementTypeApplication type="XCUIElementTypeApplication" name="TestApp" enabled="true" visible="true" x="0" y="0" width="390" height="844">
+    <XCUIElementTypeStaticText type="XCUIElementTypeStaticText" label="Subtotal: $9.50" enabled="true" visible="true" x="0" y="100" width="390" height="40"/>
+    <XCUIElementTypeStaticText type="XCUIElementTypeStaticText" label="Total: $12.50" enabled="true" visible="true" x="0" y="200" width="390" height="40"/>
+  </XCUIElementTypeApplication>
+</AppiumAUT>`,
+			})
+			return
+		}
+		jsonResponse(w, map[string]interface{}{"status": 0})
+	}))
+	defer server.Close()
+
+	driver := createTestDriver(server)
+	driver.SetFindTimeout(500)
+
+	result := driver.Execute(&flow.AssertVisibleStep{Selector: flow.Selector{TextRegex: `Total: \$[0-9]+\.[0-9]{2}`}})
+	if !result.Success {
+		t.Fatalf("expected success, got: %s", result.Message)
+	}
+	if result.Element == nil || result.Element.Bounds.Y != 200 {
+		t.Errorf("expected the Total label, got %+v", result.Element)
+	}
+
+	result = driver.Execute(&flow.AssertVisibleStep{Selector: flow.Selector{TextRegex: "Total: ($"}})
+	if result.Success {
+		t.Fatal("expected failure for invalid textRegex")
+	}
+	if result.Error == nil || !strings.Contains(result.Error.Error(), `invalid textRegex "Total: ($"`) {
+		t.Errorf("expected invalid textRegex error, got: %v", result.Error)
+	}
+}
+
+func TestAssertVisibleIndex(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if strings.HasSuffix(r.URL.Path, "/source") {
+			jsonResponse(w, map[string]interface{}{
+				"value": `<?xml version="1.0" encoding="UTF-8"?>
+<AppiumAUT>
+  <XCUIElementTypeApplication type="XCUIElementTypeApplication" name="TestApp" enabled="true" visible="true" x="0" y="0" width="390" height="844">
+    <XCUIElementTypeButton type="XCUIElementTypeButton" label="Delete" enabled="true" visible="true" x="300" y="500" width="80" height="40"/>
+    <XCUIElementTypeButton type="XCUIElementTypeButton" label="Delete" enabled="true" visible="true" x="300" y="100" width="80" height="40"/>
+  </XCUIElementTypeApplication>
+</AppiumAUT>`,
+			})
+			return
+		}
+		jsonResponse(w, map[string]interface{}{"status": 0})
+	}))
+	defer server.Close()
+
+	driver := createTestDriver(server)
+	driver.SetFindTimeout(300)
+
+	result := driver.Execute(&flow.AssertVisibleStep{Selector: flow.Selector{Text: "Delete", Index: "1"}})
+	if !result.Success {
+		t.Fatalf("expected success, got: %s", result.Message)
+	}
+	if result.Element == nil || result.Element.Bounds.Y != 500 {
+		t.Errorf("expected the lower Delete button, got %+v", result.Element)
+	}
+
+	result = driver.Execute(&flow.AssertVisibleStep{
+		BaseStep: flow.BaseStep{TimeoutMs: 300},
+		Selector: flow.Selector{Text: "Delete", Index: "2"},
+	})
+	if result.Success {
+		t.Fatal("expected failure for out-of-range index")
+	}
+	if result.Error == nil || !strings.Contains(result.Error.Error(), "index 2 out of range: 2 elements match") {
+		t.Errorf("expected match count in error, got: %v", result.Error)
+	}
+}
+
+func TestAssertVisibleState(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if strings.HasSuffix(r.URL.Path, "/source") {
+			jsonResponse(w, map[string]interface{}{
+				"value": `<?xml version="1.0" encoding="UTF-8"?>
+<AppiumAUT>
+  <XCUIElementTypeApplication type="XCUIElementTypeApplication" name="TestApp" enabled="true" visible="true" x="0" y="0" width="390" height="844">
+    <XCUIElementTypeSwitch type="XCUIElementTypeSwitch" label="Notifications" value="1" enabled="true" visible="true" x="0" y="100" width="390" height="40"/>
+    <XCUIElementTypeButton type="XCUIElementTypeButton" label="Submit" enabled="false" visible="true" x="0" y="200" width="390" height="40"/>
+  </XCUIElementTypeApplication>
+</AppiumAUT>`,
+			})
+			return
+		}
+		jsonResponse(w, map[string]interface{}{"status": 0})
+	}))
+	defer server.Close()
+
+	driver := createTestDriver(server)
+	driver.SetFindTimeout(300)
+	on, off := true, false
+
+	result := driver.Execute(&flow.AssertVisibleStep{
+		Selector: flow.Selector{Text: "Notifications", Checked: &on},
+	})
+	if !result.Success {
+		t.Fatalf("expected success, got: %s", result.Message)
+	}
+
+	result = driver.Execute(&flow.AssertVisibleStep{
+		BaseStep: flow.BaseStep{TimeoutMs: 300},
+		Selector: flow.Selector{Text: "Submit", Enabled: &on},
+	})
+	if result.Success {
+		t.Fatal("expected failure for disabled button")
+	}
+	if want := "element 'Submit' is visible but enabled=false (expected true)"; result.Message != want {
+		t.Errorf("message=%q, want %q", result.Message, want)
+	}
+
+	result = driver.Execute(&flow.AssertVisibleStep{
+		BaseStep: flow.BaseStep{TimeoutMs: 300},
+		Selector: flow.Selector{Text: "Notifications", Checked: &off},
+	})
+	if result.Success || !strings.Contains(result.Message, "checked=true (expected false)") {
+		t.Errorf("expected checked mismatch, got: %s", result.Message)
+	}
+}
+
 func TestAssertVisibleNotFound(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
@@ -3625,6 +4322,63 @@ func TestDoubleTapOnError(t *testing.T) {
 }
 
 // TestLongPressOnError tests longPressOn when longPress fails
+func TestLongPressOnDurationAndPoint(t *testing.T) {
+	tests := []struct {
+		name    string
+		step    *flow.LongPressOnStep
+		want    map[string]float64
+		wantMsg string
+	}{
+		{"default duration at center", &flow.LongPressOnStep{Selector: flow.Selector{Text: "Button"}},
+			map[string]float64{"x": 60, "y": 45, "duration": 1}, "for 1000ms"},
+		{"point within element", &flow.LongPressOnStep{Selector: flow.Selector{Text: "Button"}, Point: "25%, 50%", DurationMs: 2500},
+			map[string]float64{"x": 35, "y": 45, "duration": 2.5}, "for 2500ms"},
+		{"screen point", &flow.LongPressOnStep{Point: "50%, 25%", DurationMs: 1500},
+			map[string]float64{"x": 195, "y": 211, "duration": 1.5}, "Long pressed at (195, 211) for 1500ms"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body map[string]float64
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				switch {
+				case strings.HasSuffix(r.URL.Path, "/source"):
+					jsonResponse(w, map[string]interface{}{
+						"value": `<?xml version="1.0"?>
+<AppiumAUT>
+  <XCUIElementTypeButton name="btn" label="Button" x="10" y="20" width="100" height="50"/>
+</AppiumAUT>`,
+					})
+				case strings.HasSuffix(r.URL.Path, "/window/size"):
+					jsonResponse(w, map[string]interface{}{"value": map[string]interface{}{"width": 390.0, "height": 844.0}})
+				case strings.Contains(r.URL.Path, "/touchAndHold"):
+					_ = json.NewDecoder(r.Body).Decode(&body)
+					jsonResponse(w, map[string]interface{}{"status": 0})
+				default:
+					jsonResponse(w, map[string]interface{}{"status": 0})
+				}
+			}))
+			defer server.Close()
+			driver := createTestDriver(server)
+
+			result := driver.Execute(tt.step)
+
+			if !result.Success {
+				t.Fatalf("Expected success, got: %s", result.Message)
+			}
+			for k, v := range tt.want {
+				if body[k] != v {
+					t.Errorf("touchAndHold %s = %v, want %v (body %v)", k, body[k], v, body)
+				}
+			}
+			if !strings.Contains(result.Message, tt.wantMsg) {
+				t.Errorf("Expected %q in message, got: %s", tt.wantMsg, result.Message)
+			}
+		})
+	}
+}
+
 func TestLongPressOnError(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
@@ -3835,6 +4589,33 @@ func TestResolveRelativeSelectorAllElements(t *testing.T) {
 	}
 }
 
+// TestResolveRelativeSelectorPicksClosest tests that the match nearest the anchor wins
+func TestResolveRelativeSelectorPicksClosest(t *testing.T) {
+	elements, err := ParsePageSource(`<?xml version="1.0" encoding="UTF-8"?>
+<AppiumAUT>
+  <XCUIElementTypeApplication type="XCUIElementTypeApplication" name="TestApp" enabled="true" visible="true" x="0" y="0" width="390" height="844">
+    <XCUIElementTypeStaticText type="XCUIElementTypeStaticText" label="Title" enabled="true" visible="true" x="20" y="100" width="100" height="30"/>
+    <XCUIElementTypeButton type="XCUIElementTypeButton" label="Delete" enabled="true" visible="true" x="20" y="600" width="100" height="40"/>
+    <XCUIElementTypeStaticText type="XCUIElementTypeStaticText" label="Delete" enabled="true" visible="true" x="20" y="150" width="100" height="30"/>
+  </XCUIElementTypeApplication>
+</AppiumAUT>`)
+	if err != nil {
+		t.Fatalf("ParsePageSource failed: %v", err)
+	}
+	driver := &Driver{}
+
+	info, err := driver.resolveRelativeSelector(flow.Selector{
+		Text:  "Delete",
+		Below: &flow.Selector{Text: "Title"},
+	}, elements)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if info.Bounds.Y != 150 {
+		t.Errorf("Expected closest Delete at y=150, got y=%d", info.Bounds.Y)
+	}
+}
+
 // TestFindElementRelativeParseError tests findElementRelative when parse fails
 func TestFindElementRelativeParseError(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -4024,49 +4805,35 @@ func TestContainsDescendantsFilter(t *testing.T) {
 	}
 }
 
-// TestSortByDistanceYDirect tests sortByDistanceY function
-func TestSortByDistanceYDirect(t *testing.T) {
+// TestFilterAboveUsesCenter tests that partially overlapping elements count by center
+func TestFilterAboveUsesCenter(t *testing.T) {
+	anchor := &ParsedElement{Label: "Anchor", Bounds: core.Bounds{X: 0, Y: 100, Width: 100, Height: 50}}
 	elements := []*ParsedElement{
-		{Bounds: core.Bounds{Y: 100}},
-		{Bounds: core.Bounds{Y: 50}},
-		{Bounds: core.Bounds{Y: 200}},
+		{Label: "Overlapping", Bounds: core.Bounds{X: 0, Y: 70, Width: 100, Height: 40}},
+		{Label: "Screen", Bounds: core.Bounds{X: 0, Y: 0, Width: 390, Height: 844}},
+		anchor,
 	}
 
-	anchorY := 60
-
-	// sortByDistanceY sorts in place
-	sortByDistanceY(elements, anchorY)
+	result := FilterAbove(elements, anchor)
 
-	if len(elements) != 3 {
-		t.Errorf("Expected 3 elements, got %d", len(elements))
-	}
-	// Should be sorted by distance from anchorY=60
-	// Element at Y=50 is closest (dist=-10), then Y=100 (dist=40), then Y=200 (dist=140)
-	if elements[0].Bounds.Y != 50 {
-		t.Errorf("Expected first element Y=50, got Y=%d", elements[0].Bounds.Y)
+	if len(result) != 1 || result[0].Label != "Overlapping" {
+		t.Fatalf("Expected only Overlapping above anchor, got %d elements", len(result))
 	}
 }
 
-// TestSortByDistanceXDirect tests sortByDistanceX function
-func TestSortByDistanceXDirect(t *testing.T) {
+// TestFilterLeftOfOrdersByCenterDistance tests closest-first ordering by center distance
+func TestFilterLeftOfOrdersByCenterDistance(t *testing.T) {
+	anchor := &ParsedElement{Label: "Anchor", Bounds: core.Bounds{X: 300, Y: 100, Width: 50, Height: 50}}
 	elements := []*ParsedElement{
-		{Bounds: core.Bounds{X: 100}},
-		{Bounds: core.Bounds{X: 50}},
-		{Bounds: core.Bounds{X: 200}},
+		{Label: "Offset", Bounds: core.Bounds{X: 240, Y: 500, Width: 50, Height: 50}},
+		{Label: "Level", Bounds: core.Bounds{X: 150, Y: 100, Width: 50, Height: 50}},
+		anchor,
 	}
 
-	anchorX := 60
-
-	// sortByDistanceX sorts in place
-	sortByDistanceX(elements, anchorX)
+	result := FilterLeftOf(elements, anchor)
 
-	if len(elements) != 3 {
-		t.Errorf("Expected 3 elements, got %d", len(elements))
-	}
-	// Should be sorted by distance from anchorX=60
-	// Element at X=50 is closest (dist=-10), then X=100 (dist=40), then X=200 (dist=140)
-	if elements[0].Bounds.X != 50 {
-		t.Errorf("Expected first element X=50, got X=%d", elements[0].Bounds.X)
+	if len(result) != 2 || result[0].Label != "Level" {
+		t.Fatalf("Expected Level first, got %v", result)
 	}
 }
 
@@ -5228,9 +5995,9 @@ func TestWaitForAnimationToEndDefaultTimeout(t *testing.T) {
 	if !result.Success {
 		t.Errorf("Expected success, got error: %v", result.Error)
 	}
-	// waitForAnimationToEnd is a placeholder - verify warning message
-	if result.Message == "" {
-		t.Error("Expected warning message")
+	// The mock serves the same screenshot every time, so it settles at once
+	if !strings.Contains(result.Message, "Animation ended") {
+		t.Errorf("Expected settled message, got: %s", result.Message)
 	}
 }
 
@@ -5358,6 +6125,142 @@ func TestScrollUntilVisibleMaxScrolls(t *testing.T) {
 	}
 }
 
+// TestScrollUntilVisibleMaxScrollsCap tests that MaxScrolls caps the number of
+// swipes and overrides the timeout-based estimate
+func TestScrollUntilVisibleMaxScrollsCap(t *testing.T) {
+	var durations []float64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if strings.HasSuffix(r.URL.Path, "/source") {
+			jsonResponse(w, map[string]interface{}{
+				"value": `<XCUIElementTypeApplication></XCUIElementTypeApplication>`,
+			})
+			return
+		}
+		if strings.Contains(r.URL.Path, "/window/size") {
+			jsonResponse(w, map[string]interface{}{
+				"value": map[string]interface{}{"width": 1080.0, "height": 1920.0},
+			})
+			return
+		}
+		if strings.Contains(r.URL.Path, "/dragfromtoforduration") {
+			var body map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&body)
+			durations = append(durations, body["duration"].(float64))
+			jsonResponse(w, map[string]interface{}{"status": 0})
+			return
+		}
+		jsonResponse(w, map[string]interface{}{"status": 0})
+	}))
+	defer server.Close()
+	driver := createTestDriver(server)
+
+	step := &flow.ScrollUntilVisibleStep{
+		Element:    flow.Selector{Text: "NotFound"},
+		BaseStep:   flow.BaseStep{TimeoutMs: 30000},
+		MaxScrolls: 2,
+		Speed:      "slow",
+	}
+	result := driver.scrollUntilVisible(step)
+
+	if result.Success {
+		t.Fatal("Expected failure when element not found after max scrolls")
+	}
+	if len(durations) != 2 {
+		t.Errorf("Expected exactly 2 scrolls, got %d", len(durations))
+	}
+	if !strings.Contains(result.Message, "after 2 scrolls") {
+		t.Errorf("Expected scroll count in message, got: %s", result.Message)
+	}
+	for _, d := range durations {
+		if d != 0.8 {
+			t.Errorf("Expected slow swipe duration 0.8s, got %v", d)
+		}
+	}
+}
+
+// scrollTargetServer serves a 400x800 window whose page source holds a
+// "Target" button at y (and at movedY once a swipe has been made), recording
+// each swipe body
+func scrollTargetServer(y, movedY int, swipes *[]map[string]interface{}) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if strings.HasSuffix(r.URL.Path, "/source") {
+			top := y
+			if len(*swipes) > 0 {
+				top = movedY
+			}
+			jsonResponse(w, map[string]interface{}{
+				"value": fmt.Sprintf(`<XCUIElementTypeApplication>
+					<XCUIElementTypeButton label="Target" x="0" y="%d" width="400" height="40" enabled="true" visible="true"/>
+				</XCUIElementTypeApplication>`, top),
+			})
+			return
+		}
+		if strings.Contains(r.URL.Path, "/window/size") {
+			jsonResponse(w, map[string]interface{}{
+				"value": map[string]interface{}{"width": 400.0, "height": 800.0},
+			})
+			return
+		}
+		if strings.Contains(r.URL.Path, "/dragfromtoforduration") {
+			var body map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&body)
+			*swipes = append(*swipes, body)
+		}
+		jsonResponse(w, map[string]interface{}{"status": 0})
+	}))
+}
+
+// TestScrollUntilVisibleVisibilityPercentage tests that a partly visible
+// element is scrolled further until it meets the threshold
+func TestScrollUntilVisibleVisibilityPercentage(t *testing.T) {
+	var swipes []map[string]interface{}
+	server := scrollTargetServer(780, 400, &swipes) // 50% visible, then fully
+	defer server.Close()
+	driver := createTestDriver(server)
+
+	result := driver.scrollUntilVisible(&flow.ScrollUntilVisibleStep{
+		Element:              flow.Selector{Text: "Target"},
+		VisibilityPercentage: 100,
+		MaxScrolls:           3,
+	})
+
+	if !result.Success {
+		t.Fatalf("Expected success, got: %s", result.Message)
+	}
+	if len(swipes) != 1 {
+		t.Errorf("Expected 1 scroll, got %d", len(swipes))
+	}
+	if !strings.Contains(result.Message, "100% visible") {
+		t.Errorf("Expected achieved visibility in message, got: %s", result.Message)
+	}
+}
+
+// TestScrollUntilVisibleCenterElement tests the final centering drag
+func TestScrollUntilVisibleCenterElement(t *testing.T) {
+	var swipes []map[string]interface{}
+	server := scrollTargetServer(700, 380, &swipes)
+	defer server.Close()
+	driver := createTestDriver(server)
+
+	result := driver.scrollUntilVisible(&flow.ScrollUntilVisibleStep{
+		Element:       flow.Selector{Text: "Target"},
+		CenterElement: true,
+	})
+
+	if !result.Success {
+		t.Fatalf("Expected success, got: %s", result.Message)
+	}
+	if len(swipes) != 1 {
+		t.Fatalf("Expected 1 centering drag, got %d", len(swipes))
+	}
+	// Element center (720) is 320pt below the middle (400): drag up by 320
+	if swipes[0]["fromY"] != 400.0 || swipes[0]["toY"] != 80.0 || swipes[0]["duration"] != centerDragSec {
+		t.Errorf("Unexpected centering drag: %v", swipes[0])
+	}
+}
+
 // TestTapOnWithSelectorParseError tests tapOn when page source parsing fails
 func TestTapOnWithSelectorParseError(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -5756,3 +6659,79 @@ func TestDriverAlertActionField(t *testing.T) {
 		t.Errorf("Expected 'dismiss', got '%s'", driver.alertAction)
 	}
 }
+
+// sequencedSourceServer serves each page source in turn (repeating the last)
+// and counts /source requests.
+func sequencedSourceServer(calls *int, sources ...string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/source") {
+			i := *calls
+			*calls++
+			if i >= len(sources) {
+				i = len(sources) - 1
+			}
+			jsonResponse(w, map[string]interface{}{"value": sources[i]})
+			return
+		}
+		jsonResponse(w, map[string]interface{}{"status": 0})
+	}))
+}
+
+const (
+	retrySourceValid = `<?xml version="1.0" encoding="UTF-8"?><AppiumAUT>` +
+		`<XCUIElementTypeApplication type="XCUIElementTypeApplication" name="App" enabled="true" visible="true" x="0" y="0" width="390" height="844">` +
+		`<XCUIElementTypeButton type="XCUIElementTypeButton" name="loginBtn" label="Login" enabled="true" visible="true" x="50" y="100" width="290" height="50"/>` +
+		`</XCUIElementTypeApplication></AppiumAUT>`
+	retrySourceTruncated = `<?xml version="1.0" encoding="UTF-8"?><AppiumAUT>` +
+		`<XCUIElementTypeApplication type="XCUIElementTypeApplication" name="App" enabled="true" visible="true" x="0" y="0" width="390" height="844">` +
+		`<XCUIElementTypeButton type="XCUIElementTypeButton" name="log`
+)
+
+// TestPageSourceElementsRetriesMalformedSource tests that a truncated source is refetched
+func TestPageSourceElementsRetriesMalformedSource(t *testing.T) {
+	calls := 0
+	server := sequencedSourceServer(&calls, retrySourceTruncated, retrySourceValid)
+	defer server.Close()
+	driver := createTestDriver(server)
+
+	info, err := driver.findElementByPageSourceOnce(flow.Selector{Text: "Login"})
+	if err != nil {
+		t.Fatalf("Expected recovery after malformed source, got: %v", err)
+	}
+	if info == nil {
+		t.Fatal("Expected Login element")
+	}
+	if calls != 2 {
+		t.Errorf("Expected 2 source fetches, got %d", calls)
+	}
+}
+
+// TestPageSourceElementsGivesUp tests the retry bound on persistently malformed source
+func TestPageSourceElementsGivesUp(t *testing.T) {
+	calls := 0
+	server := sequencedSourceServer(&calls, retrySourceTruncated)
+	defer server.Close()
+	driver := createTestDriver(server)
+
+	if _, err := driver.pageSourceElements(); err == nil {
+		t.Fatal("Expected error when source stays malformed")
+	}
+	if calls != sourceParseRetries+1 {
+		t.Errorf("Expected %d source fetches, got %d", sourceParseRetries+1, calls)
+	}
+}
+
+// TestPageSourceElementsEmptyNotRetried tests that a well-formed empty source is not refetched
+func TestPageSourceElementsEmptyNotRetried(t *testing.T) {
+	calls := 0
+	server := sequencedSourceServer(&calls, `<?xml version="1.0" encoding="UTF-8"?><AppiumAUT></AppiumAUT>`)
+	defer server.Close()
+	driver := createTestDriver(server)
+
+	if _, err := driver.pageSourceElements(); err == nil {
+		t.Fatal("Expected no-elements error for empty source")
+	}
+	if calls != 1 {
+		t.Errorf("Expected a single source fetch, got %d", calls)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/xml"
 	"fmt"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -23,6 +24,7 @@ type ParsedElement struct {
 	Displayed        bool // visible
 	Selected         bool
 	Focused          bool
+	Unhittable       bool // WDA reported hittable="false"
 	Children         []*ParsedElement
 	Parent           *ParsedElement // parent element for clickable lookup
 	Depth            int
@@ -88,6 +90,8 @@ func ParsePageSource(xmlData string) ([]*ParsedElement, error) {
 						elem.Selected = attr.Value == "true"
 					case "focused":
 						elem.Focused = attr.Value == "true"
+					case "hittable":
+						elem.Unhittable = attr.Value == "false"
 					case "placeholderValue":
 						elem.PlaceholderValue = attr.Value
 					case "x":
@@ -141,7 +145,9 @@ func ParsePageSource(xmlData string) ([]*ParsedElement, error) {
 		}
 	}
 
-	if parseErr != nil && len(elements) == 0 { //nolint:gosimple // parseErr distinguishes parse failure from empty source
+	// Truncated documents still yield the elements read so far; treat them as
+	// invalid rather than returning a partial hierarchy
+	if parseErr != nil {
 		return nil, parseErr
 	}
 
@@ -177,6 +183,13 @@ func FilterBySelector(elements []*ParsedElement, sel flow.Selector) []*ParsedEle
 	return result
 }
 
+// isChecked reports the on state of a switch, toggle or checkbox. iOS has no
+// checked attribute: these report value "1" when on, and checkbox-style
+// buttons report selected.
+func isChecked(elem *ParsedElement) bool {
+	return elem.Value == "1" || elem.Selected
+}
+
 func matchesSelector(elem *ParsedElement, sel flow.Selector) bool {
 	// Text matching - check label, name, value, and placeholderValue
 	if sel.Text != "" {
@@ -184,6 +197,9 @@ func matchesSelector(elem *ParsedElement, sel flow.Selector) bool {
 			return false
 		}
 	}
+	if sel.TextRegex != "" && !sel.MatchesTextRegex(elem.Label, elem.Name, elem.Value, elem.PlaceholderValue) {
+		return false
+	}
 
 	// ID matching (accessibility identifier)
 	if sel.ID != "" {
@@ -216,10 +232,40 @@ func matchesSelector(elem *ParsedElement, sel flow.Selector) bool {
 	if sel.Focused != nil && elem.Focused != *sel.Focused {
 		return false
 	}
+	if sel.Checked != nil && isChecked(elem) != *sel.Checked {
+		return false
+	}
+
+	// Structural filters (position among the parent's children)
+	if sel.HasStructuralSelector() && !matchesStructure(elem, sel) {
+		return false
+	}
 
 	return true
 }
 
+// matchesStructure checks the element's position among its parent's children.
+// A root element (no parent) has index 0 and no siblings.
+func matchesStructure(elem *ParsedElement, sel flow.Selector) bool {
+	index, siblings := 0, 0
+	if elem.Parent != nil {
+		siblings = len(elem.Parent.Children) - 1
+		for i, child := range elem.Parent.Children {
+			if child == elem {
+				index = i
+				break
+			}
+		}
+	}
+	if sel.ChildIndex != nil && index != *sel.ChildIndex {
+		return false
+	}
+	if sel.SiblingCount != nil && siblings != *sel.SiblingCount {
+		return false
+	}
+	return true
+}
+
 // withinTolerance checks if actual is within tolerance of expected.
 func withinTolerance(actual, expected, tolerance int) bool {
 	diff := actual - expected
@@ -306,74 +352,38 @@ func looksLikeRegex(text string) bool {
 
 // Position filter functions
 
-// FilterBelow returns elements below the anchor element.
+// FilterBelow returns elements below the anchor (see core.FilterBySide).
 func FilterBelow(elements []*ParsedElement, anchor *ParsedElement) []*ParsedElement {
-	anchorBottom := anchor.Bounds.Y + anchor.Bounds.Height
-	var result []*ParsedElement
-
-	for _, elem := range elements {
-		if elem.Bounds.Y >= anchorBottom {
-			result = append(result, elem)
-		}
-	}
-
-	sortByDistanceY(result, anchorBottom)
-	return result
+	return core.FilterBySide(elements, anchor, core.SideBelow, elementBounds)
 }
 
-// FilterAbove returns elements above the anchor element.
+// FilterAbove returns elements above the anchor.
 func FilterAbove(elements []*ParsedElement, anchor *ParsedElement) []*ParsedElement {
-	anchorTop := anchor.Bounds.Y
-	var result []*ParsedElement
-
-	for _, elem := range elements {
-		elemBottom := elem.Bounds.Y + elem.Bounds.Height
-		if elemBottom <= anchorTop {
-			result = append(result, elem)
-		}
-	}
-
-	sortByDistanceYReverse(result, anchorTop)
-	return result
+	return core.FilterBySide(elements, anchor, core.SideAbove, elementBounds)
 }
 
-// FilterLeftOf returns elements left of the anchor element.
+// FilterLeftOf returns elements left of the anchor.
 func FilterLeftOf(elements []*ParsedElement, anchor *ParsedElement) []*ParsedElement {
-	anchorLeft := anchor.Bounds.X
-	var result []*ParsedElement
-
-	for _, elem := range elements {
-		elemRight := elem.Bounds.X + elem.Bounds.Width
-		if elemRight <= anchorLeft {
-			result = append(result, elem)
-		}
-	}
-
-	sortByDistanceXReverse(result, anchorLeft)
-	return result
+	return core.FilterBySide(elements, anchor, core.SideLeftOf, elementBounds)
 }
 
-// FilterRightOf returns elements right of the anchor element.
+// FilterRightOf returns elements right of the anchor.
 func FilterRightOf(elements []*ParsedElement, anchor *ParsedElement) []*ParsedElement {
-	anchorRight := anchor.Bounds.X + anchor.Bounds.Width
-	var result []*ParsedElement
-
-	for _, elem := range elements {
-		if elem.Bounds.X >= anchorRight {
-			result = append(result, elem)
-		}
-	}
-
-	sortByDistanceX(result, anchorRight)
-	return result
+	return core.FilterBySide(elements, anchor, core.SideRightOf, elementBounds)
 }
 
-// FilterChildOf returns elements that are children of anchor.
+func elementBounds(elem *ParsedElement) core.Bounds { return elem.Bounds }
+
+// FilterChildOf returns elements nested under anchor in the page source tree.
+// An anchor detached from the tree (resolved from a nested relative selector)
+// falls back to bounds containment.
 func FilterChildOf(elements []*ParsedElement, anchor *ParsedElement) []*ParsedElement {
 	var result []*ParsedElement
 
+	attached := inTree(anchor)
 	for _, elem := range elements {
-		if isInside(elem.Bounds, anchor.Bounds) {
+		if attached && core.IsDescendant(elem, anchor, parentOf) ||
+			!attached && isInside(elem.Bounds, anchor.Bounds) {
 			result = append(result, elem)
 		}
 	}
@@ -381,12 +391,15 @@ func FilterChildOf(elements []*ParsedElement, anchor *ParsedElement) []*ParsedEl
 	return result
 }
 
-// FilterContainsChild returns elements that contain anchor as child.
+// FilterContainsChild returns elements that have anchor among their
+// descendants, falling back to bounds containment for a detached anchor.
 func FilterContainsChild(elements []*ParsedElement, anchor *ParsedElement) []*ParsedElement {
 	var result []*ParsedElement
 
+	attached := inTree(anchor)
 	for _, elem := range elements {
-		if isInside(anchor.Bounds, elem.Bounds) {
+		if attached && core.IsDescendant(anchor, elem, parentOf) ||
+			!attached && isInside(anchor.Bounds, elem.Bounds) {
 			result = append(result, elem)
 		}
 	}
@@ -394,6 +407,16 @@ func FilterContainsChild(elements []*ParsedElement, anchor *ParsedElement) []*Pa
 	return result
 }
 
+// inTree reports whether elem came from a parsed hierarchy rather than being
+// built from a resolved element's info.
+func inTree(elem *ParsedElement) bool {
+	return elem.Parent != nil || len(elem.Children) > 0
+}
+
+func parentOf(elem *ParsedElement) *ParsedElement {
+	return elem.Parent
+}
+
 // FilterInsideOf returns elements whose center point is inside anchor bounds.
 // Different from ChildOf - uses visual center containment, not full bounds.
 func FilterInsideOf(elements []*ParsedElement, anchor *ParsedElement) []*ParsedElement {
@@ -459,6 +482,60 @@ func DeepestMatchingElement(elements []*ParsedElement) *ParsedElement {
 	return deepest
 }
 
+// SelectByIndex returns the index-th match in on-screen order: top-to-bottom,
+// then left-to-right, source order breaking ties. A match nested inside
+// another match counts once, as the innermost element. Negative indexes count
+// from the end.
+func SelectByIndex(elements []*ParsedElement, index int) (*ParsedElement, error) {
+	ordered := SortByPosition(innermostMatches(elements))
+	i := index
+	if i < 0 {
+		i += len(ordered)
+	}
+	if i < 0 || i >= len(ordered) {
+		return nil, fmt.Errorf("index %d out of range: %d elements match", index, len(ordered))
+	}
+	return ordered[i], nil
+}
+
+// SortByPosition returns elements ordered top-to-bottom, then left-to-right.
+// The sort is stable, so elements at the same position keep source order.
+func SortByPosition(elements []*ParsedElement) []*ParsedElement {
+	sorted := append([]*ParsedElement(nil), elements...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i].Bounds, sorted[j].Bounds
+		if a.Y != b.Y {
+			return a.Y < b.Y
+		}
+		return a.X < b.X
+	})
+	return sorted
+}
+
+// innermostMatches drops elements that are ancestors of another element in
+// the list, so a container and the label inside it count as one match.
+func innermostMatches(elements []*ParsedElement) []*ParsedElement {
+	matched := make(map[*ParsedElement]bool, len(elements))
+	for _, elem := range elements {
+		matched[elem] = true
+	}
+	hasMatchedDescendant := make(map[*ParsedElement]bool)
+	for _, elem := range elements {
+		for p := elem.Parent; p != nil; p = p.Parent {
+			if matched[p] {
+				hasMatchedDescendant[p] = true
+			}
+		}
+	}
+	var result []*ParsedElement
+	for _, elem := range elements {
+		if !hasMatchedDescendant[elem] {
+			result = append(result, elem)
+		}
+	}
+	return result
+}
+
 // isClickableType checks if an iOS element type is typically clickable/interactive.
 // This mimics Maestro's smart element selection for iOS.
 func isClickableType(elemType string) bool {
@@ -510,20 +587,22 @@ func SortClickableFirst(elements []*ParsedElement) []*ParsedElement {
 // If not, walks up the parent chain to find the first clickable parent.
 // Returns the original element if no clickable parent is found.
 // This handles patterns where text labels aren't interactive but their parent containers are.
+// Elements WDA reports as not hittable (covered or disabled for touches) are
+// skipped in favour of a hittable ancestor.
 func GetClickableElement(elem *ParsedElement) *ParsedElement {
 	if elem == nil {
 		return nil
 	}
 
 	// If element itself is clickable, use it
-	if isClickableType(elem.Type) {
+	if isTappable(elem) {
 		return elem
 	}
 
 	// Walk up parent chain to find clickable parent
 	parent := elem.Parent
 	for parent != nil {
-		if isClickableType(parent.Type) {
+		if isTappable(parent) {
 			return parent
 		}
 		parent = parent.Parent
@@ -533,52 +612,8 @@ func GetClickableElement(elem *ParsedElement) *ParsedElement {
 	return elem
 }
 
-// Sorting functions
-
-func sortByDistanceY(elements []*ParsedElement, refY int) {
-	for i := 0; i < len(elements); i++ {
-		for j := i + 1; j < len(elements); j++ {
-			distI := elements[i].Bounds.Y - refY
-			distJ := elements[j].Bounds.Y - refY
-			if distJ < distI {
-				elements[i], elements[j] = elements[j], elements[i]
-			}
-		}
-	}
-}
-
-func sortByDistanceYReverse(elements []*ParsedElement, refY int) {
-	for i := 0; i < len(elements); i++ {
-		for j := i + 1; j < len(elements); j++ {
-			distI := refY - (elements[i].Bounds.Y + elements[i].Bounds.Height)
-			distJ := refY - (elements[j].Bounds.Y + elements[j].Bounds.Height)
-			if distJ < distI {
-				elements[i], elements[j] = elements[j], elements[i]
-			}
-		}
-	}
-}
-
-func sortByDistanceX(elements []*ParsedElement, refX int) {
-	for i := 0; i < len(elements); i++ {
-		for j := i + 1; j < len(elements); j++ {
-			distI := elements[i].Bounds.X - refX
-			distJ := elements[j].Bounds.X - refX
-			if distJ < distI {
-				elements[i], elements[j] = elements[j], elements[i]
-			}
-		}
-	}
-}
-
-func sortByDistanceXReverse(elements []*ParsedElement, refX int) {
-	for i := 0; i < len(elements); i++ {
-		for j := i + 1; j < len(elements); j++ {
-			distI := refX - (elements[i].Bounds.X + elements[i].Bounds.Width)
-			distJ := refX - (elements[j].Bounds.X + elements[j].Bounds.Width)
-			if distJ < distI {
-				elements[i], elements[j] = elements[j], elements[i]
-			}
-		}
-	}
+// isTappable reports whether elem is an interactive type that WDA hasn't
+// reported as unhittable.
+func isTappable(elem *ParsedElement) bool {
+	return isClickableType(elem.Type) && !elem.Unhittable
 }
@@ -69,6 +69,14 @@ func TestParsePageSourceInvalidXML(t *testing.T) {
 	}
 }
 
+// TestParsePageSourceTruncated tests that a cut-off document is an error, not a partial tree
+func TestParsePageSourceTruncated(t *testing.T) {
+	truncated := `<AppiumAUT><XCUIElementTypeApplication name="App"><XCUIElementTypeButton name="Lo`
+	if _, err := ParsePageSource(truncated); err == nil {
+		t.Error("Expected error for truncated XML")
+	}
+}
+
 // TestParsePageSourceEmptyXML tests parsing empty content
 func TestParsePageSourceEmptyXML(t *testing.T) {
 	_, err := ParsePageSource("")
@@ -130,6 +138,67 @@ func TestFilterBySelectorStateFilters(t *testing.T) {
 	}
 }
 
+// TestFilterBySelectorChecked tests that checked follows switch values and selection
+func TestFilterBySelectorChecked(t *testing.T) {
+	source := `<?xml version="1.0" encoding="UTF-8"?>
+<AppiumAUT>
+  <XCUIElementTypeOther type="XCUIElementTypeOther" enabled="true" visible="true" x="0" y="0" width="390" height="300">
+    <XCUIElementTypeSwitch type="XCUIElementTypeSwitch" label="Wi-Fi" value="1" enabled="true" visible="true" x="0" y="0" width="390" height="50"/>
+    <XCUIElementTypeSwitch type="XCUIElementTypeSwitch" label="Bluetooth" value="0" enabled="true" visible="true" x="0" y="50" width="390" height="50"/>
+    <XCUIElementTypeButton type="XCUIElementTypeButton" label="Agree" enabled="true" visible="true" selected="true" x="0" y="100" width="390" height="50"/>
+  </XCUIElementTypeOther>
+</AppiumAUT>`
+	elements, err := ParsePageSource(source)
+	if err != nil {
+		t.Fatalf("ParsePageSource failed: %v", err)
+	}
+
+	checked, unchecked := true, false
+	if got := FilterBySelector(elements, flow.Selector{Text: "Wi-Fi", Checked: &checked}); len(got) != 1 {
+		t.Errorf("Expected Wi-Fi switch to be checked, got %d", len(got))
+	}
+	if got := FilterBySelector(elements, flow.Selector{Text: "Bluetooth", Checked: &checked}); len(got) != 0 {
+		t.Errorf("Expected Bluetooth switch not to be checked, got %d", len(got))
+	}
+	if got := FilterBySelector(elements, flow.Selector{Text: "Bluetooth", Checked: &unchecked}); len(got) != 1 {
+		t.Errorf("Expected Bluetooth switch to match checked=false, got %d", len(got))
+	}
+	if got := FilterBySelector(elements, flow.Selector{Text: "Agree", Checked: &checked}); len(got) != 1 {
+		t.Errorf("Expected selected Agree button to be checked, got %d", len(got))
+	}
+}
+
+// TestFilterBySelectorStructure tests filtering by child index and sibling count
+func TestFilterBySelectorStructure(t *testing.T) {
+	source := `<?xml version="1.0" encoding="UTF-8"?>
+<AppiumAUT>
+  <XCUIElementTypeTable type="XCUIElementTypeTable" name="list" enabled="true" visible="true" x="0" y="0" width="390" height="300">
+    <XCUIElementTypeCell type="XCUIElementTypeCell" label="Row" enabled="true" visible="true" x="0" y="0" width="390" height="100"/>
+    <XCUIElementTypeCell type="XCUIElementTypeCell" label="Row" enabled="true" visible="true" x="0" y="100" width="390" height="100"/>
+    <XCUIElementTypeCell type="XCUIElementTypeCell" label="Row" enabled="true" visible="true" x="0" y="200" width="390" height="100"/>
+  </XCUIElementTypeTable>
+</AppiumAUT>`
+	elements, err := ParsePageSource(source)
+	if err != nil {
+		t.Fatalf("ParsePageSource failed: %v", err)
+	}
+
+	middle, two := 1, 2
+	filtered := FilterBySelector(elements, flow.Selector{Text: "Row", ChildIndex: &middle, SiblingCount: &two})
+	if len(filtered) != 1 {
+		t.Fatalf("Expected 1 middle row, got %d", len(filtered))
+	}
+	if filtered[0].Bounds.Y != 100 {
+		t.Errorf("Expected middle row at y=100, got %d", filtered[0].Bounds.Y)
+	}
+
+	last := 3
+	filtered = FilterBySelector(elements, flow.Selector{Text: "Row", ChildIndex: &last})
+	if len(filtered) != 0 {
+		t.Errorf("Expected no row at index 3, got %d", len(filtered))
+	}
+}
+
 // TestFilterBySelectorWithSize tests filtering by size with tolerance
 func TestFilterBySelectorWithSize(t *testing.T) {
 	elements, _ := ParsePageSource(sampleIOSPageSource)
@@ -180,6 +249,56 @@ func TestFilterBySelectorRegex(t *testing.T) {
 	}
 }
 
+func TestSelectByIndex(t *testing.T) {
+	elements, _ := ParsePageSource(`<?xml version="1.0" encoding="UTF-8"?>
+<AppiumAUT>
+  <XCUIElementTypeApplication type="XCUIElementTypeApplication" name="TestApp" enabled="true" visible="true" x="0" y="0" width="390" height="844">
+    <XCUIElementTypeCell type="XCUIElementTypeCell" label="Delete" enabled="true" visible="true" x="0" y="300" width="390" height="60">
+      <XCUIElementTypeButton type="XCUIElementTypeButton" label="Delete" enabled="true" visible="true" x="300" y="310" width="80" height="40"/>
+    </XCUIElementTypeCell>
+    <XCUIElementTypeButton type="XCUIElementTypeButton" label="Delete" enabled="true" visible="true" x="300" y="100" width="80" height="40"/>
+    <XCUIElementTypeButton type="XCUIElementTypeButton" label="Delete" enabled="true" visible="true" x="20" y="100" width="80" height="40"/>
+  </XCUIElementTypeApplication>
+</AppiumAUT>`)
+	matches := FilterBySelector(elements, flow.Selector{Text: "Delete"})
+
+	// Top row left-to-right, then the button inside the cell (the cell itself is skipped)
+	wantX := []int{20, 300, 300}
+	wantY := []int{100, 100, 310}
+	for i := range wantX {
+		got, err := SelectByIndex(matches, i)
+		if err != nil {
+			t.Fatalf("index %d: unexpected error: %v", i, err)
+		}
+		if got.Bounds.X != wantX[i] || got.Bounds.Y != wantY[i] {
+			t.Errorf("index %d: got %+v", i, got.Bounds)
+		}
+	}
+
+	if _, err := SelectByIndex(matches, 3); err == nil || err.Error() != "index 3 out of range: 3 elements match" {
+		t.Errorf("Expected out of range error, got %v", err)
+	}
+}
+
+func TestFilterBySelectorTextRegex(t *testing.T) {
+	elements, _ := ParsePageSource(sampleIOSPageSource)
+
+	filtered := FilterBySelector(elements, flow.Selector{TextRegex: "Welcome to the [a-z]+"})
+	if len(filtered) != 1 || filtered[0].Label != "Welcome to the app" {
+		t.Errorf("Expected the welcome label, got %d elements", len(filtered))
+	}
+
+	// Matches name as well as label, but only the whole string
+	filtered = FilterBySelector(elements, flow.Selector{TextRegex: "[a-z]+Button"})
+	if len(filtered) != 2 {
+		t.Errorf("Expected 2 elements matching '[a-z]+Button', got %d", len(filtered))
+	}
+	filtered = FilterBySelector(elements, flow.Selector{TextRegex: "Log"})
+	if len(filtered) != 0 {
+		t.Errorf("Expected partial textRegex to match nothing, got %d", len(filtered))
+	}
+}
+
 // TestFilterBelow tests filtering elements below an anchor
 func TestFilterBelow(t *testing.T) {
 	elements := []*ParsedElement{
@@ -296,6 +415,61 @@ func TestFilterContainsChild(t *testing.T) {
 	}
 }
 
+// listSource has two cells with a label and button each, under a full-screen
+// overlay that is a sibling of the table, not an ancestor of the cells.
+const listSource = `<?xml version="1.0" encoding="UTF-8"?>
+<AppiumAUT>
+  <XCUIElementTypeWindow type="XCUIElementTypeWindow" enabled="true" visible="true" x="0" y="0" width="390" height="844">
+    <XCUIElementTypeTable type="XCUIElementTypeTable" name="list" enabled="true" visible="true" x="0" y="0" width="390" height="200">
+      <XCUIElementTypeCell type="XCUIElementTypeCell" name="row" enabled="true" visible="true" x="0" y="0" width="390" height="100">
+        <XCUIElementTypeStaticText type="XCUIElementTypeStaticText" label="Milk" enabled="true" visible="true" x="16" y="30" width="100" height="40"/>
+        <XCUIElementTypeButton type="XCUIElementTypeButton" label="Remove" enabled="true" visible="true" x="300" y="30" width="80" height="40"/>
+      </XCUIElementTypeCell>
+      <XCUIElementTypeCell type="XCUIElementTypeCell" name="row" enabled="true" visible="true" x="0" y="100" width="390" height="100">
+        <XCUIElementTypeStaticText type="XCUIElementTypeStaticText" label="Eggs" enabled="true" visible="true" x="16" y="130" width="100" height="40"/>
+        <XCUIElementTypeButton type="XCUIElementTypeButton" label="Remove" enabled="true" visible="true" x="300" y="130" width="80" height="40"/>
+      </XCUIElementTypeCell>
+    </XCUIElementTypeTable>
+    <XCUIElementTypeOther type="XCUIElementTypeOther" name="overlay" enabled="true" visible="true" x="0" y="0" width="390" height="844"/>
+  </XCUIElementTypeWindow>
+</AppiumAUT>`
+
+// TestFilterContainsChildTree tests that containsChild follows the tree, not bounds
+func TestFilterContainsChildTree(t *testing.T) {
+	elements, err := ParsePageSource(listSource)
+	if err != nil {
+		t.Fatalf("ParsePageSource failed: %v", err)
+	}
+	milk := FilterBySelector(elements, flow.Selector{Text: "Milk"})[0]
+
+	rows := FilterContainsChild(FilterBySelector(elements, flow.Selector{ID: "row"}), milk)
+	if len(rows) != 1 || rows[0].Bounds.Y != 0 {
+		t.Fatalf("Expected only the Milk row, got %d rows", len(rows))
+	}
+	for _, elem := range FilterContainsChild(elements, milk) {
+		if elem.Name == "overlay" {
+			t.Error("Overlay covers the label but is not its ancestor")
+		}
+	}
+}
+
+// TestFilterChildOfTree tests that childOf keeps only descendants of the anchor
+func TestFilterChildOfTree(t *testing.T) {
+	elements, err := ParsePageSource(listSource)
+	if err != nil {
+		t.Fatalf("ParsePageSource failed: %v", err)
+	}
+	secondRow := FilterBySelector(elements, flow.Selector{ID: "row"})[1]
+
+	buttons := FilterChildOf(FilterBySelector(elements, flow.Selector{Text: "Remove"}), secondRow)
+	if len(buttons) != 1 || buttons[0].Bounds.Y != 130 {
+		t.Fatalf("Expected the second row's Remove button, got %d buttons", len(buttons))
+	}
+	if got := FilterChildOf(elements, secondRow); len(got) != 2 {
+		t.Errorf("Expected 2 descendants of the row, got %d", len(got))
+	}
+}
+
 // TestFilterInsideOf tests filtering elements whose center is inside anchor
 func TestFilterInsideOf(t *testing.T) {
 	elements := []*ParsedElement{
@@ -538,3 +712,17 @@ func TestParsePageSourceWithElementAttributes(t *testing.T) {
 		t.Error("Expected focused=true")
 	}
 }
+
+func TestGetClickableElementSkipsUnhittable(t *testing.T) {
+	cell := &ParsedElement{Type: "XCUIElementTypeCell"}
+	button := &ParsedElement{Type: "XCUIElementTypeButton", Parent: cell}
+
+	if got := GetClickableElement(button); got != button {
+		t.Errorf("expected a hittable button to be tapped itself, got %+v", got)
+	}
+
+	button.Unhittable = true
+	if got := GetClickableElement(button); got != cell {
+		t.Errorf("expected an unhittable button to resolve to its cell, got %+v", got)
+	}
+}
@@ -36,6 +36,7 @@ type Runner struct {
 	logFile             *os.File
 	portForwardListener io.Closer // Port forwarding for physical devices (go-ios)
 	isSimulatorCache    bool      // Cached device type
+	output              io.Writer // Build and startup progress (nil = stdout)
 }
 
 // NewRunner creates a new WDA runner.
@@ -49,6 +50,18 @@ func NewRunner(deviceUDID, teamID string) *Runner {
 	}
 }
 
+// SetOutput sets where build and startup progress is printed.
+func (r *Runner) SetOutput(w io.Writer) {
+	r.output = w
+}
+
+func (r *Runner) out() io.Writer {
+	if r.output == nil {
+		return os.Stdout
+	}
+	return r.output
+}
+
 // Port returns the WDA port allocated for this runner's device.
 func (r *Runner) Port() uint16 {
 	return r.port
@@ -96,15 +109,16 @@ func (r *Runner) Build(ctx context.Context) error {
 	// Check if already built by looking for xctestrun file
 	if _, err := r.findXctestrun(); err == nil {
 		// Build exists - skip rebuilding
-		fmt.Printf("  ✓ Using cached WebDriverAgent build (%s)\n", filepath.Base(r.buildDir))
+		fmt.Fprintf(r.out(), "  ✓ Using cached WebDriverAgent build (%s)\n", filepath.Base(r.buildDir))
 		return nil
 	}
 
 	// Need to build
-	fmt.Println("\n  ⏳ Building WebDriverAgent for the first time...")
-	fmt.Println("     This may take 5-10 minutes depending on your machine.")
-	fmt.Println("     Next time it will be much faster (cached builds are reused).")
-	fmt.Println()
+	w := r.out()
+	fmt.Fprintln(w, "\n  ⏳ Building WebDriverAgent for the first time...")
+	fmt.Fprintln(w, "     This may take 5-10 minutes depending on your machine.")
+	fmt.Fprintln(w, "     Next time it will be much faster (cached builds are reused).")
+	fmt.Fprintln(w)
 
 	logPath := filepath.Join(r.buildDir, "logs", "build.log")
 	logFile, err := os.Create(logPath)
@@ -142,7 +156,7 @@ func (r *Runner) Build(ctx context.Context) error {
 		return err
 	}
 
-	fmt.Println("WebDriverAgent build complete")
+	fmt.Fprintln(w, "WebDriverAgent build complete")
 	return nil
 }
 
@@ -178,7 +192,7 @@ func (r *Runner) Start(ctx context.Context) error {
 	r.cmd.Stdout = r.logFile
 	r.cmd.Stderr = r.logFile
 
-	fmt.Println("Starting WebDriverAgent...")
+	fmt.Fprintln(r.out(), "Starting WebDriverAgent...")
 
 	if err := r.cmd.Start(); err != nil {
 		return fmt.Errorf("failed to start WDA: %w", err)
@@ -197,7 +211,7 @@ func (r *Runner) Start(ctx context.Context) error {
 		}
 	}
 
-	fmt.Println("WebDriverAgent started")
+	fmt.Fprintln(r.out(), "WebDriverAgent started")
 	return nil
 }
 
@@ -18,8 +18,8 @@ const (
 	WDARepoURL = "https://github.com/appium/WebDriverAgent/archive/refs/tags/v%s.zip"
 )
 
-// Setup ensures WDA is available. Downloads if missing.
-func Setup() (string, error) {
+// Setup ensures WDA is available. Downloads if missing, printing progress to w.
+func Setup(w io.Writer) (string, error) {
 	wdaPath, err := GetWDAPath()
 	if err != nil {
 		return "", err
@@ -29,8 +29,8 @@ func Setup() (string, error) {
 	projectPath := filepath.Join(wdaPath, "WebDriverAgent.xcodeproj")
 	if _, err := os.Stat(projectPath); err != nil {
 		// WDA not found, download the latest version
-		fmt.Println("WebDriverAgent not found. Downloading...")
-		if err := UpdateWDA(); err != nil {
+		fmt.Fprintln(w, "WebDriverAgent not found. Downloading...")
+		if err := UpdateWDA(w); err != nil {
 			return "", fmt.Errorf("failed to download WebDriverAgent: %w", err)
 		}
 	}
@@ -107,8 +107,9 @@ func CheckWDAUpdate() (string, string, bool, error) {
 	return localVersion, latestVersion, updateAvailable, nil
 }
 
-// DownloadWDA downloads and extracts a specific WDA version to the drivers/ios directory.
-func DownloadWDA(version string) error {
+// DownloadWDA downloads and extracts a specific WDA version to the drivers/ios
+// directory, printing progress to w.
+func DownloadWDA(version string, w io.Writer) error {
 	wdaPath, err := GetWDAPath()
 	if err != nil {
 		return err
@@ -125,7 +126,7 @@ func DownloadWDA(version string) error {
 	defer func() { _ = os.Remove(tmpPath) }()
 
 	// Download
-	fmt.Printf("Downloading WebDriverAgent v%s...\n", version)
+	fmt.Fprintf(w, "Downloading WebDriverAgent v%s...\n", version)
 	resp, err := http.Get(url)
 	if err != nil {
 		_ = tmpFile.Close()
@@ -158,7 +159,7 @@ func DownloadWDA(version string) error {
 	}
 
 	// Extract zip
-	fmt.Println("Extracting...")
+	fmt.Fprintln(w, "Extracting...")
 	if err := unzip(tmpPath, baseDir); err != nil {
 		return fmt.Errorf("failed to extract: %w", err)
 	}
@@ -169,17 +170,17 @@ func DownloadWDA(version string) error {
 		return fmt.Errorf("failed to rename WDA directory: %w", err)
 	}
 
-	fmt.Printf("WebDriverAgent v%s installed successfully\n", version)
+	fmt.Fprintf(w, "WebDriverAgent v%s installed successfully\n", version)
 	return nil
 }
 
-// UpdateWDA downloads and installs the latest WDA version.
-func UpdateWDA() error {
+// UpdateWDA downloads and installs the latest WDA version, printing progress to w.
+func UpdateWDA(w io.Writer) error {
 	latestVersion, err := GetLatestWDAVersion()
 	if err != nil {
 		return err
 	}
-	return DownloadWDA(latestVersion)
+	return DownloadWDA(latestVersion, w)
 }
 
 func unzip(src, dest string) error {
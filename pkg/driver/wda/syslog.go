@@ -0,0 +1,85 @@
+package wda
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// logTagPattern limits log tags to characters that are safe inside a quoted
+// log predicate.
+var logTagPattern = regexp.MustCompile(`^[A-Za-z0-9_.:-]+$`)
+
+// StartLogCapture remembers when the flow started so DeviceLogs only returns
+// what it logged. Only simulators are supported: their unified log is read
+// with `simctl spawn log show`, while a real device's syslog needs a
+// separate relay, so the runner skips log capture for them with a warning.
+func (d *Driver) StartLogCapture() error {
+	if d.udid == "" || d.info == nil || !d.info.IsSimulator {
+		return fmt.Errorf("device logs are only supported on iOS simulators")
+	}
+	// The simulator shares the host clock
+	d.logSince = time.Now()
+	return nil
+}
+
+// DeviceLogs returns the simulator's unified log since StartLogCapture. A
+// tag keeps only entries whose subsystem, category or process matches it;
+// otherwise entries are limited to appID's process when the app is
+// installed, or left unfiltered.
+func (d *Driver) DeviceLogs(appID, tag string) ([]byte, error) {
+	if d.udid == "" || d.info == nil || !d.info.IsSimulator {
+		return nil, fmt.Errorf("device logs are only supported on iOS simulators")
+	}
+
+	var predicate string
+	switch {
+	case tag != "":
+		if !logTagPattern.MatchString(tag) {
+			return nil, fmt.Errorf("invalid log tag %q", tag)
+		}
+		predicate = fmt.Sprintf(`subsystem == "%s" OR category == "%s" OR process == "%s"`, tag, tag, tag)
+	case appID != "":
+		if process := d.appProcessName(appID); process != "" {
+			predicate = fmt.Sprintf(`process == "%s"`, process)
+		}
+	}
+
+	since := d.logSince
+	if since.IsZero() {
+		since = time.Now().Add(-time.Minute)
+	}
+	out, err := exec.Command("xcrun", simctlLogShowArgs(d.udid, since, predicate)...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("simctl log show failed: %w", err)
+	}
+	return out, nil
+}
+
+// appProcessName returns the process name of an installed simulator app,
+// which is its .app bundle name, or "" when the app can't be found.
+func (d *Driver) appProcessName(bundleID string) string {
+	out, err := exec.Command("xcrun", "simctl", "get_app_container", d.udid, bundleID, "app").Output()
+	if err != nil {
+		return ""
+	}
+	name := strings.TrimSuffix(filepath.Base(strings.TrimSpace(string(out))), ".app")
+	if !logTagPattern.MatchString(name) {
+		return ""
+	}
+	return name
+}
+
+// simctlLogShowArgs builds the xcrun arguments for `log show` inside the
+// simulator, starting at since and optionally filtered by a predicate.
+func simctlLogShowArgs(udid string, since time.Time, predicate string) []string {
+	args := []string{"simctl", "spawn", udid, "log", "show",
+		"--style", "compact", "--start", since.Format("2006-01-02 15:04:05")}
+	if predicate != "" {
+		args = append(args, "--predicate", predicate)
+	}
+	return args
+}
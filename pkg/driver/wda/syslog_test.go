@@ -0,0 +1,59 @@
+package wda
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/devicelab-dev/maestro-runner/pkg/core"
+)
+
+func TestSimctlLogShowArgs(t *testing.T) {
+	since := time.Date(2026, 1, 2, 3, 4, 5, 0, time.Local)
+
+	tests := []struct {
+		name      string
+		predicate string
+		want      string
+	}{
+		{"unfiltered", "", "simctl spawn SIM-UDID log show --style compact --start 2026-01-02 03:04:05"},
+		{"process", `process == "MyApp"`, `simctl spawn SIM-UDID log show --style compact --start 2026-01-02 03:04:05 --predicate process == "MyApp"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := strings.Join(simctlLogShowArgs("SIM-UDID", since, tt.predicate), " "); got != tt.want {
+				t.Errorf("simctlLogShowArgs() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLogCaptureRequiresSimulator(t *testing.T) {
+	driver := &Driver{udid: "00008110-000A1B2C3D4E5F6G", info: &core.PlatformInfo{}}
+
+	if err := driver.StartLogCapture(); err == nil || !strings.Contains(err.Error(), "simulator") {
+		t.Errorf("expected a simulator-only error from StartLogCapture, got %v", err)
+	}
+	if _, err := driver.DeviceLogs("com.example.app", ""); err == nil {
+		t.Error("expected DeviceLogs to fail on a real device")
+	}
+}
+
+func TestStartLogCaptureOnSimulator(t *testing.T) {
+	driver := &Driver{udid: "SIM-UDID", info: &core.PlatformInfo{IsSimulator: true}}
+
+	before := time.Now()
+	if err := driver.StartLogCapture(); err != nil {
+		t.Fatalf("StartLogCapture failed: %v", err)
+	}
+	if driver.logSince.Before(before) {
+		t.Errorf("expected capture start at or after %v, got %v", before, driver.logSince)
+	}
+}
+
+func TestDeviceLogsRejectsUnsafeTag(t *testing.T) {
+	driver := &Driver{udid: "SIM-UDID", info: &core.PlatformInfo{IsSimulator: true}}
+	if _, err := driver.DeviceLogs("com.example.app", `x" OR 1`); err == nil {
+		t.Error("expected an error for a tag that would break the predicate")
+	}
+}
@@ -1,6 +1,8 @@
 package executor
 
 import (
+	"errors"
+
 	"github.com/devicelab-dev/maestro-runner/pkg/core"
 	"github.com/devicelab-dev/maestro-runner/pkg/report"
 )
@@ -36,7 +38,7 @@ func commandResultToError(r *core.CommandResult) *report.Error {
 		return nil
 	}
 
-	errType := "unknown"
+	errType := errorType(r.Error)
 	message := r.Error.Error()
 
 	// Use message from result if available
@@ -49,3 +51,29 @@ func commandResultToError(r *core.CommandResult) *report.Error {
 		Message: message,
 	}
 }
+
+// errorType maps a classified driver error (core.ExecutionError) to the
+// report's error type, so timeouts and lost connections can be told apart
+// from failed assertions. Unclassified errors are "unknown".
+func errorType(err error) string {
+	var execErr *core.ExecutionError
+	if !errors.As(err, &execErr) {
+		return "unknown"
+	}
+	switch execErr.Category {
+	case core.ErrCategoryTimeout:
+		return "timeout"
+	case core.ErrCategoryConnection:
+		return "network"
+	case core.ErrCategoryAssertion:
+		if execErr.Code == core.ErrElementNotFound.Code {
+			return "element_not_found"
+		}
+		return "assertion"
+	case core.ErrCategoryApp:
+		if execErr.Code == core.ErrAppCrashed.Code {
+			return "app_crash"
+		}
+	}
+	return "unknown"
+}
@@ -3,11 +3,16 @@ package executor
 import (
 	"context"
 	"fmt"
+	"image/png"
+	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/devicelab-dev/maestro-runner/pkg/core"
 	"github.com/devicelab-dev/maestro-runner/pkg/flow"
+	"github.com/devicelab-dev/maestro-runner/pkg/imagediff"
 	"github.com/devicelab-dev/maestro-runner/pkg/logger"
 	"github.com/devicelab-dev/maestro-runner/pkg/report"
 )
@@ -22,9 +27,12 @@ type FlowRunner struct {
 	indexWriter *report.IndexWriter
 	flowWriter  *report.FlowWriter
 	script      *ScriptEngine
-	depth       int // Nesting depth for runFlow reporting
-	flowIdx     int // Current flow index (0-based)
-	totalFlows  int // Total number of flows
+	depth       int    // Nesting depth for runFlow reporting
+	appID       string // appId for app-scoped steps that omit it (a sub-flow's own appId while it runs)
+	flowIdx     int    // Current flow index (0-based)
+	totalFlows  int    // Total number of flows
+	stepIdx     int    // Index of the top-level step running
+	nestedShots int    // Failure screenshots taken for nested steps
 	// Step counters
 	stepsPassed  int
 	stepsFailed  int
@@ -47,30 +55,40 @@ func (fr *FlowRunner) Run() FlowResult {
 	// Initialize script engine
 	fr.script = NewScriptEngine()
 	defer fr.script.Close()
+	fr.script.SetDriver(fr.driver)
 
 	// Import system environment variables
 	fr.script.ImportSystemEnv()
 
-	// Apply CLI environment variables (from -e flags)
-	// These take precedence over system env, but flow-level env takes precedence over these
-	fr.script.SetVariables(fr.config.Env)
-
 	// Set flow directory for relative path resolution
 	if fr.flow.SourcePath != "" {
 		fr.script.SetFlowDir(filepath.Dir(fr.flow.SourcePath))
 	}
 
+	if fr.config.ScriptOutput != nil {
+		fr.script.SetConsoleOutput(fr.config.ScriptOutput)
+	}
+
 	// Set platform in JS engine
 	if info := fr.driver.GetPlatformInfo(); info != nil {
 		fr.script.SetPlatform(info.Platform)
 	}
 
-	// Apply flow config variables (takes precedence over CLI env)
+	fr.appID = fr.flow.Config.AppID
+
+	// Apply flow header variables (take precedence over system env)
 	if fr.flow.Config.AppID != "" {
 		fr.script.SetVariable("APP_ID", fr.flow.Config.AppID)
 	}
 	fr.script.SetVariables(fr.flow.Config.Env)
 
+	// Apply --vars file (takes precedence over the flow header env)
+	fr.script.SetConfig(fr.config.Vars)
+
+	// Apply CLI environment variables (from -e flags)
+	// These take precedence over system env, the flow header env and --vars
+	fr.script.SetVariables(fr.config.Env)
+
 	// Apply commandTimeout if specified - overrides driver's default find timeout
 	if fr.flow.Config.CommandTimeout > 0 {
 		fr.driver.SetFindTimeout(fr.flow.Config.CommandTimeout)
@@ -92,36 +110,39 @@ func (fr *FlowRunner) Run() FlowResult {
 	// Notify flow start
 	flowName := fr.detail.Name
 	flowFile := filepath.Base(fr.flow.SourcePath)
-	if fr.config.OnFlowStart != nil {
-		fr.config.OnFlowStart(fr.flowIdx, fr.totalFlows, flowName, flowFile)
+	if fr.config.Sink != nil {
+		fr.config.Sink.FlowStart(fr.flowEvent(flowName, flowFile))
 	}
 
 	// Mark flow as started
 	fr.flowWriter.Start()
 
+	recording := fr.config.RecordOnFailure && fr.startFailureRecording()
+	logcat := fr.config.LogcatOnFailure && fr.startLogCapture()
+
 	// Execute all steps
 	flowStatus := report.StatusPassed
 	var flowError string
 
-	// Execute onFlowComplete in defer (runs even on failure)
-	defer func() {
-		if len(fr.flow.Config.OnFlowComplete) > 0 {
-			for _, step := range fr.flow.Config.OnFlowComplete {
-				fr.executeNestedStep(step) // Ignore failures in cleanup
-			}
-		}
-	}()
-
 	// Execute onFlowStart hooks
 	if len(fr.flow.Config.OnFlowStart) > 0 {
 		for _, step := range fr.flow.Config.OnFlowStart {
 			result := fr.executeNestedStep(step)
 			if !result.Success && !step.IsOptional() {
-				// onFlowStart failed - fail the flow
+				// onFlowStart failed - fail the flow, still running cleanup
+				errMsg := withHookError(fmt.Sprintf("onFlowStart failed: %v", result.Error), fr.runOnFlowComplete())
+				if recording {
+					fr.finishFailureRecording(report.StatusFailed)
+				}
+				var deviceLog string
+				if logcat {
+					deviceLog = fr.saveDeviceLogs()
+				}
 				fr.flowWriter.End(report.StatusFailed)
-				errMsg := fmt.Sprintf("onFlowStart failed: %v", result.Error)
-				if fr.config.OnFlowEnd != nil {
-					fr.config.OnFlowEnd(flowName, false, time.Since(flowStart).Milliseconds(), errMsg)
+				if fr.config.Sink != nil {
+					ev := fr.flowEvent(flowName, flowFile)
+					ev.DurationMs, ev.Error = time.Since(flowStart).Milliseconds(), errMsg
+					fr.config.Sink.FlowEnd(ev)
 				}
 				return FlowResult{
 					ID:           fr.detail.ID,
@@ -133,6 +154,7 @@ func (fr *FlowRunner) Run() FlowResult {
 					StepsPassed:  fr.stepsPassed,
 					StepsFailed:  fr.stepsFailed,
 					StepsSkipped: fr.stepsSkipped,
+					DeviceLog:    deviceLog,
 				}
 			}
 		}
@@ -148,21 +170,11 @@ func (fr *FlowRunner) Run() FlowResult {
 		}
 
 		// Execute step
-		stepStatus, stepError, stepDuration := fr.executeStep(i, step)
-
-		// Notify step complete
-		if fr.config.OnStepComplete != nil {
-			fr.config.OnStepComplete(i, step.Describe(), stepStatus == report.StatusPassed, stepDuration, stepError)
-		}
+		stepStatus, stepError := fr.executeStep(i, step)
 
 		// Track step counts (compound steps like runFlow/repeat/retry don't count themselves,
 		// their sub-steps are counted individually in executeNestedStep)
-		isCompoundStep := false
-		switch step.(type) {
-		case *flow.RepeatStep, *flow.RetryStep, *flow.RunFlowStep:
-			isCompoundStep = true
-		}
-		if !isCompoundStep {
+		if !isCompoundStep(step) {
 			switch stepStatus {
 			case report.StatusPassed:
 				fr.stepsPassed++
@@ -183,10 +195,7 @@ func (fr *FlowRunner) Run() FlowResult {
 			fr.flowWriter.SkipRemainingCommands(i + 1)
 			// Count remaining non-compound steps as skipped
 			for j := i + 1; j < len(fr.flow.Steps); j++ {
-				switch fr.flow.Steps[j].(type) {
-				case *flow.RepeatStep, *flow.RetryStep, *flow.RunFlowStep:
-					// Compound steps don't count themselves
-				default:
+				if !isCompoundStep(fr.flow.Steps[j]) {
 					fr.stepsSkipped++
 				}
 			}
@@ -196,6 +205,23 @@ func (fr *FlowRunner) Run() FlowResult {
 		}
 	}
 
+	// onFlowComplete runs even when the flow failed. Its failure fails a
+	// passing flow but never replaces the error that failed it first.
+	if hookErr := fr.runOnFlowComplete(); hookErr != "" {
+		if flowStatus == report.StatusPassed {
+			flowStatus = report.StatusFailed
+		}
+		flowError = withHookError(flowError, hookErr)
+	}
+
+	if recording {
+		fr.finishFailureRecording(flowStatus)
+	}
+	var deviceLog string
+	if logcat && flowStatus == report.StatusFailed {
+		deviceLog = fr.saveDeviceLogs()
+	}
+
 	// Mark flow as complete
 	fr.flowWriter.End(flowStatus)
 
@@ -203,8 +229,10 @@ func (fr *FlowRunner) Run() FlowResult {
 	flowDuration := time.Since(flowStart).Milliseconds()
 
 	// Notify flow end
-	if fr.config.OnFlowEnd != nil {
-		fr.config.OnFlowEnd(flowName, flowStatus == report.StatusPassed, flowDuration, flowError)
+	if fr.config.Sink != nil {
+		ev := fr.flowEvent(flowName, flowFile)
+		ev.Passed, ev.DurationMs, ev.Error = flowStatus == report.StatusPassed, flowDuration, flowError
+		fr.config.Sink.FlowEnd(ev)
 	}
 
 	logger.Info("=== Flow completed: %s (status: %s, duration: %dms, passed: %d, failed: %d, skipped: %d) ===",
@@ -220,17 +248,139 @@ func (fr *FlowRunner) Run() FlowResult {
 		StepsPassed:  fr.stepsPassed,
 		StepsFailed:  fr.stepsFailed,
 		StepsSkipped: fr.stepsSkipped,
+		DeviceLog:    deviceLog,
+	}
+}
+
+// runOnFlowComplete runs the onFlowComplete hooks. Like a defer, every hook
+// runs even after one fails; the first required failure is returned, or ""
+// when they all passed.
+func (fr *FlowRunner) runOnFlowComplete() string {
+	var hookErr string
+	for _, step := range fr.flow.Config.OnFlowComplete {
+		result := fr.executeNestedStep(step)
+		if result.Success || step.IsOptional() {
+			continue
+		}
+		msg := fmt.Sprintf("onFlowComplete failed: %s: %s", step.Describe(), result.Message)
+		logger.Warn("%s", msg)
+		if hookErr == "" {
+			hookErr = msg
+		}
+	}
+	return hookErr
+}
+
+// withHookError appends a hook failure to the flow's error, keeping the
+// original failure first.
+func withHookError(flowError, hookErr string) string {
+	switch {
+	case hookErr == "":
+		return flowError
+	case flowError == "":
+		return hookErr
+	default:
+		return flowError + "; " + hookErr
+	}
+}
+
+// Files used by RecordOnFailure, inside the flow's assets directory.
+const (
+	recordingFile        = "recording.mp4"
+	failureRecordingFile = "failure.mp4"
+)
+
+// startFailureRecording starts a recording that finishFailureRecording keeps
+// only if the flow fails. Returns false when the driver can't record.
+func (fr *FlowRunner) startFailureRecording() bool {
+	result := fr.driver.Execute(&flow.StartRecordingStep{
+		BaseStep: flow.BaseStep{StepType: flow.StepStartRecording},
+	})
+	if !result.Success {
+		logger.Warn("Record on failure: could not start recording: %s", result.Message)
+		return false
+	}
+	return true
+}
+
+// finishFailureRecording stops the recording, then deletes it for a passing
+// flow or keeps it as the flow's video for a failing one.
+func (fr *FlowRunner) finishFailureRecording(status report.Status) {
+	path := filepath.Join(fr.flowWriter.AssetsDir(), recordingFile)
+	result := fr.driver.Execute(&flow.StopRecordingStep{
+		BaseStep: flow.BaseStep{StepType: flow.StepStopRecording},
+		Path:     path,
+	})
+	if !result.Success {
+		logger.Warn("Record on failure: could not stop recording: %s", result.Message)
+		_ = os.Remove(path)
+		return
+	}
+
+	if status != report.StatusFailed {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			logger.Warn("Record on failure: could not discard recording: %v", err)
+		}
+		return
+	}
+
+	kept := filepath.Join(fr.flowWriter.AssetsDir(), failureRecordingFile)
+	if err := os.Rename(path, kept); err != nil {
+		logger.Warn("Record on failure: could not keep recording: %v", err)
+		return
+	}
+	artifacts := fr.flowWriter.GetFlowDetail().Artifacts
+	artifacts.Video = filepath.Join("assets", fr.detail.ID, failureRecordingFile)
+	fr.flowWriter.SetFlowArtifacts(artifacts)
+}
+
+// startLogCapture starts collecting device logs for LogcatOnFailure.
+// Returns false when the driver has no device logs.
+func (fr *FlowRunner) startLogCapture() bool {
+	capturer, ok := fr.driver.(core.LogCapturer)
+	if !ok {
+		logger.Warn("Logcat on failure: not supported by this driver")
+		return false
+	}
+	if err := capturer.StartLogCapture(); err != nil {
+		logger.Warn("Logcat on failure: could not start capture: %v", err)
+		return false
+	}
+	return true
+}
+
+// saveDeviceLogs saves the logs collected since startLogCapture as the
+// flow's device log and returns its report-relative path, or "" on error.
+func (fr *FlowRunner) saveDeviceLogs() string {
+	appID := fr.flow.Config.AppID
+	if appID == "" {
+		appID = fr.config.App.ID
+	}
+	data, err := fr.driver.(core.LogCapturer).DeviceLogs(appID, fr.config.LogcatTag)
+	if err != nil {
+		logger.Warn("Logcat on failure: could not read logs: %v", err)
+		return ""
+	}
+	path, err := fr.flowWriter.SaveDeviceLog(data)
+	if err != nil {
+		logger.Warn("Logcat on failure: could not save logs: %v", err)
+		return ""
 	}
+	artifacts := fr.flowWriter.GetFlowDetail().Artifacts
+	artifacts.DeviceLog = path
+	fr.flowWriter.SetFlowArtifacts(artifacts)
+	return path
 }
 
 // executeStep executes a single step and updates the report.
-// Returns status, error message, and duration in milliseconds.
-func (fr *FlowRunner) executeStep(idx int, step flow.Step) (report.Status, string, int64) {
+// Returns status and error message.
+func (fr *FlowRunner) executeStep(idx int, step flow.Step) (report.Status, string) {
 	stepStart := time.Now()
 
 	logger.Debug("Executing step %d: %s", idx, step.Describe())
 
 	// Mark step as started
+	fr.stepIdx = idx
 	fr.flowWriter.CommandStart(idx)
 
 	// Determine what artifacts to capture
@@ -243,12 +393,145 @@ func (fr *FlowRunner) executeStep(idx int, step flow.Step) (report.Status, strin
 		artifacts = fr.captureArtifacts(idx, "before")
 	}
 
-	// Expand variables in step before execution
-	fr.script.ExpandStep(step)
+	// Expand variables on a copy: the flow's steps are shared when the same
+	// flow runs on several devices, and retries must expand from the template
+	step = fr.script.ExpandedStep(step)
+
+	// Execute step, re-running it on failure when it sets retry
+	result := fr.withStepRetry(step, func() *core.CommandResult {
+		return fr.dispatchStep(idx, step, &artifacts)
+	})
+	fr.script.SetLastResult(result)
+
+	stepDuration := time.Since(stepStart).Milliseconds()
+
+	// Determine status and error
+	var status report.Status
+	var errorInfo *report.Error
+	var errorMsg string
 
-	// Execute step - route to appropriate handler
+	if result.Success {
+		status = report.StatusPassed
+		logger.Debug("Step %d completed successfully (%dms): %s", idx, stepDuration, step.Describe())
+	} else {
+		status = report.StatusFailed
+		errorInfo = commandResultToError(result)
+		if errorInfo != nil {
+			errorMsg = errorInfo.Message
+		}
+		logger.Error("Step %d failed (%dms): %s - Error: %s", idx, stepDuration, step.Describe(), errorMsg)
+	}
+
+	// Capture after screenshot (on failure or always)
+	shouldCaptureAfter := captureAlways || (captureOnFailure && !result.Success)
+	if shouldCaptureAfter {
+		afterArtifacts := fr.captureArtifacts(idx, "after")
+		artifacts.ScreenshotAfter = afterArtifacts.ScreenshotAfter
+		artifacts.ViewHierarchy = afterArtifacts.ViewHierarchy
+	}
+
+	// Convert element info
+	var element *report.Element
+	if result.Element != nil {
+		element = commandResultToElement(result)
+	}
+
+	fr.notifyStepResult(idx, 0, step, result, stepDuration, errorMsg, artifacts.ScreenshotAfter)
+
+	// Update report - use CommandEndWithSubs for compound steps
+	if isCompoundStep(step) {
+		fr.flowWriter.CommandEndWithSubs(idx, status, element, errorInfo, artifacts, fr.subCommands)
+		fr.subCommands = nil // Clear after use
+	} else {
+		fr.flowWriter.CommandEnd(idx, status, element, errorInfo, artifacts)
+	}
+
+	return status, errorMsg
+}
+
+// flowEvent returns the FlowEvent for this flow, without its outcome.
+func (fr *FlowRunner) flowEvent(name, file string) FlowEvent {
+	return FlowEvent{
+		Index:  fr.flowIdx,
+		Total:  fr.totalFlows,
+		Name:   name,
+		File:   file,
+		Device: fr.config.DeviceInfo,
+	}
+}
+
+// notifyStepResult passes a finished step to the result sink. screenshot is
+// the step's after-screenshot, if one was captured.
+func (fr *FlowRunner) notifyStepResult(idx, depth int, step flow.Step, result *core.CommandResult, durationMs int64, errMsg, screenshot string) {
+	if fr.config.Sink == nil {
+		return
+	}
+	fr.config.Sink.StepResult(StepEvent{
+		Flow:        fr.detail.Name,
+		Index:       idx,
+		Depth:       depth,
+		Type:        string(step.Type()),
+		Description: step.Describe(),
+		Selector:    report.DescribeSelector(step),
+		Success:     result.Success,
+		Message:     result.Message,
+		DurationMs:  durationMs,
+		Error:       errMsg,
+		Screenshot:  screenshot,
+	})
+}
+
+// withStepRetry runs a step, re-running it after a failure up to the step's
+// retry count with retryDelayMs between attempts. It returns the last result,
+// so the step only fails when every attempt failed. Optional steps run once,
+// since their failure is ignored anyway, and a request the driver's server
+// rejected (core.IsRetryable) is not re-sent.
+func (fr *FlowRunner) withStepRetry(step flow.Step, run func() *core.CommandResult) *core.CommandResult {
+	// Sub-steps recorded by a failed attempt of a compound step are dropped,
+	// so only the last attempt is reported and counted
+	subLen := len(fr.subCommands)
+	passed, failed, skipped := fr.stepsPassed, fr.stepsFailed, fr.stepsSkipped
+
+	result := run()
+	if step.IsOptional() {
+		return result
+	}
+	retries, delayMs := step.RetryPolicy()
+	for attempt := 1; attempt <= retries && !result.Success; attempt++ {
+		if !core.IsRetryable(result.Error) {
+			logger.Info("Not retrying step rejected by the driver: %s - %s", step.Describe(), result.Message)
+			break
+		}
+		logger.Info("Retrying step (attempt %d of %d): %s - %s", attempt+1, retries+1, step.Describe(), result.Message)
+		select {
+		case <-fr.ctx.Done():
+			return result
+		case <-time.After(time.Duration(delayMs) * time.Millisecond):
+		}
+		fr.subCommands = fr.subCommands[:subLen]
+		fr.stepsPassed, fr.stepsFailed, fr.stepsSkipped = passed, failed, skipped
+		result = run()
+	}
+	return result
+}
+
+// isCompoundStep reports whether step runs sub-steps of its own. Compound
+// steps are reported with their sub-commands and don't count themselves in
+// the step totals; their sub-steps are counted individually instead.
+func isCompoundStep(step flow.Step) bool {
+	switch step.(type) {
+	case *flow.RepeatStep, *flow.IfStep, *flow.RetryStep, *flow.RunFlowStep, *flow.AssertNoJankStep:
+		return true
+	}
+	return false
+}
+
+// dispatchStep routes a top-level step to its handler. Screenshot paths saved
+// by the step are recorded in artifacts.
+func (fr *FlowRunner) dispatchStep(idx int, step flow.Step, artifacts *report.CommandArtifacts) *core.CommandResult {
 	var result *core.CommandResult
 
+	step = withAppID(step, fr.appID)
 	switch s := step.(type) {
 	// JS/Scripting steps - handled by ScriptEngine
 	case *flow.DefineVariablesStep:
@@ -267,6 +550,9 @@ func (fr *FlowRunner) executeStep(idx int, step flow.Step) (report.Status, strin
 	case *flow.RepeatStep:
 		fr.subCommands = nil
 		result = fr.executeRepeat(s)
+	case *flow.IfStep:
+		fr.subCommands = nil
+		result = fr.executeIf(s)
 	case *flow.RetryStep:
 		fr.subCommands = nil
 		result = fr.executeRetry(s)
@@ -274,31 +560,17 @@ func (fr *FlowRunner) executeStep(idx int, step flow.Step) (report.Status, strin
 		fr.subCommands = nil
 		result = fr.executeRunFlow(s)
 
-	// App lifecycle steps - inject flow's appId if not specified
-	case *flow.LaunchAppStep:
-		if s.AppID == "" && fr.flow.Config.AppID != "" {
-			s.AppID = fr.flow.Config.AppID
-		}
-		result = fr.driver.Execute(step)
-	case *flow.StopAppStep:
-		if s.AppID == "" && fr.flow.Config.AppID != "" {
-			s.AppID = fr.flow.Config.AppID
-		}
-		result = fr.driver.Execute(step)
-	case *flow.KillAppStep:
-		if s.AppID == "" && fr.flow.Config.AppID != "" {
-			s.AppID = fr.flow.Config.AppID
-		}
-		result = fr.driver.Execute(step)
-	case *flow.ClearStateStep:
-		if s.AppID == "" && fr.flow.Config.AppID != "" {
-			s.AppID = fr.flow.Config.AppID
+	case *flow.AssertNoJankStep:
+		fr.subCommands = nil
+		result = fr.executeAssertNoJank(s)
+	case *flow.InputTextStep:
+		if s.TypeDelayMs == 0 {
+			s.TypeDelayMs = fr.config.TypeDelayMs
 		}
 		result = fr.driver.Execute(step)
 
 	// CopyTextFrom - delegate to driver and sync copied text to script engine
 	case *flow.CopyTextFromStep:
-		fr.script.ExpandStep(step) // Expand variables in selector
 		result = fr.driver.Execute(step)
 		if result.Success && result.Data != nil {
 			if text, ok := result.Data.(string); ok {
@@ -311,91 +583,60 @@ func (fr *FlowRunner) executeStep(idx int, step flow.Step) (report.Status, strin
 		result = fr.driver.Execute(step)
 		if result.Success {
 			if data, ok := result.Data.([]byte); ok && len(data) > 0 {
-				path, saveErr := fr.flowWriter.SaveNamedScreenshot(idx, s.Path, data)
+				reportPath, path, saveErr := fr.saveScreenshot(idx, s.Path, data)
 				if saveErr != nil {
 					logger.Warn("Failed to save screenshot: %v", saveErr)
 				} else {
-					artifacts.ScreenshotAfter = path
-					result.Message = fmt.Sprintf("Screenshot saved: %s", filepath.Base(path))
+					artifacts.ScreenshotAfter = reportPath
+					result.Data = path
+					result.Message = fmt.Sprintf("Screenshot saved: %s", path)
 				}
 			}
 		}
 
-	// PasteText - use in-memory copiedText first, clipboard as fallback
-	case *flow.PasteTextStep:
-		text := fr.script.GetCopiedText()
-		if text != "" {
-			// Use stored copiedText (like Maestro does)
-			inputStep := &flow.InputTextStep{Text: text}
-			result = fr.driver.Execute(inputStep)
-			if result.Success {
-				result.Message = fmt.Sprintf("Pasted text: %s", text)
-			}
-		} else {
-			// Fallback to clipboard
-			result = fr.driver.Execute(step)
-		}
+	// AssertThemeColor - sampled from a driver screenshot on any platform
+	case *flow.AssertThemeColorStep:
+		result = fr.executeAssertThemeColor(s)
 
-	// All other steps - delegate to driver
-	default:
-		result = fr.driver.Execute(step)
-	}
-
-	stepDuration := time.Since(stepStart).Milliseconds()
+	// AssertScreenshot - compared against a baseline image on any platform
+	case *flow.AssertScreenshotStep:
+		result = fr.executeAssertScreenshot(s)
 
-	// Determine status and error
-	var status report.Status
-	var errorInfo *report.Error
-	var errorMsg string
-
-	if result.Success {
-		status = report.StatusPassed
-		logger.Debug("Step %d completed successfully (%dms): %s", idx, stepDuration, step.Describe())
-	} else {
-		status = report.StatusFailed
-		errorInfo = commandResultToError(result)
-		if errorInfo != nil {
-			errorMsg = errorInfo.Message
+	// AssertFileExists - local artifacts are checked here, device paths by the driver
+	case *flow.AssertFileExistsStep:
+		if s.Device {
+			result = fr.driver.Execute(step)
+		} else {
+			result = assertLocalFileExists(fr.flowWriter.AssetsDir(), s)
 		}
-		logger.Error("Step %d failed (%dms): %s - Error: %s", idx, stepDuration, step.Describe(), errorMsg)
-	}
-
-	// Capture after screenshot (on failure or always)
-	shouldCaptureAfter := captureAlways || (captureOnFailure && !result.Success)
-	if shouldCaptureAfter {
-		afterArtifacts := fr.captureArtifacts(idx, "after")
-		artifacts.ScreenshotAfter = afterArtifacts.ScreenshotAfter
-		artifacts.ViewHierarchy = afterArtifacts.ViewHierarchy
-	}
 
-	// Convert element info
-	var element *report.Element
-	if result.Element != nil {
-		element = commandResultToElement(result)
-	}
+	// PasteText - use in-memory copiedText first, clipboard as fallback
+	case *flow.PasteTextStep:
+		result = fr.executePasteText(s)
 
-	// Update report - use CommandEndWithSubs for compound steps
-	switch step.(type) {
-	case *flow.RepeatStep, *flow.RetryStep, *flow.RunFlowStep:
-		fr.flowWriter.CommandEndWithSubs(idx, status, element, errorInfo, artifacts, fr.subCommands)
-		fr.subCommands = nil // Clear after use
+	// All other steps - delegate to driver
 	default:
-		fr.flowWriter.CommandEnd(idx, status, element, errorInfo, artifacts)
+		result = fr.driver.Execute(step)
 	}
-
-	return status, errorMsg, stepDuration
+	return result
 }
 
 // executeRepeat handles repeat step execution.
 func (fr *FlowRunner) executeRepeat(step *flow.RepeatStep) *core.CommandResult {
-	times := fr.script.ParseInt(step.Times, 1)
+	hasWhile := step.While.Visible != nil || step.While.NotVisible != nil || step.While.Script != ""
+
+	// Without times, a while loop runs until its condition fails
+	defaultTimes := 1
+	if hasWhile {
+		defaultTimes = 0
+	}
+	times := fr.script.ParseInt(step.Times, defaultTimes)
 	if times <= 0 {
 		times = 1000 // Default max iterations for while loops
 	}
 
-	hasWhile := step.While.Visible != nil || step.While.NotVisible != nil || step.While.Script != ""
-
-	for i := 0; i < times; i++ {
+	iterations := 0
+	for ; iterations < times; iterations++ {
 		// Check context
 		if fr.ctx.Err() != nil {
 			return &core.CommandResult{
@@ -423,7 +664,27 @@ func (fr *FlowRunner) executeRepeat(step *flow.RepeatStep) *core.CommandResult {
 
 	return &core.CommandResult{
 		Success: true,
-		Message: fmt.Sprintf("Repeat completed (%d iterations)", times),
+		Message: fmt.Sprintf("Repeat completed (%d iterations)", iterations),
+	}
+}
+
+// executeIf runs the then branch when the condition is met, otherwise the else branch.
+func (fr *FlowRunner) executeIf(step *flow.IfStep) *core.CommandResult {
+	branch, name := step.Else, "else"
+	if fr.script.CheckCondition(fr.ctx, step.Condition, fr.driver) {
+		branch, name = step.Then, "then"
+	}
+
+	for _, nestedStep := range branch {
+		result := fr.executeNestedStep(nestedStep)
+		if !result.Success && !nestedStep.IsOptional() {
+			return result
+		}
+	}
+
+	return &core.CommandResult{
+		Success: true,
+		Message: fmt.Sprintf("If completed (%s branch, %d steps)", name, len(branch)),
 	}
 }
 
@@ -497,8 +758,8 @@ func (fr *FlowRunner) executeRunFlow(step *flow.RunFlowStep) *core.CommandResult
 	}
 
 	// Report nested flow start
-	if fr.config.OnNestedFlowStart != nil && step.File != "" {
-		fr.config.OnNestedFlowStart(fr.depth+1, "Run "+step.File)
+	if fr.config.Sink != nil && step.File != "" {
+		fr.config.Sink.NestedFlowStart(fr.depth+1, "Run "+step.File)
 	}
 
 	// Increment depth for nested execution
@@ -544,17 +805,77 @@ func (fr *FlowRunner) executeRunFlow(step *flow.RunFlowStep) *core.CommandResult
 	return fr.executeSubFlow(*subFlow)
 }
 
+// executeAssertNoJank resets the app's frame stats, runs the nested commands
+// like any other compound step and checks the janky frame share rendered
+// during them.
+func (fr *FlowRunner) executeAssertNoJank(step *flow.AssertNoJankStep) *core.CommandResult {
+	stats, ok := fr.driver.(core.FrameStatsReader)
+	if !ok {
+		return &core.CommandResult{
+			Success: false,
+			Error:   fmt.Errorf("frame stats not supported by this driver"),
+			Message: "assertNoJank is only supported on Android",
+		}
+	}
+
+	if err := stats.ResetFrameStats(step.AppID); err != nil {
+		return &core.CommandResult{
+			Success: false,
+			Error:   err,
+			Message: fmt.Sprintf("Failed to reset frame stats: %v", err),
+		}
+	}
+	for _, nestedStep := range step.Steps {
+		result := fr.executeNestedStep(nestedStep)
+		if !result.Success && !nestedStep.IsOptional() {
+			return result
+		}
+	}
+
+	total, janky, err := stats.FrameStats(step.AppID)
+	if err != nil {
+		return &core.CommandResult{
+			Success: false,
+			Error:   err,
+			Message: fmt.Sprintf("Failed to read frame stats: %v", err),
+		}
+	}
+	if total == 0 {
+		return &core.CommandResult{
+			Success: false,
+			Error:   fmt.Errorf("no frames rendered"),
+			Message: fmt.Sprintf("%s rendered no frames; is it in the foreground?", step.AppID),
+		}
+	}
+
+	percent := float64(janky) * 100 / float64(total)
+	summary := fmt.Sprintf("%d of %d frames janky (%.2f%%, max %g%%)", janky, total, percent, step.MaxJanky())
+	if percent > step.MaxJanky() {
+		return &core.CommandResult{
+			Success: false,
+			Error:   fmt.Errorf("janky frames %.2f%% exceed %g%%", percent, step.MaxJanky()),
+			Message: "Too much jank: " + summary,
+		}
+	}
+	return &core.CommandResult{
+		Success: true,
+		Message: summary,
+	}
+}
+
 // executeNestedStep executes a step without report tracking (for nested execution).
 func (fr *FlowRunner) executeNestedStep(step flow.Step) *core.CommandResult {
 	start := time.Now()
 	var result *core.CommandResult
 
+	// Expand variables on a copy at execution time, so a body that runs again
+	// (repeat/retry) sees values set since its previous iteration
+	step = fr.script.ExpandedStep(step)
+
 	// For nested compound steps, we need to track their sub-commands separately
 	var nestedSubCommands []report.Command
-	isCompoundStep := false
-	switch step.(type) {
-	case *flow.RepeatStep, *flow.RetryStep, *flow.RunFlowStep:
-		isCompoundStep = true
+	compound := isCompoundStep(step)
+	if compound {
 		// Save parent's subCommands and start fresh for this nested compound step
 		parentSubCommands := fr.subCommands
 		fr.subCommands = nil
@@ -564,57 +885,15 @@ func (fr *FlowRunner) executeNestedStep(step flow.Step) *core.CommandResult {
 		}()
 	}
 
-	switch s := step.(type) {
-	case *flow.DefineVariablesStep:
-		result = fr.script.ExecuteDefineVariables(s)
-	case *flow.RunScriptStep:
-		result = fr.script.ExecuteRunScript(s)
-	case *flow.EvalScriptStep:
-		result = fr.script.ExecuteEvalScript(s)
-	case *flow.AssertTrueStep:
-		result = fr.script.ExecuteAssertTrue(s)
-	case *flow.AssertConditionStep:
-		result = fr.script.ExecuteAssertCondition(fr.ctx, s, fr.driver)
-	case *flow.RepeatStep:
-		result = fr.executeRepeat(s)
-	case *flow.RetryStep:
-		result = fr.executeRetry(s)
-	case *flow.RunFlowStep:
-		result = fr.executeRunFlow(s)
-	case *flow.TakeScreenshotStep:
-		fr.script.ExpandStep(step)
-		result = fr.driver.Execute(step)
-		if result.Success {
-			if data, ok := result.Data.([]byte); ok && len(data) > 0 {
-				subIdx := len(fr.subCommands)
-				path, saveErr := fr.flowWriter.SaveNamedScreenshot(subIdx, s.Path, data)
-				if saveErr != nil {
-					logger.Warn("Failed to save nested screenshot: %v", saveErr)
-				} else {
-					result.Message = fmt.Sprintf("Screenshot saved: %s", filepath.Base(path))
-				}
-			}
-		}
-	case *flow.CopyTextFromStep:
-		// Expand variables before driver execution
-		fr.script.ExpandStep(step)
-		result = fr.driver.Execute(step)
-		// Sync copied text to script engine
-		if result.Success && result.Data != nil {
-			if text, ok := result.Data.(string); ok {
-				fr.script.SetCopiedText(text)
-			}
-		}
-	default:
-		// Expand variables before driver execution
-		fr.script.ExpandStep(step)
-		result = fr.driver.Execute(step)
-	}
+	result = fr.withStepRetry(step, func() *core.CommandResult {
+		return fr.dispatchNestedStep(step)
+	})
+	fr.script.SetLastResult(result)
 
 	duration := time.Since(start).Milliseconds()
 
 	// Track nested step counts (compound steps like runFlow/repeat/retry don't count themselves)
-	if !isCompoundStep {
+	if !compound {
 		if result.Success {
 			fr.stepsPassed++
 		} else {
@@ -622,13 +901,20 @@ func (fr *FlowRunner) executeNestedStep(step flow.Step) *core.CommandResult {
 		}
 	}
 
+	// Capture the screen where a nested step failed; the enclosing step's own
+	// after-screenshot is only taken once it has finished
+	var screenshot string
+	if !result.Success && !compound {
+		screenshot = fr.captureNestedFailure()
+	}
+
 	// Report nested step progress
-	if fr.config.OnNestedStep != nil && fr.depth > 0 {
+	if fr.depth > 0 {
 		errMsg := ""
 		if !result.Success && result.Error != nil {
 			errMsg = result.Error.Error()
 		}
-		fr.config.OnNestedStep(fr.depth, step.Describe(), result.Success, duration, errMsg)
+		fr.notifyStepResult(len(fr.subCommands), fr.depth, step, result, duration, errMsg, screenshot)
 	}
 
 	// Add to parent's sub-commands for report
@@ -648,6 +934,7 @@ func (fr *FlowRunner) executeNestedStep(step flow.Step) *core.CommandResult {
 		StartTime: &start,
 		EndTime:   &now,
 		Duration:  &duration,
+		Artifacts: report.CommandArtifacts{ScreenshotAfter: screenshot},
 	}
 
 	// Add error info if failed
@@ -659,7 +946,7 @@ func (fr *FlowRunner) executeNestedStep(step flow.Step) *core.CommandResult {
 	}
 
 	// Add nested sub-commands for compound steps
-	if isCompoundStep {
+	if compound {
 		cmd.SubCommands = nestedSubCommands
 	}
 
@@ -668,6 +955,149 @@ func (fr *FlowRunner) executeNestedStep(step flow.Step) *core.CommandResult {
 	return result
 }
 
+// dispatchNestedStep routes a nested step to its handler.
+func (fr *FlowRunner) dispatchNestedStep(step flow.Step) *core.CommandResult {
+	var result *core.CommandResult
+
+	step = withAppID(step, fr.appID)
+	switch s := step.(type) {
+	case *flow.DefineVariablesStep:
+		result = fr.script.ExecuteDefineVariables(s)
+	case *flow.RunScriptStep:
+		result = fr.script.ExecuteRunScript(s)
+	case *flow.EvalScriptStep:
+		result = fr.script.ExecuteEvalScript(s)
+	case *flow.AssertTrueStep:
+		result = fr.script.ExecuteAssertTrue(s)
+	case *flow.AssertConditionStep:
+		result = fr.script.ExecuteAssertCondition(fr.ctx, s, fr.driver)
+	case *flow.RepeatStep:
+		result = fr.executeRepeat(s)
+	case *flow.IfStep:
+		result = fr.executeIf(s)
+	case *flow.RetryStep:
+		result = fr.executeRetry(s)
+	case *flow.RunFlowStep:
+		result = fr.executeRunFlow(s)
+	case *flow.AssertNoJankStep:
+		result = fr.executeAssertNoJank(s)
+	case *flow.TakeScreenshotStep:
+		result = fr.driver.Execute(step)
+		if result.Success {
+			if data, ok := result.Data.([]byte); ok && len(data) > 0 {
+				subIdx := len(fr.subCommands)
+				_, path, saveErr := fr.saveScreenshot(subIdx, s.Path, data)
+				if saveErr != nil {
+					logger.Warn("Failed to save nested screenshot: %v", saveErr)
+				} else {
+					result.Data = path
+					result.Message = fmt.Sprintf("Screenshot saved: %s", path)
+				}
+			}
+		}
+	case *flow.AssertThemeColorStep:
+		result = fr.executeAssertThemeColor(s)
+	case *flow.AssertScreenshotStep:
+		result = fr.executeAssertScreenshot(s)
+	case *flow.AssertFileExistsStep:
+		if s.Device {
+			result = fr.driver.Execute(step)
+		} else {
+			result = assertLocalFileExists(fr.flowWriter.AssetsDir(), s)
+		}
+	case *flow.CopyTextFromStep:
+		result = fr.driver.Execute(step)
+		// Sync copied text to script engine
+		if result.Success && result.Data != nil {
+			if text, ok := result.Data.(string); ok {
+				fr.script.SetCopiedText(text)
+			}
+		}
+	case *flow.PasteTextStep:
+		result = fr.executePasteText(s)
+	case *flow.InputTextStep:
+		if s.TypeDelayMs == 0 {
+			s.TypeDelayMs = fr.config.TypeDelayMs
+		}
+		result = fr.driver.Execute(step)
+	default:
+		result = fr.driver.Execute(step)
+	}
+	return result
+}
+
+// withAppID returns step with appID filled in when it is an app-scoped step
+// that leaves its appId unset (openLink only needs one to clear state). The
+// step is copied before it is changed, so the flow's steps keep their template.
+func withAppID(step flow.Step, appID string) flow.Step {
+	if appID == "" {
+		return step
+	}
+	switch s := step.(type) {
+	case *flow.LaunchAppStep:
+		if s.AppID == "" {
+			cp := *s
+			cp.AppID = appID
+			return &cp
+		}
+	case *flow.StopAppStep:
+		if s.AppID == "" {
+			cp := *s
+			cp.AppID = appID
+			return &cp
+		}
+	case *flow.KillAppStep:
+		if s.AppID == "" {
+			cp := *s
+			cp.AppID = appID
+			return &cp
+		}
+	case *flow.ClearStateStep:
+		if s.AppID == "" {
+			cp := *s
+			cp.AppID = appID
+			return &cp
+		}
+	case *flow.AssertResourceStep:
+		if s.AppID == "" {
+			cp := *s
+			cp.AppID = appID
+			return &cp
+		}
+	case *flow.SetAppLocaleStep:
+		if s.AppID == "" {
+			cp := *s
+			cp.AppID = appID
+			return &cp
+		}
+	case *flow.SetPreferenceStep:
+		if s.AppID == "" {
+			cp := *s
+			cp.AppID = appID
+			return &cp
+		}
+	case *flow.AssertNoJankStep:
+		if s.AppID == "" {
+			cp := *s
+			cp.AppID = appID
+			return &cp
+		}
+	case *flow.WaitForInstallStep:
+		if s.AppID == "" {
+			cp := *s
+			cp.AppID = appID
+			return &cp
+		}
+	case *flow.OpenLinkStep:
+		if s.ClearState && s.AppID == "" {
+			cp := *s
+			cp.AppID = appID
+			return &cp
+		}
+	}
+	return step
+}
+
 // executeSubFlow executes a sub-flow without separate report tracking.
 func (fr *FlowRunner) executeSubFlow(subFlow flow.Flow) *core.CommandResult {
 	// Save current flow dir
@@ -680,6 +1110,13 @@ func (fr *FlowRunner) executeSubFlow(subFlow flow.Flow) *core.CommandResult {
 	// Apply sub-flow env
 	defer fr.script.withEnvVars(subFlow.Config.Env)()
 
+	// App-scoped steps default to the sub-flow's appId
+	if subFlow.Config.AppID != "" {
+		prevAppID := fr.appID
+		fr.appID = subFlow.Config.AppID
+		defer func() { fr.appID = prevAppID }()
+	}
+
 	// Execute steps
 	for _, step := range subFlow.Steps {
 		if fr.ctx.Err() != nil {
@@ -690,26 +1127,6 @@ func (fr *FlowRunner) executeSubFlow(subFlow flow.Flow) *core.CommandResult {
 			}
 		}
 
-		// Inject subflow's appId into app lifecycle steps (same as executeStep does for main flow)
-		switch s := step.(type) {
-		case *flow.LaunchAppStep:
-			if s.AppID == "" && subFlow.Config.AppID != "" {
-				s.AppID = subFlow.Config.AppID
-			}
-		case *flow.StopAppStep:
-			if s.AppID == "" && subFlow.Config.AppID != "" {
-				s.AppID = subFlow.Config.AppID
-			}
-		case *flow.KillAppStep:
-			if s.AppID == "" && subFlow.Config.AppID != "" {
-				s.AppID = subFlow.Config.AppID
-			}
-		case *flow.ClearStateStep:
-			if s.AppID == "" && subFlow.Config.AppID != "" {
-				s.AppID = subFlow.Config.AppID
-			}
-		}
-
 		result := fr.executeNestedStep(step)
 		if !result.Success && !step.IsOptional() {
 			return result
@@ -752,6 +1169,269 @@ func (fr *FlowRunner) executeSubFlowWithRetry(subFlow flow.Flow, maxRetries int)
 	}
 }
 
+// assertLocalFileExists looks for a file matching s.Path with at least
+// s.MinSize bytes. Relative patterns resolve against dir, and a bare file name
+// also matches the "cmd-NNN-" prefix takeScreenshot adds to saved names.
+func assertLocalFileExists(dir string, s *flow.AssertFileExistsStep) *core.CommandResult {
+	if s.Path == "" {
+		return &core.CommandResult{
+			Success: false,
+			Error:   fmt.Errorf("no path specified"),
+			Message: "assertFileExists requires a path",
+		}
+	}
+
+	patterns := []string{s.Path}
+	if !filepath.IsAbs(s.Path) {
+		patterns = []string{filepath.Join(dir, s.Path)}
+		if filepath.Base(s.Path) == s.Path {
+			patterns = append(patterns, filepath.Join(dir, "cmd-*-"+s.Path))
+		}
+	}
+
+	largest, largestSize := "", int64(-1)
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return &core.CommandResult{
+				Success: false,
+				Error:   err,
+				Message: fmt.Sprintf("Invalid file pattern %q: %v", s.Path, err),
+			}
+		}
+		for _, match := range matches {
+			info, err := os.Stat(match)
+			if err != nil || info.IsDir() {
+				continue
+			}
+			if info.Size() >= s.MinSize {
+				return &core.CommandResult{
+					Success: true,
+					Message: fmt.Sprintf("File exists: %s (%d bytes)", filepath.Base(match), info.Size()),
+				}
+			}
+			if info.Size() > largestSize {
+				largest, largestSize = match, info.Size()
+			}
+		}
+	}
+
+	if largest != "" {
+		return &core.CommandResult{
+			Success: false,
+			Error:   fmt.Errorf("file too small"),
+			Message: fmt.Sprintf("File %s is %d bytes, below minSize %d", filepath.Base(largest), largestSize, s.MinSize),
+		}
+	}
+	return &core.CommandResult{
+		Success: false,
+		Error:   fmt.Errorf("file not found"),
+		Message: fmt.Sprintf("No file matching %q found", s.Path),
+	}
+}
+
+// executePasteText types the text from the flow's last copyTextFrom, like
+// Maestro does. With nothing copied yet it falls back to the driver, which
+// pastes the device clipboard.
+func (fr *FlowRunner) executePasteText(s *flow.PasteTextStep) *core.CommandResult {
+	text := fr.script.GetCopiedText()
+	if text == "" {
+		return fr.driver.Execute(s)
+	}
+	result := fr.driver.Execute(&flow.InputTextStep{Text: text, TypeDelayMs: fr.config.TypeDelayMs})
+	if result.Success {
+		result.Message = fmt.Sprintf("Pasted text: %s", text)
+	}
+	return result
+}
+
+// saveScreenshot stores a takeScreenshot capture in the report assets and,
+// when the step sets a path, also writes it there. ${testName} and
+// ${stepIndex} in the path are filled in before the usual variable
+// expansion, and relative paths resolve against the flow directory. Without
+// a path the report copy gets a timestamped name. It returns the report
+// path and the path shown to the user.
+func (fr *FlowRunner) saveScreenshot(idx int, name string, data []byte) (string, string, error) {
+	if name == "" {
+		name = "screenshot-" + time.Now().Format("20060102-150405") + ".png"
+		reportPath, err := fr.flowWriter.SaveNamedScreenshot(idx, name, data)
+		if err != nil {
+			return "", "", err
+		}
+		return reportPath, filepath.Join(fr.config.OutputDir, reportPath), nil
+	}
+
+	name = strings.NewReplacer(
+		"${testName}", fr.detail.Name,
+		"${stepIndex}", strconv.Itoa(idx),
+	).Replace(name)
+	path := fr.script.ResolvePath(fr.script.ExpandVariables(name))
+	if filepath.Ext(path) == "" {
+		path += ".png"
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", "", err
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return "", "", err
+	}
+
+	reportPath, err := fr.flowWriter.SaveNamedScreenshot(idx, filepath.Base(path), data)
+	if err != nil {
+		return "", "", err
+	}
+	return reportPath, path, nil
+}
+
+// executeAssertThemeColor takes a screenshot and checks its app bar color.
+func (fr *FlowRunner) executeAssertThemeColor(s *flow.AssertThemeColorStep) *core.CommandResult {
+	data, err := fr.driver.Screenshot()
+	if err != nil {
+		return &core.CommandResult{
+			Success: false,
+			Error:   err,
+			Message: fmt.Sprintf("Failed to take screenshot: %v", err),
+		}
+	}
+	return assertThemeColor(data, s)
+}
+
+// assertThemeColor compares the dominant color of the screenshot's top
+// s.RegionPercent() band against s.Color, allowing s.ColorTolerance() per channel.
+func assertThemeColor(screenshot []byte, s *flow.AssertThemeColorStep) *core.CommandResult {
+	want, err := core.ParseHexColor(s.Color)
+	if err != nil {
+		return &core.CommandResult{
+			Success: false,
+			Error:   err,
+			Message: "assertThemeColor requires a color in #RRGGBB form",
+		}
+	}
+	got, err := core.DominantColor(screenshot, s.RegionPercent())
+	if err != nil {
+		return &core.CommandResult{
+			Success: false,
+			Error:   err,
+			Message: fmt.Sprintf("Failed to sample theme color: %v", err),
+		}
+	}
+
+	distance := core.ColorDistance(got, want)
+	if distance > s.ColorTolerance() {
+		return &core.CommandResult{
+			Success: false,
+			Error:   fmt.Errorf("theme color mismatch"),
+			Message: fmt.Sprintf("Theme color is %s, expected %s (difference %d exceeds tolerance %d)",
+				core.HexColor(got), core.HexColor(want), distance, s.ColorTolerance()),
+		}
+	}
+	return &core.CommandResult{
+		Success: true,
+		Message: fmt.Sprintf("Theme color %s matches %s", core.HexColor(got), core.HexColor(want)),
+	}
+}
+
+// executeAssertScreenshot takes a screenshot and compares it to the baseline.
+func (fr *FlowRunner) executeAssertScreenshot(s *flow.AssertScreenshotStep) *core.CommandResult {
+	data, err := fr.driver.Screenshot()
+	if err != nil {
+		return &core.CommandResult{
+			Success: false,
+			Error:   err,
+			Message: fmt.Sprintf("Failed to take screenshot: %v", err),
+		}
+	}
+	return assertScreenshot(data, fr.script.ResolvePath(s.Baseline), s)
+}
+
+// assertScreenshot compares the screenshot against the image at baseline,
+// failing when more than s.ThresholdPercent() of the pixels differ. A missing
+// baseline is written from the screenshot when s.UpdateBaseline is set.
+func assertScreenshot(screenshot []byte, baseline string, s *flow.AssertScreenshotStep) *core.CommandResult {
+	if s.Baseline == "" {
+		return &core.CommandResult{
+			Success: false,
+			Error:   fmt.Errorf("baseline is required"),
+			Message: "assertScreenshot requires a baseline image path",
+		}
+	}
+
+	want, err := os.ReadFile(baseline)
+	if os.IsNotExist(err) && s.UpdateBaseline {
+		if err := os.MkdirAll(filepath.Dir(baseline), 0o755); err != nil {
+			return &core.CommandResult{Success: false, Error: err, Message: fmt.Sprintf("Failed to write baseline: %v", err)}
+		}
+		if err := os.WriteFile(baseline, screenshot, 0o644); err != nil {
+			return &core.CommandResult{Success: false, Error: err, Message: fmt.Sprintf("Failed to write baseline: %v", err)}
+		}
+		return &core.CommandResult{Success: true, Message: "Baseline written: " + baseline}
+	}
+	if os.IsNotExist(err) {
+		return &core.CommandResult{
+			Success: false,
+			Error:   err,
+			Message: fmt.Sprintf("Baseline not found: %s (set updateBaseline: true to create it)", baseline),
+		}
+	}
+	if err != nil {
+		return &core.CommandResult{Success: false, Error: err, Message: fmt.Sprintf("Failed to read baseline: %v", err)}
+	}
+
+	diff, diffImg, err := imagediff.Diff(want, screenshot)
+	if err != nil {
+		return &core.CommandResult{Success: false, Error: err, Message: fmt.Sprintf("Failed to compare screenshot: %v", err)}
+	}
+	percent := diff * 100
+	if percent <= s.ThresholdPercent() {
+		return &core.CommandResult{
+			Success: true,
+			Message: fmt.Sprintf("Screenshot matches baseline (%.2f%% different)", percent),
+		}
+	}
+
+	msg := fmt.Sprintf("Screenshot differs from baseline by %.2f%% (threshold %.2f%%)", percent, s.ThresholdPercent())
+	if diffImg == nil {
+		msg += ", image sizes differ"
+	} else if s.DiffImage {
+		diffPath := baseline[:len(baseline)-len(filepath.Ext(baseline))] + ".diff.png"
+		if f, err := os.Create(diffPath); err != nil {
+			logger.Warn("assertScreenshot: failed to write diff image: %v", err)
+		} else {
+			err := png.Encode(f, diffImg)
+			f.Close()
+			if err != nil {
+				logger.Warn("assertScreenshot: failed to write diff image: %v", err)
+			} else {
+				msg += ", diff written to " + diffPath
+			}
+		}
+	}
+	return &core.CommandResult{
+		Success: false,
+		Error:   fmt.Errorf("screenshot mismatch"),
+		Message: msg,
+	}
+}
+
+// captureNestedFailure saves a screenshot for a failed nested step as
+// cmd-NNN-failed-N.png under the top-level step it runs in. Returns the saved
+// path, or "" when artifacts are off or the screenshot fails.
+func (fr *FlowRunner) captureNestedFailure() string {
+	if fr.config.Artifacts == ArtifactNever {
+		return ""
+	}
+	data, err := fr.driver.Screenshot()
+	if err != nil || len(data) == 0 {
+		return ""
+	}
+	fr.nestedShots++
+	path, err := fr.flowWriter.SaveScreenshot(fr.stepIdx, fmt.Sprintf("failed-%d", fr.nestedShots), data)
+	if err != nil {
+		return ""
+	}
+	return path
+}
+
 // captureArtifacts captures screenshots and hierarchy.
 func (fr *FlowRunner) captureArtifacts(cmdIdx int, timing string) report.CommandArtifacts {
 	var artifacts report.CommandArtifacts
@@ -27,45 +27,8 @@ type workItem struct {
 
 // ParallelRunner coordinates parallel test execution across multiple devices.
 type ParallelRunner struct {
-	workers     []DeviceWorker
-	config      RunnerConfig
-	outputMutex sync.Mutex
-}
-
-// Terminal color codes for parallel output
-const (
-	colorReset = "\033[0m"
-	colorGreen = "\033[32m"
-	colorRed   = "\033[31m"
-	colorGray  = "\033[90m"
-	colorCyan  = "\033[36m"
-)
-
-func color(c string) string {
-	return c
-}
-
-// formatDeviceLabel creates a short device label for event logs
-func formatDeviceLabel(device *report.Device) string {
-	if device == nil {
-		return "Unknown"
-	}
-	// For event logs, just show device name
-	return device.Name
-}
-
-// formatDuration formats milliseconds as human-readable duration
-func formatDuration(ms int64) string {
-	if ms < 1000 {
-		return fmt.Sprintf("%dms", ms)
-	}
-	seconds := float64(ms) / 1000.0
-	if seconds < 60 {
-		return fmt.Sprintf("%.1fs", seconds)
-	}
-	minutes := int(seconds / 60)
-	secs := int(seconds) % 60
-	return fmt.Sprintf("%dm%ds", minutes, secs)
+	workers []DeviceWorker
+	config  RunnerConfig
 }
 
 // NewParallelRunner creates a parallel runner with multiple device workers.
@@ -79,10 +42,46 @@ func NewParallelRunner(workers []DeviceWorker, config RunnerConfig) *ParallelRun
 // Run executes flows in parallel using a work queue pattern.
 // All workers pull from the same queue until all flows are complete.
 func (pr *ParallelRunner) Run(ctx context.Context, flows []flow.Flow) (*RunResult, error) {
+	return pr.run(ctx, flows, false)
+}
+
+// RunOnAllDevices runs every flow on every worker's device concurrently, so
+// each device executes the whole suite. The report lists each flow once per
+// device, and the result's Devices field tallies the outcome per device.
+func (pr *ParallelRunner) RunOnAllDevices(ctx context.Context, flows []flow.Flow) (*RunResult, error) {
+	return pr.run(ctx, flows, true)
+}
+
+func (pr *ParallelRunner) run(ctx context.Context, flows []flow.Flow, allDevices bool) (*RunResult, error) {
 	if len(pr.workers) == 0 {
 		return nil, fmt.Errorf("no workers available")
 	}
 
+	// In all-devices mode each worker gets its own queue with a copy of every
+	// flow; otherwise all workers share one queue.
+	queues := make([]chan workItem, len(pr.workers))
+	if allDevices {
+		suite := flows
+		flows = make([]flow.Flow, 0, len(suite)*len(pr.workers))
+		for w := range pr.workers {
+			queues[w] = make(chan workItem, len(suite))
+			for _, f := range suite {
+				queues[w] <- workItem{flow: f, index: len(flows)}
+				flows = append(flows, f)
+			}
+			close(queues[w])
+		}
+	} else {
+		shared := make(chan workItem, len(flows))
+		for i, f := range flows {
+			shared <- workItem{flow: f, index: i}
+		}
+		close(shared)
+		for w := range queues {
+			queues[w] = shared
+		}
+	}
+
 	// Build shared report skeleton
 	builderCfg := report.BuilderConfig{
 		OutputDir:     pr.config.OutputDir,
@@ -111,13 +110,6 @@ func (pr *ParallelRunner) Run(ctx context.Context, flows []flow.Flow) (*RunResul
 	indexWriter.Start()
 	startTime := time.Now()
 
-	// Create work queue with flow indices
-	workQueue := make(chan workItem, len(flows))
-	for i, f := range flows {
-		workQueue <- workItem{flow: f, index: i}
-	}
-	close(workQueue)
-
 	// Results collection
 	results := make([]FlowResult, len(flows))
 	var resultsMu sync.Mutex
@@ -130,7 +122,7 @@ func (pr *ParallelRunner) Run(ctx context.Context, flows []flow.Flow) (*RunResul
 		wg.Add(1)
 		worker := pr.workers[i]
 
-		go func(w DeviceWorker) {
+		go func(w DeviceWorker, workQueue <-chan workItem) {
 			defer wg.Done()
 
 			// Capture device info for this worker
@@ -147,51 +139,6 @@ func (pr *ParallelRunner) Run(ctx context.Context, flows []flow.Flow) (*RunResul
 			workerConfig := pr.config
 			workerConfig.DeviceInfo = deviceInfo
 
-			// Create device-specific callbacks that include device info in output
-			deviceLabel := formatDeviceLabel(deviceInfo)
-
-			// Store flow info for OnFlowEnd callback
-			var currentFlowIdx int
-			var currentTotalFlows int
-			var currentFlowFile string
-
-			workerConfig.OnFlowStart = func(flowIdx, totalFlows int, name, file string) {
-				// Store for OnFlowEnd
-				currentFlowIdx = flowIdx
-				currentTotalFlows = totalFlows
-				currentFlowFile = file
-
-				pr.outputMutex.Lock()
-				defer pr.outputMutex.Unlock()
-				fmt.Printf("[%d/%d] %s (%s) - %s⚡ Started%s on %s\n",
-					flowIdx+1, totalFlows, name, file, color(colorCyan), color(colorReset), deviceLabel)
-			}
-
-			workerConfig.OnFlowEnd = func(name string, passed bool, durationMs int64, errMsg string) {
-				pr.outputMutex.Lock()
-				defer pr.outputMutex.Unlock()
-
-				status := "✓ Passed"
-				statusColor := color(colorGreen)
-				if !passed {
-					status = "✗ Failed"
-					statusColor = color(colorRed)
-				}
-
-				fmt.Printf("[%d/%d] %s (%s) - %s%s%s on %s (%s)\n",
-					currentFlowIdx+1, currentTotalFlows, name, currentFlowFile,
-					statusColor, status, color(colorReset), deviceLabel, formatDuration(durationMs))
-
-				if !passed && errMsg != "" {
-					fmt.Printf("  Error: %s\n", errMsg)
-				}
-			}
-
-			// Suppress detailed command output during parallel execution
-			workerConfig.OnStepComplete = func(idx int, desc string, passed bool, durationMs int64, errMsg string) {}
-			workerConfig.OnNestedStep = func(depth int, desc string, passed bool, durationMs int64, errMsg string) {}
-			workerConfig.OnNestedFlowStart = func(depth int, desc string) {}
-
 			// Create runner for this worker with device-specific config
 			runner := &Runner{
 				config: workerConfig,
@@ -206,12 +153,14 @@ func (pr *ParallelRunner) Run(ctx context.Context, flows []flow.Flow) (*RunResul
 				// Execute flow
 				result := runner.executeFlow(ctx, item.flow, &flowDetails[item.index], indexWriter, item.index, totalFlows)
 
+				result.Device = w.DeviceID
+
 				// Store result
 				resultsMu.Lock()
 				results[item.index] = result
 				resultsMu.Unlock()
 			}
-		}(worker)
+		}(worker, queues[i])
 	}
 
 	// Wait for all workers to complete
@@ -255,6 +204,8 @@ func (pr *ParallelRunner) buildRunResult(flowResults []FlowResult, wallClockDura
 		}
 	}
 
+	result.Devices = pr.deviceResults(flowResults)
+
 	// Determine overall status
 	if result.FailedFlows > 0 {
 		result.Status = report.StatusFailed
@@ -266,3 +217,32 @@ func (pr *ParallelRunner) buildRunResult(flowResults []FlowResult, wallClockDura
 
 	return result
 }
+
+// deviceResults tallies flow outcomes per worker device, in worker order.
+func (pr *ParallelRunner) deviceResults(flowResults []FlowResult) []DeviceResult {
+	if len(pr.workers) == 0 {
+		return nil
+	}
+	devices := make([]DeviceResult, len(pr.workers))
+	byID := make(map[string]*DeviceResult, len(pr.workers))
+	for i, w := range pr.workers {
+		devices[i].DeviceID = w.DeviceID
+		byID[w.DeviceID] = &devices[i]
+	}
+	for _, fr := range flowResults {
+		d := byID[fr.Device]
+		if d == nil {
+			continue
+		}
+		d.Total++
+		switch fr.Status {
+		case report.StatusPassed:
+			d.Passed++
+		case report.StatusFailed:
+			d.Failed++
+		case report.StatusSkipped:
+			d.Skipped++
+		}
+	}
+	return devices
+}
@@ -1,115 +1,16 @@
 package executor
 
 import (
+	"context"
+	"reflect"
+	"sync"
 	"testing"
 
+	"github.com/devicelab-dev/maestro-runner/pkg/core"
+	"github.com/devicelab-dev/maestro-runner/pkg/flow"
 	"github.com/devicelab-dev/maestro-runner/pkg/report"
 )
 
-func TestColor(t *testing.T) {
-	tests := []struct {
-		name  string
-		input string
-		want  string
-	}{
-		{"reset", colorReset, colorReset},
-		{"green", colorGreen, colorGreen},
-		{"red", colorRed, colorRed},
-		{"gray", colorGray, colorGray},
-		{"cyan", colorCyan, colorCyan},
-		{"empty string", "", ""},
-		{"arbitrary string", "hello", "hello"},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			got := color(tt.input)
-			if got != tt.want {
-				t.Errorf("color(%q) = %q, want %q", tt.input, got, tt.want)
-			}
-		})
-	}
-}
-
-func TestFormatDuration(t *testing.T) {
-	tests := []struct {
-		name     string
-		ms       int64
-		expected string
-	}{
-		{"zero ms", 0, "0ms"},
-		{"small ms", 100, "100ms"},
-		{"under 1s", 999, "999ms"},
-		{"exactly 1s", 1000, "1.0s"},
-		{"1.5s", 1500, "1.5s"},
-		{"under 1min", 59000, "59.0s"},
-		{"exactly 1min", 60000, "1m0s"},
-		{"1min 30s", 90000, "1m30s"},
-		{"2min 15s", 135000, "2m15s"},
-		{"10min", 600000, "10m0s"},
-		{"large value", 3661000, "61m1s"},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			got := formatDuration(tt.ms)
-			if got != tt.expected {
-				t.Errorf("formatDuration(%d) = %q, want %q", tt.ms, got, tt.expected)
-			}
-		})
-	}
-}
-
-func TestFormatDeviceLabel(t *testing.T) {
-	tests := []struct {
-		name     string
-		device   *report.Device
-		expected string
-	}{
-		{
-			name:     "nil device",
-			device:   nil,
-			expected: "Unknown",
-		},
-		{
-			name: "device with name",
-			device: &report.Device{
-				ID:   "emulator-5554",
-				Name: "Pixel 6",
-			},
-			expected: "Pixel 6",
-		},
-		{
-			name: "device with empty name",
-			device: &report.Device{
-				ID:   "emulator-5554",
-				Name: "",
-			},
-			expected: "",
-		},
-		{
-			name: "full device info",
-			device: &report.Device{
-				ID:          "ABC123",
-				Name:        "iPhone 15 Pro",
-				Platform:    "ios",
-				OSVersion:   "17.0",
-				IsSimulator: true,
-			},
-			expected: "iPhone 15 Pro",
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			got := formatDeviceLabel(tt.device)
-			if got != tt.expected {
-				t.Errorf("formatDeviceLabel() = %q, want %q", got, tt.expected)
-			}
-		})
-	}
-}
-
 func TestBuildRunResult(t *testing.T) {
 	pr := &ParallelRunner{}
 
@@ -198,3 +99,132 @@ func TestBuildRunResult(t *testing.T) {
 		}
 	})
 }
+
+func newParallelTestWorkers(failOn string) []DeviceWorker {
+	workers := make([]DeviceWorker, 2)
+	for i, id := range []string{"emulator-5554", "emulator-5556"} {
+		id := id
+		workers[i] = DeviceWorker{
+			ID:       i,
+			DeviceID: id,
+			Driver: &mockDriver{
+				executeFunc: func(step flow.Step) *core.CommandResult {
+					return &core.CommandResult{Success: id != failOn}
+				},
+				platformFunc: func() *core.PlatformInfo {
+					return &core.PlatformInfo{Platform: "android", DeviceID: id, DeviceName: id}
+				},
+			},
+			Cleanup: func() {},
+		}
+	}
+	return workers
+}
+
+func parallelTestFlows() []flow.Flow {
+	return []flow.Flow{
+		{SourcePath: "a.yaml", Config: flow.Config{Name: "A"}, Steps: []flow.Step{&flow.TapOnStep{BaseStep: flow.BaseStep{StepType: flow.StepTapOn}}}},
+		{SourcePath: "b.yaml", Config: flow.Config{Name: "B"}, Steps: []flow.Step{&flow.TapOnStep{BaseStep: flow.BaseStep{StepType: flow.StepTapOn}}}},
+		{SourcePath: "c.yaml", Config: flow.Config{Name: "C"}, Steps: []flow.Step{&flow.TapOnStep{BaseStep: flow.BaseStep{StepType: flow.StepTapOn}}}},
+	}
+}
+
+func TestParallelRunner_RunOnAllDevices(t *testing.T) {
+	pr := NewParallelRunner(newParallelTestWorkers("emulator-5556"), RunnerConfig{
+		OutputDir: t.TempDir(),
+		Artifacts: ArtifactNever,
+	})
+
+	result, err := pr.RunOnAllDevices(context.Background(), parallelTestFlows())
+	if err != nil {
+		t.Fatalf("RunOnAllDevices() error = %v", err)
+	}
+
+	if result.TotalFlows != 6 {
+		t.Errorf("TotalFlows = %d, want 6 (3 flows x 2 devices)", result.TotalFlows)
+	}
+	if result.Status != report.StatusFailed {
+		t.Errorf("Status = %v, want %v", result.Status, report.StatusFailed)
+	}
+	want := []DeviceResult{
+		{DeviceID: "emulator-5554", Total: 3, Passed: 3},
+		{DeviceID: "emulator-5556", Total: 3, Failed: 3},
+	}
+	if !reflect.DeepEqual(result.Devices, want) {
+		t.Errorf("Devices = %+v, want %+v", result.Devices, want)
+	}
+}
+
+func TestParallelRunner_RunSplitsFlows(t *testing.T) {
+	pr := NewParallelRunner(newParallelTestWorkers(""), RunnerConfig{
+		OutputDir: t.TempDir(),
+		Artifacts: ArtifactNever,
+	})
+
+	result, err := pr.Run(context.Background(), parallelTestFlows())
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if result.TotalFlows != 3 || result.PassedFlows != 3 {
+		t.Errorf("got %d/%d passed, want 3/3", result.PassedFlows, result.TotalFlows)
+	}
+	total := 0
+	for _, d := range result.Devices {
+		total += d.Total
+	}
+	if len(result.Devices) != 2 || total != 3 {
+		t.Errorf("Devices = %+v, want 2 devices sharing 3 flows", result.Devices)
+	}
+}
+
+func TestParallelRunner_RunOnAllDevicesExpandsPerDevice(t *testing.T) {
+	var mu sync.Mutex
+	typed := map[string][]string{}
+	workers := make([]DeviceWorker, 2)
+	for i, platform := range []string{"android", "ios"} {
+		platform := platform
+		workers[i] = DeviceWorker{
+			ID:       i,
+			DeviceID: platform,
+			Driver: &mockDriver{
+				executeFunc: func(step flow.Step) *core.CommandResult {
+					if s, ok := step.(*flow.InputTextStep); ok {
+						mu.Lock()
+						typed[platform] = append(typed[platform], s.Text)
+						mu.Unlock()
+					}
+					return &core.CommandResult{Success: true}
+				},
+				platformFunc: func() *core.PlatformInfo {
+					return &core.PlatformInfo{Platform: platform, DeviceID: platform, DeviceName: platform}
+				},
+			},
+			Cleanup: func() {},
+		}
+	}
+	input := &flow.InputTextStep{BaseStep: flow.BaseStep{StepType: flow.StepInputText}, Text: "on ${maestro.platform}"}
+	flows := []flow.Flow{
+		{SourcePath: "a.yaml", Config: flow.Config{Name: "A"}, Steps: []flow.Step{input}},
+		{SourcePath: "b.yaml", Config: flow.Config{Name: "B"}, Steps: []flow.Step{input}},
+	}
+
+	pr := NewParallelRunner(workers, RunnerConfig{
+		OutputDir:   t.TempDir(),
+		Artifacts:   ArtifactNever,
+		TypeDelayMs: 50,
+	})
+	if _, err := pr.RunOnAllDevices(context.Background(), flows); err != nil {
+		t.Fatalf("RunOnAllDevices() error = %v", err)
+	}
+
+	for _, platform := range []string{"android", "ios"} {
+		want := []string{"on " + platform, "on " + platform}
+		if !reflect.DeepEqual(typed[platform], want) {
+			t.Errorf("%s typed %q, want %q", platform, typed[platform], want)
+		}
+	}
+	if input.Text != "on ${maestro.platform}" || input.TypeDelayMs != 0 {
+		t.Errorf("shared step was modified: %+v", input)
+	}
+}
@@ -3,11 +3,13 @@ package executor
 
 import (
 	"context"
+	"io"
 	"path/filepath"
 	"sync"
 
 	"github.com/devicelab-dev/maestro-runner/pkg/core"
 	"github.com/devicelab-dev/maestro-runner/pkg/flow"
+	"github.com/devicelab-dev/maestro-runner/pkg/logger"
 	"github.com/devicelab-dev/maestro-runner/pkg/report"
 )
 
@@ -31,6 +33,16 @@ type RunnerConfig struct {
 	Retries     int          // Max retries per flow (0 = no retries)
 	Artifacts   ArtifactMode // When to capture artifacts
 
+	// RecordOnFailure records every flow and keeps the video only for flows
+	// that fail, so passing runs don't fill the report with recordings.
+	RecordOnFailure bool
+
+	// LogcatOnFailure collects device logs (adb logcat, the iOS simulator
+	// log) from the start of each flow and saves them for flows that fail.
+	// LogcatTag limits them to one tag instead of the app's process.
+	LogcatOnFailure bool
+	LogcatTag       string
+
 	// Device/App info for reports
 	Device report.Device
 	App    report.App
@@ -43,18 +55,26 @@ type RunnerConfig struct {
 	// Environment variables from CLI (-e KEY=VALUE)
 	Env map[string]string
 
+	// Variables from a --vars file; Env overrides them, they override flow env
+	Vars map[string]interface{}
+
+	// Tag filters: flows not selected by flow.ShouldIncludeFlow are reported
+	// as skipped instead of run
+	IncludeTags []string
+	ExcludeTags []string
+
 	// Driver settings
 	WaitForIdleTimeout int // Global wait for idle timeout in ms
+	TypeDelayMs        int // Default per-character inputText delay in ms (0 = type in one burst)
 
 	// Device information (set by executor)
 	DeviceInfo *report.Device
 
-	// Live progress callbacks
-	OnFlowStart       func(flowIdx, totalFlows int, name, file string)
-	OnStepComplete    func(idx int, desc string, passed bool, durationMs int64, err string)
-	OnNestedStep      func(depth int, desc string, passed bool, durationMs int64, err string)
-	OnNestedFlowStart func(depth int, desc string)
-	OnFlowEnd         func(name string, passed bool, durationMs int64, errMsg string)
+	// Sink receives live progress as flows and steps finish (nil = none)
+	Sink ResultSink
+
+	// ScriptOutput receives console.log output from scripts (nil = stdout)
+	ScriptOutput io.Writer
 }
 
 // RunResult contains the outcome of a test run.
@@ -66,6 +86,16 @@ type RunResult struct {
 	SkippedFlows int
 	Duration     int64 // Total duration in milliseconds
 	FlowResults  []FlowResult
+	Devices      []DeviceResult // Per-device tallies (parallel runs only)
+}
+
+// DeviceResult tallies the flows one device ran in a parallel run.
+type DeviceResult struct {
+	DeviceID string
+	Total    int
+	Passed   int
+	Failed   int
+	Skipped  int
 }
 
 // FlowResult contains the outcome of a single flow execution.
@@ -79,6 +109,8 @@ type FlowResult struct {
 	StepsPassed  int
 	StepsFailed  int
 	StepsSkipped int
+	Device       string // ID of the device that ran the flow (parallel runs only)
+	DeviceLog    string // Report-relative path of the device log saved on failure
 }
 
 // Runner orchestrates flow execution.
@@ -204,6 +236,9 @@ func (r *Runner) executeFlows(ctx context.Context, flows []flow.Flow, flowDetail
 
 // executeFlow runs a single flow.
 func (r *Runner) executeFlow(ctx context.Context, f flow.Flow, detail *report.FlowDetail, indexWriter *report.IndexWriter, flowIdx, totalFlows int) FlowResult {
+	if !flow.ShouldIncludeFlow(&f, r.config.IncludeTags, r.config.ExcludeTags) {
+		return r.skipFlow(f, detail, indexWriter)
+	}
 	fr := &FlowRunner{
 		ctx:         ctx,
 		flow:        f,
@@ -217,6 +252,23 @@ func (r *Runner) executeFlow(ctx context.Context, f flow.Flow, detail *report.Fl
 	return fr.Run()
 }
 
+// skipFlow records a flow left out by the tag filters as skipped, with all of
+// its commands skipped, without touching the device.
+func (r *Runner) skipFlow(f flow.Flow, detail *report.FlowDetail, indexWriter *report.IndexWriter) FlowResult {
+	logger.Info("Skipping flow %s: not selected by tags %v", detail.Name, f.Config.Tags)
+	fw := report.NewFlowWriter(detail, r.config.OutputDir, indexWriter)
+	fw.SkipRemainingCommands(0)
+	fw.End(report.StatusSkipped)
+	return FlowResult{
+		ID:           detail.ID,
+		Name:         detail.Name,
+		Status:       report.StatusSkipped,
+		Error:        "skipped by tags",
+		StepsTotal:   len(detail.Commands),
+		StepsSkipped: len(detail.Commands),
+	}
+}
+
 // buildRunResult aggregates flow results into a run result.
 func (r *Runner) buildRunResult(flowResults []FlowResult) *RunResult {
 	result := &RunResult{
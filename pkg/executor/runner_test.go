@@ -1,9 +1,16 @@
 package executor
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
+	"image"
+	imgcolor "image/color"
+	"image/png"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -122,6 +129,63 @@ func TestRunner_Run_AllPassed(t *testing.T) {
 	}
 }
 
+func TestRunner_Run_SkipsFlowsByTags(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	executed := 0
+	driver := &mockDriver{
+		executeFunc: func(step flow.Step) *core.CommandResult {
+			executed++
+			return &core.CommandResult{Success: true}
+		},
+	}
+
+	runner := New(driver, RunnerConfig{
+		OutputDir:   tmpDir,
+		Artifacts:   ArtifactNever,
+		ExcludeTags: []string{"slow"},
+	})
+
+	flows := []flow.Flow{
+		{
+			SourcePath: "fast.yaml",
+			Config:     flow.Config{Name: "Fast", Tags: []string{"smoke"}},
+			Steps:      []flow.Step{&flow.TapOnStep{BaseStep: flow.BaseStep{StepType: flow.StepTapOn}}},
+		},
+		{
+			SourcePath: "slow.yaml",
+			Config:     flow.Config{Name: "Slow", Tags: []string{"slow"}},
+			Steps:      []flow.Step{&flow.TapOnStep{BaseStep: flow.BaseStep{StepType: flow.StepTapOn}}},
+		},
+	}
+
+	result, err := runner.Run(context.Background(), flows)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if executed != 1 {
+		t.Errorf("executed %d steps, want 1", executed)
+	}
+	if result.Status != report.StatusPassed {
+		t.Errorf("Status = %v, want %v", result.Status, report.StatusPassed)
+	}
+	if result.PassedFlows != 1 || result.SkippedFlows != 1 {
+		t.Errorf("passed=%d skipped=%d, want 1 and 1", result.PassedFlows, result.SkippedFlows)
+	}
+	if got := result.FlowResults[1]; got.Status != report.StatusSkipped || got.Error != "skipped by tags" {
+		t.Errorf("slow flow = %+v, want skipped by tags", got)
+	}
+
+	index, err := report.ReadIndex(filepath.Join(tmpDir, "report.json"))
+	if err != nil {
+		t.Fatalf("ReadIndex() error = %v", err)
+	}
+	if index.Flows[1].Status != report.StatusSkipped {
+		t.Errorf("report status = %v, want %v", index.Flows[1].Status, report.StatusSkipped)
+	}
+}
+
 func TestRunner_Run_WithFailure(t *testing.T) {
 	tmpDir := t.TempDir()
 
@@ -418,6 +482,25 @@ func TestCommandResultToError(t *testing.T) {
 	if got.Message != "Could not find login button" {
 		t.Errorf("Message = %q, want %q", got.Message, "Could not find login button")
 	}
+	if got.Type != "unknown" {
+		t.Errorf("Type = %q, want %q", got.Type, "unknown")
+	}
+
+	// Classified driver errors keep their category
+	for _, tt := range []struct {
+		err  error
+		want string
+	}{
+		{core.ErrTimeout.WithCause(&testError{msg: "deadline"}), "timeout"},
+		{core.ErrServerUnreachable.WithCause(&testError{msg: "refused"}), "network"},
+		{core.ErrElementNotFound, "element_not_found"},
+		{core.ErrTextMismatch, "assertion"},
+	} {
+		got := commandResultToError(&core.CommandResult{Error: tt.err})
+		if got.Type != tt.want {
+			t.Errorf("Type for %v = %q, want %q", tt.err, got.Type, tt.want)
+		}
+	}
 }
 
 func TestRunner_Run_WithArtifacts(t *testing.T) {
@@ -520,6 +603,234 @@ func TestRunner_Run_ArtifactsOnFailure(t *testing.T) {
 // Flow Control Handler Tests
 // ===========================================
 
+func TestRunner_OnFlowCompleteHooks(t *testing.T) {
+	back := func() flow.Step { return &flow.BackStep{BaseStep: flow.BaseStep{StepType: flow.StepBack}} }
+	tap := func(text string) flow.Step {
+		return &flow.TapOnStep{BaseStep: flow.BaseStep{StepType: flow.StepTapOn}, Selector: flow.Selector{Text: text}}
+	}
+
+	tests := []struct {
+		name       string
+		config     flow.Config
+		steps      []flow.Step
+		wantStatus report.Status
+		wantError  string
+	}{
+		{
+			name:       "cleanup failure fails a passing flow",
+			config:     flow.Config{OnFlowComplete: []flow.Step{tap("Logout"), back()}},
+			steps:      []flow.Step{tap("Home")},
+			wantStatus: report.StatusFailed,
+			wantError:  "onFlowComplete failed: tapOn: text=\"Logout\": no Logout",
+		},
+		{
+			name:       "original failure stays first",
+			config:     flow.Config{OnFlowComplete: []flow.Step{tap("Logout"), back()}},
+			steps:      []flow.Step{tap("Checkout")},
+			wantStatus: report.StatusFailed,
+			wantError:  "no Checkout; onFlowComplete failed: tapOn: text=\"Logout\": no Logout",
+		},
+		{
+			name:       "cleanup runs after onFlowStart fails",
+			config:     flow.Config{OnFlowStart: []flow.Step{tap("Login")}, OnFlowComplete: []flow.Step{back()}},
+			steps:      []flow.Step{tap("Home")},
+			wantStatus: report.StatusFailed,
+			wantError:  "onFlowStart failed: no Login",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backs := 0
+			driver := &mockDriver{
+				executeFunc: func(step flow.Step) *core.CommandResult {
+					switch s := step.(type) {
+					case *flow.BackStep:
+						backs++
+					case *flow.TapOnStep:
+						if s.Selector.Text != "Home" {
+							return &core.CommandResult{Success: false, Error: &testError{msg: "no " + s.Selector.Text}, Message: "no " + s.Selector.Text}
+						}
+					}
+					return &core.CommandResult{Success: true}
+				},
+			}
+
+			runner := New(driver, RunnerConfig{
+				OutputDir:   t.TempDir(),
+				Parallelism: 0,
+				Artifacts:   ArtifactNever,
+				Device:      report.Device{ID: "test", Platform: "android"},
+			})
+
+			tt.config.Name = "Hooks Test"
+			result, err := runner.Run(context.Background(), []flow.Flow{{SourcePath: "test.yaml", Config: tt.config, Steps: tt.steps}})
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+
+			got := result.FlowResults[0]
+			if got.Status != tt.wantStatus {
+				t.Errorf("Status = %v, want %v", got.Status, tt.wantStatus)
+			}
+			if got.Error != tt.wantError {
+				t.Errorf("Error = %q, want %q", got.Error, tt.wantError)
+			}
+			// Every cleanup step runs, even after an earlier one failed
+			if backs != 1 {
+				t.Errorf("onFlowComplete back steps run = %d, want 1", backs)
+			}
+		})
+	}
+}
+
+func TestRunner_StepRetry(t *testing.T) {
+	tests := []struct {
+		name       string
+		retry      int
+		nested     bool
+		optional   bool
+		wantCalls  int
+		wantPass   bool
+		wantFailed int
+	}{
+		{"succeeds on last attempt", 2, false, false, 3, true, 0},
+		{"fails when all attempts fail", 1, false, false, 2, false, 1},
+		{"no retry by default", 0, false, false, 1, false, 1},
+		{"nested step", 2, true, false, 3, true, 0},
+		{"optional step runs once", 2, false, true, 1, true, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			driver := &mockDriver{
+				executeFunc: func(step flow.Step) *core.CommandResult {
+					if _, ok := step.(*flow.AssertVisibleStep); ok {
+						calls++
+						// Element shows up on the third check
+						return &core.CommandResult{Success: calls >= 3, Message: "not visible yet"}
+					}
+					return &core.CommandResult{Success: true}
+				},
+			}
+
+			runner := New(driver, RunnerConfig{
+				OutputDir:   t.TempDir(),
+				Parallelism: 0,
+				Artifacts:   ArtifactNever,
+				Device:      report.Device{ID: "test", Platform: "android"},
+			})
+
+			var step flow.Step = &flow.AssertVisibleStep{
+				BaseStep: flow.BaseStep{StepType: flow.StepAssertVisible, Retry: tt.retry, RetryDelayMs: 1, Optional: tt.optional},
+				Selector: flow.Selector{Text: "Synced"},
+			}
+			if tt.nested {
+				step = &flow.RunFlowStep{BaseStep: flow.BaseStep{StepType: flow.StepRunFlow}, Steps: []flow.Step{step}}
+			}
+
+			result, err := runner.Run(context.Background(), []flow.Flow{
+				{SourcePath: "test.yaml", Config: flow.Config{Name: "Retry Test"}, Steps: []flow.Step{step}},
+			})
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+
+			if calls != tt.wantCalls {
+				t.Errorf("attempts = %d, want %d", calls, tt.wantCalls)
+			}
+			if passed := result.Status == report.StatusPassed; passed != tt.wantPass {
+				t.Errorf("Status = %v, want passed=%v", result.Status, tt.wantPass)
+			}
+			if got := result.FlowResults[0].StepsFailed; got != tt.wantFailed {
+				t.Errorf("StepsFailed = %d, want %d", got, tt.wantFailed)
+			}
+		})
+	}
+}
+
+func TestRunner_StepRetryRejectedRequest(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		wantCalls int
+	}{
+		{"rejected request runs once", fmt.Errorf("WDA error: bad selector: %w", core.ErrRequestRejected), 1},
+		{"timeout is retried", core.ErrTimeout, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			driver := &mockDriver{
+				executeFunc: func(step flow.Step) *core.CommandResult {
+					if _, ok := step.(*flow.AssertVisibleStep); ok {
+						calls++
+						return &core.CommandResult{Success: false, Error: tt.err, Message: tt.err.Error()}
+					}
+					return &core.CommandResult{Success: true}
+				},
+			}
+
+			runner := New(driver, RunnerConfig{
+				OutputDir:   t.TempDir(),
+				Parallelism: 0,
+				Artifacts:   ArtifactNever,
+				Device:      report.Device{ID: "test", Platform: "ios"},
+			})
+
+			step := &flow.AssertVisibleStep{
+				BaseStep: flow.BaseStep{StepType: flow.StepAssertVisible, Retry: 2, RetryDelayMs: 1},
+				Selector: flow.Selector{Text: "Synced"},
+			}
+			if _, err := runner.Run(context.Background(), []flow.Flow{
+				{SourcePath: "test.yaml", Config: flow.Config{Name: "Retry Test"}, Steps: []flow.Step{step}},
+			}); err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+
+			if calls != tt.wantCalls {
+				t.Errorf("attempts = %d, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestRunner_StepRetryCompound(t *testing.T) {
+	calls := 0
+	driver := &mockDriver{
+		executeFunc: func(step flow.Step) *core.CommandResult {
+			if _, ok := step.(*flow.AssertVisibleStep); ok {
+				calls++
+				return &core.CommandResult{Success: calls >= 3, Message: "not visible yet"}
+			}
+			return &core.CommandResult{Success: true}
+		},
+	}
+	runner := New(driver, RunnerConfig{OutputDir: t.TempDir(), Artifacts: ArtifactNever})
+
+	step := &flow.RunFlowStep{
+		BaseStep: flow.BaseStep{StepType: flow.StepRunFlow, Retry: 2, RetryDelayMs: 1},
+		Steps: []flow.Step{
+			&flow.TapOnStep{BaseStep: flow.BaseStep{StepType: flow.StepTapOn}, Selector: flow.Selector{Text: "Sync"}},
+			&flow.AssertVisibleStep{BaseStep: flow.BaseStep{StepType: flow.StepAssertVisible}, Selector: flow.Selector{Text: "Synced"}},
+		},
+	}
+	result, err := runner.Run(context.Background(), []flow.Flow{
+		{SourcePath: "test.yaml", Steps: []flow.Step{step}},
+	})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	// Only the passing third attempt is counted
+	fr := result.FlowResults[0]
+	if fr.Status != report.StatusPassed || fr.StepsPassed != 2 || fr.StepsFailed != 0 {
+		t.Errorf("status = %s, passed = %d, failed = %d; want passed, 2, 0", fr.Status, fr.StepsPassed, fr.StepsFailed)
+	}
+}
+
 func TestRunner_RepeatStep_FixedTimes(t *testing.T) {
 	tmpDir := t.TempDir()
 
@@ -568,6 +879,62 @@ func TestRunner_RepeatStep_FixedTimes(t *testing.T) {
 	}
 }
 
+func TestRunner_CopyTextFromThenPasteTextInRepeat(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	var typed []string
+	driver := &mockDriver{
+		executeFunc: func(step flow.Step) *core.CommandResult {
+			switch s := step.(type) {
+			case *flow.CopyTextFromStep:
+				return &core.CommandResult{Success: true, Data: "ABC-123"}
+			case *flow.InputTextStep:
+				typed = append(typed, s.Text)
+			case *flow.PasteTextStep:
+				return &core.CommandResult{Success: false, Message: "driver clipboard should not be used"}
+			}
+			return &core.CommandResult{Success: true}
+		},
+	}
+
+	runner := New(driver, RunnerConfig{
+		OutputDir:   tmpDir,
+		Parallelism: 0,
+		Artifacts:   ArtifactNever,
+		Device:      report.Device{ID: "test", Platform: "android"},
+	})
+
+	flows := []flow.Flow{
+		{
+			SourcePath: "test.yaml",
+			Config:     flow.Config{Name: "Copy Paste Test"},
+			Steps: []flow.Step{
+				&flow.CopyTextFromStep{BaseStep: flow.BaseStep{StepType: flow.StepCopyTextFrom}, Selector: flow.Selector{ID: "code"}},
+				&flow.PasteTextStep{BaseStep: flow.BaseStep{StepType: flow.StepPasteText}},
+				&flow.RepeatStep{
+					BaseStep: flow.BaseStep{StepType: flow.StepRepeat},
+					Times:    "1",
+					Steps: []flow.Step{
+						&flow.PasteTextStep{BaseStep: flow.BaseStep{StepType: flow.StepPasteText}},
+					},
+				},
+			},
+		},
+	}
+
+	result, err := runner.Run(context.Background(), flows)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if result.Status != report.StatusPassed {
+		t.Errorf("Status = %v, want %v", result.Status, report.StatusPassed)
+	}
+	if len(typed) != 2 || typed[0] != "ABC-123" || typed[1] != "ABC-123" {
+		t.Errorf("typed = %v, want the copied text pasted twice", typed)
+	}
+}
+
 func TestRunner_RepeatStep_WhileCondition(t *testing.T) {
 	tmpDir := t.TempDir()
 
@@ -907,6 +1274,57 @@ func TestRunner_RunFlowStep_WhenCondition(t *testing.T) {
 	}
 }
 
+func TestRunner_RunFlowStep_WhenTrueScript(t *testing.T) {
+	var typed []string
+	driver := &mockDriver{
+		executeFunc: func(step flow.Step) *core.CommandResult {
+			if s, ok := step.(*flow.InputTextStep); ok {
+				typed = append(typed, s.Text)
+			}
+			return &core.CommandResult{Success: true}
+		},
+	}
+
+	runner := New(driver, RunnerConfig{
+		OutputDir:   t.TempDir(),
+		Parallelism: 0,
+		Artifacts:   ArtifactNever,
+		Device:      report.Device{ID: "test", Platform: "android"},
+	})
+
+	// Commands run in the caller's scope, so output set inside the runFlow
+	// is visible to the steps after it
+	parsed, err := flow.Parse([]byte(`
+- evalScript: ${output.mode = "guest"}
+- runFlow:
+    when:
+      true: ${output.mode == "guest"}
+    commands:
+      - evalScript: ${output.greeting = "hi " + output.mode}
+- runFlow:
+    when:
+      true: ${output.mode == "admin"}
+    commands:
+      - evalScript: ${output.greeting = "admin"}
+- inputText: ${output.greeting}
+`), "test.yaml")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	result, err := runner.Run(context.Background(), []flow.Flow{*parsed})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if result.Status != report.StatusPassed {
+		t.Errorf("Status = %v, want %v", result.Status, report.StatusPassed)
+	}
+	if len(typed) != 1 || typed[0] != "hi guest" {
+		t.Errorf("typed = %v, want [hi guest]", typed)
+	}
+}
+
 func TestRunner_RunFlowStep_NoFileOrSteps(t *testing.T) {
 	tmpDir := t.TempDir()
 
@@ -1051,6 +1469,63 @@ func TestRunner_EvalScriptStep(t *testing.T) {
 	}
 }
 
+func TestRunner_EvalScriptOutputPersistsAcrossSteps(t *testing.T) {
+	var typed []string
+	driver := &mockDriver{
+		executeFunc: func(step flow.Step) *core.CommandResult {
+			if s, ok := step.(*flow.InputTextStep); ok {
+				typed = append(typed, s.Text)
+			}
+			return &core.CommandResult{Success: true}
+		},
+	}
+
+	runner := New(driver, RunnerConfig{
+		OutputDir:   t.TempDir(),
+		Parallelism: 0,
+		Artifacts:   ArtifactNever,
+		Device:      report.Device{ID: "test", Platform: "android"},
+	})
+
+	increment := func() flow.Step {
+		return &flow.EvalScriptStep{
+			BaseStep: flow.BaseStep{StepType: flow.StepEvalScript},
+			Script:   "${output.idx += 1}",
+		}
+	}
+	flows := []flow.Flow{
+		{
+			SourcePath: "test.yaml",
+			Config:     flow.Config{Name: "Eval Output Test"},
+			Steps: []flow.Step{
+				&flow.EvalScriptStep{
+					BaseStep: flow.BaseStep{StepType: flow.StepEvalScript},
+					Script:   "${output.idx = 0}",
+				},
+				increment(),
+				increment(),
+				increment(),
+				&flow.InputTextStep{
+					BaseStep: flow.BaseStep{StepType: flow.StepInputText},
+					Text:     "${output.idx}",
+				},
+			},
+		},
+	}
+
+	result, err := runner.Run(context.Background(), flows)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if result.Status != report.StatusPassed {
+		t.Errorf("Status = %v, want %v", result.Status, report.StatusPassed)
+	}
+	if len(typed) != 1 || typed[0] != "3" {
+		t.Errorf("typed = %v, want [3]", typed)
+	}
+}
+
 func TestRunner_AssertTrueStep(t *testing.T) {
 	tmpDir := t.TempDir()
 
@@ -1238,6 +1713,108 @@ name: Sub Flow
 	}
 }
 
+func TestRunner_RunFlowStep_ExternalFileAppID(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	subFlowContent := `appId: com.test
+---
+- assertResource:
+    maxMemoryMb: 200
+- openLink:
+    link: myapp://home
+    clearState: true
+`
+	if err := os.WriteFile(filepath.Join(tmpDir, "subflow.yaml"), []byte(subFlowContent), 0o644); err != nil {
+		t.Fatalf("Failed to write subflow: %v", err)
+	}
+
+	var appIDs []string
+	driver := &mockDriver{
+		executeFunc: func(step flow.Step) *core.CommandResult {
+			switch s := step.(type) {
+			case *flow.AssertResourceStep:
+				appIDs = append(appIDs, s.AppID)
+			case *flow.OpenLinkStep:
+				appIDs = append(appIDs, s.AppID)
+			}
+			return &core.CommandResult{Success: true}
+		},
+	}
+	runner := New(driver, RunnerConfig{OutputDir: tmpDir, Artifacts: ArtifactNever})
+
+	flows := []flow.Flow{{
+		SourcePath: filepath.Join(tmpDir, "main.yaml"),
+		Config:     flow.Config{AppID: "com.main"},
+		Steps: []flow.Step{&flow.RunFlowStep{
+			BaseStep: flow.BaseStep{StepType: flow.StepRunFlow},
+			File:     "subflow.yaml",
+		}},
+	}}
+	if _, err := runner.Run(context.Background(), flows); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if strings.Join(appIDs, ",") != "com.test,com.test" {
+		t.Errorf("appIds = %v, want the sub-flow's com.test for assertResource and openLink", appIDs)
+	}
+}
+
+func TestRunner_NestedStepsGetFlowAppID(t *testing.T) {
+	var appIDs []string
+	driver := &mockDriver{
+		executeFunc: func(step flow.Step) *core.CommandResult {
+			switch s := step.(type) {
+			case *flow.StopAppStep:
+				appIDs = append(appIDs, "stopApp="+s.AppID)
+			case *flow.AssertResourceStep:
+				appIDs = append(appIDs, "assertResource="+s.AppID)
+			case *flow.SetPreferenceStep:
+				appIDs = append(appIDs, "setPreference="+s.AppID)
+			case *flow.LaunchAppStep:
+				appIDs = append(appIDs, "launchApp="+s.AppID)
+			}
+			return &core.CommandResult{Success: true}
+		},
+	}
+	runner := New(driver, RunnerConfig{OutputDir: t.TempDir(), Artifacts: ArtifactNever})
+
+	stop := &flow.StopAppStep{BaseStep: flow.BaseStep{StepType: flow.StepStopApp}}
+	resource := &flow.AssertResourceStep{BaseStep: flow.BaseStep{StepType: flow.StepAssertResource}}
+	pref := &flow.SetPreferenceStep{BaseStep: flow.BaseStep{StepType: flow.StepSetPreference}, Key: "k", Value: "v"}
+	launch := &flow.LaunchAppStep{BaseStep: flow.BaseStep{StepType: flow.StepLaunchApp}}
+	flows := []flow.Flow{{
+		SourcePath: "main.yaml",
+		Config:     flow.Config{AppID: "com.main", OnFlowStart: []flow.Step{stop}},
+		Steps: []flow.Step{
+			&flow.RepeatStep{
+				BaseStep: flow.BaseStep{StepType: flow.StepRepeat},
+				Times:    "1",
+				Steps:    []flow.Step{resource},
+			},
+			&flow.RetryStep{
+				BaseStep:   flow.BaseStep{StepType: flow.StepRetry},
+				MaxRetries: "1",
+				Steps:      []flow.Step{pref},
+			},
+			&flow.RunFlowStep{
+				BaseStep: flow.BaseStep{StepType: flow.StepRunFlow},
+				Steps:    []flow.Step{launch},
+			},
+		},
+	}}
+	if _, err := runner.Run(context.Background(), flows); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	want := "stopApp=com.main,assertResource=com.main,setPreference=com.main,launchApp=com.main"
+	if got := strings.Join(appIDs, ","); got != want {
+		t.Errorf("appIds = %s, want %s", got, want)
+	}
+	if stop.AppID != "" || resource.AppID != "" || pref.AppID != "" || launch.AppID != "" {
+		t.Error("flow steps were modified, want the appId filled in on a copy")
+	}
+}
+
 func TestRunner_RunFlowStep_ExternalFileNotFound(t *testing.T) {
 	tmpDir := t.TempDir()
 
@@ -1979,7 +2556,7 @@ func TestRunner_TakeScreenshotStep_Success(t *testing.T) {
 
 	flows := []flow.Flow{
 		{
-			SourcePath: "test.yaml",
+			SourcePath: filepath.Join(tmpDir, "test.yaml"),
 			Config:     flow.Config{Name: "Screenshot Test"},
 			Steps: []flow.Step{
 				&flow.TakeScreenshotStep{
@@ -1999,24 +2576,24 @@ func TestRunner_TakeScreenshotStep_Success(t *testing.T) {
 		t.Errorf("Status = %v, want %v", result.Status, report.StatusPassed)
 	}
 
-	// Check that screenshot file was saved
-	screenshotPath := filepath.Join(tmpDir, "assets", "flow-000", "cmd-000-my-screenshot.png")
-	if _, err := os.Stat(screenshotPath); err != nil {
-		t.Errorf("screenshot file not created at %s: %v", screenshotPath, err)
+	// Check that screenshot file was saved next to the flow and in the report
+	for _, screenshotPath := range []string{
+		filepath.Join(tmpDir, "my-screenshot.png"),
+		filepath.Join(tmpDir, "assets", "flow-000", "cmd-000-my-screenshot.png"),
+	} {
+		if _, err := os.Stat(screenshotPath); err != nil {
+			t.Errorf("screenshot file not created at %s: %v", screenshotPath, err)
+		}
 	}
 }
 
-func TestRunner_TakeScreenshotStep_EmptyName(t *testing.T) {
+func TestRunner_TakeScreenshotStep_PathTemplate(t *testing.T) {
 	tmpDir := t.TempDir()
 
-	screenshotData := []byte{0x89, 0x50, 0x4E, 0x47}
 	driver := &mockDriver{
 		executeFunc: func(step flow.Step) *core.CommandResult {
 			if _, ok := step.(*flow.TakeScreenshotStep); ok {
-				return &core.CommandResult{
-					Success: true,
-					Data:    screenshotData,
-				}
+				return &core.CommandResult{Success: true, Data: []byte{0x89, 0x50, 0x4E, 0x47}}
 			}
 			return &core.CommandResult{Success: true}
 		},
@@ -2032,12 +2609,67 @@ func TestRunner_TakeScreenshotStep_EmptyName(t *testing.T) {
 
 	flows := []flow.Flow{
 		{
-			SourcePath: "test.yaml",
+			SourcePath: filepath.Join(tmpDir, "flows", "test.yaml"),
+			Config:     flow.Config{Name: "Login"},
+			Steps: []flow.Step{
+				&flow.DefineVariablesStep{
+					BaseStep: flow.BaseStep{StepType: flow.StepDefineVariables},
+					Env:      map[string]string{"LOCALE": "de"},
+				},
+				&flow.TakeScreenshotStep{
+					BaseStep: flow.BaseStep{StepType: flow.StepTakeScreenshot},
+					Path:     "shots/${LOCALE}/${testName}_${stepIndex}",
+				},
+			},
+		},
+	}
+
+	result, err := runner.Run(context.Background(), flows)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if result.Status != report.StatusPassed {
+		t.Fatalf("Status = %v, want %v", result.Status, report.StatusPassed)
+	}
+
+	want := filepath.Join(tmpDir, "flows", "shots", "de", "Login_1.png")
+	if _, err := os.Stat(want); err != nil {
+		t.Errorf("screenshot file not created at %s: %v", want, err)
+	}
+}
+
+func TestRunner_TakeScreenshotStep_EmptyName(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	screenshotData := []byte{0x89, 0x50, 0x4E, 0x47}
+	driver := &mockDriver{
+		executeFunc: func(step flow.Step) *core.CommandResult {
+			if _, ok := step.(*flow.TakeScreenshotStep); ok {
+				return &core.CommandResult{
+					Success: true,
+					Data:    screenshotData,
+				}
+			}
+			return &core.CommandResult{Success: true}
+		},
+	}
+
+	runner := New(driver, RunnerConfig{
+		OutputDir:   tmpDir,
+		Parallelism: 0,
+		Artifacts:   ArtifactNever,
+		Device:      report.Device{ID: "test", Platform: "android"},
+		App:         report.App{ID: "com.test"},
+	})
+
+	flows := []flow.Flow{
+		{
+			SourcePath: "test.yaml",
 			Config:     flow.Config{Name: "Screenshot Empty Name"},
 			Steps: []flow.Step{
 				&flow.TakeScreenshotStep{
 					BaseStep: flow.BaseStep{StepType: flow.StepTakeScreenshot},
-					Path:     "", // Empty name should default to screenshot.png
+					Path:     "", // Empty name should default to a timestamped name
 				},
 			},
 		},
@@ -2052,10 +2684,10 @@ func TestRunner_TakeScreenshotStep_EmptyName(t *testing.T) {
 		t.Errorf("Status = %v, want %v", result.Status, report.StatusPassed)
 	}
 
-	// Check that screenshot file was saved with default name
-	screenshotPath := filepath.Join(tmpDir, "assets", "flow-000", "cmd-000-screenshot.png")
-	if _, err := os.Stat(screenshotPath); err != nil {
-		t.Errorf("screenshot file not created at %s: %v", screenshotPath, err)
+	// Check that screenshot file was saved with a timestamped default name
+	matches, _ := filepath.Glob(filepath.Join(tmpDir, "assets", "flow-000", "cmd-000-screenshot-*.png"))
+	if len(matches) != 1 {
+		t.Errorf("expected one timestamped screenshot, got %v", matches)
 	}
 }
 
@@ -2200,3 +2832,1177 @@ func TestRunner_TakeScreenshotStep_EmptyData(t *testing.T) {
 		t.Errorf("Status = %v, want %v", result.Status, report.StatusPassed)
 	}
 }
+
+// recordingSink is a ResultSink that keeps every event.
+type recordingSink struct {
+	flows []FlowEvent
+	steps []StepEvent
+}
+
+func (s *recordingSink) FlowStart(ev FlowEvent)                 { s.flows = append(s.flows, ev) }
+func (s *recordingSink) NestedFlowStart(depth int, desc string) {}
+func (s *recordingSink) StepResult(ev StepEvent)                { s.steps = append(s.steps, ev) }
+func (s *recordingSink) FlowEnd(ev FlowEvent)                   { s.flows = append(s.flows, ev) }
+
+func TestRunner_ResultSink(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	driver := &mockDriver{
+		executeFunc: func(step flow.Step) *core.CommandResult {
+			if _, ok := step.(*flow.AssertVisibleStep); ok {
+				return &core.CommandResult{Success: false, Error: &testError{msg: "not found"}, Message: "Element not found"}
+			}
+			return &core.CommandResult{Success: true, Message: "Tapped"}
+		},
+	}
+
+	sink := &recordingSink{}
+	runner := New(driver, RunnerConfig{
+		OutputDir: tmpDir,
+		Artifacts: ArtifactNever,
+		Device:    report.Device{ID: "test", Platform: "android"},
+		App:       report.App{ID: "com.test"},
+		Sink:      sink,
+	})
+
+	flows := []flow.Flow{
+		{
+			SourcePath: "test.yaml",
+			Config:     flow.Config{Name: "Login"},
+			Steps: []flow.Step{
+				&flow.TapOnStep{
+					BaseStep: flow.BaseStep{StepType: flow.StepTapOn},
+					Selector: flow.Selector{ID: "login"},
+				},
+				&flow.AssertVisibleStep{
+					BaseStep: flow.BaseStep{StepType: flow.StepAssertVisible},
+					Selector: flow.Selector{Text: "Welcome"},
+				},
+			},
+		},
+	}
+
+	if _, err := runner.Run(context.Background(), flows); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	events := sink.steps
+	if len(events) != 2 {
+		t.Fatalf("expected 2 step events, got %d: %+v", len(events), events)
+	}
+	tap := events[0]
+	if tap.Flow != "Login" || tap.Index != 0 || tap.Type != "tapOn" || tap.Selector != "id=login" || !tap.Success || tap.Message != "Tapped" {
+		t.Errorf("unexpected tap event: %+v", tap)
+	}
+	if tap.Description != `tapOn: id="login"` {
+		t.Errorf("Description = %q", tap.Description)
+	}
+	assert := events[1]
+	if assert.Index != 1 || assert.Type != "assertVisible" || assert.Selector != "text=Welcome" || assert.Success || assert.Error == "" {
+		t.Errorf("unexpected assert event: %+v", assert)
+	}
+
+	if len(sink.flows) != 2 {
+		t.Fatalf("expected flow start and end events, got %+v", sink.flows)
+	}
+	if start := sink.flows[0]; start.Name != "Login" || start.File != "test.yaml" || start.Total != 1 {
+		t.Errorf("unexpected flow start: %+v", start)
+	}
+	if end := sink.flows[1]; end.Passed || end.Error == "" {
+		t.Errorf("unexpected flow end: %+v", end)
+	}
+}
+
+func TestRunner_IfStep_ThenBranch(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	var tapped []string
+	driver := &mockDriver{
+		executeFunc: func(step flow.Step) *core.CommandResult {
+			if tap, ok := step.(*flow.TapOnStep); ok {
+				tapped = append(tapped, tap.Selector.Text)
+			}
+			return &core.CommandResult{Success: true} // Condition check passes
+		},
+	}
+
+	runner := New(driver, RunnerConfig{
+		OutputDir:   tmpDir,
+		Parallelism: 0,
+		Artifacts:   ArtifactNever,
+		Device:      report.Device{ID: "test", Platform: "android"},
+	})
+
+	flows := []flow.Flow{
+		{
+			SourcePath: "test.yaml",
+			Config:     flow.Config{Name: "If Then Test"},
+			Steps: []flow.Step{
+				&flow.IfStep{
+					BaseStep:  flow.BaseStep{StepType: flow.StepIf},
+					Condition: flow.Condition{Visible: &flow.Selector{Text: "Welcome"}},
+					Then: []flow.Step{
+						&flow.TapOnStep{BaseStep: flow.BaseStep{StepType: flow.StepTapOn}, Selector: flow.Selector{Text: "then"}},
+					},
+					Else: []flow.Step{
+						&flow.TapOnStep{BaseStep: flow.BaseStep{StepType: flow.StepTapOn}, Selector: flow.Selector{Text: "else"}},
+					},
+				},
+			},
+		},
+	}
+
+	result, err := runner.Run(context.Background(), flows)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if result.Status != report.StatusPassed {
+		t.Errorf("Status = %v, want %v", result.Status, report.StatusPassed)
+	}
+	if len(tapped) != 1 || tapped[0] != "then" {
+		t.Errorf("tapped = %v, want [then]", tapped)
+	}
+}
+
+func TestRunner_IfStep_ElseBranch(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	var tapped []string
+	driver := &mockDriver{
+		executeFunc: func(step flow.Step) *core.CommandResult {
+			if _, ok := step.(*flow.AssertVisibleStep); ok {
+				return &core.CommandResult{Success: false} // Condition not met
+			}
+			if tap, ok := step.(*flow.TapOnStep); ok {
+				tapped = append(tapped, tap.Selector.Text)
+			}
+			return &core.CommandResult{Success: true}
+		},
+	}
+
+	runner := New(driver, RunnerConfig{
+		OutputDir:   tmpDir,
+		Parallelism: 0,
+		Artifacts:   ArtifactNever,
+		Device:      report.Device{ID: "test", Platform: "android"},
+	})
+
+	flows := []flow.Flow{
+		{
+			SourcePath: "test.yaml",
+			Config:     flow.Config{Name: "If Else Test"},
+			Steps: []flow.Step{
+				&flow.IfStep{
+					BaseStep:  flow.BaseStep{StepType: flow.StepIf},
+					Condition: flow.Condition{Visible: &flow.Selector{Text: "Welcome"}},
+					Then: []flow.Step{
+						&flow.TapOnStep{BaseStep: flow.BaseStep{StepType: flow.StepTapOn}, Selector: flow.Selector{Text: "then"}},
+					},
+					Else: []flow.Step{
+						&flow.TapOnStep{BaseStep: flow.BaseStep{StepType: flow.StepTapOn}, Selector: flow.Selector{Text: "else"}},
+					},
+				},
+			},
+		},
+	}
+
+	result, err := runner.Run(context.Background(), flows)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if result.Status != report.StatusPassed {
+		t.Errorf("Status = %v, want %v", result.Status, report.StatusPassed)
+	}
+	if len(tapped) != 1 || tapped[0] != "else" {
+		t.Errorf("tapped = %v, want [else]", tapped)
+	}
+}
+
+func TestRunner_IfStep_NoElseSkips(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	execCount := 0
+	driver := &mockDriver{
+		executeFunc: func(step flow.Step) *core.CommandResult {
+			execCount++
+			return &core.CommandResult{Success: true}
+		},
+	}
+
+	runner := New(driver, RunnerConfig{
+		OutputDir:   tmpDir,
+		Parallelism: 0,
+		Artifacts:   ArtifactNever,
+		Device:      report.Device{ID: "test", Platform: "android"},
+	})
+
+	flows := []flow.Flow{
+		{
+			SourcePath: "test.yaml",
+			Config:     flow.Config{Name: "If Platform Test"},
+			Steps: []flow.Step{
+				&flow.IfStep{
+					BaseStep:  flow.BaseStep{StepType: flow.StepIf},
+					Condition: flow.Condition{Platform: "ios"},
+					Then: []flow.Step{
+						&flow.TapOnStep{BaseStep: flow.BaseStep{StepType: flow.StepTapOn}},
+					},
+				},
+			},
+		},
+	}
+
+	result, err := runner.Run(context.Background(), flows)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if result.Status != report.StatusPassed {
+		t.Errorf("Status = %v, want %v", result.Status, report.StatusPassed)
+	}
+	if execCount != 0 {
+		t.Errorf("execCount = %d, want 0", execCount)
+	}
+}
+
+func TestRunner_RepeatStep_ExpandsVariablesEachIteration(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	var tapped []string
+	driver := &mockDriver{
+		executeFunc: func(step flow.Step) *core.CommandResult {
+			if tap, ok := step.(*flow.TapOnStep); ok {
+				tapped = append(tapped, tap.Selector.Text)
+			}
+			return &core.CommandResult{Success: true}
+		},
+	}
+
+	runner := New(driver, RunnerConfig{
+		OutputDir:   tmpDir,
+		Parallelism: 0,
+		Artifacts:   ArtifactNever,
+		Device:      report.Device{ID: "test", Platform: "android"},
+	})
+
+	tapRow := &flow.TapOnStep{
+		BaseStep: flow.BaseStep{StepType: flow.StepTapOn},
+		Selector: flow.Selector{Text: "${PREFIX} ${output.row}"},
+	}
+	flows := []flow.Flow{
+		{
+			SourcePath: "test.yaml",
+			Config:     flow.Config{Name: "Repeat Expansion Test"},
+			Steps: []flow.Step{
+				&flow.DefineVariablesStep{
+					BaseStep: flow.BaseStep{StepType: flow.StepDefineVariables},
+					Env:      map[string]string{"PREFIX": "Row"},
+				},
+				&flow.EvalScriptStep{
+					BaseStep: flow.BaseStep{StepType: flow.StepEvalScript},
+					Script:   "output.row = 1",
+				},
+				&flow.RepeatStep{
+					BaseStep: flow.BaseStep{StepType: flow.StepRepeat},
+					Times:    "3",
+					Steps: []flow.Step{
+						tapRow,
+						&flow.EvalScriptStep{
+							BaseStep: flow.BaseStep{StepType: flow.StepEvalScript},
+							Script:   "output.row = output.row + 1",
+						},
+					},
+				},
+			},
+		},
+	}
+
+	result, err := runner.Run(context.Background(), flows)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if result.Status != report.StatusPassed {
+		t.Errorf("Status = %v, want %v", result.Status, report.StatusPassed)
+	}
+	want := []string{"Row 1", "Row 2", "Row 3"}
+	if strings.Join(tapped, ",") != strings.Join(want, ",") {
+		t.Errorf("tapped = %v, want %v", tapped, want)
+	}
+	// The parsed step keeps its template for the next execution
+	if tapRow.Selector.Text != "${PREFIX} ${output.row}" {
+		t.Errorf("nested step was mutated: %q", tapRow.Selector.Text)
+	}
+}
+
+// A while loop without times runs until its condition fails, not once
+func TestExecuteRepeat_WhileWithoutTimes(t *testing.T) {
+	fr := &FlowRunner{
+		ctx:    context.Background(),
+		driver: &mockDriver{},
+		script: NewScriptEngine(),
+	}
+	fr.script.ExecuteEvalScript(&flow.EvalScriptStep{Script: "output.idx = 0"})
+
+	result := fr.executeRepeat(&flow.RepeatStep{
+		While: flow.Condition{Script: "${output.idx < 3}"},
+		Steps: []flow.Step{
+			&flow.EvalScriptStep{
+				BaseStep: flow.BaseStep{StepType: flow.StepEvalScript},
+				Script:   "output.idx = output.idx + 1",
+			},
+		},
+	})
+
+	if !result.Success {
+		t.Fatalf("expected success, got: %s", result.Message)
+	}
+	if result.Message != "Repeat completed (3 iterations)" {
+		t.Errorf("Message = %q, want the iterations actually run", result.Message)
+	}
+}
+
+func TestRunner_RepeatStep_WhileConditionExpandsVariables(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	var checked []string
+	driver := &mockDriver{
+		executeFunc: func(step flow.Step) *core.CommandResult {
+			if v, ok := step.(*flow.AssertVisibleStep); ok {
+				checked = append(checked, v.Selector.Text)
+				return &core.CommandResult{Success: len(checked) < 3}
+			}
+			return &core.CommandResult{Success: true}
+		},
+	}
+
+	runner := New(driver, RunnerConfig{
+		OutputDir:   tmpDir,
+		Parallelism: 0,
+		Artifacts:   ArtifactNever,
+		Device:      report.Device{ID: "test", Platform: "android"},
+	})
+
+	flows := []flow.Flow{
+		{
+			SourcePath: "test.yaml",
+			Config:     flow.Config{Name: "While Expansion Test"},
+			Steps: []flow.Step{
+				&flow.EvalScriptStep{
+					BaseStep: flow.BaseStep{StepType: flow.StepEvalScript},
+					Script:   "output.page = 1",
+				},
+				&flow.RepeatStep{
+					BaseStep: flow.BaseStep{StepType: flow.StepRepeat},
+					Times:    "5",
+					While:    flow.Condition{Visible: &flow.Selector{Text: "Page ${output.page}"}},
+					Steps: []flow.Step{
+						&flow.EvalScriptStep{
+							BaseStep: flow.BaseStep{StepType: flow.StepEvalScript},
+							Script:   "output.page = output.page + 1",
+						},
+					},
+				},
+			},
+		},
+	}
+
+	if _, err := runner.Run(context.Background(), flows); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	want := []string{"Page 1", "Page 2", "Page 3"}
+	if strings.Join(checked, ",") != strings.Join(want, ",") {
+		t.Errorf("checked = %v, want %v", checked, want)
+	}
+}
+
+func TestRunner_InputTextTypeDelayDefault(t *testing.T) {
+	var delays []int
+	driver := &mockDriver{
+		executeFunc: func(step flow.Step) *core.CommandResult {
+			if s, ok := step.(*flow.InputTextStep); ok {
+				delays = append(delays, s.TypeDelayMs)
+			}
+			return &core.CommandResult{Success: true}
+		},
+	}
+
+	runner := New(driver, RunnerConfig{
+		OutputDir:   t.TempDir(),
+		Artifacts:   ArtifactNever,
+		TypeDelayMs: 50,
+	})
+
+	flows := []flow.Flow{{
+		SourcePath: "test.yaml",
+		Steps: []flow.Step{
+			&flow.InputTextStep{BaseStep: flow.BaseStep{StepType: flow.StepInputText}, Text: "555"},
+			&flow.InputTextStep{BaseStep: flow.BaseStep{StepType: flow.StepInputText}, Text: "555", TypeDelayMs: 10},
+			&flow.RepeatStep{
+				BaseStep: flow.BaseStep{StepType: flow.StepRepeat},
+				Times:    "1",
+				Steps:    []flow.Step{&flow.InputTextStep{BaseStep: flow.BaseStep{StepType: flow.StepInputText}, Text: "555"}},
+			},
+		},
+	}}
+
+	if _, err := runner.Run(context.Background(), flows); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(delays) != 3 || delays[0] != 50 || delays[1] != 10 || delays[2] != 50 {
+		t.Errorf("expected delays [50 10 50], got %v", delays)
+	}
+}
+
+// recordingDriver fakes a driver that writes the recording to the host path
+// given to stopRecording. tapFails makes every tapOn fail.
+func recordingDriver(tapFails bool, stopPaths *[]string) *mockDriver {
+	return &mockDriver{
+		executeFunc: func(step flow.Step) *core.CommandResult {
+			switch s := step.(type) {
+			case *flow.StopRecordingStep:
+				*stopPaths = append(*stopPaths, s.Path)
+				if err := os.WriteFile(s.Path, []byte("video"), 0o644); err != nil {
+					return &core.CommandResult{Success: false, Error: err}
+				}
+			case *flow.TapOnStep:
+				if tapFails {
+					return &core.CommandResult{Success: false, Error: errors.New("element not found")}
+				}
+			}
+			return &core.CommandResult{Success: true}
+		},
+	}
+}
+
+func TestRunner_RecordOnFailure(t *testing.T) {
+	tests := []struct {
+		name     string
+		tapFails bool
+		wantKept bool
+	}{
+		{"passing flow discards recording", false, false},
+		{"failing flow keeps recording", true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stopPaths []string
+			driver := recordingDriver(tt.tapFails, &stopPaths)
+			runner := New(driver, RunnerConfig{
+				OutputDir:       t.TempDir(),
+				Artifacts:       ArtifactNever,
+				RecordOnFailure: true,
+			})
+
+			flows := []flow.Flow{{
+				SourcePath: "test.yaml",
+				Steps: []flow.Step{
+					&flow.TapOnStep{BaseStep: flow.BaseStep{StepType: flow.StepTapOn}},
+				},
+			}}
+			result, err := runner.Run(context.Background(), flows)
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+
+			if len(stopPaths) != 1 {
+				t.Fatalf("expected one stopRecording, got %v", stopPaths)
+			}
+			if _, err := os.Stat(stopPaths[0]); !os.IsNotExist(err) {
+				t.Errorf("expected %s to be moved or removed, stat err = %v", stopPaths[0], err)
+			}
+			kept := filepath.Join(filepath.Dir(stopPaths[0]), "failure.mp4")
+			_, err = os.Stat(kept)
+			if tt.wantKept && err != nil {
+				t.Errorf("expected recording kept at %s: %v", kept, err)
+			}
+			if !tt.wantKept && !os.IsNotExist(err) {
+				t.Errorf("expected no recording for a passing flow, stat err = %v", err)
+			}
+			if got := result.FlowResults[0].Status; (got == report.StatusFailed) != tt.tapFails {
+				t.Errorf("unexpected flow status %s", got)
+			}
+		})
+	}
+}
+
+func TestRunner_RecordOnFailureDisabled(t *testing.T) {
+	var stopPaths []string
+	runner := New(recordingDriver(true, &stopPaths), RunnerConfig{
+		OutputDir: t.TempDir(),
+		Artifacts: ArtifactNever,
+	})
+
+	flows := []flow.Flow{{
+		SourcePath: "test.yaml",
+		Steps:      []flow.Step{&flow.TapOnStep{BaseStep: flow.BaseStep{StepType: flow.StepTapOn}}},
+	}}
+	if _, err := runner.Run(context.Background(), flows); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(stopPaths) != 0 {
+		t.Errorf("expected no recording when disabled, got %v", stopPaths)
+	}
+}
+
+// logDriver is a mockDriver that also captures device logs.
+type logDriver struct {
+	*mockDriver
+	started bool
+	appID   string
+	tag     string
+}
+
+func (d *logDriver) StartLogCapture() error {
+	d.started = true
+	return nil
+}
+
+func (d *logDriver) DeviceLogs(appID, tag string) ([]byte, error) {
+	d.appID, d.tag = appID, tag
+	return []byte("E AndroidRuntime: FATAL EXCEPTION: main"), nil
+}
+
+func TestRunner_LogcatOnFailure(t *testing.T) {
+	tests := []struct {
+		name     string
+		tapFails bool
+	}{
+		{"passing flow saves no log", false},
+		{"failing flow saves log", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stopPaths []string
+			driver := &logDriver{mockDriver: recordingDriver(tt.tapFails, &stopPaths)}
+			outputDir := t.TempDir()
+			runner := New(driver, RunnerConfig{
+				OutputDir:       outputDir,
+				Artifacts:       ArtifactNever,
+				LogcatOnFailure: true,
+				LogcatTag:       "AndroidRuntime",
+				App:             report.App{ID: "com.example.app"},
+			})
+
+			flows := []flow.Flow{{
+				SourcePath: "test.yaml",
+				Steps:      []flow.Step{&flow.TapOnStep{BaseStep: flow.BaseStep{StepType: flow.StepTapOn}}},
+			}}
+			result, err := runner.Run(context.Background(), flows)
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+
+			if !driver.started {
+				t.Error("expected log capture to start with the flow")
+			}
+			logPath := result.FlowResults[0].DeviceLog
+			if !tt.tapFails {
+				if logPath != "" {
+					t.Errorf("expected no device log for a passing flow, got %s", logPath)
+				}
+				return
+			}
+			if logPath == "" {
+				t.Fatal("expected the device log path on the flow result")
+			}
+			data, err := os.ReadFile(filepath.Join(outputDir, logPath))
+			if err != nil {
+				t.Fatalf("reading device log: %v", err)
+			}
+			if !strings.Contains(string(data), "FATAL EXCEPTION") {
+				t.Errorf("unexpected device log %q", data)
+			}
+			if driver.appID != "com.example.app" || driver.tag != "AndroidRuntime" {
+				t.Errorf("DeviceLogs(%q, %q), want app and tag from config", driver.appID, driver.tag)
+			}
+		})
+	}
+}
+
+func TestRunner_LogcatOnFailureUnsupportedDriver(t *testing.T) {
+	var stopPaths []string
+	runner := New(recordingDriver(true, &stopPaths), RunnerConfig{
+		OutputDir:       t.TempDir(),
+		Artifacts:       ArtifactNever,
+		LogcatOnFailure: true,
+	})
+
+	flows := []flow.Flow{{
+		SourcePath: "test.yaml",
+		Steps:      []flow.Step{&flow.TapOnStep{BaseStep: flow.BaseStep{StepType: flow.StepTapOn}}},
+	}}
+	result, err := runner.Run(context.Background(), flows)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if got := result.FlowResults[0].DeviceLog; got != "" {
+		t.Errorf("expected no device log without driver support, got %s", got)
+	}
+}
+
+// frameStatsDriver is a mockDriver with frame stats, recording the calls in
+// order with the steps it executes.
+type frameStatsDriver struct {
+	*mockDriver
+	janky int
+	calls []string
+}
+
+func (d *frameStatsDriver) ResetFrameStats(appID string) error {
+	d.calls = append(d.calls, "reset "+appID)
+	return nil
+}
+
+func (d *frameStatsDriver) FrameStats(appID string) (total, janky int, err error) {
+	d.calls = append(d.calls, "stats "+appID)
+	return 200, d.janky, nil
+}
+
+func TestRunner_AssertNoJank(t *testing.T) {
+	tests := []struct {
+		name   string
+		janky  int
+		status report.Status
+	}{
+		{"within threshold", 4, report.StatusPassed},
+		{"too much jank", 20, report.StatusFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			driver := &frameStatsDriver{janky: tt.janky}
+			driver.mockDriver = &mockDriver{
+				executeFunc: func(step flow.Step) *core.CommandResult {
+					if s, ok := step.(*flow.ScrollUntilVisibleStep); ok {
+						driver.calls = append(driver.calls, "scroll "+s.Element.Text)
+					}
+					return &core.CommandResult{Success: true}
+				},
+			}
+			runner := New(driver, RunnerConfig{OutputDir: t.TempDir(), Artifacts: ArtifactNever})
+
+			scroll := &flow.ScrollUntilVisibleStep{
+				BaseStep: flow.BaseStep{StepType: flow.StepScrollUntilVisible},
+				Element:  flow.Selector{Text: "${ITEM}"},
+			}
+			flows := []flow.Flow{{
+				SourcePath: "test.yaml",
+				Config:     flow.Config{AppID: "com.example.app", Env: map[string]string{"ITEM": "Footer"}},
+				Steps: []flow.Step{&flow.AssertNoJankStep{
+					BaseStep: flow.BaseStep{StepType: flow.StepAssertNoJank},
+					Steps:    []flow.Step{scroll},
+				}},
+			}}
+			result, err := runner.Run(context.Background(), flows)
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+
+			if got := result.FlowResults[0].Status; got != tt.status {
+				t.Errorf("status = %s, want %s", got, tt.status)
+			}
+			// Only the nested scroll counts; the compound step doesn't count itself
+			if got := result.FlowResults[0].StepsPassed; got != 1 {
+				t.Errorf("StepsPassed = %d, want 1", got)
+			}
+			want := []string{"reset com.example.app", "scroll Footer", "stats com.example.app"}
+			if strings.Join(driver.calls, ",") != strings.Join(want, ",") {
+				t.Errorf("calls = %v, want %v", driver.calls, want)
+			}
+			if scroll.Element.Text != "${ITEM}" {
+				t.Errorf("nested step template was expanded in place: %q", scroll.Element.Text)
+			}
+		})
+	}
+}
+
+func TestRunner_AssertNoJankUnsupportedDriver(t *testing.T) {
+	runner := New(&mockDriver{}, RunnerConfig{OutputDir: t.TempDir(), Artifacts: ArtifactNever})
+
+	flows := []flow.Flow{{
+		SourcePath: "test.yaml",
+		Steps: []flow.Step{&flow.AssertNoJankStep{
+			BaseStep: flow.BaseStep{StepType: flow.StepAssertNoJank},
+			AppID:    "com.example.app",
+		}},
+	}}
+	result, err := runner.Run(context.Background(), flows)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if got := result.FlowResults[0].Status; got != report.StatusFailed {
+		t.Errorf("status = %s, want failed without frame stats", got)
+	}
+}
+
+func TestAssertLocalFileExists(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "cmd-002-checkout.png"), make([]byte, 2048), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "exports"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "exports", "receipt.pdf"), []byte("%PDF"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		step    flow.AssertFileExistsStep
+		success bool
+		message string
+	}{
+		{"screenshot name", flow.AssertFileExistsStep{Path: "checkout.png"}, true, "cmd-002-checkout.png (2048 bytes)"},
+		{"glob", flow.AssertFileExistsStep{Path: "*.png"}, true, "cmd-002-checkout.png"},
+		{"subdirectory", flow.AssertFileExistsStep{Path: "exports/*.pdf"}, true, "receipt.pdf (4 bytes)"},
+		{"absolute", flow.AssertFileExistsStep{Path: filepath.Join(dir, "exports", "receipt.pdf")}, true, "receipt.pdf"},
+		{"min size met", flow.AssertFileExistsStep{Path: "checkout.png", MinSize: 1024}, true, "2048 bytes"},
+		{"min size not met", flow.AssertFileExistsStep{Path: "checkout.png", MinSize: 4096}, false, "2048 bytes, below minSize 4096"},
+		{"missing", flow.AssertFileExistsStep{Path: "share.png"}, false, `No file matching "share.png"`},
+		{"directory only", flow.AssertFileExistsStep{Path: "exports"}, false, "No file matching"},
+		{"no path", flow.AssertFileExistsStep{}, false, "requires a path"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := assertLocalFileExists(dir, &tt.step)
+			if result.Success != tt.success {
+				t.Fatalf("expected success=%v, got %v: %s", tt.success, result.Success, result.Message)
+			}
+			if !strings.Contains(result.Message, tt.message) {
+				t.Errorf("expected message to contain %q, got: %s", tt.message, result.Message)
+			}
+		})
+	}
+}
+
+func TestAssertScreenshot(t *testing.T) {
+	purple := themeScreenshot(t, imgcolor.RGBA{0x62, 0x00, 0xee, 0xff})
+	teal := themeScreenshot(t, imgcolor.RGBA{0x01, 0x87, 0x86, 0xff})
+	dir := t.TempDir()
+	baseline := filepath.Join(dir, "home.png")
+	if err := os.WriteFile(baseline, purple, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Matching screenshot
+	result := assertScreenshot(purple, baseline, &flow.AssertScreenshotStep{Baseline: "home.png"})
+	if !result.Success || !strings.Contains(result.Message, "matches baseline (0.00% different)") {
+		t.Errorf("expected match, got %v: %s", result.Success, result.Message)
+	}
+
+	// Top bar differs: 12% of the pixels
+	result = assertScreenshot(teal, baseline, &flow.AssertScreenshotStep{Baseline: "home.png", DiffImage: true})
+	if result.Success || !strings.Contains(result.Message, "differs from baseline by 12.00% (threshold 0.50%)") {
+		t.Errorf("expected mismatch, got %v: %s", result.Success, result.Message)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "home.diff.png")); err != nil {
+		t.Errorf("expected diff image: %v", err)
+	}
+
+	// Within a raised threshold
+	raised := 15.0
+	result = assertScreenshot(teal, baseline, &flow.AssertScreenshotStep{Baseline: "home.png", Threshold: &raised})
+	if !result.Success {
+		t.Errorf("expected success within threshold: %s", result.Message)
+	}
+
+	// A zero threshold asks for an exact match
+	exact := 0.0
+	result = assertScreenshot(teal, baseline, &flow.AssertScreenshotStep{Baseline: "home.png", Threshold: &exact})
+	if result.Success || !strings.Contains(result.Message, "(threshold 0.00%)") {
+		t.Errorf("expected mismatch at zero threshold, got %v: %s", result.Success, result.Message)
+	}
+
+	// Missing baseline fails without updateBaseline
+	missing := filepath.Join(dir, "new", "login.png")
+	result = assertScreenshot(purple, missing, &flow.AssertScreenshotStep{Baseline: "new/login.png"})
+	if result.Success || !strings.Contains(result.Message, "Baseline not found") {
+		t.Errorf("expected missing baseline failure, got %v: %s", result.Success, result.Message)
+	}
+
+	// ...and is written with it
+	result = assertScreenshot(purple, missing, &flow.AssertScreenshotStep{Baseline: "new/login.png", UpdateBaseline: true})
+	if !result.Success || !strings.Contains(result.Message, "Baseline written") {
+		t.Errorf("expected baseline written, got %v: %s", result.Success, result.Message)
+	}
+	if data, err := os.ReadFile(missing); err != nil || !bytes.Equal(data, purple) {
+		t.Errorf("expected baseline to hold the screenshot: %v", err)
+	}
+}
+
+// themeScreenshot encodes a 100x200 screen whose top 24 rows are bar-colored
+// and the rest white.
+func themeScreenshot(t *testing.T, bar imgcolor.Color) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, 100, 200))
+	for y := 0; y < 200; y++ {
+		for x := 0; x < 100; x++ {
+			if y < 24 {
+				img.Set(x, y, bar)
+			} else {
+				img.Set(x, y, imgcolor.White)
+			}
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestAssertThemeColor(t *testing.T) {
+	purple := themeScreenshot(t, imgcolor.RGBA{0x62, 0x00, 0xee, 0xff})
+	teal := themeScreenshot(t, imgcolor.RGBA{0x01, 0x87, 0x86, 0xff})
+
+	tests := []struct {
+		name       string
+		screenshot []byte
+		step       flow.AssertThemeColorStep
+		success    bool
+		message    string
+	}{
+		{"exact match", purple, flow.AssertThemeColorStep{Color: "#6200EE"}, true, "Theme color #6200EE matches #6200EE"},
+		{"within tolerance", purple, flow.AssertThemeColorStep{Color: "#5A08E6"}, true, "matches #5A08E6"},
+		{"outside tolerance", purple, flow.AssertThemeColorStep{Color: "#5A08E6", Tolerance: 4}, false, "difference 8 exceeds tolerance 4"},
+		{"different color", teal, flow.AssertThemeColorStep{Color: "#6200EE"}, false, "Theme color is #018786, expected #6200EE"},
+		{"region into body", purple, flow.AssertThemeColorStep{Color: "#6200EE", Region: 50}, false, "Theme color is #FFFFFF"},
+		{"invalid color", purple, flow.AssertThemeColorStep{Color: "purple"}, false, "#RRGGBB"},
+		{"undecodable screenshot", []byte("not a png"), flow.AssertThemeColorStep{Color: "#6200EE"}, false, "Failed to sample theme color"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := assertThemeColor(tt.screenshot, &tt.step)
+			if result.Success != tt.success {
+				t.Fatalf("expected success=%v, got %v: %s", tt.success, result.Success, result.Message)
+			}
+			if !strings.Contains(result.Message, tt.message) {
+				t.Errorf("expected message to contain %q, got: %s", tt.message, result.Message)
+			}
+		})
+	}
+}
+
+func TestRunner_FlowHeaderEnv_CLIOverrides(t *testing.T) {
+	var typed []string
+	driver := &mockDriver{
+		executeFunc: func(step flow.Step) *core.CommandResult {
+			if s, ok := step.(*flow.InputTextStep); ok {
+				typed = append(typed, s.Text)
+			}
+			return &core.CommandResult{Success: true}
+		},
+	}
+
+	runner := New(driver, RunnerConfig{
+		OutputDir: t.TempDir(),
+		Artifacts: ArtifactNever,
+		Env:       map[string]string{"USER": "cli-user"},
+		Device:    report.Device{ID: "test", Platform: "android"},
+		App:       report.App{ID: "com.test"},
+	})
+
+	flows := []flow.Flow{
+		{
+			SourcePath: "test.yaml",
+			Config: flow.Config{
+				Label: "Login",
+				Env:   map[string]string{"USER": "header-user", "PASS": "header-pass"},
+			},
+			Steps: []flow.Step{
+				&flow.InputTextStep{BaseStep: flow.BaseStep{StepType: flow.StepInputText}, Text: "${USER}"},
+				&flow.InputTextStep{BaseStep: flow.BaseStep{StepType: flow.StepInputText}, Text: "$PASS"},
+			},
+		},
+	}
+
+	result, err := runner.Run(context.Background(), flows)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if result.Status != report.StatusPassed {
+		t.Fatalf("Status = %v, want %v", result.Status, report.StatusPassed)
+	}
+	if result.FlowResults[0].Name != "Login" {
+		t.Errorf("flow name = %q, want %q", result.FlowResults[0].Name, "Login")
+	}
+
+	want := []string{"cli-user", "header-pass"}
+	if len(typed) != len(want) || typed[0] != want[0] || typed[1] != want[1] {
+		t.Errorf("typed = %v, want %v", typed, want)
+	}
+}
+
+func TestRunner_LastResultVisibleToScripts(t *testing.T) {
+	driver := &mockDriver{
+		executeFunc: func(step flow.Step) *core.CommandResult {
+			if _, ok := step.(*flow.TapOnStep); ok {
+				return &core.CommandResult{Success: false, Message: "Element not found: Skip"}
+			}
+			return &core.CommandResult{Success: true}
+		},
+	}
+
+	runner := New(driver, RunnerConfig{
+		OutputDir: t.TempDir(),
+		Artifacts: ArtifactNever,
+		Device:    report.Device{ID: "test", Platform: "android"},
+		App:       report.App{ID: "com.test"},
+	})
+
+	flows := []flow.Flow{
+		{
+			SourcePath: "test.yaml",
+			Steps: []flow.Step{
+				&flow.TapOnStep{
+					BaseStep: flow.BaseStep{StepType: flow.StepTapOn, Optional: true},
+					Selector: flow.Selector{Text: "Skip"},
+				},
+				&flow.AssertTrueStep{
+					BaseStep: flow.BaseStep{StepType: flow.StepAssertTrue},
+					Script:   "!maestro.lastResult.success && maestro.lastResult.message.indexOf('Skip') > 0",
+				},
+				// Only the immediately preceding step: now the passing assertTrue
+				&flow.AssertTrueStep{
+					BaseStep: flow.BaseStep{StepType: flow.StepAssertTrue},
+					Script:   "maestro.lastResult.success",
+				},
+			},
+		},
+	}
+
+	result, err := runner.Run(context.Background(), flows)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if result.Status != report.StatusPassed {
+		t.Fatalf("Status = %v, want %v (%s)", result.Status, report.StatusPassed, result.FlowResults[0].Error)
+	}
+}
+
+func TestRunner_VarsFilePrecedence(t *testing.T) {
+	vars := map[string]interface{}{
+		"USER": "vars-user",
+		"PASS": "vars-pass",
+		"PORT": 8080,
+		"api":  map[string]interface{}{"url": "https://api.example.com"},
+	}
+
+	run := func(cliEnv map[string]string) []string {
+		var typed []string
+		driver := &mockDriver{
+			executeFunc: func(step flow.Step) *core.CommandResult {
+				if s, ok := step.(*flow.InputTextStep); ok {
+					typed = append(typed, s.Text)
+				}
+				return &core.CommandResult{Success: true}
+			},
+		}
+		runner := New(driver, RunnerConfig{
+			OutputDir: t.TempDir(),
+			Artifacts: ArtifactNever,
+			Env:       cliEnv,
+			Vars:      vars,
+			Device:    report.Device{ID: "test", Platform: "android"},
+			App:       report.App{ID: "com.test"},
+		})
+		input := func(text string) flow.Step {
+			return &flow.InputTextStep{BaseStep: flow.BaseStep{StepType: flow.StepInputText}, Text: text}
+		}
+		flows := []flow.Flow{{
+			SourcePath: "test.yaml",
+			Config:     flow.Config{Env: map[string]string{"USER": "header-user", "HOST": "header-host"}},
+			Steps:      []flow.Step{input("${USER}"), input("$PASS"), input("${HOST}:$PORT"), input("${config.api.url}")},
+		}}
+		if _, err := runner.Run(context.Background(), flows); err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+		return typed
+	}
+
+	want := []string{"vars-user", "vars-pass", "header-host:8080", "https://api.example.com"}
+	if got := run(nil); strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("without -e: typed = %v, want %v", got, want)
+	}
+
+	want[0] = "cli-user"
+	if got := run(map[string]string{"USER": "cli-user"}); strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("with -e: typed = %v, want %v", got, want)
+	}
+}
+
+func TestRunner_VarsCopiedPerFlow(t *testing.T) {
+	vars := map[string]interface{}{
+		"api":   map[string]interface{}{"url": "https://api.example.com"},
+		"hosts": []interface{}{"a.example.com"},
+	}
+
+	var typed []string
+	driver := &mockDriver{
+		executeFunc: func(step flow.Step) *core.CommandResult {
+			if s, ok := step.(*flow.InputTextStep); ok {
+				typed = append(typed, s.Text)
+			}
+			return &core.CommandResult{Success: true}
+		},
+	}
+	runner := New(driver, RunnerConfig{OutputDir: t.TempDir(), Artifacts: ArtifactNever, Vars: vars})
+
+	flowWith := func(name string, steps ...flow.Step) flow.Flow {
+		return flow.Flow{SourcePath: name + ".yaml", Config: flow.Config{Name: name}, Steps: steps}
+	}
+	input := &flow.InputTextStep{BaseStep: flow.BaseStep{StepType: flow.StepInputText}, Text: "${config.api.url} ${config.hosts[0]}"}
+	flows := []flow.Flow{
+		flowWith("first", &flow.EvalScriptStep{
+			BaseStep: flow.BaseStep{StepType: flow.StepEvalScript},
+			Script:   "config.api.url = 'changed'; config.hosts[0] = 'changed'",
+		}),
+		flowWith("second", input),
+	}
+	if _, err := runner.Run(context.Background(), flows); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if want := "https://api.example.com a.example.com"; len(typed) != 1 || typed[0] != want {
+		t.Errorf("typed = %v, want [%s]", typed, want)
+	}
+	if got := vars["api"].(map[string]interface{})["url"]; got != "https://api.example.com" {
+		t.Errorf("vars api.url = %v, want it unchanged", got)
+	}
+	if got := vars["hosts"].([]interface{})[0]; got != "a.example.com" {
+		t.Errorf("vars hosts[0] = %v, want it unchanged", got)
+	}
+}
+
+func TestRunner_ScreenshotOnFailure(t *testing.T) {
+	tests := []struct {
+		name           string
+		artifacts      ArtifactMode
+		screenshotErr  error
+		wantScreenshot bool
+	}{
+		{"captured", ArtifactOnFailure, nil, true},
+		{"screenshot fails", ArtifactOnFailure, errors.New("device offline"), false},
+		{"disabled", ArtifactNever, nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			driver := &mockDriver{
+				executeFunc: func(step flow.Step) *core.CommandResult {
+					return &core.CommandResult{Success: false, Error: errors.New("element not found: Login")}
+				},
+				screenshotFunc: func() ([]byte, error) {
+					if tt.screenshotErr != nil {
+						return nil, tt.screenshotErr
+					}
+					return []byte{0x89, 0x50, 0x4E, 0x47}, nil
+				},
+			}
+
+			sink := &recordingSink{}
+			runner := New(driver, RunnerConfig{
+				OutputDir: tmpDir,
+				Artifacts: tt.artifacts,
+				Device:    report.Device{ID: "test", Platform: "android"},
+				App:       report.App{ID: "com.test"},
+				Sink:      sink,
+			})
+
+			flows := []flow.Flow{{
+				SourcePath: "login.yaml",
+				Steps: []flow.Step{
+					&flow.TapOnStep{BaseStep: flow.BaseStep{StepType: flow.StepTapOn}, Selector: flow.Selector{Text: "Login"}},
+				},
+			}}
+
+			result, err := runner.Run(context.Background(), flows)
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if got := result.FlowResults[0].Error; !strings.Contains(got, "element not found: Login") {
+				t.Errorf("flow error = %q, want the step's own error", got)
+			}
+			if len(sink.steps) != 1 {
+				t.Fatalf("got %d step events, want 1", len(sink.steps))
+			}
+
+			shot := sink.steps[0].Screenshot
+			if !tt.wantScreenshot {
+				if shot != "" {
+					t.Errorf("Screenshot = %q, want none", shot)
+				}
+				return
+			}
+			if !strings.Contains(shot, "cmd-000-after.png") {
+				t.Errorf("Screenshot = %q, want the step index in the file name", shot)
+			}
+			if _, err := os.Stat(filepath.Join(tmpDir, shot)); err != nil {
+				t.Errorf("screenshot not saved: %v", err)
+			}
+		})
+	}
+}
+
+func TestRunner_ScreenshotOnNestedFailure(t *testing.T) {
+	tap := func() flow.Step {
+		return &flow.TapOnStep{BaseStep: flow.BaseStep{StepType: flow.StepTapOn}, Selector: flow.Selector{Text: "Login"}}
+	}
+	tests := []struct {
+		name string
+		step flow.Step
+	}{
+		{"repeat", &flow.RepeatStep{BaseStep: flow.BaseStep{StepType: flow.StepRepeat}, Times: "1", Steps: []flow.Step{tap()}}},
+		{"retry", &flow.RetryStep{BaseStep: flow.BaseStep{StepType: flow.StepRetry}, MaxRetries: "1", Steps: []flow.Step{tap()}}},
+		{"runFlow", &flow.RunFlowStep{BaseStep: flow.BaseStep{StepType: flow.StepRunFlow}, Steps: []flow.Step{tap()}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			driver := &mockDriver{
+				executeFunc: func(step flow.Step) *core.CommandResult {
+					return &core.CommandResult{Success: false, Error: errors.New("element not found: Login")}
+				},
+				screenshotFunc: func() ([]byte, error) {
+					return []byte{0x89, 0x50, 0x4E, 0x47}, nil
+				},
+			}
+
+			sink := &recordingSink{}
+			runner := New(driver, RunnerConfig{
+				OutputDir: tmpDir,
+				Artifacts: ArtifactOnFailure,
+				Device:    report.Device{ID: "test", Platform: "android"},
+				Sink:      sink,
+			})
+
+			flows := []flow.Flow{{SourcePath: "login.yaml", Steps: []flow.Step{tt.step}}}
+			if _, err := runner.Run(context.Background(), flows); err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+
+			shots, _ := filepath.Glob(filepath.Join(tmpDir, "assets", "*", "cmd-000-failed-1.png"))
+			if len(shots) != 1 {
+				t.Fatalf("expected a screenshot for the nested failure, found %v", shots)
+			}
+
+			// Nested steps of a runFlow are reported with the screenshot path
+			if tt.name == "runFlow" {
+				if len(sink.steps) == 0 || !strings.HasSuffix(sink.steps[0].Screenshot, "cmd-000-failed-1.png") {
+					t.Errorf("nested step events = %+v, want the failure screenshot on the first", sink.steps)
+				}
+			}
+		})
+	}
+}
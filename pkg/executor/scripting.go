@@ -2,9 +2,12 @@ package executor
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"reflect"
 	"regexp"
 	"sort"
 	"strconv"
@@ -13,6 +16,7 @@ import (
 	"github.com/devicelab-dev/maestro-runner/pkg/core"
 	"github.com/devicelab-dev/maestro-runner/pkg/flow"
 	"github.com/devicelab-dev/maestro-runner/pkg/jsengine"
+	"gopkg.in/yaml.v3"
 )
 
 // envVarPattern matches ALL_CAPS identifiers that look like env variables
@@ -22,15 +26,21 @@ var envVarPattern = regexp.MustCompile(`\b([A-Z][A-Z0-9_]{2,})\b`)
 type ScriptEngine struct {
 	js        *jsengine.Engine
 	variables map[string]string
-	flowDir   string // Directory of current flow (for resolving relative paths)
+	flowDir   string      // Directory of current flow (for resolving relative paths)
+	scriptDir string      // Directory of the runScript file being run, the base for its require()
+	driver    core.Driver // Device driver behind maestro.copyTextFrom
 }
 
 // NewScriptEngine creates a new script engine.
 func NewScriptEngine() *ScriptEngine {
-	return &ScriptEngine{
+	se := &ScriptEngine{
 		js:        jsengine.New(),
 		variables: make(map[string]string),
 	}
+	// require('./helpers.js') is relative to the script file, or to the flow
+	// for inline scripts
+	se.js.SetRequireResolver(se.resolveRequire)
+	return se
 }
 
 // Close cleans up the script engine.
@@ -40,11 +50,69 @@ func (se *ScriptEngine) Close() {
 	}
 }
 
+// SetConsoleOutput sets where console.log from scripts prints.
+func (se *ScriptEngine) SetConsoleOutput(w io.Writer) {
+	se.js.SetConsoleOutput(w)
+}
+
 // SetFlowDir sets the current flow directory for relative path resolution.
 func (se *ScriptEngine) SetFlowDir(dir string) {
 	se.flowDir = dir
 }
 
+// SetDriver makes maestro.copyTextFrom(selector) available to scripts. The
+// selector is either a text string, as in `copyTextFrom: Price`, or an
+// object with the usual selector fields.
+func (se *ScriptEngine) SetDriver(driver core.Driver) {
+	se.driver = driver
+	se.js.SetCopyTextFunc(se.copyTextFrom)
+}
+
+// copyTextFrom runs a copyTextFrom step on the driver and returns its text.
+func (se *ScriptEngine) copyTextFrom(arg interface{}) (string, error) {
+	sel, err := scriptSelector(arg)
+	if err != nil {
+		return "", err
+	}
+
+	step := &flow.CopyTextFromStep{
+		BaseStep: flow.BaseStep{StepType: flow.StepCopyTextFrom},
+		Selector: sel,
+	}
+	// The script already evaluated the arguments; expanding them again would
+	// re-enter the JS engine the running script holds.
+
+	result := se.driver.Execute(step)
+	if !result.Success {
+		if result.Error != nil {
+			return "", result.Error
+		}
+		return "", fmt.Errorf("copyTextFrom failed: %s", result.Message)
+	}
+	text, _ := result.Data.(string)
+	return text, nil
+}
+
+// scriptSelector converts a maestro.copyTextFrom argument to a selector.
+func scriptSelector(arg interface{}) (flow.Selector, error) {
+	var sel flow.Selector
+	switch v := arg.(type) {
+	case string:
+		sel.Text = v
+	case map[string]interface{}:
+		data, err := yaml.Marshal(v)
+		if err != nil {
+			return sel, fmt.Errorf("invalid selector: %w", err)
+		}
+		if err := yaml.Unmarshal(data, &sel); err != nil {
+			return sel, fmt.Errorf("invalid selector: %w", err)
+		}
+	default:
+		return sel, fmt.Errorf("selector must be a string or an object, got %T", arg)
+	}
+	return sel, nil
+}
+
 // SetVariable sets a variable in both Go map and JS engine.
 func (se *ScriptEngine) SetVariable(name, value string) {
 	se.variables[name] = value
@@ -58,6 +126,44 @@ func (se *ScriptEngine) SetVariables(vars map[string]string) {
 	}
 }
 
+// SetConfig exposes a --vars map to scripts as the `config` object, so nested
+// values read as ${config.api.url}. Top-level scalar values are also set as
+// plain variables (${BASE_URL}, $BASE_URL). Scripts get a copy, so one flow
+// changing config neither leaks into other flows nor races with them.
+func (se *ScriptEngine) SetConfig(vars map[string]interface{}) {
+	if vars == nil {
+		return
+	}
+	se.js.SetVariable("config", copyConfigValue(vars))
+	for k, v := range vars {
+		switch v.(type) {
+		case map[string]interface{}, []interface{}, nil:
+		default:
+			se.SetVariable(k, fmt.Sprintf("%v", v))
+		}
+	}
+}
+
+// copyConfigValue deep-copies the maps and slices of a decoded --vars value.
+func copyConfigValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			m[k] = copyConfigValue(item)
+		}
+		return m
+	case []interface{}:
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			items[i] = copyConfigValue(item)
+		}
+		return items
+	default:
+		return v
+	}
+}
+
 // ImportSystemEnv imports system environment variables into the script engine.
 // Only imports variables matching the pattern (uppercase with underscores).
 func (se *ScriptEngine) ImportSystemEnv() {
@@ -94,6 +200,22 @@ func (se *ScriptEngine) GetCopiedText() string {
 	return se.js.GetCopiedText()
 }
 
+// SetLastResult exposes a finished step's outcome to scripts as
+// maestro.lastResult. It is replaced after every step, nested ones included,
+// so it only ever reflects the immediately preceding step. Only string data
+// is passed through; binary data such as screenshots shows up as null.
+func (se *ScriptEngine) SetLastResult(result *core.CommandResult) {
+	message := result.Message
+	if message == "" && result.Error != nil {
+		message = result.Error.Error()
+	}
+	var data interface{}
+	if text, ok := result.Data.(string); ok {
+		data = text
+	}
+	se.js.SetLastResult(result.Success, message, data)
+}
+
 // GetOutput returns the JS output variables.
 func (se *ScriptEngine) GetOutput() map[string]interface{} {
 	return se.js.GetOutput()
@@ -193,7 +315,9 @@ func (se *ScriptEngine) EvalCondition(script string) (bool, error) {
 	// Extract JS from ${...} wrapper if present
 	script = extractJS(script)
 	// Expand any remaining $VAR style variables
-	script = se.expandDollarVars(script)
+	script = se.expandConditionVars(script)
+	// Compare numeric-looking strings as numbers ("10" > "9", "007" == 7)
+	script = jsengine.CoerceComparisons(script)
 
 	// Pre-define potential env variables as undefined to avoid ReferenceError
 	matches := envVarPattern.FindAllString(script, -1)
@@ -229,6 +353,15 @@ func (se *ScriptEngine) ResolvePath(path string) string {
 	return filepath.Join(se.flowDir, path)
 }
 
+// resolveRequire resolves a top-level require() path against the running
+// script file's directory, falling back to the flow directory.
+func (se *ScriptEngine) resolveRequire(path string) string {
+	if se.scriptDir != "" && !filepath.IsAbs(path) {
+		return filepath.Join(se.scriptDir, path)
+	}
+	return se.ResolvePath(path)
+}
+
 // ============================================
 // Step Execution Helpers
 // ============================================
@@ -260,6 +393,10 @@ func (se *ScriptEngine) ExecuteRunScript(step *flow.RunScriptStep) *core.Command
 			}
 		}
 		script = string(content)
+
+		prevDir := se.scriptDir
+		se.scriptDir = filepath.Dir(filePath)
+		defer func() { se.scriptDir = prevDir }()
 	}
 
 	if err := se.RunScript(script, step.Env); err != nil {
@@ -325,6 +462,77 @@ func (se *ScriptEngine) expandDollarVars(text string) string {
 	return text
 }
 
+// numericPattern matches values that conditions treat as numbers.
+var numericPattern = regexp.MustCompile(`^\s*[-+]?(\d+\.?\d*|\.\d+)([eE][-+]?\d+)?\s*$`)
+
+// expandConditionVars expands $VAR in a condition. Inside quotes the raw value
+// is used, as in expandDollarVars; outside them the value becomes a JS
+// literal, so "007" is the number 7 rather than an octal literal and "abc" is
+// a string rather than an identifier.
+func (se *ScriptEngine) expandConditionVars(script string) string {
+	if !strings.Contains(script, "$") {
+		return script
+	}
+
+	var sb strings.Builder
+	start := 0
+	var quote byte
+	for i := 0; i < len(script); i++ {
+		c := script[i]
+		switch {
+		case quote != 0 && c == '\\':
+			i++
+		case quote != 0 && c == quote:
+			sb.WriteString(se.expandDollarVars(script[start : i+1]))
+			start, quote = i+1, 0
+		case quote == 0 && (c == '\'' || c == '"' || c == '`'):
+			sb.WriteString(se.expandLiteralVars(script[start:i]))
+			start, quote = i, c
+		}
+	}
+	if quote != 0 {
+		sb.WriteString(se.expandDollarVars(script[start:]))
+	} else {
+		sb.WriteString(se.expandLiteralVars(script[start:]))
+	}
+	return sb.String()
+}
+
+// expandLiteralVars expands $VAR in unquoted script text to JS literals.
+func (se *ScriptEngine) expandLiteralVars(text string) string {
+	if !strings.Contains(text, "$") {
+		return text
+	}
+	names := make([]string, 0, len(se.variables))
+	for name := range se.variables {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return len(names[i]) > len(names[j])
+	})
+
+	for _, name := range names {
+		text = expandDollarVar(text, name, jsLiteral(se.variables[name]))
+	}
+	return text
+}
+
+// jsLiteral returns value as a JS literal: numbers without leading zeros,
+// booleans, null and undefined as-is, and everything else as a string.
+func jsLiteral(value string) string {
+	if numericPattern.MatchString(value) {
+		if f, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+			return strconv.FormatFloat(f, 'g', -1, 64)
+		}
+	}
+	switch value {
+	case "true", "false", "null", "undefined":
+		return value
+	}
+	quoted, _ := json.Marshal(value)
+	return string(quoted)
+}
+
 // ExecuteAssertTrue handles assertTrue step.
 func (se *ScriptEngine) ExecuteAssertTrue(step *flow.AssertTrueStep) *core.CommandResult {
 	result, err := se.EvalCondition(step.Script)
@@ -368,7 +576,7 @@ func (se *ScriptEngine) ExecuteAssertCondition(ctx context.Context, step *flow.A
 
 	// Check visible condition
 	if cond.Visible != nil {
-		visibleStep := &flow.AssertVisibleStep{Selector: *cond.Visible}
+		visibleStep := &flow.AssertVisibleStep{Selector: *se.expandSelector(cond.Visible)}
 		result := driver.Execute(visibleStep)
 		if !result.Success {
 			return &core.CommandResult{
@@ -381,7 +589,7 @@ func (se *ScriptEngine) ExecuteAssertCondition(ctx context.Context, step *flow.A
 
 	// Check notVisible condition
 	if cond.NotVisible != nil {
-		notVisibleStep := &flow.AssertNotVisibleStep{Selector: *cond.NotVisible}
+		notVisibleStep := &flow.AssertNotVisibleStep{Selector: *se.expandSelector(cond.NotVisible)}
 		result := driver.Execute(notVisibleStep)
 		if !result.Success {
 			return &core.CommandResult{
@@ -419,9 +627,17 @@ func (se *ScriptEngine) ExecuteAssertCondition(ctx context.Context, step *flow.A
 
 // CheckCondition evaluates a flow.Condition and returns true if met.
 func (se *ScriptEngine) CheckCondition(ctx context.Context, cond flow.Condition, driver core.Driver) bool {
+	// Check platform
+	if cond.Platform != "" {
+		info := driver.GetPlatformInfo()
+		if info != nil && !strings.EqualFold(info.Platform, cond.Platform) {
+			return false
+		}
+	}
+
 	// Check visible
 	if cond.Visible != nil {
-		visibleStep := &flow.AssertVisibleStep{Selector: *cond.Visible}
+		visibleStep := &flow.AssertVisibleStep{Selector: *se.expandSelector(cond.Visible)}
 		result := driver.Execute(visibleStep)
 		if !result.Success {
 			return false
@@ -430,7 +646,7 @@ func (se *ScriptEngine) CheckCondition(ctx context.Context, cond flow.Condition,
 
 	// Check notVisible
 	if cond.NotVisible != nil {
-		notVisibleStep := &flow.AssertNotVisibleStep{Selector: *cond.NotVisible}
+		notVisibleStep := &flow.AssertNotVisibleStep{Selector: *se.expandSelector(cond.NotVisible)}
 		result := driver.Execute(notVisibleStep)
 		if !result.Success {
 			return false
@@ -473,8 +689,8 @@ func (se *ScriptEngine) ParseInt(s string, defaultVal int) int {
 }
 
 // ExpandStep expands variables in all string fields of a step.
-// Note: This modifies the step in place. For steps used in loops,
-// the parser creates fresh instances each iteration.
+// Note: This modifies the step in place. The runner never expands a flow's
+// own steps this way; it expands copies through ExpandedStep.
 func (se *ScriptEngine) ExpandStep(step flow.Step) {
 	switch s := step.(type) {
 	case *flow.InputTextStep:
@@ -486,6 +702,7 @@ func (se *ScriptEngine) ExpandStep(step flow.Step) {
 		s.Selector = *se.expandSelector(&s.Selector)
 	case *flow.LongPressOnStep:
 		s.Selector = *se.expandSelector(&s.Selector)
+		s.Point = se.ExpandVariables(s.Point)
 	case *flow.AssertVisibleStep:
 		s.Selector = *se.expandSelector(&s.Selector)
 	case *flow.AssertNotVisibleStep:
@@ -497,8 +714,21 @@ func (se *ScriptEngine) ExpandStep(step flow.Step) {
 		if s.NotVisible != nil {
 			s.NotVisible = se.expandSelector(s.NotVisible)
 		}
+	case *flow.ExtendedWaitUntilStep:
+		visible := make([]flow.Selector, len(s.Visible))
+		for i := range s.Visible {
+			visible[i] = *se.expandSelector(&s.Visible[i])
+		}
+		s.Visible = visible
+		notVisible := make([]flow.Selector, len(s.NotVisible))
+		for i := range s.NotVisible {
+			notVisible[i] = *se.expandSelector(&s.NotVisible[i])
+		}
+		s.NotVisible = notVisible
 	case *flow.ScrollUntilVisibleStep:
 		s.Element = *se.expandSelector(&s.Element)
+	case *flow.AssertSortedStep:
+		s.Element = *se.expandSelector(&s.Element)
 	case *flow.CopyTextFromStep:
 		s.Selector = *se.expandSelector(&s.Selector)
 	case *flow.LaunchAppStep:
@@ -509,13 +739,108 @@ func (se *ScriptEngine) ExpandStep(step flow.Step) {
 		s.AppID = se.ExpandVariables(s.AppID)
 	case *flow.ClearStateStep:
 		s.AppID = se.ExpandVariables(s.AppID)
+	case *flow.AssertResourceStep:
+		s.AppID = se.ExpandVariables(s.AppID)
 	case *flow.OpenLinkStep:
 		s.Link = se.ExpandVariables(s.Link)
+		s.AppID = se.ExpandVariables(s.AppID)
+		if s.WaitFor != nil {
+			s.WaitFor = se.expandSelector(s.WaitFor)
+		}
 	case *flow.PressKeyStep:
 		s.Key = se.ExpandVariables(s.Key)
+	case *flow.AssertAlertTextStep:
+		s.Text = se.ExpandVariables(s.Text)
+		s.Contains = se.ExpandVariables(s.Contains)
+	case *flow.EnsureUnlockedStep:
+		s.Passcode = se.ExpandVariables(s.Passcode)
+	case *flow.SetAppearanceStep:
+		s.Mode = se.ExpandVariables(s.Mode)
+	case *flow.AssertFileExistsStep:
+		s.Path = se.ExpandVariables(s.Path)
+	case *flow.TapSequenceStep:
+		taps := make([]flow.Selector, len(s.Taps))
+		for i := range s.Taps {
+			taps[i] = *se.expandSelector(&s.Taps[i])
+		}
+		s.Taps = taps
+	case *flow.WaitForDownloadStep:
+		s.File = se.ExpandVariables(s.File)
+		s.Directory = se.ExpandVariables(s.Directory)
+	case *flow.WaitForInstallStep:
+		s.AppID = se.ExpandVariables(s.AppID)
+	case *flow.WaitForTextStep:
+		s.Element = *se.expandSelector(&s.Element)
+		s.Equals = se.ExpandVariables(s.Equals)
+		s.Contains = se.ExpandVariables(s.Contains)
+		s.Matches = se.ExpandVariables(s.Matches)
+	case *flow.TwoFingerSwipeStep:
+		s.Direction = se.ExpandVariables(s.Direction)
+		s.Start = se.ExpandVariables(s.Start)
+		s.End = se.ExpandVariables(s.End)
+	case *flow.GesturePathStep:
+		points := make([]string, len(s.Points))
+		for i, p := range s.Points {
+			points[i] = se.ExpandVariables(p)
+		}
+		s.Points = points
+	case *flow.SetAppLocaleStep:
+		s.AppID = se.ExpandVariables(s.AppID)
+		s.Locale = se.ExpandVariables(s.Locale)
+	case *flow.AssertFieldValueStep:
+		s.Element = *se.expandSelector(&s.Element)
+		s.Equals = se.ExpandVariables(s.Equals)
+		s.Contains = se.ExpandVariables(s.Contains)
+	case *flow.SetPreferenceStep:
+		s.AppID = se.ExpandVariables(s.AppID)
+		s.Key = se.ExpandVariables(s.Key)
+		s.Value = se.ExpandVariables(s.Value)
+	case *flow.AssertShareTargetStep:
+		s.App = se.ExpandVariables(s.App)
+	case *flow.AssertThemeColorStep:
+		s.Color = se.ExpandVariables(s.Color)
+	case *flow.AssertScreenshotStep:
+		s.Baseline = se.ExpandVariables(s.Baseline)
+	case *flow.AssertTextNotContainsStep:
+		text := make([]string, len(s.Text))
+		for i, t := range s.Text {
+			text[i] = se.ExpandVariables(t)
+		}
+		s.Text = text
+		regex := make([]string, len(s.Regex))
+		for i, r := range s.Regex {
+			regex[i] = se.ExpandVariables(r)
+		}
+		s.Regex = regex
+	case *flow.AssertNoJankStep:
+		// Nested steps are expanded on a copy as each one runs
+		s.AppID = se.ExpandVariables(s.AppID)
+	case *flow.SearchStep:
+		s.Field = *se.expandSelector(&s.Field)
+		s.Query = se.ExpandVariables(s.Query)
+		if s.WaitFor != nil {
+			s.WaitFor = se.expandSelector(s.WaitFor)
+		}
 	}
 }
 
+// ExpandedStep returns a shallow copy of step with variables expanded, leaving
+// the original untouched. Top-level and nested steps both go through it: a step
+// can run more than once (repeat/retry bodies, retries, the same flow on several
+// devices), so each execution must expand from the unexpanded template.
+func (se *ScriptEngine) ExpandedStep(step flow.Step) flow.Step {
+	v := reflect.ValueOf(step)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		se.ExpandStep(step)
+		return step
+	}
+	cp := reflect.New(v.Elem().Type())
+	cp.Elem().Set(v.Elem())
+	expanded := cp.Interface().(flow.Step)
+	se.ExpandStep(expanded)
+	return expanded
+}
+
 // expandSelector expands variables in selector fields and returns a copy.
 func (se *ScriptEngine) expandSelector(sel *flow.Selector) *flow.Selector {
 	if sel == nil {
@@ -524,6 +849,7 @@ func (se *ScriptEngine) expandSelector(sel *flow.Selector) *flow.Selector {
 	// Create a copy to avoid modifying the original
 	expanded := *sel
 	expanded.Text = se.ExpandVariables(expanded.Text)
+	expanded.TextRegex = se.ExpandVariables(expanded.TextRegex)
 	expanded.ID = se.ExpandVariables(expanded.ID)
 	expanded.CSS = se.ExpandVariables(expanded.CSS)
 	expanded.Index = se.ExpandVariables(expanded.Index)
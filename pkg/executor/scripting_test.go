@@ -4,7 +4,9 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/devicelab-dev/maestro-runner/pkg/core"
 	"github.com/devicelab-dev/maestro-runner/pkg/flow"
@@ -234,6 +236,94 @@ func TestScriptEngine_EvalCondition(t *testing.T) {
 	}
 }
 
+func TestScriptEngine_MaestroHelpers(t *testing.T) {
+	se := NewScriptEngine()
+	defer se.Close()
+
+	if err := se.RunScript("output.id = maestro.uuid(); output.pin = maestro.randomNumber(4)", nil); err != nil {
+		t.Fatalf("RunScript() error = %v", err)
+	}
+	out := se.GetOutput()
+	if id, _ := out["id"].(string); len(id) != 36 {
+		t.Errorf("output.id = %v, want a UUID", out["id"])
+	}
+	if pin, _ := out["pin"].(string); len(pin) != 4 {
+		t.Errorf("output.pin = %v, want 4 digits", out["pin"])
+	}
+
+	ok, err := se.EvalCondition("maestro.randomEmail().indexOf('@') > 0 && maestro.now() > 0")
+	if err != nil {
+		t.Fatalf("EvalCondition() error = %v", err)
+	}
+	if !ok {
+		t.Error("EvalCondition() = false, want true")
+	}
+
+	if got := se.ExpandVariables("${maestro.formatDate('YYYY')}"); len(got) != 4 {
+		t.Errorf("ExpandVariables() = %q, want a 4-digit year", got)
+	}
+}
+
+func TestScriptEngine_EvalCondition_NumericCoercion(t *testing.T) {
+	se := NewScriptEngine()
+	defer se.Close()
+
+	se.SetVariables(map[string]string{
+		"LEAD":  "007",
+		"OCT":   "010",
+		"FLOAT": "1.50",
+		"NEG":   "-3",
+		"TEN":   "10",
+		"NINE":  "9",
+		"NAME":  "abc",
+		"ZIP":   "00501",
+	})
+
+	tests := []struct {
+		name     string
+		script   string
+		expected bool
+	}{
+		{"leading zeros equal number", "LEAD == 7", true},
+		{"strict equal keeps types", "LEAD === 7", false},
+		{"strict not equal keeps types", "TEN !== 10", true},
+		{"leading zeros equal numeric string", "LEAD == '7'", true},
+		{"leading zeros greater than", "LEAD > 6", true},
+		{"dollar var leading zeros", "$LEAD == 7", true},
+		{"dollar var not octal", "$OCT == 10", true},
+		{"dollar var quoted keeps text", "'$LEAD' === '007'", true},
+		{"float equal", "FLOAT == 1.5", true},
+		{"float greater than", "FLOAT > 1.25", true},
+		{"float less than string literal", "FLOAT < '2'", true},
+		{"negative less than zero", "NEG < 0", true},
+		{"negative less than negative", "NEG < -2", true},
+		{"negative string literal", "NEG > '-4'", true},
+		{"numeric variables compare as numbers", "TEN > NINE", true},
+		{"numeric variables not equal", "TEN != NINE", true},
+		{"string equal literal", "NAME == 'abc'", true},
+		{"string ordering", "NAME > 'abb'", true},
+		{"string vs number", "NAME > 5", false},
+		{"string not equal number", "NAME != 10", true},
+		{"dollar var string", "$NAME == 'abc'", true},
+		{"string methods still work", "ZIP.length == 5 && ZIP == 501", true},
+		{"nested in logic", "TEN > NINE && NAME == 'abc'", true},
+		{"nested in ternary", "(TEN > NINE ? NAME : '') === 'abc'", true},
+		{"chained comparison", "(NINE < TEN) == true", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := se.EvalCondition(tt.script)
+			if err != nil {
+				t.Fatalf("EvalCondition(%q) error = %v", tt.script, err)
+			}
+			if got != tt.expected {
+				t.Errorf("EvalCondition(%q) = %v, want %v", tt.script, got, tt.expected)
+			}
+		})
+	}
+}
+
 func TestScriptEngine_EvalCondition_Error(t *testing.T) {
 	se := NewScriptEngine()
 	defer se.Close()
@@ -855,6 +945,23 @@ func TestScriptEngine_ExpandStep_TapOnStep(t *testing.T) {
 	}
 }
 
+func TestScriptEngine_ExpandStep_TextRegex(t *testing.T) {
+	se := NewScriptEngine()
+	defer se.Close()
+
+	se.SetVariable("CURRENCY", "EUR")
+
+	step := &flow.AssertVisibleStep{
+		Selector: flow.Selector{TextRegex: "Total: [0-9.]+ ${CURRENCY}"},
+	}
+
+	se.ExpandStep(step)
+
+	if step.Selector.TextRegex != "Total: [0-9.]+ EUR" {
+		t.Errorf("Selector.TextRegex = %q, want %q", step.Selector.TextRegex, "Total: [0-9.]+ EUR")
+	}
+}
+
 func TestScriptEngine_ExpandStep_DoubleTapOnStep(t *testing.T) {
 	se := NewScriptEngine()
 	defer se.Close()
@@ -991,6 +1098,23 @@ func TestScriptEngine_ExpandStep_ClearStateStep(t *testing.T) {
 	}
 }
 
+func TestScriptEngine_ExpandStep_AssertResourceStep(t *testing.T) {
+	se := NewScriptEngine()
+	defer se.Close()
+
+	se.SetVariable("APP_ID", "com.example.app")
+
+	step := &flow.AssertResourceStep{
+		AppID: "${APP_ID}",
+	}
+
+	se.ExpandStep(step)
+
+	if step.AppID != "com.example.app" {
+		t.Errorf("AppID = %q, want %q", step.AppID, "com.example.app")
+	}
+}
+
 func TestScriptEngine_ExpandStep_OpenLinkStep(t *testing.T) {
 	se := NewScriptEngine()
 	defer se.Close()
@@ -1025,6 +1149,27 @@ func TestScriptEngine_ExpandStep_PressKeyStep(t *testing.T) {
 	}
 }
 
+func TestScriptEngine_ExpandStep_AssertAlertTextStep(t *testing.T) {
+	se := NewScriptEngine()
+	defer se.Close()
+
+	se.SetVariable("APP_NAME", "Photos")
+
+	step := &flow.AssertAlertTextStep{
+		Text:     "\"${APP_NAME}\" Would Like to Access Your Camera",
+		Contains: "${APP_NAME}",
+	}
+
+	se.ExpandStep(step)
+
+	if want := "\"Photos\" Would Like to Access Your Camera"; step.Text != want {
+		t.Errorf("Text = %q, want %q", step.Text, want)
+	}
+	if step.Contains != "Photos" {
+		t.Errorf("Contains = %q, want %q", step.Contains, "Photos")
+	}
+}
+
 func TestScriptEngine_ExpandStep_WaitUntilStep(t *testing.T) {
 	se := NewScriptEngine()
 	defer se.Close()
@@ -1354,6 +1499,20 @@ func TestScriptEngine_CheckCondition_NotVisible_Success(t *testing.T) {
 	}
 }
 
+func TestScriptEngine_CheckCondition_Platform(t *testing.T) {
+	se := NewScriptEngine()
+	defer se.Close()
+
+	driver := &mockDriver{} // reports android
+
+	if !se.CheckCondition(context.Background(), flow.Condition{Platform: "Android"}, driver) {
+		t.Error("CheckCondition() with matching platform should return true")
+	}
+	if se.CheckCondition(context.Background(), flow.Condition{Platform: "iOS"}, driver) {
+		t.Error("CheckCondition() with other platform should return false")
+	}
+}
+
 func TestScriptEngine_CheckCondition_NotVisible_Failure(t *testing.T) {
 	se := NewScriptEngine()
 	defer se.Close()
@@ -1610,3 +1769,163 @@ func TestScriptEngine_EvalCondition_UndefinedVariable(t *testing.T) {
 		t.Error("EvalCondition(SOME_UNDEFINED_VAR) should return false for undefined variable")
 	}
 }
+
+func TestScriptEngine_CopyTextFromBinding(t *testing.T) {
+	var selectors []flow.Selector
+	driver := &mockDriver{
+		executeFunc: func(step flow.Step) *core.CommandResult {
+			s, ok := step.(*flow.CopyTextFromStep)
+			if !ok {
+				t.Fatalf("unexpected step %T", step)
+			}
+			selectors = append(selectors, s.Selector)
+			return &core.CommandResult{Success: true, Data: "$9.99"}
+		},
+	}
+
+	se := NewScriptEngine()
+	defer se.Close()
+	se.SetDriver(driver)
+	se.SetVariable("FIELD", "total")
+
+	if err := se.RunScript("output.ok = maestro.copyTextFrom('price').indexOf('$') === 0", nil); err != nil {
+		t.Fatalf("RunScript() error = %v", err)
+	}
+	if ok, _ := se.GetOutput()["ok"].(bool); !ok {
+		t.Errorf("output.ok = %v, want true", se.GetOutput()["ok"])
+	}
+
+	if err := se.RunScript("output.total = maestro.copyTextFrom({id: FIELD, index: 1})", nil); err != nil {
+		t.Fatalf("RunScript() error = %v", err)
+	}
+	if got := se.GetOutput()["total"]; got != "$9.99" {
+		t.Errorf("output.total = %v, want $9.99", got)
+	}
+
+	if len(selectors) != 2 {
+		t.Fatalf("driver called %d times, want 2", len(selectors))
+	}
+	if selectors[0].Text != "price" {
+		t.Errorf("string selector Text = %q, want price", selectors[0].Text)
+	}
+	if selectors[1].ID != "total" || selectors[1].Index != "1" {
+		t.Errorf("object selector = %+v, want id=total index=1", selectors[1])
+	}
+}
+
+// The script has already evaluated its arguments, so a selector containing
+// "${" reaches the driver as written instead of being expanded again while
+// the script holds the JS engine.
+func TestScriptEngine_CopyTextFromBindingLiteralExpression(t *testing.T) {
+	var selector flow.Selector
+	driver := &mockDriver{
+		executeFunc: func(step flow.Step) *core.CommandResult {
+			selector = step.(*flow.CopyTextFromStep).Selector
+			return &core.CommandResult{Success: true, Data: "2"}
+		},
+	}
+
+	se := NewScriptEngine()
+	defer se.Close()
+	se.SetDriver(driver)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- se.RunScript("output.total = maestro.copyTextFrom('Total $' + '{1 + 1}')", nil)
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("RunScript() error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("RunScript() hung on a selector containing ${")
+	}
+
+	if selector.Text != "Total ${1 + 1}" {
+		t.Errorf("selector Text = %q, want it unexpanded", selector.Text)
+	}
+}
+
+func TestScriptEngine_CopyTextFromBindingError(t *testing.T) {
+	driver := &mockDriver{
+		executeFunc: func(step flow.Step) *core.CommandResult {
+			return &core.CommandResult{Success: false, Message: "element not found"}
+		},
+	}
+
+	se := NewScriptEngine()
+	defer se.Close()
+	se.SetDriver(driver)
+
+	err := se.RunScript("maestro.copyTextFrom('missing')", nil)
+	if err == nil || !strings.Contains(err.Error(), "element not found") {
+		t.Errorf("RunScript() error = %v, want element not found", err)
+	}
+}
+
+func TestScriptEngine_RunScriptRequire(t *testing.T) {
+	dir := t.TempDir()
+	helper := "exports.isPrice = function(s) { return s.indexOf('$') === 0; };\n"
+	if err := os.WriteFile(filepath.Join(dir, "helpers.js"), []byte(helper), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	main := "var h = require('./helpers.js');\noutput.ok = h.isPrice(PRICE);\n"
+	if err := os.WriteFile(filepath.Join(dir, "main.js"), []byte(main), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	se := NewScriptEngine()
+	defer se.Close()
+	se.SetFlowDir(dir)
+	se.SetVariable("PRICE", "$9.99")
+
+	result := se.ExecuteRunScript(&flow.RunScriptStep{Script: "main.js"})
+	if !result.Success {
+		t.Fatalf("ExecuteRunScript() failed: %s", result.Message)
+	}
+	if got := se.GetVariable("ok"); got != "true" {
+		t.Errorf("ok = %q, want true", got)
+	}
+}
+
+func TestScriptEngine_RunScriptRequireFromSubdirectory(t *testing.T) {
+	dir := t.TempDir()
+	scripts := filepath.Join(dir, "scripts")
+	if err := os.Mkdir(scripts, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	// A helpers.js next to the flow must not shadow the script's own
+	flowHelper := "exports.name = 'flow';\n"
+	if err := os.WriteFile(filepath.Join(dir, "helpers.js"), []byte(flowHelper), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	helper := "exports.name = 'scripts';\n"
+	if err := os.WriteFile(filepath.Join(scripts, "helpers.js"), []byte(helper), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	main := "output.from = require('./helpers.js').name;\n"
+	if err := os.WriteFile(filepath.Join(scripts, "main.js"), []byte(main), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	se := NewScriptEngine()
+	defer se.Close()
+	se.SetFlowDir(dir)
+
+	result := se.ExecuteRunScript(&flow.RunScriptStep{Script: "scripts/main.js"})
+	if !result.Success {
+		t.Fatalf("ExecuteRunScript() failed: %s", result.Message)
+	}
+	if got := se.GetVariable("from"); got != "scripts" {
+		t.Errorf("require loaded %q helpers, want scripts/helpers.js", got)
+	}
+
+	// Inline scripts still resolve against the flow directory
+	if err := se.RunScript("output.inline = require('./helpers.js').name", nil); err != nil {
+		t.Fatalf("RunScript() error = %v", err)
+	}
+	if got := se.GetVariable("inline"); got != "flow" {
+		t.Errorf("inline require loaded %q helpers, want the flow's", got)
+	}
+}
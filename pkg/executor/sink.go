@@ -0,0 +1,76 @@
+package executor
+
+import "github.com/devicelab-dev/maestro-runner/pkg/report"
+
+// ResultSink receives a run's progress as flows and steps finish: the CLI's
+// terminal printer and its --format json stream both implement it. Parallel
+// runs call it from several goroutines.
+type ResultSink interface {
+	FlowStart(FlowEvent)
+	// NestedFlowStart is called when a runFlow step starts running a file.
+	NestedFlowStart(depth int, desc string)
+	// StepResult is called for every finished top-level step and for steps
+	// nested in a runFlow file.
+	StepResult(StepEvent)
+	FlowEnd(FlowEvent)
+}
+
+// FlowEvent describes a flow starting or ending.
+type FlowEvent struct {
+	Index  int // position in the run
+	Total  int // number of flows in the run
+	Name   string
+	File   string         // base name of the flow file
+	Device *report.Device // device running the flow (parallel runs only)
+
+	// Set when the flow ends
+	Passed     bool
+	DurationMs int64
+	Error      string
+}
+
+// StepEvent describes a finished step.
+type StepEvent struct {
+	Flow        string `json:"flow"`
+	Index       int    `json:"index"` // position in the flow, or in the parent step when nested
+	Depth       int    `json:"depth"` // 0 for top-level steps
+	Type        string `json:"type"`
+	Description string `json:"-"`                  // step.Describe(), for terminal output
+	Selector    string `json:"selector,omitempty"` // "type=value", as in the timing CSV
+	Success     bool   `json:"success"`
+	Message     string `json:"message,omitempty"`
+	DurationMs  int64  `json:"durationMs"`
+	Error       string `json:"error,omitempty"`
+	Screenshot  string `json:"screenshot,omitempty"` // failure screenshot, relative to the report dir
+}
+
+// MultiSink passes every event to each of its sinks in order.
+type MultiSink []ResultSink
+
+// FlowStart implements ResultSink.
+func (m MultiSink) FlowStart(ev FlowEvent) {
+	for _, s := range m {
+		s.FlowStart(ev)
+	}
+}
+
+// NestedFlowStart implements ResultSink.
+func (m MultiSink) NestedFlowStart(depth int, desc string) {
+	for _, s := range m {
+		s.NestedFlowStart(depth, desc)
+	}
+}
+
+// StepResult implements ResultSink.
+func (m MultiSink) StepResult(ev StepEvent) {
+	for _, s := range m {
+		s.StepResult(ev)
+	}
+}
+
+// FlowEnd implements ResultSink.
+func (m MultiSink) FlowEnd(ev FlowEvent) {
+	for _, s := range m {
+		s.FlowEnd(ev)
+	}
+}
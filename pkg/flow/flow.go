@@ -57,6 +57,7 @@ type Config struct {
 	AppID              string            `yaml:"appId"`
 	URL                string            `yaml:"url"` // Web app URL (alternative to appId)
 	Name               string            `yaml:"name"`
+	Label              string            `yaml:"label"` // Display name used when name is not set
 	Tags               []string          `yaml:"tags"`
 	Env                map[string]string `yaml:"env"`
 	Timeout            int               `yaml:"timeout"`            // Flow timeout in ms
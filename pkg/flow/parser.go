@@ -221,19 +221,19 @@ func extractStepType(node *yaml.Node) (string, *yaml.Node) {
 
 func isStepType(key string) bool {
 	switch StepType(key) {
-	case StepTapOn, StepDoubleTapOn, StepLongPressOn, StepTapOnPoint,
-		StepSwipe, StepScroll, StepScrollUntilVisible, StepBack, StepHideKeyboard,
-		StepAcceptAlert, StepDismissAlert,
+	case StepTapOn, StepDoubleTapOn, StepLongPressOn, StepTapOnPoint, StepTapSequence,
+		StepSwipe, StepGesturePath, StepTwoFingerSwipe, StepScroll, StepScrollUntilVisible, StepScrollToPosition, StepBack, StepHideKeyboard,
+		StepAcceptAlert, StepDismissAlert, StepAssertAlertText,
 		StepInputText, StepInputRandom, StepInputRandomEmail, StepInputRandomNumber,
 		StepInputRandomPersonName, StepInputRandomText,
-		StepEraseText, StepCopyTextFrom, StepPasteText, StepSetClipboard,
+		StepEraseText, StepCopyTextFrom, StepPasteText, StepSetClipboard, StepSearch,
 		StepAssertVisible, StepAssertNotVisible, StepAssertTrue, StepAssertCondition,
-		StepAssertNoDefectsWithAI, StepAssertWithAI, StepExtractTextWithAI, StepWaitUntil,
-		StepLaunchApp, StepStopApp, StepKillApp, StepClearState, StepClearKeychain, StepSetPermissions,
+		StepAssertNoDefectsWithAI, StepAssertWithAI, StepExtractTextWithAI, StepWaitUntil, StepAssertResource, StepAssertSorted, StepAssertFileExists, StepAssertFieldValue, StepAssertShareTarget, StepAssertAccessible, StepAssertNoJank, StepAssertThemeColor, StepAssertScreenshot, StepAssertTextNotContains,
+		StepLaunchApp, StepStopApp, StepKillApp, StepClearState, StepClearKeychain, StepSetPermissions, StepSetAppLocale, StepSetPreference,
 		StepSetLocation, StepSetOrientation, StepSetAirplaneMode, StepToggleAirplaneMode,
-		StepTravel, StepOpenLink, StepOpenBrowser, StepRepeat, StepRetry, StepRunFlow,
+		StepTravel, StepOpenLink, StepOpenBrowser, StepClearNotifications, StepEnsureUnlocked, StepSetAppearance, StepRepeat, StepIf, StepRetry, StepRunFlow,
 		StepRunScript, StepEvalScript, StepTakeScreenshot, StepStartRecording,
-		StepStopRecording, StepAddMedia, StepPressKey, StepWaitForAnimationToEnd,
+		StepStopRecording, StepAddMedia, StepPressKey, StepWaitForAnimationToEnd, StepWaitForDownload, StepWaitForText, StepWaitForInstall,
 		StepDefineVariables:
 		return true
 	}
@@ -281,6 +281,40 @@ func decodeStep(stepType StepType, valueNode *yaml.Node, sourcePath string) (Ste
 		s.StepType = stepType
 		return &s, nil
 
+	case StepTapSequence:
+		var s TapSequenceStep
+		if valueNode.Kind == yaml.SequenceNode {
+			if err := valueNode.Decode(&s.Taps); err != nil {
+				return nil, wrapParseError(sourcePath, valueNode.Line, err)
+			}
+		} else if err := valueNode.Decode(&s); err != nil {
+			return nil, wrapParseError(sourcePath, valueNode.Line, err)
+		}
+		s.StepType = stepType
+		return &s, nil
+
+	case StepTwoFingerSwipe:
+		var s TwoFingerSwipeStep
+		if valueNode.Kind == yaml.ScalarNode {
+			s.Direction = valueNode.Value
+		} else if err := valueNode.Decode(&s); err != nil {
+			return nil, wrapParseError(sourcePath, valueNode.Line, err)
+		}
+		s.StepType = stepType
+		return &s, nil
+
+	case StepGesturePath:
+		var s GesturePathStep
+		if valueNode.Kind == yaml.SequenceNode {
+			if err := valueNode.Decode(&s.Points); err != nil {
+				return nil, wrapParseError(sourcePath, valueNode.Line, err)
+			}
+		} else if err := valueNode.Decode(&s); err != nil {
+			return nil, wrapParseError(sourcePath, valueNode.Line, err)
+		}
+		s.StepType = stepType
+		return &s, nil
+
 	case StepSwipe:
 		var s SwipeStep
 		if valueNode.Kind == yaml.ScalarNode {
@@ -311,8 +345,25 @@ func decodeStep(stepType StepType, valueNode *yaml.Node, sourcePath string) (Ste
 		s.StepType = stepType
 		return &s, nil
 
+	case StepScrollToPosition:
+		var s ScrollToPositionStep
+		if valueNode.Kind == yaml.ScalarNode {
+			s.Position = valueNode.Value
+		} else if err := valueNode.Decode(&s); err != nil {
+			return nil, wrapParseError(sourcePath, valueNode.Line, err)
+		}
+		s.StepType = stepType
+		return &s, nil
+
 	case StepBack:
-		return &BackStep{BaseStep: BaseStep{StepType: stepType}}, nil
+		var s BackStep
+		if valueNode.Kind == yaml.MappingNode {
+			if err := valueNode.Decode(&s); err != nil {
+				return nil, wrapParseError(sourcePath, valueNode.Line, err)
+			}
+		}
+		s.StepType = stepType
+		return &s, nil
 
 	case StepHideKeyboard:
 		return &HideKeyboardStep{BaseStep: BaseStep{StepType: stepType}}, nil
@@ -323,6 +374,16 @@ func decodeStep(stepType StepType, valueNode *yaml.Node, sourcePath string) (Ste
 	case StepDismissAlert:
 		return &DismissAlertStep{BaseStep: BaseStep{StepType: stepType}}, nil
 
+	case StepAssertAlertText:
+		var s AssertAlertTextStep
+		if valueNode.Kind == yaml.ScalarNode {
+			s.Text = valueNode.Value
+		} else if err := valueNode.Decode(&s); err != nil {
+			return nil, wrapParseError(sourcePath, valueNode.Line, err)
+		}
+		s.StepType = stepType
+		return &s, nil
+
 	case StepInputText:
 		var s InputTextStep
 		if valueNode.Kind == yaml.ScalarNode {
@@ -403,6 +464,26 @@ func decodeStep(stepType StepType, valueNode *yaml.Node, sourcePath string) (Ste
 		s.StepType = stepType
 		return &s, nil
 
+	case StepSearch:
+		var s SearchStep
+		if valueNode.Kind == yaml.ScalarNode {
+			s.Query = valueNode.Value
+		} else if err := valueNode.Decode(&s); err != nil {
+			return nil, wrapParseError(sourcePath, valueNode.Line, err)
+		}
+		s.StepType = stepType
+		return &s, nil
+
+	case StepAssertAccessible:
+		var s AssertAccessibleStep
+		if valueNode.Kind == yaml.MappingNode {
+			if err := valueNode.Decode(&s); err != nil {
+				return nil, wrapParseError(sourcePath, valueNode.Line, err)
+			}
+		}
+		s.StepType = stepType
+		return &s, nil
+
 	case StepAssertVisible:
 		var s AssertVisibleStep
 		if valueNode.Kind == yaml.ScalarNode {
@@ -468,6 +549,9 @@ func decodeStep(stepType StepType, valueNode *yaml.Node, sourcePath string) (Ste
 		return &s, nil
 
 	case StepWaitUntil:
+		if hasSequenceValue(valueNode, "visible", "notVisible") {
+			return parseExtendedWaitUntilStep(valueNode, sourcePath)
+		}
 		var s WaitUntilStep
 		if err := valueNode.Decode(&s); err != nil {
 			return nil, wrapParseError(sourcePath, valueNode.Line, err)
@@ -475,6 +559,32 @@ func decodeStep(stepType StepType, valueNode *yaml.Node, sourcePath string) (Ste
 		s.StepType = stepType
 		return &s, nil
 
+	case StepAssertResource:
+		var s AssertResourceStep
+		if err := valueNode.Decode(&s); err != nil {
+			return nil, wrapParseError(sourcePath, valueNode.Line, err)
+		}
+		s.StepType = stepType
+		return &s, nil
+
+	case StepAssertSorted:
+		var s AssertSortedStep
+		if err := valueNode.Decode(&s); err != nil {
+			return nil, wrapParseError(sourcePath, valueNode.Line, err)
+		}
+		s.StepType = stepType
+		return &s, nil
+
+	case StepAssertFileExists:
+		var s AssertFileExistsStep
+		if valueNode.Kind == yaml.ScalarNode {
+			s.Path = valueNode.Value
+		} else if err := valueNode.Decode(&s); err != nil {
+			return nil, wrapParseError(sourcePath, valueNode.Line, err)
+		}
+		s.StepType = stepType
+		return &s, nil
+
 	case StepLaunchApp:
 		var s LaunchAppStep
 		if valueNode.Kind == yaml.ScalarNode {
@@ -555,6 +665,29 @@ func decodeStep(stepType StepType, valueNode *yaml.Node, sourcePath string) (Ste
 	case StepToggleAirplaneMode:
 		return &ToggleAirplaneModeStep{BaseStep: BaseStep{StepType: stepType}}, nil
 
+	case StepClearNotifications:
+		return &ClearNotificationsStep{BaseStep: BaseStep{StepType: stepType}}, nil
+
+	case StepEnsureUnlocked:
+		var s EnsureUnlockedStep
+		if valueNode.Kind == yaml.ScalarNode {
+			s.Passcode = valueNode.Value
+		} else if err := valueNode.Decode(&s); err != nil {
+			return nil, wrapParseError(sourcePath, valueNode.Line, err)
+		}
+		s.StepType = stepType
+		return &s, nil
+
+	case StepSetAppearance:
+		var s SetAppearanceStep
+		if valueNode.Kind == yaml.ScalarNode {
+			s.Mode = valueNode.Value
+		} else if err := valueNode.Decode(&s); err != nil {
+			return nil, wrapParseError(sourcePath, valueNode.Line, err)
+		}
+		s.StepType = stepType
+		return &s, nil
+
 	case StepTravel:
 		var s TravelStep
 		if err := valueNode.Decode(&s); err != nil {
@@ -586,12 +719,18 @@ func decodeStep(stepType StepType, valueNode *yaml.Node, sourcePath string) (Ste
 	case StepRepeat:
 		return parseRepeatStep(valueNode, sourcePath)
 
+	case StepIf:
+		return parseIfStep(valueNode, sourcePath)
+
 	case StepRetry:
 		return parseRetryStep(valueNode, sourcePath)
 
 	case StepRunFlow:
 		return parseRunFlowStep(valueNode, sourcePath)
 
+	case StepAssertNoJank:
+		return parseAssertNoJankStep(valueNode, sourcePath)
+
 	case StepRunScript:
 		var s RunScriptStep
 		if valueNode.Kind == yaml.ScalarNode {
@@ -668,6 +807,107 @@ func decodeStep(stepType StepType, valueNode *yaml.Node, sourcePath string) (Ste
 		s.StepType = stepType
 		return &s, nil
 
+	case StepWaitForDownload:
+		var s WaitForDownloadStep
+		if valueNode.Kind == yaml.ScalarNode {
+			s.File = valueNode.Value
+		} else if err := valueNode.Decode(&s); err != nil {
+			return nil, wrapParseError(sourcePath, valueNode.Line, err)
+		}
+		s.StepType = stepType
+		return &s, nil
+
+	case StepAssertFieldValue:
+		var s AssertFieldValueStep
+		if err := valueNode.Decode(&s); err != nil {
+			return nil, wrapParseError(sourcePath, valueNode.Line, err)
+		}
+		s.StepType = stepType
+		return &s, nil
+
+	case StepSetPreference:
+		var s SetPreferenceStep
+		if err := valueNode.Decode(&s); err != nil {
+			return nil, wrapParseError(sourcePath, valueNode.Line, err)
+		}
+		s.StepType = stepType
+		return &s, nil
+
+	case StepAssertShareTarget:
+		var s AssertShareTargetStep
+		if valueNode.Kind == yaml.ScalarNode {
+			s.App = valueNode.Value
+		} else if err := valueNode.Decode(&s); err != nil {
+			return nil, wrapParseError(sourcePath, valueNode.Line, err)
+		}
+		s.StepType = stepType
+		return &s, nil
+
+	case StepSetAppLocale:
+		var s SetAppLocaleStep
+		if valueNode.Kind == yaml.ScalarNode {
+			s.Locale = valueNode.Value
+		} else if err := valueNode.Decode(&s); err != nil {
+			return nil, wrapParseError(sourcePath, valueNode.Line, err)
+		}
+		s.StepType = stepType
+		return &s, nil
+
+	case StepAssertThemeColor:
+		var s AssertThemeColorStep
+		if valueNode.Kind == yaml.ScalarNode {
+			s.Color = valueNode.Value
+		} else if err := valueNode.Decode(&s); err != nil {
+			return nil, wrapParseError(sourcePath, valueNode.Line, err)
+		}
+		s.StepType = stepType
+		return &s, nil
+
+	case StepAssertScreenshot:
+		var s AssertScreenshotStep
+		if valueNode.Kind == yaml.ScalarNode {
+			s.Baseline = valueNode.Value
+		} else if err := valueNode.Decode(&s); err != nil {
+			return nil, wrapParseError(sourcePath, valueNode.Line, err)
+		}
+		s.StepType = stepType
+		return &s, nil
+
+	case StepAssertTextNotContains:
+		var s AssertTextNotContainsStep
+		var err error
+		switch valueNode.Kind {
+		case yaml.ScalarNode:
+			s.Text = []string{valueNode.Value}
+		case yaml.SequenceNode:
+			err = valueNode.Decode(&s.Text)
+		default:
+			err = valueNode.Decode(&s)
+		}
+		if err != nil {
+			return nil, wrapParseError(sourcePath, valueNode.Line, err)
+		}
+		s.StepType = stepType
+		return &s, nil
+
+	case StepWaitForInstall:
+		var s WaitForInstallStep
+		if valueNode.Kind == yaml.ScalarNode {
+			s.AppID = valueNode.Value
+		} else if err := valueNode.Decode(&s); err != nil {
+			return nil, wrapParseError(sourcePath, valueNode.Line, err)
+		}
+		s.StepType = stepType
+		return &s, nil
+
+	case StepWaitForText:
+		var s WaitForTextStep
+		if err := valueNode.Decode(&s); err != nil {
+			return nil, wrapParseError(sourcePath, valueNode.Line, err)
+		}
+		s.StepType = stepType
+		return &s, nil
+
 	case StepDefineVariables:
 		var s DefineVariablesStep
 		s.Env = make(map[string]string)
@@ -722,6 +962,52 @@ func parseRepeatStep(valueNode *yaml.Node, sourcePath string) (Step, error) {
 	return s, nil
 }
 
+// parseIfStep handles if with then/else branches.
+func parseIfStep(valueNode *yaml.Node, sourcePath string) (Step, error) {
+	var raw struct {
+		Condition Condition   `yaml:"condition"`
+		Then      []yaml.Node `yaml:"then"`
+		Else      []yaml.Node `yaml:"else"`
+		Optional  bool        `yaml:"optional"`
+		Label     string      `yaml:"label"`
+	}
+
+	if err := valueNode.Decode(&raw); err != nil {
+		return nil, wrapParseError(sourcePath, valueNode.Line, err)
+	}
+
+	cond := raw.Condition
+	if cond.Visible == nil && cond.NotVisible == nil && cond.Script == "" && cond.Platform == "" {
+		return nil, wrapParseError(sourcePath, valueNode.Line, fmt.Errorf("if requires a condition"))
+	}
+
+	s := &IfStep{
+		BaseStep: BaseStep{
+			StepType:  StepIf,
+			Optional:  raw.Optional,
+			StepLabel: raw.Label,
+		},
+		Condition: cond,
+	}
+
+	for _, cmdNode := range raw.Then {
+		step, err := parseStep(&cmdNode, sourcePath)
+		if err != nil {
+			return nil, err
+		}
+		s.Then = append(s.Then, step)
+	}
+	for _, cmdNode := range raw.Else {
+		step, err := parseStep(&cmdNode, sourcePath)
+		if err != nil {
+			return nil, err
+		}
+		s.Else = append(s.Else, step)
+	}
+
+	return s, nil
+}
+
 // parseRetryStep handles retry with nested commands.
 func parseRetryStep(valueNode *yaml.Node, sourcePath string) (Step, error) {
 	var raw struct {
@@ -759,6 +1045,98 @@ func parseRetryStep(valueNode *yaml.Node, sourcePath string) (Step, error) {
 	return s, nil
 }
 
+// hasSequenceValue reports whether mapping node has any of keys bound to a list.
+func hasSequenceValue(node *yaml.Node, keys ...string) bool {
+	if node.Kind != yaml.MappingNode {
+		return false
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		for _, key := range keys {
+			if node.Content[i].Value == key && node.Content[i+1].Kind == yaml.SequenceNode {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// parseExtendedWaitUntilStep handles extendedWaitUntil with selector lists.
+// Either key may still hold a single selector alongside a list in the other.
+func parseExtendedWaitUntilStep(valueNode *yaml.Node, sourcePath string) (Step, error) {
+	var raw struct {
+		BaseStep   `yaml:",inline"`
+		Visible    yaml.Node `yaml:"visible"`
+		NotVisible yaml.Node `yaml:"notVisible"`
+	}
+	if err := valueNode.Decode(&raw); err != nil {
+		return nil, wrapParseError(sourcePath, valueNode.Line, err)
+	}
+
+	s := &ExtendedWaitUntilStep{BaseStep: raw.BaseStep}
+	s.StepType = StepWaitUntil
+	var err error
+	if s.Visible, err = decodeSelectorList(&raw.Visible); err != nil {
+		return nil, wrapParseError(sourcePath, raw.Visible.Line, err)
+	}
+	if s.NotVisible, err = decodeSelectorList(&raw.NotVisible); err != nil {
+		return nil, wrapParseError(sourcePath, raw.NotVisible.Line, err)
+	}
+	return s, nil
+}
+
+// decodeSelectorList decodes a selector or a list of selectors.
+func decodeSelectorList(node *yaml.Node) ([]Selector, error) {
+	switch node.Kind {
+	case 0:
+		return nil, nil
+	case yaml.SequenceNode:
+		var sels []Selector
+		err := node.Decode(&sels)
+		return sels, err
+	default:
+		var sel Selector
+		if err := node.Decode(&sel); err != nil {
+			return nil, err
+		}
+		return []Selector{sel}, nil
+	}
+}
+
+// parseAssertNoJankStep handles assertNoJank and the commands it measures.
+func parseAssertNoJankStep(valueNode *yaml.Node, sourcePath string) (Step, error) {
+	var raw struct {
+		AppID           string      `yaml:"appId"`
+		MaxJankyPercent float64     `yaml:"maxJankyPercent"`
+		Commands        []yaml.Node `yaml:"commands"`
+		Optional        bool        `yaml:"optional"`
+		Label           string      `yaml:"label"`
+	}
+
+	if err := valueNode.Decode(&raw); err != nil {
+		return nil, wrapParseError(sourcePath, valueNode.Line, err)
+	}
+
+	s := &AssertNoJankStep{
+		BaseStep: BaseStep{
+			StepType:  StepAssertNoJank,
+			Optional:  raw.Optional,
+			StepLabel: raw.Label,
+		},
+		AppID:           raw.AppID,
+		MaxJankyPercent: raw.MaxJankyPercent,
+	}
+
+	for _, cmdNode := range raw.Commands {
+		step, err := parseStep(&cmdNode, sourcePath)
+		if err != nil {
+			return nil, err
+		}
+		s.Steps = append(s.Steps, step)
+	}
+
+	return s, nil
+}
+
 // parseRunFlowStep handles runFlow with optional nested commands.
 func parseRunFlowStep(valueNode *yaml.Node, sourcePath string) (Step, error) {
 	s := &RunFlowStep{BaseStep: BaseStep{StepType: StepRunFlow}}
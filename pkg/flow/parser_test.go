@@ -90,6 +90,31 @@ timeout: 30000
 	}
 }
 
+func TestParse_WithLabel(t *testing.T) {
+	yaml := `
+appId: com.example.app
+label: Checkout
+env:
+  CARD: "4242"
+---
+- inputText: ${CARD}
+`
+	flow, err := Parse([]byte(yaml), "test.yaml")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if flow.Config.Label != "Checkout" {
+		t.Errorf("expected label=Checkout, got %q", flow.Config.Label)
+	}
+	if flow.Config.Name != "" {
+		t.Errorf("expected empty name, got %q", flow.Config.Name)
+	}
+	if flow.Config.Env["CARD"] != "4242" {
+		t.Errorf("expected env.CARD=4242, got %q", flow.Config.Env["CARD"])
+	}
+}
+
 func TestParse_AllStepTypes(t *testing.T) {
 	testCases := []struct {
 		name     string
@@ -101,14 +126,18 @@ func TestParse_AllStepTypes(t *testing.T) {
 		{"doubleTapOn", `- doubleTapOn: "Button"`, StepDoubleTapOn},
 		{"longPressOn", `- longPressOn: "Button"`, StepLongPressOn},
 		{"tapOnPoint", `- tapOnPoint: {x: 100, y: 200}`, StepTapOnPoint},
+		{"tapSequence list", `- tapSequence: ["1", "2"]`, StepTapSequence},
+		{"tapSequence mapping", `- tapSequence: {delay: 50, taps: [{point: "10%,10%"}]}`, StepTapSequence},
 		{"swipe scalar", `- swipe: UP`, StepSwipe},
 		{"swipe mapping", `- swipe: {direction: DOWN}`, StepSwipe},
 		{"scroll", `- scroll: DOWN`, StepScroll},
 		{"scrollUntilVisible", `- scrollUntilVisible: "End"`, StepScrollUntilVisible},
+		{"scrollToPosition", `- scrollToPosition: "50%"`, StepScrollToPosition},
 		{"back", `- back:`, StepBack},
 		{"hideKeyboard", `- hideKeyboard:`, StepHideKeyboard},
 		{"acceptAlert", `- acceptAlert:`, StepAcceptAlert},
 		{"dismissAlert", `- dismissAlert:`, StepDismissAlert},
+		{"assertAlertText", `- assertAlertText: "Allow?"`, StepAssertAlertText},
 		{"inputText scalar", `- inputText: "hello"`, StepInputText},
 		{"inputText mapping", `- inputText: {text: hello}`, StepInputText},
 		{"inputRandom", `- inputRandom: EMAIL`, StepInputRandom},
@@ -128,6 +157,10 @@ func TestParse_AllStepTypes(t *testing.T) {
 		{"assertWithAI", `- assertWithAI: "Button visible"`, StepAssertWithAI},
 		{"extractTextWithAI", `- extractTextWithAI: {query: "price", variable: p}`, StepExtractTextWithAI},
 		{"extendedWaitUntil", `- extendedWaitUntil: {visible: {text: "Ready"}}`, StepWaitUntil},
+		{"assertResource", `- assertResource: {maxMemoryMb: 300}`, StepAssertResource},
+		{"assertSorted", `- assertSorted: {element: {id: "price"}, numeric: true}`, StepAssertSorted},
+		{"assertFileExists scalar", `- assertFileExists: "receipt.png"`, StepAssertFileExists},
+		{"assertFileExists mapping", `- assertFileExists: {path: "/sdcard/Pictures/*.png", minSize: 1024, device: true}`, StepAssertFileExists},
 		{"launchApp scalar", `- launchApp: com.example.app`, StepLaunchApp},
 		{"launchApp mapping", `- launchApp: {appId: com.app}`, StepLaunchApp},
 		{"stopApp", `- stopApp: com.example.app`, StepStopApp},
@@ -139,6 +172,11 @@ func TestParse_AllStepTypes(t *testing.T) {
 		{"setOrientation mapping", `- setOrientation: {orientation: PORTRAIT}`, StepSetOrientation},
 		{"setAirplaneMode", `- setAirplaneMode: {enabled: true}`, StepSetAirplaneMode},
 		{"toggleAirplaneMode", `- toggleAirplaneMode:`, StepToggleAirplaneMode},
+		{"clearNotifications", `- clearNotifications`, StepClearNotifications},
+		{"ensureUnlocked", `- ensureUnlocked`, StepEnsureUnlocked},
+		{"ensureUnlocked mapping", `- ensureUnlocked: {passcode: "1234"}`, StepEnsureUnlocked},
+		{"setAppearance scalar", `- setAppearance: dark`, StepSetAppearance},
+		{"setAppearance mapping", `- setAppearance: {mode: light, settleMs: 500}`, StepSetAppearance},
 		{"travel", `- travel: {points: ["0,0"], speed: 50}`, StepTravel},
 		{"openLink scalar", `- openLink: "https://example.com"`, StepOpenLink},
 		{"openLink mapping", `- openLink: {link: "https://example.com"}`, StepOpenLink},
@@ -153,6 +191,26 @@ func TestParse_AllStepTypes(t *testing.T) {
 		{"addMedia", `- addMedia: {files: ["img.png"]}`, StepAddMedia},
 		{"pressKey", `- pressKey: ENTER`, StepPressKey},
 		{"waitForAnimationToEnd", `- waitForAnimationToEnd: {}`, StepWaitForAnimationToEnd},
+		{"waitForDownload scalar", `- waitForDownload: "invoice-*.pdf"`, StepWaitForDownload},
+		{"waitForDownload mapping", `- waitForDownload: {file: "report.csv", directory: "/sdcard/Documents"}`, StepWaitForDownload},
+		{"assertFieldValue", `- assertFieldValue: {element: {id: "promo"}, equals: "SAVE20"}`, StepAssertFieldValue},
+		{"setPreference", `- setPreference: {key: onboarded, type: boolean, value: "true"}`, StepSetPreference},
+		{"assertShareTarget scalar", `- assertShareTarget: Gmail`, StepAssertShareTarget},
+		{"search scalar", `- search: coffee`, StepSearch},
+		{"assertAccessible", `- assertAccessible`, StepAssertAccessible},
+		{"assertAccessible with options", `- assertAccessible: {label: audit}`, StepAssertAccessible},
+		{"assertNoJank", `- assertNoJank: {commands: [scroll]}`, StepAssertNoJank},
+		{"assertThemeColor scalar", `- assertThemeColor: "#6200EE"`, StepAssertThemeColor},
+		{"assertScreenshot scalar", `- assertScreenshot: baselines/home.png`, StepAssertScreenshot},
+		{"assertTextNotContains scalar", `- assertTextNotContains: Error`, StepAssertTextNotContains},
+		{"setAppLocale scalar", `- setAppLocale: fr-FR`, StepSetAppLocale},
+		{"setAppLocale mapping", `- setAppLocale: {appId: com.example, locale: ja, relaunch: false}`, StepSetAppLocale},
+		{"gesturePath", `- gesturePath: {points: ["10%, 50%", "90%, 50%"], duration: 800}`, StepGesturePath},
+		{"twoFingerSwipe scalar", `- twoFingerSwipe: LEFT`, StepTwoFingerSwipe},
+		{"twoFingerSwipe mapping", `- twoFingerSwipe: {start: "50%, 60%", end: "50%, 40%"}`, StepTwoFingerSwipe},
+		{"waitForText", `- waitForText: {element: {id: "status"}, equals: "Done"}`, StepWaitForText},
+		{"waitForInstall scalar", `- waitForInstall: com.example.app`, StepWaitForInstall},
+		{"waitForInstall mapping", `- waitForInstall: {appId: com.example.app, timeout: 120000}`, StepWaitForInstall},
 		{"defineVariables", `- defineVariables: {VAR1: value1}`, StepDefineVariables},
 	}
 
@@ -223,6 +281,28 @@ func TestParse_RepeatWithWhile(t *testing.T) {
 	}
 }
 
+func TestParse_RepeatWithWhileTrue(t *testing.T) {
+	yaml := `
+- repeat:
+    while:
+      true: ${output.idx < 3}
+    commands:
+      - evalScript: ${output.idx += 1}
+`
+	flow, err := Parse([]byte(yaml), "test.yaml")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	repeat, ok := flow.Steps[0].(*RepeatStep)
+	if !ok {
+		t.Fatalf("expected RepeatStep, got %T", flow.Steps[0])
+	}
+	if repeat.While.Script != "${output.idx < 3}" {
+		t.Errorf("expected while.true as script condition, got %q", repeat.While.Script)
+	}
+}
+
 func TestParse_RetryStep(t *testing.T) {
 	yaml := `
 - retry:
@@ -362,6 +442,754 @@ func TestParse_RunFlowWithInlineSteps(t *testing.T) {
 	}
 }
 
+func TestParse_AssertResource(t *testing.T) {
+	yaml := `
+- assertResource:
+    appId: com.example.app
+    maxMemoryMb: 256.5
+    maxCpuPercent: 40
+`
+	flow, err := Parse([]byte(yaml), "test.yaml")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	s, ok := flow.Steps[0].(*AssertResourceStep)
+	if !ok {
+		t.Fatalf("expected AssertResourceStep, got %T", flow.Steps[0])
+	}
+	if s.AppID != "com.example.app" || s.MaxMemoryMB != 256.5 || s.MaxCPUPercent != 40 {
+		t.Errorf("unexpected fields: %+v", s)
+	}
+}
+
+func TestParse_TapOnPixelOffset(t *testing.T) {
+	yaml := `
+- tapOn:
+    id: "promo_banner"
+    offsetX: 310
+    offsetY: 12
+- tapOn: "Submit"
+`
+	flow, err := Parse([]byte(yaml), "test.yaml")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	step := flow.Steps[0].(*TapOnStep)
+	dx, dy, ok := step.PixelOffset()
+	if !ok || dx != 310 || dy != 12 {
+		t.Errorf("expected offset (310, 12), got (%d, %d, %v)", dx, dy, ok)
+	}
+
+	if _, _, ok := flow.Steps[1].(*TapOnStep).PixelOffset(); ok {
+		t.Error("expected no offset when offsetX/offsetY are unset")
+	}
+}
+
+func TestParse_TapOnTapParent(t *testing.T) {
+	yaml := `
+- tapOn:
+    text: "Invoice.pdf"
+    tapParent: true
+- tapOn: "Submit"
+`
+	flow, err := Parse([]byte(yaml), "test.yaml")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	step := flow.Steps[0].(*TapOnStep)
+	if !step.TapParent || step.Selector.Text != "Invoice.pdf" {
+		t.Errorf("expected tapParent on text=Invoice.pdf, got %+v", step)
+	}
+	if flow.Steps[1].(*TapOnStep).TapParent {
+		t.Error("expected tapParent to default to false")
+	}
+}
+
+func TestParse_AssertVisibleFullyVisible(t *testing.T) {
+	yaml := `
+- assertVisible:
+    id: "checkout_button"
+    fullyVisible: true
+- assertVisible: "Total"
+`
+	flow, err := Parse([]byte(yaml), "test.yaml")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	step := flow.Steps[0].(*AssertVisibleStep)
+	if !step.FullyVisible || step.Selector.ID != "checkout_button" {
+		t.Errorf("expected fullyVisible on id=checkout_button, got %+v", step)
+	}
+	if flow.Steps[1].(*AssertVisibleStep).FullyVisible {
+		t.Error("expected fullyVisible to default to false")
+	}
+}
+
+func TestParse_SetAppearance(t *testing.T) {
+	yaml := `
+- setAppearance: dark
+- setAppearance:
+    mode: light
+    settleMs: 750
+`
+	flow, err := Parse([]byte(yaml), "test.yaml")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	dark := flow.Steps[0].(*SetAppearanceStep)
+	if isDark, err := dark.Dark(); err != nil || !isDark {
+		t.Errorf("expected dark mode, got %v (err=%v)", isDark, err)
+	}
+
+	light := flow.Steps[1].(*SetAppearanceStep)
+	if isDark, err := light.Dark(); err != nil || isDark {
+		t.Errorf("expected light mode, got dark=%v (err=%v)", isDark, err)
+	}
+	if light.SettleMs != 750 {
+		t.Errorf("expected settleMs 750, got %d", light.SettleMs)
+	}
+
+	if _, err := (&SetAppearanceStep{Mode: "sepia"}).Dark(); err == nil {
+		t.Error("expected error for unknown appearance")
+	}
+}
+
+func TestParse_TapSequence(t *testing.T) {
+	yaml := `
+- tapSequence:
+    delay: 40
+    taps:
+      - point: "20%, 30%"
+      - id: "dot_5"
+      - text: "Dot 9"
+        point: "50%, 50%"
+- tapSequence: ["1", "2", "3"]
+`
+	flow, err := Parse([]byte(yaml), "test.yaml")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	step := flow.Steps[0].(*TapSequenceStep)
+	if step.Delay() != 40 || len(step.Taps) != 3 {
+		t.Fatalf("expected 3 taps with 40ms delay, got %d taps, %dms", len(step.Taps), step.Delay())
+	}
+	if step.Taps[0].Point != "20%, 30%" || step.Taps[1].ID != "dot_5" || step.Taps[2].Text != "Dot 9" {
+		t.Errorf("unexpected taps: %+v", step.Taps)
+	}
+
+	short := flow.Steps[1].(*TapSequenceStep)
+	if len(short.Taps) != 3 || short.Taps[2].Text != "3" {
+		t.Errorf("expected list shorthand to decode text taps, got %+v", short.Taps)
+	}
+	if short.Delay() != DefaultTapSequenceDelayMs {
+		t.Errorf("expected default delay, got %d", short.Delay())
+	}
+}
+
+func TestParse_AssertFileExists(t *testing.T) {
+	yaml := `
+- assertFileExists: "checkout.png"
+- assertFileExists:
+    path: "/sdcard/Pictures/share_*.png"
+    minSize: 10240
+    device: true
+`
+	flow, err := Parse([]byte(yaml), "test.yaml")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	local := flow.Steps[0].(*AssertFileExistsStep)
+	if local.Path != "checkout.png" || local.Device || local.MinSize != 0 {
+		t.Errorf("unexpected scalar form: %+v", local)
+	}
+
+	device := flow.Steps[1].(*AssertFileExistsStep)
+	if device.Path != "/sdcard/Pictures/share_*.png" || !device.Device || device.MinSize != 10240 {
+		t.Errorf("unexpected mapping form: %+v", device)
+	}
+	if got := device.Describe(); got != "assertFileExists (device): /sdcard/Pictures/share_*.png" {
+		t.Errorf("unexpected description: %s", got)
+	}
+}
+
+func TestParse_WaitForText(t *testing.T) {
+	yaml := `
+- waitForText:
+    element:
+      id: "progress"
+    matches: "\\d+% complete"
+    timeout: 30000
+`
+	flow, err := Parse([]byte(yaml), "test.yaml")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	step, ok := flow.Steps[0].(*WaitForTextStep)
+	if !ok {
+		t.Fatalf("expected WaitForTextStep, got %T", flow.Steps[0])
+	}
+	if step.Element.ID != "progress" || step.Matches != `\d+% complete` || step.TimeoutMs != 30000 {
+		t.Errorf("unexpected step: %+v", step)
+	}
+	if got := step.Describe(); got != `waitForText: id="progress"` {
+		t.Errorf("unexpected description: %s", got)
+	}
+}
+
+func TestParse_WaitForInstall(t *testing.T) {
+	yaml := `
+- waitForInstall: com.example.app
+- waitForInstall:
+    appId: com.example.other
+    timeout: 120000
+`
+	flow, err := Parse([]byte(yaml), "test.yaml")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	short, ok := flow.Steps[0].(*WaitForInstallStep)
+	if !ok {
+		t.Fatalf("expected WaitForInstallStep, got %T", flow.Steps[0])
+	}
+	if short.AppID != "com.example.app" || short.Timeout() != DefaultInstallTimeoutMs {
+		t.Errorf("unexpected step: %+v", short)
+	}
+	if got := short.Describe(); got != "waitForInstall: com.example.app" {
+		t.Errorf("unexpected description: %s", got)
+	}
+
+	long := flow.Steps[1].(*WaitForInstallStep)
+	if long.AppID != "com.example.other" || long.Timeout() != 120000 {
+		t.Errorf("unexpected step: %+v", long)
+	}
+}
+
+func TestParse_GesturePath(t *testing.T) {
+	yaml := `
+- gesturePath:
+    - "20%, 60%"
+    - "35%, 55%"
+    - "50%, 62%"
+- gesturePath:
+    points: ["10%, 10%", "90%, 90%"]
+    duration: 1500
+`
+	flow, err := Parse([]byte(yaml), "test.yaml")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	short := flow.Steps[0].(*GesturePathStep)
+	if len(short.Points) != 3 || short.Points[2] != "50%, 62%" || short.Duration() != DefaultGesturePathDurationMs {
+		t.Errorf("unexpected shorthand form: %+v", short)
+	}
+
+	full := flow.Steps[1].(*GesturePathStep)
+	if len(full.Points) != 2 || full.Duration() != 1500 {
+		t.Errorf("unexpected mapping form: %+v", full)
+	}
+	if got := full.Describe(); got != "gesturePath: 2 points over 1500ms" {
+		t.Errorf("unexpected description: %s", got)
+	}
+}
+
+func TestParse_TwoFingerSwipe(t *testing.T) {
+	yaml := `
+- twoFingerSwipe: LEFT
+- twoFingerSwipe:
+    start: "50%, 60%"
+    end: "50%, 40%"
+    duration: 800
+    spacing: 150
+`
+	flow, err := Parse([]byte(yaml), "test.yaml")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	short := flow.Steps[0].(*TwoFingerSwipeStep)
+	start, end, err := short.Endpoints()
+	if err != nil || start != "70%, 50%" || end != "30%, 50%" {
+		t.Errorf("unexpected endpoints %q -> %q (%v)", start, end, err)
+	}
+	if short.Duration() != DefaultTwoFingerSwipeDurationMs || short.FingerSpacing(1080) != 108 {
+		t.Errorf("unexpected defaults: %+v", short)
+	}
+	if got := short.Describe(); got != "twoFingerSwipe: LEFT" {
+		t.Errorf("unexpected description: %s", got)
+	}
+
+	full := flow.Steps[1].(*TwoFingerSwipeStep)
+	start, end, err = full.Endpoints()
+	if err != nil || start != "50%, 60%" || end != "50%, 40%" {
+		t.Errorf("unexpected endpoints %q -> %q (%v)", start, end, err)
+	}
+	if full.Duration() != 800 || full.FingerSpacing(1080) != 150 {
+		t.Errorf("unexpected mapping form: %+v", full)
+	}
+}
+
+func TestParse_SetAppLocale(t *testing.T) {
+	yaml := `
+- setAppLocale: "de-DE"
+- setAppLocale:
+    appId: com.example.app
+    locale: ar
+    relaunch: false
+`
+	flow, err := Parse([]byte(yaml), "test.yaml")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	short := flow.Steps[0].(*SetAppLocaleStep)
+	if short.Locale != "de-DE" || short.AppID != "" || !short.ShouldRelaunch() {
+		t.Errorf("unexpected scalar form: %+v", short)
+	}
+
+	full := flow.Steps[1].(*SetAppLocaleStep)
+	if full.AppID != "com.example.app" || full.Locale != "ar" || full.ShouldRelaunch() {
+		t.Errorf("unexpected mapping form: %+v", full)
+	}
+	if got := full.Describe(); got != "setAppLocale: ar" {
+		t.Errorf("unexpected description: %s", got)
+	}
+}
+
+func TestParse_StopAppGraceful(t *testing.T) {
+	yaml := `
+- stopApp: com.example.app
+- stopApp:
+    appId: com.example.app
+    graceful: true
+`
+	flow, err := Parse([]byte(yaml), "test.yaml")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	short := flow.Steps[0].(*StopAppStep)
+	if short.AppID != "com.example.app" || short.Graceful {
+		t.Errorf("unexpected scalar form: %+v", short)
+	}
+
+	full := flow.Steps[1].(*StopAppStep)
+	if full.AppID != "com.example.app" || !full.Graceful {
+		t.Errorf("unexpected mapping form: %+v", full)
+	}
+}
+
+func TestParse_AssertFieldValue(t *testing.T) {
+	yaml := `
+- assertFieldValue:
+    element:
+      id: "email"
+    contains: "${EMAIL_DOMAIN}"
+`
+	flow, err := Parse([]byte(yaml), "test.yaml")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	step, ok := flow.Steps[0].(*AssertFieldValueStep)
+	if !ok {
+		t.Fatalf("expected AssertFieldValueStep, got %T", flow.Steps[0])
+	}
+	if step.Element.ID != "email" || step.Contains != "${EMAIL_DOMAIN}" || step.Equals != "" {
+		t.Errorf("unexpected step: %+v", step)
+	}
+	if got := step.Describe(); got != `assertFieldValue: id="email"` {
+		t.Errorf("unexpected description: %s", got)
+	}
+}
+
+func TestParse_SetPreference(t *testing.T) {
+	yaml := `
+- setPreference:
+    appId: com.example.app
+    file: settings
+    key: onboarded
+    type: boolean
+    value: true
+`
+	flow, err := Parse([]byte(yaml), "test.yaml")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	step, ok := flow.Steps[0].(*SetPreferenceStep)
+	if !ok {
+		t.Fatalf("expected SetPreferenceStep, got %T", flow.Steps[0])
+	}
+	if step.AppID != "com.example.app" || step.File != "settings" || step.Key != "onboarded" || step.ValueType != "boolean" || step.Value != "true" {
+		t.Errorf("unexpected step: %+v", step)
+	}
+	if got := step.Describe(); got != "setPreference: onboarded = true" {
+		t.Errorf("unexpected description: %s", got)
+	}
+}
+
+func TestParse_InputTextTypeDelay(t *testing.T) {
+	yaml := `
+- inputText:
+    text: "+1 555 0100"
+    typeDelay: 80
+`
+	flow, err := Parse([]byte(yaml), "test.yaml")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	step := flow.Steps[0].(*InputTextStep)
+	if step.Text != "+1 555 0100" || step.TypeDelayMs != 80 {
+		t.Errorf("unexpected step: %+v", step)
+	}
+}
+
+func TestParse_AssertShareTarget(t *testing.T) {
+	yaml := `
+- assertShareTarget: Gmail
+- assertShareTarget:
+    app: ${BLOCKED_APP}
+    visible: false
+`
+	flow, err := Parse([]byte(yaml), "test.yaml")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	short := flow.Steps[0].(*AssertShareTargetStep)
+	if short.App != "Gmail" || !short.ExpectPresent() {
+		t.Errorf("unexpected scalar form: %+v", short)
+	}
+	if got := short.Describe(); got != `assertShareTarget: "Gmail"` {
+		t.Errorf("unexpected description: %s", got)
+	}
+
+	absent := flow.Steps[1].(*AssertShareTargetStep)
+	if absent.App != "${BLOCKED_APP}" || absent.ExpectPresent() {
+		t.Errorf("unexpected mapping form: %+v", absent)
+	}
+	if got := absent.Describe(); got != `assertShareTarget: not "${BLOCKED_APP}"` {
+		t.Errorf("unexpected description: %s", got)
+	}
+}
+
+func TestParse_LongPressOnPointAndDuration(t *testing.T) {
+	yaml := `
+- longPressOn: Delete
+- longPressOn:
+    id: avatar
+    point: "90%, 10%"
+    duration: 2500
+- longPressOn:
+    point: "50%, 50%"
+`
+	flow, err := Parse([]byte(yaml), "test.yaml")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	short := flow.Steps[0].(*LongPressOnStep)
+	if short.Selector.Text != "Delete" || short.Duration() != DefaultLongPressDurationMs {
+		t.Errorf("unexpected scalar form: %+v", short)
+	}
+
+	full := flow.Steps[1].(*LongPressOnStep)
+	if full.Selector.ID != "avatar" || full.Point != "90%, 10%" || full.Duration() != 2500 {
+		t.Errorf("unexpected mapping form: %+v", full)
+	}
+
+	screen := flow.Steps[2].(*LongPressOnStep)
+	if got := screen.Describe(); got != "longPressOn: point 50%, 50%" {
+		t.Errorf("unexpected description: %s", got)
+	}
+}
+
+func TestParse_Search(t *testing.T) {
+	yaml := `
+- search:
+    field:
+      id: search_src_text
+    query: ${TERM}
+    submit: false
+    waitFor:
+      text: Results
+`
+	flow, err := Parse([]byte(yaml), "test.yaml")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	step, ok := flow.Steps[0].(*SearchStep)
+	if !ok {
+		t.Fatalf("expected SearchStep, got %T", flow.Steps[0])
+	}
+	if step.Field.ID != "search_src_text" || step.Query != "${TERM}" {
+		t.Errorf("unexpected step: %+v", step)
+	}
+	if step.ShouldSubmit() {
+		t.Error("expected submit to be disabled")
+	}
+	if step.WaitFor == nil || step.WaitFor.Text != "Results" {
+		t.Errorf("expected waitFor selector, got %+v", step.WaitFor)
+	}
+	if got := step.Describe(); got != `search: "${TERM}"` {
+		t.Errorf("unexpected description: %s", got)
+	}
+
+	if !(&SearchStep{}).ShouldSubmit() {
+		t.Error("expected submit by default")
+	}
+}
+
+func TestParse_WaitForAnimationToEndOptions(t *testing.T) {
+	yaml := `
+- waitForAnimationToEnd
+- waitForAnimationToEnd:
+    timeout: 2000
+    continueOnTimeout: false
+`
+	flow, err := Parse([]byte(yaml), "test.yaml")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	bare, ok := flow.Steps[0].(*WaitForAnimationToEndStep)
+	if !ok {
+		t.Fatalf("expected WaitForAnimationToEndStep, got %T", flow.Steps[0])
+	}
+	if bare.Timeout() != DefaultAnimationTimeoutMs || !bare.ShouldContinueOnTimeout() {
+		t.Errorf("unexpected defaults: timeout=%d continue=%v", bare.Timeout(), bare.ShouldContinueOnTimeout())
+	}
+
+	step := flow.Steps[1].(*WaitForAnimationToEndStep)
+	if step.Timeout() != 2000 || step.ShouldContinueOnTimeout() {
+		t.Errorf("unexpected options: timeout=%d continue=%v", step.Timeout(), step.ShouldContinueOnTimeout())
+	}
+}
+
+func TestParse_AssertNoJank(t *testing.T) {
+	yaml := `
+- assertNoJank:
+    appId: com.example.app
+    maxJankyPercent: 2.5
+    label: Feed scroll
+    commands:
+      - scroll
+      - swipe:
+          direction: UP
+`
+	flow, err := Parse([]byte(yaml), "test.yaml")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	step, ok := flow.Steps[0].(*AssertNoJankStep)
+	if !ok {
+		t.Fatalf("expected AssertNoJankStep, got %T", flow.Steps[0])
+	}
+	if step.AppID != "com.example.app" || step.MaxJanky() != 2.5 || step.Label() != "Feed scroll" {
+		t.Errorf("unexpected step: %+v", step)
+	}
+	if len(step.Steps) != 2 || step.Steps[0].Type() != StepScroll || step.Steps[1].Type() != StepSwipe {
+		t.Fatalf("unexpected nested steps: %+v", step.Steps)
+	}
+	if got := step.Describe(); got != "assertNoJank: max 2.5% janky frames" {
+		t.Errorf("unexpected description: %s", got)
+	}
+	if got := (&AssertNoJankStep{}).MaxJanky(); got != DefaultMaxJankyPercent {
+		t.Errorf("expected default threshold, got %g", got)
+	}
+}
+
+func TestParse_AssertThemeColor(t *testing.T) {
+	yaml := `
+- assertThemeColor:
+    color: "#6200EE"
+    region: 8
+    tolerance: 24
+- assertThemeColor: "#018786"
+`
+	flow, err := Parse([]byte(yaml), "test.yaml")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	step, ok := flow.Steps[0].(*AssertThemeColorStep)
+	if !ok {
+		t.Fatalf("expected AssertThemeColorStep, got %T", flow.Steps[0])
+	}
+	if step.Color != "#6200EE" || step.RegionPercent() != 8 || step.ColorTolerance() != 24 {
+		t.Errorf("unexpected step: %+v", step)
+	}
+
+	step = flow.Steps[1].(*AssertThemeColorStep)
+	if step.Color != "#018786" || step.RegionPercent() != DefaultThemeRegionPercent || step.ColorTolerance() != DefaultThemeColorTolerance {
+		t.Errorf("unexpected defaults: %+v", step)
+	}
+	if got := step.Describe(); got != "assertThemeColor: #018786" {
+		t.Errorf("unexpected description: %s", got)
+	}
+}
+
+func TestParse_AssertScreenshot(t *testing.T) {
+	yaml := `
+- assertScreenshot:
+    baseline: baselines/home.png
+    threshold: 2.5
+    diffImage: true
+    updateBaseline: true
+- assertScreenshot: baselines/login.png
+- assertScreenshot:
+    baseline: baselines/exact.png
+    threshold: 0
+`
+	flow, err := Parse([]byte(yaml), "test.yaml")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	step, ok := flow.Steps[0].(*AssertScreenshotStep)
+	if !ok {
+		t.Fatalf("expected AssertScreenshotStep, got %T", flow.Steps[0])
+	}
+	if step.Baseline != "baselines/home.png" || step.ThresholdPercent() != 2.5 || !step.DiffImage || !step.UpdateBaseline {
+		t.Errorf("unexpected step: %+v", step)
+	}
+
+	step = flow.Steps[1].(*AssertScreenshotStep)
+	if step.Baseline != "baselines/login.png" || step.ThresholdPercent() != DefaultScreenshotThreshold || step.DiffImage || step.UpdateBaseline {
+		t.Errorf("unexpected defaults: %+v", step)
+	}
+	if got := step.Describe(); got != "assertScreenshot: baselines/login.png" {
+		t.Errorf("unexpected description: %s", got)
+	}
+
+	step = flow.Steps[2].(*AssertScreenshotStep)
+	if step.ThresholdPercent() != 0 {
+		t.Errorf("expected explicit zero threshold, got %v", step.ThresholdPercent())
+	}
+}
+
+func TestParse_AssertTextNotContains(t *testing.T) {
+	yaml := `
+- assertTextNotContains: "null"
+- assertTextNotContains: [Error, undefined]
+- assertTextNotContains:
+    text: [NaN]
+    regex: ['\$0\.00$']
+`
+	flow, err := Parse([]byte(yaml), "test.yaml")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		text, regex []string
+		desc        string
+	}{
+		{[]string{"null"}, nil, `assertTextNotContains: "null"`},
+		{[]string{"Error", "undefined"}, nil, `assertTextNotContains: "Error", "undefined"`},
+		{[]string{"NaN"}, []string{`\$0\.00$`}, `assertTextNotContains: "NaN", /\$0\.00$/`},
+	}
+	for i, tt := range tests {
+		step, ok := flow.Steps[i].(*AssertTextNotContainsStep)
+		if !ok {
+			t.Fatalf("step %d: expected AssertTextNotContainsStep, got %T", i, flow.Steps[i])
+		}
+		if strings.Join(step.Text, ",") != strings.Join(tt.text, ",") || strings.Join(step.Regex, ",") != strings.Join(tt.regex, ",") {
+			t.Errorf("step %d: unexpected terms text=%v regex=%v", i, step.Text, step.Regex)
+		}
+		if got := step.Describe(); got != tt.desc {
+			t.Errorf("step %d: Describe() = %q, want %q", i, got, tt.desc)
+		}
+	}
+}
+
+func TestParse_OpenLinkColdStart(t *testing.T) {
+	yaml := `
+- openLink:
+    link: "myapp://orders/42"
+    clearState: true
+    appId: com.example.app
+    waitFor: "Order #42"
+`
+	flow, err := Parse([]byte(yaml), "test.yaml")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	step, ok := flow.Steps[0].(*OpenLinkStep)
+	if !ok {
+		t.Fatalf("expected OpenLinkStep, got %T", flow.Steps[0])
+	}
+	if !step.ClearState {
+		t.Error("expected clearState=true")
+	}
+	if step.AppID != "com.example.app" {
+		t.Errorf("expected appId=com.example.app, got %q", step.AppID)
+	}
+	if step.WaitFor == nil || step.WaitFor.Text != "Order #42" {
+		t.Errorf("expected waitFor text=Order #42, got %+v", step.WaitFor)
+	}
+}
+
+func TestParse_IfStep(t *testing.T) {
+	yaml := `
+- if:
+    condition:
+      visible: "Welcome"
+    then:
+      - tapOn: "Continue"
+      - inputText: "user"
+    else:
+      - tapOn: "Sign in"
+    label: "skip onboarding"
+`
+	flow, err := Parse([]byte(yaml), "test.yaml")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ifStep, ok := flow.Steps[0].(*IfStep)
+	if !ok {
+		t.Fatalf("expected IfStep, got %T", flow.Steps[0])
+	}
+	if ifStep.Condition.Visible == nil || ifStep.Condition.Visible.Text != "Welcome" {
+		t.Errorf("expected condition.visible=Welcome, got %+v", ifStep.Condition.Visible)
+	}
+	if len(ifStep.Then) != 2 {
+		t.Errorf("expected 2 then steps, got %d", len(ifStep.Then))
+	}
+	if len(ifStep.Else) != 1 {
+		t.Errorf("expected 1 else step, got %d", len(ifStep.Else))
+	}
+	if ifStep.StepLabel != "skip onboarding" {
+		t.Errorf("expected label=skip onboarding, got %q", ifStep.StepLabel)
+	}
+}
+
+func TestParse_IfStepWithoutCondition(t *testing.T) {
+	yaml := `
+- if:
+    then:
+      - tapOn: "Continue"
+`
+	if _, err := Parse([]byte(yaml), "test.yaml"); err == nil {
+		t.Error("expected error for if without condition")
+	}
+}
+
 func TestParse_NestedRepeat(t *testing.T) {
 	yaml := `
 - repeat:
@@ -645,6 +1473,119 @@ func TestParseDirectory_WithSubdirs(t *testing.T) {
 	}
 }
 
+func TestParse_StepRetry(t *testing.T) {
+	yaml := `
+- assertVisible:
+    text: "Synced"
+    retry: 3
+    retryDelayMs: 500
+`
+	flow, err := Parse([]byte(yaml), "test.yaml")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	retries, delayMs := flow.Steps[0].RetryPolicy()
+	if retries != 3 || delayMs != 500 {
+		t.Errorf("RetryPolicy() = %d, %d, want 3, 500", retries, delayMs)
+	}
+}
+
+func TestParse_AssertVisibleCount(t *testing.T) {
+	yaml := `
+- assertVisible:
+    id: "row"
+    count: 5
+    countComparator: ">="
+`
+	flow, err := Parse([]byte(yaml), "test.yaml")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	step := flow.Steps[0].(*AssertVisibleStep)
+	if step.Count == nil || *step.Count != 5 {
+		t.Errorf("Count = %v, want 5", step.Count)
+	}
+	if step.CountComparator != CountAtLeast {
+		t.Errorf("CountComparator = %q, want %q", step.CountComparator, CountAtLeast)
+	}
+	if got, want := step.Describe(), `assertVisible: id="row" (count >= 5)`; got != want {
+		t.Errorf("Describe() = %q, want %q", got, want)
+	}
+}
+
+func TestParse_BackWithTapBackButton(t *testing.T) {
+	yaml := `
+- back
+- back:
+    tapBackButton: true
+    optional: true
+`
+	flow, err := Parse([]byte(yaml), "test.yaml")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if step := flow.Steps[0].(*BackStep); step.TapBackButton {
+		t.Error("bare back should not set TapBackButton")
+	}
+	step := flow.Steps[1].(*BackStep)
+	if !step.TapBackButton || !step.IsOptional() {
+		t.Errorf("expected tapBackButton and optional, got %+v", step)
+	}
+	if step.Type() != StepBack {
+		t.Errorf("Type() = %q, want %q", step.Type(), StepBack)
+	}
+}
+
+func TestParse_ExtendedWaitUntilSelectorLists(t *testing.T) {
+	yaml := `
+- extendedWaitUntil:
+    visible:
+      - "Welcome"
+      - id: profile_avatar
+    notVisible:
+      text: "Loading"
+    timeout: 5000
+`
+	flow, err := Parse([]byte(yaml), "test.yaml")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	step, ok := flow.Steps[0].(*ExtendedWaitUntilStep)
+	if !ok {
+		t.Fatalf("expected *ExtendedWaitUntilStep, got %T", flow.Steps[0])
+	}
+	if step.Type() != StepWaitUntil {
+		t.Errorf("Type() = %q, want %q", step.Type(), StepWaitUntil)
+	}
+	if len(step.Visible) != 2 || step.Visible[0].Text != "Welcome" || step.Visible[1].ID != "profile_avatar" {
+		t.Errorf("unexpected visible selectors: %+v", step.Visible)
+	}
+	if len(step.NotVisible) != 1 || step.NotVisible[0].Text != "Loading" {
+		t.Errorf("unexpected notVisible selectors: %+v", step.NotVisible)
+	}
+	if step.TimeoutMs != 5000 {
+		t.Errorf("TimeoutMs = %d, want 5000", step.TimeoutMs)
+	}
+}
+
+func TestParse_ExtendedWaitUntilSingleSelector(t *testing.T) {
+	yaml := `
+- extendedWaitUntil:
+    visible: "Welcome"
+`
+	flow, err := Parse([]byte(yaml), "test.yaml")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := flow.Steps[0].(*WaitUntilStep); !ok {
+		t.Errorf("expected *WaitUntilStep for a single selector, got %T", flow.Steps[0])
+	}
+}
+
 func TestParse_TapOnWithAllFields(t *testing.T) {
 	yaml := `
 - tapOn:
@@ -659,6 +1600,7 @@ func TestParse_TapOnWithAllFields(t *testing.T) {
     delay: 100
     point: "50%, 50%"
     retryTapIfNoChange: true
+    maxTaps: 3
     waitUntilVisible: true
     waitToSettleTimeoutMs: 500
     optional: true
@@ -705,6 +1647,9 @@ func TestParse_TapOnWithAllFields(t *testing.T) {
 	if tap.RetryTapIfNoChange == nil || !*tap.RetryTapIfNoChange {
 		t.Error("expected RetryTapIfNoChange=true")
 	}
+	if tap.MaxTaps != 3 {
+		t.Errorf("MaxTaps=%d, want 3", tap.MaxTaps)
+	}
 	if tap.WaitUntilVisible == nil || !*tap.WaitUntilVisible {
 		t.Error("expected WaitUntilVisible=true")
 	}
@@ -772,6 +1717,83 @@ func TestParse_SwipeWithAllFields(t *testing.T) {
 	}
 }
 
+func TestParse_InputTextVerifyText(t *testing.T) {
+	yaml := `
+- inputText:
+    text: "user@example.com"
+    id: email
+    verifyText: true
+`
+	flow, err := Parse([]byte(yaml), "test.yaml")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	input, ok := flow.Steps[0].(*InputTextStep)
+	if !ok {
+		t.Fatalf("expected InputTextStep, got %T", flow.Steps[0])
+	}
+	if !input.VerifyText {
+		t.Error("VerifyText=false, want true")
+	}
+	if input.Text != "user@example.com" {
+		t.Errorf("Text=%q, want user@example.com", input.Text)
+	}
+	if input.Selector.ID != "email" {
+		t.Errorf("Selector.ID=%q, want email", input.Selector.ID)
+	}
+}
+
+func TestParse_ScrollWithAmount(t *testing.T) {
+	yaml := `
+- scroll:
+    direction: UP
+    amount: "30%"
+`
+	flow, err := Parse([]byte(yaml), "test.yaml")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	scroll, ok := flow.Steps[0].(*ScrollStep)
+	if !ok {
+		t.Fatalf("expected ScrollStep, got %T", flow.Steps[0])
+	}
+	if scroll.Direction != "UP" {
+		t.Errorf("Direction=%q, want UP", scroll.Direction)
+	}
+	if scroll.Amount != "30%" {
+		t.Errorf("Amount=%q, want 30%%", scroll.Amount)
+	}
+}
+
+func TestParse_ScrollToPositionWithAllFields(t *testing.T) {
+	yaml := `
+- scrollToPosition:
+    position: "75%"
+    direction: RIGHT
+    maxScrolls: 30
+`
+	flow, err := Parse([]byte(yaml), "test.yaml")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	scroll, ok := flow.Steps[0].(*ScrollToPositionStep)
+	if !ok {
+		t.Fatalf("expected ScrollToPositionStep, got %T", flow.Steps[0])
+	}
+	if scroll.Position != "75%" {
+		t.Errorf("Position=%q, want 75%%", scroll.Position)
+	}
+	if scroll.Direction != "RIGHT" {
+		t.Errorf("Direction=%q, want RIGHT", scroll.Direction)
+	}
+	if scroll.MaxScrolls != 30 {
+		t.Errorf("MaxScrolls=%d, want 30", scroll.MaxScrolls)
+	}
+}
+
 func TestParse_ScrollUntilVisibleWithAllFields(t *testing.T) {
 	yaml := `
 - scrollUntilVisible:
@@ -807,8 +1829,8 @@ func TestParse_ScrollUntilVisibleWithAllFields(t *testing.T) {
 	if scroll.MaxScrolls != 20 {
 		t.Errorf("MaxScrolls=%d, want 20", scroll.MaxScrolls)
 	}
-	if scroll.Speed != 40 {
-		t.Errorf("Speed=%d, want 40", scroll.Speed)
+	if scroll.Speed != "40" {
+		t.Errorf("Speed=%q, want 40", scroll.Speed)
 	}
 	if scroll.VisibilityPercentage != 80 {
 		t.Errorf("VisibilityPercentage=%d, want 80", scroll.VisibilityPercentage)
@@ -884,18 +1906,19 @@ func TestParse_AssertConditionStep(t *testing.T) {
 
 func TestIsStepType(t *testing.T) {
 	validTypes := []string{
-		"tapOn", "doubleTapOn", "longPressOn", "tapOnPoint", "swipe", "scroll",
-		"scrollUntilVisible", "back", "hideKeyboard", "acceptAlert", "dismissAlert",
+		"tapOn", "doubleTapOn", "longPressOn", "tapOnPoint", "tapSequence", "swipe", "scroll",
+		"scrollUntilVisible", "scrollToPosition", "back", "hideKeyboard", "acceptAlert", "dismissAlert",
+		"assertAlertText",
 		"inputText", "inputRandom", "inputRandomEmail", "inputRandomNumber",
 		"inputRandomPersonName", "inputRandomText",
 		"eraseText", "copyTextFrom", "pasteText", "setClipboard", "assertVisible",
 		"assertNotVisible", "assertTrue", "assertCondition", "assertNoDefectsWithAI",
-		"assertWithAI", "extractTextWithAI", "extendedWaitUntil", "launchApp",
+		"assertWithAI", "extractTextWithAI", "extendedWaitUntil", "assertResource", "assertSorted", "assertFileExists", "launchApp",
 		"stopApp", "killApp", "clearState", "clearKeychain", "setPermissions",
 		"setLocation", "setOrientation", "setAirplaneMode", "toggleAirplaneMode",
-		"travel", "openLink", "openBrowser", "repeat", "retry", "runFlow",
+		"travel", "openLink", "openBrowser", "clearNotifications", "ensureUnlocked", "setAppearance", "repeat", "if", "retry", "runFlow",
 		"runScript", "evalScript", "takeScreenshot", "startRecording", "stopRecording",
-		"addMedia", "pressKey", "waitForAnimationToEnd", "defineVariables",
+		"addMedia", "pressKey", "waitForAnimationToEnd", "waitForDownload", "defineVariables",
 	}
 
 	for _, st := range validTypes {
@@ -1,15 +1,24 @@
 // Package flow handles parsing and representation of Maestro YAML flow files.
 package flow
 
-import "gopkg.in/yaml.v3"
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
 
 // Selector represents element selection criteria.
 // This mirrors Maestro's YamlElementSelector exactly.
 // Pure data structure - executor decides how to use it.
 type Selector struct {
 	// Primary selectors
-	Text string `yaml:"text"` // Text to match
-	ID   string `yaml:"id"`   // Resource ID or accessibility ID
+	Text      string `yaml:"text"`      // Text to match
+	TextRegex string `yaml:"textRegex"` // Regular expression the whole text must match
+	ID        string `yaml:"id"`        // Resource ID or accessibility ID
 
 	// Size matching
 	Width     int `yaml:"width"`
@@ -22,9 +31,14 @@ type Selector struct {
 	Checked  *bool `yaml:"checked"`
 	Focused  *bool `yaml:"focused"`
 
-	// Index for multiple matches (string for variable support)
+	// Index picks one of several matches in on-screen order, top-to-bottom then
+	// left-to-right; negative counts from the end (string for variable support)
 	Index string `yaml:"index"`
 
+	// Structural filters (position among the parent's children in the source tree)
+	ChildIndex   *int `yaml:"childIndex"`   // 0-based index within the parent
+	SiblingCount *int `yaml:"siblingCount"` // Number of other children of the parent
+
 	// Traits (comma-separated string, e.g., "button,heading")
 	Traits string `yaml:"traits"`
 
@@ -57,6 +71,7 @@ type Selector struct {
 // selectorRaw is used for YAML parsing to capture the "element" field.
 type selectorRaw struct {
 	Text                  string      `yaml:"text"`
+	TextRegex             string      `yaml:"textRegex"`
 	Element               string      `yaml:"element"` // Shorthand for text (used in scrollUntilVisible, etc.)
 	ID                    string      `yaml:"id"`
 	Width                 int         `yaml:"width"`
@@ -67,6 +82,8 @@ type selectorRaw struct {
 	Checked               *bool       `yaml:"checked"`
 	Focused               *bool       `yaml:"focused"`
 	Index                 string      `yaml:"index"`
+	ChildIndex            *int        `yaml:"childIndex"`
+	SiblingCount          *int        `yaml:"siblingCount"`
 	Traits                string      `yaml:"traits"`
 	CSS                   string      `yaml:"css"`
 	ChildOf               *Selector   `yaml:"childOf"`
@@ -103,6 +120,7 @@ func (s *Selector) UnmarshalYAML(node *yaml.Node) error {
 
 	// Copy fields
 	s.Text = raw.Text
+	s.TextRegex = raw.TextRegex
 	s.ID = raw.ID
 	s.Width = raw.Width
 	s.Height = raw.Height
@@ -112,6 +130,8 @@ func (s *Selector) UnmarshalYAML(node *yaml.Node) error {
 	s.Checked = raw.Checked
 	s.Focused = raw.Focused
 	s.Index = raw.Index
+	s.ChildIndex = raw.ChildIndex
+	s.SiblingCount = raw.SiblingCount
 	s.Traits = raw.Traits
 	s.CSS = raw.CSS
 	s.ChildOf = raw.ChildOf
@@ -144,10 +164,13 @@ func (s *Selector) UnmarshalYAML(node *yaml.Node) error {
 // IsEmpty returns true if no selector properties are set.
 func (s *Selector) IsEmpty() bool {
 	return s.Text == "" &&
+		s.TextRegex == "" &&
 		s.ID == "" &&
 		s.CSS == "" &&
 		s.Width == 0 &&
 		s.Height == 0 &&
+		s.ChildIndex == nil &&
+		s.SiblingCount == nil &&
 		s.ChildOf == nil &&
 		s.Below == nil &&
 		s.Above == nil &&
@@ -170,11 +193,112 @@ func (s *Selector) HasRelativeSelector() bool {
 		s.InsideOf != nil
 }
 
+// HasStateFilter returns true if any of enabled, selected, checked or focused is set.
+func (s *Selector) HasStateFilter() bool {
+	return s.Enabled != nil || s.Selected != nil || s.Checked != nil || s.Focused != nil
+}
+
+// WithoutStateFilter returns a copy of the selector with enabled, selected,
+// checked and focused cleared, for finding an element regardless of its state.
+func (s *Selector) WithoutStateFilter() Selector {
+	c := *s
+	c.Enabled, c.Selected, c.Checked, c.Focused = nil, nil, nil, nil
+	return c
+}
+
+// HasStructuralSelector returns true if childIndex or siblingCount is set.
+// These need the source tree, so drivers resolve them via page source.
+func (s *Selector) HasStructuralSelector() bool {
+	return s.ChildIndex != nil || s.SiblingCount != nil
+}
+
+// RequiresPageSource returns true if the selector can only be resolved
+// against the parsed source tree (structural filters, textRegex or index),
+// since native driver queries can't express them.
+func (s *Selector) RequiresPageSource() bool {
+	return s.HasStructuralSelector() || s.TextRegex != "" || s.Index != ""
+}
+
+// IndexValue parses Index. Callers check Index != "" first.
+func (s *Selector) IndexValue() (int, error) {
+	i, err := strconv.Atoi(strings.TrimSpace(s.Index))
+	if err != nil {
+		return 0, fmt.Errorf("invalid index %q: must be an integer", s.Index)
+	}
+	return i, nil
+}
+
+// compiledTextRegex caches compiled textRegex patterns; matchers run once per
+// element on every page source poll.
+var compiledTextRegex sync.Map
+
+// TextPattern compiles TextRegex, anchored so the whole text must match.
+// Returns nil when TextRegex is empty.
+func (s *Selector) TextPattern() (*regexp.Regexp, error) {
+	if s.TextRegex == "" {
+		return nil, nil
+	}
+	if re, ok := compiledTextRegex.Load(s.TextRegex); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile("^(?:" + s.TextRegex + ")$")
+	if err != nil {
+		return nil, fmt.Errorf("invalid textRegex %q: %w", s.TextRegex, err)
+	}
+	compiledTextRegex.Store(s.TextRegex, re)
+	return re, nil
+}
+
+// MatchesTextRegex reports whether any of the texts fully matches TextRegex.
+// Newlines are also tried as spaces, like literal text matching.
+// An invalid pattern matches nothing; drivers call Validate up front.
+func (s *Selector) MatchesTextRegex(texts ...string) bool {
+	re, err := s.TextPattern()
+	if err != nil || re == nil {
+		return false
+	}
+	for _, text := range texts {
+		if text == "" {
+			continue
+		}
+		if re.MatchString(text) || re.MatchString(strings.ReplaceAll(text, "\n", " ")) {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks the selector and its relative anchors for values that can
+// never match, such as an invalid textRegex or a non-numeric index.
+func (s *Selector) Validate() error {
+	if _, err := s.TextPattern(); err != nil {
+		return err
+	}
+	if s.Index != "" {
+		if _, err := s.IndexValue(); err != nil {
+			return err
+		}
+	}
+	anchors := []*Selector{s.ChildOf, s.Below, s.Above, s.LeftOf, s.RightOf, s.ContainsChild, s.InsideOf}
+	anchors = append(anchors, s.ContainsDescendants...)
+	for _, anchor := range anchors {
+		if anchor == nil {
+			continue
+		}
+		if err := anchor.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Describe returns a human-readable description.
 func (s *Selector) Describe() string {
 	switch {
 	case s.Text != "":
 		return s.Text
+	case s.TextRegex != "":
+		return "regex:" + s.TextRegex
 	case s.ID != "":
 		return "#" + s.ID
 	case s.CSS != "":
@@ -1,6 +1,7 @@
 package flow
 
 import (
+	"strings"
 	"testing"
 
 	"gopkg.in/yaml.v3"
@@ -489,6 +490,159 @@ func TestSelector_HasRelativeSelector(t *testing.T) {
 	}
 }
 
+func TestSelector_HasStructuralSelector(t *testing.T) {
+	one := 1
+	tests := []struct {
+		name     string
+		selector Selector
+		expected bool
+	}{
+		{"none", Selector{Text: "Item"}, false},
+		{"childIndex set", Selector{ChildIndex: &one}, true},
+		{"siblingCount set", Selector{SiblingCount: &one}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.selector.HasStructuralSelector(); got != tt.expected {
+				t.Errorf("HasStructuralSelector()=%v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSelector_HasStateFilter(t *testing.T) {
+	on := true
+	tests := []struct {
+		name     string
+		selector Selector
+		expected bool
+	}{
+		{"none", Selector{Text: "Item"}, false},
+		{"enabled set", Selector{Enabled: &on}, true},
+		{"selected set", Selector{Selected: &on}, true},
+		{"checked set", Selector{Checked: &on}, true},
+		{"focused set", Selector{Focused: &on}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.selector.HasStateFilter(); got != tt.expected {
+				t.Errorf("HasStateFilter()=%v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSelector_WithoutStateFilter(t *testing.T) {
+	on, off := true, false
+	sel := Selector{Text: "Submit", Enabled: &on, Checked: &off, Selected: &on, Focused: &on}
+
+	got := sel.WithoutStateFilter()
+	if got.HasStateFilter() {
+		t.Errorf("expected state filters cleared, got %+v", got)
+	}
+	if got.Text != "Submit" {
+		t.Errorf("Text=%q, want Submit", got.Text)
+	}
+	if !sel.HasStateFilter() {
+		t.Error("original selector should keep its state filters")
+	}
+}
+
+func TestSelector_UnmarshalYAML_Structural(t *testing.T) {
+	var sel Selector
+	if err := yaml.Unmarshal([]byte("text: Item\nchildIndex: 0\nsiblingCount: 2\n"), &sel); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sel.ChildIndex == nil || *sel.ChildIndex != 0 {
+		t.Errorf("ChildIndex=%v, want 0", sel.ChildIndex)
+	}
+	if sel.SiblingCount == nil || *sel.SiblingCount != 2 {
+		t.Errorf("SiblingCount=%v, want 2", sel.SiblingCount)
+	}
+	if sel.IsEmpty() {
+		t.Error("expected structural selector to be non-empty")
+	}
+}
+
+func TestSelector_UnmarshalYAML_TextRegex(t *testing.T) {
+	var sel Selector
+	if err := yaml.Unmarshal([]byte("textRegex: 'Total: \\$[0-9]+\\.[0-9]{2}'\n"), &sel); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sel.TextRegex != `Total: \$[0-9]+\.[0-9]{2}` {
+		t.Errorf("TextRegex=%q", sel.TextRegex)
+	}
+	if sel.IsEmpty() {
+		t.Error("expected textRegex selector to be non-empty")
+	}
+	if !sel.RequiresPageSource() {
+		t.Error("expected textRegex selector to require page source")
+	}
+	if got := sel.Describe(); got != `regex:Total: \$[0-9]+\.[0-9]{2}` {
+		t.Errorf("Describe()=%q", got)
+	}
+}
+
+func TestSelector_MatchesTextRegex(t *testing.T) {
+	sel := Selector{TextRegex: `Total: \$[0-9]+\.[0-9]{2}`}
+	tests := []struct {
+		name  string
+		texts []string
+		want  bool
+	}{
+		{"whole text matches", []string{"Total: $12.50"}, true},
+		{"matches second text", []string{"", "Total: $0.99"}, true},
+		{"partial match rejected", []string{"Grand Total: $12.50"}, false},
+		{"wrong format", []string{"Total: $12.5"}, false},
+		{"newline treated as space", []string{"Total:\n$3.00"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sel.MatchesTextRegex(tt.texts...); got != tt.want {
+				t.Errorf("MatchesTextRegex(%q)=%v, want %v", tt.texts, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelector_Validate(t *testing.T) {
+	if err := (&Selector{TextRegex: "Item [0-9]+"}).Validate(); err != nil {
+		t.Errorf("unexpected error for valid pattern: %v", err)
+	}
+
+	bad := Selector{Text: "Save", Below: &Selector{TextRegex: "Total: ($"}}
+	err := bad.Validate()
+	if err == nil {
+		t.Fatal("expected error for invalid textRegex in anchor")
+	}
+	if !strings.Contains(err.Error(), `invalid textRegex "Total: ($"`) {
+		t.Errorf("error=%q, want it to name the pattern", err.Error())
+	}
+	if bad.Below.MatchesTextRegex("Total: (") {
+		t.Error("invalid pattern should match nothing")
+	}
+}
+
+func TestSelector_IndexValue(t *testing.T) {
+	sel := Selector{Text: "Delete", Index: "2"}
+	if i, err := sel.IndexValue(); err != nil || i != 2 {
+		t.Errorf("IndexValue()=%d, %v; want 2", i, err)
+	}
+	if !sel.RequiresPageSource() {
+		t.Error("expected indexed selector to require page source")
+	}
+	if err := sel.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	sel.Index = "third"
+	if err := sel.Validate(); err == nil || !strings.Contains(err.Error(), `invalid index "third"`) {
+		t.Errorf("expected invalid index error, got %v", err)
+	}
+}
+
 func TestSelector_Describe(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -1,6 +1,15 @@
 // Package flow handles parsing and representation of Maestro YAML flow files.
 package flow
 
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
 // StepType represents the type of step.
 type StepType string
 
@@ -11,13 +20,18 @@ const (
 	StepDoubleTapOn        StepType = "doubleTapOn"
 	StepLongPressOn        StepType = "longPressOn"
 	StepTapOnPoint         StepType = "tapOnPoint"
+	StepTapSequence        StepType = "tapSequence"
 	StepSwipe              StepType = "swipe"
+	StepGesturePath        StepType = "gesturePath"
+	StepTwoFingerSwipe     StepType = "twoFingerSwipe"
 	StepScroll             StepType = "scroll"
 	StepScrollUntilVisible StepType = "scrollUntilVisible"
+	StepScrollToPosition   StepType = "scrollToPosition"
 	StepBack               StepType = "back"
 	StepHideKeyboard       StepType = "hideKeyboard"
 	StepAcceptAlert        StepType = "acceptAlert"
 	StepDismissAlert       StepType = "dismissAlert"
+	StepAssertAlertText    StepType = "assertAlertText"
 
 	// Text
 	StepInputText             StepType = "inputText"
@@ -30,6 +44,7 @@ const (
 	StepCopyTextFrom          StepType = "copyTextFrom"
 	StepPasteText             StepType = "pasteText"
 	StepSetClipboard          StepType = "setClipboard"
+	StepSearch                StepType = "search"
 
 	// Assertions
 	StepAssertVisible         StepType = "assertVisible"
@@ -40,6 +55,16 @@ const (
 	StepAssertWithAI          StepType = "assertWithAI"
 	StepExtractTextWithAI     StepType = "extractTextWithAI"
 	StepWaitUntil             StepType = "extendedWaitUntil"
+	StepAssertResource        StepType = "assertResource"
+	StepAssertSorted          StepType = "assertSorted"
+	StepAssertFileExists      StepType = "assertFileExists"
+	StepAssertFieldValue      StepType = "assertFieldValue"
+	StepAssertShareTarget     StepType = "assertShareTarget"
+	StepAssertAccessible      StepType = "assertAccessible"
+	StepAssertNoJank          StepType = "assertNoJank"
+	StepAssertThemeColor      StepType = "assertThemeColor"
+	StepAssertScreenshot      StepType = "assertScreenshot"
+	StepAssertTextNotContains StepType = "assertTextNotContains"
 
 	// App Management
 	StepLaunchApp      StepType = "launchApp"
@@ -48,6 +73,8 @@ const (
 	StepClearState     StepType = "clearState"
 	StepClearKeychain  StepType = "clearKeychain"
 	StepSetPermissions StepType = "setPermissions"
+	StepSetAppLocale   StepType = "setAppLocale"
+	StepSetPreference  StepType = "setPreference"
 
 	// Device Control
 	StepSetLocation        StepType = "setLocation"
@@ -57,9 +84,13 @@ const (
 	StepTravel             StepType = "travel"
 	StepOpenLink           StepType = "openLink"
 	StepOpenBrowser        StepType = "openBrowser"
+	StepClearNotifications StepType = "clearNotifications"
+	StepEnsureUnlocked     StepType = "ensureUnlocked"
+	StepSetAppearance      StepType = "setAppearance"
 
 	// Flow Control
 	StepRepeat     StepType = "repeat"
+	StepIf         StepType = "if"
 	StepRetry      StepType = "retry"
 	StepRunFlow    StepType = "runFlow"
 	StepRunScript  StepType = "runScript"
@@ -74,6 +105,9 @@ const (
 	// Other
 	StepPressKey              StepType = "pressKey"
 	StepWaitForAnimationToEnd StepType = "waitForAnimationToEnd"
+	StepWaitForDownload       StepType = "waitForDownload"
+	StepWaitForText           StepType = "waitForText"
+	StepWaitForInstall        StepType = "waitForInstall"
 	StepDefineVariables       StepType = "defineVariables"
 )
 
@@ -83,14 +117,17 @@ type Step interface {
 	IsOptional() bool
 	Label() string
 	Describe() string
+	RetryPolicy() (retries, delayMs int)
 }
 
 // BaseStep contains common fields for all steps.
 type BaseStep struct {
-	StepType  StepType `yaml:"-"`
-	Optional  bool     `yaml:"optional"`
-	StepLabel string   `yaml:"label"`
-	TimeoutMs int      `yaml:"timeout"`
+	StepType     StepType `yaml:"-"`
+	Optional     bool     `yaml:"optional"`
+	StepLabel    string   `yaml:"label"`
+	TimeoutMs    int      `yaml:"timeout"`
+	Retry        int      `yaml:"retry"`        // Re-runs after a failure
+	RetryDelayMs int      `yaml:"retryDelayMs"` // Pause before each re-run
 }
 
 // Type returns the step type.
@@ -105,6 +142,12 @@ func (b *BaseStep) Label() string { return b.StepLabel }
 // Describe returns a human-readable description.
 func (b *BaseStep) Describe() string { return string(b.StepType) }
 
+// RetryPolicy returns how many times a failed step is re-run and the pause
+// before each re-run. Negative values count as zero.
+func (b *BaseStep) RetryPolicy() (retries, delayMs int) {
+	return max(b.Retry, 0), max(b.RetryDelayMs, 0)
+}
+
 // ============================================
 // Navigation & Interaction Steps
 // ============================================
@@ -117,9 +160,45 @@ type TapOnStep struct {
 	Repeat                int      `yaml:"repeat"`
 	DelayMs               int      `yaml:"delay"`
 	Point                 string   `yaml:"point"`
+	OffsetX               *int     `yaml:"offsetX"` // pixels from the element's left edge
+	OffsetY               *int     `yaml:"offsetY"` // pixels from the element's top edge
 	RetryTapIfNoChange    *bool    `yaml:"retryTapIfNoChange"`
+	MaxTaps               int      `yaml:"maxTaps"` // tap limit with retryTapIfNoChange (default 2)
 	WaitUntilVisible      *bool    `yaml:"waitUntilVisible"`
 	WaitToSettleTimeoutMs int      `yaml:"waitToSettleTimeoutMs"`
+	WebView               bool     `yaml:"webView"`   // match text in the WebView DOM (Android)
+	TapParent             bool     `yaml:"tapParent"` // tap the nearest clickable ancestor of a non-clickable match
+}
+
+// DefaultMaxTaps is how many taps retryTapIfNoChange issues at most when the
+// step sets no maxTaps.
+const DefaultMaxTaps = 2
+
+// TapLimit returns how many taps to issue at most: 1 unless retryTapIfNoChange
+// is set, in which case MaxTaps (default DefaultMaxTaps).
+func (s *TapOnStep) TapLimit() int {
+	if s.RetryTapIfNoChange == nil || !*s.RetryTapIfNoChange {
+		return 1
+	}
+	if s.MaxTaps > 0 {
+		return s.MaxTaps
+	}
+	return DefaultMaxTaps
+}
+
+// PixelOffset returns the tap offset from the element's top-left corner.
+// ok is false when neither OffsetX nor OffsetY is set; an unset axis is 0.
+func (s *TapOnStep) PixelOffset() (dx, dy int, ok bool) {
+	if s.OffsetX == nil && s.OffsetY == nil {
+		return 0, 0, false
+	}
+	if s.OffsetX != nil {
+		dx = *s.OffsetX
+	}
+	if s.OffsetY != nil {
+		dy = *s.OffsetY
+	}
+	return dx, dy, true
 }
 
 // DoubleTapOnStep double taps on an element (alias for tapOn with repeat=2).
@@ -131,15 +210,31 @@ type DoubleTapOnStep struct {
 	WaitToSettleTimeoutMs int      `yaml:"waitToSettleTimeoutMs"`
 }
 
+// DefaultLongPressDurationMs is how long longPressOn holds when the step sets
+// no duration.
+const DefaultLongPressDurationMs = 1000
+
 // LongPressOnStep long presses on an element (alias for tapOn with longPress=true).
+// Point ("x%, y%") presses at a screen position, or at a position within the
+// element when a selector is also given.
 type LongPressOnStep struct {
 	BaseStep              `yaml:",inline"`
 	Selector              Selector `yaml:",inline"`
+	Point                 string   `yaml:"point"`
+	DurationMs            int      `yaml:"duration"`
 	RetryTapIfNoChange    *bool    `yaml:"retryTapIfNoChange"`
 	WaitUntilVisible      *bool    `yaml:"waitUntilVisible"`
 	WaitToSettleTimeoutMs int      `yaml:"waitToSettleTimeoutMs"`
 }
 
+// Duration returns the hold time in milliseconds.
+func (s *LongPressOnStep) Duration() int {
+	if s.DurationMs > 0 {
+		return s.DurationMs
+	}
+	return DefaultLongPressDurationMs
+}
+
 // TapOnPointStep taps on specific coordinates.
 type TapOnPointStep struct {
 	BaseStep              `yaml:",inline"`
@@ -152,6 +247,105 @@ type TapOnPointStep struct {
 	WaitToSettleTimeoutMs int    `yaml:"waitToSettleTimeoutMs"`
 }
 
+// DefaultTapSequenceDelayMs is the pause between taps in a tapSequence.
+const DefaultTapSequenceDelayMs = 100
+
+// TapSequenceStep taps an ordered list of targets in quick succession.
+// Each target is a selector (tapped at its center, or at Point within it) or
+// a bare Point as a percentage of the screen. All targets are resolved before
+// the first tap so lookups don't stretch the gaps between taps.
+type TapSequenceStep struct {
+	BaseStep `yaml:",inline"`
+	Taps     []Selector `yaml:"taps"`
+	DelayMs  int        `yaml:"delay"` // pause between taps (default 100ms)
+}
+
+// Delay returns the pause between taps in milliseconds.
+func (s *TapSequenceStep) Delay() int {
+	if s.DelayMs > 0 {
+		return s.DelayMs
+	}
+	return DefaultTapSequenceDelayMs
+}
+
+// DefaultGesturePathDurationMs is how long a gesturePath takes end to end.
+const DefaultGesturePathDurationMs = 1000
+
+// GesturePathStep drags one finger through an ordered list of points, e.g. to
+// draw a signature. Points are "x%, y%" screen percentages. The total duration
+// is spread across segments by length so the finger moves at a steady speed.
+type GesturePathStep struct {
+	BaseStep   `yaml:",inline"`
+	Points     []string `yaml:"points"`
+	DurationMs int      `yaml:"duration"` // total duration (default 1000ms)
+}
+
+// Duration returns the total gesture duration in milliseconds.
+func (s *GesturePathStep) Duration() int {
+	if s.DurationMs > 0 {
+		return s.DurationMs
+	}
+	return DefaultGesturePathDurationMs
+}
+
+// DefaultTwoFingerSwipeDurationMs is how long a twoFingerSwipe takes.
+const DefaultTwoFingerSwipeDurationMs = 500
+
+// TwoFingerSwipeStep moves two fingers side by side in parallel, e.g. to pan a
+// map that treats one finger as a drag. Start and End ("x%, y%") place the
+// midpoint between the fingers; without them Direction pans across the
+// middle of the screen.
+type TwoFingerSwipeStep struct {
+	BaseStep   `yaml:",inline"`
+	Direction  string `yaml:"direction"` // UP, DOWN, LEFT, RIGHT
+	Start      string `yaml:"start"`     // "x%, y%"
+	End        string `yaml:"end"`       // "x%, y%"
+	DurationMs int    `yaml:"duration"`  // default 500ms
+	Spacing    int    `yaml:"spacing"`   // distance between fingers, default 10% of screen width
+}
+
+// Duration returns the gesture duration in milliseconds.
+func (s *TwoFingerSwipeStep) Duration() int {
+	if s.DurationMs > 0 {
+		return s.DurationMs
+	}
+	return DefaultTwoFingerSwipeDurationMs
+}
+
+// FingerSpacing returns the distance between the fingers for a screen of the
+// given width.
+func (s *TwoFingerSwipeStep) FingerSpacing(screenWidth int) float64 {
+	if s.Spacing > 0 {
+		return float64(s.Spacing)
+	}
+	return float64(screenWidth) / 10
+}
+
+// Endpoints returns the "x%, y%" start and end of the midpoint between the
+// fingers, from Start/End or else from Direction.
+func (s *TwoFingerSwipeStep) Endpoints() (string, string, error) {
+	if s.Start != "" || s.End != "" {
+		if s.Start == "" || s.End == "" {
+			return "", "", fmt.Errorf("twoFingerSwipe needs both start and end")
+		}
+		return s.Start, s.End, nil
+	}
+	switch strings.ToUpper(s.Direction) {
+	case "UP":
+		return "50%, 70%", "50%, 30%", nil
+	case "DOWN":
+		return "50%, 30%", "50%, 70%", nil
+	case "LEFT":
+		return "70%, 50%", "30%, 50%", nil
+	case "RIGHT":
+		return "30%, 50%", "70%, 50%", nil
+	case "":
+		return "", "", fmt.Errorf("twoFingerSwipe needs a direction or start and end")
+	default:
+		return "", "", fmt.Errorf("invalid twoFingerSwipe direction %q", s.Direction)
+	}
+}
+
 // SwipeStep performs a swipe gesture.
 type SwipeStep struct {
 	BaseStep              `yaml:",inline"`
@@ -172,6 +366,29 @@ type SwipeStep struct {
 type ScrollStep struct {
 	BaseStep  `yaml:",inline"`
 	Direction string `yaml:"direction"`
+	Amount    string `yaml:"amount"` // "30%" or "0.3" of the scroll area; empty uses the driver default
+}
+
+// AmountFraction returns Amount as a fraction of the scroll area. ok is false
+// when Amount is empty, so the driver keeps its default distance.
+func (s *ScrollStep) AmountFraction() (fraction float64, ok bool, err error) {
+	amount := strings.TrimSpace(s.Amount)
+	if amount == "" {
+		return 0, false, nil
+	}
+	if strings.HasSuffix(amount, "%") {
+		fraction, err = strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(amount, "%")), 64)
+		fraction /= 100
+	} else {
+		fraction, err = strconv.ParseFloat(amount, 64)
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("invalid scroll amount %q", s.Amount)
+	}
+	if fraction <= 0 || fraction > 1 {
+		return 0, false, fmt.Errorf("scroll amount %q out of range (0%%, 100%%]", s.Amount)
+	}
+	return fraction, true, nil
 }
 
 // ScrollUntilVisibleStep scrolls until element is visible.
@@ -179,16 +396,86 @@ type ScrollUntilVisibleStep struct {
 	BaseStep              `yaml:",inline"`
 	Element               Selector `yaml:"element"`
 	Direction             string   `yaml:"direction"`
-	MaxScrolls            int      `yaml:"maxScrolls"` // Legacy: max scroll attempts
-	Speed                 int      `yaml:"speed"`
+	MaxScrolls            int      `yaml:"maxScrolls"` // Hard cap on scrolls; 0 uses the driver default
+	Speed                 string   `yaml:"speed"`      // slow, normal, fast, or Maestro's 0-100
 	VisibilityPercentage  int      `yaml:"visibilityPercentage"`
 	CenterElement         bool     `yaml:"centerElement"`
 	WaitToSettleTimeoutMs int      `yaml:"waitToSettleTimeoutMs"`
 }
 
+// DefaultVisibilityPercentage is how much of the element must be on screen
+// for scrollUntilVisible to stop when VisibilityPercentage is unset.
+const DefaultVisibilityPercentage = 1
+
+// VisibilityThreshold returns VisibilityPercentage clamped to 1-100, or
+// DefaultVisibilityPercentage when unset.
+func (s *ScrollUntilVisibleStep) VisibilityThreshold() int {
+	if s.VisibilityPercentage <= 0 {
+		return DefaultVisibilityPercentage
+	}
+	return min(s.VisibilityPercentage, 100)
+}
+
+// Named scrollUntilVisible speeds.
+const (
+	ScrollSpeedSlow   = "slow"
+	ScrollSpeedNormal = "normal"
+	ScrollSpeedFast   = "fast"
+)
+
+// ScrollProfile is the swipe used for each scrollUntilVisible iteration.
+type ScrollProfile struct {
+	Distance   float64 // Fraction of the scroll area covered by one swipe
+	DurationMs int     // Swipe duration
+}
+
+var scrollProfiles = map[string]ScrollProfile{
+	ScrollSpeedSlow:   {Distance: 0.2, DurationMs: 800},
+	ScrollSpeedNormal: {Distance: 0.3, DurationMs: 300},
+	ScrollSpeedFast:   {Distance: 0.5, DurationMs: 150},
+}
+
+// ScrollProfile maps Speed to a swipe profile. Empty means normal; numeric
+// Maestro speeds (0-100) fall into thirds: below 34 slow, below 67 normal,
+// otherwise fast.
+func (s *ScrollUntilVisibleStep) ScrollProfile() (ScrollProfile, error) {
+	speed := strings.ToLower(strings.TrimSpace(s.Speed))
+	if speed == "" {
+		return scrollProfiles[ScrollSpeedNormal], nil
+	}
+	if p, ok := scrollProfiles[speed]; ok {
+		return p, nil
+	}
+	n, err := strconv.Atoi(speed)
+	if err != nil || n < 0 || n > 100 {
+		return ScrollProfile{}, fmt.Errorf("invalid scroll speed %q (want slow, normal, fast or 0-100)", s.Speed)
+	}
+	switch {
+	case n < 34:
+		return scrollProfiles[ScrollSpeedSlow], nil
+	case n < 67:
+		return scrollProfiles[ScrollSpeedNormal], nil
+	default:
+		return scrollProfiles[ScrollSpeedFast], nil
+	}
+}
+
+// ScrollToPositionStep scrolls the main scrollable to a fraction of its content
+// (0% = start, 100% = end). Content extent is measured by swiping and comparing
+// page source snapshots, so the landing position is approximate.
+type ScrollToPositionStep struct {
+	BaseStep   `yaml:",inline"`
+	Position   string `yaml:"position"`   // "50%" or "0.5"
+	Direction  string `yaml:"direction"`  // DOWN (vertical, default) or RIGHT (horizontal)
+	MaxScrolls int    `yaml:"maxScrolls"` // Cap on swipes while measuring extent
+}
+
 // BackStep presses back.
 type BackStep struct {
 	BaseStep `yaml:",inline"`
+	// TapBackButton (iOS) taps the navigation bar's "Back" button when the
+	// edge swipe leaves the page source unchanged.
+	TapBackButton bool `yaml:"tapBackButton"`
 }
 
 // HideKeyboardStep hides the keyboard.
@@ -201,6 +488,14 @@ type AcceptAlertStep struct {
 	BaseStep `yaml:",inline"`
 }
 
+// AssertAlertTextStep waits for a system alert and asserts its message text.
+// Text requires an exact match; Contains requires a substring.
+type AssertAlertTextStep struct {
+	BaseStep `yaml:",inline"`
+	Text     string `yaml:"text"`
+	Contains string `yaml:"contains"`
+}
+
 // DismissAlertStep dismisses a system alert dialog (taps Don't Allow/Cancel).
 type DismissAlertStep struct {
 	BaseStep `yaml:",inline"`
@@ -212,10 +507,12 @@ type DismissAlertStep struct {
 
 // InputTextStep inputs text.
 type InputTextStep struct {
-	BaseStep `yaml:",inline"`
-	Text     string   `yaml:"text"`
-	KeyPress bool     `yaml:"keyPress"` // If true, simulate real key presses (Android native only)
-	Selector Selector `yaml:",inline"`
+	BaseStep    `yaml:",inline"`
+	Text        string   `yaml:"text"`
+	KeyPress    bool     `yaml:"keyPress"`   // If true, simulate real key presses (Android native only)
+	VerifyText  bool     `yaml:"verifyText"` // If true, read the field back and retry once on mismatch
+	TypeDelayMs int      `yaml:"typeDelay"`  // If > 0, type one character at a time with this pause
+	Selector    Selector `yaml:",inline"`
 }
 
 // InputRandomStep generates random input.
@@ -248,6 +545,22 @@ type SetClipboardStep struct {
 	Text     string `yaml:"text"`
 }
 
+// SearchStep taps a search field, types the query and presses the keyboard's
+// search key. With no Field, the query goes into the focused field. WaitFor
+// optionally waits for a results element after submitting.
+type SearchStep struct {
+	BaseStep `yaml:",inline"`
+	Field    Selector  `yaml:"field"`
+	Query    string    `yaml:"query"`
+	Submit   *bool     `yaml:"submit"`
+	WaitFor  *Selector `yaml:"waitFor"`
+}
+
+// ShouldSubmit reports whether to press search after typing (default true).
+func (s *SearchStep) ShouldSubmit() bool {
+	return s.Submit == nil || *s.Submit
+}
+
 // ============================================
 // Assertion Steps
 // ============================================
@@ -256,14 +569,200 @@ type SetClipboardStep struct {
 type AssertVisibleStep struct {
 	BaseStep `yaml:",inline"`
 	Selector Selector `yaml:",inline"`
+	// FullyVisible additionally requires the element's bounds to lie
+	// entirely within the window, so partially clipped elements fail.
+	FullyVisible bool `yaml:"fullyVisible"`
+	WebView      bool `yaml:"webView"` // match text in the WebView DOM (Android)
+	// Count, when set, requires the number of visible matches to compare
+	// against it with CountComparator ("==" by default, ">=" or "<=").
+	Count           *int   `yaml:"count"`
+	CountComparator string `yaml:"countComparator"`
+}
+
+// Comparators accepted by AssertVisibleStep.CountComparator.
+const (
+	CountExactly = "=="
+	CountAtLeast = ">="
+	CountAtMost  = "<="
+)
+
+// ValidateCount rejects a negative count or an unknown comparator.
+func (s *AssertVisibleStep) ValidateCount() error {
+	if s.Count != nil && *s.Count < 0 {
+		return fmt.Errorf("invalid count %d (must be >= 0)", *s.Count)
+	}
+	switch s.CountComparator {
+	case "", CountExactly, CountAtLeast, CountAtMost:
+		return nil
+	}
+	return fmt.Errorf("invalid countComparator %q (want ==, >= or <=)", s.CountComparator)
+}
+
+// CheckCount reports why found visible matches miss the count expectation,
+// e.g. "expected 5 'row' elements, found 3". It returns nil when the count is
+// met or unset.
+func (s *AssertVisibleStep) CheckCount(found int) error {
+	if s.Count == nil {
+		return nil
+	}
+	want := *s.Count
+	var ok bool
+	var qualifier string
+	switch s.CountComparator {
+	case CountAtLeast:
+		ok, qualifier = found >= want, "at least "
+	case CountAtMost:
+		ok, qualifier = found <= want, "at most "
+	default:
+		ok = found == want
+	}
+	if ok {
+		return nil
+	}
+	return fmt.Errorf("expected %s%d '%s' elements, found %d", qualifier, want, s.Selector.Describe(), found)
+}
+
+// AssertFieldValueStep asserts the current value of an input field, e.g. one
+// prefilled by a deep link. Exactly one of Equals or Contains must be set.
+type AssertFieldValueStep struct {
+	BaseStep `yaml:",inline"`
+	Element  Selector `yaml:"element"`
+	Equals   string   `yaml:"equals"`
+	Contains string   `yaml:"contains"`
+}
+
+// AssertShareTargetStep checks that the open share sheet (Android chooser or
+// iOS activity view) lists an app by its visible name, or with Visible set to
+// false, that it does not.
+type AssertShareTargetStep struct {
+	BaseStep `yaml:",inline"`
+	App      string `yaml:"app"`
+	Visible  *bool  `yaml:"visible"`
 }
 
-// AssertNotVisibleStep asserts element is not visible.
+// ExpectPresent reports whether the app should be listed (default true).
+func (s *AssertShareTargetStep) ExpectPresent() bool {
+	return s.Visible == nil || *s.Visible
+}
+
+// AssertAccessibleStep fails when the current screen has interactive elements
+// (clickable nodes on Android, controls on iOS) that a screen reader cannot
+// announce: no text, content-desc or hint on Android, no label or
+// accessibility identifier on iOS.
+type AssertAccessibleStep struct {
+	BaseStep `yaml:",inline"`
+}
+
+// DefaultMaxJankyPercent is the share of janky frames assertNoJank allows
+// when the step sets no maxJankyPercent.
+const DefaultMaxJankyPercent = 5.0
+
+// AssertNoJankStep resets the app's frame stats, runs its nested commands
+// (typically scrolls) and fails when the share of janky frames rendered during
+// them exceeds MaxJankyPercent. Android only.
+type AssertNoJankStep struct {
+	BaseStep        `yaml:",inline"`
+	AppID           string  `yaml:"appId"`
+	MaxJankyPercent float64 `yaml:"maxJankyPercent"`
+	Steps           []Step  `yaml:"-"`
+}
+
+// MaxJanky returns MaxJankyPercent, or DefaultMaxJankyPercent when unset.
+func (s *AssertNoJankStep) MaxJanky() float64 {
+	if s.MaxJankyPercent > 0 {
+		return s.MaxJankyPercent
+	}
+	return DefaultMaxJankyPercent
+}
+
+// DefaultThemeRegionPercent is the top band of the screen, in percent of its
+// height, that assertThemeColor samples when the step sets no region. It
+// covers the status bar and a standard app bar on most phones.
+const DefaultThemeRegionPercent = 12.0
+
+// DefaultThemeColorTolerance is the largest per-channel difference (0-255)
+// assertThemeColor accepts when the step sets no tolerance.
+const DefaultThemeColorTolerance = 16
+
+// AssertThemeColorStep takes a screenshot and asserts that the dominant color
+// of its top band (the app bar) is within Tolerance of Color ("#RRGGBB").
+type AssertThemeColorStep struct {
+	BaseStep  `yaml:",inline"`
+	Color     string  `yaml:"color"`
+	Region    float64 `yaml:"region"`    // band height in percent of the screen
+	Tolerance int     `yaml:"tolerance"` // max per-channel difference
+}
+
+// RegionPercent returns Region, or DefaultThemeRegionPercent when unset.
+func (s *AssertThemeColorStep) RegionPercent() float64 {
+	if s.Region > 0 {
+		return s.Region
+	}
+	return DefaultThemeRegionPercent
+}
+
+// ColorTolerance returns Tolerance, or DefaultThemeColorTolerance when unset.
+func (s *AssertThemeColorStep) ColorTolerance() int {
+	if s.Tolerance > 0 {
+		return s.Tolerance
+	}
+	return DefaultThemeColorTolerance
+}
+
+// DefaultScreenshotThreshold is the largest share of differing pixels, in
+// percent, assertScreenshot accepts when the step sets no threshold.
+const DefaultScreenshotThreshold = 0.5
+
+// AssertScreenshotStep takes a screenshot and compares it pixel by pixel
+// against the Baseline image, failing when more than Threshold percent of
+// the pixels differ.
+type AssertScreenshotStep struct {
+	BaseStep       `yaml:",inline"`
+	Baseline       string   `yaml:"baseline"`       // image path, relative to the flow file
+	Threshold      *float64 `yaml:"threshold"`      // max differing pixels in percent
+	DiffImage      bool     `yaml:"diffImage"`      // on failure, write <baseline>.diff.png
+	UpdateBaseline bool     `yaml:"updateBaseline"` // save a missing baseline instead of failing
+}
+
+// ThresholdPercent returns Threshold, or DefaultScreenshotThreshold when unset.
+// An explicit threshold of 0 asks for an exact match.
+func (s *AssertScreenshotStep) ThresholdPercent() float64 {
+	if s.Threshold != nil {
+		return *s.Threshold
+	}
+	return DefaultScreenshotThreshold
+}
+
+// AssertTextNotContainsStep fails when any visible text on screen contains
+// one of Text or matches (anywhere in the text) one of Regex.
+type AssertTextNotContainsStep struct {
+	BaseStep `yaml:",inline"`
+	Text     []string `yaml:"text"`
+	Regex    []string `yaml:"regex"`
+}
+
+// DefaultNotVisibleWindowMs is how long assertNotVisible watches the screen
+// when the step sets no timeout.
+const DefaultNotVisibleWindowMs = 1000
+
+// AssertNotVisibleStep asserts that no element matches Selector. The screen is
+// checked repeatedly for a short confirmation window (the step timeout, or
+// DefaultNotVisibleWindowMs) and the step fails as soon as any check finds a
+// match, whatever the selector kind. It does not wait for an element to go
+// away; use extendedWaitUntil with notVisible for that.
 type AssertNotVisibleStep struct {
 	BaseStep `yaml:",inline"`
 	Selector Selector `yaml:",inline"`
 }
 
+// Window returns the confirmation window in milliseconds.
+func (s *AssertNotVisibleStep) Window() int {
+	if s.TimeoutMs > 0 {
+		return s.TimeoutMs
+	}
+	return DefaultNotVisibleWindowMs
+}
+
 // AssertTrueStep asserts a script condition is true (alias for assertCondition).
 type AssertTrueStep struct {
 	BaseStep `yaml:",inline"`
@@ -278,6 +777,24 @@ type Condition struct {
 	Platform   string    `yaml:"platform"`
 }
 
+// UnmarshalYAML accepts Maestro's `true: ${...}` key as the script condition,
+// alongside scriptCondition.
+func (c *Condition) UnmarshalYAML(node *yaml.Node) error {
+	type plain Condition
+	var raw struct {
+		plain `yaml:",inline"`
+		True  string `yaml:"true"`
+	}
+	if err := node.Decode(&raw); err != nil {
+		return err
+	}
+	*c = Condition(raw.plain)
+	if c.Script == "" {
+		c.Script = raw.True
+	}
+	return nil
+}
+
 // AssertConditionStep asserts a condition.
 type AssertConditionStep struct {
 	BaseStep  `yaml:",inline"`
@@ -309,6 +826,44 @@ type WaitUntilStep struct {
 	NotVisible *Selector `yaml:"notVisible"`
 }
 
+// ExtendedWaitUntilStep waits until every Visible selector is visible and every
+// NotVisible selector is gone, under a single shared timeout. It is parsed from
+// extendedWaitUntil when visible or notVisible is given as a list.
+type ExtendedWaitUntilStep struct {
+	BaseStep   `yaml:",inline"`
+	Visible    []Selector
+	NotVisible []Selector
+}
+
+// AssertResourceStep asserts the app's memory and/or CPU usage is under a threshold.
+type AssertResourceStep struct {
+	BaseStep      `yaml:",inline"`
+	AppID         string  `yaml:"appId"`
+	MaxMemoryMB   float64 `yaml:"maxMemoryMb"`   // Total PSS limit in MB
+	MaxCPUPercent float64 `yaml:"maxCpuPercent"` // CPU usage limit in percent
+}
+
+// AssertSortedStep asserts that values read from every element matching Element,
+// in screen hierarchy order, are sorted.
+type AssertSortedStep struct {
+	BaseStep `yaml:",inline"`
+	Element  Selector `yaml:"element"`
+	Pattern  string   `yaml:"pattern"` // regex; first capture group (or whole match) is the value
+	Numeric  bool     `yaml:"numeric"`
+	Order    string   `yaml:"order"` // "ascending" (default) or "descending"
+}
+
+// AssertFileExistsStep asserts that a file matching Path exists, optionally
+// with at least MinSize bytes. Relative paths are looked up in the flow's
+// artifacts directory (where takeScreenshot saves); with Device set, Path is
+// checked on the device instead.
+type AssertFileExistsStep struct {
+	BaseStep `yaml:",inline"`
+	Path     string `yaml:"path"`    // file name or glob pattern
+	MinSize  int64  `yaml:"minSize"` // bytes
+	Device   bool   `yaml:"device"`
+}
+
 // ============================================
 // App Management Steps
 // ============================================
@@ -325,9 +880,12 @@ type LaunchAppStep struct {
 }
 
 // StopAppStep stops an app.
+// Graceful sends the app to the background and waits briefly before
+// terminating it, so it can flush state on exit.
 type StopAppStep struct {
 	BaseStep `yaml:",inline"`
 	AppID    string `yaml:"appId"`
+	Graceful bool   `yaml:"graceful"`
 }
 
 // KillAppStep kills an app.
@@ -392,12 +950,151 @@ type TravelStep struct {
 	Speed    float64  `yaml:"speed"`  // km/h
 }
 
+// DefaultTravelSpeed is the travel speed in km/h when Speed is unset.
+const DefaultTravelSpeed = 50
+
+// TravelWaypoint is one parsed "lat, lon" point of a travel route.
+type TravelWaypoint struct {
+	Lat string
+	Lon string
+}
+
+// Waypoints parses Points as "lat, lon" pairs, skipping malformed entries.
+func (s *TravelStep) Waypoints() []TravelWaypoint {
+	var points []TravelWaypoint
+	for _, point := range s.Points {
+		parts := strings.Split(point, ",")
+		if len(parts) != 2 {
+			continue
+		}
+		points = append(points, TravelWaypoint{
+			Lat: strings.TrimSpace(parts[0]),
+			Lon: strings.TrimSpace(parts[1]),
+		})
+	}
+	return points
+}
+
+// PointDelay is the pause after each waypoint, assuming ~1km between points.
+func (s *TravelStep) PointDelay() time.Duration {
+	speed := s.Speed
+	if speed <= 0 {
+		speed = DefaultTravelSpeed
+	}
+	return time.Duration(3600/speed) * time.Second
+}
+
+// ClearNotificationsStep dismisses all notifications and verifies the shade is empty.
+type ClearNotificationsStep struct {
+	BaseStep `yaml:",inline"`
+}
+
+// EnsureUnlockedStep wakes the device and dismisses the lock screen.
+// Passcode is entered only when the lock screen is secure.
+type EnsureUnlockedStep struct {
+	BaseStep `yaml:",inline"`
+	Passcode string `yaml:"passcode"`
+}
+
+// SetAppearanceStep switches the system between dark and light mode.
+// SettleMs optionally pauses afterwards so the app can redraw.
+type SetAppearanceStep struct {
+	BaseStep `yaml:",inline"`
+	Mode     string `yaml:"mode"` // dark, light
+	SettleMs int    `yaml:"settleMs"`
+}
+
+// Dark reports whether the step selects dark mode, or an error for an
+// unknown mode.
+func (s *SetAppearanceStep) Dark() (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(s.Mode)) {
+	case "dark":
+		return true, nil
+	case "light":
+		return false, nil
+	}
+	return false, fmt.Errorf("invalid appearance %q: must be dark or light", s.Mode)
+}
+
+// SetAppLocaleStep sets the app's own language (Android 13+ per-app locales)
+// without changing the device locale. The app is relaunched afterwards unless
+// Relaunch is false, since running activities keep their old configuration.
+type SetAppLocaleStep struct {
+	BaseStep `yaml:",inline"`
+	AppID    string `yaml:"appId"`
+	Locale   string `yaml:"locale"` // BCP 47 tag, e.g. "fr-FR"
+	Relaunch *bool  `yaml:"relaunch"`
+}
+
+// ShouldRelaunch reports whether the app is restarted after the change.
+func (s *SetAppLocaleStep) ShouldRelaunch() bool {
+	return s.Relaunch == nil || *s.Relaunch
+}
+
+// Preference types accepted by SetPreferenceStep.
+const (
+	PrefString  = "string"
+	PrefBoolean = "boolean"
+	PrefInt     = "int"
+	PrefLong    = "long"
+	PrefFloat   = "float"
+)
+
+// SetPreferenceStep seeds one key in the app's preferences before it runs:
+// SharedPreferences on Android (debuggable builds only) and UserDefaults on
+// iOS simulators. The app is stopped first so it cannot overwrite the change.
+type SetPreferenceStep struct {
+	BaseStep  `yaml:",inline"`
+	AppID     string `yaml:"appId"`
+	File      string `yaml:"file"` // Android prefs file name, default "<appId>_preferences"
+	Key       string `yaml:"key"`
+	ValueType string `yaml:"type"` // string (default), boolean, int, long, float
+	Value     string `yaml:"value"`
+}
+
+// Typed returns the normalized type and value, or an error when the type is
+// unknown or the value does not parse as that type.
+func (s *SetPreferenceStep) Typed() (string, string, error) {
+	typ := strings.ToLower(strings.TrimSpace(s.ValueType))
+	value := s.Value
+	switch typ {
+	case "", PrefString:
+		return PrefString, value, nil
+	case PrefBoolean, "bool":
+		b, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			return "", "", fmt.Errorf("preference %q: %q is not a boolean", s.Key, value)
+		}
+		return PrefBoolean, strconv.FormatBool(b), nil
+	case PrefInt, PrefLong:
+		bits := 32
+		if typ == PrefLong {
+			bits = 64
+		}
+		if _, err := strconv.ParseInt(strings.TrimSpace(value), 10, bits); err != nil {
+			return "", "", fmt.Errorf("preference %q: %q is not a valid %s", s.Key, value, typ)
+		}
+		return typ, strings.TrimSpace(value), nil
+	case PrefFloat:
+		if _, err := strconv.ParseFloat(strings.TrimSpace(value), 32); err != nil {
+			return "", "", fmt.Errorf("preference %q: %q is not a float", s.Key, value)
+		}
+		return PrefFloat, strings.TrimSpace(value), nil
+	}
+	return "", "", fmt.Errorf("preference %q: unknown type %q (use string, boolean, int, long or float)", s.Key, s.ValueType)
+}
+
 // OpenLinkStep opens a URL.
+// With ClearState set, the app is cleared and killed before the link fires so
+// it is handled from a cold start; WaitFor optionally verifies the landing screen.
 type OpenLinkStep struct {
 	BaseStep   `yaml:",inline"`
-	Link       string `yaml:"link"`
-	AutoVerify *bool  `yaml:"autoVerify"`
-	Browser    *bool  `yaml:"browser"`
+	Link       string    `yaml:"link"`
+	AutoVerify *bool     `yaml:"autoVerify"`
+	Browser    *bool     `yaml:"browser"`
+	ClearState bool      `yaml:"clearState"`
+	AppID      string    `yaml:"appId"`
+	WaitFor    *Selector `yaml:"waitFor"`
 }
 
 // OpenBrowserStep opens a URL in the browser.
@@ -418,6 +1115,14 @@ type RepeatStep struct {
 	Steps    []Step    `yaml:"-"`
 }
 
+// IfStep runs Then when Condition is met, otherwise Else.
+type IfStep struct {
+	BaseStep  `yaml:",inline"`
+	Condition Condition `yaml:"condition"`
+	Then      []Step    `yaml:"-"`
+	Else      []Step    `yaml:"-"`
+}
+
 // RetryStep retries steps on failure.
 type RetryStep struct {
 	BaseStep   `yaml:",inline"`
@@ -468,10 +1173,14 @@ type TakeScreenshotStep struct {
 	Path     string `yaml:"path"`
 }
 
-// StartRecordingStep starts recording.
+// StartRecordingStep starts recording. Size and Mask only change how the
+// whole screen is rendered; Crop cuts a region off the saved video.
 type StartRecordingStep struct {
 	BaseStep `yaml:",inline"`
 	Path     string `yaml:"path"`
+	Size     string `yaml:"size"` // Android only: scale the video to "WIDTHxHEIGHT" (screenrecord --size)
+	Mask     string `yaml:"mask"` // iOS simulator only: display mask ignored, alpha or black (recordVideo --mask)
+	Crop     string `yaml:"crop"` // "statusBar": cut the status bar off the host copy with ffmpeg
 }
 
 // StopRecordingStep stops recording.
@@ -496,9 +1205,66 @@ type PressKeyStep struct {
 	Key      string `yaml:"key"`
 }
 
-// WaitForAnimationToEndStep waits for animations.
+// DefaultAnimationTimeoutMs is how long waitForAnimationToEnd watches the
+// screen when the step sets no timeout.
+const DefaultAnimationTimeoutMs = 5000
+
+// WaitForAnimationToEndStep waits until consecutive screenshots stop changing.
+// When the screen is still moving at the timeout the step passes unless
+// ContinueOnTimeout is set to false.
 type WaitForAnimationToEndStep struct {
+	BaseStep          `yaml:",inline"`
+	ContinueOnTimeout *bool `yaml:"continueOnTimeout"`
+}
+
+// Timeout returns the step timeout, or DefaultAnimationTimeoutMs.
+func (s *WaitForAnimationToEndStep) Timeout() int {
+	if s.TimeoutMs > 0 {
+		return s.TimeoutMs
+	}
+	return DefaultAnimationTimeoutMs
+}
+
+// ShouldContinueOnTimeout reports whether a timeout still passes (default true).
+func (s *WaitForAnimationToEndStep) ShouldContinueOnTimeout() bool {
+	return s.ContinueOnTimeout == nil || *s.ContinueOnTimeout
+}
+
+// WaitForDownloadStep waits for a downloaded file to appear and stop growing.
+type WaitForDownloadStep struct {
+	BaseStep  `yaml:",inline"`
+	File      string `yaml:"file"`      // file name or glob pattern, e.g. "invoice-*.pdf"
+	Directory string `yaml:"directory"` // defaults to the device Downloads folder
+}
+
+// WaitForTextStep polls the text of the first element matching Element until
+// it satisfies exactly one of Equals, Contains or Matches (a regex that must
+// match the whole text). The timeout comes from BaseStep.TimeoutMs.
+type WaitForTextStep struct {
+	BaseStep `yaml:",inline"`
+	Element  Selector `yaml:"element"`
+	Equals   string   `yaml:"equals"`
+	Contains string   `yaml:"contains"`
+	Matches  string   `yaml:"matches"`
+}
+
+// DefaultInstallTimeoutMs bounds waitForInstall when no timeout is given.
+const DefaultInstallTimeoutMs = 60000
+
+// WaitForInstallStep polls the device's installed packages until AppID shows
+// up, for flows that start while a background install is still running.
+type WaitForInstallStep struct {
 	BaseStep `yaml:",inline"`
+	AppID    string `yaml:"appId"`
+}
+
+// Timeout returns the step timeout in milliseconds, defaulting to
+// DefaultInstallTimeoutMs.
+func (s *WaitForInstallStep) Timeout() int {
+	if s.TimeoutMs > 0 {
+		return s.TimeoutMs
+	}
+	return DefaultInstallTimeoutMs
 }
 
 // DefineVariablesStep defines variables.
@@ -527,6 +1293,24 @@ func (s *TapOnStep) Describe() string {
 	return "tapOn: " + s.Selector.DescribeQuoted()
 }
 
+// Describe returns a human-readable description of the tap sequence step.
+func (s *TapSequenceStep) Describe() string {
+	return fmt.Sprintf("tapSequence: %d taps", len(s.Taps))
+}
+
+// Describe returns a human-readable description of the gesture path step.
+func (s *GesturePathStep) Describe() string {
+	return fmt.Sprintf("gesturePath: %d points over %dms", len(s.Points), s.Duration())
+}
+
+// Describe returns a human-readable description of the two-finger swipe step.
+func (s *TwoFingerSwipeStep) Describe() string {
+	if s.Direction != "" && s.Start == "" {
+		return "twoFingerSwipe: " + s.Direction
+	}
+	return fmt.Sprintf("twoFingerSwipe: (%s) -> (%s)", s.Start, s.End)
+}
+
 // Describe returns a human-readable description of the double tap step.
 func (s *DoubleTapOnStep) Describe() string {
 	return "doubleTapOn: " + s.Selector.DescribeQuoted()
@@ -534,11 +1318,24 @@ func (s *DoubleTapOnStep) Describe() string {
 
 // Describe returns a human-readable description of the long press step.
 func (s *LongPressOnStep) Describe() string {
+	if s.Selector.IsEmpty() && s.Point != "" {
+		return "longPressOn: point " + s.Point
+	}
 	return "longPressOn: " + s.Selector.DescribeQuoted()
 }
 
 // Describe returns a human-readable description of the assert visible step.
 func (s *AssertVisibleStep) Describe() string {
+	if s.Count != nil {
+		comparator := s.CountComparator
+		if comparator == "" {
+			comparator = CountExactly
+		}
+		return fmt.Sprintf("assertVisible: %s (count %s %d)", s.Selector.DescribeQuoted(), comparator, *s.Count)
+	}
+	if s.FullyVisible {
+		return "assertVisible (fully): " + s.Selector.DescribeQuoted()
+	}
 	return "assertVisible: " + s.Selector.DescribeQuoted()
 }
 
@@ -552,6 +1349,11 @@ func (s *InputTextStep) Describe() string {
 	return "inputText: \"" + s.Text + "\""
 }
 
+// Describe returns a human-readable description of the search step.
+func (s *SearchStep) Describe() string {
+	return fmt.Sprintf("search: %q", s.Query)
+}
+
 // Describe returns a human-readable description of the launch app step.
 func (s *LaunchAppStep) Describe() string {
 	if s.ClearState {
@@ -571,11 +1373,145 @@ func (s *WaitUntilStep) Describe() string {
 	return "extendedWaitUntil"
 }
 
+// Describe returns a human-readable description of the multi-selector wait step.
+func (s *ExtendedWaitUntilStep) Describe() string {
+	var parts []string
+	if len(s.Visible) > 0 {
+		parts = append(parts, "visible "+describeSelectors(s.Visible))
+	}
+	if len(s.NotVisible) > 0 {
+		parts = append(parts, "notVisible "+describeSelectors(s.NotVisible))
+	}
+	if len(parts) == 0 {
+		return "extendedWaitUntil"
+	}
+	return "extendedWaitUntil: " + strings.Join(parts, "; ")
+}
+
+// describeSelectors joins the quoted descriptions of sels with ", ".
+func describeSelectors(sels []Selector) string {
+	descs := make([]string, len(sels))
+	for i := range sels {
+		descs[i] = sels[i].DescribeQuoted()
+	}
+	return strings.Join(descs, ", ")
+}
+
+// Describe returns a human-readable description of the assert alert text step.
+func (s *AssertAlertTextStep) Describe() string {
+	if s.Contains != "" {
+		return "assertAlertText contains: \"" + s.Contains + "\""
+	}
+	return "assertAlertText: \"" + s.Text + "\""
+}
+
+// Describe returns a human-readable description of the assert resource step.
+func (s *AssertResourceStep) Describe() string {
+	var limits []string
+	if s.MaxMemoryMB > 0 {
+		limits = append(limits, fmt.Sprintf("memory < %gMB", s.MaxMemoryMB))
+	}
+	if s.MaxCPUPercent > 0 {
+		limits = append(limits, fmt.Sprintf("cpu < %g%%", s.MaxCPUPercent))
+	}
+	if len(limits) == 0 {
+		return "assertResource"
+	}
+	return "assertResource: " + strings.Join(limits, ", ")
+}
+
+// Describe returns a human-readable description of the assert sorted step.
+func (s *AssertSortedStep) Describe() string {
+	order := s.Order
+	if order == "" {
+		order = "ascending"
+	}
+	return fmt.Sprintf("assertSorted %s: %s", order, s.Element.DescribeQuoted())
+}
+
+// Describe returns a human-readable description of the assert file exists step.
+func (s *AssertFileExistsStep) Describe() string {
+	if s.Device {
+		return "assertFileExists (device): " + s.Path
+	}
+	return "assertFileExists: " + s.Path
+}
+
+// Describe returns a human-readable description of the set app locale step.
+func (s *SetAppLocaleStep) Describe() string {
+	return "setAppLocale: " + s.Locale
+}
+
+// Describe returns a human-readable description of the assert field value step.
+func (s *AssertFieldValueStep) Describe() string {
+	return "assertFieldValue: " + s.Element.DescribeQuoted()
+}
+
+// Describe returns a human-readable description of the assert share target step.
+func (s *AssertShareTargetStep) Describe() string {
+	if !s.ExpectPresent() {
+		return fmt.Sprintf("assertShareTarget: not %q", s.App)
+	}
+	return fmt.Sprintf("assertShareTarget: %q", s.App)
+}
+
+// Describe returns a human-readable description of the assert no jank step.
+func (s *AssertNoJankStep) Describe() string {
+	return fmt.Sprintf("assertNoJank: max %g%% janky frames", s.MaxJanky())
+}
+
+// Describe returns a human-readable description of the assert theme color step.
+func (s *AssertThemeColorStep) Describe() string {
+	return "assertThemeColor: " + s.Color
+}
+
+// Describe returns a human-readable description of the assert screenshot step.
+func (s *AssertScreenshotStep) Describe() string {
+	return "assertScreenshot: " + s.Baseline
+}
+
+// Describe returns a human-readable description of the assert text not contains step.
+func (s *AssertTextNotContainsStep) Describe() string {
+	terms := make([]string, 0, len(s.Text)+len(s.Regex))
+	for _, t := range s.Text {
+		terms = append(terms, fmt.Sprintf("%q", t))
+	}
+	for _, r := range s.Regex {
+		terms = append(terms, "/"+r+"/")
+	}
+	return "assertTextNotContains: " + strings.Join(terms, ", ")
+}
+
+// Describe returns a human-readable description of the set preference step.
+func (s *SetPreferenceStep) Describe() string {
+	return fmt.Sprintf("setPreference: %s = %s", s.Key, s.Value)
+}
+
+// Describe returns a human-readable description of the wait for install step.
+func (s *WaitForInstallStep) Describe() string {
+	return "waitForInstall: " + s.AppID
+}
+
+// Describe returns a human-readable description of the wait for download step.
+func (s *WaitForDownloadStep) Describe() string {
+	return "waitForDownload: " + s.File
+}
+
+// Describe returns a human-readable description of the wait for text step.
+func (s *WaitForTextStep) Describe() string {
+	return "waitForText: " + s.Element.DescribeQuoted()
+}
+
 // Describe returns a human-readable description of the scroll until visible step.
 func (s *ScrollUntilVisibleStep) Describe() string {
 	return "scrollUntilVisible: " + s.Element.DescribeQuoted()
 }
 
+// Describe returns a human-readable description of the scroll to position step.
+func (s *ScrollToPositionStep) Describe() string {
+	return "scrollToPosition: " + s.Position
+}
+
 // Describe returns a human-readable description of the copy text step.
 func (s *CopyTextFromStep) Describe() string {
 	return "copyTextFrom: " + s.Selector.DescribeQuoted()
@@ -589,6 +1525,22 @@ func (s *RunFlowStep) Describe() string {
 	return "runFlow"
 }
 
+// Describe returns a human-readable description of the if step.
+func (s *IfStep) Describe() string {
+	switch {
+	case s.Condition.Visible != nil:
+		return "if visible: " + s.Condition.Visible.DescribeQuoted()
+	case s.Condition.NotVisible != nil:
+		return "if notVisible: " + s.Condition.NotVisible.DescribeQuoted()
+	case s.Condition.Script != "":
+		return "if: " + s.Condition.Script
+	case s.Condition.Platform != "":
+		return "if platform: " + s.Condition.Platform
+	default:
+		return "if"
+	}
+}
+
 // Describe returns a human-readable description of the press key step.
 func (s *PressKeyStep) Describe() string {
 	return "pressKey: " + s.Key
@@ -604,10 +1556,14 @@ func (s *SwipeStep) Describe() string {
 
 // Describe returns a human-readable description of the scroll step.
 func (s *ScrollStep) Describe() string {
+	desc := "scroll"
 	if s.Direction != "" {
-		return "scroll: " + s.Direction
+		desc += ": " + s.Direction
+	}
+	if s.Amount != "" {
+		desc += " by " + s.Amount
 	}
-	return "scroll"
+	return desc
 }
 
 // Describe returns a human-readable description of the set permissions step.
@@ -1,6 +1,9 @@
 package flow
 
-import "testing"
+import (
+	"testing"
+	"time"
+)
 
 func TestBaseStep_Type(t *testing.T) {
 	b := BaseStep{StepType: StepTapOn}
@@ -49,6 +52,18 @@ func TestBaseStep_Label(t *testing.T) {
 	}
 }
 
+func TestBaseStep_RetryPolicy(t *testing.T) {
+	b := BaseStep{Retry: 2, RetryDelayMs: 250}
+	if retries, delayMs := b.RetryPolicy(); retries != 2 || delayMs != 250 {
+		t.Errorf("RetryPolicy() = %d, %d, want 2, 250", retries, delayMs)
+	}
+
+	b = BaseStep{Retry: -1, RetryDelayMs: -5}
+	if retries, delayMs := b.RetryPolicy(); retries != 0 || delayMs != 0 {
+		t.Errorf("RetryPolicy() with negatives = %d, %d, want 0, 0", retries, delayMs)
+	}
+}
+
 func TestBaseStep_Describe(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -89,6 +104,7 @@ func TestStepInterface(t *testing.T) {
 		&DoubleTapOnStep{BaseStep: BaseStep{StepType: StepDoubleTapOn}},
 		&LongPressOnStep{BaseStep: BaseStep{StepType: StepLongPressOn}},
 		&TapOnPointStep{BaseStep: BaseStep{StepType: StepTapOnPoint}},
+		&TapSequenceStep{BaseStep: BaseStep{StepType: StepTapSequence}},
 		&SwipeStep{BaseStep: BaseStep{StepType: StepSwipe}},
 		&ScrollStep{BaseStep: BaseStep{StepType: StepScroll}},
 		&ScrollUntilVisibleStep{BaseStep: BaseStep{StepType: StepScrollUntilVisible}},
@@ -96,6 +112,7 @@ func TestStepInterface(t *testing.T) {
 		&HideKeyboardStep{BaseStep: BaseStep{StepType: StepHideKeyboard}},
 		&AcceptAlertStep{BaseStep: BaseStep{StepType: StepAcceptAlert}},
 		&DismissAlertStep{BaseStep: BaseStep{StepType: StepDismissAlert}},
+		&AssertAlertTextStep{BaseStep: BaseStep{StepType: StepAssertAlertText}},
 		&InputTextStep{BaseStep: BaseStep{StepType: StepInputText}},
 		&InputRandomStep{BaseStep: BaseStep{StepType: StepInputRandom}},
 		&EraseTextStep{BaseStep: BaseStep{StepType: StepEraseText}},
@@ -110,6 +127,9 @@ func TestStepInterface(t *testing.T) {
 		&AssertWithAIStep{BaseStep: BaseStep{StepType: StepAssertWithAI}},
 		&ExtractTextWithAIStep{BaseStep: BaseStep{StepType: StepExtractTextWithAI}},
 		&WaitUntilStep{BaseStep: BaseStep{StepType: StepWaitUntil}},
+		&AssertResourceStep{BaseStep: BaseStep{StepType: StepAssertResource}},
+		&AssertSortedStep{BaseStep: BaseStep{StepType: StepAssertSorted}},
+		&AssertFileExistsStep{BaseStep: BaseStep{StepType: StepAssertFileExists}},
 		&LaunchAppStep{BaseStep: BaseStep{StepType: StepLaunchApp}},
 		&StopAppStep{BaseStep: BaseStep{StepType: StepStopApp}},
 		&KillAppStep{BaseStep: BaseStep{StepType: StepKillApp}},
@@ -123,7 +143,11 @@ func TestStepInterface(t *testing.T) {
 		&TravelStep{BaseStep: BaseStep{StepType: StepTravel}},
 		&OpenLinkStep{BaseStep: BaseStep{StepType: StepOpenLink}},
 		&OpenBrowserStep{BaseStep: BaseStep{StepType: StepOpenBrowser}},
+		&ClearNotificationsStep{BaseStep: BaseStep{StepType: StepClearNotifications}},
+		&EnsureUnlockedStep{BaseStep: BaseStep{StepType: StepEnsureUnlocked}},
+		&SetAppearanceStep{BaseStep: BaseStep{StepType: StepSetAppearance}},
 		&RepeatStep{BaseStep: BaseStep{StepType: StepRepeat}},
+		&IfStep{BaseStep: BaseStep{StepType: StepIf}},
 		&RetryStep{BaseStep: BaseStep{StepType: StepRetry}},
 		&RunFlowStep{BaseStep: BaseStep{StepType: StepRunFlow}},
 		&RunScriptStep{BaseStep: BaseStep{StepType: StepRunScript}},
@@ -134,6 +158,21 @@ func TestStepInterface(t *testing.T) {
 		&AddMediaStep{BaseStep: BaseStep{StepType: StepAddMedia}},
 		&PressKeyStep{BaseStep: BaseStep{StepType: StepPressKey}},
 		&WaitForAnimationToEndStep{BaseStep: BaseStep{StepType: StepWaitForAnimationToEnd}},
+		&WaitForDownloadStep{BaseStep: BaseStep{StepType: StepWaitForDownload}},
+		&WaitForTextStep{BaseStep: BaseStep{StepType: StepWaitForText}},
+		&WaitForInstallStep{BaseStep: BaseStep{StepType: StepWaitForInstall}},
+		&GesturePathStep{BaseStep: BaseStep{StepType: StepGesturePath}},
+		&TwoFingerSwipeStep{BaseStep: BaseStep{StepType: StepTwoFingerSwipe}},
+		&SetAppLocaleStep{BaseStep: BaseStep{StepType: StepSetAppLocale}},
+		&AssertFieldValueStep{BaseStep: BaseStep{StepType: StepAssertFieldValue}},
+		&SetPreferenceStep{BaseStep: BaseStep{StepType: StepSetPreference}},
+		&AssertShareTargetStep{BaseStep: BaseStep{StepType: StepAssertShareTarget}},
+		&SearchStep{BaseStep: BaseStep{StepType: StepSearch}},
+		&AssertAccessibleStep{BaseStep: BaseStep{StepType: StepAssertAccessible}},
+		&AssertNoJankStep{BaseStep: BaseStep{StepType: StepAssertNoJank}},
+		&AssertThemeColorStep{BaseStep: BaseStep{StepType: StepAssertThemeColor}},
+		&AssertScreenshotStep{BaseStep: BaseStep{StepType: StepAssertScreenshot}},
+		&AssertTextNotContainsStep{BaseStep: BaseStep{StepType: StepAssertTextNotContains}},
 		&DefineVariablesStep{BaseStep: BaseStep{StepType: StepDefineVariables}},
 		&UnsupportedStep{BaseStep: BaseStep{StepType: "unknown"}, Reason: "test"},
 	}
@@ -200,6 +239,28 @@ func TestTapOnStep_Fields(t *testing.T) {
 	}
 }
 
+func TestTapOnStep_TapLimit(t *testing.T) {
+	on, off := true, false
+	tests := []struct {
+		name string
+		step TapOnStep
+		want int
+	}{
+		{"retry unset", TapOnStep{MaxTaps: 5}, 1},
+		{"retry disabled", TapOnStep{RetryTapIfNoChange: &off, MaxTaps: 5}, 1},
+		{"retry default", TapOnStep{RetryTapIfNoChange: &on}, DefaultMaxTaps},
+		{"retry with maxTaps", TapOnStep{RetryTapIfNoChange: &on, MaxTaps: 4}, 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.step.TapLimit(); got != tt.want {
+				t.Errorf("TapLimit()=%d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestSwipeStep_Fields(t *testing.T) {
 	s := SwipeStep{
 		BaseStep:              BaseStep{StepType: StepSwipe},
@@ -251,7 +312,7 @@ func TestScrollUntilVisibleStep_Fields(t *testing.T) {
 		Element:               Selector{Text: "End of list"},
 		Direction:             "DOWN",
 		MaxScrolls:            20,
-		Speed:                 40,
+		Speed:                 "40",
 		VisibilityPercentage:  80,
 		CenterElement:         true,
 		WaitToSettleTimeoutMs: 100,
@@ -266,8 +327,8 @@ func TestScrollUntilVisibleStep_Fields(t *testing.T) {
 	if s.MaxScrolls != 20 {
 		t.Errorf("MaxScrolls=%d, want 20", s.MaxScrolls)
 	}
-	if s.Speed != 40 {
-		t.Errorf("Speed=%d, want 40", s.Speed)
+	if s.Speed != "40" {
+		t.Errorf("Speed=%q, want 40", s.Speed)
 	}
 	if s.VisibilityPercentage != 80 {
 		t.Errorf("VisibilityPercentage=%d, want 80", s.VisibilityPercentage)
@@ -533,6 +594,60 @@ func TestAssertVisibleStep_Describe(t *testing.T) {
 	}
 }
 
+func TestAssertVisibleStep_CheckCount(t *testing.T) {
+	five := 5
+	tests := []struct {
+		name       string
+		comparator string
+		found      int
+		wantErr    string
+	}{
+		{"exact match", "", 5, ""},
+		{"exact mismatch", "==", 3, "expected 5 'row' elements, found 3"},
+		{"at least met", ">=", 7, ""},
+		{"at least missed", ">=", 3, "expected at least 5 'row' elements, found 3"},
+		{"at most met", "<=", 0, ""},
+		{"at most missed", "<=", 6, "expected at most 5 'row' elements, found 6"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := AssertVisibleStep{
+				Selector:        Selector{Text: "row"},
+				Count:           &five,
+				CountComparator: tt.comparator,
+			}
+			err := s.CheckCount(tt.found)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("CheckCount(%d) = %v, want nil", tt.found, err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("CheckCount(%d) = %v, want %q", tt.found, err, tt.wantErr)
+			}
+		})
+	}
+
+	if err := (&AssertVisibleStep{}).CheckCount(3); err != nil {
+		t.Errorf("CheckCount without count = %v, want nil", err)
+	}
+}
+
+func TestAssertVisibleStep_ValidateCount(t *testing.T) {
+	negative := -1
+	if err := (&AssertVisibleStep{Count: &negative}).ValidateCount(); err == nil {
+		t.Error("expected error for negative count")
+	}
+	if err := (&AssertVisibleStep{CountComparator: ">"}).ValidateCount(); err == nil {
+		t.Error("expected error for unknown comparator")
+	}
+	if err := (&AssertVisibleStep{CountComparator: "<="}).ValidateCount(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
 func TestAssertNotVisibleStep_Describe(t *testing.T) {
 	s := AssertNotVisibleStep{
 		BaseStep: BaseStep{StepType: StepAssertNotVisible},
@@ -626,6 +741,18 @@ func TestWaitUntilStep_Describe(t *testing.T) {
 	}
 }
 
+func TestExtendedWaitUntilStep_Describe(t *testing.T) {
+	step := &ExtendedWaitUntilStep{
+		BaseStep:   BaseStep{StepType: StepWaitUntil},
+		Visible:    []Selector{{Text: "Welcome"}, {ID: "avatar"}},
+		NotVisible: []Selector{{Text: "Loading"}},
+	}
+	want := `extendedWaitUntil: visible text="Welcome", id="avatar"; notVisible text="Loading"`
+	if got := step.Describe(); got != want {
+		t.Errorf("Describe() = %q, want %q", got, want)
+	}
+}
+
 func TestScrollUntilVisibleStep_Describe(t *testing.T) {
 	s := ScrollUntilVisibleStep{
 		BaseStep: BaseStep{StepType: StepScrollUntilVisible},
@@ -637,6 +764,17 @@ func TestScrollUntilVisibleStep_Describe(t *testing.T) {
 	}
 }
 
+func TestScrollToPositionStep_Describe(t *testing.T) {
+	s := ScrollToPositionStep{
+		BaseStep: BaseStep{StepType: StepScrollToPosition},
+		Position: "50%",
+	}
+	expected := "scrollToPosition: 50%"
+	if got := s.Describe(); got != expected {
+		t.Errorf("Describe() = %q, want %q", got, expected)
+	}
+}
+
 func TestCopyTextFromStep_Describe(t *testing.T) {
 	s := CopyTextFromStep{
 		BaseStep: BaseStep{StepType: StepCopyTextFrom},
@@ -680,6 +818,63 @@ func TestRunFlowStep_Describe(t *testing.T) {
 	}
 }
 
+func TestAssertAlertTextStep_Describe(t *testing.T) {
+	if got := (&AssertAlertTextStep{Text: "Allow?"}).Describe(); got != `assertAlertText: "Allow?"` {
+		t.Errorf("Describe() = %q", got)
+	}
+	if got := (&AssertAlertTextStep{Contains: "Allow"}).Describe(); got != `assertAlertText contains: "Allow"` {
+		t.Errorf("Describe() = %q", got)
+	}
+}
+
+func TestAssertResourceStep_Describe(t *testing.T) {
+	s := AssertResourceStep{MaxMemoryMB: 300, MaxCPUPercent: 50}
+	expected := "assertResource: memory < 300MB, cpu < 50%"
+	if got := s.Describe(); got != expected {
+		t.Errorf("Describe() = %q, want %q", got, expected)
+	}
+}
+
+func TestAssertSortedStep_Describe(t *testing.T) {
+	s := AssertSortedStep{Element: Selector{ID: "price"}, Order: "descending"}
+	expected := `assertSorted descending: id="price"`
+	if got := s.Describe(); got != expected {
+		t.Errorf("Describe() = %q, want %q", got, expected)
+	}
+}
+
+func TestIfStep_Describe(t *testing.T) {
+	tests := []struct {
+		name     string
+		step     IfStep
+		expected string
+	}{
+		{
+			name:     "visible",
+			step:     IfStep{Condition: Condition{Visible: &Selector{Text: "Login"}}},
+			expected: `if visible: text="Login"`,
+		},
+		{
+			name:     "script",
+			step:     IfStep{Condition: Condition{Script: "${loggedIn}"}},
+			expected: "if: ${loggedIn}",
+		},
+		{
+			name:     "platform",
+			step:     IfStep{Condition: Condition{Platform: "iOS"}},
+			expected: "if platform: iOS",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.step.Describe(); got != tt.expected {
+				t.Errorf("Describe() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
 func TestPressKeyStep_Describe(t *testing.T) {
 	s := PressKeyStep{
 		BaseStep: BaseStep{StepType: StepPressKey},
@@ -744,6 +939,15 @@ func TestScrollStep_Describe(t *testing.T) {
 			},
 			expected: "scroll",
 		},
+		{
+			name: "with amount",
+			step: ScrollStep{
+				BaseStep:  BaseStep{StepType: StepScroll},
+				Direction: "DOWN",
+				Amount:    "30%",
+			},
+			expected: "scroll: DOWN by 30%",
+		},
 	}
 
 	for _, tt := range tests {
@@ -755,6 +959,117 @@ func TestScrollStep_Describe(t *testing.T) {
 	}
 }
 
+func TestScrollStep_AmountFraction(t *testing.T) {
+	tests := []struct {
+		amount  string
+		want    float64
+		wantOK  bool
+		wantErr bool
+	}{
+		{"", 0, false, false},
+		{"30%", 0.3, true, false},
+		{" 25 % ", 0.25, true, false},
+		{"0.5", 0.5, true, false},
+		{"100%", 1, true, false},
+		{"0%", 0, false, true},
+		{"150%", 0, false, true},
+		{"lots", 0, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.amount, func(t *testing.T) {
+			s := ScrollStep{Amount: tt.amount}
+			got, ok, err := s.AmountFraction()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("AmountFraction() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("AmountFraction() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestScrollUntilVisibleStep_VisibilityThreshold(t *testing.T) {
+	tests := []struct {
+		pct  int
+		want int
+	}{
+		{0, DefaultVisibilityPercentage},
+		{-5, DefaultVisibilityPercentage},
+		{80, 80},
+		{150, 100},
+	}
+
+	for _, tt := range tests {
+		s := ScrollUntilVisibleStep{VisibilityPercentage: tt.pct}
+		if got := s.VisibilityThreshold(); got != tt.want {
+			t.Errorf("VisibilityThreshold() with %d = %d, want %d", tt.pct, got, tt.want)
+		}
+	}
+}
+
+func TestScrollUntilVisibleStep_ScrollProfile(t *testing.T) {
+	tests := []struct {
+		speed   string
+		want    ScrollProfile
+		wantErr bool
+	}{
+		{"", scrollProfiles[ScrollSpeedNormal], false},
+		{"slow", scrollProfiles[ScrollSpeedSlow], false},
+		{"FAST", scrollProfiles[ScrollSpeedFast], false},
+		{"10", scrollProfiles[ScrollSpeedSlow], false},
+		{"40", scrollProfiles[ScrollSpeedNormal], false},
+		{"90", scrollProfiles[ScrollSpeedFast], false},
+		{"101", ScrollProfile{}, true},
+		{"warp", ScrollProfile{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.speed, func(t *testing.T) {
+			s := ScrollUntilVisibleStep{Speed: tt.speed}
+			got, err := s.ScrollProfile()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ScrollProfile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ScrollProfile() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTravelStep_Waypoints(t *testing.T) {
+	s := &TravelStep{Points: []string{"37.7749, -122.4194", "malformed", "1,2,3", " 37.8049 ,-122.4094 "}}
+
+	got := s.Waypoints()
+	want := []TravelWaypoint{{Lat: "37.7749", Lon: "-122.4194"}, {Lat: "37.8049", Lon: "-122.4094"}}
+	if len(got) != len(want) {
+		t.Fatalf("Waypoints() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Waypoints()[%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestTravelStep_PointDelay(t *testing.T) {
+	tests := []struct {
+		speed float64
+		want  time.Duration
+	}{
+		{0, 72 * time.Second},
+		{3600, time.Second},
+		{100, 36 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := (&TravelStep{Speed: tt.speed}).PointDelay(); got != tt.want {
+			t.Errorf("PointDelay() with speed %v = %v, want %v", tt.speed, got, tt.want)
+		}
+	}
+}
+
 func TestSetPermissionsStep_Describe(t *testing.T) {
 	s := SetPermissionsStep{
 		BaseStep:    BaseStep{StepType: StepSetPermissions},
@@ -773,13 +1088,16 @@ func TestStepTypeConstants(t *testing.T) {
 		StepDoubleTapOn:           "doubleTapOn",
 		StepLongPressOn:           "longPressOn",
 		StepTapOnPoint:            "tapOnPoint",
+		StepTapSequence:           "tapSequence",
 		StepSwipe:                 "swipe",
 		StepScroll:                "scroll",
 		StepScrollUntilVisible:    "scrollUntilVisible",
+		StepScrollToPosition:      "scrollToPosition",
 		StepBack:                  "back",
 		StepHideKeyboard:          "hideKeyboard",
 		StepAcceptAlert:           "acceptAlert",
 		StepDismissAlert:          "dismissAlert",
+		StepAssertAlertText:       "assertAlertText",
 		StepInputText:             "inputText",
 		StepInputRandom:           "inputRandom",
 		StepEraseText:             "eraseText",
@@ -794,6 +1112,9 @@ func TestStepTypeConstants(t *testing.T) {
 		StepAssertWithAI:          "assertWithAI",
 		StepExtractTextWithAI:     "extractTextWithAI",
 		StepWaitUntil:             "extendedWaitUntil",
+		StepAssertResource:        "assertResource",
+		StepAssertSorted:          "assertSorted",
+		StepAssertFileExists:      "assertFileExists",
 		StepLaunchApp:             "launchApp",
 		StepStopApp:               "stopApp",
 		StepKillApp:               "killApp",
@@ -807,7 +1128,11 @@ func TestStepTypeConstants(t *testing.T) {
 		StepTravel:                "travel",
 		StepOpenLink:              "openLink",
 		StepOpenBrowser:           "openBrowser",
+		StepClearNotifications:    "clearNotifications",
+		StepSetAppearance:         "setAppearance",
+		StepEnsureUnlocked:        "ensureUnlocked",
 		StepRepeat:                "repeat",
+		StepIf:                    "if",
 		StepRetry:                 "retry",
 		StepRunFlow:               "runFlow",
 		StepRunScript:             "runScript",
@@ -818,6 +1143,21 @@ func TestStepTypeConstants(t *testing.T) {
 		StepAddMedia:              "addMedia",
 		StepPressKey:              "pressKey",
 		StepWaitForAnimationToEnd: "waitForAnimationToEnd",
+		StepWaitForDownload:       "waitForDownload",
+		StepWaitForText:           "waitForText",
+		StepWaitForInstall:        "waitForInstall",
+		StepGesturePath:           "gesturePath",
+		StepTwoFingerSwipe:        "twoFingerSwipe",
+		StepSetAppLocale:          "setAppLocale",
+		StepAssertFieldValue:      "assertFieldValue",
+		StepSetPreference:         "setPreference",
+		StepAssertShareTarget:     "assertShareTarget",
+		StepSearch:                "search",
+		StepAssertAccessible:      "assertAccessible",
+		StepAssertNoJank:          "assertNoJank",
+		StepAssertThemeColor:      "assertThemeColor",
+		StepAssertScreenshot:      "assertScreenshot",
+		StepAssertTextNotContains: "assertTextNotContains",
 		StepDefineVariables:       "defineVariables",
 	}
 
@@ -827,3 +1167,43 @@ func TestStepTypeConstants(t *testing.T) {
 		}
 	}
 }
+
+func TestAssertNotVisibleStep_Window(t *testing.T) {
+	s := AssertNotVisibleStep{}
+	if got := s.Window(); got != DefaultNotVisibleWindowMs {
+		t.Errorf("expected default window %d, got %d", DefaultNotVisibleWindowMs, got)
+	}
+	s.TimeoutMs = 3000
+	if got := s.Window(); got != 3000 {
+		t.Errorf("expected step timeout as window, got %d", got)
+	}
+}
+
+func TestSetPreferenceStep_Typed(t *testing.T) {
+	tests := []struct {
+		typ, value        string
+		wantType, wantVal string
+		wantErr           bool
+	}{
+		{"", "jane", PrefString, "jane", false},
+		{"bool", "TRUE", PrefBoolean, "true", false},
+		{"boolean", "yes", "", "", true},
+		{"int", " 42 ", PrefInt, "42", false},
+		{"int", "4000000000", "", "", true},
+		{"long", "4000000000", PrefLong, "4000000000", false},
+		{"float", "1.5", PrefFloat, "1.5", false},
+		{"date", "2024-01-01", "", "", true},
+	}
+
+	for _, tt := range tests {
+		s := SetPreferenceStep{Key: "k", ValueType: tt.typ, Value: tt.value}
+		typ, val, err := s.Typed()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Typed(%q, %q) error = %v, wantErr %v", tt.typ, tt.value, err, tt.wantErr)
+			continue
+		}
+		if typ != tt.wantType || val != tt.wantVal {
+			t.Errorf("Typed(%q, %q) = (%q, %q), want (%q, %q)", tt.typ, tt.value, typ, val, tt.wantType, tt.wantVal)
+		}
+	}
+}
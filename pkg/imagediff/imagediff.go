@@ -0,0 +1,80 @@
+// Package imagediff compares encoded screenshots pixel by pixel.
+package imagediff
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+
+	// Screenshots arrive as PNG (UIAutomator2, WDA) or JPEG (some Appium setups).
+	_ "image/jpeg"
+	_ "image/png"
+)
+
+// channelThreshold ignores per-channel differences below ~8/255, which
+// covers compression noise between otherwise identical images.
+const channelThreshold = 0x0800
+
+// mark is the color Diff paints over differing pixels.
+var mark = color.RGBA{R: 0xff, A: 0xff}
+
+// Diff returns the fraction of pixels (0 to 1) that differ between two
+// encoded images, ignoring compression noise, and a diff image showing
+// differing pixels in red over a faded copy of a. Images of different sizes
+// differ entirely and have no diff image. An error is returned, with a diff
+// of 1, when either image cannot be decoded.
+func Diff(a, b []byte) (float64, image.Image, error) {
+	imgA, _, err := image.Decode(bytes.NewReader(a))
+	if err != nil {
+		return 1, nil, fmt.Errorf("decode image: %w", err)
+	}
+	imgB, _, err := image.Decode(bytes.NewReader(b))
+	if err != nil {
+		return 1, nil, fmt.Errorf("decode image: %w", err)
+	}
+
+	bounds := imgA.Bounds()
+	if bounds.Size() != imgB.Bounds().Size() {
+		return 1, nil, nil
+	}
+	offset := imgB.Bounds().Min.Sub(bounds.Min)
+
+	out := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	changed := 0
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			ca := imgA.At(x, y)
+			if PixelsDiffer(ca, imgB.At(x+offset.X, y+offset.Y)) {
+				changed++
+				out.Set(x-bounds.Min.X, y-bounds.Min.Y, mark)
+				continue
+			}
+			// Fade unchanged pixels toward white so the marks stand out
+			r, g, bl, _ := ca.RGBA()
+			out.Set(x-bounds.Min.X, y-bounds.Min.Y, color.RGBA{
+				R: uint8(0xbf + (r>>8)/4), G: uint8(0xbf + (g>>8)/4), B: uint8(0xbf + (bl>>8)/4), A: 0xff,
+			})
+		}
+	}
+	total := bounds.Dx() * bounds.Dy()
+	if total == 0 {
+		return 0, out, nil
+	}
+	return float64(changed) / float64(total), out, nil
+}
+
+// PixelsDiffer reports whether any color channel of a and b differs by more
+// than compression noise.
+func PixelsDiffer(a, b color.Color) bool {
+	r1, g1, b1, _ := a.RGBA()
+	r2, g2, b2, _ := b.RGBA()
+	return channelDiff(r1, r2) > channelThreshold || channelDiff(g1, g2) > channelThreshold || channelDiff(b1, b2) > channelThreshold
+}
+
+func channelDiff(a, b uint32) uint32 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
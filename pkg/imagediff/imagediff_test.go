@@ -0,0 +1,60 @@
+package imagediff
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func testImage(t *testing.T, w, h int, fill color.Color, marks ...image.Point) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, fill)
+		}
+	}
+	for _, p := range marks {
+		img.Set(p.X, p.Y, color.Black)
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDiff(t *testing.T) {
+	white := color.RGBA{255, 255, 255, 255}
+	base := testImage(t, 10, 10, white)
+
+	diff, img, err := Diff(base, testImage(t, 10, 10, white, image.Pt(1, 1), image.Pt(2, 2)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff != 0.02 {
+		t.Errorf("expected diff 0.02, got %v", diff)
+	}
+	if got := color.RGBAModel.Convert(img.At(1, 1)); got != mark {
+		t.Errorf("expected changed pixel marked, got %v", got)
+	}
+	if got := color.RGBAModel.Convert(img.At(0, 0)); got == mark {
+		t.Errorf("expected unchanged pixel unmarked")
+	}
+
+	diff, _, err = Diff(base, testImage(t, 10, 10, color.RGBA{252, 253, 255, 255}))
+	if err != nil || diff != 0 {
+		t.Errorf("expected compression noise ignored, got %v %v", diff, err)
+	}
+
+	diff, img, err = Diff(base, testImage(t, 10, 12, white))
+	if err != nil || diff != 1 || img != nil {
+		t.Errorf("expected size mismatch to differ entirely, got %v %v %v", diff, img, err)
+	}
+
+	if _, _, err := Diff(base, []byte("not a png")); err == nil {
+		t.Error("expected decode error")
+	}
+}
@@ -0,0 +1,174 @@
+package jsengine
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dop251/goja/ast"
+	"github.com/dop251/goja/parser"
+	"github.com/dop251/goja/token"
+)
+
+// compareFuncName is the global that CoerceComparisons routes comparisons through.
+const compareFuncName = "__maestroCompare"
+
+// compareFuncSource compares two values with a relational or loose equality
+// operator. When both are numbers or numeric-looking strings ("007", "-1.5",
+// "1e3") they compare as numbers, so "10" > "9" and "007" == 7; anything else
+// keeps plain JS semantics, so non-numeric strings compare as strings.
+// Strict equality is never routed here and keeps comparing types.
+const compareFuncSource = `(function() {
+	var numeric = /^\s*[-+]?(\d+\.?\d*|\.\d+)([eE][-+]?\d+)?\s*$/;
+	function toNumber(v) {
+		if (typeof v === 'number') return v;
+		if (typeof v === 'string' && numeric.test(v)) return Number(v);
+		return undefined;
+	}
+	return function(a, op, b) {
+		var x = toNumber(a), y = toNumber(b);
+		if (x !== undefined && y !== undefined) { a = x; b = y; }
+		switch (op) {
+		case '<': return a < b;
+		case '>': return a > b;
+		case '<=': return a <= b;
+		case '>=': return a >= b;
+		case '==': return a == b;
+		case '!=': return a != b;
+		}
+		throw new TypeError('unknown comparison ' + op);
+	};
+})()`
+
+// setupCompare registers the comparison helper used by CoerceComparisons.
+func (e *Engine) setupCompare() error {
+	fn, err := e.runtime.RunString(compareFuncSource)
+	if err != nil {
+		return err
+	}
+	return e.runtime.Set(compareFuncName, fn)
+}
+
+// CoerceComparisons rewrites every <, >, <=, >=, == and != in a
+// single-expression condition into a call to the comparison helper, so
+// numeric-looking string variables compare as numbers; === and !== stay
+// strict. Comparisons inside object literals, tagged templates and function
+// bodies are left as written. Scripts it cannot parse, or whose rewrite does
+// not parse, are returned unchanged.
+func CoerceComparisons(script string) string {
+	prog, err := parser.ParseFile(nil, "", script, 0)
+	if err != nil || len(prog.Body) != 1 {
+		return script
+	}
+	stmt, ok := prog.Body[0].(*ast.ExpressionStatement)
+	if !ok {
+		return script
+	}
+
+	r := comparisonRewriter{src: script}
+	out := r.render(stmt.Expression)
+	if !r.changed {
+		return script
+	}
+	if _, err := parser.ParseFile(nil, "", out, 0); err != nil {
+		return script
+	}
+	return out
+}
+
+// comparisonRewriter renders an expression back to source with comparisons
+// replaced. Operands are wrapped in parentheses so precedence is kept; nodes
+// it does not know are copied from the source as-is.
+type comparisonRewriter struct {
+	src     string
+	changed bool
+}
+
+func (r *comparisonRewriter) raw(n ast.Node) string {
+	start, end := int(n.Idx0())-1, int(n.Idx1())-1
+	if start < 0 || end > len(r.src) || start > end {
+		return ""
+	}
+	return r.src[start:end]
+}
+
+func (r *comparisonRewriter) wrap(n ast.Expression) string {
+	return "(" + r.render(n) + ")"
+}
+
+func (r *comparisonRewriter) render(n ast.Expression) string {
+	switch n := n.(type) {
+	case *ast.BinaryExpression:
+		if isComparison(n.Operator) {
+			r.changed = true
+			return fmt.Sprintf("%s(%s, %q, %s)", compareFuncName, r.wrap(n.Left), n.Operator.String(), r.wrap(n.Right))
+		}
+		return r.wrap(n.Left) + " " + n.Operator.String() + " " + r.wrap(n.Right)
+	case *ast.UnaryExpression:
+		if n.Postfix || n.Operator == token.INCREMENT || n.Operator == token.DECREMENT {
+			return r.raw(n)
+		}
+		return n.Operator.String() + " " + r.wrap(n.Operand)
+	case *ast.ConditionalExpression:
+		return r.wrap(n.Test) + " ? " + r.wrap(n.Consequent) + " : " + r.wrap(n.Alternate)
+	case *ast.DotExpression:
+		return r.wrap(n.Left) + "." + string(n.Identifier.Name)
+	case *ast.BracketExpression:
+		return r.wrap(n.Left) + "[" + r.render(n.Member) + "]"
+	case *ast.CallExpression:
+		return r.wrap(n.Callee) + "(" + r.list(n.ArgumentList) + ")"
+	case *ast.NewExpression:
+		return "new " + r.wrap(n.Callee) + "(" + r.list(n.ArgumentList) + ")"
+	case *ast.ArrayLiteral:
+		items := r.list(n.Value)
+		if len(n.Value) > 0 && n.Value[len(n.Value)-1] == nil {
+			items += "," // keep a trailing hole
+		}
+		return "[" + items + "]"
+	case *ast.TemplateLiteral:
+		if n.Tag != nil {
+			return r.raw(n)
+		}
+		var b strings.Builder
+		b.WriteString("`")
+		for i, el := range n.Elements {
+			b.WriteString(el.Literal)
+			if i < len(n.Expressions) {
+				b.WriteString("${" + r.render(n.Expressions[i]) + "}")
+			}
+		}
+		b.WriteString("`")
+		return b.String()
+	case *ast.SequenceExpression:
+		items := make([]string, len(n.Sequence))
+		for i, item := range n.Sequence {
+			items[i] = r.wrap(item)
+		}
+		return strings.Join(items, ", ")
+	default:
+		return r.raw(n)
+	}
+}
+
+// list renders call arguments or array elements, keeping spreads and holes.
+func (r *comparisonRewriter) list(items []ast.Expression) string {
+	out := make([]string, len(items))
+	for i, item := range items {
+		switch item := item.(type) {
+		case nil:
+		case *ast.SpreadElement:
+			out[i] = "..." + r.wrap(item.Expression)
+		default:
+			out[i] = r.wrap(item)
+		}
+	}
+	return strings.Join(out, ", ")
+}
+
+func isComparison(op token.Token) bool {
+	switch op {
+	case token.LESS, token.GREATER, token.LESS_OR_EQUAL, token.GREATER_OR_EQUAL,
+		token.EQUAL, token.NOT_EQUAL:
+		return true
+	}
+	return false
+}
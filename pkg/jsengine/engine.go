@@ -3,6 +3,8 @@ package jsengine
 
 import (
 	"fmt"
+	"io"
+	"os"
 	"strings"
 	"sync"
 	"time"
@@ -18,10 +20,22 @@ type Engine struct {
 	output     map[string]interface{}
 	copiedText string
 	platform   string
+	copyText   CopyTextFunc
+	lastResult map[string]interface{}
 	timers     *timerRegistry
-	mu         sync.Mutex
+	console    io.Writer // console.log output (nil = stdout)
+
+	// require() state, see require.go
+	resolve     ResolveFunc
+	modules     map[string]*module
+	moduleStack []*module
+	mu          sync.Mutex
 }
 
+// CopyTextFunc reads the text of the element matched by selector, which is
+// the exported JS argument of maestro.copyTextFrom (a string or an object).
+type CopyTextFunc func(selector interface{}) (string, error)
+
 // timerRegistry manages setTimeout/setInterval timers
 type timerRegistry struct {
 	timers    map[int]*time.Timer
@@ -81,6 +95,28 @@ func (e *Engine) setupBuiltins() {
 	if err := e.runtime.Set("maestro", e.maestroObject()); err != nil {
 		logger.Warn("failed to set JS runtime global 'maestro': %v", err)
 	}
+
+	// CommonJS require for helper files
+	if err := e.setupRequire(); err != nil {
+		logger.Warn("failed to set JS runtime global 'require': %v", err)
+	}
+
+	// Comparison helper for conditions (see CoerceComparisons)
+	if err := e.setupCompare(); err != nil {
+		logger.Warn("failed to set JS runtime global '%s': %v", compareFuncName, err)
+	}
+}
+
+// SetConsoleOutput sets where console.log and friends print.
+func (e *Engine) SetConsoleOutput(w io.Writer) {
+	e.console = w
+}
+
+func (e *Engine) consoleOutput() io.Writer {
+	if e.console == nil {
+		return os.Stdout
+	}
+	return e.console
 }
 
 // setupConsole adds console.log, console.error, etc.
@@ -93,9 +129,9 @@ func (e *Engine) setupConsole() {
 				args[i] = arg.Export()
 			}
 			if prefix != "" {
-				fmt.Println(prefix, args)
+				fmt.Fprintln(e.consoleOutput(), prefix, args)
 			} else {
-				fmt.Println(args...)
+				fmt.Fprintln(e.consoleOutput(), args...)
 			}
 			return goja.Undefined()
 		}
@@ -142,7 +178,7 @@ func (e *Engine) setupTimers() {
 			// Call the callback
 			_, err := callback(goja.Undefined())
 			if err != nil {
-				fmt.Printf("setTimeout callback error: %v\n", err)
+				logger.Warn("setTimeout callback error: %v", err)
 			}
 
 			// Clean up
@@ -210,7 +246,7 @@ func (e *Engine) setupTimers() {
 					e.mu.Lock()
 					_, err := callback(goja.Undefined())
 					if err != nil {
-						fmt.Printf("setInterval callback error: %v\n", err)
+						logger.Warn("setInterval callback error: %v", err)
 					}
 					e.mu.Unlock()
 				}
@@ -280,9 +316,54 @@ func (e *Engine) maestroObject() *goja.Object {
 		logger.Warn("failed to define maestro.platform: %v", err)
 	}
 
+	// maestro.lastResult - {success, message, data} of the preceding step, or null
+	if err := obj.DefineAccessorProperty("lastResult", e.runtime.ToValue(func() goja.Value {
+		if e.lastResult == nil {
+			return goja.Null()
+		}
+		return e.runtime.ToValue(e.lastResult)
+	}), nil, goja.FLAG_FALSE, goja.FLAG_TRUE); err != nil {
+		logger.Warn("failed to define maestro.lastResult: %v", err)
+	}
+
+	// maestro.randomEmail(), maestro.uuid(), ... - see stdlib.go
+	e.setupStdlib(obj)
+
+	// maestro.copyTextFrom(selector) - reads element text from the device
+	if err := obj.Set("copyTextFrom", e.copyTextFromFunc()); err != nil {
+		logger.Warn("failed to set maestro.copyTextFrom: %v", err)
+	}
+
 	return obj
 }
 
+// copyTextFromFunc returns maestro.copyTextFrom. It runs while a script holds
+// e.mu, so it updates copiedText without locking.
+func (e *Engine) copyTextFromFunc() func(call goja.FunctionCall) goja.Value {
+	return func(call goja.FunctionCall) goja.Value {
+		if len(call.Arguments) < 1 {
+			panic(e.runtime.NewTypeError("maestro.copyTextFrom requires a selector"))
+		}
+		if e.copyText == nil {
+			panic(e.runtime.NewTypeError("maestro.copyTextFrom is not available without a device"))
+		}
+
+		text, err := e.copyText(call.Arguments[0].Export())
+		if err != nil {
+			panic(e.runtime.NewGoError(err))
+		}
+		e.copiedText = text
+		return e.runtime.ToValue(text)
+	}
+}
+
+// SetCopyTextFunc sets the function behind maestro.copyTextFrom.
+func (e *Engine) SetCopyTextFunc(fn CopyTextFunc) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.copyText = fn
+}
+
 // SetVariable sets a variable accessible in JS as a global
 func (e *Engine) SetVariable(name string, value interface{}) {
 	e.mu.Lock()
@@ -315,6 +396,18 @@ func (e *Engine) GetCopiedText() string {
 	return e.copiedText
 }
 
+// SetLastResult sets maestro.lastResult. data is exposed as-is, so callers
+// should pass only script-friendly values (strings, numbers, maps).
+func (e *Engine) SetLastResult(success bool, message string, data interface{}) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.lastResult = map[string]interface{}{
+		"success": success,
+		"message": message,
+		"data":    data,
+	}
+}
+
 // SetPlatform sets the current platform
 func (e *Engine) SetPlatform(platform string) {
 	e.mu.Lock()
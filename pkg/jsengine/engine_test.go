@@ -1,6 +1,9 @@
 package jsengine
 
 import (
+	"os"
+	"path/filepath"
+	"regexp"
 	"strings"
 	"testing"
 	"time"
@@ -290,6 +293,233 @@ func TestMaestroObject(t *testing.T) {
 	}
 }
 
+func TestMaestroCopyTextFrom(t *testing.T) {
+	engine := New()
+	defer engine.Close()
+
+	if _, err := engine.Eval("maestro.copyTextFrom('price')"); err == nil {
+		t.Error("expected error without a copy text function")
+	}
+
+	var got interface{}
+	engine.SetCopyTextFunc(func(selector interface{}) (string, error) {
+		got = selector
+		return "$9.99", nil
+	})
+
+	result, err := engine.EvalString("maestro.copyTextFrom('price')")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result != "$9.99" {
+		t.Errorf("expected '$9.99', got %q", result)
+	}
+	if got != "price" {
+		t.Errorf("expected selector 'price', got %v", got)
+	}
+	if engine.GetCopiedText() != "$9.99" {
+		t.Errorf("expected copiedText '$9.99', got %q", engine.GetCopiedText())
+	}
+}
+
+func TestMaestroStdlib(t *testing.T) {
+	engine := New()
+	defer engine.Close()
+
+	tests := []struct {
+		script string
+		want   *regexp.Regexp
+	}{
+		{"maestro.randomEmail()", regexp.MustCompile(`^[a-zA-Z0-9]{8}@(example|test|mail)\.com$`)},
+		{"maestro.randomNumber(6)", regexp.MustCompile(`^[0-9]{6}$`)},
+		{"maestro.randomNumber()", regexp.MustCompile(`^[0-9]{10}$`)},
+		{"maestro.randomPersonName()", regexp.MustCompile(`^[A-Z][a-z]+ [A-Z][a-z]+$`)},
+		{"maestro.now()", regexp.MustCompile(`^[0-9]{13}$`)},
+		{"maestro.formatDate('YYYY-MM-DD')", regexp.MustCompile(`^[0-9]{4}-[0-9]{2}-[0-9]{2}$`)},
+		{"maestro.uuid()", regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.script, func(t *testing.T) {
+			got, err := engine.EvalString(tt.script)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !tt.want.MatchString(got) {
+				t.Errorf("%s = %q, want match for %s", tt.script, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := engine.Eval("maestro.randomNumber(0)"); err == nil {
+		t.Error("expected error for randomNumber(0)")
+	}
+}
+
+func TestFormatDate(t *testing.T) {
+	ts := time.Date(2024, time.March, 5, 9, 7, 3, 42*int(time.Millisecond), time.Local)
+
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{"YYYY-MM-DD", "2024-03-05"},
+		{"DD/MM/YY HH:mm:ss.SSS", "05/03/24 09:07:03.042"},
+		{"Day 1 of Mon", "Day 1 of Mon"},
+	}
+
+	for _, tt := range tests {
+		if got := formatDate(tt.format, ts); got != tt.want {
+			t.Errorf("formatDate(%q) = %q, want %q", tt.format, got, tt.want)
+		}
+	}
+
+	engine := New()
+	defer engine.Close()
+	engine.SetVariable("TS", ts.UnixMilli())
+	got, err := engine.EvalString("maestro.formatDate('YYYY-MM-DD HH:mm', TS)")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "2024-03-05 09:07" {
+		t.Errorf("formatDate with timestamp = %q, want %q", got, "2024-03-05 09:07")
+	}
+}
+
+func TestCoerceComparisons(t *testing.T) {
+	unchanged := []string{
+		"a && b",
+		"count +",
+		"var x = 1; x > 0",
+		"A === '10'",
+	}
+	for _, script := range unchanged {
+		if got := CoerceComparisons(script); got != script {
+			t.Errorf("CoerceComparisons(%q) = %q, want unchanged", script, got)
+		}
+	}
+
+	engine := New()
+	defer engine.Close()
+	engine.SetVariable("A", "10")
+	engine.SetVariable("B", "9")
+
+	tests := []struct {
+		script string
+		want   bool
+	}{
+		{"A > B", true},
+		{"!(A <= B)", true},
+		{"[A, B].length === 2 && A >= B", true},
+		{"typeof A === 'string'", true},
+		{"A.startsWith('1') && B == 9", true},
+		{"A !== 10 && A == 10", true},
+		{"[A > B, ...[B]].length == 2 && [A > B][0]", true},
+		{"`${A > B}` == 'true'", true},
+		{"new Boolean(A > B).valueOf()", true},
+		{"({ok: A > B}).ok", false},
+	}
+	for _, tt := range tests {
+		got, err := engine.Eval(CoerceComparisons(tt.script))
+		if err != nil {
+			t.Fatalf("Eval(%q) error: %v", tt.script, err)
+		}
+		if got != tt.want {
+			t.Errorf("Eval(%q) = %v, want %v", tt.script, got, tt.want)
+		}
+	}
+}
+
+func TestMaestroLastResult(t *testing.T) {
+	engine := New()
+	defer engine.Close()
+
+	got, err := engine.Eval("maestro.lastResult === null")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != true {
+		t.Error("expected lastResult to be null before any step")
+	}
+
+	engine.SetLastResult(false, "Element not found", "abc")
+	got, err = engine.Eval("!maestro.lastResult.success && maestro.lastResult.message === 'Element not found' && maestro.lastResult.data === 'abc'")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != true {
+		t.Error("expected lastResult to reflect the last SetLastResult call")
+	}
+}
+
+func writeModule(t *testing.T, dir, name, src string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRequire(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "lib"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeModule(t, dir, "helpers.js", `
+var fmt = require('./lib/format');
+exports.loads = (exports.loads || 0) + 1;
+exports.price = function(cents) { return fmt.currency(cents); };
+`)
+	writeModule(t, filepath.Join(dir, "lib"), "format.js", `
+module.exports = { currency: function(cents) { return '$' + (cents / 100).toFixed(2); } };
+`)
+
+	engine := New()
+	defer engine.Close()
+	engine.SetRequireResolver(func(path string) string { return filepath.Join(dir, path) })
+
+	result, err := engine.EvalString("require('./helpers.js').price(1999)")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result != "$19.99" {
+		t.Errorf("expected $19.99, got %q", result)
+	}
+
+	// Cached: the module body ran once
+	result, err = engine.EvalString("require('./helpers').loads")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result != "1" {
+		t.Errorf("expected helpers.js to load once, got %s loads", result)
+	}
+}
+
+func TestRequireCircular(t *testing.T) {
+	dir := t.TempDir()
+	writeModule(t, dir, "a.js", "require('./b.js'); exports.a = 1;")
+	writeModule(t, dir, "b.js", "require('./a.js'); exports.b = 1;")
+
+	engine := New()
+	defer engine.Close()
+	engine.SetRequireResolver(func(path string) string { return filepath.Join(dir, path) })
+
+	_, err := engine.Eval("require('./a.js')")
+	if err == nil || !strings.Contains(err.Error(), "circular require: a.js -> b.js -> a.js") {
+		t.Errorf("expected circular require error, got %v", err)
+	}
+}
+
+func TestRequireMissingFile(t *testing.T) {
+	engine := New()
+	defer engine.Close()
+	engine.SetRequireResolver(func(path string) string { return filepath.Join(t.TempDir(), path) })
+
+	if _, err := engine.Eval("require('./missing.js')"); err == nil {
+		t.Error("expected error for missing module")
+	}
+}
+
 func TestAsyncAwait(t *testing.T) {
 	engine := New()
 	defer engine.Close()
@@ -0,0 +1,131 @@
+package jsengine
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dop251/goja"
+)
+
+// ResolveFunc maps a path passed to require() by a top-level script to a
+// file path, e.g. relative to the flow directory.
+type ResolveFunc func(path string) string
+
+// module is a file loaded by require().
+type module struct {
+	path    string
+	exports goja.Value
+	loaded  bool
+}
+
+// SetRequireResolver sets how require() resolves paths from top-level scripts.
+// Paths required from inside a module resolve relative to that module's file.
+func (e *Engine) SetRequireResolver(fn ResolveFunc) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.resolve = fn
+}
+
+// setupRequire adds a minimal CommonJS require(): a module sees exports,
+// module, require, __filename and __dirname, its module.exports is cached by
+// absolute path, and a require cycle throws instead of looping.
+func (e *Engine) setupRequire() error {
+	e.modules = make(map[string]*module)
+	return e.runtime.Set("require", e.requireFunc(""))
+}
+
+// requireFunc returns require() for a module in dir; "" is the top level.
+func (e *Engine) requireFunc(dir string) func(call goja.FunctionCall) goja.Value {
+	return func(call goja.FunctionCall) goja.Value {
+		path := call.Argument(0)
+		if goja.IsUndefined(path) || path.String() == "" {
+			panic(e.runtime.NewTypeError("require requires a path"))
+		}
+		exports, err := e.require(path.String(), dir)
+		if err != nil {
+			panic(e.runtime.NewGoError(err))
+		}
+		return exports
+	}
+}
+
+// require runs while a script holds e.mu, so it does not lock.
+func (e *Engine) require(name, dir string) (goja.Value, error) {
+	path := e.resolveModule(name, dir)
+
+	if m, ok := e.modules[path]; ok {
+		if !m.loaded {
+			return nil, fmt.Errorf("circular require: %s", e.requireChain(path))
+		}
+		return m.exports, nil
+	}
+
+	src, err := os.ReadFile(path) //#nosec G304 -- user-provided script file
+	if err != nil {
+		return nil, fmt.Errorf("require %s: %w", name, err)
+	}
+
+	wrapped := "(function (exports, require, module, __filename, __dirname) {" + string(src) + "\n})"
+	fnValue, err := e.runtime.RunScript(path, wrapped)
+	if err != nil {
+		return nil, fmt.Errorf("require %s: %w", name, err)
+	}
+	fn, ok := goja.AssertFunction(fnValue)
+	if !ok {
+		return nil, fmt.Errorf("require %s: not a module", name)
+	}
+
+	m := &module{path: path}
+	e.modules[path] = m
+	e.moduleStack = append(e.moduleStack, m)
+	defer func() { e.moduleStack = e.moduleStack[:len(e.moduleStack)-1] }()
+
+	moduleObj := e.runtime.NewObject()
+	exportsObj := e.runtime.NewObject()
+	if err := moduleObj.Set("exports", exportsObj); err != nil {
+		return nil, err
+	}
+	dirname := filepath.Dir(path)
+	if _, err := fn(goja.Undefined(), exportsObj, e.runtime.ToValue(e.requireFunc(dirname)), moduleObj,
+		e.runtime.ToValue(path), e.runtime.ToValue(dirname)); err != nil {
+		delete(e.modules, path)
+		return nil, fmt.Errorf("require %s: %w", name, err)
+	}
+
+	m.exports = moduleObj.Get("exports")
+	m.loaded = true
+	return m.exports, nil
+}
+
+// resolveModule returns the absolute file path for a require() argument
+// made from a module in dir. A missing extension defaults to .js.
+func (e *Engine) resolveModule(name, dir string) string {
+	path := name
+	switch {
+	case filepath.IsAbs(path):
+	case dir != "":
+		path = filepath.Join(dir, path)
+	case e.resolve != nil:
+		path = e.resolve(path)
+	}
+	if filepath.Ext(path) == "" {
+		path += ".js"
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	return path
+}
+
+// requireChain describes the modules being loaded, ending with the one that
+// closes the cycle.
+func (e *Engine) requireChain(path string) string {
+	names := make([]string, 0, len(e.moduleStack)+1)
+	for _, m := range e.moduleStack {
+		names = append(names, filepath.Base(m.path))
+	}
+	names = append(names, filepath.Base(path))
+	return strings.Join(names, " -> ")
+}
@@ -0,0 +1,111 @@
+package jsengine
+
+import (
+	"crypto/rand"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/devicelab-dev/maestro-runner/pkg/core"
+	"github.com/devicelab-dev/maestro-runner/pkg/logger"
+	"github.com/dop251/goja"
+)
+
+// defaultRandomLength matches the inputRandom default length.
+const defaultRandomLength = 10
+
+// setupStdlib adds the helper functions to the maestro object:
+//
+//	maestro.randomEmail()         "aB3dE9xQ@example.com"
+//	maestro.randomNumber(n)       n random digits (default 10)
+//	maestro.randomPersonName()    "Jane Smith"
+//	maestro.now()                 milliseconds since the epoch
+//	maestro.formatDate(fmt, [ms]) "YYYY-MM-DD HH:mm:ss.SSS" tokens, local time
+//	maestro.uuid()                random (version 4) UUID
+func (e *Engine) setupStdlib(obj *goja.Object) {
+	helpers := map[string]interface{}{
+		"randomEmail":      core.RandomEmail,
+		"randomNumber":     e.randomNumberFunc(),
+		"randomPersonName": core.RandomPersonName,
+		"now":              func() int64 { return time.Now().UnixMilli() },
+		"formatDate":       e.formatDateFunc(),
+		"uuid":             newUUID,
+	}
+	for name, fn := range helpers {
+		if err := obj.Set(name, fn); err != nil {
+			logger.Warn("failed to set maestro.%s: %v", name, err)
+		}
+	}
+}
+
+func (e *Engine) randomNumberFunc() func(call goja.FunctionCall) goja.Value {
+	return func(call goja.FunctionCall) goja.Value {
+		length := defaultRandomLength
+		if arg := call.Argument(0); !goja.IsUndefined(arg) {
+			length = int(arg.ToInteger())
+		}
+		if length <= 0 {
+			panic(e.runtime.NewTypeError("maestro.randomNumber length must be positive"))
+		}
+		return e.runtime.ToValue(core.RandomNumber(length))
+	}
+}
+
+func (e *Engine) formatDateFunc() func(call goja.FunctionCall) goja.Value {
+	return func(call goja.FunctionCall) goja.Value {
+		if len(call.Arguments) < 1 {
+			panic(e.runtime.NewTypeError("maestro.formatDate requires a format"))
+		}
+		t := time.Now()
+		if arg := call.Argument(1); !goja.IsUndefined(arg) {
+			t = time.UnixMilli(arg.ToInteger())
+		}
+		return e.runtime.ToValue(formatDate(call.Argument(0).String(), t))
+	}
+}
+
+// dateTokens maps formatDate tokens to Go layouts, longest first so "YYYY"
+// wins over "YY". Go only reads fractional seconds after a dot, so SSS
+// formats ".000" and drops the dot.
+var dateTokens = []struct{ token, layout string }{
+	{"YYYY", "2006"},
+	{"SSS", ".000"},
+	{"YY", "06"},
+	{"MM", "01"},
+	{"DD", "02"},
+	{"HH", "15"},
+	{"mm", "04"},
+	{"ss", "05"},
+}
+
+// formatDate formats t with YYYY/YY/MM/DD/HH/mm/ss/SSS tokens. Everything
+// else is copied literally, unlike a Go layout where "1" or "Mon" would be
+// read as a date field.
+func formatDate(format string, t time.Time) string {
+	var sb strings.Builder
+	for i := 0; i < len(format); {
+		matched := false
+		for _, dt := range dateTokens {
+			if strings.HasPrefix(format[i:], dt.token) {
+				sb.WriteString(strings.TrimPrefix(t.Format(dt.layout), "."))
+				i += len(dt.token)
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			sb.WriteByte(format[i])
+			i++
+		}
+	}
+	return sb.String()
+}
+
+// newUUID returns a random version 4 UUID.
+func newUUID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])  // only fails without an OS entropy source
+	b[6] = b[6]&0x0f | 0x40 // version 4
+	b[8] = b[8]&0x3f | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
@@ -107,6 +107,9 @@ func GenerateAllure(reportDir string) error {
 		}
 	}
 
+	// Copy screenshots next to the result files so attachment sources resolve
+	copyAllureAttachments(reportDir, allureDir, flows)
+
 	// Write categories.json
 	if err := writeAllureCategories(allureDir); err != nil {
 		return err
@@ -229,14 +232,14 @@ func buildAllureStep(cmd Command) AllureStep {
 	if cmd.Artifacts.ScreenshotBefore != "" {
 		attachments = append(attachments, AllureAttachment{
 			Name:   "Before",
-			Source: filepath.Base(cmd.Artifacts.ScreenshotBefore),
+			Source: allureAttachmentSource(cmd.Artifacts.ScreenshotBefore),
 			Type:   "image/png",
 		})
 	}
 	if cmd.Artifacts.ScreenshotAfter != "" {
 		attachments = append(attachments, AllureAttachment{
 			Name:   "After",
-			Source: filepath.Base(cmd.Artifacts.ScreenshotAfter),
+			Source: allureAttachmentSource(cmd.Artifacts.ScreenshotAfter),
 			Type:   "image/png",
 		})
 	}
@@ -264,14 +267,14 @@ func collectAttachmentsRecursive(commands []Command, attachments *[]AllureAttach
 		if cmd.Artifacts.ScreenshotBefore != "" {
 			*attachments = append(*attachments, AllureAttachment{
 				Name:   "Screenshot",
-				Source: filepath.Base(cmd.Artifacts.ScreenshotBefore),
+				Source: allureAttachmentSource(cmd.Artifacts.ScreenshotBefore),
 				Type:   "image/png",
 			})
 		}
 		if cmd.Artifacts.ScreenshotAfter != "" {
 			*attachments = append(*attachments, AllureAttachment{
 				Name:   "Screenshot",
-				Source: filepath.Base(cmd.Artifacts.ScreenshotAfter),
+				Source: allureAttachmentSource(cmd.Artifacts.ScreenshotAfter),
 				Type:   "image/png",
 			})
 		}
@@ -281,6 +284,14 @@ func collectAttachmentsRecursive(commands []Command, attachments *[]AllureAttach
 	}
 }
 
+// allureAttachmentSource flattens a report-relative artifact path into a file
+// name for allure-results/. The flow directory is kept in the name because every
+// flow numbers its screenshots from cmd-000, so base names collide across flows.
+func allureAttachmentSource(path string) string {
+	rel := strings.TrimPrefix(filepath.ToSlash(path), "assets/")
+	return strings.ReplaceAll(rel, "/", "-")
+}
+
 // copyAllureAttachments copies screenshot files from assets subdirs into allure-results/ flat.
 func copyAllureAttachments(reportDir, allureDir string, flows []FlowDetail) {
 	for _, flow := range flows {
@@ -295,7 +306,7 @@ func copyCommandAttachments(reportDir, allureDir string, commands []Command) {
 				continue
 			}
 			src := filepath.Join(reportDir, path)
-			dst := filepath.Join(allureDir, filepath.Base(path))
+			dst := filepath.Join(allureDir, allureAttachmentSource(path))
 			copyFile(src, dst)
 		}
 		if len(cmd.SubCommands) > 0 {
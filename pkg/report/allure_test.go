@@ -443,10 +443,10 @@ func TestAllureScreenshotAttachments(t *testing.T) {
 	if len(result.Attachments) != 2 {
 		t.Fatalf("expected 2 flow attachments, got %d", len(result.Attachments))
 	}
-	if result.Attachments[0].Source != "cmd-000-before.png" {
+	if result.Attachments[0].Source != "flow-000-cmd-000-before.png" {
 		t.Errorf("attachment[0] source = %q", result.Attachments[0].Source)
 	}
-	if result.Attachments[1].Source != "cmd-000-after.png" {
+	if result.Attachments[1].Source != "flow-000-cmd-000-after.png" {
 		t.Errorf("attachment[1] source = %q", result.Attachments[1].Source)
 	}
 	if result.Attachments[0].Type != "image/png" {
@@ -468,6 +468,83 @@ func TestAllureScreenshotAttachments(t *testing.T) {
 	}
 }
 
+func TestGenerateAllureAttachmentsResolve(t *testing.T) {
+	tmpDir := t.TempDir()
+	now := time.Now()
+	endTime := now.Add(3 * time.Second)
+	d := int64(3000)
+
+	// Both flows number their screenshots from cmd-000
+	for flowID, content := range map[string]string{"flow-000": "first", "flow-001": "second"} {
+		assetsDir := filepath.Join(tmpDir, "assets", flowID)
+		if err := os.MkdirAll(assetsDir, 0o755); err != nil {
+			t.Fatalf("MkdirAll: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(assetsDir, "cmd-000-after.png"), []byte(content), 0o644); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+	}
+
+	index := &Index{
+		Version: "1.0.0", Status: StatusFailed,
+		StartTime: now, EndTime: &endTime, LastUpdated: now,
+		Device:  Device{ID: "test", Name: "Pixel 6", Platform: "android"},
+		Summary: Summary{Total: 2, Passed: 1, Failed: 1},
+		Flows: []FlowEntry{
+			{Index: 0, ID: "flow-000", Name: "Login", SourceFile: "login.yaml", DataFile: "flows/flow-000.json",
+				Status: StatusPassed, Duration: &d, StartTime: &now, EndTime: &endTime},
+			{Index: 1, ID: "flow-001", Name: "Checkout", SourceFile: "checkout.yaml", DataFile: "flows/flow-001.json",
+				Status: StatusFailed, Duration: &d, StartTime: &now, EndTime: &endTime},
+		},
+	}
+	flows := []FlowDetail{
+		{ID: "flow-000", Name: "Login", StartTime: now, Duration: &d, Commands: []Command{
+			{ID: "cmd-000", Type: "takeScreenshot", Status: StatusPassed,
+				Artifacts: CommandArtifacts{ScreenshotAfter: "assets/flow-000/cmd-000-after.png"}},
+		}},
+		{ID: "flow-001", Name: "Checkout", StartTime: now, Duration: &d, Commands: []Command{
+			{ID: "cmd-000", Type: "tapOn", Status: StatusFailed,
+				Artifacts: CommandArtifacts{ScreenshotAfter: "assets/flow-001/cmd-000-after.png"}},
+		}},
+	}
+	writeTestReport(t, tmpDir, index, flows)
+
+	if err := GenerateAllure(tmpDir); err != nil {
+		t.Fatalf("GenerateAllure: %v", err)
+	}
+
+	allureDir := filepath.Join(tmpDir, "allure-results")
+	for _, tc := range []struct{ flowID, status, content string }{
+		{"flow-000", "passed", "first"},
+		{"flow-001", "failed", "second"},
+	} {
+		data, err := os.ReadFile(filepath.Join(allureDir, tc.flowID+"-result.json"))
+		if err != nil {
+			t.Fatalf("%s: result file missing: %v", tc.flowID, err)
+		}
+		var result AllureResult
+		if err := json.Unmarshal(data, &result); err != nil {
+			t.Fatalf("json.Unmarshal: %v", err)
+		}
+		if result.Status != tc.status {
+			t.Errorf("%s: status = %q, want %q", tc.flowID, result.Status, tc.status)
+		}
+		if len(result.Steps) != 1 || len(result.Steps[0].Attachments) != 1 {
+			t.Fatalf("%s: expected one step with one attachment, got %+v", tc.flowID, result.Steps)
+		}
+
+		// The referenced file must exist in allure-results with this flow's content
+		source := result.Steps[0].Attachments[0].Source
+		copied, err := os.ReadFile(filepath.Join(allureDir, source))
+		if err != nil {
+			t.Fatalf("%s: attachment %q not copied: %v", tc.flowID, source, err)
+		}
+		if string(copied) != tc.content {
+			t.Errorf("%s: attachment %q content = %q, want %q", tc.flowID, source, copied, tc.content)
+		}
+	}
+}
+
 func TestAllureCopyAttachments(t *testing.T) {
 	tmpDir := t.TempDir()
 	reportDir := filepath.Join(tmpDir, "report")
@@ -496,7 +573,7 @@ func TestAllureCopyAttachments(t *testing.T) {
 	copyAllureAttachments(reportDir, allureDir, flows)
 
 	// Check file was copied
-	copied, err := os.ReadFile(filepath.Join(allureDir, "cmd-000-after.png"))
+	copied, err := os.ReadFile(filepath.Join(allureDir, "flow-000-cmd-000-after.png"))
 	if err != nil {
 		t.Fatalf("copied file not found: %v", err)
 	}
@@ -947,10 +1024,10 @@ func TestAllureCopyAttachmentsWithSubcommands(t *testing.T) {
 	copyAllureAttachments(reportDir, allureDir, flows)
 
 	// Both files should be copied flat
-	if _, err := os.Stat(filepath.Join(allureDir, "cmd-000-before.png")); err != nil {
+	if _, err := os.Stat(filepath.Join(allureDir, "flow-000-cmd-000-before.png")); err != nil {
 		t.Error("parent screenshot not copied")
 	}
-	if _, err := os.Stat(filepath.Join(allureDir, "sub-000-after.png")); err != nil {
+	if _, err := os.Stat(filepath.Join(allureDir, "flow-000-sub-000-after.png")); err != nil {
 		t.Error("subcommand screenshot not copied")
 	}
 }
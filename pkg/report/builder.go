@@ -94,6 +94,9 @@ func extractFlowName(f flow.Flow) string {
 	if f.Config.Name != "" {
 		return f.Config.Name
 	}
+	if f.Config.Label != "" {
+		return f.Config.Label
+	}
 	// Use filename without extension
 	base := filepath.Base(f.SourcePath)
 	ext := filepath.Ext(base)
@@ -187,6 +190,10 @@ func extractSelector(step flow.Step) *Selector {
 		sel = &s.Selector
 	case *flow.CopyTextFromStep:
 		sel = &s.Selector
+	case *flow.AssertFieldValueStep:
+		sel = &s.Element
+	case *flow.SearchStep:
+		sel = &s.Field
 	default:
 		return nil
 	}
@@ -198,6 +205,16 @@ func extractSelector(step flow.Step) *Selector {
 	return convertSelector(sel)
 }
 
+// DescribeSelector returns the step's selector as "type=value", or "" when
+// the step has none.
+func DescribeSelector(step flow.Step) string {
+	sel := extractSelector(step)
+	if sel == nil {
+		return ""
+	}
+	return sel.Type + "=" + sel.Value
+}
+
 // convertSelector converts flow.Selector to report.Selector.
 func convertSelector(sel *flow.Selector) *Selector {
 	if sel == nil {
@@ -213,6 +230,9 @@ func convertSelector(sel *flow.Selector) *Selector {
 	case sel.Text != "":
 		sType = "text"
 		sValue = sel.Text
+	case sel.TextRegex != "":
+		sType = "textRegex"
+		sValue = sel.TextRegex
 	case sel.CSS != "":
 		sType = "css"
 		sValue = sel.CSS
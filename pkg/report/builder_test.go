@@ -220,6 +220,16 @@ func TestExtractFlowName(t *testing.T) {
 			flow:     flow.Flow{Config: flow.Config{Name: "My Flow"}, SourcePath: "test.yaml"},
 			expected: "My Flow",
 		},
+		{
+			name:     "uses config label when name is empty",
+			flow:     flow.Flow{Config: flow.Config{Label: "Checkout"}, SourcePath: "test.yaml"},
+			expected: "Checkout",
+		},
+		{
+			name:     "name takes precedence over label",
+			flow:     flow.Flow{Config: flow.Config{Name: "My Flow", Label: "Checkout"}, SourcePath: "test.yaml"},
+			expected: "My Flow",
+		},
 		{
 			name:     "uses filename without extension",
 			flow:     flow.Flow{SourcePath: "flows/login.yaml"},
@@ -734,6 +744,11 @@ func TestConvertSelector(t *testing.T) {
 			selector: &flow.Selector{Text: "Click me"},
 			expected: &Selector{Type: "text", Value: "Click me"},
 		},
+		{
+			name:     "textRegex selector",
+			selector: &flow.Selector{TextRegex: "Total: .+"},
+			expected: &Selector{Type: "textRegex", Value: "Total: .+"},
+		},
 		{
 			name:     "css selector",
 			selector: &flow.Selector{CSS: ".button"},
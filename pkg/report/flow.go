@@ -142,6 +142,11 @@ func (w *FlowWriter) AddVideoTimestamp(cmdIndex int, videoTimeMs int64) {
 	w.flush()
 }
 
+// AssetsDir returns the directory holding this flow's saved artifacts.
+func (w *FlowWriter) AssetsDir() string {
+	return w.assetsDir
+}
+
 // SaveScreenshot saves a screenshot and returns the relative path.
 func (w *FlowWriter) SaveScreenshot(cmdIndex int, timing string, data []byte) (string, error) {
 	filename := fmt.Sprintf("cmd-%03d-%s.png", cmdIndex, timing)
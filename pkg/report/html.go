@@ -49,6 +49,9 @@ func GenerateHTML(reportDir string, cfg HTMLConfig) error {
 	}
 
 	// Write file
+	if err := os.MkdirAll(filepath.Dir(cfg.OutputPath), 0o755); err != nil {
+		return fmt.Errorf("write html: %w", err)
+	}
 	if err := os.WriteFile(cfg.OutputPath, []byte(html), 0o644); err != nil {
 		return fmt.Errorf("write html: %w", err)
 	}
@@ -168,10 +171,19 @@ func buildHTMLData(index *Index, flows []FlowDetail, cfg HTMLConfig) HTMLData {
 		totalDurationMs = index.EndTime.Sub(index.StartTime).Milliseconds()
 	}
 
-	// Serialize index and flows to JSON for JavaScript
+	// Serialize index and flows to JSON for JavaScript. The page renders
+	// screenshots from this data, so embedded assets must be swapped in here.
+	jsonFlows := flows
+	if cfg.EmbedAssets {
+		jsonFlows = make([]FlowDetail, len(flows))
+		for i, f := range flows {
+			f.Commands = embedScreenshots(f.Commands, cfg.ReportDir)
+			jsonFlows[i] = f
+		}
+	}
 	jsonBytes, _ := json.Marshal(map[string]interface{}{
 		"index": index,
-		"flows": flows,
+		"flows": jsonFlows,
 	})
 
 	return HTMLData{
@@ -201,6 +213,25 @@ func formatDuration(ms *int64) string {
 	return fmt.Sprintf("%dm %ds", int(d.Minutes()), int(d.Seconds())%60)
 }
 
+// embedScreenshots returns a copy of commands, including sub-commands, with
+// screenshot paths replaced by base64 data URIs of the files in reportDir.
+func embedScreenshots(commands []Command, reportDir string) []Command {
+	out := make([]Command, len(commands))
+	for i, c := range commands {
+		if c.Artifacts.ScreenshotBefore != "" {
+			c.Artifacts.ScreenshotBefore = loadAsBase64(filepath.Join(reportDir, c.Artifacts.ScreenshotBefore))
+		}
+		if c.Artifacts.ScreenshotAfter != "" {
+			c.Artifacts.ScreenshotAfter = loadAsBase64(filepath.Join(reportDir, c.Artifacts.ScreenshotAfter))
+		}
+		if len(c.SubCommands) > 0 {
+			c.SubCommands = embedScreenshots(c.SubCommands, reportDir)
+		}
+		out[i] = c
+	}
+	return out
+}
+
 func loadAsBase64(path string) string {
 	data, err := os.ReadFile(path)
 	if err != nil {
@@ -552,6 +552,57 @@ func TestBuildHTMLData_WithScreenshots(t *testing.T) {
 	}
 }
 
+func TestBuildHTMLData_EmbedScreenshots(t *testing.T) {
+	tmpDir := t.TempDir()
+	assets := filepath.Join(tmpDir, "assets", "flow-000")
+	if err := os.MkdirAll(assets, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	png := []byte{0x89, 0x50, 0x4E, 0x47}
+	for _, name := range []string{"cmd-000-after.png", "cmd-001-after.png"} {
+		if err := os.WriteFile(filepath.Join(assets, name), png, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	now := time.Now()
+	index := &Index{
+		StartTime: now,
+		Summary:   Summary{Total: 1, Failed: 1},
+		Flows:     []FlowEntry{{Index: 0, ID: "flow-000", Status: StatusFailed}},
+	}
+	flows := []FlowDetail{
+		{
+			ID: "flow-000",
+			Commands: []Command{
+				{ID: "cmd-000", Type: "tapOn", Status: StatusPassed,
+					Artifacts: CommandArtifacts{ScreenshotAfter: "assets/flow-000/cmd-000-after.png"}},
+				{ID: "cmd-001", Type: "runFlow", Status: StatusFailed, SubCommands: []Command{
+					{ID: "cmd-001", Type: "assertVisible", Status: StatusFailed,
+						Artifacts: CommandArtifacts{ScreenshotAfter: "assets/flow-000/cmd-001-after.png"}},
+				}},
+			},
+		},
+	}
+
+	data := buildHTMLData(index, flows, HTMLConfig{EmbedAssets: true, ReportDir: tmpDir})
+
+	const uri = "data:image/png;base64,iVBORw=="
+	if got := data.Flows[0].Commands[0].ScreenshotAfter; got != uri {
+		t.Errorf("ScreenshotAfter = %q, want %q", got, uri)
+	}
+	js := string(data.JSONData)
+	if strings.Contains(js, "assets/flow-000") {
+		t.Errorf("expected no asset paths in embedded JSON, got: %s", js)
+	}
+	if strings.Count(js, uri) != 2 {
+		t.Errorf("expected both screenshots embedded in JSON, got: %s", js)
+	}
+	if flows[0].Commands[0].Artifacts.ScreenshotAfter != "assets/flow-000/cmd-000-after.png" {
+		t.Error("embedding must not modify the input flows")
+	}
+}
+
 func TestRenderHTML(t *testing.T) {
 	data := HTMLData{
 		Title:       "Render Test",
@@ -133,6 +133,134 @@ func buildTestCase(entry *FlowEntry, detail *FlowDetail, index *Index) string {
 	return b.String()
 }
 
+// GenerateStepJUnit writes a JUnit XML report of the report in reportDir to
+// outputPath with one testsuite per flow and one testcase per top-level step,
+// so CI systems can point at the step that failed.
+func GenerateStepJUnit(reportDir, outputPath string) error {
+	index, flows, err := ReadReport(reportDir)
+	if err != nil {
+		return fmt.Errorf("read report: %w", err)
+	}
+
+	if dir := filepath.Dir(outputPath); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("write junit xml: %w", err)
+		}
+	}
+	if err := os.WriteFile(outputPath, []byte(buildStepJUnitXML(index, flows)), 0o644); err != nil {
+		return fmt.Errorf("write junit xml: %w", err)
+	}
+	return nil
+}
+
+// buildStepJUnitXML builds the per-step JUnit XML string. Steps that never
+// ran (pending, skipped) are reported as skipped testcases.
+func buildStepJUnitXML(index *Index, flows []FlowDetail) string {
+	var suites strings.Builder
+	var tests, failures, skipped int
+	var totalTime float64
+
+	for i := range flows {
+		flow := &flows[i]
+		var flowTests, flowFailures, flowSkipped int
+		var cases strings.Builder
+		for j := range flow.Commands {
+			cmd := &flow.Commands[j]
+			flowTests++
+			cases.WriteString(buildStepTestCase(flow, cmd))
+			switch cmd.Status {
+			case StatusFailed:
+				flowFailures++
+			case StatusPassed:
+			default:
+				flowSkipped++
+			}
+		}
+
+		var flowTime float64
+		if flow.Duration != nil {
+			flowTime = float64(*flow.Duration) / 1000.0
+		}
+		suites.WriteString(fmt.Sprintf(
+			`  <testsuite name="%s" tests="%d" failures="%d" skipped="%d" errors="0" time="%.3f" timestamp="%s">`+"\n",
+			xmlEscape(flow.Name), flowTests, flowFailures, flowSkipped, flowTime, flow.StartTime.Format(time.RFC3339),
+		))
+		suites.WriteString("    <properties>\n")
+		suites.WriteString(fmt.Sprintf(
+			`      <property name="file" value="%s"/>`+"\n",
+			xmlEscape(filepath.Base(flow.SourceFile)),
+		))
+		suites.WriteString("    </properties>\n")
+		suites.WriteString(cases.String())
+		suites.WriteString("  </testsuite>\n")
+
+		tests += flowTests
+		failures += flowFailures
+		skipped += flowSkipped
+		totalTime += flowTime
+	}
+
+	if index.EndTime != nil {
+		totalTime = index.EndTime.Sub(index.StartTime).Seconds()
+	}
+
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	b.WriteString(fmt.Sprintf(
+		`<testsuites name="maestro-runner" tests="%d" failures="%d" skipped="%d" errors="0" time="%.3f">`+"\n",
+		tests, failures, skipped, totalTime,
+	))
+	b.WriteString(suites.String())
+	b.WriteString("</testsuites>\n")
+	return b.String()
+}
+
+// buildStepTestCase builds the <testcase> element for one flow step. A
+// failed step carries its error message; a failure inside a nested step
+// (runFlow, repeat, retry) is reported through the innermost failed command.
+func buildStepTestCase(flow *FlowDetail, cmd *Command) string {
+	var tcTime float64
+	if cmd.Duration != nil {
+		tcTime = float64(*cmd.Duration) / 1000.0
+	}
+	name := cmd.Type
+	if cmd.Label != "" {
+		name = cmd.Label
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf(
+		`    <testcase name="%s" classname="%s" time="%.3f">`+"\n",
+		xmlEscape(fmt.Sprintf("%d. %s", cmd.Index+1, name)), xmlEscape(flow.Name), tcTime,
+	))
+
+	switch cmd.Status {
+	case StatusPassed:
+	case StatusFailed:
+		failed := cmd
+		if sub := findFailedCommand(cmd.SubCommands); sub != nil {
+			failed = sub
+		}
+		message, details := "", ""
+		if failed.Error != nil {
+			message, details = failed.Error.Message, failed.Error.Details
+		} else if cmd.Error != nil {
+			message, details = cmd.Error.Message, cmd.Error.Details
+		}
+		b.WriteString(fmt.Sprintf(
+			`      <failure message="%s" type="%s">%s</failure>`+"\n",
+			xmlEscape(message),
+			xmlEscape(mapCommandTypeToFailure(failed.Type)),
+			xmlEscape(details),
+		))
+	default:
+		b.WriteString("      <skipped/>\n")
+	}
+
+	b.WriteString("    </testcase>\n")
+	return b.String()
+}
+
 // resolveDevice returns the device for a flow entry, falling back to the index-level device.
 func resolveDevice(entry *FlowEntry, index *Index) *Device {
 	if entry.Device != nil {
@@ -184,7 +312,7 @@ func findFailedCommand(commands []Command) *Command {
 // mapCommandTypeToFailure maps a Maestro command type to a JUnit failure type.
 func mapCommandTypeToFailure(cmdType string) string {
 	switch cmdType {
-	case "assertVisible", "assertNotVisible":
+	case "assertVisible", "assertNotVisible", "assertResource", "assertSorted", "assertFileExists", "assertFieldValue", "assertShareTarget", "assertAccessible", "assertNoJank", "assertThemeColor", "assertTextNotContains", "assertAlertText", "assertScreenshot":
 		return "AssertionError"
 	case "tapOn", "doubleTapOn", "longPressOn":
 		return "ElementInteractionError"
@@ -194,7 +322,7 @@ func mapCommandTypeToFailure(cmdType string) string {
 		return "AppLifecycleError"
 	case "runFlow", "runScript":
 		return "SubflowError"
-	case "scroll", "swipe", "scrollUntilVisible":
+	case "scroll", "swipe", "scrollUntilVisible", "scrollToPosition":
 		return "ScrollError"
 	default:
 		return "TestError"
@@ -634,3 +634,104 @@ func TestBuildJUnitXMLNoEndTime(t *testing.T) {
 		t.Errorf("expected time=0.000 when no end time\nGot:\n%s", xml)
 	}
 }
+
+func TestBuildStepJUnitXML(t *testing.T) {
+	d := func(ms int64) *int64 { return &ms }
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	index := &Index{StartTime: now}
+	flows := []FlowDetail{
+		{
+			Name:       "Login",
+			SourceFile: "/flows/login.yaml",
+			StartTime:  now,
+			Duration:   d(2500),
+			Commands: []Command{
+				{Index: 0, Type: "launchApp", Status: StatusPassed, Duration: d(1800)},
+				{Index: 1, Type: "assertVisible", Label: "Welcome <shown>", Status: StatusFailed, Duration: d(700),
+					Error: &Error{Message: "Element not found: text='Welcome'", Details: "timeout 5s"}},
+				{Index: 2, Type: "tapOn", Status: StatusSkipped},
+			},
+		},
+		{
+			Name:      "Signup",
+			StartTime: now,
+			Duration:  d(4200),
+			Commands: []Command{
+				{Index: 0, Type: "runFlow", Status: StatusFailed, Duration: d(4200),
+					Error: &Error{Message: "Sub-flow failed"},
+					SubCommands: []Command{
+						{Index: 0, Type: "tapOn", Status: StatusFailed, Error: &Error{Message: "Element not found: id='next'"}},
+					}},
+			},
+		},
+	}
+
+	xml := buildStepJUnitXML(index, flows)
+
+	for _, want := range []string{
+		`<testsuites name="maestro-runner" tests="4" failures="2" skipped="1" errors="0" time="6.700">`,
+		`<testsuite name="Login" tests="3" failures="1" skipped="1" errors="0" time="2.500" timestamp="2026-01-02T03:04:05Z">`,
+		`<property name="file" value="login.yaml"/>`,
+		`<testcase name="1. launchApp" classname="Login" time="1.800">`,
+		`<testcase name="2. Welcome &lt;shown&gt;" classname="Login" time="0.700">`,
+		`<failure message="Element not found: text=&apos;Welcome&apos;" type="AssertionError">timeout 5s</failure>`,
+		`<testcase name="3. tapOn" classname="Login" time="0.000">` + "\n      <skipped/>",
+		`<testsuite name="Signup" tests="1" failures="1" skipped="0"`,
+		`<failure message="Element not found: id=&apos;next&apos;" type="ElementInteractionError"></failure>`,
+	} {
+		if !strings.Contains(xml, want) {
+			t.Errorf("expected XML to contain %q, got:\n%s", want, xml)
+		}
+	}
+}
+
+func TestGenerateStepJUnit(t *testing.T) {
+	tmpDir := t.TempDir()
+	now := time.Now()
+	duration := int64(420)
+
+	index := &Index{
+		Version:   "1.0.0",
+		Status:    StatusPassed,
+		StartTime: now,
+		Summary:   Summary{Total: 1, Passed: 1},
+		Flows: []FlowEntry{
+			{ID: "flow-000", Name: "Login Test", DataFile: "flows/flow-000.json", Status: StatusPassed},
+		},
+	}
+	flow0 := FlowDetail{
+		ID:        "flow-000",
+		Name:      "Login Test",
+		StartTime: now,
+		Commands: []Command{
+			{ID: "cmd-000", Index: 0, Type: "tapOn", Status: StatusPassed, Duration: &duration},
+		},
+	}
+	if err := os.MkdirAll(filepath.Join(tmpDir, "flows"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := atomicWriteJSON(filepath.Join(tmpDir, "report.json"), index); err != nil {
+		t.Fatalf("write index: %v", err)
+	}
+	if err := atomicWriteJSON(filepath.Join(tmpDir, "flows", "flow-000.json"), flow0); err != nil {
+		t.Fatalf("write flow-000: %v", err)
+	}
+
+	outputPath := filepath.Join(tmpDir, "ci", "junit.xml")
+	if err := GenerateStepJUnit(tmpDir, outputPath); err != nil {
+		t.Fatalf("GenerateStepJUnit: %v", err)
+	}
+	content, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatalf("read junit: %v", err)
+	}
+	if !strings.Contains(string(content), `<testcase name="1. tapOn" classname="Login Test" time="0.420">`) {
+		t.Errorf("unexpected JUnit XML:\n%s", content)
+	}
+}
+
+func TestGenerateStepJUnitReadError(t *testing.T) {
+	if err := GenerateStepJUnit(t.TempDir(), filepath.Join(t.TempDir(), "junit.xml")); err == nil {
+		t.Error("expected error for missing report")
+	}
+}
@@ -0,0 +1,75 @@
+package report
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// timingCSVHeader lists the columns of the timing CSV.
+var timingCSVHeader = []string{"flow", "step_index", "step_type", "selector", "success", "duration_ms"}
+
+// GenerateTimingCSV writes a per-step timing breakdown of the report in
+// reportDir to outputPath, for teams that analyse runs in a spreadsheet.
+func GenerateTimingCSV(reportDir, outputPath string) error {
+	_, flows, err := ReadReport(reportDir)
+	if err != nil {
+		return fmt.Errorf("read report: %w", err)
+	}
+
+	data, err := buildTimingCSV(flows)
+	if err != nil {
+		return fmt.Errorf("build timing csv: %w", err)
+	}
+
+	if err := os.WriteFile(outputPath, data, 0o644); err != nil {
+		return fmt.Errorf("write timing csv: %w", err)
+	}
+	return nil
+}
+
+// buildTimingCSV renders one row per executed top-level command. Commands
+// that never ran (pending, skipped) are left out; nested commands are part of
+// their parent's duration.
+func buildTimingCSV(flows []FlowDetail) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.Write(timingCSVHeader); err != nil {
+		return nil, err
+	}
+
+	for _, flow := range flows {
+		for _, cmd := range flow.Commands {
+			if cmd.Status != StatusPassed && cmd.Status != StatusFailed {
+				continue
+			}
+			selector := ""
+			if cmd.Params != nil && cmd.Params.Selector != nil {
+				selector = cmd.Params.Selector.Type + "=" + cmd.Params.Selector.Value
+			}
+			duration := ""
+			if cmd.Duration != nil {
+				duration = strconv.FormatInt(*cmd.Duration, 10)
+			}
+			row := []string{
+				flow.Name,
+				strconv.Itoa(cmd.Index),
+				cmd.Type,
+				selector,
+				strconv.FormatBool(cmd.Status == StatusPassed),
+				duration,
+			}
+			if err := w.Write(row); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
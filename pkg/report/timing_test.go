@@ -0,0 +1,112 @@
+package report
+
+import (
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildTimingCSV(t *testing.T) {
+	d := func(ms int64) *int64 { return &ms }
+	flows := []FlowDetail{
+		{
+			Name: "Login, happy path",
+			Commands: []Command{
+				{Index: 0, Type: "launchApp", Status: StatusPassed, Duration: d(1800)},
+				{Index: 1, Type: "tapOn", Status: StatusPassed, Duration: d(350),
+					Params: &CommandParams{Selector: &Selector{Type: "id", Value: "login_btn"}}},
+				{Index: 2, Type: "assertVisible", Status: StatusFailed, Duration: d(17000),
+					Params: &CommandParams{Selector: &Selector{Type: "text", Value: "Welcome"}}},
+				{Index: 3, Type: "tapOn", Status: StatusSkipped},
+			},
+		},
+		{
+			Name: "Signup",
+			Commands: []Command{
+				{Index: 0, Type: "runFlow", Status: StatusPassed, Duration: d(4200),
+					SubCommands: []Command{{Index: 0, Type: "tapOn", Status: StatusPassed, Duration: d(300)}}},
+				{Index: 1, Type: "inputText", Status: StatusPending},
+			},
+		},
+	}
+
+	data, err := buildTimingCSV(flows)
+	if err != nil {
+		t.Fatalf("buildTimingCSV: %v", err)
+	}
+	rows, err := csv.NewReader(strings.NewReader(string(data))).ReadAll()
+	if err != nil {
+		t.Fatalf("parse csv: %v", err)
+	}
+
+	want := [][]string{
+		{"flow", "step_index", "step_type", "selector", "success", "duration_ms"},
+		{"Login, happy path", "0", "launchApp", "", "true", "1800"},
+		{"Login, happy path", "1", "tapOn", "id=login_btn", "true", "350"},
+		{"Login, happy path", "2", "assertVisible", "text=Welcome", "false", "17000"},
+		{"Signup", "0", "runFlow", "", "true", "4200"},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("got %d rows, want %d:\n%s", len(rows), len(want), data)
+	}
+	for i := range want {
+		if strings.Join(rows[i], "|") != strings.Join(want[i], "|") {
+			t.Errorf("row %d = %v, want %v", i, rows[i], want[i])
+		}
+	}
+}
+
+func TestGenerateTimingCSV(t *testing.T) {
+	tmpDir := t.TempDir()
+	now := time.Now()
+	duration := int64(420)
+
+	index := &Index{
+		Version:   "1.0.0",
+		Status:    StatusPassed,
+		StartTime: now,
+		Summary:   Summary{Total: 1, Passed: 1},
+		Flows: []FlowEntry{
+			{ID: "flow-000", Name: "Login Test", DataFile: "flows/flow-000.json", Status: StatusPassed},
+		},
+	}
+	flow0 := FlowDetail{
+		ID:        "flow-000",
+		Name:      "Login Test",
+		StartTime: now,
+		Commands: []Command{
+			{ID: "cmd-000", Index: 0, Type: "tapOn", Status: StatusPassed, Duration: &duration},
+		},
+	}
+	if err := os.MkdirAll(filepath.Join(tmpDir, "flows"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := atomicWriteJSON(filepath.Join(tmpDir, "report.json"), index); err != nil {
+		t.Fatalf("write index: %v", err)
+	}
+	if err := atomicWriteJSON(filepath.Join(tmpDir, "flows", "flow-000.json"), flow0); err != nil {
+		t.Fatalf("write flow-000: %v", err)
+	}
+
+	outputPath := filepath.Join(tmpDir, "timing.csv")
+	if err := GenerateTimingCSV(tmpDir, outputPath); err != nil {
+		t.Fatalf("GenerateTimingCSV: %v", err)
+	}
+	content, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatalf("read csv: %v", err)
+	}
+	want := "flow,step_index,step_type,selector,success,duration_ms\nLogin Test,0,tapOn,,true,420\n"
+	if string(content) != want {
+		t.Errorf("CSV = %q, want %q", content, want)
+	}
+}
+
+func TestGenerateTimingCSVReadError(t *testing.T) {
+	if err := GenerateTimingCSV(t.TempDir(), filepath.Join(t.TempDir(), "timing.csv")); err == nil {
+		t.Error("expected error for missing report")
+	}
+}
@@ -216,3 +216,18 @@ func extractOSVersion(runtime string) string {
 	version := runtime[idx+4:] // skip "iOS-"
 	return strings.ReplaceAll(version, "-", ".")
 }
+
+// RecordVideoArgs builds the xcrun arguments for recording a simulator screen
+// to path. mask ("ignored", "alpha" or "black") controls how the area outside
+// the device's display shape is rendered; empty leaves simctl's default.
+func RecordVideoArgs(udid, path, mask string) ([]string, error) {
+	args := []string{"simctl", "io", udid, "recordVideo", "--codec=h264"}
+	switch mask {
+	case "":
+	case "ignored", "alpha", "black":
+		args = append(args, "--mask="+mask)
+	default:
+		return nil, fmt.Errorf("invalid recording mask %q (want ignored, alpha or black)", mask)
+	}
+	return append(args, "--force", path), nil
+}
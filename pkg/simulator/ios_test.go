@@ -2,6 +2,7 @@ package simulator
 
 import (
 	"runtime"
+	"strings"
 	"testing"
 	"time"
 )
@@ -98,6 +99,30 @@ func TestExtractOSVersion(t *testing.T) {
 	}
 }
 
+func TestRecordVideoArgs(t *testing.T) {
+	args, err := RecordVideoArgs("SIM-UDID", "/tmp/out.mp4", "black")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := strings.Join(args, " ")
+	want := "simctl io SIM-UDID recordVideo --codec=h264 --mask=black --force /tmp/out.mp4"
+	if got != want {
+		t.Errorf("args = %q, want %q", got, want)
+	}
+
+	args, err = RecordVideoArgs("SIM-UDID", "/tmp/out.mp4", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(strings.Join(args, " "), "--mask") {
+		t.Errorf("expected no --mask without a mask, got %v", args)
+	}
+
+	if _, err := RecordVideoArgs("SIM-UDID", "/tmp/out.mp4", "blur"); err == nil {
+		t.Error("expected error for invalid mask")
+	}
+}
+
 func TestManager_NewManager(t *testing.T) {
 	mgr := NewManager()
 	if mgr == nil {
@@ -190,6 +190,34 @@ func (c *Client) SetOrientation(orientation string) error {
 	return err
 }
 
+// NativeContext is the context for the native UI hierarchy.
+const NativeContext = "NATIVE_APP"
+
+// Contexts lists the available automation contexts: NATIVE_APP plus a
+// WEBVIEW_<package> entry for each debuggable WebView.
+func (c *Client) Contexts() ([]string, error) {
+	data, err := c.request("GET", c.sessionPath("/contexts"), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		Value []string `json:"value"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Value, nil
+}
+
+// SetContext switches the automation context. In a WebView context,
+// element commands operate on the DOM through chromedriver.
+func (c *Client) SetContext(name string) error {
+	req := ContextRequest{Name: name}
+	_, err := c.request("POST", c.sessionPath("/context"), req)
+	return err
+}
+
 // GetAlertText returns the current alert text.
 func (c *Client) GetAlertText() (string, error) {
 	data, err := c.request("GET", c.sessionPath("/alert/text"), nil)
@@ -393,6 +393,55 @@ func TestSetOrientation(t *testing.T) {
 	}
 }
 
+func TestContexts(t *testing.T) {
+	client, server := newTestClientWithSession(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/contexts") || r.Method != "GET" {
+			t.Errorf("expected GET /contexts, got %s %s", r.Method, r.URL.Path)
+		}
+		if err := json.NewEncoder(w).Encode(map[string]interface{}{
+			"value": []string{"NATIVE_APP", "WEBVIEW_com.example"},
+		}); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	})
+	defer server.Close()
+
+	contexts, err := client.Contexts()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(contexts) != 2 || contexts[1] != "WEBVIEW_com.example" {
+		t.Errorf("unexpected contexts: %v", contexts)
+	}
+}
+
+func TestSetContext(t *testing.T) {
+	client, server := newTestClientWithSession(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/context") || r.Method != "POST" {
+			t.Errorf("expected POST /context, got %s %s", r.Method, r.URL.Path)
+		}
+
+		var req ContextRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if req.Name != "WEBVIEW_com.example" {
+			t.Errorf("expected WEBVIEW_com.example, got %s", req.Name)
+		}
+		if err := json.NewEncoder(w).Encode(map[string]interface{}{}); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	})
+	defer server.Close()
+
+	if err := client.SetContext("WEBVIEW_com.example"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
 func TestGetAlertText(t *testing.T) {
 	client, server := newTestClientWithSession(func(w http.ResponseWriter, r *http.Request) {
 		if !strings.Contains(r.URL.Path, "/alert/text") {
@@ -1,5 +1,7 @@
 package uiautomator2
 
+import "fmt"
+
 // Click performs a tap at coordinates or on an element.
 func (c *Client) Click(x, y int) error {
 	req := ClickRequest{
@@ -116,6 +118,61 @@ func (c *Client) Drag(elementID string, endX, endY, speed int) error {
 	return err
 }
 
+// PointerPath drags one finger through points using a W3C pointer action
+// sequence. durationsMs[i] is the time taken to move from points[i] to
+// points[i+1].
+func (c *Client) PointerPath(points []PointModel, durationsMs []int) error {
+	if len(points) == 0 {
+		return nil
+	}
+	moves := []map[string]interface{}{
+		{"type": "pointerMove", "duration": 0, "x": points[0].X, "y": points[0].Y},
+		{"type": "pointerDown", "button": 0},
+	}
+	for i, p := range points[1:] {
+		duration := 0
+		if i < len(durationsMs) {
+			duration = durationsMs[i]
+		}
+		moves = append(moves, map[string]interface{}{"type": "pointerMove", "duration": duration, "x": p.X, "y": p.Y})
+	}
+	moves = append(moves, map[string]interface{}{"type": "pointerUp", "button": 0})
+
+	req := map[string]interface{}{
+		"actions": []map[string]interface{}{
+			{
+				"type":       "pointer",
+				"id":         "finger1",
+				"parameters": map[string]string{"pointerType": "touch"},
+				"actions":    moves,
+			},
+		},
+	}
+	_, err := c.request("POST", c.sessionPath("/actions"), req)
+	return err
+}
+
+// ParallelSwipe moves one finger per track from its start to its end at the
+// same time, as a W3C multi-touch action with one pointer source per finger.
+func (c *Client) ParallelSwipe(tracks [][2]PointModel, durationMs int) error {
+	sources := make([]map[string]interface{}, len(tracks))
+	for i, track := range tracks {
+		sources[i] = map[string]interface{}{
+			"type":       "pointer",
+			"id":         fmt.Sprintf("finger%d", i+1),
+			"parameters": map[string]string{"pointerType": "touch"},
+			"actions": []map[string]interface{}{
+				{"type": "pointerMove", "duration": 0, "x": track[0].X, "y": track[0].Y},
+				{"type": "pointerDown", "button": 0},
+				{"type": "pointerMove", "duration": durationMs, "x": track[1].X, "y": track[1].Y},
+				{"type": "pointerUp", "button": 0},
+			},
+		}
+	}
+	_, err := c.request("POST", c.sessionPath("/actions"), map[string]interface{}{"actions": sources})
+	return err
+}
+
 // PinchOpen performs a pinch-open (zoom in) gesture.
 func (c *Client) PinchOpen(elementID string, percent float64, speed int) error {
 	req := PinchRequest{
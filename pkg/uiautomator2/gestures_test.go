@@ -2,6 +2,7 @@ package uiautomator2
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"strings"
 	"testing"
@@ -303,6 +304,119 @@ func TestDrag(t *testing.T) {
 	}
 }
 
+func TestParallelSwipe(t *testing.T) {
+	type action struct {
+		Type     string `json:"type"`
+		Duration int    `json:"duration"`
+		X        int    `json:"x"`
+		Y        int    `json:"y"`
+	}
+	var body struct {
+		Actions []struct {
+			Type    string   `json:"type"`
+			ID      string   `json:"id"`
+			Actions []action `json:"actions"`
+		} `json:"actions"`
+	}
+	client, server := newTestClientWithSession(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := json.NewEncoder(w).Encode(map[string]interface{}{}); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+	defer server.Close()
+
+	tracks := [][2]PointModel{
+		{{X: 490, Y: 1600}, {X: 490, Y: 800}},
+		{{X: 590, Y: 1600}, {X: 590, Y: 800}},
+	}
+	if err := client.ParallelSwipe(tracks, 400); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(body.Actions) != 2 {
+		t.Fatalf("expected two pointer sources, got %+v", body.Actions)
+	}
+	for i, source := range body.Actions {
+		if source.Type != "pointer" || source.ID != fmt.Sprintf("finger%d", i+1) {
+			t.Errorf("source %d: unexpected type/id %q/%q", i, source.Type, source.ID)
+		}
+		want := []action{
+			{"pointerMove", 0, tracks[i][0].X, tracks[i][0].Y},
+			{"pointerDown", 0, 0, 0},
+			{"pointerMove", 400, tracks[i][1].X, tracks[i][1].Y},
+			{"pointerUp", 0, 0, 0},
+		}
+		if len(source.Actions) != len(want) {
+			t.Fatalf("source %d: expected %d actions, got %+v", i, len(want), source.Actions)
+		}
+		for j := range want {
+			if source.Actions[j] != want[j] {
+				t.Errorf("source %d action %d: expected %+v, got %+v", i, j, want[j], source.Actions[j])
+			}
+		}
+	}
+}
+
+func TestPointerPath(t *testing.T) {
+	var body struct {
+		Actions []struct {
+			Type    string `json:"type"`
+			Actions []struct {
+				Type     string `json:"type"`
+				Duration int    `json:"duration"`
+				X        int    `json:"x"`
+				Y        int    `json:"y"`
+			} `json:"actions"`
+		} `json:"actions"`
+	}
+	client, server := newTestClientWithSession(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/actions") {
+			t.Errorf("expected /actions suffix, got %s", r.URL.Path)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := json.NewEncoder(w).Encode(map[string]interface{}{}); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+	defer server.Close()
+
+	points := []PointModel{{X: 10, Y: 20}, {X: 30, Y: 40}, {X: 50, Y: 60}}
+	if err := client.PointerPath(points, []int{200, 300}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(body.Actions) != 1 || body.Actions[0].Type != "pointer" {
+		t.Fatalf("expected one pointer source, got %+v", body.Actions)
+	}
+	got := body.Actions[0].Actions
+	want := []struct {
+		typ      string
+		duration int
+		x, y     int
+	}{
+		{"pointerMove", 0, 10, 20},
+		{"pointerDown", 0, 0, 0},
+		{"pointerMove", 200, 30, 40},
+		{"pointerMove", 300, 50, 60},
+		{"pointerUp", 0, 0, 0},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d actions, got %d: %+v", len(want), len(got), got)
+	}
+	for i, w := range want {
+		if got[i].Type != w.typ || got[i].Duration != w.duration || got[i].X != w.x || got[i].Y != w.y {
+			t.Errorf("action %d: expected %+v, got %+v", i, w, got[i])
+		}
+	}
+}
+
 func TestPinchOpen(t *testing.T) {
 	client, server := newTestClientWithSession(func(w http.ResponseWriter, r *http.Request) {
 		if !strings.Contains(r.URL.Path, "/appium/gestures/pinch_open") {
@@ -125,6 +125,11 @@ type OrientationRequest struct {
 	Orientation string `json:"orientation"` // PORTRAIT, LANDSCAPE
 }
 
+// ContextRequest for switching automation context.
+type ContextRequest struct {
+	Name string `json:"name"` // NATIVE_APP, WEBVIEW_<package>
+}
+
 // ClipboardRequest for setting clipboard.
 type ClipboardRequest struct {
 	Content     string `json:"content"`     // base64 encoded
@@ -0,0 +1,175 @@
+package validator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/devicelab-dev/maestro-runner/pkg/flow"
+)
+
+// WithStepChecks makes the validator also check each step's required fields
+// (selectors, directions, appId, ...) so mistakes surface before a device is
+// used. ${NAME} references are expanded from env and the flow's own env where
+// possible; values that still hold variables are left to run time. appID is
+// the app ID used when neither the step nor the flow sets one.
+func (v *Validator) WithStepChecks(env map[string]string, appID string) *Validator {
+	v.checkSteps = true
+	v.env = env
+	v.appID = appID
+	return v
+}
+
+// validSwipeDirections are the directions swipe and scroll steps accept.
+var validSwipeDirections = map[string]bool{"UP": true, "DOWN": true, "LEFT": true, "RIGHT": true}
+
+// stepChecker checks the steps of one flow file.
+type stepChecker struct {
+	file    string
+	env     map[string]string
+	appID   string
+	checkID bool // check appId, which sub-flows inherit from their caller
+	result  *Result
+}
+
+// checkFlowSteps records a ValidationError for every step of f, including
+// nested and lifecycle-hook steps, that is missing a required field.
+func (v *Validator) checkFlowSteps(f *flow.Flow, filePath string, result *Result, isTestCase bool) {
+	env := make(map[string]string, len(v.env)+len(f.Config.Env))
+	for k, val := range v.env {
+		env[k] = val
+	}
+	for k, val := range f.Config.Env {
+		env[k] = val
+	}
+	appID := f.Config.AppID
+	if appID == "" {
+		appID = v.appID
+	}
+
+	c := &stepChecker{file: filePath, env: env, appID: appID, checkID: isTestCase, result: result}
+	c.check(f.Config.OnFlowStart, "onFlowStart step ")
+	c.check(f.Steps, "step ")
+	c.check(f.Config.OnFlowComplete, "onFlowComplete step ")
+}
+
+func (c *stepChecker) check(steps []flow.Step, prefix string) {
+	for i, step := range steps {
+		pos := fmt.Sprintf("%s%d", prefix, i+1)
+		if problem := c.problem(step); problem != "" {
+			c.result.Errors = append(c.result.Errors, &ValidationError{
+				File:    c.file,
+				Message: fmt.Sprintf("%s (%s): %s", pos, step.Type(), problem),
+			})
+		}
+
+		switch s := step.(type) {
+		case *flow.RepeatStep:
+			c.check(s.Steps, pos+".")
+		case *flow.RetryStep:
+			c.check(s.Steps, pos+".")
+		case *flow.RunFlowStep:
+			c.check(s.Steps, pos+".")
+		case *flow.IfStep:
+			c.check(s.Then, pos+".then.")
+			c.check(s.Else, pos+".else.")
+		}
+	}
+}
+
+// problem returns what is wrong with a single step, or "" when nothing is.
+func (c *stepChecker) problem(step flow.Step) string {
+	switch s := step.(type) {
+	case *flow.TapOnStep:
+		if s.Selector.IsEmpty() && s.Point == "" {
+			return "needs a selector or point"
+		}
+		return selectorProblem(&s.Selector)
+	case *flow.LongPressOnStep:
+		if s.Selector.IsEmpty() && s.Point == "" {
+			return "needs a selector or point"
+		}
+		return selectorProblem(&s.Selector)
+	case *flow.DoubleTapOnStep:
+		return requiredSelector(&s.Selector)
+	case *flow.AssertVisibleStep:
+		if p := requiredSelector(&s.Selector); p != "" {
+			return p
+		}
+		if err := s.ValidateCount(); err != nil {
+			return err.Error()
+		}
+	case *flow.AssertNotVisibleStep:
+		return requiredSelector(&s.Selector)
+	case *flow.CopyTextFromStep:
+		return requiredSelector(&s.Selector)
+	case *flow.ScrollUntilVisibleStep:
+		if p := requiredSelector(&s.Element); p != "" {
+			return "element " + p
+		}
+		return c.directionProblem(s.Direction)
+	case *flow.ScrollStep:
+		return c.directionProblem(s.Direction)
+	case *flow.SwipeStep:
+		if s.Direction == "" && s.Start == "" && s.End == "" && s.StartX == 0 && s.StartY == 0 && s.EndX == 0 && s.EndY == 0 {
+			return "needs a direction or start and end"
+		}
+		return c.directionProblem(s.Direction)
+	case *flow.InputTextStep:
+		if s.Text == "" {
+			return "needs text"
+		}
+	case *flow.OpenLinkStep:
+		if s.Link == "" {
+			return "needs a link"
+		}
+	case *flow.LaunchAppStep:
+		return c.appIDProblem(s.AppID)
+	case *flow.StopAppStep:
+		return c.appIDProblem(s.AppID)
+	case *flow.KillAppStep:
+		return c.appIDProblem(s.AppID)
+	case *flow.ClearStateStep:
+		return c.appIDProblem(s.AppID)
+	}
+	return ""
+}
+
+func requiredSelector(sel *flow.Selector) string {
+	if sel.IsEmpty() {
+		return "needs a selector"
+	}
+	return selectorProblem(sel)
+}
+
+func selectorProblem(sel *flow.Selector) string {
+	if err := sel.Validate(); err != nil {
+		return err.Error()
+	}
+	return ""
+}
+
+func (c *stepChecker) directionProblem(direction string) string {
+	direction = c.expand(direction)
+	if direction == "" || strings.Contains(direction, "${") {
+		return ""
+	}
+	if !validSwipeDirections[strings.ToUpper(direction)] {
+		return fmt.Sprintf("invalid direction %q (expected UP, DOWN, LEFT or RIGHT)", direction)
+	}
+	return ""
+}
+
+func (c *stepChecker) appIDProblem(appID string) string {
+	if !c.checkID || c.expand(appID) != "" || c.appID != "" {
+		return ""
+	}
+	return "no appId on the step or in the flow config"
+}
+
+// expand replaces ${NAME} references to known variables.
+func (c *stepChecker) expand(s string) string {
+	for name, val := range c.env {
+		s = strings.ReplaceAll(s, "${"+name+"}", val)
+	}
+	return s
+}
@@ -26,6 +26,9 @@ func (e *ValidationError) Error() string {
 type Result struct {
 	// TestCases is the list of top-level test case file paths.
 	TestCases []string
+	// SkippedByTags lists test case files left out of TestCases by the
+	// include/exclude tag filters, so they can be reported as skipped.
+	SkippedByTags []string
 	// Errors contains all validation errors found.
 	Errors []error
 }
@@ -39,6 +42,11 @@ func (r *Result) IsValid() bool {
 type Validator struct {
 	includeTags []string
 	excludeTags []string
+
+	// Step field checks, enabled by WithStepChecks
+	checkSteps bool
+	env        map[string]string
+	appID      string
 }
 
 // New creates a new Validator.
@@ -49,6 +57,12 @@ func New(includeTags, excludeTags []string) *Validator {
 	}
 }
 
+// Tags returns the include and exclude tag filters in effect, including
+// those merged from config.yaml files of validated directories.
+func (v *Validator) Tags() (include, exclude []string) {
+	return v.includeTags, v.excludeTags
+}
+
 // Validate validates a file or directory.
 // It parses all flows, resolves runFlow references, and returns validation results.
 func (v *Validator) Validate(path string) *Result {
@@ -277,6 +291,10 @@ func (v *Validator) validateFile(filePath string, result *Result, validated map[
 		}
 		validated[filePath] = true
 
+		if v.checkSteps {
+			v.checkFlowSteps(f, filePath, result, isTestCase)
+		}
+
 		// Recursively validate runFlow dependencies (not test cases)
 		newChain := append(chain, filePath)
 		v.validateRunFlowSteps(f.Steps, filePath, result, validated, testCasesAdded, newChain)
@@ -299,8 +317,10 @@ func (v *Validator) validateFile(filePath string, result *Result, validated map[
 		// Check tag filters
 		if flow.ShouldIncludeFlow(f, v.includeTags, v.excludeTags) {
 			result.TestCases = append(result.TestCases, filePath)
-			testCasesAdded[filePath] = true
+		} else {
+			result.SkippedByTags = append(result.SkippedByTags, filePath)
 		}
+		testCasesAdded[filePath] = true
 	}
 }
 
@@ -322,6 +342,10 @@ func (v *Validator) validateRunFlowSteps(steps []flow.Step, parentFile string, r
 		case *flow.RepeatStep:
 			v.validateRunFlowSteps(s.Steps, parentFile, result, validated, testCasesAdded, chain)
 
+		case *flow.IfStep:
+			v.validateRunFlowSteps(s.Then, parentFile, result, validated, testCasesAdded, chain)
+			v.validateRunFlowSteps(s.Else, parentFile, result, validated, testCasesAdded, chain)
+
 		case *flow.RetryStep:
 			if s.File != "" {
 				refPath := resolveFilePath(parentDir, s.File)
@@ -241,6 +241,9 @@ tags:
 	if len(result.TestCases) != 1 {
 		t.Errorf("expected 1 test case with smoke tag, got %d", len(result.TestCases))
 	}
+	if len(result.SkippedByTags) != 1 || filepath.Base(result.SkippedByTags[0]) != "regression.yaml" {
+		t.Errorf("expected regression.yaml skipped by tags, got %v", result.SkippedByTags)
+	}
 
 	// Test exclude tags
 	v = New(nil, []string{"regression"})
@@ -1218,3 +1221,79 @@ func TestValidate_GetTopLevelFlowsWithMixedContent(t *testing.T) {
 		t.Errorf("expected 3 test cases, got %d: %v", len(result.TestCases), result.TestCases)
 	}
 }
+
+func TestValidate_StepChecks(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "test.yaml")
+
+	content := `
+env:
+  DIR: LEFT
+---
+- launchApp
+- tapOn:
+    index: "first"
+- swipe:
+    direction: SIDEWAYS
+- swipe:
+    direction: ${DIR}
+- scroll:
+    direction: ${FROM_CLI}
+- repeat:
+    times: 2
+    commands:
+      - assertVisible:
+          enabled: true
+- inputText: ""
+- tapOn: "Login"
+`
+	if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without step checks only parse errors count
+	if result := New(nil, nil).Validate(file); !result.IsValid() {
+		t.Fatalf("expected valid result without step checks, got: %v", result.Errors)
+	}
+
+	result := New(nil, nil).WithStepChecks(nil, "").Validate(file)
+	want := []string{
+		"step 1 (launchApp): no appId on the step or in the flow config",
+		"step 2 (tapOn): ",
+		`step 3 (swipe): invalid direction "SIDEWAYS"`,
+		"step 6.1 (assertVisible): needs a selector",
+		"step 7 (inputText): needs text",
+	}
+	if len(result.Errors) != len(want) {
+		t.Fatalf("expected %d errors, got %d: %v", len(want), len(result.Errors), result.Errors)
+	}
+	for i, w := range want {
+		if !strings.Contains(result.Errors[i].Error(), w) {
+			t.Errorf("error %d = %q, want it to contain %q", i, result.Errors[i], w)
+		}
+	}
+
+	// A default appId satisfies launchApp
+	result = New(nil, nil).WithStepChecks(nil, "com.example.app").Validate(file)
+	if len(result.Errors) != len(want)-1 {
+		t.Errorf("expected %d errors with a default appId, got: %v", len(want)-1, result.Errors)
+	}
+}
+
+func TestValidate_StepChecksSkipSubflowAppID(t *testing.T) {
+	dir := t.TempDir()
+	main := filepath.Join(dir, "main.yaml")
+	sub := filepath.Join(dir, "sub.yaml")
+
+	if err := os.WriteFile(main, []byte("appId: com.example.app\n---\n- runFlow: sub.yaml\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(sub, []byte("- stopApp\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	result := New(nil, nil).WithStepChecks(nil, "").Validate(main)
+	if !result.IsValid() {
+		t.Errorf("expected sub-flow to inherit appId, got: %v", result.Errors)
+	}
+}